@@ -44,6 +44,17 @@ type client struct {
 	credentials Credentials
 	endpoints   endpoints
 	cookie      string
+	httpClient  *http.Client
+}
+
+// Option configures the client
+type Option func(*client)
+
+// WithHTTPClient sets the HTTP client of GraphQL requests, http.DefaultClient is used by default
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.httpClient = httpClient
+	}
 }
 
 type endpoints struct {
@@ -64,7 +75,7 @@ func New(baseURI string, creds Credentials) (Client, error) {
 	return NewWithContext(ctx, baseURI, creds)
 }
 
-func NewWithContext(ctx context.Context, baseURI string, creds Credentials) (Client, error) {
+func NewWithContext(ctx context.Context, baseURI string, creds Credentials, opts ...Option) (Client, error) {
 	ep := endpoints{
 		Sessions: baseURI + "/sessions",
 		Query:    baseURI + "/query",
@@ -72,6 +83,10 @@ func NewWithContext(ctx context.Context, baseURI string, creds Credentials) (Cli
 	c := &client{
 		endpoints:   ep,
 		credentials: creds,
+		httpClient:  http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 
 	// extract duration from context
@@ -92,7 +107,7 @@ func NewWithContext(ctx context.Context, baseURI string, creds Credentials) (Cli
 
 	c.gqlClient = graphql.NewClient(
 		c.endpoints.Query,
-		doer.NewAuthed(c.cookie),
+		doer.NewAuthedWithClient(c.cookie, c.httpClient),
 	)
 
 	return c, nil
@@ -8,9 +8,13 @@ type Authed struct {
 }
 
 func NewAuthed(cookie string) *Authed {
+	return NewAuthedWithClient(cookie, http.DefaultClient)
+}
+
+func NewAuthedWithClient(cookie string, httpClient *http.Client) *Authed {
 	return &Authed{
 		cookie:  cookie,
-		wrapped: http.DefaultClient,
+		wrapped: httpClient,
 	}
 }
 
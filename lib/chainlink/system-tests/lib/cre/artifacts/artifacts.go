@@ -0,0 +1,269 @@
+// Package artifacts stores environment reports, logs and profiles in a local directory or a bucket (S3, GCS),
+// using a stable layout: <prefix>/<run ID>/<kind>/<name>, so that analysis tooling can find artifacts of any run.
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+)
+
+type Kind string
+
+const (
+	KindReports    Kind = "reports"
+	KindLogs       Kind = "logs"
+	KindProfiles   Kind = "profiles"
+	KindState      Kind = "state"      // environment artifact and state files
+	KindDashboards Kind = "dashboards" // rendered Grafana panels
+)
+
+type StorageType string
+
+const (
+	StorageTypeLocal StorageType = "local"
+	StorageTypeS3    StorageType = "s3"
+	StorageTypeGCS   StorageType = "gcs"
+)
+
+const (
+	DefaultS3Endpoint  = "s3.amazonaws.com"
+	DefaultGCSEndpoint = "storage.googleapis.com"
+
+	// GCS is accessed with its S3-compatible XML API, which requires HMAC keys of a service account
+	GCSAccessKeyEnvVar = "GCS_HMAC_ACCESS_KEY"
+	GCSSecretKeyEnvVar = "GCS_HMAC_SECRET"
+)
+
+// Store persists artifacts under keys built with Layout.Key
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Location returns a human-readable location of the artifact (path or URL), used in logs
+	Location(key string) string
+}
+
+type Config struct {
+	Type     StorageType `toml:"type"`     // local (default), s3 or gcs
+	Dir      string      `toml:"dir"`      // directory for local storage
+	Bucket   string      `toml:"bucket"`   // bucket for s3 and gcs storage
+	Prefix   string      `toml:"prefix"`   // prepended to all keys, e.g. name of the test suite
+	Endpoint string      `toml:"endpoint"` // overrides the default endpoint, e.g. for MinIO
+	Region   string      `toml:"region"`
+	Insecure bool        `toml:"insecure"` // use plain HTTP, only for local S3-compatible servers
+	RunID    string      `toml:"run_id"`   // DefaultRunID is used if not set
+}
+
+func (c *Config) Validate() error {
+	switch c.Type {
+	case "", StorageTypeLocal:
+		if c.Dir == "" {
+			return errors.New("dir is required for local artifact storage")
+		}
+	case StorageTypeS3, StorageTypeGCS:
+		if c.Bucket == "" {
+			return fmt.Errorf("bucket is required for %s artifact storage", c.Type)
+		}
+	default:
+		return fmt.Errorf("unsupported artifact storage type %q, supported types: local, s3, gcs", c.Type)
+	}
+
+	return nil
+}
+
+// Layout builds keys of artifacts of a single run
+type Layout struct {
+	Prefix string
+	RunID  string
+}
+
+func (l Layout) Key(kind Kind, name string) string {
+	return path.Join(l.Prefix, l.RunID, string(kind), filepath.ToSlash(name))
+}
+
+// DefaultRunID identifies the run with GitHub Actions run ID and attempt, if available, and with the current time otherwise
+func DefaultRunID() string {
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		attempt := os.Getenv("GITHUB_RUN_ATTEMPT")
+		if attempt == "" {
+			attempt = "1"
+		}
+		return runID + "-" + attempt
+	}
+
+	return time.Now().UTC().Format("20060102T150405Z")
+}
+
+// New returns the store described by the config and the layout of keys for this run
+func New(config *Config) (Store, Layout, error) {
+	if config == nil {
+		return nil, Layout{}, errors.New("artifact storage config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, Layout{}, err
+	}
+
+	layout := Layout{Prefix: config.Prefix, RunID: config.RunID}
+	if layout.RunID == "" {
+		layout.RunID = DefaultRunID()
+	}
+
+	switch config.Type {
+	case StorageTypeS3, StorageTypeGCS:
+		store, err := newBucketStore(config)
+		return store, layout, err
+	default:
+		return &LocalStore{Dir: config.Dir}, layout, nil
+	}
+}
+
+type LocalStore struct {
+	Dir string
+}
+
+func (s *LocalStore) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	target := s.Location(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for artifact %s", key)
+	}
+
+	f, createErr := os.Create(target)
+	if createErr != nil {
+		return errors.Wrapf(createErr, "failed to create artifact %s", target)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return errors.Wrapf(err, "failed to write artifact %s", target)
+	}
+
+	return nil
+}
+
+func (s *LocalStore) Location(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(key))
+}
+
+// BucketStore stores artifacts in an S3-compatible bucket, which covers S3, GCS (with HMAC keys) and MinIO
+type BucketStore struct {
+	client   *minio.Client
+	bucket   string
+	location string // URL prefix used in logs
+}
+
+func newBucketStore(config *Config) (*BucketStore, error) {
+	var endpoint, scheme string
+	var creds *credentials.Credentials
+
+	switch config.Type {
+	case StorageTypeGCS:
+		endpoint, scheme = DefaultGCSEndpoint, "gs"
+		accessKey, secretKey := os.Getenv(GCSAccessKeyEnvVar), os.Getenv(GCSSecretKeyEnvVar)
+		if accessKey == "" || secretKey == "" {
+			return nil, fmt.Errorf("%s and %s must be set to store artifacts in GCS", GCSAccessKeyEnvVar, GCSSecretKeyEnvVar)
+		}
+		creds = credentials.NewStaticV4(accessKey, secretKey, "")
+	default:
+		endpoint, scheme = DefaultS3Endpoint, "s3"
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{Client: &http.Client{Transport: http.DefaultTransport}},
+		})
+	}
+	if config.Endpoint != "" {
+		endpoint = config.Endpoint
+	}
+
+	region := config.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+
+	client, clientErr := minio.New(endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: !config.Insecure,
+		Region: region,
+	})
+	if clientErr != nil {
+		return nil, errors.Wrapf(clientErr, "failed to create %s client", config.Type)
+	}
+
+	return &BucketStore{client: client, bucket: config.Bucket, location: fmt.Sprintf("%s://%s", scheme, config.Bucket)}, nil
+}
+
+func (s *BucketStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if _, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType}); err != nil {
+		return errors.Wrapf(err, "failed to upload artifact %s", s.Location(key))
+	}
+
+	return nil
+}
+
+func (s *BucketStore) Location(key string) string {
+	return s.location + "/" + key
+}
+
+// UploadFile stores the file under the given kind, named after the file
+func UploadFile(ctx context.Context, store Store, layout Layout, kind Kind, filePath string) (string, error) {
+	key := layout.Key(kind, filepath.Base(filePath))
+	if err := upload(ctx, store, key, filePath); err != nil {
+		return "", err
+	}
+
+	return store.Location(key), nil
+}
+
+// UploadDir stores all files from the directory under the given kind, keeping their paths prefixed with the directory name
+func UploadDir(ctx context.Context, store Store, layout Layout, kind Kind, dir string) (int, error) {
+	uploaded := 0
+	walkErr := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		relativePath, relErr := filepath.Rel(dir, filePath)
+		if relErr != nil {
+			return relErr
+		}
+		if uploadErr := upload(ctx, store, layout.Key(kind, path.Join(filepath.Base(dir), filepath.ToSlash(relativePath))), filePath); uploadErr != nil {
+			return uploadErr
+		}
+		uploaded++
+		return nil
+	})
+	if walkErr != nil {
+		return uploaded, errors.Wrapf(walkErr, "failed to upload artifacts from %s", dir)
+	}
+
+	return uploaded, nil
+}
+
+func upload(ctx context.Context, store Store, key, filePath string) error {
+	f, openErr := os.Open(filePath)
+	if openErr != nil {
+		return errors.Wrapf(openErr, "failed to open artifact %s", filePath)
+	}
+	defer f.Close()
+
+	stat, statErr := f.Stat()
+	if statErr != nil {
+		return errors.Wrapf(statErr, "failed to stat artifact %s", filePath)
+	}
+
+	return store.Put(ctx, strings.TrimPrefix(key, "/"), f, stat.Size())
+}
@@ -0,0 +1,42 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutKey(t *testing.T) {
+	layout := Layout{Prefix: "cre-nightly", RunID: "123-1"}
+	assert.Equal(t, "cre-nightly/123-1/logs/node-0.log", layout.Key(KindLogs, "node-0.log"))
+	assert.Equal(t, "123-1/reports/report.json", Layout{RunID: "123-1"}.Key(KindReports, "report.json"))
+}
+
+func TestLocalStoreUploadDir(t *testing.T) {
+	srcDir := filepath.Join(t.TempDir(), "logs")
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "host-processes"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "node-0.log"), []byte("node"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "host-processes", "mock.log"), []byte("mock"), 0o600))
+
+	targetDir := t.TempDir()
+	store, layout, err := New(&Config{Dir: targetDir, RunID: "run"})
+	require.NoError(t, err)
+
+	count, err := UploadDir(t.Context(), store, layout, KindLogs, srcDir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	content, err := os.ReadFile(filepath.Join(targetDir, "run", "logs", "logs", "host-processes", "mock.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "mock", string(content))
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.Error(t, (&Config{}).Validate())
+	require.Error(t, (&Config{Type: StorageTypeS3}).Validate())
+	require.Error(t, (&Config{Type: "azure", Bucket: "b"}).Validate())
+	require.NoError(t, (&Config{Type: StorageTypeGCS, Bucket: "b"}).Validate())
+}
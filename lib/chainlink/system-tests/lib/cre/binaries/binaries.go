@@ -0,0 +1,498 @@
+// Package binaries resolves capability binaries referenced by remote URLs (https://, s3://, oci://) to local files,
+// so that they can be copied to nodes the same way as binaries built locally. Downloads are cached by reference and
+// its version (ETag of https and s3 objects, digest of OCI artifacts), so that mutable references (e.g. OCI tags) are
+// fetched again once they change. Binaries can also be built from Go source for the platform of node containers,
+// see Source and Builder.
+package binaries
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+)
+
+type Scheme string
+
+const (
+	SchemeLocal Scheme = ""
+	SchemeHTTPS Scheme = "https"
+	SchemeS3    Scheme = "s3"
+	SchemeOCI   Scheme = "oci"
+
+	// S3EndpointEnvVar overrides the S3 endpoint, e.g. to fetch binaries from MinIO
+	S3EndpointEnvVar  = "CRE_BINARIES_S3_ENDPOINT"
+	DefaultS3Endpoint = "s3.amazonaws.com"
+)
+
+// Ref is a parsed reference to a binary. The local file name of a remote binary is the last segment of its path
+// (or of the OCI repository), it can be overridden with a fragment, e.g. oci://ghcr.io/org/capabilities:v1.0.0#cron
+type Ref struct {
+	Raw      string
+	Scheme   Scheme
+	Location string // URL without the fragment, or the local path
+	Name     string // file name of the binary
+}
+
+// IsRemote returns true, if the reference is a URL with a supported scheme
+func IsRemote(ref string) bool {
+	scheme, _, ok := strings.Cut(ref, "://")
+	if !ok {
+		return false
+	}
+	switch Scheme(scheme) {
+	case SchemeHTTPS, SchemeS3, SchemeOCI:
+		return true
+	default:
+		return false
+	}
+}
+
+func ParseRef(ref string) (Ref, error) {
+	if ref == "" {
+		return Ref{}, errors.New("binary reference is empty")
+	}
+	if !strings.Contains(ref, "://") {
+		return Ref{Raw: ref, Scheme: SchemeLocal, Location: ref, Name: filepath.Base(ref)}, nil
+	}
+	if !IsRemote(ref) {
+		return Ref{}, fmt.Errorf("unsupported scheme of binary reference '%s', use a local path or one of %s://, %s://, %s://", ref, SchemeHTTPS, SchemeS3, SchemeOCI)
+	}
+
+	location, name, _ := strings.Cut(ref, "#")
+	parsed := Ref{Raw: ref, Location: location, Name: name}
+	scheme, rest, _ := strings.Cut(location, "://")
+	parsed.Scheme = Scheme(scheme)
+
+	switch parsed.Scheme {
+	case SchemeHTTPS, SchemeS3:
+		u, err := url.Parse(location)
+		if err != nil {
+			return Ref{}, errors.Wrapf(err, "failed to parse binary reference '%s'", ref)
+		}
+		if u.Host == "" || strings.Trim(u.Path, "/") == "" {
+			return Ref{}, fmt.Errorf("binary reference '%s' must include a host (or bucket) and a path", ref)
+		}
+		if parsed.Name == "" {
+			parsed.Name = path.Base(u.Path)
+		}
+	case SchemeOCI:
+		repository := rest
+		if at := strings.Index(repository, "@"); at != -1 {
+			repository = repository[:at]
+		}
+		if colon := strings.LastIndex(repository, ":"); colon > strings.LastIndex(repository, "/") {
+			repository = repository[:colon]
+		}
+		if !strings.Contains(repository, "/") {
+			return Ref{}, fmt.Errorf("binary reference '%s' must include a registry and a repository", ref)
+		}
+		if parsed.Name == "" {
+			parsed.Name = path.Base(repository)
+		}
+	}
+	if parsed.Name == "" || strings.ContainsAny(parsed.Name, `/\`) || parsed.Name == "." || parsed.Name == ".." {
+		return Ref{}, fmt.Errorf("invalid binary name '%s' in reference '%s'", parsed.Name, ref)
+	}
+
+	return parsed, nil
+}
+
+// Name returns the file name of the binary, job specs reference binaries by it. Invalid references return their base.
+func Name(ref string) string {
+	parsed, err := ParseRef(ref)
+	if err != nil {
+		return filepath.Base(ref)
+	}
+
+	return parsed.Name
+}
+
+// SignatureRef returns the reference of the detached signature of the binary: <binary path>.sig for local binaries,
+// the binary's URL with .sig appended to its path for https and s3 and the <binary name>.sig file of the same artifact
+// for OCI. Signatures of remote binaries are named <binary name>.sig, so that they can be downloaded next to them.
+func SignatureRef(ref Ref) string {
+	switch ref.Scheme {
+	case SchemeLocal:
+		return ref.Location + ".sig"
+	case SchemeOCI:
+		return ref.Location + "#" + ref.Name + ".sig"
+	default:
+		u, err := url.Parse(ref.Location)
+		if err != nil {
+			return ref.Location + ".sig#" + ref.Name + ".sig"
+		}
+		u.Path += ".sig"
+		return u.String() + "#" + ref.Name + ".sig"
+	}
+}
+
+// DefaultCacheDir returns the directory in the user's cache dir, where downloaded binaries are kept between runs
+func DefaultCacheDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+
+	return filepath.Join(cacheDir, "cre", "binaries")
+}
+
+type Resolver struct {
+	CacheDir   string
+	HTTPClient *http.Client
+}
+
+// NewResolver returns a resolver caching binaries in cacheDir, DefaultCacheDir() is used if it's empty
+func NewResolver(cacheDir string) *Resolver {
+	if cacheDir == "" {
+		cacheDir = DefaultCacheDir()
+	}
+
+	return &Resolver{CacheDir: cacheDir, HTTPClient: http.DefaultClient}
+}
+
+// CachePath returns the local path of the binary with given version (e.g. ETag or digest) of the reference, it's stable
+// for them, so that repeated runs reuse downloads until the remote binary changes
+func (r *Resolver) CachePath(ref Ref, version string) string {
+	sum := sha256.Sum256([]byte(ref.Location + "\x00" + version))
+	return filepath.Join(r.CacheDir, hex.EncodeToString(sum[:])[:16], ref.Name)
+}
+
+// Resolve returns the local path of the binary, downloading it, unless its current version is already cached. Binaries,
+// whose version can't be determined (e.g. servers without ETags), are downloaded every time and cached by their digest.
+// Local paths are returned as they are.
+func (r *Resolver) Resolve(ctx context.Context, reference string) (string, error) {
+	ref, err := ParseRef(reference)
+	if err != nil {
+		return "", err
+	}
+	if ref.Scheme == SchemeLocal {
+		return ref.Location, nil
+	}
+
+	version, versionErr := r.remoteVersion(ctx, ref)
+	if versionErr != nil {
+		return "", errors.Wrapf(versionErr, "failed to get version of binary %s", reference)
+	}
+	if version != "" {
+		if _, statErr := os.Stat(r.CachePath(ref, version)); statErr == nil {
+			return r.CachePath(ref, version), nil
+		}
+	}
+
+	tmpPath, fetchErr := r.fetch(ctx, ref, r.CacheDir)
+	if fetchErr != nil {
+		return "", errors.Wrapf(fetchErr, "failed to fetch binary %s", reference)
+	}
+	defer os.RemoveAll(tmpPath)
+
+	if version == "" {
+		digest, digestErr := FileDigest(tmpPath)
+		if digestErr != nil {
+			return "", errors.Wrapf(digestErr, "failed to compute digest of binary %s", reference)
+		}
+		version = "sha256:" + digest
+	}
+	localPath := r.CachePath(ref, version)
+	if mkdirErr := os.MkdirAll(filepath.Dir(localPath), 0o755); mkdirErr != nil {
+		return "", errors.Wrapf(mkdirErr, "failed to create cache directory for binary %s", reference)
+	}
+	// renaming is atomic, a concurrent download of the same version is replaced by an identical file
+	if renameErr := os.Rename(tmpPath, localPath); renameErr != nil {
+		return "", errors.Wrapf(renameErr, "failed to move binary %s to the cache", reference)
+	}
+
+	return localPath, nil
+}
+
+// Download downloads the remote file to target, replacing it atomically, e.g. the detached signature of a binary
+// next to its cached copy. Downloads aren't cached.
+func (r *Resolver) Download(ctx context.Context, reference, target string) error {
+	ref, err := ParseRef(reference)
+	if err != nil {
+		return err
+	}
+	if ref.Scheme == SchemeLocal {
+		return fmt.Errorf("%s is not a remote reference", reference)
+	}
+
+	tmpPath, fetchErr := r.fetch(ctx, ref, filepath.Dir(target))
+	if fetchErr != nil {
+		return errors.Wrapf(fetchErr, "failed to fetch %s", reference)
+	}
+	defer os.RemoveAll(tmpPath)
+	if renameErr := os.Rename(tmpPath, target); renameErr != nil {
+		return errors.Wrapf(renameErr, "failed to move %s to %s", reference, target)
+	}
+
+	return nil
+}
+
+// fetch downloads the remote file to a unique temporary file in dir and returns its path. Downloading to a temporary
+// file first means that interrupted downloads are never taken from the cache and concurrent downloads of the same
+// file don't write to the same file.
+func (r *Resolver) fetch(ctx context.Context, ref Ref, dir string) (string, error) {
+	if mkdirErr := os.MkdirAll(dir, 0o755); mkdirErr != nil {
+		return "", errors.Wrapf(mkdirErr, "failed to create directory %s", dir)
+	}
+	tmpFile, tmpErr := os.CreateTemp(dir, ref.Name+".*.download")
+	if tmpErr != nil {
+		return "", errors.Wrap(tmpErr, "failed to create temporary file")
+	}
+	tmpPath := tmpFile.Name()
+	_ = tmpFile.Close()
+
+	var fetchErr error
+	switch ref.Scheme {
+	case SchemeHTTPS:
+		fetchErr = r.fetchHTTPS(ctx, ref, tmpPath)
+	case SchemeS3:
+		fetchErr = fetchS3(ctx, ref, tmpPath)
+	case SchemeOCI:
+		fetchErr = fetchOCI(ctx, ref, tmpPath)
+	default:
+		fetchErr = fmt.Errorf("unsupported scheme '%s'", ref.Scheme)
+	}
+	if fetchErr != nil {
+		_ = os.RemoveAll(tmpPath)
+		return "", fetchErr
+	}
+
+	return tmpPath, nil
+}
+
+// remoteVersion returns the version of the remote binary without downloading it: the ETag (or the modification time)
+// of https and s3 objects and the manifest digest of OCI artifacts. It's empty, if the server doesn't provide any.
+func (r *Resolver) remoteVersion(ctx context.Context, ref Ref) (string, error) {
+	switch ref.Scheme {
+	case SchemeHTTPS:
+		return r.httpsVersion(ctx, ref), nil
+	case SchemeS3:
+		return s3Version(ctx, ref)
+	case SchemeOCI:
+		return ociVersion(ctx, ref)
+	default:
+		return "", nil
+	}
+}
+
+// httpsVersion returns an empty version, if the HEAD request fails, e.g. because the server doesn't support it, the
+// download reports the actual problem
+func (r *Resolver) httpsVersion(ctx context.Context, ref Ref) string {
+	request, requestErr := http.NewRequestWithContext(ctx, http.MethodHead, ref.Location, nil)
+	if requestErr != nil {
+		return ""
+	}
+	response, responseErr := r.httpClient().Do(request)
+	if responseErr != nil {
+		return ""
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return ""
+	}
+	if etag := response.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return "etag:" + etag
+	}
+	if lastModified := response.Header.Get("Last-Modified"); lastModified != "" {
+		return "last-modified:" + lastModified
+	}
+
+	return ""
+}
+
+func (r *Resolver) httpClient() *http.Client {
+	if r.HTTPClient == nil {
+		return http.DefaultClient
+	}
+
+	return r.HTTPClient
+}
+
+func (r *Resolver) fetchHTTPS(ctx context.Context, ref Ref, target string) error {
+	request, requestErr := http.NewRequestWithContext(ctx, http.MethodGet, ref.Location, nil)
+	if requestErr != nil {
+		return requestErr
+	}
+	response, responseErr := r.httpClient().Do(request)
+	if responseErr != nil {
+		return responseErr
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return writeFile(target, response.Body)
+}
+
+func s3Version(ctx context.Context, ref Ref) (string, error) {
+	client, bucket, key, err := newS3Client(ref)
+	if err != nil {
+		return "", err
+	}
+
+	info, statErr := client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if statErr != nil {
+		return "", statErr
+	}
+	if info.ETag == "" {
+		return "", nil
+	}
+
+	return "etag:" + info.ETag, nil
+}
+
+func fetchS3(ctx context.Context, ref Ref, target string) error {
+	client, bucket, key, err := newS3Client(ref)
+	if err != nil {
+		return err
+	}
+
+	object, objectErr := client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if objectErr != nil {
+		return objectErr
+	}
+	defer object.Close()
+
+	return writeFile(target, object)
+}
+
+// newS3Client returns a client for the endpoint of the reference, its bucket and object key
+func newS3Client(ref Ref) (client *minio.Client, bucket, key string, err error) {
+	u, parseErr := url.Parse(ref.Location)
+	if parseErr != nil {
+		return nil, "", "", parseErr
+	}
+
+	endpoint := DefaultS3Endpoint
+	if override := os.Getenv(S3EndpointEnvVar); override != "" {
+		endpoint = override
+	}
+	client, clientErr := minio.New(endpoint, &minio.Options{
+		Creds: credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{Client: &http.Client{Transport: http.DefaultTransport}},
+		}),
+		Secure: true,
+		Region: os.Getenv("AWS_REGION"),
+	})
+	if clientErr != nil {
+		return nil, "", "", errors.Wrap(clientErr, "failed to create S3 client")
+	}
+
+	return client, u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// ociVersion returns the digest of the artifact, references pinned by a digest aren't resolved with the registry
+func ociVersion(ctx context.Context, ref Ref) (string, error) {
+	reference := strings.TrimPrefix(ref.Location, string(SchemeOCI)+"://")
+	if _, digest, pinned := strings.Cut(reference, "@"); pinned {
+		return digest, nil
+	}
+	if _, lookErr := exec.LookPath("oras"); lookErr != nil {
+		return "", errors.New("oras CLI is required to fetch binaries from OCI registries, see https://oras.land/docs/installation")
+	}
+
+	buffer := bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "oras", "resolve", reference) // #nosec G204 -- the reference comes from the test's configuration
+	cmd.Stdout = &buffer
+	cmd.Stderr = &buffer
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "oras resolve failed: %s", strings.TrimSpace(buffer.String()))
+	}
+
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// fetchOCI pulls the artifact with the oras CLI, which uses credentials of `oras login` or `docker login`.
+// Artifacts with more files must reference the binary with a fragment.
+func fetchOCI(ctx context.Context, ref Ref, target string) error {
+	if _, lookErr := exec.LookPath("oras"); lookErr != nil {
+		return errors.New("oras CLI is required to fetch binaries from OCI registries, see https://oras.land/docs/installation")
+	}
+
+	pullDir := target + ".oci"
+	defer os.RemoveAll(pullDir)
+
+	buffer := bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "oras", "pull", strings.TrimPrefix(ref.Location, string(SchemeOCI)+"://"), "--output", pullDir) // #nosec G204 -- the reference comes from the test's configuration
+	cmd.Stdout = &buffer
+	cmd.Stderr = &buffer
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "oras pull failed: %s", strings.TrimSpace(buffer.String()))
+	}
+
+	var files []string
+	walkErr := filepath.WalkDir(pullDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+
+	pulled := ""
+	for _, file := range files {
+		if filepath.Base(file) == ref.Name {
+			pulled = file
+			break
+		}
+	}
+	if pulled == "" && len(files) == 1 && !strings.Contains(ref.Raw, "#") {
+		pulled = files[0]
+	}
+	if pulled == "" {
+		return fmt.Errorf("artifact contains %d files, but none is named '%s', reference the binary with #<file name>", len(files), ref.Name)
+	}
+
+	return os.Rename(pulled, target)
+}
+
+// FileDigest returns the SHA-256 checksum of the file, hex-encoded
+func FileDigest(path string) (string, error) {
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return "", openErr
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeFile(target string, r io.Reader) error {
+	file, createErr := os.Create(target)
+	if createErr != nil {
+		return createErr
+	}
+	if _, copyErr := io.Copy(file, r); copyErr != nil {
+		_ = file.Close()
+		return copyErr
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,226 @@
+package binaries
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := map[string]Ref{
+		"./binaries/cron":                                       {Scheme: SchemeLocal, Location: "./binaries/cron", Name: "cron"},
+		"https://example.com/releases/v1/cron":                  {Scheme: SchemeHTTPS, Location: "https://example.com/releases/v1/cron", Name: "cron"},
+		"https://example.com/download?id=1#cron":                {Scheme: SchemeHTTPS, Location: "https://example.com/download?id=1", Name: "cron"},
+		"s3://bucket/capabilities/v1/readcontract":              {Scheme: SchemeS3, Location: "s3://bucket/capabilities/v1/readcontract", Name: "readcontract"},
+		"oci://ghcr.io/org/cron:v1.0.0":                         {Scheme: SchemeOCI, Location: "oci://ghcr.io/org/cron:v1.0.0", Name: "cron"},
+		"oci://localhost:5000/org/capabilities@sha256:abc#cron": {Scheme: SchemeOCI, Location: "oci://localhost:5000/org/capabilities@sha256:abc", Name: "cron"},
+	}
+	for raw, expected := range tests {
+		parsed, err := ParseRef(raw)
+		require.NoError(t, err, raw)
+		expected.Raw = raw
+		require.Equal(t, expected, parsed, raw)
+	}
+
+	for _, invalid := range []string{"", "ftp://example.com/cron", "https://example.com", "oci://cron:v1", "s3://bucket/cron#../cron"} {
+		_, err := ParseRef(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestSignatureRef(t *testing.T) {
+	tests := map[string]string{
+		"./binaries/cron":                        "./binaries/cron.sig",
+		"https://example.com/releases/v1/cron":   "https://example.com/releases/v1/cron.sig#cron.sig",
+		"https://example.com/download?id=1#cron": "https://example.com/download.sig?id=1#cron.sig",
+		"s3://bucket/capabilities/v1/cron":       "s3://bucket/capabilities/v1/cron.sig#cron.sig",
+		"oci://ghcr.io/org/capabilities:v1#cron": "oci://ghcr.io/org/capabilities:v1#cron.sig",
+	}
+	for raw, expected := range tests {
+		ref, err := ParseRef(raw)
+		require.NoError(t, err, raw)
+		signatureRef := SignatureRef(ref)
+		require.Equal(t, expected, signatureRef, raw)
+
+		parsed, err := ParseRef(signatureRef)
+		require.NoError(t, err, raw)
+		require.Equal(t, ref.Name+".sig", parsed.Name, raw)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	var content atomic.Value
+	content.Store("signature v1")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/cron.sig" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content.Load().(string)))
+	}))
+	defer server.Close()
+
+	resolver := NewResolver(t.TempDir())
+	resolver.HTTPClient = server.Client()
+	dir := t.TempDir()
+	target := filepath.Join(dir, "cron.sig")
+
+	require.NoError(t, resolver.Download(context.Background(), server.URL+"/v1/cron.sig", target))
+	downloaded, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "signature v1", string(downloaded))
+
+	// downloads aren't cached, the target is replaced
+	content.Store("signature v2")
+	require.NoError(t, resolver.Download(context.Background(), server.URL+"/v1/cron.sig", target))
+	downloaded, err = os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "signature v2", string(downloaded))
+
+	require.Error(t, resolver.Download(context.Background(), server.URL+"/v1/missing.sig", filepath.Join(dir, "missing.sig")))
+	require.Equal(t, []string{"cron.sig"}, cachedFiles(t, dir), "failed download was left next to the target")
+	require.ErrorContains(t, resolver.Download(context.Background(), "./cron.sig", target), "is not a remote reference")
+}
+
+func TestResolveHTTPS(t *testing.T) {
+	var downloads atomic.Int32
+	var content atomic.Value
+	content.Store("binary v1")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/cron" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"`+content.Load().(string)+`"`)
+		if r.Method == http.MethodGet {
+			downloads.Add(1)
+			_, _ = w.Write([]byte(content.Load().(string)))
+		}
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	resolver := NewResolver(cacheDir)
+	resolver.HTTPClient = server.Client()
+
+	localPath, err := resolver.Resolve(context.Background(), server.URL+"/v1/cron")
+	require.NoError(t, err)
+	require.Equal(t, "cron", filepath.Base(localPath))
+	downloaded, err := os.ReadFile(localPath)
+	require.NoError(t, err)
+	require.Equal(t, "binary v1", string(downloaded))
+
+	// cached binaries are not downloaded again, while their ETag doesn't change
+	cachedPath, err := resolver.Resolve(context.Background(), server.URL+"/v1/cron")
+	require.NoError(t, err)
+	require.Equal(t, localPath, cachedPath)
+	require.Equal(t, int32(1), downloads.Load())
+
+	// mutable references are downloaded again once they change
+	content.Store("binary v2")
+	updatedPath, err := resolver.Resolve(context.Background(), server.URL+"/v1/cron")
+	require.NoError(t, err)
+	require.NotEqual(t, localPath, updatedPath)
+	require.Equal(t, int32(2), downloads.Load())
+	downloaded, err = os.ReadFile(updatedPath)
+	require.NoError(t, err)
+	require.Equal(t, "binary v2", string(downloaded))
+
+	_, err = resolver.Resolve(context.Background(), server.URL+"/v1/missing")
+	require.Error(t, err)
+	require.Equal(t, []string{"cron", "cron"}, cachedFiles(t, cacheDir), "failed download was left in the cache")
+
+	local, err := resolver.Resolve(context.Background(), "./cron")
+	require.NoError(t, err)
+	require.Equal(t, "./cron", local)
+}
+
+func TestResolveHTTPSWithoutETag(t *testing.T) {
+	var downloads atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		downloads.Add(1)
+		_, _ = w.Write([]byte("binary"))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	resolver := NewResolver(cacheDir)
+	resolver.HTTPClient = server.Client()
+
+	// binaries without a version are downloaded every time and cached by their content, concurrent downloads of the
+	// same binary must not write to the same temporary file
+	var wg sync.WaitGroup
+	paths := make([]string, 8)
+	errs := make([]error, len(paths))
+	for i := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			paths[i], errs[i] = resolver.Resolve(context.Background(), server.URL+"/v1/cron")
+		}()
+	}
+	wg.Wait()
+
+	for i := range paths {
+		require.NoError(t, errs[i])
+		require.Equal(t, paths[0], paths[i])
+	}
+	require.Equal(t, int32(len(paths)), downloads.Load())
+	downloaded, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	require.Equal(t, "binary", string(downloaded))
+	require.Equal(t, []string{"cron"}, cachedFiles(t, cacheDir), "temporary files were left in the cache")
+}
+
+// cachedFiles returns sorted names of all files in the cache directory
+func cachedFiles(t *testing.T, cacheDir string) []string {
+	t.Helper()
+
+	files := make([]string, 0)
+	require.NoError(t, filepath.WalkDir(cacheDir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, entry.Name())
+		}
+		return err
+	}))
+	slices.Sort(files)
+
+	return files
+}
+
+func TestPlatform(t *testing.T) {
+	executable, err := os.Executable()
+	require.NoError(t, err)
+
+	platform, err := Platform(executable)
+	require.NoError(t, err)
+	require.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, platform)
+	require.NoError(t, CheckPlatform(executable, platform))
+
+	otherArch := "arm64"
+	if runtime.GOARCH == otherArch {
+		otherArch = "amd64"
+	}
+	require.ErrorContains(t, CheckPlatform(executable, "linux/"+otherArch), "but target container is linux/"+otherArch)
+
+	script := filepath.Join(t.TempDir(), "capability.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho ok\n"), 0o755))
+	platform, err = Platform(script)
+	require.NoError(t, err)
+	require.Empty(t, platform)
+	require.NoError(t, CheckPlatform(script, "linux/"+otherArch))
+}
@@ -0,0 +1,227 @@
+package binaries
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Source is Go source of a capability binary, which is built for the platform of node containers instead of using a
+// prebuilt binary. Either a module with a version, or a local directory of a module is set, e.g.
+//
+//	[capability_configs.cron.source]
+//	module = "github.com/smartcontractkit/capabilities/cron"
+//	version = "v1.2.0"
+type Source struct {
+	// Module is the path of a Go module, which is downloaded with 'go mod download'
+	Module string `toml:"module"`
+	// Version of Module: a tag, branch or commit, anything 'go mod download' accepts
+	Version string `toml:"version"`
+	// Dir is the directory of a local Go module, e.g. a checkout of the capability's repository
+	Dir string `toml:"dir"`
+	// optional, the main package relative to the module root, the root is used if not set
+	Package string `toml:"package"`
+	// optional, file name of the binary, the last element of the package (or of the module) path is used if not set
+	Name string `toml:"name"`
+}
+
+func (s *Source) Validate() error {
+	switch {
+	case s.Module == "" && s.Dir == "":
+		return errors.New("source must set either module or dir")
+	case s.Module != "" && s.Dir != "":
+		return errors.New("source must set only one of module and dir")
+	case s.Module != "" && s.Version == "":
+		return fmt.Errorf("source module %s must have a version", s.Module)
+	case s.Dir != "" && s.Version != "":
+		return errors.New("version can be set only for source modules, local directories are built as they are")
+	}
+	if path.IsAbs(s.Package) || strings.HasPrefix(path.Clean(s.Package), "..") {
+		return fmt.Errorf("source package '%s' must be relative to the module root", s.Package)
+	}
+	if strings.ContainsAny(s.Name, `/\`) || s.Name == "." || s.Name == ".." {
+		return fmt.Errorf("invalid binary name '%s' of source", s.Name)
+	}
+
+	return nil
+}
+
+// BinaryName returns the file name of the built binary, job specs reference binaries by it
+func (s *Source) BinaryName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if pkg := path.Clean(s.Package); s.Package != "" && pkg != "." {
+		return path.Base(pkg)
+	}
+	if s.Module != "" {
+		return path.Base(s.Module)
+	}
+	if abs, err := filepath.Abs(s.Dir); err == nil {
+		return filepath.Base(abs)
+	}
+
+	return filepath.Base(s.Dir)
+}
+
+type Builder struct {
+	CacheDir string
+}
+
+// NewBuilder returns a builder caching binaries in cacheDir, DefaultCacheDir() is used if it's empty
+func NewBuilder(cacheDir string) *Builder {
+	if cacheDir == "" {
+		cacheDir = DefaultCacheDir()
+	}
+
+	return &Builder{CacheDir: cacheDir}
+}
+
+// Build cross-compiles the source for the platform (e.g. linux/arm64) with CGO disabled and returns the path of the
+// binary. Binaries are cached by the commit they are built from: the commit of the module version, or HEAD of
+// the local directory. Local directories with uncommitted changes, or outside of git repositories, are rebuilt every
+// time, Go's build cache keeps that fast.
+func (b *Builder) Build(ctx context.Context, source Source, platform string) (string, error) {
+	if err := source.Validate(); err != nil {
+		return "", err
+	}
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("platform '%s' must have the os/arch format, e.g. linux/amd64", platform)
+	}
+	goos, goarch := parts[0], parts[1]
+
+	if mkdirErr := os.MkdirAll(b.CacheDir, 0o755); mkdirErr != nil {
+		return "", errors.Wrap(mkdirErr, "failed to create cache directory for built binaries")
+	}
+
+	var moduleDir, origin, commit string
+	clean := true
+	if source.Module != "" {
+		downloaded, downloadErr := downloadModule(ctx, b.CacheDir, source.Module, source.Version)
+		if downloadErr != nil {
+			return "", downloadErr
+		}
+		moduleDir, origin, commit = downloaded.Dir, source.Module, downloaded.Version
+		if downloaded.Origin != nil && downloaded.Origin.Hash != "" {
+			commit = downloaded.Origin.Hash
+		}
+	} else {
+		abs, absErr := filepath.Abs(source.Dir)
+		if absErr != nil {
+			return "", errors.Wrapf(absErr, "failed to get absolute path of source directory %s", source.Dir)
+		}
+		moduleDir, origin = abs, abs
+		commit, clean = gitCommit(ctx, abs)
+	}
+
+	pkg := path.Clean(source.Package)
+	if pkg != "." {
+		pkg = "./" + pkg
+	}
+	key := sha256.Sum256([]byte(origin + "\n" + pkg + "\n" + commit))
+	keyDir := hex.EncodeToString(key[:])[:16]
+	if !clean {
+		// kept apart from clean builds of the same commit, so that these are never replaced by uncommitted changes
+		keyDir += "-dirty"
+	}
+	target := filepath.Join(b.CacheDir, "build", keyDir, goos+"-"+goarch, source.BinaryName())
+	if clean {
+		if _, statErr := os.Stat(target); statErr == nil {
+			return target, nil
+		}
+	}
+	if mkdirErr := os.MkdirAll(filepath.Dir(target), 0o755); mkdirErr != nil {
+		return "", errors.Wrapf(mkdirErr, "failed to create cache directory for binary %s", source.BinaryName())
+	}
+
+	// built to a temporary file first, so that failed builds are never taken from the cache. Its name is unique, because
+	// concurrent builds of the same binary, e.g. by parallel test runs, would overwrite each other's output.
+	tmpFile, tmpErr := os.CreateTemp(filepath.Dir(target), source.BinaryName()+".*.build")
+	if tmpErr != nil {
+		return "", errors.Wrapf(tmpErr, "failed to create temporary file for binary %s", source.BinaryName())
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	if closeErr := tmpFile.Close(); closeErr != nil {
+		return "", errors.Wrapf(closeErr, "failed to create temporary file for binary %s", source.BinaryName())
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-trimpath", "-o", tmpPath, pkg) // #nosec G204 -- the package comes from the test's configuration
+	cmd.Dir = moduleDir
+	cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0")
+	if source.Module != "" {
+		// downloaded modules are built on their own, not as part of the workspace of the current directory
+		cmd.Env = append(cmd.Env, "GOWORK=off")
+	}
+	if output, buildErr := cmd.CombinedOutput(); buildErr != nil {
+		return "", errors.Wrapf(buildErr, "failed to build %s for %s: %s", origin, platform, strings.TrimSpace(string(output)))
+	}
+	if renameErr := os.Rename(tmpPath, target); renameErr != nil {
+		return "", errors.Wrapf(renameErr, "failed to move binary %s to the cache", source.BinaryName())
+	}
+
+	return target, nil
+}
+
+type downloadedModule struct {
+	Version string
+	Dir     string
+	Error   string
+	Origin  *struct {
+		Hash string
+	}
+}
+
+// downloadModule downloads the module to the module cache with 'go mod download', which resolves branches and commits
+// to (pseudo-)versions
+func downloadModule(ctx context.Context, workDir, module, version string) (*downloadedModule, error) {
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "go", "mod", "download", "-json", module+"@"+version) // #nosec G204 -- the module comes from the test's configuration
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "GOWORK=off")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	downloaded := &downloadedModule{}
+	if decodeErr := json.Unmarshal(stdout.Bytes(), downloaded); decodeErr != nil {
+		if runErr != nil {
+			return nil, errors.Wrapf(runErr, "failed to download module %s@%s: %s", module, version, strings.TrimSpace(stderr.String()))
+		}
+		return nil, errors.Wrapf(decodeErr, "failed to parse output of downloading module %s@%s", module, version)
+	}
+	if downloaded.Error != "" {
+		return nil, fmt.Errorf("failed to download module %s@%s: %s", module, version, downloaded.Error)
+	}
+	if runErr != nil {
+		return nil, errors.Wrapf(runErr, "failed to download module %s@%s: %s", module, version, strings.TrimSpace(stderr.String()))
+	}
+
+	return downloaded, nil
+}
+
+// gitCommit returns HEAD of the repository of dir, and whether the directory has no uncommitted changes
+func gitCommit(ctx context.Context, dir string) (string, bool) {
+	head, headErr := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+	if headErr != nil {
+		return "", false
+	}
+	status, statusErr := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain", "--", ".").Output()
+	if statusErr != nil {
+		return "", false
+	}
+
+	return strings.TrimSpace(string(head)), len(bytes.TrimSpace(status)) == 0
+}
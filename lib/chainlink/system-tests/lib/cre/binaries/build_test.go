@@ -0,0 +1,125 @@
+package binaries
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceValidate(t *testing.T) {
+	valid := []Source{
+		{Module: "github.com/org/capabilities/cron", Version: "v1.0.0"},
+		{Dir: "./capabilities/cron", Package: "cmd/cron"},
+	}
+	for _, source := range valid {
+		require.NoError(t, source.Validate(), source)
+	}
+
+	invalid := []Source{
+		{},
+		{Module: "github.com/org/capabilities/cron"},
+		{Module: "github.com/org/capabilities/cron", Version: "v1.0.0", Dir: "./cron"},
+		{Dir: "./cron", Version: "v1.0.0"},
+		{Dir: "./cron", Package: "../other"},
+		{Dir: "./cron", Name: "bin/cron"},
+	}
+	for _, source := range invalid {
+		require.Error(t, source.Validate(), source)
+	}
+
+	assert.Equal(t, "cron", (&Source{Module: "github.com/org/capabilities", Version: "v1.0.0", Package: "cmd/cron"}).BinaryName())
+	assert.Equal(t, "capabilities", (&Source{Module: "github.com/org/capabilities", Version: "v1.0.0"}).BinaryName())
+	assert.Equal(t, "cron-v2", (&Source{Dir: "./cron", Name: "cron-v2"}).BinaryName())
+}
+
+func TestBuildLocalSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/plugin\n\ngo 1.21\n"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cmd", "cron"), 0o755))
+	mainPath := filepath.Join(dir, "cmd", "cron", "main.go")
+	require.NoError(t, os.WriteFile(mainPath, []byte("package main\n\nfunc main() {}\n"), 0o600))
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-qm", "plugin")
+
+	builder := NewBuilder(t.TempDir())
+	source := Source{Dir: dir, Package: "cmd/cron"}
+	built, err := builder.Build(context.Background(), source, "linux/arm64")
+	require.NoError(t, err)
+	assert.Equal(t, "cron", filepath.Base(built))
+
+	platform, err := Platform(built)
+	require.NoError(t, err)
+	assert.Equal(t, "linux/arm64", platform)
+
+	t.Run("builds of the same commit are cached", func(t *testing.T) {
+		info, err := os.Stat(built)
+		require.NoError(t, err)
+
+		cached, err := builder.Build(context.Background(), source, "linux/arm64")
+		require.NoError(t, err)
+		assert.Equal(t, built, cached)
+		cachedInfo, err := os.Stat(cached)
+		require.NoError(t, err)
+		assert.Equal(t, info.ModTime(), cachedInfo.ModTime())
+
+		other, err := builder.Build(context.Background(), source, "linux/amd64")
+		require.NoError(t, err)
+		assert.NotEqual(t, built, other)
+	})
+
+	t.Run("uncommitted changes are built apart from the commit", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(mainPath, []byte("package main\n\nfunc main() { println() }\n"), 0o600))
+
+		dirty, err := builder.Build(context.Background(), source, "linux/arm64")
+		require.NoError(t, err)
+		assert.NotEqual(t, built, dirty)
+	})
+
+	t.Run("concurrent builds don't share their output", func(t *testing.T) {
+		// builds of uncommitted changes aren't taken from the cache, so all of them run
+		var wg sync.WaitGroup
+		results := make([]string, 4)
+		errs := make([]error, len(results))
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], errs[i] = builder.Build(context.Background(), source, "linux/arm64")
+			}()
+		}
+		wg.Wait()
+
+		for i := range results {
+			require.NoError(t, errs[i])
+			platform, err := Platform(results[i])
+			require.NoError(t, err)
+			assert.Equal(t, "linux/arm64", platform)
+		}
+		leftovers, err := filepath.Glob(filepath.Join(filepath.Dir(results[0]), "*.build"))
+		require.NoError(t, err)
+		assert.Empty(t, leftovers)
+	})
+
+	t.Run("build errors are returned", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(mainPath, []byte("package main\n\nfunc main() {\n"), 0o600))
+
+		_, err := builder.Build(context.Background(), source, "linux/arm64")
+		require.ErrorContains(t, err, "failed to build")
+	})
+}
@@ -0,0 +1,115 @@
+package binaries
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	elfArchitectures = map[elf.Machine]string{
+		elf.EM_X86_64:  "amd64",
+		elf.EM_AARCH64: "arm64",
+		elf.EM_386:     "386",
+		elf.EM_ARM:     "arm",
+	}
+	machoArchitectures = map[macho.Cpu]string{
+		macho.CpuAmd64: "amd64",
+		macho.CpuArm64: "arm64",
+		macho.Cpu386:   "386",
+		macho.CpuArm:   "arm",
+	}
+	peArchitectures = map[uint16]string{
+		pe.IMAGE_FILE_MACHINE_AMD64: "amd64",
+		pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+		pe.IMAGE_FILE_MACHINE_I386:  "386",
+	}
+)
+
+// Platform returns the platform of the executable in the Go format, e.g. linux/amd64 or darwin/arm64, read from its
+// ELF, Mach-O or PE header. An empty platform is returned for files in other formats, e.g. scripts.
+func Platform(path string) (string, error) {
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return "", openErr
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// shorter than any header
+			return "", nil
+		}
+		return "", err
+	}
+
+	switch {
+	case string(magic) == elf.ELFMAG:
+		elfFile, err := elf.NewFile(file)
+		if err != nil {
+			return "", err
+		}
+		return platformString(elfOS(elfFile), elfArchitectures[elfFile.Machine], elfFile.Machine.String()), nil
+	case string(magic[:2]) == "MZ":
+		peFile, err := pe.NewFile(file)
+		if err != nil {
+			return "", err
+		}
+		return platformString("windows", peArchitectures[peFile.Machine], fmt.Sprintf("machine 0x%x", peFile.Machine)), nil
+	}
+
+	if fatFile, err := macho.NewFatFile(file); err == nil {
+		// universal binaries run on any of their architectures, the first one is reported
+		defer fatFile.Close()
+		return platformString("darwin", machoArchitectures[fatFile.Arches[0].Cpu], fatFile.Arches[0].Cpu.String()), nil
+	}
+	if machoFile, err := macho.NewFile(file); err == nil {
+		return platformString("darwin", machoArchitectures[machoFile.Cpu], machoFile.Cpu.String()), nil
+	}
+
+	return "", nil
+}
+
+// CheckPlatform returns an error, if the executable can't run on the target platform (e.g. linux/amd64).
+// Files in unknown formats are not checked.
+func CheckPlatform(path, target string) error {
+	platform, err := Platform(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read platform of binary %s", path)
+	}
+	if platform == "" || platform == target {
+		return nil
+	}
+
+	targetOS, targetArch, _ := strings.Cut(target, "/")
+	return fmt.Errorf("binary %s is %s but target container is %s. Build it for the container, e.g. with GOOS=%s GOARCH=%s", path, platform, target, targetOS, targetArch)
+}
+
+func elfOS(file *elf.File) string {
+	switch file.OSABI {
+	case elf.ELFOSABI_FREEBSD:
+		return "freebsd"
+	case elf.ELFOSABI_OPENBSD:
+		return "openbsd"
+	case elf.ELFOSABI_NETBSD:
+		return "netbsd"
+	default:
+		// Go and most other toolchains leave it as SYSV (or set GNU) for Linux executables
+		return "linux"
+	}
+}
+
+func platformString(goos, arch, unknownArch string) string {
+	if arch == "" {
+		arch = unknownArch
+	}
+
+	return goos + "/" + arch
+}
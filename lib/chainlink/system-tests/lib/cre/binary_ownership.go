@@ -0,0 +1,33 @@
+package cre
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultChainlinkUserID is UID and GID of the chainlink user in official node images
+const DefaultChainlinkUserID = 14933
+
+// CapabilityBinariesOwnerIDs returns UID and GID, which should own capability binaries copied to nodes. Binaries are copied
+// as root by default, which prevents non-root users of hardened images from executing them. ok is false if the owner isn't set.
+func (c *CapabilitiesAwareNodeSet) CapabilityBinariesOwnerIDs() (uid, gid int, ok bool, err error) {
+	if c.CapabilityBinariesOwner == "" {
+		return 0, 0, false, nil
+	}
+	if c.CapabilityBinariesOwner == "chainlink" {
+		return DefaultChainlinkUserID, DefaultChainlinkUserID, true, nil
+	}
+
+	rawUID, rawGID, found := strings.Cut(c.CapabilityBinariesOwner, ":")
+	if !found {
+		rawGID = rawUID
+	}
+	uid, uidErr := strconv.Atoi(rawUID)
+	gid, gidErr := strconv.Atoi(rawGID)
+	if uidErr != nil || gidErr != nil || uid < 0 || gid < 0 {
+		return 0, 0, false, fmt.Errorf("capability binaries owner of nodeset %s must be 'chainlink', '<uid>' or '<uid>:<gid>', got '%s'", c.Name, c.CapabilityBinariesOwner)
+	}
+
+	return uid, gid, true, nil
+}
@@ -0,0 +1,158 @@
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/experimental/tracing"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// BuildBinaries cross-compiles binaries of capability configs with a source for the platform of node containers and
+// returns capability configs, in which binary paths of built binaries replace sources. All nodes must run on the same
+// platform, because a capability has one binary path.
+func BuildBinaries(ctx context.Context, builder *binaries.Builder, provider infra.Provider, capabilityConfigs cre.CapabilityConfigs, nodeSets []*cre.CapabilitiesAwareNodeSet) (cre.CapabilityConfigs, error) {
+	built := maps.Clone(capabilityConfigs)
+	if !slices.ContainsFunc(slices.Collect(maps.Values(built)), func(config cre.CapabilityConfig) bool { return config.Source != nil }) {
+		return built, nil
+	}
+
+	images := make([]string, 0)
+	for _, nodeSet := range nodeSets {
+		for _, nodeSpec := range nodeSet.NodeSpecs {
+			images = append(images, nodeImage(nodeSpec.Node.Image, nodeSpec.Node.DockerContext))
+		}
+	}
+	platforms, platformsErr := infra.ContainerPlatforms(ctx, provider, images)
+	if platformsErr != nil {
+		return nil, errors.Wrap(platformsErr, "failed to get platforms of node containers")
+	}
+	distinct := slices.Compact(slices.Sorted(maps.Values(platforms)))
+	if len(distinct) != 1 {
+		return nil, fmt.Errorf("capability binaries can be built from source only if all nodes run on the same platform, but they run on: %s", strings.Join(distinct, ", "))
+	}
+
+	for _, flag := range slices.Sorted(maps.Keys(built)) {
+		config := built[flag]
+		if config.Source == nil {
+			continue
+		}
+		buildCtx, endSpan := tracing.StartSpan(ctx, tracing.Span{Stage: BinaryStageBuild, Capability: flag})
+		binaryPath, buildErr := builder.Build(buildCtx, *config.Source, distinct[0])
+		endSpan(buildErr)
+		if buildErr != nil {
+			return nil, errors.Wrapf(buildErr, "failed to build binary of capability %s", flag)
+		}
+		config.BinaryPath = binaryPath
+		config.Source = nil
+		built[flag] = config
+	}
+
+	return built, nil
+}
+
+// ResolveBinaries downloads capability binaries referenced by remote URLs in capability configs and in per-node and
+// per-label binary overrides of nodesets, and returns capability configs with local paths, which are then used by PrepareBinaries
+// and AppendBinariesPathsNodeSpec. Overrides of nodesets are replaced in place.
+func ResolveBinaries(ctx context.Context, resolver *binaries.Resolver, capabilityConfigs cre.CapabilityConfigs, nodeSets []*cre.CapabilitiesAwareNodeSet) (cre.CapabilityConfigs, error) {
+	resolved := maps.Clone(capabilityConfigs)
+	for flag, config := range resolved {
+		if !binaries.IsRemote(config.BinaryPath) {
+			continue
+		}
+		localPath, err := resolveBinary(ctx, resolver, tracing.Span{Capability: flag, Path: config.BinaryPath})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve binary of capability %s", flag)
+		}
+		if config.Signature != nil {
+			signature, signatureErr := resolveSignature(ctx, resolver, config.BinaryPath, localPath, *config.Signature)
+			if signatureErr != nil {
+				return nil, errors.Wrapf(signatureErr, "failed to resolve signature of binary of capability %s", flag)
+			}
+			config.Signature = signature
+		}
+		config.BinaryPath = localPath
+		resolved[flag] = config
+	}
+
+	for _, nodeSet := range nodeSets {
+		if err := resolveNodeSetBinaries(ctx, resolver, nodeSet); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// resolveNodeSetBinaries replaces remote URLs in per-node and per-label overrides of the nodeset with local paths
+func resolveNodeSetBinaries(ctx context.Context, resolver *binaries.Resolver, nodeSet *cre.CapabilitiesAwareNodeSet) error {
+	for nodeIdx, nodeBinaries := range nodeSet.NodeCapabilityBinaries {
+		for flag, binaryPath := range nodeBinaries {
+			if !binaries.IsRemote(binaryPath) {
+				continue
+			}
+			localPath, err := resolveBinary(ctx, resolver, tracing.Span{Capability: flag, Target: fmt.Sprintf("node %d of nodeset %s", nodeIdx, nodeSet.Name), Path: binaryPath})
+			if err != nil {
+				return errors.Wrapf(err, "failed to resolve binary of capability %s for node %d in nodeset %s", flag, nodeIdx, nodeSet.Name)
+			}
+			nodeBinaries[flag] = localPath
+		}
+	}
+	for label, labelBinaries := range nodeSet.LabelCapabilityBinaries {
+		for flag, binaryPath := range labelBinaries {
+			if !binaries.IsRemote(binaryPath) {
+				continue
+			}
+			localPath, err := resolveBinary(ctx, resolver, tracing.Span{Capability: flag, Target: fmt.Sprintf("nodes labeled '%s' of nodeset %s", label, nodeSet.Name), Path: binaryPath})
+			if err != nil {
+				return errors.Wrapf(err, "failed to resolve binary of capability %s for label '%s' in nodeset %s", flag, label, nodeSet.Name)
+			}
+			labelBinaries[flag] = localPath
+		}
+	}
+
+	return nil
+}
+
+// resolveSignature downloads the detached signature of the remote binary next to its cached copy, from the path of the
+// signature, if it's a remote reference, or from binaries.SignatureRef of the binary, if it's not set. Local signature
+// paths are kept as they are.
+func resolveSignature(ctx context.Context, resolver *binaries.Resolver, binaryRef, localPath string, signature cre.BinarySignature) (*cre.BinarySignature, error) {
+	signatureRef := signature.Path
+	switch {
+	case signatureRef == "":
+		ref, err := binaries.ParseRef(binaryRef)
+		if err != nil {
+			return nil, err
+		}
+		signatureRef = binaries.SignatureRef(ref)
+	case !binaries.IsRemote(signatureRef):
+		return &signature, nil
+	}
+
+	// downloaded every time, the binary's cache directory changes with its version, but the signature may be replaced
+	// on its own, e.g. when the binary is signed with a new key
+	signature.Path = localPath + ".sig"
+	if err := resolver.Download(ctx, signatureRef, signature.Path); err != nil {
+		return nil, errors.Wrapf(err, "failed to download signature %s", signatureRef)
+	}
+
+	return &signature, nil
+}
+
+// resolveBinary resolves the binary at the path of the span and reports it to the tracer
+func resolveBinary(ctx context.Context, resolver *binaries.Resolver, span tracing.Span) (string, error) {
+	span.Stage = BinaryStageResolve
+	resolveCtx, endSpan := tracing.StartSpan(ctx, span)
+	localPath, err := resolver.Resolve(resolveCtx, span.Path)
+	endSpan(err)
+
+	return localPath, err
+}
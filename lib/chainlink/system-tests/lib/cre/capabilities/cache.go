@@ -0,0 +1,200 @@
+package capabilities
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+)
+
+// digestsFile stores digests of host binaries in the cache directory
+const digestsFile = "digests.json"
+
+// BinaryCache skips copies of capability binaries to destinations, which already have a binary with the same SHA-256
+// digest. Copies are checked only if they report what the destination has, see BinaryCopy.RemoteDigest. Digests of host
+// binaries are stored in the cache directory by path, size and modification time, so that unchanged binaries aren't
+// hashed again in later runs. It's safe for concurrent use and can be shared by preparers, see BinaryPreparer.UseCache.
+//
+// Preparers with a cache stage binaries by digest on persistent volumes of nodes, which outlive containers and pods: the
+// home volume of Docker nodes (see BinaryPreparer.StageInDockerVolumes) and the volume of the home directory of pods
+// (see BinaryPreparer.PodBinaryCopy). Hits and misses of a setup are logged once DONs are started, see Stats.
+//
+// Experimental: the way digests are stored may change.
+type BinaryCache struct {
+	dir string
+
+	mu      sync.Mutex
+	digests map[string]hostDigest // by absolute path
+	stats   CacheStats
+}
+
+// CacheStats counts copies skipped by the cache (hits) and copies, which had to run (misses)
+type CacheStats struct {
+	Hits         int
+	Misses       int
+	BytesSkipped int64
+	BytesCopied  int64
+}
+
+type hostDigest struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	SHA256  string    `json:"sha256"`
+}
+
+// BinaryCacheConfig configures the binary cache, which is enabled unless it's disabled explicitly
+type BinaryCacheConfig struct {
+	Disabled bool   `toml:"disabled"`
+	Dir      string `toml:"dir"` // where digests are stored, DefaultBinaryCacheDir is used if empty
+}
+
+// NewBinaryCache returns the configured cache or nil, if it's disabled. Nil config enables the cache with defaults.
+func (c *BinaryCacheConfig) NewBinaryCache() (*BinaryCache, error) {
+	if c == nil {
+		return NewBinaryCache("")
+	}
+	if c.Disabled {
+		return nil, nil
+	}
+
+	return NewBinaryCache(c.Dir)
+}
+
+// DefaultBinaryCacheDir is in the user's cache directory, so that it's shared by test runs
+func DefaultBinaryCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to find user cache directory")
+	}
+
+	return filepath.Join(cacheDir, "cre", "capability-binaries"), nil
+}
+
+// NewBinaryCache returns a cache storing digests in dir, DefaultBinaryCacheDir is used if it's empty
+func NewBinaryCache(dir string) (*BinaryCache, error) {
+	if dir == "" {
+		defaultDir, dirErr := DefaultBinaryCacheDir()
+		if dirErr != nil {
+			return nil, dirErr
+		}
+		dir = defaultDir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create binary cache directory %s", dir)
+	}
+
+	cache := &BinaryCache{dir: dir, digests: make(map[string]hostDigest)}
+	content, readErr := os.ReadFile(filepath.Join(dir, digestsFile))
+	switch {
+	case os.IsNotExist(readErr):
+	case readErr != nil:
+		return nil, errors.Wrap(readErr, "failed to read digests of the binary cache")
+	default:
+		// a corrupted file only means binaries are hashed again
+		_ = json.Unmarshal(content, &cache.digests)
+	}
+
+	return cache, nil
+}
+
+// Digest returns the SHA-256 digest of the host binary, computing it only if the binary changed since it was last seen
+func (c *BinaryCache) Digest(binaryPath string) (string, error) {
+	absPath, absErr := filepath.Abs(binaryPath)
+	if absErr != nil {
+		return "", errors.Wrapf(absErr, "failed to get absolute path for binary %s", binaryPath)
+	}
+	info, statErr := os.Stat(absPath)
+	if statErr != nil {
+		return "", errors.Wrapf(statErr, "failed to stat binary %s", binaryPath)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.digests[absPath]
+	c.mu.Unlock()
+	if ok && cached.Size == info.Size() && cached.ModTime.Equal(info.ModTime()) {
+		return cached.SHA256, nil
+	}
+
+	digest, hashErr := binaries.FileDigest(absPath)
+	if hashErr != nil {
+		return "", errors.Wrapf(hashErr, "failed to compute digest of binary %s", binaryPath)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.digests[absPath] = hostDigest{Size: info.Size(), ModTime: info.ModTime(), SHA256: digest}
+	if err := c.saveLocked(); err != nil {
+		return "", err
+	}
+
+	return digest, nil
+}
+
+// Stats returns hits and misses counted since the cache was created
+func (c *BinaryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// upToDate reports whether the destination of the copy already has the binary
+func (c *BinaryCache) upToDate(ctx context.Context, binaryCopy BinaryCopy) (bool, error) {
+	digest, digestErr := c.Digest(binaryCopy.Path)
+	if digestErr != nil {
+		return false, digestErr
+	}
+	remoteDigest, remoteErr := binaryCopy.RemoteDigest(ctx)
+	if remoteErr != nil {
+		return false, errors.Wrapf(remoteErr, "failed to check binary %s in %s", binaryCopy.Path, binaryCopy.Target)
+	}
+
+	return remoteDigest == digest, nil
+}
+
+// record counts a copy, which was skipped (hit) or ran
+func (c *BinaryCache) record(binaryPath string, hit bool) {
+	var size int64
+	if info, err := os.Stat(binaryPath); err == nil {
+		size = info.Size()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.stats.Hits++
+		c.stats.BytesSkipped += size
+		return
+	}
+	c.stats.Misses++
+	c.stats.BytesCopied += size
+}
+
+func (c *BinaryCache) saveLocked() error {
+	content, marshalErr := json.MarshalIndent(c.digests, "", "  ")
+	if marshalErr != nil {
+		return errors.Wrap(marshalErr, "failed to marshal digests of the binary cache")
+	}
+
+	// written to a temporary file first, so that concurrent test runs don't read a partially written file
+	tmpFile, tmpErr := os.CreateTemp(c.dir, digestsFile+".*")
+	if tmpErr != nil {
+		return errors.Wrap(tmpErr, "failed to create digests file of the binary cache")
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return errors.Wrap(err, "failed to write digests of the binary cache")
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrap(err, "failed to write digests of the binary cache")
+	}
+
+	return errors.Wrap(os.Rename(tmpFile.Name(), filepath.Join(c.dir, digestsFile)), "failed to save digests of the binary cache")
+}
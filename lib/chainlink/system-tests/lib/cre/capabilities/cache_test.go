@@ -0,0 +1,100 @@
+package capabilities
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+)
+
+func TestBinaryCacheSkipsUpToDateCopies(t *testing.T) {
+	binaryPath := filepath.Join(t.TempDir(), "cron")
+	require.NoError(t, os.WriteFile(binaryPath, []byte("binary"), 0o600))
+	digest, err := binaries.FileDigest(binaryPath)
+	require.NoError(t, err)
+
+	cacheDir := t.TempDir()
+	cache, err := NewBinaryCache(cacheDir)
+	require.NoError(t, err)
+	preparer := NewBinaryPreparer(0, nil)
+	preparer.UseCache(cache)
+
+	copied := 0
+	copyOf := func(remoteDigest string) BinaryCopy {
+		return BinaryCopy{
+			Path:         binaryPath,
+			Target:       "node",
+			Copy:         func(context.Context) error { copied++; return nil },
+			RemoteDigest: func(context.Context) (string, error) { return remoteDigest, nil },
+		}
+	}
+	require.NoError(t, preparer.Copy(context.Background(), []BinaryCopy{copyOf(digest)}))
+	require.NoError(t, preparer.Copy(context.Background(), []BinaryCopy{copyOf("")}))
+	require.NoError(t, preparer.Copy(context.Background(), []BinaryCopy{{Path: binaryPath, Target: "node", Copy: func(context.Context) error { copied++; return nil }}}))
+
+	assert.Equal(t, 2, copied)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, BytesSkipped: 6, BytesCopied: 6}, cache.Stats(), "copies without remote digest aren't counted")
+
+	reloaded, err := NewBinaryCache(cacheDir)
+	require.NoError(t, err)
+	abs, err := filepath.Abs(binaryPath)
+	require.NoError(t, err)
+	assert.Equal(t, digest, reloaded.digests[abs].SHA256)
+}
+
+func TestBinaryCacheConfigEnablesCacheByDefault(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	var unset *BinaryCacheConfig
+	cache, err := unset.NewBinaryCache()
+	require.NoError(t, err)
+	assert.NotNil(t, cache)
+
+	cache, err = (&BinaryCacheConfig{Disabled: true}).NewBinaryCache()
+	require.NoError(t, err)
+	assert.Nil(t, cache)
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	cache, err = (&BinaryCacheConfig{Dir: dir}).NewBinaryCache()
+	require.NoError(t, err)
+	require.NotNil(t, cache)
+	assert.Equal(t, dir, cache.dir)
+}
+
+func TestWriteShimExecsStagedBinary(t *testing.T) {
+	stagingDir := t.TempDir()
+	digest := "0123456789abcdef0123456789abcdef"
+	staged := VolumeStagingDir + "/" + digest + "/cron"
+
+	shim, err := writeShim(stagingDir, digest, staged)
+	require.NoError(t, err)
+	assert.Equal(t, "cron", filepath.Base(shim), "job specs reference binaries by name")
+	content, err := os.ReadFile(shim)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\nexec '"+staged+"' \"$@\"\n", string(content))
+	info, err := os.Stat(shim)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	again, err := writeShim(stagingDir, digest, staged)
+	require.NoError(t, err)
+	assert.Equal(t, shim, again)
+}
+
+func TestStageInDockerVolumesNeedsCache(t *testing.T) {
+	preparer := NewBinaryPreparer(0, nil)
+	nodeSets := []*cre.CapabilitiesAwareNodeSet{{Input: &ns.Input{Name: "workflow", NodeSpecs: []*clnode.Input{{Node: &clnode.NodeInput{Image: "chainlink", CapabilitiesBinaryPaths: []string{"/bin/cron"}}}}}}}
+
+	require.NoError(t, preparer.StageInDockerVolumes(context.Background(), nodeSets, nil))
+	assert.Equal(t, []string{"/bin/cron"}, nodeSets[0].NodeSpecs[0].Node.CapabilitiesBinaryPaths, "CTF copies binaries without a cache")
+}
@@ -0,0 +1,34 @@
+// Package capabilities builds installable capabilities (see New and its options) and prepares their binaries for nodes.
+//
+// # API stability
+//
+// New, Capability and Option constructors are stable and are the supported way of adding capabilities outside of the
+// framework. Preparation of binaries is supported too, but its signatures may gain parameters. SwapBinary,
+// JobSpecFactories, BinaryCache and image capabilities are experimental, their doc comments say what may change.
+// Tracing of setup stages moved to the experimental tracing package, Span, Tracer, StageTimings, ContextWithTracer and
+// StartSpan of this package are deprecated aliases of it.
+//
+// # Binaries
+//
+// BuildBinaries, ResolveBinaries, VerifyBinary, VerifyBinariesPlatform, BinaryPreparer, BinaryCache, PrepareBinaries,
+// AppendBinariesPathsNodeSpecWithOverrides and AppendBinariesPathsNodeSpecWithRoles are called by the environment
+// package during setup. Failures to find binaries or to install them with the infra can be matched with
+// ErrBinaryNotFound, ErrEmptyBinaryPath and ErrUnsupportedInfra. PlanBinaries reports which binaries the setup would
+// install on which nodes without changing anything. SwapBinary replaces binaries on running DONs.
+//
+// # Topology
+//
+// The environment package calls ValidateTopology and ValidateNodeSet during setup. ConfigureTopology validates and
+// configures nodesets for callers that don't use the environment package. Capabilities enabled in TOML, which no
+// feature passed to the environment handles, are set up by features and factories of JobSpecFactories.
+//
+// # Readiness
+//
+// WaitForReady checks that binaries installed on nodes were launched by their jobs, WaitForReadyWithRegistry also
+// that capabilities are added to their DONs in the capabilities registry.
+//
+// # Node images
+//
+// DiscoverImageCapabilities and CheckImageCapabilities catch binaries of capabilities node images already have and
+// missing binaries of ones they don't. Plugins images ship are registered with RegisterImagePlugin.
+package capabilities
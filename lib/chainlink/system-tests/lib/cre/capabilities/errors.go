@@ -0,0 +1,58 @@
+package capabilities
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// Sentinels of configuration failures, which can be matched with errors.Is. Errors matching them can be inspected with
+// errors.As as BinaryNotFoundError, EmptyBinaryPathError and UnsupportedInfraError.
+var (
+	ErrBinaryNotFound   = errors.New("capability binary not found")
+	ErrEmptyBinaryPath  = errors.New("capability binary path is empty")
+	ErrUnsupportedInfra = errors.New("unsupported infra type")
+)
+
+// BinaryNotFoundError is returned, when there's no binary of the capability at Path, which is absolute
+type BinaryNotFoundError struct {
+	Capability cre.CapabilityFlag
+	Path       string
+}
+
+func (e *BinaryNotFoundError) Error() string {
+	return fmt.Sprintf("no binary file for capability %s found at '%s'. Please make sure the path is correct, update it in the capabilities TOML config or copy the binary to the expected location", e.Capability, e.Path)
+}
+
+func (e *BinaryNotFoundError) Is(target error) bool {
+	return target == ErrBinaryNotFound
+}
+
+// EmptyBinaryPathError is returned, when the capability is expected to have a binary, but its path isn't set
+type EmptyBinaryPathError struct {
+	Capability cre.CapabilityFlag
+}
+
+func (e *EmptyBinaryPathError) Error() string {
+	return fmt.Sprintf("binary path for capability %s is empty. Please set the binary path in the capabilities TOML config", e.Capability)
+}
+
+func (e *EmptyBinaryPathError) Is(target error) bool {
+	return target == ErrEmptyBinaryPath
+}
+
+// UnsupportedInfraError is returned for infra types, which capability binaries can't be installed with
+type UnsupportedInfraError struct {
+	Type infra.Type
+}
+
+func (e *UnsupportedInfraError) Error() string {
+	return fmt.Sprintf("unknown infra type: %s", e.Type)
+}
+
+func (e *UnsupportedInfraError) Is(target error) bool {
+	return target == ErrUnsupportedInfra
+}
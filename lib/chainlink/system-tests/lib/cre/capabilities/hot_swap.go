@@ -0,0 +1,237 @@
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/sethvargo/go-retry"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// SwapRestart is how nodes pick up a capability binary replaced by SwapBinary
+type SwapRestart string
+
+const (
+	// SwapRestartPlugin kills running processes of the binary, nodes relaunch LOOP plugins they supervise from the new binary.
+	// The next node is swapped only after the plugin of the previous one runs again.
+	SwapRestartPlugin SwapRestart = "plugin"
+	// SwapRestartNode restarts whole nodes, which is needed when the node caches anything about the capability. Docker only.
+	SwapRestartNode SwapRestart = "node"
+)
+
+// DefaultSwapNodeTimeout is how long a node restarted by SwapBinary has to become healthy (or relaunch the plugin)
+const DefaultSwapNodeTimeout = 2 * time.Minute
+
+// swapStagingDir is a directory in the capabilities directory of Docker containers, where new binaries are copied to
+// before they are renamed over the old ones, because a running executable can't be overwritten
+const swapStagingDir = ".swap"
+
+// swapUser runs commands, which replace binaries in Docker containers. Nodes run as a non-root user, which can't write
+// to the capabilities directory owned by root.
+const swapUser = "root"
+
+// BinarySwap describes a capability binary, which should replace the one running on nodes of a DON
+type BinarySwap struct {
+	Capability string
+	// Path is the new binary on the host. Its file name has to match the binary it replaces, because job specs reference it.
+	Path string
+	// Config of the capability, if set, its checksum or signature is verified before the binary is copied
+	Config *cre.CapabilityConfig
+	// Restart defaults to SwapRestartPlugin
+	Restart SwapRestart
+	// NodeTimeout is how long each node has to become healthy or relaunch the plugin, defaults to DefaultSwapNodeTimeout
+	NodeTimeout time.Duration
+}
+
+// SwapBinary replaces a capability binary on running nodes of the DON without redeploying the environment, e.g. to test
+// upgrades and downgrades or to try a rebuilt capability. Nodes are swapped one by one, so that the DON keeps its quorum,
+// and only nodes, which run a binary with the same file name, are changed. Node specs of the nodeset are updated with
+// the new path, so that nodes recreated later (e.g. by a DB migration test) use it too. Pods recreated in Kubernetes lose
+// the new binary, like any binary copied to them, so SwapRestartNode is supported only with Docker.
+//
+// Experimental: restart modes and options may change.
+func SwapBinary(ctx context.Context, lggr zerolog.Logger, provider *infra.Provider, don *cre.Don, nodeSet *cre.CapabilitiesAwareNodeSet, swap BinarySwap) error {
+	if provider.ReadOnly {
+		return errors.Wrap(infra.ErrReadOnly, "capability binaries can't be swapped")
+	}
+	if provider.IsCRIB() {
+		return errors.New("swapping capability binaries is not supported in CRIB, binaries are part of its deployment")
+	}
+	if swap.Path == "" {
+		return fmt.Errorf("path of the new %s binary is empty", swap.Capability)
+	}
+	restart := swap.Restart
+	if restart == "" {
+		restart = SwapRestartPlugin
+	}
+	if restart != SwapRestartPlugin && restart != SwapRestartNode {
+		return fmt.Errorf("unknown restart mode '%s'. Valid ones are: %s, %s", restart, SwapRestartPlugin, SwapRestartNode)
+	}
+	if restart == SwapRestartNode && provider.IsKubernetes() {
+		return fmt.Errorf("restart mode %s is not supported in Kubernetes, restarted pods lose copied binaries", SwapRestartNode)
+	}
+	nodeTimeout := swap.NodeTimeout
+	if nodeTimeout <= 0 {
+		nodeTimeout = DefaultSwapNodeTimeout
+	}
+	if nodeSet.Out == nil && !provider.IsKubernetes() {
+		return fmt.Errorf("nodeset %s isn't running", nodeSet.Name)
+	}
+
+	preparer := NewBinaryPreparer(1, nil)
+	if err := preparer.Prepare(ctx, []Binary{{Capability: swap.Capability, Path: swap.Path, Config: swap.Config}}); err != nil {
+		return errors.Wrapf(err, "failed to prepare new %s binary", swap.Capability)
+	}
+	swap.Path = preparer.PreparedPath(swap.Path)
+
+	containerDir, containerDirErr := DefaultContainerDirectory(provider.Type)
+	if containerDirErr != nil {
+		return containerDirErr
+	}
+	uid, gid, _, ownerErr := nodeSet.CapabilityBinariesOwnerIDs()
+	if ownerErr != nil {
+		return ownerErr
+	}
+
+	binaryName := filepath.Base(swap.Path)
+	swapped := 0
+	for _, node := range don.Nodes {
+		if node.Index < 0 || node.Index >= len(nodeSet.NodeSpecs) {
+			return fmt.Errorf("node %s of DON %s has no node spec in nodeset %s", node.Name, don.Name, nodeSet.Name)
+		}
+		nodeSpec := nodeSet.NodeSpecs[node.Index]
+		binaryIdx := slices.IndexFunc(nodeSpec.Node.CapabilitiesBinaryPaths, func(binaryPath string) bool {
+			return filepath.Base(binaryPath) == binaryName
+		})
+		if binaryIdx == -1 {
+			continue
+		}
+		if node.ReadOnly() {
+			return errors.Wrapf(infra.ErrReadOnly, "capability binary of node %s can't be swapped", node.Name)
+		}
+		if err := provider.CheckSafeTarget(ctx, node.Index, nodeSet.Name); err != nil {
+			return err
+		}
+
+		nodeDir := containerDir
+		if provider.IsKubernetes() {
+			executor, ok := provider.NodeExecutor(node.Index, nodeSet.Name).(*infra.KubernetesExecutor)
+			if !ok {
+				return fmt.Errorf("node %s of nodeset %s doesn't run in Kubernetes", node.Name, nodeSet.Name)
+			}
+			if err := infra.CopyFileToKubernetesPod(ctx, executor, swap.Path, nodeDir); err != nil {
+				return err
+			}
+		} else {
+			if dir := nodeSpec.Node.CapabilityContainerDir; dir != "" {
+				nodeDir = strings.TrimSuffix(dir, "/")
+			}
+			if err := copyBinaryToDockerNode(ctx, node, nodeSet.Out.CLNodes[node.Index].Node.ContainerName, swap.Path, nodeDir, uid, gid); err != nil {
+				return err
+			}
+		}
+
+		switch restart {
+		case SwapRestartPlugin:
+			containerPath := path.Join(nodeDir, binaryName)
+			killed, killErr := killPluginProcesses(ctx, node, containerPath)
+			if killErr != nil {
+				return killErr
+			}
+			// plugin of a capability, whose job wasn't created yet, isn't running, so there's nothing to wait for
+			if killed {
+				if err := waitPluginRunning(ctx, node, containerPath, nodeTimeout); err != nil {
+					return err
+				}
+			}
+		case SwapRestartNode:
+			if err := provider.RestartNode(ctx, node.Index, nodeSet.Name); err != nil {
+				return err
+			}
+			if err := node.WaitHealthy(ctx, nodeTimeout); err != nil {
+				return err
+			}
+		}
+
+		nodeSpec.Node.CapabilitiesBinaryPaths[binaryIdx] = swap.Path
+		swapped++
+		lggr.Info().Msgf("Swapped %s binary of node %s for %s (restart: %s)", swap.Capability, node.Name, swap.Path, restart)
+	}
+
+	if swapped == 0 {
+		return fmt.Errorf("no node of DON %s runs a capability binary named %s", don.Name, binaryName)
+	}
+
+	return nil
+}
+
+// copyBinaryToDockerNode copies the binary to a staging directory first and renames it over the old one, which replaces
+// the file the container directory points to, while running processes keep executing the old one
+func copyBinaryToDockerNode(ctx context.Context, node *cre.Node, containerName, hostPath, containerDir string, uid, gid int) error {
+	stagingDir := path.Join(containerDir, swapStagingDir)
+	if err := execSucceeded(ctx, node, swapUser, "mkdir", "-p", stagingDir); err != nil {
+		return err
+	}
+	if err := infra.CopyFileToDockerContainer(ctx, containerName, hostPath, stagingDir, uid, gid); err != nil {
+		return err
+	}
+	name := filepath.Base(hostPath)
+
+	return execSucceeded(ctx, node, swapUser, "mv", "-f", path.Join(stagingDir, name), path.Join(containerDir, name))
+}
+
+// killPluginProcesses kills processes started from the binary and returns whether any was running. pkill exits with 1,
+// if no process matched, which is expected when the capability's job wasn't created yet.
+func killPluginProcesses(ctx context.Context, node *cre.Node, containerPath string) (bool, error) {
+	result, execErr := node.Exec(ctx, "pkill", "-KILL", "-f", containerPath)
+	if execErr != nil {
+		return false, execErr
+	}
+	if result.ExitCode > 1 {
+		return false, fmt.Errorf("failed to kill processes of %s in node %s (exit code %d): %s", containerPath, node.Name, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	return result.Succeeded(), nil
+}
+
+// waitPluginRunning waits until the node relaunched the killed plugin from the new binary and is healthy again, so that
+// the DON doesn't lose more than one node at a time
+func waitPluginRunning(ctx context.Context, node *cre.Node, containerPath string, timeout time.Duration) error {
+	waitErr := retry.Do(ctx, retry.WithMaxDuration(timeout, retry.NewConstant(2*time.Second)), func(ctx context.Context) error {
+		// pgrep exits with 1, if no process matched
+		result, execErr := node.Exec(ctx, "pgrep", "-f", containerPath)
+		if execErr != nil {
+			return execErr
+		}
+		if !result.Succeeded() {
+			return retry.RetryableError(fmt.Errorf("no process of %s is running (exit code %d)", containerPath, result.ExitCode))
+		}
+		return nil
+	})
+	if waitErr != nil {
+		return fmt.Errorf("node %s didn't relaunch plugin %s after %s: %w", node.Name, containerPath, timeout, waitErr)
+	}
+
+	return node.WaitHealthy(ctx, timeout)
+}
+
+func execSucceeded(ctx context.Context, node *cre.Node, user string, cmd ...string) error {
+	result, execErr := node.ExecStream(ctx, infra.ExecOptions{User: user}, cmd...)
+	if execErr != nil {
+		return execErr
+	}
+	if !result.Succeeded() {
+		return fmt.Errorf("'%s' failed in node %s (exit code %d): %s", strings.Join(cmd, " "), node.Name, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// ImagePluginProvider is implemented by features of capabilities, whose jobs run a plugin shipped by node images
+// instead of a copied binary
+type ImagePluginProvider interface {
+	Flag() cre.CapabilityFlag
+	ImagePlugin() string
+}
+
+var (
+	imagePluginsMu sync.RWMutex
+	imagePlugins   = make(map[cre.CapabilityFlag]string)
+)
+
+// RegisterImagePlugin registers the path of the plugin in node images, which jobs of the capability with given flag
+// run, replacing the one registered before. DiscoverImageCapabilities looks for it in images. Empty path unregisters
+// the flag.
+//
+// Experimental: images may be interrogated differently, once nodes can list their capabilities.
+func RegisterImagePlugin(flag cre.CapabilityFlag, containerPath string) {
+	imagePluginsMu.Lock()
+	defer imagePluginsMu.Unlock()
+
+	if containerPath == "" {
+		delete(imagePlugins, flag)
+		return
+	}
+	imagePlugins[flag] = containerPath
+}
+
+func registeredImagePlugins() map[cre.CapabilityFlag]string {
+	imagePluginsMu.RLock()
+	defer imagePluginsMu.RUnlock()
+
+	return maps.Clone(imagePlugins)
+}
+
+// ImageCapabilities is what an image of the node brings for capabilities: capabilities, whose registered plugins the
+// image ships (see RegisterImagePlugin), and names of binaries in the directory capability binaries are copied to
+type ImageCapabilities struct {
+	Image string
+	// Plugins are registered plugins the image was checked for, BuiltIn is authoritative only for their capabilities
+	Plugins  map[cre.CapabilityFlag]string
+	BuiltIn  []cre.CapabilityFlag
+	Binaries []string
+}
+
+// DiscoverImageCapabilities inspects images of nodes of all DONs of the topology, see infra.InspectNodeImage. Images
+// built from a Docker context don't exist yet and are skipped. Only Docker is supported.
+//
+// Experimental: images may be interrogated differently, once nodes can list their capabilities.
+func DiscoverImageCapabilities(ctx context.Context, provider infra.Provider, topology *cre.Topology) (map[string]*ImageCapabilities, error) {
+	if !provider.IsDocker() {
+		return nil, &UnsupportedInfraError{Type: provider.Type}
+	}
+	if topology == nil || topology.DonsMetadata == nil {
+		return nil, errors.New("topology has no DONs")
+	}
+	containerDir, dirErr := DefaultContainerDirectory(provider.Type)
+	if dirErr != nil {
+		return nil, dirErr
+	}
+	plugins := registeredImagePlugins()
+	dirs := []string{containerDir}
+	for _, plugin := range plugins {
+		if dir := path.Dir(plugin); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	discovered := make(map[string]*ImageCapabilities)
+	for _, donMetadata := range topology.DonsMetadata.List() {
+		for _, nodeSpec := range donMetadata.CapabilitiesAwareNodeSet().NodeSpecs {
+			image := nodeImage(nodeSpec.Node.Image, nodeSpec.Node.DockerContext)
+			if _, ok := discovered[image]; ok || image == "" {
+				continue
+			}
+			contents, inspectErr := infra.InspectNodeImage(ctx, image, dirs...)
+			if inspectErr != nil {
+				return nil, errors.Wrapf(inspectErr, "failed to discover capabilities of image %s", image)
+			}
+			discovered[image] = newImageCapabilities(image, contents, containerDir, plugins)
+		}
+	}
+
+	return discovered, nil
+}
+
+func newImageCapabilities(image string, contents *infra.NodeImageContents, containerDir string, plugins map[cre.CapabilityFlag]string) *ImageCapabilities {
+	imageCapabilities := &ImageCapabilities{Image: image, Plugins: plugins, Binaries: contents.Executables[containerDir]}
+	for flag, plugin := range plugins {
+		if slices.Contains(contents.Executables[path.Dir(plugin)], path.Base(plugin)) {
+			imageCapabilities.BuiltIn = append(imageCapabilities.BuiltIn, flag)
+		}
+	}
+	slices.Sort(imageCapabilities.BuiltIn)
+
+	return imageCapabilities
+}
+
+// CheckImageCapabilities cross-checks capabilities enabled on DONs of the topology and their configs against images
+// of their nodes, see DiscoverImageCapabilities. It catches binaries of capabilities the image already has, either as
+// a plugin or as a binary of the same name, and capabilities without binary_path or source, whose plugin the image
+// doesn't ship. Nodes with images, which weren't discovered, aren't checked. Problems of all DONs are returned at once.
+func CheckImageCapabilities(topology *cre.Topology, capabilityConfigs cre.CapabilityConfigs, images map[string]*ImageCapabilities) error {
+	if topology == nil || topology.DonsMetadata == nil {
+		return errors.New("topology has no DONs")
+	}
+
+	var problems []string
+	for _, donMetadata := range topology.DonsMetadata.List() {
+		checked := make(map[string]struct{})
+		for _, nodeSpec := range donMetadata.CapabilitiesAwareNodeSet().NodeSpecs {
+			imageCapabilities, ok := images[nodeImage(nodeSpec.Node.Image, nodeSpec.Node.DockerContext)]
+			if !ok {
+				continue
+			}
+			// nodes of a DON usually share the image, report it once
+			if _, ok := checked[imageCapabilities.Image]; ok {
+				continue
+			}
+			checked[imageCapabilities.Image] = struct{}{}
+
+			for _, flag := range enabledCapabilities(donMetadata.Flags) {
+				if problem := imageCapabilityProblem(flag, capabilityConfigs[flag], imageCapabilities); problem != "" {
+					problems = append(problems, fmt.Sprintf("DON %s: %s", donMetadata.Name, problem))
+				}
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("capability configs don't match node images:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// enabledCapabilities returns sorted capabilities of DON flags, chain-specific flags (e.g. evm-1337) are reduced to
+// their capability and DON types are skipped
+func enabledCapabilities(donFlags []string) []cre.CapabilityFlag {
+	var enabled []cre.CapabilityFlag
+	for _, flag := range donFlags {
+		if idx := strings.LastIndex(flag, "-"); idx > 0 {
+			if _, err := strconv.ParseUint(flag[idx+1:], 10, 64); err == nil {
+				flag = flag[:idx]
+			}
+		}
+		if slices.Contains([]cre.CapabilityFlag{cre.WorkflowDON, cre.CapabilitiesDON, cre.GatewayDON}, flag) || slices.Contains(enabled, flag) {
+			continue
+		}
+		enabled = append(enabled, flag)
+	}
+	slices.Sort(enabled)
+
+	return enabled
+}
+
+func imageCapabilityProblem(flag cre.CapabilityFlag, config cre.CapabilityConfig, imageCapabilities *ImageCapabilities) string {
+	binaryName := config.BinaryName()
+	plugin, pluginChecked := imageCapabilities.Plugins[flag]
+	builtIn := slices.Contains(imageCapabilities.BuiltIn, flag)
+	switch {
+	case binaryName != "" && builtIn:
+		return fmt.Sprintf("capability %s is shipped by image %s as plugin %s, remove binary %s from its config", flag, imageCapabilities.Image, plugin, binaryName)
+	case binaryName != "" && slices.Contains(imageCapabilities.Binaries, binaryName):
+		return fmt.Sprintf("image %s already has binary %s of capability %s, the copied one would replace it, remove it from the config or use an image without it", imageCapabilities.Image, binaryName, flag)
+	case binaryName == "" && pluginChecked && !builtIn:
+		return fmt.Sprintf("image %s doesn't ship plugin %s of capability %s, set binary_path or source in its config", imageCapabilities.Image, plugin, flag)
+	default:
+		return ""
+	}
+}
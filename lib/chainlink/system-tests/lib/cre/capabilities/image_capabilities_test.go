@@ -0,0 +1,64 @@
+package capabilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+func TestCheckImageCapabilities(t *testing.T) {
+	topology := twoDONTopology(t)
+	for _, donMetadata := range topology.DonsMetadata.List() {
+		for _, nodeSpec := range donMetadata.CapabilitiesAwareNodeSet().NodeSpecs {
+			nodeSpec.Node.Image = "chainlink:custom"
+		}
+	}
+	containerDir, err := DefaultContainerDirectory(infra.Docker)
+	require.NoError(t, err)
+	contents := &infra.NodeImageContents{Executables: map[string][]string{
+		"/usr/local/bin": {"chainlink", "chainlink-cron"},
+		containerDir:     {"http_action"},
+	}}
+	plugins := map[cre.CapabilityFlag]string{
+		cre.CronCapability:      "/usr/local/bin/chainlink-cron",
+		cre.ConsensusCapability: "/usr/local/bin/chainlink-ocr3-capability",
+	}
+	images := map[string]*ImageCapabilities{"chainlink:custom": newImageCapabilities("chainlink:custom", contents, containerDir, plugins)}
+	assert.Equal(t, []cre.CapabilityFlag{cre.CronCapability}, images["chainlink:custom"].BuiltIn)
+	assert.Equal(t, []string{"http_action"}, images["chainlink:custom"].Binaries)
+
+	// capabilities enabled on DONs are checked also without configs
+	require.NoError(t, CheckImageCapabilities(topology, nil, images))
+
+	err = CheckImageCapabilities(topology, cre.CapabilityConfigs{
+		cre.CronCapability:       {BinaryPath: "./binaries/cron"},
+		cre.HTTPActionCapability: {BinaryPath: "./binaries/http_action"},
+	}, images)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DON workflow: capability cron is shipped by image chainlink:custom as plugin /usr/local/bin/chainlink-cron, remove binary cron")
+	assert.Contains(t, err.Error(), "DON capabilities: image chainlink:custom already has binary http_action of capability http-action")
+
+	plugins[cre.HTTPActionCapability] = "/usr/local/bin/chainlink-http-action"
+	images = map[string]*ImageCapabilities{"chainlink:custom": newImageCapabilities("chainlink:custom", contents, containerDir, plugins)}
+	err = CheckImageCapabilities(topology, nil, images)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DON capabilities: image chainlink:custom doesn't ship plugin /usr/local/bin/chainlink-http-action of capability http-action, set binary_path or source")
+}
+
+func TestEnabledCapabilities(t *testing.T) {
+	assert.Equal(t,
+		[]cre.CapabilityFlag{cre.CronCapability, cre.EVMCapability, cre.LogEventTriggerCapability, cre.WriteEVMCapability},
+		enabledCapabilities([]string{cre.WorkflowDON, cre.CronCapability, "evm-1337", "evm-2337", "write-evm-1337", cre.LogEventTriggerCapability}))
+}
+
+func TestRegisterImagePlugin(t *testing.T) {
+	RegisterImagePlugin(cre.MockCapability, "/usr/local/bin/mock")
+	assert.Equal(t, "/usr/local/bin/mock", registeredImagePlugins()[cre.MockCapability])
+
+	RegisterImagePlugin(cre.MockCapability, "")
+	assert.NotContains(t, registeredImagePlugins(), cre.MockCapability)
+}
@@ -0,0 +1,106 @@
+package capabilities
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+)
+
+// JobSpecFactory generates job specs of a capability for the DON in the input. It's called with every DON and returns
+// no jobs for DONs, which don't have the capability enabled.
+type JobSpecFactory = cre.JobSpecFn
+
+// JobSpecFactories is a registry of job spec factories and features by capability flag. The environment proposes jobs
+// of every registered capability, which is enabled on a DON in TOML and isn't handled by a feature or an installable
+// capability passed to it. Registered features are applied to such DONs as a whole, so that their node configs and
+// capabilities registry entries are set up together with their jobs. It's safe for concurrent use.
+//
+// Experimental: factories may gain access to more of the environment.
+type JobSpecFactories struct {
+	mu        sync.RWMutex
+	factories map[cre.CapabilityFlag]JobSpecFactory
+	features  map[cre.CapabilityFlag]cre.Feature
+}
+
+func NewJobSpecFactories() *JobSpecFactories {
+	return &JobSpecFactories{
+		factories: make(map[cre.CapabilityFlag]JobSpecFactory),
+		features:  make(map[cre.CapabilityFlag]cre.Feature),
+	}
+}
+
+// Register registers the factory of jobs of the capability with given flag, replacing the factory or feature registered
+// before. Nil factory unregisters the flag.
+func (r *JobSpecFactories) Register(flag cre.CapabilityFlag, factory JobSpecFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.features, flag)
+	if factory == nil {
+		delete(r.factories, flag)
+		return
+	}
+	r.factories[flag] = factory
+}
+
+// RegisterFeature registers the feature under its flag, replacing the factory or feature registered before
+func (r *JobSpecFactories) RegisterFeature(feature cre.Feature) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.factories, feature.Flag())
+	r.features[feature.Flag()] = feature
+}
+
+// Get returns the factory registered for the flag
+func (r *JobSpecFactories) Get(flag cre.CapabilityFlag) (JobSpecFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	factory, ok := r.factories[flag]
+	return factory, ok
+}
+
+// Enabled returns factories of registered capabilities enabled on any of the nodesets, ordered by their flags. Flags
+// in handled are skipped, because their jobs are created by features or installable capabilities.
+func (r *JobSpecFactories) Enabled(nodeSets []*cre.CapabilitiesAwareNodeSet, handled []cre.CapabilityFlag) []JobSpecFactory {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var factories []JobSpecFactory
+	for _, flag := range enabledFlags(slices.Sorted(maps.Keys(r.factories)), nodeSets, handled) {
+		factories = append(factories, r.factories[flag])
+	}
+
+	return factories
+}
+
+// EnabledFeatures returns registered features enabled on any of the nodesets, ordered by their flags. Flags in handled
+// are skipped.
+func (r *JobSpecFactories) EnabledFeatures(nodeSets []*cre.CapabilitiesAwareNodeSet, handled []cre.CapabilityFlag) []cre.Feature {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var features []cre.Feature
+	for _, flag := range enabledFlags(slices.Sorted(maps.Keys(r.features)), nodeSets, handled) {
+		features = append(features, r.features[flag])
+	}
+
+	return features
+}
+
+func enabledFlags(flags []cre.CapabilityFlag, nodeSets []*cre.CapabilitiesAwareNodeSet, handled []cre.CapabilityFlag) []cre.CapabilityFlag {
+	var enabled []cre.CapabilityFlag
+	for _, flag := range flags {
+		if slices.Contains(handled, flag) {
+			continue
+		}
+		if slices.ContainsFunc(nodeSets, func(nodeSet *cre.CapabilitiesAwareNodeSet) bool { return slices.Contains(nodeSet.Flags(), flag) }) {
+			enabled = append(enabled, flag)
+		}
+	}
+
+	return enabled
+}
@@ -0,0 +1,75 @@
+package capabilities
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+)
+
+func TestJobSpecFactoriesEnabled(t *testing.T) {
+	var called []string
+	factoryOf := func(flag cre.CapabilityFlag) JobSpecFactory {
+		return func(*cre.JobSpecInput) (cre.DonJobs, error) {
+			called = append(called, flag)
+			return nil, nil
+		}
+	}
+	factories := NewJobSpecFactories()
+	for _, flag := range []cre.CapabilityFlag{"test-cron", "test-mock", "test-vault"} {
+		factories.Register(flag, factoryOf(flag))
+	}
+
+	nodeSets := []*cre.CapabilitiesAwareNodeSet{
+		{ComputedCapabilities: []string{"test-vault", "test-cron"}},
+		{ComputedCapabilities: []string{"test-mock", "unregistered"}},
+	}
+	for _, factory := range factories.Enabled(nodeSets, []cre.CapabilityFlag{"test-mock"}) {
+		_, err := factory(&cre.JobSpecInput{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"test-cron", "test-vault"}, called)
+
+	_, ok := factories.Get("test-mock")
+	assert.True(t, ok)
+	factories.Register("test-mock", nil)
+	_, ok = factories.Get("test-mock")
+	assert.False(t, ok)
+}
+
+type testFeature struct {
+	flag cre.CapabilityFlag
+}
+
+func (f *testFeature) Flag() cre.CapabilityFlag {
+	return f.flag
+}
+
+func (f *testFeature) PreEnvStartup(context.Context, zerolog.Logger, *cre.DonMetadata, *cre.Topology, *cre.Environment) (*cre.PreEnvStartupOutput, error) {
+	return nil, nil
+}
+
+func (f *testFeature) PostEnvStartup(context.Context, zerolog.Logger, *cre.Don, *cre.Dons, *cre.Environment) error {
+	return nil
+}
+
+func TestJobSpecFactoriesEnabledFeatures(t *testing.T) {
+	factories := NewJobSpecFactories()
+	cron, vault := &testFeature{flag: "test-cron"}, &testFeature{flag: "test-vault"}
+	factories.RegisterFeature(vault)
+	factories.RegisterFeature(cron)
+	factories.RegisterFeature(&testFeature{flag: "test-mock"})
+
+	nodeSets := []*cre.CapabilitiesAwareNodeSet{{ComputedCapabilities: []string{"test-vault", "test-cron", "test-mock"}}}
+	assert.Equal(t, []cre.Feature{cron, vault}, factories.EnabledFeatures(nodeSets, []cre.CapabilityFlag{"test-mock"}))
+	assert.Empty(t, factories.Enabled(nodeSets, nil), "features aren't plain factories")
+
+	// a factory replaces the feature registered under its flag
+	factories.Register("test-cron", func(*cre.JobSpecInput) (cre.DonJobs, error) { return nil, nil })
+	assert.Equal(t, []cre.Feature{vault}, factories.EnabledFeatures(nodeSets, []cre.CapabilityFlag{"test-mock"}))
+	assert.Len(t, factories.Enabled(nodeSets, nil), 1)
+}
@@ -1,44 +1,142 @@
 package capabilities
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/pkg/errors"
 
-	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/experimental/tracing"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
 )
 
+// MakeBinariesExecutable makes binaries executable. It fails for binaries, which can't be made executable in place,
+// because their staged copies can't be returned, see PrepareBinaries.
+//
+// Deprecated: use PrepareBinaries, which also verifies checksums and signatures set in capability configs and returns
+// paths of staged copies.
 func MakeBinariesExecutable(customBinariesPaths map[cre.CapabilityFlag]string) error {
-	for capabilityFlag, binaryPath := range customBinariesPaths {
-		if binaryPath == "" {
-			return fmt.Errorf("binary path for capability %s is empty. Please set the binary path in the capabilities TOML config", capabilityFlag)
+	prepared, err := PrepareBinaries(context.Background(), customBinariesPaths, nil)
+	if err != nil {
+		return err
+	}
+	for _, capabilityFlag := range slices.Sorted(maps.Keys(customBinariesPaths)) {
+		if prepared[capabilityFlag] != customBinariesPaths[capabilityFlag] {
+			return fmt.Errorf("binary %s of capability %s can't be made executable in place, use PrepareBinaries to get its staged copy %s", customBinariesPaths[capabilityFlag], capabilityFlag, prepared[capabilityFlag])
 		}
+	}
 
-		// Check if file exists
-		if _, err := os.Stat(binaryPath); os.IsNotExist(err) {
-			absPath, absErr := filepath.Abs(binaryPath)
-			if absErr != nil {
-				return errors.Wrapf(absErr, "failed to get absolute path for binary %s", binaryPath)
-			}
+	return nil
+}
 
-			return fmt.Errorf("no binary file for capability %s not found at '%s'. Please make sure the path is correct, update it in the capabilities TOML config or copy the binary to the expected location", absPath, capabilityFlag)
-		}
+// PrepareBinaries makes binaries executable after verifying their checksums and signatures set in capability configs
+// and returns paths to use in node specs instead of the given ones, see BinaryPreparer.PreparedPath. Configs can be
+// nil, e.g. for per-node binaries, which are different versions than the DON-wide ones. Binaries are prepared
+// concurrently, use BinaryPreparer to deduplicate them across calls and to report progress.
+func PrepareBinaries(ctx context.Context, customBinariesPaths map[cre.CapabilityFlag]string, capabilityConfigs cre.CapabilityConfigs) (map[cre.CapabilityFlag]string, error) {
+	preparer := NewBinaryPreparer(0, nil)
+	if err := preparer.Prepare(ctx, BinariesToPrepare(customBinariesPaths, capabilityConfigs)); err != nil {
+		return nil, err
+	}
+
+	return preparer.PreparedPaths(customBinariesPaths), nil
+}
 
-		// Make the binary executable
-		if err := os.Chmod(binaryPath, 0755); err != nil {
-			return errors.Wrapf(err, "failed to make binary %s executable for capability %s", binaryPath, capabilityFlag)
+// BinariesToPrepare returns binaries of capabilities with their configs, if capability configs have them
+func BinariesToPrepare(customBinariesPaths map[cre.CapabilityFlag]string, capabilityConfigs cre.CapabilityConfigs) []Binary {
+	binariesToPrepare := make([]Binary, 0, len(customBinariesPaths))
+	for _, capabilityFlag := range slices.Sorted(maps.Keys(customBinariesPaths)) {
+		binary := Binary{Capability: capabilityFlag, Path: customBinariesPaths[capabilityFlag]}
+		if config, ok := capabilityConfigs[capabilityFlag]; ok {
+			binary.Config = &config
 		}
+		binariesToPrepare = append(binariesToPrepare, binary)
 	}
 
-	return nil
+	return binariesToPrepare
 }
 
+// AppendBinariesPathsNodeSpec appends binaries of capabilities to node specs of all workers of the DON.
+//
+// Deprecated: use AppendBinariesPathsNodeSpecWithOverrides, which also accepts programmatic overrides.
 func AppendBinariesPathsNodeSpec(nodeSetInput *cre.CapabilitiesAwareNodeSet, donMetadata *cre.DonMetadata, customBinariesPaths map[cre.CapabilityFlag]string) (*cre.CapabilitiesAwareNodeSet, error) {
-	if len(customBinariesPaths) == 0 {
+	return AppendBinariesPathsNodeSpecWithOverrides(nodeSetInput, donMetadata, customBinariesPaths, nil)
+}
+
+// BinaryPathOverrides make selected workers run different binaries (usually different versions) of capabilities than
+// the DON-wide ones, e.g. to test mixed-version DONs. They are added to overrides set in the nodeset's TOML config.
+type BinaryPathOverrides struct {
+	// ByNodeIndex is keyed by node index and capability flag, it takes precedence over ByLabel
+	ByNodeIndex map[int]map[cre.CapabilityFlag]string
+	// ByLabel is keyed by node label (see CapabilitiesAwareNodeSet.NodeLabels) and capability flag
+	ByLabel map[string]map[cre.CapabilityFlag]string
+}
+
+// AppendBinariesPathsNodeSpecWithOverrides appends binaries of capabilities to node specs of all workers of the DON,
+// using overrides by node index or label where they are set and DON-wide binaries otherwise. It fails, if overrides
+// target nodes which aren't workers or capabilities the DON doesn't host, or if any worker doesn't get exactly one
+// binary of each capability.
+func AppendBinariesPathsNodeSpecWithOverrides(nodeSetInput *cre.CapabilitiesAwareNodeSet, donMetadata *cre.DonMetadata, customBinariesPaths map[cre.CapabilityFlag]string, overrides *BinaryPathOverrides) (*cre.CapabilitiesAwareNodeSet, error) {
+	return AppendBinariesPathsNodeSpecWithRoles(nodeSetInput, donMetadata, customBinariesPaths, nil, overrides)
+}
+
+// AppendBinariesPathsNodeSpecWithRoles works like AppendBinariesPathsNodeSpecWithOverrides, but appends binaries to
+// node specs of nodes with roles set in node_roles of capability configs, e.g. to bootstrap or gateway nodes for
+// capabilities, which depend on the gateway connector. Binaries of capabilities without a config go to workers.
+func AppendBinariesPathsNodeSpecWithRoles(nodeSetInput *cre.CapabilitiesAwareNodeSet, donMetadata *cre.DonMetadata, customBinariesPaths map[cre.CapabilityFlag]string, capabilityConfigs cre.CapabilityConfigs, overrides *BinaryPathOverrides) (*cre.CapabilitiesAwareNodeSet, error) {
+	return AppendBinariesPathsNodeSpecWithPreparer(context.Background(), NewBinaryPreparer(0, nil), nodeSetInput, donMetadata, customBinariesPaths, capabilityConfigs, overrides)
+}
+
+// AppendBinariesPathsNodeSpecWithPreparer works like AppendBinariesPathsNodeSpecWithRoles, but binaries of overrides
+// are prepared by the given preparer, so that binaries shared by nodes and DONs are prepared once and their progress
+// is reported with the others. Remote URLs in overrides are downloaded with the resolver of the preparer (see
+// BinaryPreparer.UseResolver) and overrides, which couldn't be made executable in place, are installed from the staging
+// directory of the preparer.
+func AppendBinariesPathsNodeSpecWithPreparer(ctx context.Context, preparer *BinaryPreparer, nodeSetInput *cre.CapabilitiesAwareNodeSet, donMetadata *cre.DonMetadata, customBinariesPaths map[cre.CapabilityFlag]string, capabilityConfigs cre.CapabilityConfigs, overrides *BinaryPathOverrides) (*cre.CapabilitiesAwareNodeSet, error) {
+	if preparer == nil {
+		return nil, errors.New("binary preparer is nil")
+	}
+
+	return appendBinariesPaths(ctx, nodeSetInput, donMetadata, customBinariesPaths, capabilityConfigs, overrides, preparer)
+}
+
+// appendBinariesPaths appends binaries to node specs, binaries of overrides are made executable only if preparer is set
+func appendBinariesPaths(ctx context.Context, nodeSetInput *cre.CapabilitiesAwareNodeSet, donMetadata *cre.DonMetadata, customBinariesPaths map[cre.CapabilityFlag]string, capabilityConfigs cre.CapabilityConfigs, overrides *BinaryPathOverrides, preparer *BinaryPreparer) (*cre.CapabilitiesAwareNodeSet, error) {
+	if nodeSetInput == nil {
+		return nil, errors.New("nodeset is nil")
+	}
+
+	if overrides != nil {
+		for nodeIdx, nodeBinaries := range overrides.ByNodeIndex {
+			for flag, binaryPath := range nodeBinaries {
+				nodeSetInput.SetNodeCapabilityBinary(nodeIdx, flag, binaryPath)
+			}
+		}
+		for label, labelBinaries := range overrides.ByLabel {
+			if nodeSetInput.LabelCapabilityBinaries == nil {
+				nodeSetInput.LabelCapabilityBinaries = make(map[string]map[cre.CapabilityFlag]string)
+			}
+			if nodeSetInput.LabelCapabilityBinaries[label] == nil {
+				nodeSetInput.LabelCapabilityBinaries[label] = make(map[cre.CapabilityFlag]string)
+			}
+			maps.Copy(nodeSetInput.LabelCapabilityBinaries[label], labelBinaries)
+		}
+	}
+
+	// overrides from TOML are resolved by ResolveBinaries before, programmatic ones only now
+	if overrides != nil && preparer != nil {
+		if err := resolveNodeSetBinaries(ctx, preparer.binaryResolver(), nodeSetInput); err != nil {
+			return nil, err
+		}
+	}
+
+	hasOverrides := len(nodeSetInput.NodeCapabilityBinaries) > 0 || len(nodeSetInput.LabelCapabilityBinaries) > 0
+	if len(customBinariesPaths) == 0 && !hasOverrides {
 		return nodeSetInput, nil
 	}
 
@@ -52,19 +150,65 @@ func AppendBinariesPathsNodeSpec(nodeSetInput *cre.CapabilitiesAwareNodeSet, don
 		}
 	}
 
-	if !hasCapabilitiesBinaries {
-		for capabilityFlag, binaryPath := range customBinariesPaths {
-			if binaryPath == "" {
-				return nil, fmt.Errorf("binary path for capability %s is empty. Make sure you have set the binary path in the TOML config", capabilityFlag)
-			}
+	if hasCapabilitiesBinaries && hasOverrides {
+		return nil, fmt.Errorf("nodeset %s sets both capabilities binary paths in node specs and node capability binaries. Please use only one of them", nodeSetInput.Name)
+	}
+
+	if hasCapabilitiesBinaries {
+		return nodeSetInput, nil
+	}
+
+	targetNodes, targetsErr := capabilityTargetNodes(donMetadata, customBinariesPaths, capabilityConfigs)
+	if targetsErr != nil {
+		return nil, targetsErr
+	}
+	if err := validateBinaryOverrides(nodeSetInput, targetNodes, customBinariesPaths); err != nil {
+		return nil, err
+	}
+
+	for _, capabilityFlag := range slices.Sorted(maps.Keys(customBinariesPaths)) {
+		if customBinariesPaths[capabilityFlag] == "" {
+			return nil, &EmptyBinaryPathError{Capability: capabilityFlag}
+		}
+	}
+
+	// binaries of overrides are prepared at once, DON-wide binaries are prepared by the caller
+	if preparer != nil {
+		if err := preparer.Prepare(ctx, overrideBinaries(nodeSetInput, targetNodes, customBinariesPaths)); err != nil {
+			return nil, errors.Wrapf(err, "failed to make binaries of overrides of nodeset %s executable", nodeSetInput.Name)
+		}
+	}
 
-			workerNodes, wErr := donMetadata.Workers()
-			if wErr != nil {
-				return nil, errors.Wrap(wErr, "failed to find worker nodes")
+	nodeCapabilities := make(map[int][]cre.CapabilityFlag)
+	for _, capabilityFlag := range slices.Sorted(maps.Keys(customBinariesPaths)) {
+		for _, node := range targetNodes[capabilityFlag] {
+			nodeCapabilities[node.Index] = append(nodeCapabilities[node.Index], capabilityFlag)
+		}
+	}
+	for _, nodeIdx := range slices.Sorted(maps.Keys(nodeCapabilities)) {
+		_, endSpan := tracing.StartSpan(ctx, tracing.Span{Stage: BinaryStageNodeSpec, Target: fmt.Sprintf("node %d of nodeset %s", nodeIdx, nodeSetInput.Name)})
+		for _, capabilityFlag := range nodeCapabilities[nodeIdx] {
+			binaryPath := customBinariesPaths[capabilityFlag]
+			nodeBinaryPath := nodeSetInput.NodeCapabilityBinaryPath(nodeIdx, capabilityFlag, binaryPath)
+			if preparer != nil && nodeBinaryPath != binaryPath {
+				nodeBinaryPath = preparer.PreparedPath(nodeBinaryPath)
 			}
+			nodeSetInput.NodeSpecs[nodeIdx].Node.CapabilitiesBinaryPaths = append(nodeSetInput.NodeSpecs[nodeIdx].Node.CapabilitiesBinaryPaths, nodeBinaryPath)
+		}
+		endSpan(nil)
+	}
 
-			for _, workerNode := range workerNodes {
-				nodeSetInput.NodeSpecs[workerNode.Index].Node.CapabilitiesBinaryPaths = append(nodeSetInput.NodeSpecs[workerNode.Index].Node.CapabilitiesBinaryPaths, binaryPath)
+	// each node must get exactly one binary of each of its capabilities, job specs reference them by file name
+	for capabilityFlag, binaryPath := range customBinariesPaths {
+		for _, node := range targetNodes[capabilityFlag] {
+			count := 0
+			for _, nodeBinaryPath := range nodeSetInput.NodeSpecs[node.Index].Node.CapabilitiesBinaryPaths {
+				if binaries.Name(nodeBinaryPath) == binaries.Name(binaryPath) {
+					count++
+				}
+			}
+			if count != 1 {
+				return nil, fmt.Errorf("node %d in nodeset %s has %d binaries of capability %s, expected exactly one", node.Index, nodeSetInput.Name, count, capabilityFlag)
 			}
 		}
 	}
@@ -72,15 +216,94 @@ func AppendBinariesPathsNodeSpec(nodeSetInput *cre.CapabilitiesAwareNodeSet, don
 	return nodeSetInput, nil
 }
 
+// BinaryCapability returns the capability, whose config has a binary with the same file name, empty if there's none.
+// Binaries of other versions of a capability have the same name, see BinaryPathOverrides.
+func BinaryCapability(binaryPath string, capabilityConfigs cre.CapabilityConfigs) cre.CapabilityFlag {
+	name := binaries.Name(binaryPath)
+	for _, flag := range slices.Sorted(maps.Keys(capabilityConfigs)) {
+		if capabilityConfigs[flag].BinaryName() == name {
+			return flag
+		}
+	}
+
+	return ""
+}
+
+// overrideBinaries returns binaries, which override DON-wide binaries on target nodes, each of them once
+func overrideBinaries(nodeSetInput *cre.CapabilitiesAwareNodeSet, targetNodes map[cre.CapabilityFlag][]*cre.NodeMetadata, customBinariesPaths map[cre.CapabilityFlag]string) []Binary {
+	var overrides []Binary
+	seen := make(map[string]struct{})
+	for _, capabilityFlag := range slices.Sorted(maps.Keys(customBinariesPaths)) {
+		for _, node := range targetNodes[capabilityFlag] {
+			nodeBinaryPath := nodeSetInput.NodeCapabilityBinaryPath(node.Index, capabilityFlag, customBinariesPaths[capabilityFlag])
+			if _, ok := seen[nodeBinaryPath]; ok || nodeBinaryPath == customBinariesPaths[capabilityFlag] {
+				continue
+			}
+			seen[nodeBinaryPath] = struct{}{}
+			overrides = append(overrides, Binary{Capability: capabilityFlag, Path: nodeBinaryPath})
+		}
+	}
+
+	return overrides
+}
+
+// capabilityTargetNodes returns nodes, which get binaries of each capability, based on node roles of capability configs
+func capabilityTargetNodes(donMetadata *cre.DonMetadata, customBinariesPaths map[cre.CapabilityFlag]string, capabilityConfigs cre.CapabilityConfigs) (map[cre.CapabilityFlag][]*cre.NodeMetadata, error) {
+	targetNodes := make(map[cre.CapabilityFlag][]*cre.NodeMetadata, len(customBinariesPaths))
+	for capabilityFlag := range customBinariesPaths {
+		nodeTypes := []cre.NodeType{cre.WorkerNode}
+		if config, ok := capabilityConfigs[capabilityFlag]; ok {
+			nodeTypes = config.NodeTypes()
+		}
+
+		nodes := donMetadata.NodesWithTypes(nodeTypes...)
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("capability %s is installed on nodes with types %s, but DON %s has none", capabilityFlag, strings.Join(nodeTypes, ", "), donMetadata.Name)
+		}
+		targetNodes[capabilityFlag] = nodes
+	}
+
+	return targetNodes, nil
+}
+
+// validateBinaryOverrides checks that overrides target only capabilities hosted by the DON and nodes, which get them
+func validateBinaryOverrides(nodeSetInput *cre.CapabilitiesAwareNodeSet, targetNodes map[cre.CapabilityFlag][]*cre.NodeMetadata, customBinariesPaths map[cre.CapabilityFlag]string) error {
+	isTarget := func(flag cre.CapabilityFlag) func(int) bool {
+		return func(nodeIdx int) bool {
+			return slices.ContainsFunc(targetNodes[flag], func(n *cre.NodeMetadata) bool { return n.Index == nodeIdx })
+		}
+	}
+
+	for idx, nodeBinaries := range nodeSetInput.NodeCapabilityBinaries {
+		for flag := range nodeBinaries {
+			if _, ok := customBinariesPaths[flag]; !ok {
+				return fmt.Errorf("node %d in nodeset %s overrides binary of capability %s, which isn't hosted by the DON or has no binary", idx, nodeSetInput.Name, flag)
+			}
+			if !isTarget(flag)(idx) {
+				return fmt.Errorf("node %d in nodeset %s overrides binary of capability %s, which isn't installed on it. Set node_roles of the capability to install it on other than worker nodes", idx, nodeSetInput.Name, flag)
+			}
+		}
+	}
+
+	for label, labelBinaries := range nodeSetInput.LabelCapabilityBinaries {
+		for flag := range labelBinaries {
+			if _, ok := customBinariesPaths[flag]; !ok {
+				return fmt.Errorf("label '%s' in nodeset %s overrides binary of capability %s, which isn't hosted by the DON or has no binary", label, nodeSetInput.Name, flag)
+			}
+			if !slices.ContainsFunc(nodeSetInput.NodesWithLabel(label), isTarget(flag)) {
+				return fmt.Errorf("label '%s' in nodeset %s overrides binary of capability %s, but no node with the label gets it", label, nodeSetInput.Name, flag)
+			}
+		}
+	}
+
+	return nil
+}
+
 func DefaultContainerDirectory(infraType infra.Type) (string, error) {
-	switch infraType {
-	case infra.CRIB:
-		// chainlink user will always have access to this directory
-		return "/home/chainlink", nil
-	case infra.Docker:
-		// needs to match what CTFv2 uses by default, we should define a constant there and import it here
-		return clnode.DefaultCapabilitiesDir, nil
-	default:
-		return "", fmt.Errorf("unknown infra type: %s", infraType)
+	containerDir, ok := infra.CapabilitiesContainerDir(infraType)
+	if !ok {
+		return "", &UnsupportedInfraError{Type: infraType}
 	}
+
+	return containerDir, nil
 }
@@ -0,0 +1,237 @@
+package capabilities
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// mixedRoleNodeSet has a bootstrap node (0), a gateway node (1) and two other workers (2, 3)
+func mixedRoleNodeSet(t *testing.T) (*cre.CapabilitiesAwareNodeSet, *cre.DonMetadata) {
+	t.Helper()
+
+	nodeSet := &cre.CapabilitiesAwareNodeSet{
+		Input:              &ns.Input{Name: "workflow"},
+		Capabilities:       []string{cre.CronCapability},
+		DONTypes:           []string{cre.WorkflowDON, cre.GatewayDON},
+		BootstrapNodeIndex: 0,
+		GatewayNodeIndex:   1,
+	}
+	for range 4 {
+		nodeSet.NodeSpecs = append(nodeSet.NodeSpecs, &clnode.Input{Node: &clnode.NodeInput{}})
+	}
+	nodeSet.Nodes = len(nodeSet.NodeSpecs)
+
+	donMetadata, err := cre.NewDonMetadata(nodeSet, 1, infra.Provider{Type: infra.Docker})
+	require.NoError(t, err)
+
+	return nodeSet, donMetadata
+}
+
+func nodeBinaries(nodeSet *cre.CapabilitiesAwareNodeSet) [][]string {
+	out := make([][]string, 0, len(nodeSet.NodeSpecs))
+	for _, nodeSpec := range nodeSet.NodeSpecs {
+		out = append(out, nodeSpec.Node.CapabilitiesBinaryPaths)
+	}
+
+	return out
+}
+
+func TestAppendBinariesPathsNodeSpecWithRoles(t *testing.T) {
+	binariesPaths := map[cre.CapabilityFlag]string{
+		cre.CronCapability:         "./binaries/cron",
+		cre.HTTPActionCapability:   "./binaries/http_action",
+		cre.WebAPITargetCapability: "./binaries/web_api",
+	}
+
+	t.Run("capabilities without node roles go to workers", func(t *testing.T) {
+		nodeSet, donMetadata := mixedRoleNodeSet(t)
+
+		_, err := AppendBinariesPathsNodeSpecWithRoles(nodeSet, donMetadata, map[cre.CapabilityFlag]string{cre.CronCapability: "./binaries/cron"}, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{nil, {"./binaries/cron"}, {"./binaries/cron"}, {"./binaries/cron"}}, nodeBinaries(nodeSet))
+	})
+
+	t.Run("capabilities go to nodes of their roles", func(t *testing.T) {
+		nodeSet, donMetadata := mixedRoleNodeSet(t)
+		capabilityConfigs := cre.CapabilityConfigs{
+			cre.CronCapability:         {BinaryPath: "./binaries/cron"},
+			cre.HTTPActionCapability:   {BinaryPath: "./binaries/http_action", NodeRoles: []string{cre.TemplateRoleGateway}},
+			cre.WebAPITargetCapability: {BinaryPath: "./binaries/web_api", NodeRoles: []string{cre.TemplateRoleBootstrap, cre.TemplateRoleWorker}},
+		}
+
+		_, err := AppendBinariesPathsNodeSpecWithRoles(nodeSet, donMetadata, binariesPaths, capabilityConfigs, nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"./binaries/web_api"},
+			{"./binaries/cron", "./binaries/http_action", "./binaries/web_api"},
+			{"./binaries/cron", "./binaries/web_api"},
+			{"./binaries/cron", "./binaries/web_api"},
+		}, nodeBinaries(nodeSet))
+
+		require.NoError(t, ValidateNodeSet(nodeSet, infra.Docker, capabilityConfigs))
+	})
+
+	t.Run("bootstrap binaries without the bootstrap role are invalid", func(t *testing.T) {
+		nodeSet, _ := mixedRoleNodeSet(t)
+		nodeSet.NodeSpecs[0].Node.CapabilitiesBinaryPaths = []string{"./binaries/cron"}
+
+		err := ValidateNodeSet(nodeSet, infra.Docker, cre.CapabilityConfigs{cre.CronCapability: {BinaryPath: "./binaries/cron"}})
+		require.ErrorContains(t, err, "bootstrap node 0 has capability binaries ./binaries/cron")
+	})
+
+	t.Run("overrides must target nodes, which get the capability", func(t *testing.T) {
+		nodeSet, donMetadata := mixedRoleNodeSet(t)
+		capabilityConfigs := cre.CapabilityConfigs{
+			cre.HTTPActionCapability: {BinaryPath: "./binaries/http_action", NodeRoles: []string{cre.TemplateRoleGateway}},
+		}
+		overrides := &BinaryPathOverrides{ByNodeIndex: map[int]map[cre.CapabilityFlag]string{2: {cre.HTTPActionCapability: "./v2/http_action"}}}
+
+		_, err := AppendBinariesPathsNodeSpecWithRoles(nodeSet, donMetadata, map[cre.CapabilityFlag]string{cre.HTTPActionCapability: "./binaries/http_action"}, capabilityConfigs, overrides)
+		require.ErrorContains(t, err, "isn't installed on it")
+	})
+
+	t.Run("overrides are prepared once by the shared preparer", func(t *testing.T) {
+		nodeSet, donMetadata := mixedRoleNodeSet(t)
+		v2 := filepath.Join(t.TempDir(), "cron")
+		require.NoError(t, os.WriteFile(v2, []byte("v2"), 0o600))
+		overrides := &BinaryPathOverrides{ByNodeIndex: map[int]map[cre.CapabilityFlag]string{2: {cre.CronCapability: v2}, 3: {cre.CronCapability: v2}}}
+
+		var prepared []string
+		preparer := NewBinaryPreparer(0, func(progress BinaryProgress) {
+			if progress.Finished {
+				prepared = append(prepared, progress.Path)
+			}
+		})
+		_, err := AppendBinariesPathsNodeSpecWithPreparer(context.Background(), preparer, nodeSet, donMetadata, map[cre.CapabilityFlag]string{cre.CronCapability: "./binaries/cron"}, nil, overrides)
+		require.NoError(t, err)
+		assert.Equal(t, []string{v2}, prepared)
+		assert.Equal(t, [][]string{nil, {"./binaries/cron"}, {v2}, {v2}}, nodeBinaries(nodeSet))
+	})
+
+	t.Run("DON without nodes of the role fails", func(t *testing.T) {
+		nodeSet, _ := mixedRoleNodeSet(t)
+		nodeSet.BootstrapNodeIndex = -1
+		donMetadata, err := cre.NewDonMetadata(nodeSet, 1, infra.Provider{Type: infra.Docker})
+		require.NoError(t, err)
+		capabilityConfigs := cre.CapabilityConfigs{
+			cre.CronCapability: {BinaryPath: "./binaries/cron", NodeRoles: []string{cre.TemplateRoleBootstrap}},
+		}
+
+		_, err = AppendBinariesPathsNodeSpecWithRoles(nodeSet, donMetadata, map[cre.CapabilityFlag]string{cre.CronCapability: "./binaries/cron"}, capabilityConfigs, nil)
+		require.ErrorContains(t, err, "has none")
+	})
+}
+
+func TestConfigurationErrors(t *testing.T) {
+	nodeSet, donMetadata := mixedRoleNodeSet(t)
+	_, err := AppendBinariesPathsNodeSpec(nodeSet, donMetadata, map[cre.CapabilityFlag]string{cre.CronCapability: ""})
+	require.ErrorIs(t, err, ErrEmptyBinaryPath)
+	var emptyPathErr *EmptyBinaryPathError
+	require.ErrorAs(t, err, &emptyPathErr)
+	assert.Equal(t, cre.CronCapability, emptyPathErr.Capability)
+
+	missingPath := filepath.Join(t.TempDir(), "cron")
+	_, err = PrepareBinaries(context.Background(), map[cre.CapabilityFlag]string{cre.CronCapability: missingPath}, nil)
+	require.ErrorIs(t, err, ErrBinaryNotFound)
+	var notFoundErr *BinaryNotFoundError
+	require.ErrorAs(t, err, &notFoundErr)
+	assert.Equal(t, BinaryNotFoundError{Capability: cre.CronCapability, Path: missingPath}, *notFoundErr)
+	assert.False(t, errors.Is(err, ErrEmptyBinaryPath))
+	require.ErrorIs(t, MakeBinariesExecutable(map[cre.CapabilityFlag]string{cre.CronCapability: missingPath}), ErrBinaryNotFound)
+
+	_, err = DefaultContainerDirectory("bare-metal")
+	require.ErrorIs(t, err, ErrUnsupportedInfra)
+	var infraErr *UnsupportedInfraError
+	require.ErrorAs(t, err, &infraErr)
+	assert.Equal(t, infra.Type("bare-metal"), infraErr.Type)
+}
+
+func writeCronBinary(t *testing.T, version string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), version, "cron")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(version), 0o600))
+
+	return path
+}
+
+func TestAppendBinariesPathsNodeSpecWithLabelOverrides(t *testing.T) {
+	donBinaries := map[cre.CapabilityFlag]string{cre.CronCapability: "./binaries/cron"}
+
+	t.Run("programmatic overrides are merged with the ones from TOML", func(t *testing.T) {
+		nodeSet, donMetadata := mixedRoleNodeSet(t)
+		canary, stable, pinned := writeCronBinary(t, "canary"), writeCronBinary(t, "stable"), writeCronBinary(t, "pinned")
+		nodeSet.NodeLabels = map[int][]string{1: {"canary"}, 2: {"canary"}, 3: {"stable"}}
+		nodeSet.LabelCapabilityBinaries = map[string]map[cre.CapabilityFlag]string{"canary": {cre.CronCapability: canary}}
+		overrides := &BinaryPathOverrides{
+			ByLabel:     map[string]map[cre.CapabilityFlag]string{"stable": {cre.CronCapability: stable}},
+			ByNodeIndex: map[int]map[cre.CapabilityFlag]string{2: {cre.CronCapability: pinned}},
+		}
+
+		_, err := AppendBinariesPathsNodeSpecWithOverrides(nodeSet, donMetadata, donBinaries, overrides)
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{nil, {canary}, {pinned}, {stable}}, nodeBinaries(nodeSet), "overrides by node index must take precedence over the ones by label")
+	})
+
+	t.Run("label overrides must target nodes, which get the capability", func(t *testing.T) {
+		nodeSet, donMetadata := mixedRoleNodeSet(t)
+		nodeSet.NodeLabels = map[int][]string{0: {"bootstrap"}}
+		overrides := &BinaryPathOverrides{ByLabel: map[string]map[cre.CapabilityFlag]string{"bootstrap": {cre.CronCapability: writeCronBinary(t, "v2")}}}
+
+		_, err := AppendBinariesPathsNodeSpecWithOverrides(nodeSet, donMetadata, donBinaries, overrides)
+		require.ErrorContains(t, err, "no node with the label gets it")
+	})
+
+	t.Run("label overrides must target capabilities of the DON", func(t *testing.T) {
+		nodeSet, donMetadata := mixedRoleNodeSet(t)
+		nodeSet.NodeLabels = map[int][]string{2: {"canary"}}
+		overrides := &BinaryPathOverrides{ByLabel: map[string]map[cre.CapabilityFlag]string{"canary": {cre.HTTPActionCapability: "./v2/http_action"}}}
+
+		_, err := AppendBinariesPathsNodeSpecWithOverrides(nodeSet, donMetadata, donBinaries, overrides)
+		require.ErrorContains(t, err, "isn't hosted by the DON")
+	})
+
+	t.Run("remote URLs of programmatic overrides are resolved", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("binary of " + r.URL.Path))
+		}))
+		defer server.Close()
+		resolver := binaries.NewResolver(t.TempDir())
+		resolver.HTTPClient = server.Client()
+		preparer := NewBinaryPreparer(0, nil)
+		preparer.UseResolver(resolver)
+
+		nodeSet, donMetadata := mixedRoleNodeSet(t)
+		nodeSet.NodeLabels = map[int][]string{2: {"canary"}}
+		overrides := &BinaryPathOverrides{
+			ByLabel:     map[string]map[cre.CapabilityFlag]string{"canary": {cre.CronCapability: server.URL + "/canary/cron"}},
+			ByNodeIndex: map[int]map[cre.CapabilityFlag]string{3: {cre.CronCapability: server.URL + "/pinned/cron"}},
+		}
+
+		_, err := AppendBinariesPathsNodeSpecWithPreparer(context.Background(), preparer, nodeSet, donMetadata, donBinaries, nil, overrides)
+		require.NoError(t, err)
+		canary, pinned := nodeSet.LabelCapabilityBinaries["canary"][cre.CronCapability], nodeSet.NodeCapabilityBinaries[3][cre.CronCapability]
+		assert.False(t, binaries.IsRemote(canary))
+		assert.False(t, binaries.IsRemote(pinned))
+		assert.Equal(t, [][]string{nil, {"./binaries/cron"}, {canary}, {pinned}}, nodeBinaries(nodeSet))
+		content, readErr := os.ReadFile(canary)
+		require.NoError(t, readErr)
+		assert.Equal(t, "binary of /canary/cron", string(content))
+	})
+}
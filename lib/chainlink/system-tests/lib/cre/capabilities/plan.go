@@ -0,0 +1,183 @@
+package capabilities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/flags"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// binaryMode is the mode binaries get on the host, see prepareBinary
+const binaryMode = "0755"
+
+// BinaryPlacement is a capability binary, which the setup would copy to a node
+type BinaryPlacement struct {
+	NodeSet       string `json:"nodeset"`
+	NodeIndex     int    `json:"node_index"`
+	NodeAlias     string `json:"node_alias"`
+	Capability    string `json:"capability,omitempty"` // empty for binaries set in node specs of the TOML config
+	HostPath      string `json:"host_path"`
+	ContainerPath string `json:"container_path"`
+	Mode          string `json:"mode"`
+	Owner         string `json:"owner"`
+	// Override is set for binaries of node_capability_binaries or label_capability_binaries, which replace DON-wide ones
+	Override bool `json:"override,omitempty"`
+	// Missing is set, if there's no file at HostPath yet, e.g. because it's fetched or built during the setup
+	Missing bool `json:"missing,omitempty"`
+}
+
+// BinariesPlan describes what setup would do with capability binaries of all DONs, see PlanBinaries
+type BinariesPlan struct {
+	Placements []BinaryPlacement `json:"placements"`
+	// Problems are errors the setup would fail with, by nodeset
+	Problems map[string]string `json:"problems,omitempty"`
+}
+
+func (p *BinariesPlan) JSON() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// Table returns placements as a human-readable table, followed by problems
+func (p *BinariesPlan) Table() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODESET\tNODE\tCAPABILITY\tHOST PATH\tCONTAINER PATH\tMODE\tOWNER\tNOTES")
+	for _, placement := range p.Placements {
+		var notes []string
+		if placement.Override {
+			notes = append(notes, "override")
+		}
+		if placement.Missing {
+			notes = append(notes, "missing on host")
+		}
+		capability := placement.Capability
+		if capability == "" {
+			capability = "(node spec)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", placement.NodeSet, placement.NodeAlias, capability, placement.HostPath, placement.ContainerPath, placement.Mode, placement.Owner, strings.Join(notes, ", "))
+	}
+	_ = w.Flush()
+
+	for _, nodeSet := range slices.Sorted(maps.Keys(p.Problems)) {
+		fmt.Fprintf(&sb, "\nnodeset %s: %s\n", nodeSet, p.Problems[nodeSet])
+	}
+
+	return sb.String()
+}
+
+// DONBinariesPaths returns binary paths of capabilities enabled on the DON, which have a binary
+func DONBinariesPaths(donFlags []string, capabilityConfigs cre.CapabilityConfigs) map[cre.CapabilityFlag]string {
+	customBinariesPaths := make(map[cre.CapabilityFlag]string)
+	for flag, config := range capabilityConfigs {
+		if flags.HasFlagForAnyChain(donFlags, flag) && config.BinaryPath != "" {
+			customBinariesPaths[flag] = config.BinaryPath
+		}
+	}
+
+	return customBinariesPaths
+}
+
+// PlanBinaries reports which binaries the setup would append to node specs of each nodeset with
+// AppendBinariesPathsNodeSpecWithRoles, where they would be copied to and with which mode and owner, without changing
+// nodesets or binaries. donsMetadata must be in the order of nodesets, as in cre.Topology. Nodesets the setup would
+// reject are reported in Problems, the error is returned only for invalid arguments.
+func PlanBinaries(nodeSets []*cre.CapabilitiesAwareNodeSet, donsMetadata []*cre.DonMetadata, capabilityConfigs cre.CapabilityConfigs, infraType infra.Type) (*BinariesPlan, error) {
+	if len(nodeSets) != len(donsMetadata) {
+		return nil, fmt.Errorf("got %d nodesets, but metadata of %d DONs", len(nodeSets), len(donsMetadata))
+	}
+	defaultContainerDir, dirErr := DefaultContainerDirectory(infraType)
+	if dirErr != nil {
+		return nil, dirErr
+	}
+
+	plan := &BinariesPlan{Problems: make(map[string]string)}
+	for idx, nodeSet := range nodeSets {
+		donMetadata := donsMetadata[idx]
+		customBinariesPaths := DONBinariesPaths(donMetadata.Flags, capabilityConfigs)
+
+		// dry runs aren't traced, the context has no tracer
+		planned, appendErr := appendBinariesPaths(context.Background(), nodeSet.Clone(), donMetadata, customBinariesPaths, capabilityConfigs, nil, nil)
+		if appendErr != nil {
+			plan.Problems[nodeSet.Name] = appendErr.Error()
+			continue
+		}
+		if err := ValidateNodeSet(planned, infraType, capabilityConfigs); err != nil {
+			plan.Problems[nodeSet.Name] = err.Error()
+		}
+
+		owner, ownerErr := plannedOwner(nodeSet, infraType)
+		if ownerErr != nil {
+			return nil, ownerErr
+		}
+		for nodeIdx, nodeSpec := range planned.NodeSpecs {
+			containerDir := defaultContainerDir
+			if dir := nodeSpec.Node.CapabilityContainerDir; dir != "" {
+				containerDir = strings.TrimSuffix(dir, "/")
+			}
+			fromTOML := len(nodeSet.NodeSpecs[nodeIdx].Node.CapabilitiesBinaryPaths)
+			for pathIdx, binaryPath := range nodeSpec.Node.CapabilitiesBinaryPaths {
+				placement := BinaryPlacement{
+					NodeSet:       nodeSet.Name,
+					NodeIndex:     nodeIdx,
+					NodeAlias:     donMetadata.NodesMetadata[nodeIdx].Alias,
+					HostPath:      binaryPath,
+					ContainerPath: path.Join(containerDir, binaries.Name(binaryPath)),
+					Mode:          binaryMode,
+					Owner:         owner,
+				}
+				if pathIdx >= fromTOML {
+					placement.Capability = capabilityOfBinary(customBinariesPaths, binaryPath)
+					placement.Override = binaryPath != customBinariesPaths[placement.Capability]
+				}
+				if _, statErr := os.Stat(binaryPath); statErr != nil {
+					placement.Missing = true
+				}
+				plan.Placements = append(plan.Placements, placement)
+			}
+		}
+	}
+	if len(plan.Problems) == 0 {
+		plan.Problems = nil
+	}
+
+	return plan, nil
+}
+
+// plannedOwner describes who owns binaries in nodes: the configured owner, the user of the node in Kubernetes (see
+// infra.CopyFileToKubernetesPod) or root, as CTF copies them
+func plannedOwner(nodeSet *cre.CapabilitiesAwareNodeSet, infraType infra.Type) (string, error) {
+	uid, gid, ok, err := nodeSet.CapabilityBinariesOwnerIDs()
+	switch {
+	case err != nil:
+		return "", errors.Wrap(err, "invalid capability binaries owner")
+	case infraType == infra.Kubernetes:
+		return "node user", nil
+	case ok:
+		return fmt.Sprintf("%d:%d", uid, gid), nil
+	default:
+		return "root", nil
+	}
+}
+
+// capabilityOfBinary finds the capability by the binary's name, overrides have different paths but the same name
+func capabilityOfBinary(customBinariesPaths map[cre.CapabilityFlag]string, binaryPath string) cre.CapabilityFlag {
+	for _, flag := range slices.Sorted(maps.Keys(customBinariesPaths)) {
+		if binaries.Name(customBinariesPaths[flag]) == binaries.Name(binaryPath) {
+			return flag
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,66 @@
+package capabilities
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+func TestPlanBinaries(t *testing.T) {
+	cronPath := filepath.Join(t.TempDir(), "cron")
+	require.NoError(t, os.WriteFile(cronPath, []byte("binary"), 0o600))
+	overridePath := filepath.Join(t.TempDir(), "v2", "cron")
+
+	nodeSet, donMetadata := mixedRoleNodeSet(t)
+	nodeSet.SetNodeCapabilityBinary(3, cre.CronCapability, overridePath)
+	capabilityConfigs := cre.CapabilityConfigs{cre.CronCapability: {BinaryPath: cronPath}}
+
+	plan, err := PlanBinaries([]*cre.CapabilitiesAwareNodeSet{nodeSet}, []*cre.DonMetadata{donMetadata}, capabilityConfigs, infra.Docker)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Problems)
+	assert.Equal(t, [][]string{nil, nil, nil, nil}, nodeBinaries(nodeSet), "planning must not change node specs")
+
+	require.Len(t, plan.Placements, 3)
+	for _, placement := range plan.Placements {
+		assert.Equal(t, "workflow", placement.NodeSet)
+		assert.Equal(t, cre.CronCapability, placement.Capability)
+		assert.Equal(t, clnode.DefaultCapabilitiesDir+"/cron", placement.ContainerPath)
+		assert.Equal(t, "0755", placement.Mode)
+		assert.Equal(t, "root", placement.Owner)
+	}
+	assert.Equal(t, []int{1, 2, 3}, []int{plan.Placements[0].NodeIndex, plan.Placements[1].NodeIndex, plan.Placements[2].NodeIndex})
+	assert.False(t, plan.Placements[1].Override)
+	assert.False(t, plan.Placements[1].Missing)
+	assert.True(t, plan.Placements[2].Override)
+	assert.True(t, plan.Placements[2].Missing)
+	assert.Equal(t, overridePath, plan.Placements[2].HostPath)
+
+	out, jsonErr := plan.JSON()
+	require.NoError(t, jsonErr)
+	var decoded BinariesPlan
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, plan.Placements, decoded.Placements)
+
+	assert.Contains(t, plan.Table(), "override, missing on host")
+}
+
+func TestPlanBinariesReportsProblems(t *testing.T) {
+	nodeSet, donMetadata := mixedRoleNodeSet(t)
+	nodeSet.SetNodeCapabilityBinary(0, cre.CronCapability, "./binaries/v2/cron")
+	capabilityConfigs := cre.CapabilityConfigs{cre.CronCapability: {BinaryPath: "./binaries/cron"}}
+
+	plan, err := PlanBinaries([]*cre.CapabilitiesAwareNodeSet{nodeSet}, []*cre.DonMetadata{donMetadata}, capabilityConfigs, infra.Docker)
+	require.NoError(t, err)
+	assert.Contains(t, plan.Problems["workflow"], "isn't installed on it")
+	assert.Empty(t, plan.Placements)
+	assert.Contains(t, plan.Table(), "nodeset workflow:")
+}
@@ -0,0 +1,67 @@
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// VerifyBinariesPlatform checks that capability binaries (including per-node and per-label overrides) are built for
+// the platform of containers of nodes they are copied to. Binaries built on the host, e.g. darwin/arm64 ones on a Mac,
+// would otherwise fail to execute only once nodes start. Binary paths are expected to be resolved, see ResolveBinaries.
+func VerifyBinariesPlatform(ctx context.Context, provider infra.Provider, capabilityConfigs cre.CapabilityConfigs, nodeSets []*cre.CapabilitiesAwareNodeSet) error {
+	images := make([]string, 0)
+	for _, nodeSet := range nodeSets {
+		for _, nodeSpec := range nodeSet.NodeSpecs {
+			images = append(images, nodeImage(nodeSpec.Node.Image, nodeSpec.Node.DockerContext))
+		}
+	}
+	platforms, platformsErr := infra.ContainerPlatforms(ctx, provider, images)
+	if platformsErr != nil {
+		return errors.Wrap(platformsErr, "failed to get platforms of node containers")
+	}
+
+	flags := slices.Sorted(maps.Keys(capabilityConfigs))
+	checked := make(map[string]bool)
+	for _, nodeSet := range nodeSets {
+		for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+			target := platforms[nodeImage(nodeSpec.Node.Image, nodeSpec.Node.DockerContext)]
+
+			binaryPaths := slices.Clone(nodeSpec.Node.CapabilitiesBinaryPaths)
+			for _, flag := range flags {
+				if defaultPath := capabilityConfigs[flag].BinaryPath; defaultPath != "" {
+					binaryPaths = append(binaryPaths, nodeSet.NodeCapabilityBinaryPath(nodeIdx, flag, defaultPath))
+				}
+			}
+
+			for _, binaryPath := range binaryPaths {
+				key := binaryPath + "@" + target
+				if checked[key] || binaries.IsRemote(binaryPath) {
+					continue
+				}
+				if err := binaries.CheckPlatform(binaryPath, target); err != nil {
+					return fmt.Errorf("capability binary for node %d of nodeset %s can't run in its container: %w", nodeIdx, nodeSet.Name, err)
+				}
+				checked[key] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// nodeImage returns the image of the node, images built from a Docker context are identified by an empty string
+func nodeImage(image, dockerContext string) string {
+	if dockerContext != "" {
+		return ""
+	}
+
+	return image
+}
@@ -0,0 +1,345 @@
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/experimental/tracing"
+)
+
+// DefaultMaxConcurrentBinaries is the default number of binaries a BinaryPreparer prepares or copies at once
+const DefaultMaxConcurrentBinaries = 4
+
+type BinaryStage = string
+
+const (
+	BinaryStagePrepare BinaryStage = "prepare" // checksum and signature verification and chmod (or staging) on the host
+	BinaryStageCopy    BinaryStage = "copy"    // copy to a node container or pod
+)
+
+// BinaryProgress is emitted when preparation or copy of a binary starts and when it finishes. Done and Total count
+// binaries of the current Prepare or Copy call.
+type BinaryProgress struct {
+	Stage      BinaryStage
+	Capability cre.CapabilityFlag // empty for copies of binaries without a capability
+	Path       string
+	Target     string // copy destination, e.g. "node 1 of nodeset workflow", empty for preparation
+	Finished   bool
+	Done       int
+	Total      int
+	Duration   time.Duration // set once finished
+	Err        error
+	Cached     bool // set, if the copy was skipped, because the destination already had the binary, see BinaryCache
+}
+
+// LogBinaryProgress returns a progress function, which logs events with structured fields, so that setups copying many
+// large binaries show what they are waiting for
+func LogBinaryProgress(lggr zerolog.Logger) func(BinaryProgress) {
+	return func(p BinaryProgress) {
+		event := lggr.Info()
+		if p.Err != nil {
+			event = lggr.Error().Err(p.Err)
+		}
+		event = event.Str("stage", p.Stage).Str("path", p.Path).Int("done", p.Done).Int("total", p.Total)
+		if p.Capability != "" {
+			event = event.Str("capability", p.Capability)
+		}
+		if p.Target != "" {
+			event = event.Str("target", p.Target)
+		}
+
+		switch {
+		case !p.Finished:
+			event.Msgf("Binary %s: %s started", p.Stage, filepath.Base(p.Path))
+		case p.Err != nil:
+			event.Dur("duration", p.Duration).Msgf("Binary %s: %s failed", p.Stage, filepath.Base(p.Path))
+		case p.Cached:
+			event.Dur("duration", p.Duration).Msgf("Binary %s: %s skipped, %s already has it (%d/%d)", p.Stage, filepath.Base(p.Path), p.Target, p.Done, p.Total)
+		default:
+			event.Dur("duration", p.Duration).Msgf("Binary %s: %s finished (%d/%d)", p.Stage, filepath.Base(p.Path), p.Done, p.Total)
+		}
+	}
+}
+
+// Binary is a capability binary to prepare. Config is used to verify it, it can be nil, e.g. for per-node binaries,
+// which are different versions than the DON-wide ones.
+type Binary struct {
+	Capability cre.CapabilityFlag
+	Path       string
+	Config     *cre.CapabilityConfig
+}
+
+// BinaryCopy copies a binary to a single destination, e.g. with infra.CopyFileToDockerContainer
+type BinaryCopy struct {
+	Path       string
+	Capability cre.CapabilityFlag // optional, only reported to progress and tracers
+	Target     string
+	Copy       func(ctx context.Context) error
+	// RemoteDigest is optional, it returns the SHA-256 digest of the binary at the destination (empty if there's none),
+	// so that the copy can be skipped by BinaryCache, e.g. with infra.FileDigest
+	RemoteDigest func(ctx context.Context) (string, error)
+}
+
+// BinaryPreparer prepares and copies capability binaries with a bounded number of workers. The bound is shared by all
+// Prepare and Copy calls, so that DONs started at once don't multiply it. Binaries are prepared only once per
+// preparer, even if they are used by many DONs and nodes or if Prepare is called from many goroutines at once.
+type BinaryPreparer struct {
+	maxConcurrentBinaries int
+	slots                 chan struct{} // taken by each preparation or copy, while it runs
+	progress              func(BinaryProgress)
+	cache                 *BinaryCache
+	resolver              *binaries.Resolver
+	stagingDir            string
+
+	mu       sync.Mutex
+	prepared map[string]*preparation
+	staged   map[string]string // absolute paths of binaries, which couldn't be made executable in place, to their copies
+}
+
+type preparation struct {
+	done chan struct{}
+	err  error
+}
+
+// NewBinaryPreparer returns a preparer, which prepares or copies at most maxConcurrentBinaries binaries at once across
+// all its calls, DefaultMaxConcurrentBinaries is used if it's not positive. Progress is optional, see
+// LogBinaryProgress.
+func NewBinaryPreparer(maxConcurrentBinaries int, progress func(BinaryProgress)) *BinaryPreparer {
+	if maxConcurrentBinaries <= 0 {
+		maxConcurrentBinaries = DefaultMaxConcurrentBinaries
+	}
+	if progress == nil {
+		progress = func(BinaryProgress) {}
+	}
+
+	return &BinaryPreparer{
+		maxConcurrentBinaries: maxConcurrentBinaries,
+		slots:                 make(chan struct{}, maxConcurrentBinaries),
+		progress:              progress,
+		stagingDir:            DefaultBinaryStagingDir(),
+		prepared:              make(map[string]*preparation),
+		staged:                make(map[string]string),
+	}
+}
+
+// acquire waits for a free slot of the preparer, see release
+func (p *BinaryPreparer) acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *BinaryPreparer) release() {
+	<-p.slots
+}
+
+// UseCache makes Copy skip copies, whose destinations already have the binary
+func (p *BinaryPreparer) UseCache(cache *BinaryCache) {
+	p.cache = cache
+}
+
+// UseResolver sets the resolver of remote binaries in programmatic overrides, see
+// AppendBinariesPathsNodeSpecWithPreparer. A resolver caching binaries in binaries.DefaultCacheDir is used otherwise.
+func (p *BinaryPreparer) UseResolver(resolver *binaries.Resolver) {
+	p.resolver = resolver
+}
+
+// binaryResolver returns the resolver set by UseResolver or the default one
+func (p *BinaryPreparer) binaryResolver() *binaries.Resolver {
+	if p.resolver == nil {
+		return binaries.NewResolver("")
+	}
+
+	return p.resolver
+}
+
+// Prepare checks that binaries exist, verifies their checksums and signatures and makes them executable. Binaries,
+// which can't be made executable in place, are copied to the staging directory, see PreparedPath. Binaries with the
+// same path and verification are prepared only once, failed preparations aren't retried.
+func (p *BinaryPreparer) Prepare(ctx context.Context, binariesToPrepare []Binary) error {
+	var owned []Binary
+	var ownedPreparations, waitFor []*preparation
+	p.mu.Lock()
+	for _, binary := range binariesToPrepare {
+		if binary.Path == "" {
+			p.mu.Unlock()
+			return &EmptyBinaryPathError{Capability: binary.Capability}
+		}
+		key := preparationKey(binary)
+		if existing, ok := p.prepared[key]; ok {
+			waitFor = append(waitFor, existing)
+			continue
+		}
+		prep := &preparation{done: make(chan struct{})}
+		p.prepared[key] = prep
+		owned = append(owned, binary)
+		ownedPreparations = append(ownedPreparations, prep)
+	}
+	p.mu.Unlock()
+
+	var doneMu sync.Mutex
+	done := 0
+	group := &errgroup.Group{}
+	group.SetLimit(p.maxConcurrentBinaries)
+	for idx, binary := range owned {
+		prep := ownedPreparations[idx]
+		group.Go(func() error {
+			defer close(prep.done)
+			if err := p.acquire(ctx); err != nil {
+				prep.err = err
+				return err
+			}
+			defer p.release()
+
+			p.progress(BinaryProgress{Stage: BinaryStagePrepare, Capability: binary.Capability, Path: binary.Path, Total: len(owned)})
+			startTime := time.Now()
+			_, endSpan := tracing.StartSpan(ctx, tracing.Span{Stage: BinaryStagePrepare, Capability: binary.Capability, Path: binary.Path})
+			prep.err = p.prepareBinary(binary)
+			endSpan(prep.err)
+
+			doneMu.Lock()
+			done++
+			event := BinaryProgress{Stage: BinaryStagePrepare, Capability: binary.Capability, Path: binary.Path, Finished: true, Done: done, Total: len(owned), Duration: time.Since(startTime), Err: prep.err}
+			doneMu.Unlock()
+			p.progress(event)
+
+			return prep.err
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return err
+	}
+
+	for _, prep := range waitFor {
+		select {
+		case <-prep.done:
+			if prep.err != nil {
+				return prep.err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Copy runs copies with a bounded number of workers, emitting progress of each of them. The first failure is returned
+// once running copies finish, copies that didn't start yet are skipped.
+func (p *BinaryPreparer) Copy(ctx context.Context, copies []BinaryCopy) error {
+	var doneMu sync.Mutex
+	done := 0
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(p.maxConcurrentBinaries)
+	for _, binaryCopy := range copies {
+		group.Go(func() error {
+			if err := p.acquire(groupCtx); err != nil {
+				return err
+			}
+			defer p.release()
+
+			p.progress(BinaryProgress{Stage: BinaryStageCopy, Capability: binaryCopy.Capability, Path: binaryCopy.Path, Target: binaryCopy.Target, Total: len(copies)})
+			startTime := time.Now()
+			copyCtx, endSpan := tracing.StartSpan(groupCtx, tracing.Span{Stage: BinaryStageCopy, Capability: binaryCopy.Capability, Target: binaryCopy.Target, Path: binaryCopy.Path})
+			cached, copyErr := p.copyBinary(copyCtx, binaryCopy)
+			endSpan(copyErr)
+
+			doneMu.Lock()
+			done++
+			event := BinaryProgress{Stage: BinaryStageCopy, Capability: binaryCopy.Capability, Path: binaryCopy.Path, Target: binaryCopy.Target, Finished: true, Done: done, Total: len(copies), Duration: time.Since(startTime), Err: copyErr, Cached: cached}
+			doneMu.Unlock()
+			p.progress(event)
+
+			return copyErr
+		})
+	}
+
+	return group.Wait()
+}
+
+// copyBinary runs the copy, unless the cache finds its destination up to date
+func (p *BinaryPreparer) copyBinary(ctx context.Context, binaryCopy BinaryCopy) (cached bool, err error) {
+	useCache := p.cache != nil && binaryCopy.RemoteDigest != nil
+	if useCache {
+		upToDate, cacheErr := p.cache.upToDate(ctx, binaryCopy)
+		if cacheErr != nil {
+			return false, cacheErr
+		}
+		if upToDate {
+			p.cache.record(binaryCopy.Path, true)
+			return true, nil
+		}
+	}
+
+	if copyErr := binaryCopy.Copy(ctx); copyErr != nil {
+		return false, errors.Wrapf(copyErr, "failed to copy binary %s to %s", binaryCopy.Path, binaryCopy.Target)
+	}
+	if useCache {
+		p.cache.record(binaryCopy.Path, false)
+	}
+
+	return false, nil
+}
+
+// preparationKey identifies a preparation by absolute path of the binary and the verification applied to it, so that
+// a binary used without a config (e.g. as a per-node override) is still verified if another usage sets a checksum
+func preparationKey(binary Binary) string {
+	path := binary.Path
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	if binary.Config == nil {
+		return path
+	}
+
+	key := path + "|sha256=" + binary.Config.SHA256
+	if signature := binary.Config.Signature; signature != nil {
+		key += fmt.Sprintf("|signature=%s:%s:%s", signature.Type, signature.PublicKey, signature.Path)
+	}
+
+	return key
+}
+
+func (p *BinaryPreparer) prepareBinary(binary Binary) error {
+	absPath, absErr := filepath.Abs(binary.Path)
+	if absErr != nil {
+		return errors.Wrapf(absErr, "failed to get absolute path for binary %s", binary.Path)
+	}
+	if _, err := os.Stat(binary.Path); os.IsNotExist(err) {
+		return &BinaryNotFoundError{Capability: binary.Capability, Path: absPath}
+	}
+
+	if binary.Config != nil {
+		if err := VerifyBinary(binary.Capability, binary.Path, *binary.Config); err != nil {
+			return err
+		}
+	}
+
+	executablePath, executableErr := makeExecutable(binary.Path, p.stagingDir)
+	if executableErr != nil {
+		return errors.Wrapf(executableErr, "failed to make binary %s executable for capability %s", binary.Path, binary.Capability)
+	}
+	if executablePath != binary.Path {
+		p.mu.Lock()
+		p.staged[absPath] = executablePath
+		p.mu.Unlock()
+	}
+
+	return nil
+}
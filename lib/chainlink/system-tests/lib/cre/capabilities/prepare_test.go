@@ -0,0 +1,94 @@
+package capabilities
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryPreparerPreparesSharedBinariesOnce(t *testing.T) {
+	binaryPath := filepath.Join(t.TempDir(), "cron")
+	require.NoError(t, os.WriteFile(binaryPath, []byte("binary"), 0o600))
+
+	var mu sync.Mutex
+	started := 0
+	preparer := NewBinaryPreparer(2, func(p BinaryProgress) {
+		if p.Stage == BinaryStagePrepare && !p.Finished {
+			mu.Lock()
+			started++
+			mu.Unlock()
+		}
+	})
+
+	// DONs sharing a binary prepare it at once
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- preparer.Prepare(context.Background(), []Binary{{Capability: "cron", Path: binaryPath}, {Capability: "cron", Path: binaryPath}})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, started, "binary was prepared more than once")
+
+	info, err := os.Stat(binaryPath)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0o100, "binary wasn't made executable")
+}
+
+func TestBinaryPreparerLimitsConcurrencyAcrossCalls(t *testing.T) {
+	preparer := NewBinaryPreparer(2, nil)
+
+	var running, peak atomic.Int32
+	copyFn := func(context.Context) error {
+		current := running.Add(1)
+		for {
+			observed := peak.Load()
+			if current <= observed || peak.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+
+		return nil
+	}
+
+	// each DON copies its binaries with its own call
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			copies := []BinaryCopy{{Path: "cron", Copy: copyFn}, {Path: "cron", Copy: copyFn}, {Path: "cron", Copy: copyFn}}
+			assert.NoError(t, preparer.Copy(context.Background(), copies))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), peak.Load(), "more binaries were copied at once than allowed")
+}
+
+func TestBinaryPreparerCopyStopsOnCanceledContext(t *testing.T) {
+	preparer := NewBinaryPreparer(1, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	copied := false
+	err := preparer.Copy(ctx, []BinaryCopy{{Path: "cron", Copy: func(context.Context) error { copied = true; return nil }}})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.False(t, copied)
+}
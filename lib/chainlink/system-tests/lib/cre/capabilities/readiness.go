@@ -0,0 +1,334 @@
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/clclient"
+
+	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/v2/core/services/keystore/keys/p2pkey"
+)
+
+const readinessPollInterval = 2 * time.Second
+
+// registryNode is the node of statuses of capabilities, which aren't added to the DON in the capabilities registry
+const registryNode = "registry"
+
+type CapabilityStatus string
+
+const (
+	// CapabilityStatusReady means that the node has a job of the capability and its plugin process is running
+	CapabilityStatusReady CapabilityStatus = "ready"
+	// CapabilityStatusNoJob means that the node has no standard capability job, which runs the binary
+	CapabilityStatusNoJob CapabilityStatus = "no_job"
+	// CapabilityStatusNotLaunched means that the job exists, but the plugin process isn't running (yet)
+	CapabilityStatusNotLaunched CapabilityStatus = "not_launched"
+	// CapabilityStatusFailed means that the plugin process isn't running and the job reported errors
+	CapabilityStatusFailed CapabilityStatus = "failed"
+	// CapabilityStatusUnknown means that the node couldn't be asked, e.g. because its API doesn't respond
+	CapabilityStatusUnknown CapabilityStatus = "unknown"
+	// CapabilityStatusNotRegistered means that the capability isn't added to the DON in the capabilities registry or the
+	// node doesn't declare its support there, so the launcher of the node doesn't run it
+	CapabilityStatusNotRegistered CapabilityStatus = "not_registered"
+)
+
+// RegistryCheck makes WaitForReadyWithRegistry check capabilities in the capabilities registry, which launchers of
+// nodes read capabilities of their DONs from. Capabilities are identified by their labelled name and version there.
+type RegistryCheck struct {
+	Registry     *Registry
+	Capabilities []keystone_changeset.DONCapabilityWithConfig // e.g. returned by DesiredCapabilities
+}
+
+// CapabilityReadiness is the status of a capability binary on a node, capabilities are identified by binary names
+type CapabilityReadiness struct {
+	Node       string
+	Capability string
+	Status     CapabilityStatus
+	Detail     string
+}
+
+type ReadinessReport struct {
+	DON      string
+	Statuses []CapabilityReadiness
+}
+
+func (r *ReadinessReport) Ready() bool {
+	return len(r.NotReady()) == 0
+}
+
+// NotReady returns statuses of capabilities, which aren't ready
+func (r *ReadinessReport) NotReady() []CapabilityReadiness {
+	var notReady []CapabilityReadiness
+	for _, status := range r.Statuses {
+		if status.Status != CapabilityStatusReady {
+			notReady = append(notReady, status)
+		}
+	}
+
+	return notReady
+}
+
+// String lists statuses of all capabilities of all nodes, one per line
+func (r *ReadinessReport) String() string {
+	lines := make([]string, 0, len(r.Statuses))
+	for _, status := range r.Statuses {
+		line := fmt.Sprintf("%s/%s: %s", status.Node, status.Capability, status.Status)
+		if status.Detail != "" {
+			line += " (" + status.Detail + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// WaitForReady polls nodes of the DON until every capability binary installed on them (see
+// AppendBinariesPathsNodeSpecWithRoles) has a standard capability job and a running plugin process. Capabilities
+// built into nodes have no binary and aren't checked. donMetadata has to be the one nodes were created from, because
+// it knows their binaries, don provides clients of node APIs. The last report is returned also on timeout, together
+// with an error listing capabilities, which aren't ready.
+func WaitForReady(ctx context.Context, donMetadata *cre.DonMetadata, don *cre.Don, timeout time.Duration) (*ReadinessReport, error) {
+	return WaitForReadyWithRegistry(ctx, donMetadata, don, nil, timeout)
+}
+
+// WaitForReadyWithRegistry works like WaitForReady, but additionally waits until capabilities of the registry check
+// are added to the DON in the capabilities registry and nodes of the DON declare their support, so that launchers of
+// nodes run them. Nodes, which aren't members of the DON in the registry (e.g. bootstrap nodes), aren't checked. Nil
+// check skips the registry.
+func WaitForReadyWithRegistry(ctx context.Context, donMetadata *cre.DonMetadata, don *cre.Don, registryCheck *RegistryCheck, timeout time.Duration) (*ReadinessReport, error) {
+	if registryCheck != nil && registryCheck.Registry == nil {
+		return nil, fmt.Errorf("registry check of DON %s has no registry", donMetadata.Name)
+	}
+	nodeSet := donMetadata.CapabilitiesAwareNodeSet()
+	if nodeSet == nil || nodeSet.Input == nil {
+		return nil, fmt.Errorf("metadata of DON %s has no nodeset, use the one created with cre.NewDonMetadata", donMetadata.Name)
+	}
+
+	expected := make(map[*cre.Node][]string, len(don.Nodes))
+	for _, node := range don.Nodes {
+		if node.Index < 0 || node.Index >= len(nodeSet.NodeSpecs) {
+			return nil, fmt.Errorf("node %s of DON %s has no node spec in nodeset %s", node.Name, don.Name, nodeSet.Name)
+		}
+		for _, binaryPath := range nodeSet.NodeSpecs[node.Index].Node.CapabilitiesBinaryPaths {
+			expected[node] = append(expected[node], filepath.Base(binaryPath))
+		}
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	for {
+		report := &ReadinessReport{DON: don.Name}
+		for _, node := range don.Nodes {
+			report.Statuses = append(report.Statuses, nodeReadiness(waitCtx, node, expected[node])...)
+		}
+		if registryCheck != nil {
+			report.Statuses = append(report.Statuses, registryReadiness(waitCtx, registryCheck, donMetadata, don)...)
+		}
+		if report.Ready() {
+			return report, nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return report, errors.Wrapf(ctx.Err(), "waiting for capabilities of DON %s was interrupted", don.Name)
+			}
+			notReady := (&ReadinessReport{Statuses: report.NotReady()}).String()
+			return report, fmt.Errorf("capabilities of DON %s aren't ready after %s:\n%s", don.Name, timeout, notReady)
+		case <-ticker.C:
+		}
+	}
+}
+
+// JobsReadiness reports whether plugins of all standard capability jobs nodes of the DON have are running, e.g. at the
+// end of a test. Unlike WaitForReady it doesn't need the nodeset and checks only once. Jobs of capabilities built into
+// nodes have no plugin process and are skipped.
+func JobsReadiness(ctx context.Context, don *cre.Don) *ReadinessReport {
+	report := &ReadinessReport{DON: don.Name}
+	for _, node := range don.Nodes {
+		jobs, jobsErr := nodeJobs(node)
+		if jobsErr != nil {
+			report.Statuses = append(report.Statuses, CapabilityReadiness{Node: node.Name, Capability: "*", Status: CapabilityStatusUnknown, Detail: jobsErr.Error()})
+			continue
+		}
+		var names []string
+		for name := range jobs {
+			if !strings.HasPrefix(name, builtinCommandPrefix) {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		report.Statuses = append(report.Statuses, binariesReadiness(ctx, node, names, jobs, nil)...)
+	}
+
+	return report
+}
+
+// builtinCommandPrefix starts commands of standard capability jobs of capabilities built into nodes
+const builtinCommandPrefix = "__builtin_"
+
+func nodeReadiness(ctx context.Context, node *cre.Node, binaryNames []string) []CapabilityReadiness {
+	if len(binaryNames) == 0 {
+		return []CapabilityReadiness{}
+	}
+
+	jobs, jobsErr := nodeJobs(node)
+	return binariesReadiness(ctx, node, binaryNames, jobs, jobsErr)
+}
+
+func nodeJobs(node *cre.Node) (map[string]standardCapabilityJob, error) {
+	if node.Clients.RestClient == nil {
+		return nil, errors.New("node has no API client")
+	}
+	response, _, readErr := node.Clients.RestClient.ReadJobs()
+	if readErr != nil {
+		return nil, errors.Wrap(readErr, "failed to read jobs")
+	}
+
+	return standardCapabilityJobs(response)
+}
+
+func binariesReadiness(ctx context.Context, node *cre.Node, binaryNames []string, jobs map[string]standardCapabilityJob, jobsErr error) []CapabilityReadiness {
+	statuses := make([]CapabilityReadiness, 0, len(binaryNames))
+	for _, name := range binaryNames {
+		status := CapabilityReadiness{Node: node.Name, Capability: name}
+		job, hasJob := jobs[name]
+		switch {
+		case jobsErr != nil:
+			status.Status, status.Detail = CapabilityStatusUnknown, jobsErr.Error()
+		case !hasJob:
+			status.Status = CapabilityStatusNoJob
+		default:
+			running, runningErr := pluginRunning(ctx, node, name)
+			switch {
+			case runningErr != nil:
+				status.Status, status.Detail = CapabilityStatusUnknown, runningErr.Error()
+			case running:
+				status.Status = CapabilityStatusReady
+			case len(job.Errors) > 0:
+				status.Status, status.Detail = CapabilityStatusFailed, fmt.Sprintf("job %s: %s", job.Name, strings.Join(job.Errors, "; "))
+			default:
+				status.Status, status.Detail = CapabilityStatusNotLaunched, "job "+job.Name
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+type standardCapabilityJob struct {
+	Name   string
+	Errors []string
+}
+
+// standardCapabilityJobs returns standard capability jobs from the response of /v2/jobs by binary names of their commands
+func standardCapabilityJobs(response *clclient.ResponseSlice) (map[string]standardCapabilityJob, error) {
+	jobs := make(map[string]standardCapabilityJob)
+	if response == nil {
+		return jobs, nil
+	}
+
+	for _, resource := range response.Data {
+		attributes, _ := resource["attributes"].(map[string]any)
+		spec, _ := attributes["standardCapabilitiesSpec"].(map[string]any)
+		command, _ := spec["command"].(string)
+		if command == "" {
+			continue
+		}
+		name, _ := attributes["name"].(string)
+		job := standardCapabilityJob{Name: name}
+		jobErrors, _ := attributes["errors"].([]any)
+		for _, jobError := range jobErrors {
+			if described, ok := jobError.(map[string]any); ok {
+				if description, _ := described["description"].(string); description != "" && !slices.Contains(job.Errors, description) {
+					job.Errors = append(job.Errors, description)
+				}
+			}
+		}
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("standard capability job %s has a blank command", name)
+		}
+		jobs[filepath.Base(fields[0])] = job
+	}
+
+	return jobs, nil
+}
+
+// registryReadiness checks that capabilities of the check are added to the DON in the capabilities registry and that
+// its member nodes declare their support
+func registryReadiness(ctx context.Context, check *RegistryCheck, donMetadata *cre.DonMetadata, don *cre.Don) []CapabilityReadiness {
+	donID := uint32(donMetadata.ID) //nolint:gosec // G115
+	unknown := func(err error) []CapabilityReadiness {
+		return []CapabilityReadiness{{Node: registryNode, Capability: "*", Status: CapabilityStatusUnknown, Detail: err.Error()}}
+	}
+
+	state, stateErr := check.Registry.state(ctx, donID)
+	if stateErr != nil {
+		return unknown(stateErr)
+	}
+	if state.don.id == 0 {
+		return []CapabilityReadiness{{Node: registryNode, Capability: "*", Status: CapabilityStatusNotRegistered, Detail: fmt.Sprintf("DON %d isn't registered", donID)}}
+	}
+
+	var statuses []CapabilityReadiness
+	for _, desired := range check.Capabilities {
+		name := desired.Capability.LabelledName + "@" + desired.Capability.Version
+		if _, ok := state.don.configs[name]; !ok {
+			statuses = append(statuses, CapabilityReadiness{Node: registryNode, Capability: name, Status: CapabilityStatusNotRegistered, Detail: fmt.Sprintf("not added to DON %d", donID)})
+			continue
+		}
+		for _, node := range don.Nodes {
+			if node.Keys == nil {
+				statuses = append(statuses, CapabilityReadiness{Node: node.Name, Capability: name, Status: CapabilityStatusUnknown, Detail: "node has no keys"})
+				continue
+			}
+			peerID, peerErr := p2pkey.MakePeerID(node.PeerID())
+			if peerErr != nil {
+				statuses = append(statuses, CapabilityReadiness{Node: node.Name, Capability: name, Status: CapabilityStatusUnknown, Detail: errors.Wrap(peerErr, "invalid peer ID").Error()})
+				continue
+			}
+			declared, isMember := state.nodes[peerID]
+			if !isMember {
+				continue
+			}
+			status := CapabilityReadiness{Node: node.Name, Capability: name, Status: CapabilityStatusReady}
+			if !slices.Contains(declared, name) {
+				status.Status, status.Detail = CapabilityStatusNotRegistered, "node doesn't declare its support"
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// pluginRunning checks whether a process started from the binary runs in the node's container. pgrep exits with 1,
+// if no process matched.
+func pluginRunning(ctx context.Context, node *cre.Node, binaryName string) (bool, error) {
+	result, execErr := node.Exec(ctx, "pgrep", "-f", "/"+regexp.QuoteMeta(binaryName)+"( |$)")
+	if execErr != nil {
+		return false, execErr
+	}
+	switch result.ExitCode {
+	case 0:
+		return true, nil
+	case 1:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to list processes of node %s (exit code %d): %s", node.Name, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+}
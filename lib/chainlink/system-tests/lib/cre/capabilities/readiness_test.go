@@ -0,0 +1,57 @@
+package capabilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/clclient"
+)
+
+func TestStandardCapabilityJobs(t *testing.T) {
+	response := &clclient.ResponseSlice{Data: []map[string]any{
+		{"attributes": map[string]any{
+			"name":                     "cron-capabilities",
+			"standardCapabilitiesSpec": map[string]any{"command": "/usr/local/bin/cron"},
+			"errors":                   []any{},
+		}},
+		{"attributes": map[string]any{
+			"name":                     "http-action",
+			"standardCapabilitiesSpec": map[string]any{"command": "/usr/local/bin/http_action --verbose"},
+			"errors": []any{
+				map[string]any{"description": "failed to start plugin"},
+				map[string]any{"description": "failed to start plugin"},
+			},
+		}},
+		{"attributes": map[string]any{"name": "workflow", "standardCapabilitiesSpec": nil}},
+	}}
+
+	jobs, err := standardCapabilityJobs(response)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]standardCapabilityJob{
+		"cron":        {Name: "cron-capabilities"},
+		"http_action": {Name: "http-action", Errors: []string{"failed to start plugin"}},
+	}, jobs)
+
+	response.Data = append(response.Data, map[string]any{"attributes": map[string]any{
+		"name":                     "blank",
+		"standardCapabilitiesSpec": map[string]any{"command": "  "},
+	}})
+	_, err = standardCapabilityJobs(response)
+	require.ErrorContains(t, err, "standard capability job blank has a blank command")
+}
+
+func TestReadinessReport(t *testing.T) {
+	report := &ReadinessReport{DON: "workflow", Statuses: []CapabilityReadiness{
+		{Node: "workflow-node1", Capability: "cron", Status: CapabilityStatusReady},
+		{Node: "workflow-node1", Capability: "http_action", Status: CapabilityStatusFailed, Detail: "job http-action: failed to start plugin"},
+		{Node: "workflow-node2", Capability: "cron", Status: CapabilityStatusNoJob},
+	}}
+
+	assert.False(t, report.Ready())
+	assert.Len(t, report.NotReady(), 2)
+	assert.Equal(t, "workflow-node1/cron: ready\n"+
+		"workflow-node1/http_action: failed (job http-action: failed to start plugin)\n"+
+		"workflow-node2/cron: no_job", report.String())
+}
@@ -0,0 +1,607 @@
+package capabilities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	cldf_evm "github.com/smartcontractkit/chainlink-deployments-framework/chain/evm"
+	cldf "github.com/smartcontractkit/chainlink-deployments-framework/deployment"
+	kcr "github.com/smartcontractkit/chainlink-evm/gethwrappers/keystone/generated/capabilities_registry_1_1_0"
+	"github.com/smartcontractkit/chainlink-evm/gethwrappers/keystone/generated/forwarder"
+	capabilities_registry_v2 "github.com/smartcontractkit/chainlink-evm/gethwrappers/workflow/generated/capabilities_registry_wrapper_v2"
+
+	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains/evm"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/experimental/tracing"
+	syncer_v2 "github.com/smartcontractkit/chainlink/v2/core/services/registrysyncer/v2"
+)
+
+// maxRegistryCapabilities is the page size used to read capabilities of the v2 registry
+var maxRegistryCapabilities = big.NewInt(128)
+
+// Registry is a deployed CapabilitiesRegistry together with the chain used to send its transactions. Exactly one of
+// V1 and V2 is set.
+type Registry struct {
+	V1    *kcr.CapabilitiesRegistry
+	V2    *capabilities_registry_v2.CapabilitiesRegistry
+	Chain cldf_evm.Chain
+}
+
+// NewRegistry binds the registry deployed at the address and detects its version. Transactions are sent with the tx
+// manager of the blockchain, so they don't race with nonces of other helpers sending from the root key.
+func NewRegistry(ctx context.Context, blockchain *evm.Blockchain, address common.Address) (*Registry, error) {
+	chain, chainErr := evmChain(blockchain)
+	if chainErr != nil {
+		return nil, chainErr
+	}
+
+	v1, v1Err := kcr.NewCapabilitiesRegistry(address, chain.Client)
+	if v1Err != nil {
+		return nil, errors.Wrapf(v1Err, "failed to bind capabilities registry at %s", address.Hex())
+	}
+	// both versions implement typeAndVersion() with the same signature
+	typeAndVersion, tvErr := v1.TypeAndVersion(&bind.CallOpts{Context: ctx})
+	if tvErr != nil {
+		return nil, errors.Wrapf(tvErr, "failed to get type and version of capabilities registry at %s", address.Hex())
+	}
+	if !strings.HasPrefix(typeAndVersion, "CapabilitiesRegistry 2.") {
+		return &Registry{V1: v1, Chain: chain}, nil
+	}
+
+	v2, v2Err := capabilities_registry_v2.NewCapabilitiesRegistry(address, chain.Client)
+	if v2Err != nil {
+		return nil, errors.Wrapf(v2Err, "failed to bind capabilities registry at %s", address.Hex())
+	}
+
+	return &Registry{V2: v2, Chain: chain}, nil
+}
+
+// Forwarder is a deployed KeystoneForwarder together with the chain used to send its transactions
+type Forwarder struct {
+	Contract *forwarder.KeystoneForwarder
+	Chain    cldf_evm.Chain
+}
+
+// NewForwarder binds the forwarder deployed at the address, transactions are sent with the tx manager of the blockchain
+func NewForwarder(blockchain *evm.Blockchain, address common.Address) (*Forwarder, error) {
+	chain, chainErr := evmChain(blockchain)
+	if chainErr != nil {
+		return nil, chainErr
+	}
+
+	contract, err := forwarder.NewKeystoneForwarder(address, chain.Client)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to bind forwarder at %s", address.Hex())
+	}
+
+	return &Forwarder{Contract: contract, Chain: chain}, nil
+}
+
+func evmChain(blockchain *evm.Blockchain) (cldf_evm.Chain, error) {
+	chain, chainErr := blockchain.ToCldfChain()
+	if chainErr != nil {
+		return cldf_evm.Chain{}, errors.Wrapf(chainErr, "failed to create CLDF chain of blockchain %d", blockchain.ChainID())
+	}
+	onchain, ok := chain.(cldf_evm.Chain)
+	if !ok {
+		return cldf_evm.Chain{}, fmt.Errorf("blockchain %d isn't an EVM chain", blockchain.ChainID())
+	}
+
+	return onchain, nil
+}
+
+// RegistryDiff lists changes applied by SyncRegistry, capabilities are identified by their labelled name and version
+type RegistryDiff struct {
+	AddedCapabilities      []string // registered, because no DON used them before
+	AddedToDON             []string
+	UpdatedInDON           []string // their config changed
+	RemovedFromDON         []string // they stay registered, other DONs may use them
+	UpdatedNodes           int      // nodes, which had to declare support of added capabilities
+	ReconfiguredForwarders int      // forwarders moved to the new config version of a DON accepting workflows
+}
+
+func (d *RegistryDiff) IsEmpty() bool {
+	return len(d.AddedCapabilities) == 0 && len(d.AddedToDON) == 0 && len(d.UpdatedInDON) == 0 && len(d.RemovedFromDON) == 0
+}
+
+func (d *RegistryDiff) String() string {
+	if d.IsEmpty() {
+		return "capabilities registry is up to date"
+	}
+
+	return fmt.Sprintf("registered: %v, added to DON: %v, updated: %v, removed from DON: %v, updated nodes: %d, reconfigured forwarders: %d",
+		d.AddedCapabilities, d.AddedToDON, d.UpdatedInDON, d.RemovedFromDON, d.UpdatedNodes, d.ReconfiguredForwarders)
+}
+
+// DesiredCapabilities returns capabilities of the DON with their configs, the same way full registry setup derives them
+// from capability flags of the DON and its nodeset
+func DesiredCapabilities(donMetadata *cre.DonMetadata, nodeSet *cre.CapabilitiesAwareNodeSet, configFns []cre.CapabilityRegistryConfigFn) ([]keystone_changeset.DONCapabilityWithConfig, error) {
+	var desired []keystone_changeset.DONCapabilityWithConfig
+	for _, configFn := range configFns {
+		if configFn == nil {
+			continue
+		}
+		enabledCapabilities, err := configFn(donMetadata.Flags, nodeSet)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get capabilities from config function")
+		}
+		desired = append(desired, enabledCapabilities...)
+	}
+
+	return desired, nil
+}
+
+// SyncRegistry makes capabilities of an already registered DON match the desired ones, sending only transactions,
+// which are needed: capabilities unknown to the registry are added, nodes declare support of capabilities added to the
+// DON and the DON is updated only if its capabilities or their configs differ. Nothing is sent, if the registry is up
+// to date. Use it instead of full registry setup when iterating on capability configs of a running environment.
+//
+// Updating a DON bumps its config version. Forwarders accept only reports signed for the config version they know, so
+// forwarders of a DON accepting workflows are moved to the new version with their current signers and F. Pass all
+// forwarders configured for the DON, the ones left out reject its reports after the update.
+func SyncRegistry(ctx context.Context, registry *Registry, donMetadata *cre.DonMetadata, desiredCapabilities []keystone_changeset.DONCapabilityWithConfig, forwarders []*Forwarder) (_ *RegistryDiff, err error) {
+	ctx, endSpan := tracing.StartSpan(ctx, tracing.Span{Stage: BinaryStageRegistrySync, Target: "DON " + donMetadata.Name})
+	defer func() { endSpan(err) }()
+
+	donID := uint32(donMetadata.ID) //nolint:gosec // G115
+	state, stateErr := registry.state(ctx, donID)
+	if stateErr != nil {
+		return nil, stateErr
+	}
+	if state.don.id == 0 {
+		return nil, fmt.Errorf("DON %d (%s) isn't registered in capabilities registry, run full registry setup first", donID, donMetadata.Name)
+	}
+
+	desired, desiredErr := toDesiredCapabilities(desiredCapabilities, registry.V2 != nil)
+	if desiredErr != nil {
+		return nil, desiredErr
+	}
+	plan, planErr := planRegistrySync(state, desired)
+	if planErr != nil {
+		return nil, planErr
+	}
+	if plan.diff.IsEmpty() {
+		return plan.diff, nil
+	}
+
+	// read configs of forwarders before sending anything, so that a forwarder, which can't be reconfigured, doesn't
+	// leave the DON updated without it
+	var forwarderConfigs []*forwarder.KeystoneForwarderConfigSet
+	if plan.donChanged() && state.don.acceptsWorkflows {
+		for _, fwd := range forwarders {
+			config, configErr := latestForwarderConfig(ctx, fwd, donID)
+			if configErr != nil {
+				return nil, configErr
+			}
+			forwarderConfigs = append(forwarderConfigs, config)
+		}
+	}
+
+	if len(plan.register) > 0 {
+		if err := registry.addCapabilities(plan.register); err != nil {
+			return nil, err
+		}
+	}
+	if !plan.donChanged() {
+		return plan.diff, nil
+	}
+
+	if len(plan.nodeCapabilities) > 0 {
+		if err := registry.updateNodes(ctx, state, plan.nodeCapabilities); err != nil {
+			return nil, err
+		}
+		plan.diff.UpdatedNodes = len(plan.nodeCapabilities)
+	}
+	if err := registry.updateDON(ctx, state, plan.configs); err != nil {
+		return nil, err
+	}
+
+	if len(forwarderConfigs) == 0 {
+		return plan.diff, nil
+	}
+	updated, updatedErr := registry.state(ctx, donID)
+	if updatedErr != nil {
+		return nil, updatedErr
+	}
+	for i, fwd := range forwarders {
+		config := forwarderConfigs[i]
+		if err := send(fwd.Chain, forwarder.KeystoneForwarderABI, "SetConfig", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return fwd.Contract.SetConfig(opts, donID, updated.don.configCount, config.F, config.Signers)
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to reconfigure forwarder %s", fwd.Contract.Address().Hex())
+		}
+		plan.diff.ReconfiguredForwarders++
+	}
+
+	return plan.diff, nil
+}
+
+// latestForwarderConfig returns the last config of the DON set in the forwarder
+func latestForwarderConfig(ctx context.Context, fwd *Forwarder, donID uint32) (*forwarder.KeystoneForwarderConfigSet, error) {
+	iterator, filterErr := fwd.Contract.FilterConfigSet(&bind.FilterOpts{Context: ctx}, []uint32{donID}, nil)
+	if filterErr != nil {
+		return nil, errors.Wrapf(filterErr, "failed to get configs of DON %d set in forwarder %s", donID, fwd.Contract.Address().Hex())
+	}
+	defer iterator.Close()
+
+	var latest *forwarder.KeystoneForwarderConfigSet
+	for iterator.Next() {
+		if latest == nil || iterator.Event.ConfigVersion >= latest.ConfigVersion {
+			latest = iterator.Event
+		}
+	}
+	if iterator.Error() != nil {
+		return nil, errors.Wrapf(iterator.Error(), "failed to read configs of DON %d set in forwarder %s", donID, fwd.Contract.Address().Hex())
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("forwarder %s has no config of DON %d, run full setup to configure it", fwd.Contract.Address().Hex(), donID)
+	}
+
+	return latest, nil
+}
+
+// registryState is the part of the registry read by SyncRegistry. Capabilities are identified by their labelled name
+// and version, so that both registry versions are handled the same way.
+type registryState struct {
+	don        registryDON
+	registered map[string]bool       // whether the capability is deprecated
+	nodes      map[[32]byte][]string // capabilities declared by members of the DON
+	hashedIDs  map[string][32]byte   // only set for the v1 registry
+}
+
+type registryDON struct {
+	id               uint32
+	configCount      uint32
+	f                uint8
+	isPublic         bool
+	acceptsWorkflows bool
+	nodeP2PIDs       [][32]byte
+	name             string // only set for the v2 registry
+	config           []byte // only set for the v2 registry
+	configs          map[string][]byte
+}
+
+type desiredCapability struct {
+	id         string
+	capability kcr.CapabilitiesRegistryCapability
+	config     []byte
+}
+
+type capabilityConfig struct {
+	id     string
+	config []byte
+}
+
+// registryPlan lists transactions needed to make capabilities of the DON match the desired ones
+type registryPlan struct {
+	diff             *RegistryDiff
+	register         []desiredCapability
+	configs          []capabilityConfig    // all capabilities of the DON after the update
+	nodeCapabilities map[[32]byte][]string // capabilities, which nodes have to declare, including the ones they declare already
+}
+
+func (p *registryPlan) donChanged() bool {
+	return len(p.diff.AddedToDON) > 0 || len(p.diff.UpdatedInDON) > 0 || len(p.diff.RemovedFromDON) > 0
+}
+
+// toDesiredCapabilities marshals configs of capabilities. The v2 registry stores an empty JSON object for capabilities
+// without a config, the same as full registry setup does.
+func toDesiredCapabilities(capabilities []keystone_changeset.DONCapabilityWithConfig, v2 bool) ([]desiredCapability, error) {
+	desired := make([]desiredCapability, 0, len(capabilities))
+	for _, capability := range capabilities {
+		id := capability.Capability.LabelledName + "@" + capability.Capability.Version
+		config := []byte("{}")
+		switch {
+		case capability.Config != nil:
+			var configErr error
+			if config, configErr = proto.Marshal(capability.Config); configErr != nil {
+				return nil, errors.Wrapf(configErr, "failed to marshal config of capability %s", id)
+			}
+		case !v2:
+			return nil, fmt.Errorf("config of capability %s is not set", id)
+		}
+		desired = append(desired, desiredCapability{id: id, capability: capability.Capability, config: config})
+	}
+
+	return desired, nil
+}
+
+func planRegistrySync(state *registryState, desired []desiredCapability) (*registryPlan, error) {
+	plan := &registryPlan{diff: &RegistryDiff{}}
+	var desiredIDs []string
+	for _, capability := range desired {
+		if slices.Contains(desiredIDs, capability.id) {
+			continue
+		}
+		deprecated, isRegistered := state.registered[capability.id]
+		switch {
+		case !isRegistered:
+			plan.register = append(plan.register, capability)
+			plan.diff.AddedCapabilities = append(plan.diff.AddedCapabilities, capability.id)
+		case deprecated:
+			return nil, fmt.Errorf("capability %s is deprecated in capabilities registry and can't be added to DONs", capability.id)
+		}
+
+		currentConfig, inDON := state.don.configs[capability.id]
+		switch {
+		case !inDON:
+			plan.diff.AddedToDON = append(plan.diff.AddedToDON, capability.id)
+		case !bytes.Equal(currentConfig, capability.config):
+			plan.diff.UpdatedInDON = append(plan.diff.UpdatedInDON, capability.id)
+		}
+		desiredIDs = append(desiredIDs, capability.id)
+		plan.configs = append(plan.configs, capabilityConfig{id: capability.id, config: capability.config})
+	}
+	for id := range state.don.configs {
+		if !slices.Contains(desiredIDs, id) {
+			plan.diff.RemovedFromDON = append(plan.diff.RemovedFromDON, id)
+		}
+	}
+	slices.Sort(plan.diff.RemovedFromDON)
+
+	if !plan.donChanged() {
+		return plan, nil
+	}
+	for _, p2pID := range state.don.nodeP2PIDs {
+		declared := state.nodes[p2pID]
+		capabilities := slices.Clone(declared)
+		for _, id := range desiredIDs {
+			if !slices.Contains(capabilities, id) {
+				capabilities = append(capabilities, id)
+			}
+		}
+		if len(capabilities) == len(declared) {
+			continue
+		}
+		if plan.nodeCapabilities == nil {
+			plan.nodeCapabilities = make(map[[32]byte][]string)
+		}
+		plan.nodeCapabilities[p2pID] = capabilities
+	}
+
+	return plan, nil
+}
+
+// state reads the DON and capabilities relevant for it. ID of the DON is 0, if it isn't registered.
+func (r *Registry) state(ctx context.Context, donID uint32) (*registryState, error) {
+	callOpts := &bind.CallOpts{Context: ctx}
+	state := &registryState{registered: make(map[string]bool), nodes: make(map[[32]byte][]string)}
+
+	switch {
+	case r.V1 != nil:
+		registered, registeredErr := r.V1.GetCapabilities(callOpts)
+		if registeredErr != nil {
+			return nil, errors.Wrap(registeredErr, "failed to get registered capabilities")
+		}
+		state.hashedIDs = make(map[string][32]byte, len(registered))
+		byHashedID := make(map[[32]byte]string, len(registered))
+		for _, info := range registered {
+			id := info.LabelledName + "@" + info.Version
+			state.registered[id] = info.IsDeprecated
+			state.hashedIDs[id] = info.HashedId
+			byHashedID[info.HashedId] = id
+		}
+
+		don, donErr := r.V1.GetDON(callOpts, donID)
+		if donErr != nil {
+			return nil, errors.Wrapf(donErr, "failed to get DON %d from capabilities registry", donID)
+		}
+		state.don = registryDON{id: don.Id, configCount: don.ConfigCount, f: don.F, isPublic: don.IsPublic, acceptsWorkflows: don.AcceptsWorkflows, nodeP2PIDs: don.NodeP2PIds, configs: make(map[string][]byte)}
+		for _, configuration := range don.CapabilityConfigurations {
+			state.don.configs[byHashedID[configuration.CapabilityId]] = configuration.Config
+		}
+		if don.Id == 0 {
+			return state, nil
+		}
+
+		nodes, nodesErr := r.V1.GetNodesByP2PIds(callOpts, don.NodeP2PIds)
+		if nodesErr != nil {
+			return nil, errors.Wrapf(nodesErr, "failed to get nodes of DON %d", donID)
+		}
+		for _, node := range nodes {
+			declared := make([]string, 0, len(node.HashedCapabilityIds))
+			for _, hashedID := range node.HashedCapabilityIds {
+				declared = append(declared, byHashedID[hashedID])
+			}
+			state.nodes[node.P2pId] = declared
+		}
+	case r.V2 != nil:
+		registered, registeredErr := r.V2.GetCapabilities(callOpts, big.NewInt(0), maxRegistryCapabilities)
+		if registeredErr != nil {
+			return nil, errors.Wrap(registeredErr, "failed to get registered capabilities")
+		}
+		for _, info := range registered {
+			state.registered[info.CapabilityId] = info.IsDeprecated
+		}
+
+		don, donErr := r.V2.GetDON(callOpts, donID)
+		if donErr != nil {
+			return nil, errors.Wrapf(donErr, "failed to get DON %d from capabilities registry", donID)
+		}
+		state.don = registryDON{id: don.Id, configCount: don.ConfigCount, f: don.F, isPublic: don.IsPublic, acceptsWorkflows: don.AcceptsWorkflows, nodeP2PIDs: don.NodeP2PIds, name: don.Name, config: don.Config, configs: make(map[string][]byte)}
+		for _, configuration := range don.CapabilityConfigurations {
+			state.don.configs[configuration.CapabilityId] = configuration.Config
+		}
+		if don.Id == 0 {
+			return state, nil
+		}
+
+		nodes, nodesErr := r.V2.GetNodesByP2PIds(callOpts, don.NodeP2PIds)
+		if nodesErr != nil {
+			return nil, errors.Wrapf(nodesErr, "failed to get nodes of DON %d", donID)
+		}
+		for _, node := range nodes {
+			state.nodes[node.P2pId] = node.CapabilityIds
+		}
+	default:
+		return nil, errors.New("nil capabilities registry contract")
+	}
+
+	return state, nil
+}
+
+func (r *Registry) addCapabilities(capabilities []desiredCapability) error {
+	if r.V1 != nil {
+		toRegister := make([]kcr.CapabilitiesRegistryCapability, 0, len(capabilities))
+		for _, capability := range capabilities {
+			toRegister = append(toRegister, capability.capability)
+		}
+
+		return send(r.Chain, kcr.CapabilitiesRegistryABI, "AddCapabilities", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return r.V1.AddCapabilities(opts, toRegister)
+		})
+	}
+
+	toRegister := make([]capabilities_registry_v2.CapabilitiesRegistryCapability, 0, len(capabilities))
+	for _, capability := range capabilities {
+		metadata, metadataErr := json.Marshal(syncer_v2.CapabilityMetadata{
+			CapabilityType: capability.capability.CapabilityType,
+			ResponseType:   capability.capability.ResponseType,
+		})
+		if metadataErr != nil {
+			return errors.Wrapf(metadataErr, "failed to marshal metadata of capability %s", capability.id)
+		}
+		toRegister = append(toRegister, capabilities_registry_v2.CapabilitiesRegistryCapability{
+			CapabilityId:          capability.id,
+			ConfigurationContract: capability.capability.ConfigurationContract,
+			Metadata:              metadata,
+		})
+	}
+
+	return send(r.Chain, capabilities_registry_v2.CapabilitiesRegistryABI, "AddCapabilities", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.V2.AddCapabilities(opts, toRegister)
+	})
+}
+
+// updateNodes makes the nodes declare the capabilities, other node params are kept
+func (r *Registry) updateNodes(ctx context.Context, state *registryState, nodeCapabilities map[[32]byte][]string) error {
+	callOpts := &bind.CallOpts{Context: ctx}
+	p2pIDs := make([][32]byte, 0, len(nodeCapabilities))
+	for _, p2pID := range state.don.nodeP2PIDs {
+		if _, ok := nodeCapabilities[p2pID]; ok {
+			p2pIDs = append(p2pIDs, p2pID)
+		}
+	}
+
+	if r.V1 != nil {
+		nodes, nodesErr := r.V1.GetNodesByP2PIds(callOpts, p2pIDs)
+		if nodesErr != nil {
+			return errors.Wrapf(nodesErr, "failed to get nodes of DON %d", state.don.id)
+		}
+		params := make([]kcr.CapabilitiesRegistryNodeParams, 0, len(nodes))
+		for _, node := range nodes {
+			hashedIDs := make([][32]byte, 0, len(nodeCapabilities[node.P2pId]))
+			for _, id := range nodeCapabilities[node.P2pId] {
+				hashedID, idErr := r.hashedID(ctx, state, id)
+				if idErr != nil {
+					return idErr
+				}
+				hashedIDs = append(hashedIDs, hashedID)
+			}
+			params = append(params, kcr.CapabilitiesRegistryNodeParams{
+				NodeOperatorId:      node.NodeOperatorId,
+				Signer:              node.Signer,
+				P2pId:               node.P2pId,
+				EncryptionPublicKey: node.EncryptionPublicKey,
+				HashedCapabilityIds: hashedIDs,
+			})
+		}
+
+		return send(r.Chain, kcr.CapabilitiesRegistryABI, "UpdateNodes", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return r.V1.UpdateNodes(opts, params)
+		})
+	}
+
+	nodes, nodesErr := r.V2.GetNodesByP2PIds(callOpts, p2pIDs)
+	if nodesErr != nil {
+		return errors.Wrapf(nodesErr, "failed to get nodes of DON %d", state.don.id)
+	}
+	params := make([]capabilities_registry_v2.CapabilitiesRegistryNodeParams, 0, len(nodes))
+	for _, node := range nodes {
+		params = append(params, capabilities_registry_v2.CapabilitiesRegistryNodeParams{
+			NodeOperatorId:      node.NodeOperatorId,
+			Signer:              node.Signer,
+			P2pId:               node.P2pId,
+			EncryptionPublicKey: node.EncryptionPublicKey,
+			CsaKey:              node.CsaKey,
+			CapabilityIds:       nodeCapabilities[node.P2pId],
+		})
+	}
+
+	return send(r.Chain, capabilities_registry_v2.CapabilitiesRegistryABI, "UpdateNodes", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.V2.UpdateNodes(opts, params)
+	})
+}
+
+// updateDON sets capabilities of the DON, its nodes, F and visibility are kept
+func (r *Registry) updateDON(ctx context.Context, state *registryState, configs []capabilityConfig) error {
+	don := state.don
+	if r.V1 != nil {
+		v1Configs := make([]kcr.CapabilitiesRegistryCapabilityConfiguration, 0, len(configs))
+		for _, config := range configs {
+			hashedID, idErr := r.hashedID(ctx, state, config.id)
+			if idErr != nil {
+				return idErr
+			}
+			v1Configs = append(v1Configs, kcr.CapabilitiesRegistryCapabilityConfiguration{CapabilityId: hashedID, Config: config.config})
+		}
+
+		return send(r.Chain, kcr.CapabilitiesRegistryABI, "UpdateDON", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return r.V1.UpdateDON(opts, don.id, don.nodeP2PIDs, v1Configs, don.isPublic, don.f)
+		})
+	}
+
+	v2Configs := make([]capabilities_registry_v2.CapabilitiesRegistryCapabilityConfiguration, 0, len(configs))
+	for _, config := range configs {
+		v2Configs = append(v2Configs, capabilities_registry_v2.CapabilitiesRegistryCapabilityConfiguration{CapabilityId: config.id, Config: config.config})
+	}
+
+	return send(r.Chain, capabilities_registry_v2.CapabilitiesRegistryABI, "UpdateDON", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.V2.UpdateDON(opts, don.id, capabilities_registry_v2.CapabilitiesRegistryUpdateDONParams{
+			Name:                     don.name,
+			Config:                   don.config,
+			CapabilityConfigurations: v2Configs,
+			Nodes:                    don.nodeP2PIDs,
+			F:                        don.f,
+			IsPublic:                 don.isPublic,
+		})
+	})
+}
+
+// hashedID returns the ID of the capability in the v1 registry, capabilities registered by the sync aren't in the state
+func (r *Registry) hashedID(ctx context.Context, state *registryState, id string) ([32]byte, error) {
+	if hashedID, ok := state.hashedIDs[id]; ok {
+		return hashedID, nil
+	}
+	labelledName, version, _ := strings.Cut(id, "@")
+	hashedID, idErr := r.V1.GetHashedCapabilityId(&bind.CallOpts{Context: ctx}, labelledName, version)
+	if idErr != nil {
+		return [32]byte{}, errors.Wrapf(idErr, "failed to get ID of capability %s", id)
+	}
+
+	return hashedID, nil
+}
+
+func send(chain cldf_evm.Chain, contractABI string, method string, transact func(opts *bind.TransactOpts) (*types.Transaction, error)) error {
+	tx, txErr := transact(chain.DeployerKey)
+	if txErr != nil {
+		return errors.Wrapf(cldf.DecodeErr(contractABI, txErr), "failed to call %s", method)
+	}
+	if _, err := chain.Confirm(tx); err != nil {
+		return errors.Wrapf(err, "failed to confirm %s transaction %s", method, tx.Hash().Hex())
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package capabilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	capabilitiespb "github.com/smartcontractkit/chainlink-common/pkg/capabilities/pb"
+	kcr "github.com/smartcontractkit/chainlink-evm/gethwrappers/keystone/generated/capabilities_registry_1_1_0"
+
+	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
+)
+
+func TestPlanRegistrySync(t *testing.T) {
+	node1, node2 := [32]byte{1}, [32]byte{2}
+	state := &registryState{
+		don: registryDON{
+			id:         1,
+			nodeP2PIDs: [][32]byte{node1, node2},
+			configs: map[string][]byte{
+				"cron@1.0.0":    []byte("cron"),
+				"http@1.0.0":    []byte("old"),
+				"removed@1.0.0": []byte("removed"),
+			},
+		},
+		registered: map[string]bool{"cron@1.0.0": false, "http@1.0.0": false, "removed@1.0.0": false, "consensus@1.0.0": false},
+		nodes: map[[32]byte][]string{
+			node1: {"cron@1.0.0", "http@1.0.0", "removed@1.0.0", "consensus@1.0.0"},
+			node2: {"cron@1.0.0", "http@1.0.0", "removed@1.0.0"},
+		},
+	}
+	desired := []desiredCapability{
+		{id: "cron@1.0.0", config: []byte("cron")},
+		{id: "http@1.0.0", config: []byte("new")},
+		{id: "consensus@1.0.0", config: []byte("consensus")},
+		{id: "evm@1.0.0", config: []byte("evm")},
+		{id: "cron@1.0.0", config: []byte("duplicate")},
+	}
+
+	plan, err := planRegistrySync(state, desired)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"evm@1.0.0"}, plan.diff.AddedCapabilities)
+	assert.Equal(t, []string{"consensus@1.0.0", "evm@1.0.0"}, plan.diff.AddedToDON)
+	assert.Equal(t, []string{"http@1.0.0"}, plan.diff.UpdatedInDON)
+	assert.Equal(t, []string{"removed@1.0.0"}, plan.diff.RemovedFromDON)
+	require.Len(t, plan.register, 1)
+	assert.Equal(t, "evm@1.0.0", plan.register[0].id)
+	assert.Equal(t, []capabilityConfig{
+		{id: "cron@1.0.0", config: []byte("cron")},
+		{id: "http@1.0.0", config: []byte("new")},
+		{id: "consensus@1.0.0", config: []byte("consensus")},
+		{id: "evm@1.0.0", config: []byte("evm")},
+	}, plan.configs, "duplicated capability wasn't skipped")
+	assert.Equal(t, map[[32]byte][]string{
+		node1: {"cron@1.0.0", "http@1.0.0", "removed@1.0.0", "consensus@1.0.0", "evm@1.0.0"},
+		node2: {"cron@1.0.0", "http@1.0.0", "removed@1.0.0", "consensus@1.0.0", "evm@1.0.0"},
+	}, plan.nodeCapabilities, "nodes must keep declaring capabilities removed from the DON, other DONs may use them")
+}
+
+func TestPlanRegistrySyncUpToDate(t *testing.T) {
+	state := &registryState{
+		don:        registryDON{id: 1, nodeP2PIDs: [][32]byte{{1}}, configs: map[string][]byte{"cron@1.0.0": []byte("cron")}},
+		registered: map[string]bool{"cron@1.0.0": false},
+		nodes:      map[[32]byte][]string{{1}: {"cron@1.0.0"}},
+	}
+
+	plan, err := planRegistrySync(state, []desiredCapability{{id: "cron@1.0.0", config: []byte("cron")}})
+	require.NoError(t, err)
+	assert.True(t, plan.diff.IsEmpty())
+	assert.False(t, plan.donChanged())
+	assert.Empty(t, plan.nodeCapabilities)
+	assert.Equal(t, "capabilities registry is up to date", plan.diff.String())
+}
+
+func TestPlanRegistrySyncRejectsDeprecatedCapabilities(t *testing.T) {
+	state := &registryState{
+		don:        registryDON{id: 1, configs: map[string][]byte{}},
+		registered: map[string]bool{"cron@1.0.0": true},
+	}
+
+	_, err := planRegistrySync(state, []desiredCapability{{id: "cron@1.0.0", config: []byte("cron")}})
+	require.ErrorContains(t, err, "capability cron@1.0.0 is deprecated")
+}
+
+func TestToDesiredCapabilities(t *testing.T) {
+	config := &capabilitiespb.CapabilityConfig{RemoteConfig: &capabilitiespb.CapabilityConfig_RemoteTriggerConfig{
+		RemoteTriggerConfig: &capabilitiespb.RemoteTriggerConfig{MinResponsesToAggregate: 2},
+	}}
+	configBytes, err := proto.Marshal(config)
+	require.NoError(t, err)
+	require.NotEmpty(t, configBytes)
+	capabilities := []keystone_changeset.DONCapabilityWithConfig{
+		{Capability: kcr.CapabilitiesRegistryCapability{LabelledName: "cron", Version: "1.0.0"}, Config: config},
+		{Capability: kcr.CapabilitiesRegistryCapability{LabelledName: "http", Version: "1.0.0"}},
+	}
+
+	desired, err := toDesiredCapabilities(capabilities, true)
+	require.NoError(t, err)
+	require.Len(t, desired, 2)
+	assert.Equal(t, "cron@1.0.0", desired[0].id)
+	assert.Equal(t, configBytes, desired[0].config)
+	assert.Equal(t, []byte("{}"), desired[1].config, "v2 registry stores an empty object for capabilities without config")
+
+	_, err = toDesiredCapabilities(capabilities, false)
+	require.ErrorContains(t, err, "config of capability http@1.0.0 is not set")
+}
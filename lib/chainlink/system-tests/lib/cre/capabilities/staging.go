@@ -0,0 +1,127 @@
+package capabilities
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+)
+
+// DefaultBinaryStagingDir is where binaries, which can't be made executable in place, are copied to
+func DefaultBinaryStagingDir() string {
+	return filepath.Join(os.TempDir(), "cre-staged-capability-binaries")
+}
+
+// UseStagingDir makes Prepare copy binaries, which can't be made executable in place, to dir instead of
+// DefaultBinaryStagingDir
+func (p *BinaryPreparer) UseStagingDir(dir string) {
+	p.stagingDir = dir
+}
+
+// PreparedPath returns the path, at which the binary was prepared by Prepare. It's a copy in the staging directory,
+// if the binary couldn't be made executable in place, e.g. because it's on a read-only mount or owned by another
+// user, otherwise it's the path itself. Use it in node specs instead of the original path.
+func (p *BinaryPreparer) PreparedPath(path string) string {
+	absPath, absErr := filepath.Abs(path)
+	if absErr != nil {
+		return path
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if staged, ok := p.staged[absPath]; ok {
+		return staged
+	}
+
+	return path
+}
+
+// PreparedPaths returns PreparedPath of each binary
+func (p *BinaryPreparer) PreparedPaths(customBinariesPaths map[cre.CapabilityFlag]string) map[cre.CapabilityFlag]string {
+	prepared := make(map[cre.CapabilityFlag]string, len(customBinariesPaths))
+	for flag, binaryPath := range customBinariesPaths {
+		prepared[flag] = p.PreparedPath(binaryPath)
+	}
+
+	return prepared
+}
+
+// makeExecutable makes the binary executable in place or, if that fails, in the staging directory and returns the
+// path of the executable binary. Windows files have no executable bits, binaries copied to Linux containers get them
+// from the copy (see infra.CopyFileToDockerContainer), so nothing is changed there.
+func makeExecutable(binaryPath, stagingDir string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return binaryPath, nil
+	}
+
+	info, statErr := os.Stat(binaryPath)
+	if statErr != nil {
+		return "", statErr
+	}
+	// binaries on read-only mounts are often executable already
+	if info.Mode().Perm()&0o111 == 0o111 {
+		return binaryPath, nil
+	}
+
+	chmodErr := os.Chmod(binaryPath, 0755)
+	if chmodErr == nil {
+		return binaryPath, nil
+	}
+
+	staged, stageErr := stageBinary(binaryPath, stagingDir)
+	if stageErr != nil {
+		return "", errors.Wrapf(stageErr, "failed to make binary executable (%s) and to stage it in %s", chmodErr, stagingDir)
+	}
+
+	return staged, nil
+}
+
+// stageBinary copies the binary to a directory named after its digest, so that different binaries with the same name
+// don't overwrite each other, but keeps its name, because job specs reference binaries by name
+func stageBinary(binaryPath, stagingDir string) (string, error) {
+	digest, digestErr := binaries.FileDigest(binaryPath)
+	if digestErr != nil {
+		return "", errors.Wrap(digestErr, "failed to compute digest")
+	}
+	dir := filepath.Join(stagingDir, digest[:16])
+	staged := filepath.Join(dir, filepath.Base(binaryPath))
+	if info, err := os.Stat(staged); err == nil && info.Mode().Perm()&0o111 == 0o111 {
+		return staged, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", errors.Wrap(err, "failed to create staging directory")
+	}
+	source, openErr := os.Open(binaryPath)
+	if openErr != nil {
+		return "", openErr
+	}
+	defer source.Close()
+
+	// write to a temporary file first, so that concurrent setups never see a partial binary
+	tmp, tmpErr := os.CreateTemp(dir, ".staging-*")
+	if tmpErr != nil {
+		return "", errors.Wrap(tmpErr, "failed to create staged file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, source); err != nil {
+		_ = tmp.Close()
+		return "", errors.Wrap(err, "failed to copy binary")
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return "", errors.Wrap(err, "failed to make staged binary executable")
+	}
+	if err := os.Rename(tmp.Name(), staged); err != nil {
+		return "", errors.Wrap(err, "failed to move staged binary in place")
+	}
+
+	return staged, nil
+}
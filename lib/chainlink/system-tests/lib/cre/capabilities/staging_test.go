@@ -0,0 +1,62 @@
+package capabilities
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStageBinaryKeepsNameAndSeparatesVersions(t *testing.T) {
+	stagingDir := t.TempDir()
+	v1 := filepath.Join(t.TempDir(), "cron")
+	v2 := filepath.Join(t.TempDir(), "cron")
+	require.NoError(t, os.WriteFile(v1, []byte("v1"), 0o444))
+	require.NoError(t, os.WriteFile(v2, []byte("v2"), 0o444))
+
+	stagedV1, err := stageBinary(v1, stagingDir)
+	require.NoError(t, err)
+	stagedV2, err := stageBinary(v2, stagingDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "cron", filepath.Base(stagedV1))
+	assert.NotEqual(t, stagedV1, stagedV2)
+	info, err := os.Stat(stagedV1)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	content, err := os.ReadFile(stagedV2)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(content))
+
+	again, err := stageBinary(v1, stagingDir)
+	require.NoError(t, err)
+	assert.Equal(t, stagedV1, again)
+}
+
+func TestPreparedPathReturnsStagedCopy(t *testing.T) {
+	binaryPath := filepath.Join(t.TempDir(), "cron")
+	require.NoError(t, os.WriteFile(binaryPath, []byte("binary"), 0o600))
+
+	preparer := NewBinaryPreparer(0, nil)
+	preparer.UseStagingDir(t.TempDir())
+	require.NoError(t, preparer.Prepare(context.Background(), []Binary{{Capability: "cron", Path: binaryPath}}))
+	assert.Equal(t, binaryPath, preparer.PreparedPath(binaryPath), "binaries made executable in place aren't staged")
+
+	abs, err := filepath.Abs(binaryPath)
+	require.NoError(t, err)
+	preparer.staged[abs] = "/staged/cron"
+	assert.Equal(t, map[string]string{"cron": "/staged/cron", "consensus": "consensus"}, preparer.PreparedPaths(map[string]string{"cron": binaryPath, "consensus": "consensus"}))
+}
+
+func TestPrepareBinariesReturnsPreparedPaths(t *testing.T) {
+	binaryPath := filepath.Join(t.TempDir(), "cron")
+	require.NoError(t, os.WriteFile(binaryPath, []byte("binary"), 0o600))
+
+	prepared, err := PrepareBinaries(context.Background(), map[string]string{"cron": binaryPath}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"cron": binaryPath}, prepared)
+	require.NoError(t, MakeBinariesExecutable(map[string]string{"cron": binaryPath}))
+}
@@ -0,0 +1,50 @@
+package capabilities
+
+import (
+	"context"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/experimental/tracing"
+)
+
+// Stages of capability setup reported only to tracers, see tracing.ContextWithTracer
+const (
+	BinaryStageResolve       BinaryStage = "resolve"        // download of a remote binary
+	BinaryStageBuild         BinaryStage = "build"          // cross-compilation of a binary from source
+	BinaryStageNodeSpec      BinaryStage = "node-spec"      // appending binaries to node specs of a nodeset
+	BinaryStageRegistrySetup BinaryStage = "registry-setup" // full configuration of the capabilities registry
+	BinaryStageRegistrySync  BinaryStage = "registry-sync"  // SyncRegistry of a DON
+)
+
+// Span is a stage of capability setup.
+//
+// Deprecated: use tracing.Span.
+type Span = tracing.Span
+
+// Tracer records stages of capability setup.
+//
+// Deprecated: use tracing.Tracer.
+type Tracer = tracing.Tracer
+
+// StageTiming is a finished span recorded by StageTimings.
+//
+// Deprecated: use tracing.StageTiming.
+type StageTiming = tracing.StageTiming
+
+// StageTimings records how long each stage of capability setup took.
+//
+// Deprecated: use tracing.StageTimings.
+type StageTimings = tracing.StageTimings
+
+// ContextWithTracer returns a context, with which stages of capability setup are reported to the tracer.
+//
+// Deprecated: use tracing.ContextWithTracer.
+func ContextWithTracer(ctx context.Context, t Tracer) context.Context {
+	return tracing.ContextWithTracer(ctx, t)
+}
+
+// StartSpan starts the span with the tracer of the context, if any.
+//
+// Deprecated: use tracing.StartSpan.
+func StartSpan(ctx context.Context, span Span) (context.Context, func(err error)) {
+	return tracing.StartSpan(ctx, span)
+}
@@ -0,0 +1,61 @@
+package capabilities
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/experimental/tracing"
+)
+
+func TestStageTimingsRecordsSetupStages(t *testing.T) {
+	timings := &tracing.StageTimings{}
+	ctx := tracing.ContextWithTracer(context.Background(), timings)
+
+	binaryPath := filepath.Join(t.TempDir(), "cron")
+	require.NoError(t, os.WriteFile(binaryPath, []byte("binary"), 0o600))
+
+	preparer := NewBinaryPreparer(0, nil)
+	require.NoError(t, preparer.Prepare(ctx, []Binary{{Capability: "cron", Path: binaryPath}}))
+	copyErr := errors.New("container is gone")
+	require.ErrorIs(t, preparer.Copy(ctx, []BinaryCopy{{Path: binaryPath, Capability: "cron", Target: "node 0", Copy: func(context.Context) error { return copyErr }}}), copyErr)
+
+	recorded := timings.Timings()
+	require.Len(t, recorded, 2)
+	assert.Equal(t, tracing.Span{Stage: BinaryStagePrepare, Capability: "cron", Path: binaryPath}, recorded[0].Span)
+	require.NoError(t, recorded[0].Err)
+	assert.Equal(t, tracing.Span{Stage: BinaryStageCopy, Capability: "cron", Target: "node 0", Path: binaryPath}, recorded[1].Span)
+	require.ErrorIs(t, recorded[1].Err, copyErr)
+
+	summary := timings.Summary()
+	assert.Contains(t, summary, "STAGE")
+	assert.Regexp(t, `copy\s+1\s+1\s+`, summary)
+	assert.Contains(t, summary, "cron")
+
+	otherPath := filepath.Join(t.TempDir(), "other")
+	require.NoError(t, os.WriteFile(otherPath, []byte("binary"), 0o600))
+	require.NoError(t, preparer.Prepare(context.Background(), []Binary{{Capability: "other", Path: otherPath}}))
+	assert.Len(t, timings.Timings(), 2, "without a tracer in the context nothing is recorded")
+}
+
+func TestNodeSpecSpansArePerNode(t *testing.T) {
+	timings := &tracing.StageTimings{}
+	nodeSet, donMetadata := mixedRoleNodeSet(t)
+	_, err := AppendBinariesPathsNodeSpecWithPreparer(tracing.ContextWithTracer(context.Background(), timings), NewBinaryPreparer(0, nil), nodeSet, donMetadata, map[string]string{"cron": "./binaries/cron"}, nil, nil)
+	require.NoError(t, err)
+
+	var targets []string
+	for _, timing := range timings.Timings() {
+		assert.Equal(t, BinaryStageNodeSpec, timing.Stage)
+		targets = append(targets, timing.Target)
+	}
+	assert.Equal(t, []string{"node 1 of nodeset workflow", "node 2 of nodeset workflow", "node 3 of nodeset workflow"}, targets)
+
+	_, err = AppendBinariesPathsNodeSpecWithPreparer(context.Background(), NewBinaryPreparer(0, nil), nil, donMetadata, nil, nil, nil)
+	require.ErrorContains(t, err, "nodeset is nil")
+}
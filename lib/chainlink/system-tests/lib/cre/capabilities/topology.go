@@ -0,0 +1,108 @@
+package capabilities
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// CapabilitiesConfig configures binaries of capabilities of all DONs of a topology, see ConfigureTopology
+type CapabilitiesConfig struct {
+	CapabilityConfigs cre.CapabilityConfigs
+	InfraType         infra.Type
+	// Overrides are keyed by DON name, see AppendBinariesPathsNodeSpecWithOverrides
+	Overrides map[string]*BinaryPathOverrides
+}
+
+// ConfigureTopology appends binaries of capabilities enabled on each DON of the topology to node specs of its nodes, as
+// AppendBinariesPathsNodeSpecWithRoles does for a single DON, and validates the result with ValidateNodeSet. Before
+// that it checks the topology with ValidateTopology. It returns copies of nodesets in the order of DONs, nodesets of the
+// topology aren't changed. DON-wide binaries aren't prepared, prepare them with BinaryPreparer first. Problems of all
+// DONs are returned at once. It's meant for callers, which configure nodesets without the environment package, setup
+// appends binaries prepared by its BinaryPreparer instead and calls only ValidateTopology.
+func ConfigureTopology(topology *cre.Topology, cfg CapabilitiesConfig) ([]*cre.CapabilitiesAwareNodeSet, error) {
+	if err := ValidateTopology(topology, cfg); err != nil {
+		return nil, err
+	}
+	donsMetadata := topology.DonsMetadata.List()
+
+	var problems []string
+	nodeSets := make([]*cre.CapabilitiesAwareNodeSet, len(donsMetadata))
+	for idx, donMetadata := range donsMetadata {
+		customBinariesPaths := DONBinariesPaths(donMetadata.Flags, cfg.CapabilityConfigs)
+		nodeSet, appendErr := AppendBinariesPathsNodeSpecWithRoles(donMetadata.CapabilitiesAwareNodeSet().Clone(), donMetadata, customBinariesPaths, cfg.CapabilityConfigs, cfg.Overrides[donMetadata.Name])
+		if appendErr != nil {
+			problems = append(problems, fmt.Sprintf("DON %s: %s", donMetadata.Name, appendErr))
+			continue
+		}
+		if err := ValidateNodeSet(nodeSet, cfg.InfraType, cfg.CapabilityConfigs); err != nil {
+			problems = append(problems, fmt.Sprintf("DON %s: %s", donMetadata.Name, err))
+			continue
+		}
+		nodeSets[idx] = nodeSet
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("failed to configure capabilities of the topology:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nodeSets, nil
+}
+
+// ValidateTopology checks constraints across DONs: names of DONs are unique, overrides target DONs of the topology and
+// capabilities installed on the topology don't have binaries with the same file name
+func ValidateTopology(topology *cre.Topology, cfg CapabilitiesConfig) error {
+	if topology == nil || topology.DonsMetadata == nil {
+		return errors.New("topology has no DONs")
+	}
+
+	if problems := topologyProblems(topology.DonsMetadata.List(), cfg); len(problems) > 0 {
+		return fmt.Errorf("topology is invalid:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// topologyProblems checks constraints, which can't be checked by looking at a single DON
+func topologyProblems(donsMetadata []*cre.DonMetadata, cfg CapabilitiesConfig) []string {
+	var problems []string
+
+	// node containers are named after DONs, see infra.Provider
+	donNames := make(map[string]struct{}, len(donsMetadata))
+	for _, donMetadata := range donsMetadata {
+		if _, ok := donNames[donMetadata.Name]; ok {
+			problems = append(problems, fmt.Sprintf("two DONs are named %s", donMetadata.Name))
+		}
+		donNames[donMetadata.Name] = struct{}{}
+	}
+
+	for _, donName := range slices.Sorted(maps.Keys(cfg.Overrides)) {
+		if _, ok := donNames[donName]; !ok {
+			problems = append(problems, fmt.Sprintf("binary overrides target DON %s, which isn't in the topology", donName))
+		}
+	}
+
+	// job specs reference binaries by file name, so capabilities can't share it, even if they are hosted by different DONs
+	installed := make(map[cre.CapabilityFlag]string)
+	for _, donMetadata := range donsMetadata {
+		maps.Copy(installed, DONBinariesPaths(donMetadata.Flags, cfg.CapabilityConfigs))
+	}
+	flagsByName := make(map[string][]cre.CapabilityFlag)
+	for _, flag := range slices.Sorted(maps.Keys(installed)) {
+		name := binaries.Name(installed[flag])
+		flagsByName[name] = append(flagsByName[name], flag)
+	}
+	for _, name := range slices.Sorted(maps.Keys(flagsByName)) {
+		if flags := flagsByName[name]; len(flags) > 1 {
+			problems = append(problems, fmt.Sprintf("capabilities %s have binaries with the same name %s", strings.Join(flags, ", "), name))
+		}
+	}
+
+	return problems
+}
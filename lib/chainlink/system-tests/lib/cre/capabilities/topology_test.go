@@ -0,0 +1,91 @@
+package capabilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// twoDONTopology has the workflow DON of mixedRoleNodeSet with cron and a capabilities DON of two nodes with HTTP action
+func twoDONTopology(t *testing.T) *cre.Topology {
+	t.Helper()
+
+	_, workflowDON := mixedRoleNodeSet(t)
+	nodeSet := &cre.CapabilitiesAwareNodeSet{
+		Input:              &ns.Input{Name: "capabilities"},
+		Capabilities:       []string{cre.HTTPActionCapability},
+		DONTypes:           []string{cre.CapabilitiesDON},
+		BootstrapNodeIndex: -1,
+		GatewayNodeIndex:   -1,
+	}
+	for range 2 {
+		nodeSet.NodeSpecs = append(nodeSet.NodeSpecs, &clnode.Input{Node: &clnode.NodeInput{}})
+	}
+	nodeSet.Nodes = len(nodeSet.NodeSpecs)
+	capabilitiesDON, err := cre.NewDonMetadata(nodeSet, 2, infra.Provider{Type: infra.Docker})
+	require.NoError(t, err)
+
+	donsMetadata, err := cre.NewDonsMetadata([]*cre.DonMetadata{workflowDON, capabilitiesDON}, infra.Provider{Type: infra.Docker})
+	require.NoError(t, err)
+
+	return &cre.Topology{WorkflowDONID: workflowDON.ID, DonsMetadata: donsMetadata}
+}
+
+func TestConfigureTopology(t *testing.T) {
+	topology := twoDONTopology(t)
+	cfg := CapabilitiesConfig{
+		CapabilityConfigs: cre.CapabilityConfigs{
+			cre.CronCapability:       {BinaryPath: "./binaries/cron"},
+			cre.HTTPActionCapability: {BinaryPath: "./binaries/http_action"},
+		},
+		InfraType: infra.Docker,
+	}
+
+	nodeSets, err := ConfigureTopology(topology, cfg)
+	require.NoError(t, err)
+	require.Len(t, nodeSets, 2)
+	assert.Equal(t, [][]string{nil, {"./binaries/cron"}, {"./binaries/cron"}, {"./binaries/cron"}}, nodeBinaries(nodeSets[0]))
+	assert.Equal(t, [][]string{{"./binaries/http_action"}, {"./binaries/http_action"}}, nodeBinaries(nodeSets[1]))
+	assert.Equal(t, [][]string{nil, nil, nil, nil}, nodeBinaries(topology.DonsMetadata.List()[0].CapabilitiesAwareNodeSet()), "topology must not change")
+
+	t.Run("cross-DON problems", func(t *testing.T) {
+		cfg := cfg
+		cfg.CapabilityConfigs = cre.CapabilityConfigs{
+			cre.CronCapability:       {BinaryPath: "./binaries/v1/capability"},
+			cre.HTTPActionCapability: {BinaryPath: "./binaries/v2/capability"},
+		}
+		cfg.Overrides = map[string]*BinaryPathOverrides{"gateway": {}}
+
+		_, err := ConfigureTopology(topology, cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "binary overrides target DON gateway, which isn't in the topology")
+		assert.Contains(t, err.Error(), "have binaries with the same name capability")
+	})
+
+	t.Run("setup checks only constraints across DONs", func(t *testing.T) {
+		cfg := cfg
+		cfg.CapabilityConfigs = cre.CapabilityConfigs{
+			cre.CronCapability:       {BinaryPath: "./binaries/v1/capability"},
+			cre.HTTPActionCapability: {BinaryPath: "./binaries/v2/capability"},
+		}
+
+		require.ErrorContains(t, ValidateTopology(topology, cfg), "have binaries with the same name capability")
+		require.ErrorContains(t, ValidateTopology(nil, cfg), "topology has no DONs")
+	})
+
+	t.Run("problems of DONs", func(t *testing.T) {
+		cfg := cfg
+		cfg.Overrides = map[string]*BinaryPathOverrides{"capabilities": {ByNodeIndex: map[int]map[cre.CapabilityFlag]string{0: {cre.CronCapability: "./binaries/v2/cron"}}}}
+
+		_, err := ConfigureTopology(topology, cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "DON capabilities: node 0 in nodeset capabilities overrides binary of capability cron")
+	})
+}
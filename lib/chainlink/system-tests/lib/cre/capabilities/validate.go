@@ -0,0 +1,101 @@
+package capabilities
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// ValidateNodeSet checks the assembled nodeset for mistakes, which otherwise surface only as errors of nodes once they
+// run: duplicate capability binaries of a node, capability binaries of the bootstrap node (it runs only capabilities
+// with the bootstrap role in node_roles of their config), DONs without capabilities and DON types, and capability
+// directories, which differ from the one job specs use with the infra type. It should be called after binaries are
+// appended to node specs. All problems are returned at once.
+func ValidateNodeSet(nodeSet *cre.CapabilitiesAwareNodeSet, infraType infra.Type, capabilityConfigs cre.CapabilityConfigs) error {
+	var problems []string
+
+	// binary names and flags of capabilities, which can be installed on the bootstrap node
+	bootstrapBinaries := make(map[string]struct{})
+	bootstrapFlags := make(map[string]struct{})
+	for flag, config := range capabilityConfigs {
+		if config.BinaryPath != "" && slices.Contains(config.NodeTypes(), cre.BootstrapNode) {
+			bootstrapBinaries[binaries.Name(config.BinaryPath)] = struct{}{}
+			bootstrapFlags[flag] = struct{}{}
+		}
+	}
+
+	if len(nodeSet.ComputedCapabilities) == 0 && len(nodeSet.DONTypes) == 0 {
+		problems = append(problems, "it has no capabilities and no DON types, set capabilities, chain_capabilities or don_types")
+	}
+
+	containerDir, containerDirErr := DefaultContainerDirectory(infraType)
+	if containerDirErr != nil {
+		return containerDirErr
+	}
+
+	for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+		if nodeSpec == nil || nodeSpec.Node == nil {
+			problems = append(problems, fmt.Sprintf("node %d has no node spec", nodeIdx))
+			continue
+		}
+		binaryPaths := nodeSpec.Node.CapabilitiesBinaryPaths
+
+		if nodeIdx == nodeSet.BootstrapNodeIndex {
+			var workerBinaries []string
+			for _, binaryPath := range binaryPaths {
+				if _, ok := bootstrapBinaries[binaries.Name(binaryPath)]; !ok {
+					workerBinaries = append(workerBinaries, binaryPath)
+				}
+			}
+			if len(workerBinaries) > 0 {
+				problems = append(problems, fmt.Sprintf("bootstrap node %d has capability binaries %s, but their capabilities don't have the bootstrap node role", nodeIdx, strings.Join(workerBinaries, ", ")))
+			}
+		}
+
+		// job specs reference binaries by file name, so binaries with the same name overwrite each other in the container
+		seen := make(map[string]string, len(binaryPaths))
+		for _, binaryPath := range binaryPaths {
+			name := binaries.Name(binaryPath)
+			if previous, ok := seen[name]; ok {
+				problems = append(problems, fmt.Sprintf("node %d has two capability binaries named %s: %s and %s", nodeIdx, name, previous, binaryPath))
+				continue
+			}
+			seen[name] = binaryPath
+		}
+
+		if dir := nodeSpec.Node.CapabilityContainerDir; len(binaryPaths) > 0 && dir != "" && strings.TrimSuffix(dir, "/") != containerDir {
+			problems = append(problems, fmt.Sprintf("node %d copies capability binaries to %s, but job specs with %s infra expect them in %s", nodeIdx, dir, infraType, containerDir))
+		}
+	}
+
+	if nodeSet.BootstrapNodeIndex != -1 {
+		bootstrapIdx := nodeSet.BootstrapNodeIndex
+		hasWorkerFlags := func(nodeBinaries map[string]string) bool {
+			for flag := range nodeBinaries {
+				if _, ok := bootstrapFlags[flag]; !ok {
+					return true
+				}
+			}
+			return false
+		}
+		if nodeBinaries, ok := nodeSet.NodeCapabilityBinaries[bootstrapIdx]; ok && hasWorkerFlags(nodeBinaries) {
+			problems = append(problems, fmt.Sprintf("node_capability_binaries override binaries of bootstrap node %d of capabilities, which don't have the bootstrap node role", bootstrapIdx))
+		}
+		for _, label := range slices.Sorted(maps.Keys(nodeSet.LabelCapabilityBinaries)) {
+			if slices.Equal(nodeSet.NodesWithLabel(label), []int{nodeSet.BootstrapNodeIndex}) && hasWorkerFlags(nodeSet.LabelCapabilityBinaries[label]) {
+				problems = append(problems, fmt.Sprintf("label '%s' overrides capability binaries only of bootstrap node %d, but their capabilities don't have the bootstrap node role", label, bootstrapIdx))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("nodeset %s is invalid:\n%s", nodeSet.Name, strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package capabilities
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+)
+
+// VerifyBinary checks the binary against the checksum and the signature set in its capability config, if any, so that
+// stale or corrupted binaries fail the setup before they are copied to nodes
+func VerifyBinary(capabilityFlag cre.CapabilityFlag, binaryPath string, config cre.CapabilityConfig) error {
+	if config.SHA256 != "" {
+		checksum, checksumErr := binaries.FileDigest(binaryPath)
+		if checksumErr != nil {
+			return errors.Wrapf(checksumErr, "failed to compute checksum of binary %s of capability %s", binaryPath, capabilityFlag)
+		}
+		if !strings.EqualFold(checksum, config.SHA256) {
+			return fmt.Errorf("checksum mismatch of binary %s of capability %s: expected sha256 %s, got %s. The binary is stale or corrupted, rebuild it or update the sha256 in the capabilities TOML config", binaryPath, capabilityFlag, config.SHA256, checksum)
+		}
+	}
+
+	if config.Signature != nil {
+		if err := verifySignature(binaryPath, config.Signature); err != nil {
+			return errors.Wrapf(err, "failed to verify signature of binary %s of capability %s", binaryPath, capabilityFlag)
+		}
+	}
+
+	return nil
+}
+
+func verifySignature(binaryPath string, signature *cre.BinarySignature) error {
+	signaturePath := signature.Path
+	if signaturePath == "" {
+		signaturePath = binaryPath + ".sig"
+	}
+	if _, err := os.Stat(signaturePath); err != nil {
+		return errors.Wrapf(err, "signature %s not found", signaturePath)
+	}
+
+	var name string
+	var args []string
+	switch signature.Type {
+	case cre.SignatureTypeGPG:
+		// gpgv looks up keyrings given without a directory in GNUPGHOME instead of the working directory
+		keyring, absErr := filepath.Abs(signature.PublicKey)
+		if absErr != nil {
+			return errors.Wrapf(absErr, "failed to get absolute path of keyring %s", signature.PublicKey)
+		}
+		name, args = "gpgv", []string{"--keyring", keyring, signaturePath, binaryPath}
+	case cre.SignatureTypeCosign:
+		name, args = "cosign", []string{"verify-blob", "--key", signature.PublicKey, "--signature", signaturePath, binaryPath}
+	default:
+		return fmt.Errorf("unsupported signature type '%s'", signature.Type)
+	}
+	if _, lookErr := exec.LookPath(name); lookErr != nil {
+		return fmt.Errorf("%s is required to verify %s signatures", name, signature.Type)
+	}
+
+	buffer := bytes.Buffer{}
+	cmd := exec.Command(name, args...) // #nosec G204 -- paths come from the capabilities TOML config
+	cmd.Stdout = &buffer
+	cmd.Stderr = &buffer
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "%s signature is invalid: %s", signature.Type, strings.TrimSpace(buffer.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package capabilities
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+)
+
+func TestResolveSignature(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/cron.sig":
+			_, _ = w.Write([]byte("default signature"))
+		case "/signatures/cron.sig":
+			_, _ = w.Write([]byte("remote signature"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	resolver := binaries.NewResolver(t.TempDir())
+	resolver.HTTPClient = server.Client()
+	localPath := filepath.Join(t.TempDir(), "cron")
+	original := cre.BinarySignature{Type: cre.SignatureTypeGPG, PublicKey: "keyring.gpg"}
+
+	// signatures of remote binaries are downloaded next to the cached binary, where VerifyBinary looks for them
+	signature, err := resolveSignature(context.Background(), resolver, server.URL+"/v1/cron", localPath, original)
+	require.NoError(t, err)
+	assert.Equal(t, localPath+".sig", signature.Path)
+	assert.Equal(t, original.PublicKey, signature.PublicKey)
+	downloaded, err := os.ReadFile(signature.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "default signature", string(downloaded))
+
+	remote := original
+	remote.Path = server.URL + "/signatures/cron.sig"
+	signature, err = resolveSignature(context.Background(), resolver, server.URL+"/v1/cron", localPath, remote)
+	require.NoError(t, err)
+	assert.Equal(t, localPath+".sig", signature.Path)
+	downloaded, err = os.ReadFile(signature.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "remote signature", string(downloaded))
+
+	local := original
+	local.Path = "./cron.sig"
+	signature, err = resolveSignature(context.Background(), resolver, server.URL+"/v1/cron", localPath, local)
+	require.NoError(t, err)
+	assert.Equal(t, local, *signature)
+
+	_, err = resolveSignature(context.Background(), resolver, server.URL+"/v1/missing", localPath, original)
+	require.ErrorContains(t, err, "failed to download signature")
+}
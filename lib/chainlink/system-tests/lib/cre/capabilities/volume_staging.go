@@ -0,0 +1,180 @@
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+const (
+	// nodeHomeDir is the home directory of the chainlink user, CTF mounts a named volume of each node there and charts of
+	// Kubernetes nodes usually put it on a persistent volume
+	nodeHomeDir = "/home/chainlink"
+
+	// VolumeStagingDir is where a BinaryPreparer with a cache stages binaries by their digest on persistent volumes of
+	// nodes, so that containers and pods created later with the same volume don't get the binaries again
+	VolumeStagingDir = nodeHomeDir + "/.capabilities"
+)
+
+// StageInDockerVolumes stages binaries of nodes, which aren't started yet, in home volumes of their containers and
+// replaces their paths in node specs with paths of small shims, which exec the staged binaries. CTF copies the shims
+// instead of the binaries when it creates the containers, binaries are copied only to volumes, which don't have them.
+// Volumes outlive containers, so nodes of later runs (or recreated nodes) with the same names get binaries from them.
+//
+// It does nothing without a cache (see UseCache). Nodesets, whose binaries have a configured owner, and nodes built
+// by CTF from a Dockerfile are skipped, those get the binaries copied by CTF as before.
+func (p *BinaryPreparer) StageInDockerVolumes(ctx context.Context, nodeSets []*cre.CapabilitiesAwareNodeSet, capabilityConfigs cre.CapabilityConfigs) error {
+	if p.cache == nil {
+		return nil
+	}
+
+	type stagedPath struct {
+		paths []string
+		idx   int
+		shim  string
+	}
+	var copies []BinaryCopy
+	var staged []stagedPath
+	for _, nodeSet := range nodeSets {
+		_, _, hasOwner, ownerErr := nodeSet.CapabilityBinariesOwnerIDs()
+		if ownerErr != nil {
+			return ownerErr
+		}
+		if hasOwner {
+			continue
+		}
+		for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+			imageName := nodeSpec.Node.Image
+			if imageName == "" || nodeSpec.Node.DockerContext != "" {
+				continue
+			}
+			volume := clnode.HomeVolumeName + "-" + ns.NodeNamePrefix(nodeSet.Name) + strconv.Itoa(nodeIdx)
+			for pathIdx, binaryPath := range nodeSpec.Node.CapabilitiesBinaryPaths {
+				digest, digestErr := p.cache.Digest(binaryPath)
+				if digestErr != nil {
+					return digestErr
+				}
+				stagedBinary := path.Join(VolumeStagingDir, digest, filepath.Base(binaryPath))
+				shim, shimErr := writeShim(p.stagingDir, digest, stagedBinary)
+				if shimErr != nil {
+					return errors.Wrapf(shimErr, "failed to write shim of binary %s", binaryPath)
+				}
+
+				copies = append(copies, BinaryCopy{
+					Path:       binaryPath,
+					Capability: BinaryCapability(binaryPath, capabilityConfigs),
+					Target:     fmt.Sprintf("home volume of node %d of nodeset %s", nodeIdx, nodeSet.Name),
+					Copy: func(ctx context.Context) error {
+						return infra.CopyFileToDockerVolume(ctx, volume, imageName, nodeHomeDir, binaryPath, stagedBinary)
+					},
+					// staged binaries are named by their digest
+					RemoteDigest: func(ctx context.Context) (string, error) {
+						found, err := infra.DockerVolumeHasFile(ctx, volume, imageName, nodeHomeDir, stagedBinary)
+						if !found {
+							return "", err
+						}
+						return digest, nil
+					},
+				})
+				staged = append(staged, stagedPath{paths: nodeSpec.Node.CapabilitiesBinaryPaths, idx: pathIdx, shim: shim})
+			}
+		}
+	}
+
+	if err := p.Copy(ctx, copies); err != nil {
+		return err
+	}
+	for _, s := range staged {
+		s.paths[s.idx] = s.shim
+	}
+
+	return nil
+}
+
+// PodBinaryCopy returns the copy of the binary to containerDir in the pod selected by the executor. With a cache (see
+// UseCache) the binary is staged by its digest in VolumeStagingDir and linked to containerDir, so that pods recreated
+// with the same persistent volume and pods switched back to a binary they had before don't get it again.
+func (p *BinaryPreparer) PodBinaryCopy(executor *infra.KubernetesExecutor, binaryPath, containerDir string) (BinaryCopy, error) {
+	installed := path.Join(containerDir, filepath.Base(binaryPath))
+	binaryCopy := BinaryCopy{
+		Path: binaryPath,
+		Copy: func(ctx context.Context) error {
+			return infra.CopyFileToKubernetesPod(ctx, executor, binaryPath, containerDir)
+		},
+		// symlinks to staged binaries are followed
+		RemoteDigest: func(ctx context.Context) (string, error) {
+			digest, _, err := infra.FileDigest(ctx, executor, installed)
+			return digest, err
+		},
+	}
+	if p.cache == nil {
+		return binaryCopy, nil
+	}
+
+	digest, digestErr := p.cache.Digest(binaryPath)
+	if digestErr != nil {
+		return BinaryCopy{}, digestErr
+	}
+	stageDir := path.Join(VolumeStagingDir, digest)
+	binaryCopy.Copy = func(ctx context.Context) error {
+		stagedDigest, _, digestErr := infra.FileDigest(ctx, executor, path.Join(stageDir, filepath.Base(binaryPath)))
+		if digestErr != nil {
+			return digestErr
+		}
+		if stagedDigest != digest {
+			if err := infra.CopyFileToKubernetesPod(ctx, executor, binaryPath, stageDir); err != nil {
+				return err
+			}
+		}
+		return infra.LinkFileInKubernetesPod(ctx, executor, path.Join(stageDir, filepath.Base(binaryPath)), installed)
+	}
+
+	return binaryCopy, nil
+}
+
+// writeShim writes a script, which execs the staged binary, named like the binary, so that job specs find it where
+// they expect the binary. Shims of the same binary are shared by nodes and runs.
+func writeShim(stagingDir, digest, stagedBinary string) (string, error) {
+	dir := filepath.Join(stagingDir, "shims", digest[:16])
+	shim := filepath.Join(dir, path.Base(stagedBinary))
+	content := fmt.Sprintf("#!/bin/sh\nexec '%s' \"$@\"\n", stagedBinary)
+	if existing, err := os.ReadFile(shim); err == nil && string(existing) == content {
+		return shim, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", errors.Wrap(err, "failed to create shim directory")
+	}
+	// write to a temporary file first, so that concurrent setups never see a partial shim
+	tmp, tmpErr := os.CreateTemp(dir, ".shim-*")
+	if tmpErr != nil {
+		return "", errors.Wrap(tmpErr, "failed to create shim")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		_ = tmp.Close()
+		return "", errors.Wrap(err, "failed to write shim")
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return "", errors.Wrap(err, "failed to make shim executable")
+	}
+	if err := os.Rename(tmp.Name(), shim); err != nil {
+		return "", errors.Wrap(err, "failed to move shim in place")
+	}
+
+	return shim, nil
+}
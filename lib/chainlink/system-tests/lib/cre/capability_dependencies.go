@@ -0,0 +1,168 @@
+package cre
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultCapabilityDependencies are dependencies of capabilities, whose configs don't declare depends_on. Workflows using
+// consensus v2 are run by the v2 engine, which reads time from DON time of the same DON. Set depends_on = [] in the
+// capability config to disable a default dependency. Default dependencies, which the flags provider doesn't support,
+// are skipped.
+var DefaultCapabilityDependencies = map[CapabilityFlag][]CapabilityFlag{
+	ConsensusCapabilityV2: {DONTimeCapability},
+}
+
+// capabilityDependencies returns dependencies declared in the config of the capability, or the default ones
+func capabilityDependencies(capabilityConfigs CapabilityConfigs, flag CapabilityFlag) (dependencies []CapabilityFlag, isDefault bool) {
+	if config, ok := capabilityConfigs[flag]; ok && config.DependsOn != nil {
+		return config.DependsOn, false
+	}
+
+	return DefaultCapabilityDependencies[flag], true
+}
+
+// ValidateCapabilityDependencies checks that dependencies declared in capability configs (depends_on) and default ones
+// don't form a cycle, e.g. a -> b -> a, which can't be resolved. All configs are checked, not only those enabled on some DON.
+func ValidateCapabilityDependencies(capabilityConfigs CapabilityConfigs) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[CapabilityFlag]int, len(capabilityConfigs))
+
+	var visit func(flag CapabilityFlag, path []CapabilityFlag) error
+	visit = func(flag CapabilityFlag, path []CapabilityFlag) error {
+		switch state[flag] {
+		case visited:
+			return nil
+		case visiting:
+			cycleStart := slices.Index(path, flag)
+			return fmt.Errorf("capability dependencies form a cycle: %s", strings.Join(append(slices.Clone(path[cycleStart:]), flag), " -> "))
+		}
+
+		state[flag] = visiting
+		dependencies, _ := capabilityDependencies(capabilityConfigs, flag)
+		for _, dependency := range dependencies {
+			if dependency == "" {
+				return fmt.Errorf("depends_on of capability %s contains an empty capability flag", flag)
+			}
+			if err := visit(dependency, append(path, flag)); err != nil {
+				return err
+			}
+		}
+		state[flag] = visited
+
+		return nil
+	}
+
+	capabilityFlags := slices.Concat(slices.Collect(maps.Keys(capabilityConfigs)), slices.Collect(maps.Keys(DefaultCapabilityDependencies)))
+	slices.Sort(capabilityFlags)
+	for _, flag := range slices.Compact(capabilityFlags) {
+		if err := visit(flag, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResolveCapabilityDependencies enables transitive dependencies of capabilities of the nodeset, declared with depends_on
+// in capability configs or by DefaultCapabilityDependencies, and returns the added flags. Dependencies of chain-specific capabilities, which are chain-specific
+// too, are enabled on the same chain, e.g. write-evm-1337 depending on evm enables evm-1337. Global capabilities can
+// depend on chain-specific ones only if the nodeset already enables them on some chain, because the chain is unknown.
+// It fails on cycles and on dependencies, which aren't supported capability flags.
+func (c *CapabilitiesAwareNodeSet) ResolveCapabilityDependencies(capabilityConfigs CapabilityConfigs, flagsProvider CapabilityFlagsProvider) ([]string, error) {
+	if err := ValidateCapabilityDependencies(capabilityConfigs); err != nil {
+		return nil, err
+	}
+
+	chainSpecific := flagsProvider.ChainSpecificCapabilityFlags()
+	supported := flagsProvider.SupportedCapabilityFlags()
+
+	var added []string
+	pending := slices.Clone(c.ComputedCapabilities)
+	for len(pending) > 0 {
+		flag := pending[0]
+		pending = pending[1:]
+
+		capability, chainID, isChainSpecific := splitChainFlag(flag, chainSpecific)
+		dependencies, isDefault := capabilityDependencies(capabilityConfigs, capability)
+		for _, dependency := range dependencies {
+			if !slices.Contains(supported, dependency) {
+				if isDefault {
+					continue
+				}
+				return nil, fmt.Errorf("capability %s of nodeset %s depends on capability %s, which isn't supported. Add it to the capabilityFlagsProvider or remove it from depends_on of %s", capability, c.Name, dependency, capability)
+			}
+
+			dependencyFlag := dependency
+			if slices.Contains(chainSpecific, dependency) {
+				if !isChainSpecific {
+					if c.hasCapabilityOnAnyChain(dependency, chainSpecific) {
+						continue
+					}
+					return nil, fmt.Errorf("global capability %s of nodeset %s depends on chain-specific capability %s, which isn't enabled on any chain. Enable it under chain_capabilities of the nodeset", capability, c.Name, dependency)
+				}
+				dependencyFlag = dependency + "-" + strconv.FormatUint(chainID, 10)
+			}
+			if slices.Contains(c.ComputedCapabilities, dependencyFlag) {
+				continue
+			}
+
+			c.ComputedCapabilities = append(c.ComputedCapabilities, dependencyFlag)
+			if dependencyFlag != dependency {
+				c.enableChainCapability(dependency, chainID)
+			}
+			added = append(added, dependencyFlag)
+			pending = append(pending, dependencyFlag)
+		}
+	}
+
+	return added, nil
+}
+
+// splitChainFlag splits computed flags of chain-specific capabilities, e.g. evm-1337, into the capability and chain ID
+func splitChainFlag(flag string, chainSpecific []CapabilityFlag) (CapabilityFlag, uint64, bool) {
+	capability := ""
+	for _, candidate := range chainSpecific {
+		// the longest match wins, so that e.g. write-evm isn't split as write + evm
+		if strings.HasPrefix(flag, candidate+"-") && len(candidate) > len(capability) {
+			capability = candidate
+		}
+	}
+	if capability == "" {
+		return flag, 0, false
+	}
+	chainID, err := strconv.ParseUint(strings.TrimPrefix(flag, capability+"-"), 10, 64)
+	if err != nil {
+		return flag, 0, false
+	}
+
+	return capability, chainID, true
+}
+
+func (c *CapabilitiesAwareNodeSet) hasCapabilityOnAnyChain(capability CapabilityFlag, chainSpecific []CapabilityFlag) bool {
+	return slices.ContainsFunc(c.ComputedCapabilities, func(flag string) bool {
+		enabled, _, isChainSpecific := splitChainFlag(flag, chainSpecific)
+		return isChainSpecific && enabled == capability
+	})
+}
+
+func (c *CapabilitiesAwareNodeSet) enableChainCapability(capability CapabilityFlag, chainID uint64) {
+	if c.ChainCapabilities == nil {
+		c.ChainCapabilities = make(map[string]*ChainCapabilityConfig)
+	}
+	config, ok := c.ChainCapabilities[capability]
+	if !ok {
+		config = &ChainCapabilityConfig{}
+		c.ChainCapabilities[capability] = config
+	}
+	if !slices.Contains(config.EnabledChains, chainID) {
+		config.EnabledChains = append(config.EnabledChains, chainID)
+	}
+}
@@ -0,0 +1,83 @@
+package cre
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+)
+
+type testFlagsProvider struct {
+	global, chainSpecific []CapabilityFlag
+}
+
+func (p testFlagsProvider) SupportedCapabilityFlags() []CapabilityFlag {
+	return append(append([]CapabilityFlag{}, p.global...), p.chainSpecific...)
+}
+
+func (p testFlagsProvider) GlobalCapabilityFlags() []CapabilityFlag { return p.global }
+
+func (p testFlagsProvider) ChainSpecificCapabilityFlags() []CapabilityFlag { return p.chainSpecific }
+
+var dependenciesFlagsProvider = testFlagsProvider{
+	global:        []CapabilityFlag{ConsensusCapabilityV2, DONTimeCapability, CronCapability},
+	chainSpecific: []CapabilityFlag{EVMCapability, WriteEVMCapability},
+}
+
+func dependenciesNodeSet(capabilities ...string) *CapabilitiesAwareNodeSet {
+	return &CapabilitiesAwareNodeSet{Input: &ns.Input{Name: "workflow"}, ComputedCapabilities: capabilities}
+}
+
+func TestResolveCapabilityDependencies(t *testing.T) {
+	t.Run("default dependencies", func(t *testing.T) {
+		nodeSet := dependenciesNodeSet(ConsensusCapabilityV2)
+		added, err := nodeSet.ResolveCapabilityDependencies(CapabilityConfigs{}, dependenciesFlagsProvider)
+		require.NoError(t, err)
+		assert.Equal(t, []string{DONTimeCapability}, added)
+		assert.Equal(t, []string{ConsensusCapabilityV2, DONTimeCapability}, nodeSet.ComputedCapabilities)
+
+		added, err = nodeSet.ResolveCapabilityDependencies(CapabilityConfigs{}, dependenciesFlagsProvider)
+		require.NoError(t, err)
+		assert.Empty(t, added, "resolving again must not add anything")
+	})
+
+	t.Run("declared dependencies replace default ones", func(t *testing.T) {
+		nodeSet := dependenciesNodeSet(ConsensusCapabilityV2)
+		added, err := nodeSet.ResolveCapabilityDependencies(CapabilityConfigs{ConsensusCapabilityV2: {DependsOn: []CapabilityFlag{}}}, dependenciesFlagsProvider)
+		require.NoError(t, err)
+		assert.Empty(t, added)
+	})
+
+	t.Run("unsupported default dependencies are skipped", func(t *testing.T) {
+		provider := testFlagsProvider{global: []CapabilityFlag{ConsensusCapabilityV2}}
+		added, err := dependenciesNodeSet(ConsensusCapabilityV2).ResolveCapabilityDependencies(CapabilityConfigs{}, provider)
+		require.NoError(t, err)
+		assert.Empty(t, added)
+
+		_, err = dependenciesNodeSet(CronCapability).ResolveCapabilityDependencies(CapabilityConfigs{CronCapability: {DependsOn: []CapabilityFlag{"unknown"}}}, provider)
+		require.ErrorContains(t, err, "depends on capability unknown, which isn't supported")
+	})
+
+	t.Run("chain-specific dependencies are enabled on the same chain", func(t *testing.T) {
+		nodeSet := dependenciesNodeSet("write-evm-1337")
+		added, err := nodeSet.ResolveCapabilityDependencies(CapabilityConfigs{WriteEVMCapability: {DependsOn: []CapabilityFlag{EVMCapability}}}, dependenciesFlagsProvider)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"evm-1337"}, added)
+		assert.Equal(t, []uint64{1337}, nodeSet.ChainCapabilities[EVMCapability].EnabledChains)
+	})
+
+	t.Run("cycles", func(t *testing.T) {
+		configs := CapabilityConfigs{
+			CronCapability:    {DependsOn: []CapabilityFlag{DONTimeCapability}},
+			DONTimeCapability: {DependsOn: []CapabilityFlag{CronCapability}},
+		}
+		_, err := dependenciesNodeSet(CronCapability).ResolveCapabilityDependencies(configs, dependenciesFlagsProvider)
+		require.ErrorContains(t, err, "capability dependencies form a cycle: don-time -> cron -> don-time")
+
+		// default dependencies are part of the graph too
+		err = ValidateCapabilityDependencies(CapabilityConfigs{DONTimeCapability: {DependsOn: []CapabilityFlag{ConsensusCapabilityV2}}})
+		require.ErrorContains(t, err, "capability dependencies form a cycle: consensus -> don-time -> consensus")
+	})
+}
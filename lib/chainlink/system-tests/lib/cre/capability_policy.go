@@ -0,0 +1,388 @@
+package cre
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	capabilitiespb "github.com/smartcontractkit/chainlink-common/pkg/capabilities/pb"
+	kcr "github.com/smartcontractkit/chainlink-evm/gethwrappers/keystone/generated/capabilities_registry_1_1_0"
+	capabilities_registry_v2 "github.com/smartcontractkit/chainlink-evm/gethwrappers/workflow/generated/capabilities_registry_wrapper_v2"
+
+	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
+)
+
+// envCRESettingsDefault overrides default CRE settings of the workflow engine (JSON, merged into defaults)
+const envCRESettingsDefault = "CL_CRE_SETTINGS_DEFAULT"
+
+const (
+	TransmissionScheduleAllAtOnce  = "all_at_once"
+	TransmissionScheduleOneAtATime = "one_at_a_time"
+)
+
+// CapabilityPolicy sets step-level timeouts and retries of a capability, which are otherwise left at their defaults.
+// Empty fields keep the values set by the capability's feature. Executable capabilities (actions, targets) retry
+// by transmitting the request to the next node after DeltaStage with the one_at_a_time schedule,
+// triggers retry by re-registering every RegistrationRefresh.
+type CapabilityPolicy struct {
+	RequestTimeout       string `toml:"request_timeout"`       // Go duration, how long the workflow DON waits for responses of the capability DON
+	TransmissionSchedule string `toml:"transmission_schedule"` // all_at_once or one_at_a_time
+	DeltaStage           string `toml:"delta_stage"`           // Go duration, delay between transmissions to consecutive nodes with one_at_a_time
+	MaxParallelRequests  uint32 `toml:"max_parallel_requests"` // per capability node
+	RegistrationRefresh  string `toml:"registration_refresh"`  // Go duration, triggers only
+	RegistrationExpiry   string `toml:"registration_expiry"`   // Go duration, triggers only
+	// Methods override the policy for selected methods of capabilities with method configs, e.g. WriteReport of evm
+	Methods map[string]*CapabilityPolicy `toml:"methods"`
+}
+
+func (p *CapabilityPolicy) Validate() error {
+	for name, value := range map[string]string{
+		"request_timeout":      p.RequestTimeout,
+		"delta_stage":          p.DeltaStage,
+		"registration_refresh": p.RegistrationRefresh,
+		"registration_expiry":  p.RegistrationExpiry,
+	} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("%s must be a positive Go duration, got '%s'", name, value)
+		}
+	}
+
+	switch p.TransmissionSchedule {
+	case "", TransmissionScheduleAllAtOnce, TransmissionScheduleOneAtATime:
+	default:
+		return fmt.Errorf("unsupported transmission schedule '%s', supported: %s, %s", p.TransmissionSchedule, TransmissionScheduleAllAtOnce, TransmissionScheduleOneAtATime)
+	}
+
+	for method, methodPolicy := range p.Methods {
+		if len(methodPolicy.Methods) > 0 {
+			return fmt.Errorf("method %s can't have nested methods", method)
+		}
+		if err := methodPolicy.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid policy of method %s", method)
+		}
+	}
+
+	return nil
+}
+
+// ValidateCapabilityPolicies validates capability policies and the engine's capability call timeout of the nodeset
+func (c *CapabilitiesAwareNodeSet) ValidateCapabilityPolicies() error {
+	for name, policy := range c.CapabilityPolicies {
+		if err := policy.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid policy of capability %s", name)
+		}
+	}
+
+	if c.CapabilityCallTimeout != "" {
+		if d, err := time.ParseDuration(c.CapabilityCallTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("capability_call_timeout must be a positive Go duration, got '%s'", c.CapabilityCallTimeout)
+		}
+	}
+
+	return nil
+}
+
+// CapabilityPolicy returns the policy for a capability with the labelled name. Chain-specific capabilities
+// (e.g. "evm:ChainSelector:123") also match policies set for their base name ("evm"), the exact name takes precedence.
+func (c *CapabilitiesAwareNodeSet) CapabilityPolicy(labelledName string) (*CapabilityPolicy, bool) {
+	if policy, ok := c.CapabilityPolicies[labelledName]; ok {
+		return policy, true
+	}
+	if base, _, found := strings.Cut(labelledName, ":"); found {
+		policy, ok := c.CapabilityPolicies[base]
+		return policy, ok
+	}
+
+	return nil, false
+}
+
+// ApplyCapabilityPolicies overrides remote configs of DON capabilities with the nodeset's policies
+func (c *CapabilitiesAwareNodeSet) ApplyCapabilityPolicies(capabilities []keystone_changeset.DONCapabilityWithConfig) error {
+	for idx := range capabilities {
+		policy, ok := c.CapabilityPolicy(capabilities[idx].Capability.LabelledName)
+		if !ok {
+			continue
+		}
+		if capabilities[idx].Config == nil {
+			capabilities[idx].Config = &capabilitiespb.CapabilityConfig{}
+		}
+		if err := applyCapabilityPolicy(capabilities[idx].Capability.CapabilityType, capabilities[idx].Config, policy); err != nil {
+			return errors.Wrapf(err, "failed to apply policy to capability %s", capabilities[idx].Capability.LabelledName)
+		}
+	}
+
+	return nil
+}
+
+// capability types as defined in the capabilities registry
+const (
+	capabilityTypeTrigger   = 0
+	capabilityTypeConsensus = 2
+)
+
+func applyCapabilityPolicy(capabilityType uint8, config *capabilitiespb.CapabilityConfig, policy *CapabilityPolicy) error {
+	if len(config.MethodConfigs) > 0 {
+		for method, methodConfig := range config.MethodConfigs {
+			methodPolicy := policy
+			if override, ok := policy.Methods[method]; ok {
+				methodPolicy = mergePolicies(policy, override)
+			}
+			switch remote := methodConfig.RemoteConfig.(type) {
+			case *capabilitiespb.CapabilityMethodConfig_RemoteTriggerConfig:
+				applyTriggerPolicy(remote.RemoteTriggerConfig, methodPolicy)
+			case *capabilitiespb.CapabilityMethodConfig_RemoteExecutableConfig:
+				applyExecutablePolicy(remote.RemoteExecutableConfig, methodPolicy)
+			}
+		}
+		return nil
+	}
+
+	if len(policy.Methods) > 0 {
+		return errors.New("policy has method overrides, but the capability has no method configs")
+	}
+
+	switch remote := config.RemoteConfig.(type) {
+	case *capabilitiespb.CapabilityConfig_RemoteTriggerConfig:
+		applyTriggerPolicy(remote.RemoteTriggerConfig, policy)
+	case *capabilitiespb.CapabilityConfig_RemoteTargetConfig:
+		if policy.RequestTimeout != "" || policy.TransmissionSchedule != "" || policy.DeltaStage != "" {
+			return errors.New("timeouts and retries of target capabilities with remote target config are not configurable, use remote executable config")
+		}
+	case *capabilitiespb.CapabilityConfig_RemoteExecutableConfig:
+		applyExecutablePolicy(remote.RemoteExecutableConfig, policy)
+	case nil:
+		// capability is local to the DON, remote config is added only if the policy sets something. Consensus runs
+		// on the workflow DON itself and is never called remotely, a remote config would have no effect.
+		if capabilityType == capabilityTypeConsensus {
+			return errors.New("consensus capabilities aren't called remotely, their timeouts and retries are not configurable")
+		}
+		if capabilityType == capabilityTypeTrigger {
+			trigger := &capabilitiespb.RemoteTriggerConfig{}
+			applyTriggerPolicy(trigger, policy)
+			if !proto.Equal(trigger, &capabilitiespb.RemoteTriggerConfig{}) {
+				config.RemoteConfig = &capabilitiespb.CapabilityConfig_RemoteTriggerConfig{RemoteTriggerConfig: trigger}
+			}
+			return nil
+		}
+		executable := &capabilitiespb.RemoteExecutableConfig{}
+		applyExecutablePolicy(executable, policy)
+		if !proto.Equal(executable, &capabilitiespb.RemoteExecutableConfig{}) {
+			config.RemoteConfig = &capabilitiespb.CapabilityConfig_RemoteExecutableConfig{RemoteExecutableConfig: executable}
+		}
+	}
+
+	return nil
+}
+
+func mergePolicies(base, override *CapabilityPolicy) *CapabilityPolicy {
+	merged := *base
+	merged.Methods = nil
+	if override.RequestTimeout != "" {
+		merged.RequestTimeout = override.RequestTimeout
+	}
+	if override.TransmissionSchedule != "" {
+		merged.TransmissionSchedule = override.TransmissionSchedule
+	}
+	if override.DeltaStage != "" {
+		merged.DeltaStage = override.DeltaStage
+	}
+	if override.MaxParallelRequests != 0 {
+		merged.MaxParallelRequests = override.MaxParallelRequests
+	}
+	if override.RegistrationRefresh != "" {
+		merged.RegistrationRefresh = override.RegistrationRefresh
+	}
+	if override.RegistrationExpiry != "" {
+		merged.RegistrationExpiry = override.RegistrationExpiry
+	}
+
+	return &merged
+}
+
+func applyExecutablePolicy(config *capabilitiespb.RemoteExecutableConfig, policy *CapabilityPolicy) {
+	if d, ok := parsePolicyDuration(policy.RequestTimeout); ok {
+		config.RequestTimeout = durationpb.New(d)
+	}
+	if d, ok := parsePolicyDuration(policy.DeltaStage); ok {
+		config.DeltaStage = durationpb.New(d)
+	}
+	switch policy.TransmissionSchedule {
+	case TransmissionScheduleAllAtOnce:
+		config.TransmissionSchedule = capabilitiespb.TransmissionSchedule_AllAtOnce
+	case TransmissionScheduleOneAtATime:
+		config.TransmissionSchedule = capabilitiespb.TransmissionSchedule_OneAtATime
+	}
+	if policy.MaxParallelRequests != 0 {
+		config.ServerMaxParallelRequests = policy.MaxParallelRequests
+	}
+}
+
+func applyTriggerPolicy(config *capabilitiespb.RemoteTriggerConfig, policy *CapabilityPolicy) {
+	if d, ok := parsePolicyDuration(policy.RegistrationRefresh); ok {
+		config.RegistrationRefresh = durationpb.New(d)
+	}
+	if d, ok := parsePolicyDuration(policy.RegistrationExpiry); ok {
+		config.RegistrationExpiry = durationpb.New(d)
+	}
+}
+
+func parsePolicyDuration(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	d, err := time.ParseDuration(value)
+
+	return d, err == nil
+}
+
+// ApplyEngineSettings passes the capability call timeout to the workflow engine of each node. Nodes, whose specs
+// already set the env var of default CRE settings, would silently ignore the timeout, so they are an error: add the
+// timeout to the env var instead. Nodes, to which the settings were already applied, are skipped, so that DONs can be
+// started again with the same nodeset.
+func (c *CapabilitiesAwareNodeSet) ApplyEngineSettings() error {
+	if c.CapabilityCallTimeout == "" {
+		return nil
+	}
+
+	settings, mErr := json.Marshal(map[string]any{"PerWorkflow": map[string]string{"CapabilityCallTimeout": c.CapabilityCallTimeout}})
+	if mErr != nil {
+		return errors.Wrap(mErr, "failed to marshal CRE settings")
+	}
+
+	for nodeIdx, nodeSpec := range c.NodeSpecs {
+		if value, ok := nodeSpec.Node.EnvVars[envCRESettingsDefault]; ok {
+			if value == string(settings) {
+				continue
+			}
+			return fmt.Errorf("node %d sets %s, which can't be combined with capability_call_timeout, set PerWorkflow.CapabilityCallTimeout in it instead", nodeIdx, envCRESettingsDefault)
+		}
+		envVars := make(map[string]string, len(nodeSpec.Node.EnvVars)+1)
+		maps.Copy(envVars, nodeSpec.Node.EnvVars)
+		envVars[envCRESettingsDefault] = string(settings)
+		c.NodeSpecs[nodeIdx].Node.EnvVars = envVars
+	}
+
+	return nil
+}
+
+// registryCapabilityConfig is a capability config of a DON read from the capabilities registry
+type registryCapabilityConfig struct {
+	id             string // <labelled name>@<version>
+	labelledName   string
+	capabilityType uint8
+	config         []byte
+}
+
+// VerifyCapabilityPolicies checks that the capability configs of the DON stored in the capabilities registry (v1.1)
+// reflect the nodeset's policies, so that a test exercising timeouts or retries fails early, if they weren't applied.
+// Use VerifyCapabilityPoliciesV2 with the v2 registry.
+func VerifyCapabilityPolicies(registry *kcr.CapabilitiesRegistry, donID uint32, nodeSet *CapabilitiesAwareNodeSet) error {
+	don, donErr := registry.GetDON(&bind.CallOpts{}, donID)
+	if donErr != nil {
+		return errors.Wrapf(donErr, "failed to get DON %d from capabilities registry", donID)
+	}
+
+	configs := make([]registryCapabilityConfig, 0, len(don.CapabilityConfigurations))
+	for _, capabilityConfig := range don.CapabilityConfigurations {
+		info, infoErr := registry.GetCapability(&bind.CallOpts{}, capabilityConfig.CapabilityId)
+		if infoErr != nil {
+			return errors.Wrap(infoErr, "failed to get capability from capabilities registry")
+		}
+		configs = append(configs, registryCapabilityConfig{
+			id:             fmt.Sprintf("%s@%s", info.LabelledName, info.Version),
+			labelledName:   info.LabelledName,
+			capabilityType: info.CapabilityType,
+			config:         capabilityConfig.Config,
+		})
+	}
+
+	return verifyCapabilityPolicies(donID, nodeSet, configs)
+}
+
+// VerifyCapabilityPoliciesV2 is VerifyCapabilityPolicies for the capabilities registry v2, which identifies
+// capabilities by <labelled name>@<version> and keeps their types in metadata
+func VerifyCapabilityPoliciesV2(registry *capabilities_registry_v2.CapabilitiesRegistry, donID uint32, nodeSet *CapabilitiesAwareNodeSet) error {
+	don, donErr := registry.GetDON(&bind.CallOpts{}, donID)
+	if donErr != nil {
+		return errors.Wrapf(donErr, "failed to get DON %d from capabilities registry", donID)
+	}
+
+	configs := make([]registryCapabilityConfig, 0, len(don.CapabilityConfigurations))
+	for _, capabilityConfig := range don.CapabilityConfigurations {
+		info, infoErr := registry.GetCapability(&bind.CallOpts{}, capabilityConfig.CapabilityId)
+		if infoErr != nil {
+			return errors.Wrapf(infoErr, "failed to get capability %s from capabilities registry", capabilityConfig.CapabilityId)
+		}
+		capabilityType, typeErr := capabilityTypeFromMetadata(info.Metadata)
+		if typeErr != nil {
+			return errors.Wrapf(typeErr, "invalid metadata of capability %s", capabilityConfig.CapabilityId)
+		}
+		labelledName := capabilityConfig.CapabilityId
+		if idx := strings.LastIndex(labelledName, "@"); idx != -1 {
+			labelledName = labelledName[:idx]
+		}
+		configs = append(configs, registryCapabilityConfig{
+			id:             capabilityConfig.CapabilityId,
+			labelledName:   labelledName,
+			capabilityType: capabilityType,
+			config:         capabilityConfig.Config,
+		})
+	}
+
+	return verifyCapabilityPolicies(donID, nodeSet, configs)
+}
+
+// capabilityTypeFromMetadata reads the capability type from metadata of a capability in the v2 registry, it's JSON
+// written by the configuration of the registry
+func capabilityTypeFromMetadata(metadata []byte) (uint8, error) {
+	parsed := struct {
+		CapabilityType uint8 `json:"capabilityType"`
+	}{}
+	if err := json.Unmarshal(metadata, &parsed); err != nil {
+		return 0, err
+	}
+
+	return parsed.CapabilityType, nil
+}
+
+func verifyCapabilityPolicies(donID uint32, nodeSet *CapabilitiesAwareNodeSet, configs []registryCapabilityConfig) error {
+	var mismatches []string
+	for _, capabilityConfig := range configs {
+		policy, ok := nodeSet.CapabilityPolicy(capabilityConfig.labelledName)
+		if !ok {
+			continue
+		}
+
+		config := &capabilitiespb.CapabilityConfig{}
+		// the v2 registry is configured with {} for capabilities without a config
+		if string(capabilityConfig.config) != "{}" {
+			if err := proto.Unmarshal(capabilityConfig.config, config); err != nil {
+				return errors.Wrapf(err, "failed to unmarshal config of capability %s", capabilityConfig.id)
+			}
+		}
+
+		expected := proto.Clone(config).(*capabilitiespb.CapabilityConfig)
+		if err := applyCapabilityPolicy(capabilityConfig.capabilityType, expected, policy); err != nil {
+			return errors.Wrapf(err, "failed to apply policy to capability %s", capabilityConfig.id)
+		}
+		if !proto.Equal(expected, config) {
+			mismatches = append(mismatches, capabilityConfig.id)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("capability configs in the registry don't match policies of DON %d: %s", donID, strings.Join(mismatches, ", "))
+	}
+
+	return nil
+}
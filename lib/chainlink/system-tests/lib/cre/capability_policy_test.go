@@ -0,0 +1,87 @@
+package cre
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	capabilitiespb "github.com/smartcontractkit/chainlink-common/pkg/capabilities/pb"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+)
+
+func policyNodeSet(policies map[string]*CapabilityPolicy) *CapabilitiesAwareNodeSet {
+	return &CapabilitiesAwareNodeSet{
+		Input: &ns.Input{Name: "workflow", NodeSpecs: []*clnode.Input{
+			{Node: &clnode.NodeInput{}},
+			{Node: &clnode.NodeInput{EnvVars: map[string]string{"CL_LOG_LEVEL": "debug"}}},
+		}},
+		CapabilityPolicies: policies,
+	}
+}
+
+func TestApplyCapabilityPolicyToLocalCapabilities(t *testing.T) {
+	policy := &CapabilityPolicy{RequestTimeout: "30s", RegistrationRefresh: "10s"}
+
+	executable := &capabilitiespb.CapabilityConfig{}
+	require.NoError(t, applyCapabilityPolicy(1, executable, policy))
+	assert.Equal(t, durationpb.New(30*time.Second), executable.GetRemoteExecutableConfig().GetRequestTimeout())
+
+	trigger := &capabilitiespb.CapabilityConfig{}
+	require.NoError(t, applyCapabilityPolicy(capabilityTypeTrigger, trigger, policy))
+	assert.Equal(t, durationpb.New(10*time.Second), trigger.GetRemoteTriggerConfig().GetRegistrationRefresh())
+
+	consensus := &capabilitiespb.CapabilityConfig{}
+	require.ErrorContains(t, applyCapabilityPolicy(capabilityTypeConsensus, consensus, policy), "consensus capabilities aren't called remotely")
+	assert.Nil(t, consensus.RemoteConfig, "remote config was added to consensus")
+}
+
+func TestApplyEngineSettings(t *testing.T) {
+	nodeSet := policyNodeSet(nil)
+	nodeSet.CapabilityCallTimeout = "45s"
+	require.NoError(t, nodeSet.ApplyEngineSettings())
+	for _, nodeSpec := range nodeSet.NodeSpecs {
+		assert.JSONEq(t, `{"PerWorkflow":{"CapabilityCallTimeout":"45s"}}`, nodeSpec.Node.EnvVars[envCRESettingsDefault])
+	}
+	assert.Equal(t, "debug", nodeSet.NodeSpecs[1].Node.EnvVars["CL_LOG_LEVEL"])
+	require.NoError(t, nodeSet.ApplyEngineSettings(), "applying settings again must be a no-op")
+
+	conflicting := policyNodeSet(nil)
+	conflicting.CapabilityCallTimeout = "45s"
+	conflicting.NodeSpecs[1].Node.EnvVars[envCRESettingsDefault] = `{"PerWorkflow":{}}`
+	require.ErrorContains(t, conflicting.ApplyEngineSettings(), "node 1 sets CL_CRE_SETTINGS_DEFAULT")
+}
+
+func TestVerifyCapabilityPolicies(t *testing.T) {
+	nodeSet := policyNodeSet(map[string]*CapabilityPolicy{"evm": {RequestTimeout: "30s"}, "cron-trigger": {RegistrationRefresh: "10s"}})
+
+	applied := &capabilitiespb.CapabilityConfig{}
+	require.NoError(t, applyCapabilityPolicy(1, applied, nodeSet.CapabilityPolicies["evm"]))
+	appliedBytes, err := proto.Marshal(applied)
+	require.NoError(t, err)
+
+	require.NoError(t, verifyCapabilityPolicies(1, nodeSet, []registryCapabilityConfig{
+		{id: "evm:ChainSelector:1@1.0.0", labelledName: "evm:ChainSelector:1", capabilityType: 1, config: appliedBytes},
+		{id: "consensus@1.0.0-alpha", labelledName: "consensus", capabilityType: capabilityTypeConsensus, config: []byte("{}")},
+	}))
+
+	// the v2 registry stores {} for capabilities configured without a config
+	err = verifyCapabilityPolicies(1, nodeSet, []registryCapabilityConfig{
+		{id: "evm:ChainSelector:1@1.0.0", labelledName: "evm:ChainSelector:1", capabilityType: 1, config: appliedBytes},
+		{id: "cron-trigger@1.0.0", labelledName: "cron-trigger", capabilityType: capabilityTypeTrigger, config: []byte("{}")},
+	})
+	require.EqualError(t, err, "capability configs in the registry don't match policies of DON 1: cron-trigger@1.0.0")
+}
+
+func TestCapabilityTypeFromMetadata(t *testing.T) {
+	capabilityType, err := capabilityTypeFromMetadata([]byte(`{"capabilityType":3,"responseType":0}`))
+	require.NoError(t, err)
+	assert.Equal(t, uint8(3), capabilityType)
+
+	_, err = capabilityTypeFromMetadata([]byte("not json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,257 @@
+package contracts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-deployments-framework/datastore"
+	cldf "github.com/smartcontractkit/chainlink-deployments-framework/deployment"
+)
+
+// ContractReader is the subset of an EVM client needed to check, whether cached contracts are still on chain
+type ContractReader interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// CachedDeployment holds addresses of contracts deployed on a chain, in both address book and datastore formats
+type CachedDeployment struct {
+	ChainSelector uint64                         `json:"chain_selector"`
+	AddressBook   map[string]cldf.TypeAndVersion `json:"address_book"`
+	AddressRefs   []datastore.AddressRef         `json:"address_refs"`
+	// CodeHashes are hashes of runtime code of the contracts by address, recorded when they were cached
+	CodeHashes map[string]string `json:"code_hashes"`
+	DeployedAt time.Time         `json:"deployed_at"`
+}
+
+// DeploymentCache persists addresses of deployed contracts keyed by a hash of their bytecode, constructor arguments, chain
+// and deployer. When a chain is reused between runs, contracts can be reused instead of being redeployed, which saves time
+// and keeps the address book stable. Entries are verified against the chain before use (code and type and version of
+// every contract), so a restarted chain or contracts replaced on it only cause a cache miss.
+type DeploymentCache struct {
+	Entries map[string]*CachedDeployment `json:"entries"`
+
+	absPath string
+	mu      sync.Mutex
+}
+
+// LoadDeploymentCache reads the cache from disk. If it doesn't exist, an empty cache is returned.
+func LoadDeploymentCache(absPath string) (*DeploymentCache, error) {
+	cache := &DeploymentCache{Entries: make(map[string]*CachedDeployment), absPath: absPath}
+
+	content, readErr := os.ReadFile(absPath)
+	if os.IsNotExist(readErr) {
+		return cache, nil
+	}
+	if readErr != nil {
+		return nil, errors.Wrapf(readErr, "failed to read contract deployment cache from %s", absPath)
+	}
+
+	if err := json.Unmarshal(content, cache); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal contract deployment cache")
+	}
+	if cache.Entries == nil {
+		cache.Entries = make(map[string]*CachedDeployment)
+	}
+
+	return cache, nil
+}
+
+// DeploymentCacheKey returns a key identifying a deployment of contracts with given bytecodes and constructor arguments
+// by given deployer on given chain. The fingerprint is the one of the environment definition the contracts are deployed
+// for, see environment.ContractsFingerprint.
+func DeploymentCacheKey(chainSelector uint64, deployer common.Address, fingerprint string, bytecodes []string, constructorArgs ...any) (string, error) {
+	args, err := json.Marshal(constructorArgs)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal constructor arguments")
+	}
+
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%d:%s:%s:", chainSelector, deployer.Hex(), fingerprint)
+	for _, bytecode := range bytecodes {
+		_, _ = hash.Write([]byte(bytecode))
+		_, _ = hash.Write([]byte{0})
+	}
+	_, _ = hash.Write(args)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Get returns the cached deployment, if all of its contracts still have the code they had when they were cached and
+// report the type and version they were cached with. Stale entries, including ones without any contract, are removed.
+func (c *DeploymentCache) Get(ctx context.Context, key string, client ContractReader) (*CachedDeployment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Entries[key]
+	if !ok {
+		return nil, nil
+	}
+
+	fresh, err := entry.verify(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		delete(c.Entries, key)
+		return nil, nil
+	}
+
+	return entry, nil
+}
+
+// Put stores the deployment under given key and persists the cache
+func (c *DeploymentCache) Put(key string, entry *CachedDeployment) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Entries[key] = entry
+
+	if err := os.MkdirAll(filepath.Dir(c.absPath), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create directory for the contract deployment cache")
+	}
+
+	d, mErr := json.MarshalIndent(c, "", "  ")
+	if mErr != nil {
+		return errors.Wrap(mErr, "failed to marshal contract deployment cache")
+	}
+
+	return os.WriteFile(c.absPath, d, 0o600)
+}
+
+// NewCachedDeployment extracts addresses deployed on the chain from the deployment output and records code of the
+// contracts, so that Get recognizes, when they are replaced
+func NewCachedDeployment(ctx context.Context, client ContractReader, chainSelector uint64, addressBook cldf.AddressBook, addressRefs datastore.AddressRefStore) (*CachedDeployment, error) {
+	addresses, abErr := addressBook.AddressesForChain(chainSelector)
+	if abErr != nil {
+		return nil, errors.Wrap(abErr, "failed to get addresses from address book")
+	}
+
+	refs, refsErr := addressRefs.Fetch()
+	if refsErr != nil {
+		return nil, errors.Wrap(refsErr, "failed to get address refs from datastore")
+	}
+
+	entry := &CachedDeployment{ChainSelector: chainSelector, AddressBook: addresses, CodeHashes: make(map[string]string), DeployedAt: time.Now()}
+	for _, ref := range refs {
+		if ref.ChainSelector == chainSelector {
+			entry.AddressRefs = append(entry.AddressRefs, ref)
+		}
+	}
+
+	for address := range entry.contracts() {
+		code, err := client.CodeAt(ctx, address, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get code of contract %s", address)
+		}
+		if len(code) == 0 {
+			return nil, fmt.Errorf("contract %s has no code on chain %d", address, chainSelector)
+		}
+		entry.CodeHashes[address.Hex()] = codeHash(code)
+	}
+
+	return entry, nil
+}
+
+// contracts returns types and versions of cached contracts by address, from both the address book and address refs
+func (d *CachedDeployment) contracts() map[common.Address]cldf.TypeAndVersion {
+	contracts := make(map[common.Address]cldf.TypeAndVersion, len(d.AddressBook)+len(d.AddressRefs))
+	for address, typeAndVersion := range d.AddressBook {
+		contracts[common.HexToAddress(address)] = typeAndVersion
+	}
+	for _, ref := range d.AddressRefs {
+		if ref.Version == nil {
+			continue
+		}
+		contracts[common.HexToAddress(ref.Address)] = cldf.NewTypeAndVersion(cldf.ContractType(ref.Type), *ref.Version)
+	}
+
+	return contracts
+}
+
+// verify reports, whether all cached contracts have the recorded code and report the cached type and version
+func (d *CachedDeployment) verify(ctx context.Context, client ContractReader) (bool, error) {
+	contracts := d.contracts()
+	if len(contracts) == 0 {
+		return false, nil
+	}
+
+	for address, expected := range contracts {
+		code, err := client.CodeAt(ctx, address, nil)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get code of cached contract %s", address)
+		}
+		if len(code) == 0 || d.CodeHashes[address.Hex()] != codeHash(code) {
+			return false, nil
+		}
+
+		output, callErr := client.CallContract(ctx, ethereum.CallMsg{To: &address, Data: typeAndVersionSelector}, nil)
+		if callErr != nil {
+			// contracts without typeAndVersion revert, they aren't the cached ones
+			return false, nil //nolint:nilerr // a revert means a different contract, not a failure
+		}
+		onChain, decodeErr := decodeTypeAndVersion(output)
+		if decodeErr != nil {
+			return false, nil //nolint:nilerr // an undecodable result means a different contract, not a failure
+		}
+		if onChain.Type != expected.Type || !onChain.Version.Equal(&expected.Version) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// typeAndVersionSelector is the selector of typeAndVersion(), which Chainlink contracts implement
+var typeAndVersionSelector = crypto.Keccak256([]byte("typeAndVersion()"))[:4]
+
+func decodeTypeAndVersion(output []byte) (cldf.TypeAndVersion, error) {
+	stringType, typeErr := abi.NewType("string", "", nil)
+	if typeErr != nil {
+		return cldf.TypeAndVersion{}, typeErr
+	}
+	values, unpackErr := abi.Arguments{{Type: stringType}}.Unpack(output)
+	if unpackErr != nil {
+		return cldf.TypeAndVersion{}, errors.Wrap(unpackErr, "failed to decode typeAndVersion")
+	}
+	typeAndVersion, ok := values[0].(string)
+	if !ok {
+		return cldf.TypeAndVersion{}, fmt.Errorf("typeAndVersion returned %T", values[0])
+	}
+
+	return cldf.TypeAndVersionFromString(typeAndVersion)
+}
+
+func codeHash(code []byte) string {
+	hash := sha256.Sum256(code)
+	return hex.EncodeToString(hash[:])
+}
+
+// Restore adds cached addresses to the address book and datastore
+func (d *CachedDeployment) Restore(addressBook cldf.AddressBook, memoryDatastore *datastore.MemoryDataStore) error {
+	if err := addressBook.Merge(cldf.NewMemoryAddressBookFromMap(map[uint64]map[string]cldf.TypeAndVersion{d.ChainSelector: d.AddressBook})); err != nil {
+		return errors.Wrap(err, "failed to restore address book from cache")
+	}
+
+	for _, ref := range d.AddressRefs {
+		if err := memoryDatastore.AddressRefStore.Add(ref); err != nil {
+			return errors.Wrapf(err, "failed to restore address ref %v from cache", ref)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-deployments-framework/datastore"
+	cldf "github.com/smartcontractkit/chainlink-deployments-framework/deployment"
+)
+
+// fakeChain serves code and typeAndVersion of contracts by address
+type fakeChain struct {
+	code           map[common.Address][]byte
+	typeAndVersion map[common.Address]string
+}
+
+func (c *fakeChain) CodeAt(_ context.Context, contract common.Address, _ *big.Int) ([]byte, error) {
+	return c.code[contract], nil
+}
+
+func (c *fakeChain) CallContract(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	typeAndVersion, ok := c.typeAndVersion[*call.To]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return abi.Arguments{{Type: stringType}}.Pack(typeAndVersion)
+}
+
+var (
+	capRegAddress = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	wfRegAddress  = common.HexToAddress("0x1000000000000000000000000000000000000002")
+)
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{
+		code:           map[common.Address][]byte{capRegAddress: []byte("capabilities registry"), wfRegAddress: []byte("workflow registry")},
+		typeAndVersion: map[common.Address]string{capRegAddress: "CapabilitiesRegistry 1.1.0", wfRegAddress: "WorkflowRegistry 1.0.0"},
+	}
+}
+
+func cacheWithEntry(t *testing.T, chain *fakeChain, addressBook map[string]cldf.TypeAndVersion, refs []datastore.AddressRef) *DeploymentCache {
+	t.Helper()
+
+	ab := cldf.NewMemoryAddressBookFromMap(map[uint64]map[string]cldf.TypeAndVersion{1: addressBook})
+	store := datastore.NewMemoryDataStore()
+	for _, ref := range refs {
+		require.NoError(t, store.AddressRefStore.Add(ref))
+	}
+	entry, err := NewCachedDeployment(t.Context(), chain, 1, ab, store.Addresses())
+	require.NoError(t, err)
+
+	cache, err := LoadDeploymentCache(filepath.Join(t.TempDir(), "cache.json"))
+	require.NoError(t, err)
+	require.NoError(t, cache.Put("key", entry))
+
+	return cache
+}
+
+func addressBook() map[string]cldf.TypeAndVersion {
+	return map[string]cldf.TypeAndVersion{
+		capRegAddress.Hex(): cldf.NewTypeAndVersion("CapabilitiesRegistry", *semver.MustParse("1.1.0")),
+		wfRegAddress.Hex():  cldf.NewTypeAndVersion("WorkflowRegistry", *semver.MustParse("1.0.0")),
+	}
+}
+
+func TestDeploymentCacheGet(t *testing.T) {
+	t.Run("hit", func(t *testing.T) {
+		chain := newFakeChain()
+		cache := cacheWithEntry(t, chain, addressBook(), nil)
+
+		entry, err := cache.Get(t.Context(), "key", chain)
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+	})
+
+	t.Run("hit with address refs only", func(t *testing.T) {
+		chain := newFakeChain()
+		cache := cacheWithEntry(t, chain, nil, []datastore.AddressRef{
+			{Address: capRegAddress.Hex(), ChainSelector: 1, Type: "CapabilitiesRegistry", Version: semver.MustParse("1.1.0")},
+		})
+
+		entry, err := cache.Get(t.Context(), "key", chain)
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		chain := newFakeChain()
+		cache := cacheWithEntry(t, chain, addressBook(), nil)
+
+		entry, err := cache.Get(t.Context(), "other-key", chain)
+		require.NoError(t, err)
+		require.Nil(t, entry)
+	})
+
+	stale := map[string]func(chain *fakeChain){
+		"chain was restarted": func(chain *fakeChain) { delete(chain.code, capRegAddress) },
+		"contract was replaced": func(chain *fakeChain) {
+			chain.code[wfRegAddress] = []byte("another contract")
+		},
+		"contract reports another version": func(chain *fakeChain) {
+			chain.typeAndVersion[capRegAddress] = "CapabilitiesRegistry 2.0.0"
+		},
+		"contract has no typeAndVersion": func(chain *fakeChain) { delete(chain.typeAndVersion, wfRegAddress) },
+	}
+	for name, change := range stale {
+		t.Run("stale when "+name, func(t *testing.T) {
+			chain := newFakeChain()
+			cache := cacheWithEntry(t, chain, addressBook(), nil)
+			change(chain)
+
+			entry, err := cache.Get(t.Context(), "key", chain)
+			require.NoError(t, err)
+			require.Nil(t, entry)
+			require.NotContains(t, cache.Entries, "key", "stale entry wasn't removed")
+		})
+	}
+
+	t.Run("stale without contracts", func(t *testing.T) {
+		chain := newFakeChain()
+		cache := cacheWithEntry(t, chain, nil, nil)
+
+		entry, err := cache.Get(t.Context(), "key", chain)
+		require.NoError(t, err)
+		require.Nil(t, entry)
+	})
+}
+
+func TestDeploymentCacheKey(t *testing.T) {
+	deployer := common.HexToAddress("0x1")
+	key, err := DeploymentCacheKey(1, deployer, "3f1c", []string{"0x6080"}, "1.1.0")
+	require.NoError(t, err)
+
+	same, err := DeploymentCacheKey(1, deployer, "3f1c", []string{"0x6080"}, "1.1.0")
+	require.NoError(t, err)
+	require.Equal(t, key, same)
+
+	otherFingerprint, err := DeploymentCacheKey(1, deployer, "9a0e", []string{"0x6080"}, "1.1.0")
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherFingerprint, "contracts of another environment definition were reused")
+
+	otherArgs, err := DeploymentCacheKey(1, deployer, "3f1c", []string{"0x6080"}, "2.0.0")
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherArgs)
+}
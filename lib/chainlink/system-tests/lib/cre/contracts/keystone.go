@@ -8,7 +8,9 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
@@ -22,6 +24,8 @@ import (
 	"github.com/smartcontractkit/chainlink-deployments-framework/operations"
 	kcr "github.com/smartcontractkit/chainlink-evm/gethwrappers/keystone/generated/capabilities_registry_1_1_0"
 	capabilities_registry_v2 "github.com/smartcontractkit/chainlink-evm/gethwrappers/workflow/generated/capabilities_registry_wrapper_v2"
+	workflow_registry_wrapper "github.com/smartcontractkit/chainlink-evm/gethwrappers/workflow/generated/workflow_registry_wrapper_v1"
+	workflow_registry_wrapper_v2 "github.com/smartcontractkit/chainlink-evm/gethwrappers/workflow/generated/workflow_registry_wrapper_v2"
 	"github.com/smartcontractkit/chainlink/deployment"
 	"github.com/smartcontractkit/chainlink/deployment/cre/capabilities_registry/v2/changeset/operations/contracts"
 	cap_reg_v2_seq "github.com/smartcontractkit/chainlink/deployment/cre/capabilities_registry/v2/changeset/sequences"
@@ -41,6 +45,10 @@ type DeployKeystoneContractsInput struct {
 	CtfBlockchains   []blockchains.Blockchain
 	ContractVersions map[string]string
 	WithV2Registries bool
+	// Cache, if set, allows reusing registries deployed by a previous run on the same chain, use it only with persistent chains
+	Cache *DeploymentCache
+	// Fingerprint of the environment definition, which is part of cache keys, see environment.ContractsFingerprint
+	Fingerprint string
 }
 
 type DeployKeystoneContractsOutput struct {
@@ -58,11 +66,54 @@ func DeployKeystoneContracts(
 
 	homeChainOutput := input.CtfBlockchains[0]
 	homeChainSelector := homeChainOutput.ChainSelector()
+	for _, registry := range []string{keystone_changeset.WorkflowRegistry.String(), keystone_changeset.CapabilitiesRegistry.String()} {
+		version, vErr := semver.NewVersion(input.ContractVersions[registry])
+		if vErr != nil {
+			return nil, errors.Wrapf(vErr, "invalid version '%s' of %s contract", input.ContractVersions[registry], registry)
+		}
+		if (version.Major() == 2) != input.WithV2Registries {
+			return nil, fmt.Errorf("version %s of %s contract can't be deployed with V2 registries set to %t", version, registry, input.WithV2Registries)
+		}
+	}
+
 	deployRegistrySeq := ks_contracts_op.DeployRegistryContractsSequence
 	if input.WithV2Registries {
 		deployRegistrySeq = ks_contracts_op.DeployV2RegistryContractsSequence
 	}
 
+	registryBytecodes := []string{kcr.CapabilitiesRegistryBin, workflow_registry_wrapper.WorkflowRegistryBin}
+	if input.WithV2Registries {
+		registryBytecodes = []string{capabilities_registry_v2.CapabilitiesRegistryBin, workflow_registry_wrapper_v2.WorkflowRegistryBin}
+	}
+
+	var cacheKey string
+	if input.Cache != nil {
+		homeChain, ok := input.CldfEnvironment.BlockChains.EVMChains()[homeChainSelector]
+		if !ok {
+			return nil, fmt.Errorf("home chain %d is not an EVM chain", homeChainSelector)
+		}
+
+		var keyErr error
+		cacheKey, keyErr = DeploymentCacheKey(homeChainSelector, homeChain.DeployerKey.From, input.Fingerprint, registryBytecodes,
+			input.ContractVersions[keystone_changeset.WorkflowRegistry.String()], input.ContractVersions[keystone_changeset.CapabilitiesRegistry.String()])
+		if keyErr != nil {
+			return nil, errors.Wrap(keyErr, "failed to compute contract deployment cache key")
+		}
+
+		cached, cacheErr := input.Cache.Get(ctx, cacheKey, homeChain.Client)
+		if cacheErr != nil {
+			return nil, errors.Wrap(cacheErr, "failed to get registries from contract deployment cache")
+		}
+		if cached != nil {
+			if err := cached.Restore(input.CldfEnvironment.ExistingAddresses, memoryDatastore); err != nil { //nolint:staticcheck // won't migrate now
+				return nil, err
+			}
+			testLogger.Info().Msgf("Reusing Workflow and Capabilities Registry contracts deployed on chain %d at %s", homeChainSelector, cached.DeployedAt.Format(time.RFC3339))
+
+			return finishKeystoneContractsDeployment(testLogger, input, memoryDatastore, homeChainSelector), nil
+		}
+	}
+
 	registryContractsReport, seqErr := operations.ExecuteSequence(
 		input.CldfEnvironment.OperationsBundle,
 		deployRegistrySeq,
@@ -85,6 +136,21 @@ func DeployKeystoneContracts(
 		return nil, errors.Wrap(err, "failed to merge datastore with Keystone contracts addresses")
 	}
 
+	if input.Cache != nil {
+		homeChain := input.CldfEnvironment.BlockChains.EVMChains()[homeChainSelector]
+		entry, entryErr := NewCachedDeployment(ctx, homeChain.Client, homeChainSelector, registryContractsReport.Output.AddressBook, registryContractsReport.Output.Datastore.Addresses())
+		if entryErr != nil {
+			return nil, errors.Wrap(entryErr, "failed to create contract deployment cache entry")
+		}
+		if err := input.Cache.Put(cacheKey, entry); err != nil {
+			return nil, errors.Wrap(err, "failed to store registries in contract deployment cache")
+		}
+	}
+
+	return finishKeystoneContractsDeployment(testLogger, input, memoryDatastore, homeChainSelector), nil
+}
+
+func finishKeystoneContractsDeployment(testLogger zerolog.Logger, input DeployKeystoneContractsInput, memoryDatastore *datastore.MemoryDataStore, homeChainSelector uint64) *DeployKeystoneContractsOutput {
 	wfRegAddr := MustGetAddressFromMemoryDataStore(memoryDatastore, homeChainSelector, keystone_changeset.WorkflowRegistry.String(), input.ContractVersions[keystone_changeset.WorkflowRegistry.String()], "")
 	testLogger.Info().Msgf("Deployed Workflow Registry %s contract on chain %d at %s", input.ContractVersions[keystone_changeset.WorkflowRegistry.String()], homeChainSelector, wfRegAddr)
 
@@ -96,7 +162,7 @@ func DeployKeystoneContracts(
 	return &DeployKeystoneContractsOutput{
 		Env:             input.CldfEnvironment,
 		MemoryDataStore: memoryDatastore,
-	}, nil
+	}
 }
 
 const DonFamily = "test-don-family"
@@ -161,7 +161,7 @@ func DeployDons(input *DeployCribDonsInput) ([]*cre.CapabilitiesAwareNodeSet, er
 			cFunc := nodev1.Component(&nodev1.Props{
 				Namespace:       input.Namespace,
 				Image:           fmt.Sprintf("%s:%s", imageName, imageTag),
-				AppInstanceName: fmt.Sprintf("%s-%d", donMetadata.Name, nodeIdx),
+				AppInstanceName: infra.CRIBNodeName(nodeIdx, nodeMetadata.HasRole(cre.BootstrapNode), donMetadata.Name),
 				// passing as config not as override
 				Config: *configToml,
 				SecretsOverrides: map[string]string{
@@ -0,0 +1,114 @@
+package crib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// ResourceRequest holds resources requested by a single CRIB component
+type ResourceRequest struct {
+	CPUCores  float64
+	MemoryGiB float64
+}
+
+// Default resource requests of components deployed by this package. They approximate requests set by CRIB Helm charts,
+// update them together with the charts.
+var (
+	NodeResources       = ResourceRequest{CPUCores: 1, MemoryGiB: 2}       // includes the node's database
+	BlockchainResources = ResourceRequest{CPUCores: 0.5, MemoryGiB: 0.5}   // one Anvil instance
+	JdResources         = ResourceRequest{CPUCores: 0.5, MemoryGiB: 1}     // includes JD's database
+	ClusterServices     = ResourceRequest{CPUCores: 0.25, MemoryGiB: 0.25} // telepresence
+)
+
+const defaultEstimateDurationHours = 1
+
+// DefaultBudget limits deployments, which have no budget or don't set some of its limits. It allows a few DONs of
+// the usual size, anything bigger has to be allowed explicitly.
+var DefaultBudget = infra.CRIBBudget{MaxCPUCores: 32, MaxMemoryGiB: 64}
+
+type ResourceEstimate struct {
+	Nodes         int
+	Blockchains   int
+	CPUCores      float64
+	MemoryGiB     float64
+	DurationHours float64
+	Cost          float64 // 0, if no prices are set in the budget
+}
+
+func (e *ResourceEstimate) String() string {
+	s := fmt.Sprintf("%d node(s), %d blockchain(s): %.2f CPU cores, %.2f GiB of memory for %.1f hour(s)", e.Nodes, e.Blockchains, e.CPUCores, e.MemoryGiB, e.DurationHours)
+	if e.Cost > 0 {
+		s += fmt.Sprintf(", estimated cost %.2f", e.Cost)
+	}
+
+	return s
+}
+
+// EstimateResources estimates resources requested from the cluster by the topology. Budget is optional and is only
+// used to estimate the cost.
+func EstimateResources(nodeSets []*cre.CapabilitiesAwareNodeSet, blockchainsCount int, budget *infra.CRIBBudget) *ResourceEstimate {
+	estimate := &ResourceEstimate{Blockchains: blockchainsCount, DurationHours: defaultEstimateDurationHours}
+	for _, nodeSet := range nodeSets {
+		count := len(nodeSet.NodeSpecs)
+		if count == 0 {
+			count = nodeSet.Nodes
+		}
+		estimate.Nodes += count
+	}
+
+	add := func(r ResourceRequest, count int) {
+		estimate.CPUCores += r.CPUCores * float64(count)
+		estimate.MemoryGiB += r.MemoryGiB * float64(count)
+	}
+	add(NodeResources, estimate.Nodes)
+	add(BlockchainResources, blockchainsCount)
+	add(JdResources, 1)
+	add(ClusterServices, 1)
+
+	if budget != nil {
+		if budget.DurationHours > 0 {
+			estimate.DurationHours = budget.DurationHours
+		}
+		estimate.Cost = (estimate.CPUCores*budget.CPUCoreHourCost + estimate.MemoryGiB*budget.MemoryGiBHourCost) * estimate.DurationHours
+	}
+
+	return estimate
+}
+
+// CheckBudget returns an error, if the estimate exceeds any of the budget's limits and exceeding it wasn't explicitly
+// allowed. CPU and memory limits, which aren't set (and all of them, if budget is nil), default to DefaultBudget.
+func CheckBudget(estimate *ResourceEstimate, budget *infra.CRIBBudget) error {
+	if budget == nil {
+		budget = &infra.CRIBBudget{}
+	}
+
+	maxCPUCores, maxMemoryGiB := budget.MaxCPUCores, budget.MaxMemoryGiB
+	if maxCPUCores <= 0 {
+		maxCPUCores = DefaultBudget.MaxCPUCores
+	}
+	if maxMemoryGiB <= 0 {
+		maxMemoryGiB = DefaultBudget.MaxMemoryGiB
+	}
+
+	var exceeded []string
+	if estimate.CPUCores > maxCPUCores {
+		exceeded = append(exceeded, fmt.Sprintf("CPU cores: %.2f > %.2f", estimate.CPUCores, maxCPUCores))
+	}
+	if estimate.MemoryGiB > maxMemoryGiB {
+		exceeded = append(exceeded, fmt.Sprintf("memory GiB: %.2f > %.2f", estimate.MemoryGiB, maxMemoryGiB))
+	}
+	if budget.MaxCost > 0 && estimate.Cost > budget.MaxCost {
+		exceeded = append(exceeded, fmt.Sprintf("cost: %.2f > %.2f", estimate.Cost, budget.MaxCost))
+	}
+
+	if len(exceeded) == 0 || budget.AllowOverBudget {
+		return nil
+	}
+
+	return errors.Errorf("CRIB deployment exceeds the budget (%s). Reduce the topology or set 'allow_over_budget = true' in [infra.crib.budget] to deploy anyway", strings.Join(exceeded, ", "))
+}
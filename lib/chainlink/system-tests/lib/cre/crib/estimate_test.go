@@ -0,0 +1,58 @@
+package crib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+func nodeSets(sizes ...int) []*cre.CapabilitiesAwareNodeSet {
+	sets := make([]*cre.CapabilitiesAwareNodeSet, 0, len(sizes))
+	for _, size := range sizes {
+		sets = append(sets, &cre.CapabilitiesAwareNodeSet{Input: &ns.Input{Nodes: size}})
+	}
+
+	return sets
+}
+
+func TestEstimateResources(t *testing.T) {
+	withSpecs := &cre.CapabilitiesAwareNodeSet{Input: &ns.Input{Nodes: 10, NodeSpecs: []*clnode.Input{{}, {}}}}
+	estimate := EstimateResources(append(nodeSets(5), withSpecs), 2, nil)
+
+	assert.Equal(t, 7, estimate.Nodes, "node specs take precedence over the node count")
+	assert.InDelta(t, 7*1+2*0.5+0.5+0.25, estimate.CPUCores, 0.001)
+	assert.InDelta(t, 7*2+2*0.5+1+0.25, estimate.MemoryGiB, 0.001)
+	assert.InDelta(t, 1, estimate.DurationHours, 0.001)
+	assert.Zero(t, estimate.Cost, "cost can't be estimated without prices")
+
+	priced := EstimateResources(nodeSets(5), 1, &infra.CRIBBudget{DurationHours: 2, CPUCoreHourCost: 1, MemoryGiBHourCost: 0.5})
+	assert.InDelta(t, (priced.CPUCores+priced.MemoryGiB*0.5)*2, priced.Cost, 0.001)
+}
+
+func TestCheckBudgetDefaultsLimits(t *testing.T) {
+	small := EstimateResources(nodeSets(5, 5), 2, nil)
+	require.NoError(t, CheckBudget(small, nil), "usual topology exceeds the default budget")
+
+	giant := EstimateResources(nodeSets(50), 2, nil)
+	require.ErrorContains(t, CheckBudget(giant, nil), "CRIB deployment exceeds the budget (CPU cores: 51.75 > 32.00, memory GiB: 102.25 > 64.00)")
+
+	// only the memory limit is raised, CPU cores still default
+	require.ErrorContains(t, CheckBudget(giant, &infra.CRIBBudget{MaxMemoryGiB: 200}), "(CPU cores: 51.75 > 32.00)")
+	require.NoError(t, CheckBudget(giant, &infra.CRIBBudget{MaxCPUCores: 100, MaxMemoryGiB: 200}))
+	require.NoError(t, CheckBudget(giant, &infra.CRIBBudget{AllowOverBudget: true}))
+}
+
+func TestCheckBudgetCost(t *testing.T) {
+	budget := &infra.CRIBBudget{MaxCost: 10, DurationHours: 4, CPUCoreHourCost: 1}
+	estimate := EstimateResources(nodeSets(5), 1, budget)
+
+	require.ErrorContains(t, CheckBudget(estimate, budget), "cost: 25.00 > 10.00")
+	require.NoError(t, CheckBudget(estimate, &infra.CRIBBudget{DurationHours: 4, CPUCoreHourCost: 1}), "cost is checked only if its limit is set")
+}
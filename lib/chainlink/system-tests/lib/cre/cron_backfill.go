@@ -0,0 +1,146 @@
+package cre
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/logs"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// CronCatchUp is the behavior of a cron trigger for schedule windows missed while the DON was down
+type CronCatchUp string
+
+const (
+	CronCatchUpSkip CronCatchUp = "skip" // missed windows are dropped, executions continue with the next window
+	CronCatchUpOnce CronCatchUp = "once" // a single catch-up execution is run for all missed windows
+	CronCatchUpAll  CronCatchUp = "all"  // each missed window is executed
+)
+
+// CronOutage is a period, during which all workers of a DON were stopped
+type CronOutage struct {
+	Don       string
+	StoppedAt time.Time
+	StartedAt time.Time // when workers were started again, catch-up executions can begin before they are healthy
+}
+
+// MissedWindows returns how many windows of a schedule with given interval fell into the outage
+func (o *CronOutage) MissedWindows(interval time.Duration) int {
+	if interval <= 0 {
+		return 0
+	}
+
+	return int(o.StartedAt.Truncate(interval).Sub(o.StoppedAt.Truncate(interval)) / interval)
+}
+
+// StopDONPastCronWindows stops all workers of the DON, keeps them stopped for given number of windows of a cron
+// schedule with given interval and starts them again. Only Docker is supported, see infra.StopNode.
+func StopDONPastCronWindows(ctx context.Context, provider infra.Provider, don *Don, interval time.Duration, windows int) (*CronOutage, error) {
+	if interval <= 0 || windows <= 0 {
+		return nil, errors.New("interval and number of windows must be positive")
+	}
+
+	workers, wErr := don.Workers()
+	if wErr != nil {
+		return nil, wErr
+	}
+
+	outage := &CronOutage{Don: don.Name, StoppedAt: time.Now()}
+	for _, worker := range workers {
+		if err := provider.StopNode(ctx, worker.Index, don.Name); err != nil {
+			return outage, errors.Wrapf(err, "failed to stop node %s", worker.Name)
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Duration(windows) * interval):
+	}
+
+	// nodes are started even if the context was cancelled, so that the environment isn't left without a working DON
+	startCtx := context.WithoutCancel(ctx)
+	outage.StartedAt = time.Now()
+	for _, worker := range workers {
+		if err := provider.StartNode(startCtx, worker.Index, don.Name); err != nil {
+			return outage, errors.Wrapf(err, "failed to start node %s", worker.Name)
+		}
+	}
+	for _, worker := range workers {
+		if err := worker.WaitHealthy(startCtx, nodeStartTimeout); err != nil {
+			return outage, err
+		}
+	}
+
+	return outage, ctx.Err()
+}
+
+// CronCatchUpResult counts executions of the workflow during and after the outage
+type CronCatchUpResult struct {
+	MissedWindows int
+	// DuringOutage should be 0, since no worker was running
+	DuringOutage int
+	// AfterRestart are executions started within the observation period after the restart
+	AfterRestart int
+	// Regular is the number of windows in the observation period, which are executed regardless of the catch-up behavior
+	Regular int
+}
+
+// CatchUpExecutions returns the number of executions on top of regular ones
+func (r *CronCatchUpResult) CatchUpExecutions() int {
+	return max(r.AfterRestart-r.Regular, 0)
+}
+
+// AssertCronCatchUp checks that executions of the workflow with a cron schedule with given interval match the expected
+// catch-up behavior. Only executions started within observe after the restart are counted, so observe should be a few
+// intervals long and the check should run only once that period is over. Each execution is counted once, even though
+// all workers start it. One regular execution more or less is tolerated, since the restart isn't aligned with windows.
+func AssertCronCatchUp(starts []logs.ExecutionStart, workflowID string, outage *CronOutage, interval, observe time.Duration, expected CronCatchUp) (*CronCatchUpResult, error) {
+	result := &CronCatchUpResult{
+		MissedWindows: outage.MissedWindows(interval),
+		Regular:       int(observe / interval),
+	}
+
+	observeUntil := outage.StartedAt.Add(observe)
+	seen := make(map[string]bool)
+	for _, start := range starts {
+		if start.WorkflowID != workflowID || seen[start.ExecutionID] {
+			continue
+		}
+		seen[start.ExecutionID] = true
+
+		switch {
+		case start.Timestamp.After(outage.StoppedAt) && start.Timestamp.Before(outage.StartedAt):
+			result.DuringOutage++
+		case !start.Timestamp.Before(outage.StartedAt) && start.Timestamp.Before(observeUntil):
+			result.AfterRestart++
+		}
+	}
+
+	if result.DuringOutage > 0 {
+		return result, fmt.Errorf("%d executions started while all workers of DON %s were stopped", result.DuringOutage, outage.Don)
+	}
+
+	var expectedCatchUp int
+	switch expected {
+	case CronCatchUpSkip:
+		expectedCatchUp = 0
+	case CronCatchUpOnce:
+		expectedCatchUp = min(result.MissedWindows, 1)
+	case CronCatchUpAll:
+		expectedCatchUp = result.MissedWindows
+	default:
+		return result, fmt.Errorf("unknown catch-up behavior '%s'", expected)
+	}
+
+	minExecutions := max(result.Regular-1, 0) + expectedCatchUp
+	maxExecutions := result.Regular + 1 + expectedCatchUp
+	if result.AfterRestart < minExecutions || result.AfterRestart > maxExecutions {
+		return result, fmt.Errorf("expected catch-up behavior '%s' after missing %d windows: %d to %d executions within %s after restart, got %d",
+			expected, result.MissedWindows, minExecutions, maxExecutions, observe, result.AfterRestart)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,234 @@
+// Package datagen generates synthetic price updates of data feeds on a schedule and publishes them through a trigger
+// path of the test (e.g. the mock capability trigger or an HTTP endpoint), so that data-dependent workflows get realistic
+// inputs. Prices follow a geometric random walk with configurable trend, volatility and gaps, and the same seed always
+// generates the same sequence, so failures can be reproduced.
+package datagen
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const DefaultInterval = 10 * time.Second
+
+type Config struct {
+	Interval string        `toml:"interval"` // Go duration between updates, DefaultInterval is used if not set
+	Seed     int64         `toml:"seed"`     // generators with the same seed and feeds generate the same prices
+	Updates  int           `toml:"updates"`  // number of updates published by Run, 0 means until the context is done
+	Feeds    []*FeedConfig `toml:"feeds"`
+	// URL updates are posted to with HTTPPublisher, when the generator is started by the environment setup. It's
+	// optional, if the setup is given another publisher, e.g. MockTriggerPublisher.
+	URL     string            `toml:"url"`
+	Headers map[string]string `toml:"headers"`
+}
+
+type FeedConfig struct {
+	FeedID       string  `toml:"feed_id"`
+	InitialPrice float64 `toml:"initial_price"`
+	Decimals     uint8   `toml:"decimals"` // of scaled prices, see FeedPrice.Scaled
+	// Trend is the expected relative change per update, e.g. 0.001 for +0.1%
+	Trend float64 `toml:"trend"`
+	// Volatility is the standard deviation of the relative change per update, e.g. 0.01 for 1%
+	Volatility float64 `toml:"volatility"`
+	// GapProbability is the probability that the feed is missing in an update, e.g. to test staleness handling
+	GapProbability float64 `toml:"gap_probability"`
+}
+
+func (c *Config) Validate() error {
+	if c.Interval != "" {
+		if interval, err := time.ParseDuration(c.Interval); err != nil || interval <= 0 {
+			return fmt.Errorf("interval of data generator must be a positive Go duration, got '%s'", c.Interval)
+		}
+	}
+	if c.Updates < 0 {
+		return fmt.Errorf("updates of data generator can't be negative, got %d", c.Updates)
+	}
+	if c.URL != "" {
+		if parsed, err := url.Parse(c.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("url of data generator must be an http(s) URL, got '%s'", c.URL)
+		}
+	}
+	if len(c.Feeds) == 0 {
+		return errors.New("data generator needs at least one feed")
+	}
+
+	seen := make(map[string]bool, len(c.Feeds))
+	for idx, feed := range c.Feeds {
+		if feed.FeedID == "" {
+			return fmt.Errorf("feed_id of feed %d is required", idx)
+		}
+		if seen[feed.FeedID] {
+			return fmt.Errorf("feed %s is configured more than once", feed.FeedID)
+		}
+		seen[feed.FeedID] = true
+		if feed.InitialPrice <= 0 {
+			return fmt.Errorf("initial_price of feed %s must be positive", feed.FeedID)
+		}
+		if feed.Volatility < 0 {
+			return fmt.Errorf("volatility of feed %s can't be negative", feed.FeedID)
+		}
+		if feed.GapProbability < 0 || feed.GapProbability > 1 {
+			return fmt.Errorf("gap_probability of feed %s must be between 0 and 1", feed.FeedID)
+		}
+	}
+
+	return nil
+}
+
+// IntervalDuration returns the interval between updates, invalid values fall back to the default, call Validate to catch them
+func (c *Config) IntervalDuration() time.Duration {
+	if interval, err := time.ParseDuration(c.Interval); err == nil && interval > 0 {
+		return interval
+	}
+
+	return DefaultInterval
+}
+
+type FeedPrice struct {
+	FeedID   string
+	Price    float64
+	Decimals uint8
+}
+
+// Scaled returns the price as an integer with the feed's decimals, as it's usually reported on-chain
+func (p FeedPrice) Scaled() *big.Int {
+	multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(p.Decimals)), nil)
+	scaled, _ := new(big.Float).Mul(big.NewFloat(p.Price), new(big.Float).SetInt(multiplier)).Int(nil)
+
+	return scaled
+}
+
+// Update is a single round of prices. Feeds, which have a gap in the round, are missing.
+type Update struct {
+	Round     int
+	Timestamp time.Time
+	Prices    []FeedPrice
+}
+
+// Publisher publishes updates through a trigger path, e.g. MockTriggerPublisher or HTTPPublisher
+type Publisher interface {
+	Publish(ctx context.Context, update Update) error
+}
+
+type PublisherFunc func(ctx context.Context, update Update) error
+
+func (f PublisherFunc) Publish(ctx context.Context, update Update) error {
+	return f(ctx, update)
+}
+
+type Generator struct {
+	config *Config
+	rand   *rand.Rand
+	prices []float64
+	round  int
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func NewGenerator(config *Config) (*Generator, error) {
+	if config == nil {
+		return nil, errors.New("data generator config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid data generator config")
+	}
+
+	prices := make([]float64, len(config.Feeds))
+	for idx, feed := range config.Feeds {
+		prices[idx] = feed.InitialPrice
+	}
+
+	return &Generator{
+		config: config,
+		rand:   rand.New(rand.NewSource(config.Seed)), //nolint:gosec // G404 reproducible test data, not used for security
+		prices: prices,
+	}, nil
+}
+
+// Next generates the next update. Prices move even in rounds, in which their feed has a gap, like real markets do.
+func (g *Generator) Next(timestamp time.Time) Update {
+	g.round++
+	update := Update{Round: g.round, Timestamp: timestamp}
+	for idx, feed := range g.config.Feeds {
+		// random numbers are drawn for every feed in every round, so that gaps don't change prices of later rounds
+		change := g.rand.NormFloat64()
+		gap := g.rand.Float64() < feed.GapProbability
+
+		g.prices[idx] *= math.Exp(feed.Trend + feed.Volatility*change)
+		if gap {
+			continue
+		}
+		update.Prices = append(update.Prices, FeedPrice{FeedID: feed.FeedID, Price: g.prices[idx], Decimals: feed.Decimals})
+	}
+
+	return update
+}
+
+// Run publishes an update every interval, starting immediately, until the configured number of updates is published
+// or the context is done. Failure to publish an update stops it.
+func (g *Generator) Run(ctx context.Context, publisher Publisher) error {
+	ticker := time.NewTicker(g.config.IntervalDuration())
+	defer ticker.Stop()
+
+	for published := 1; ; published++ {
+		update := g.Next(time.Now())
+		if err := publisher.Publish(ctx, update); err != nil {
+			return errors.Wrapf(err, "failed to publish update %d", update.Round)
+		}
+		if published == g.config.Updates {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Start runs the generator in the background until Stop is called, see Run. Calling it again before Stop does nothing.
+func (g *Generator) Start(ctx context.Context, publisher Publisher) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		return
+	}
+
+	ctx, g.cancel = context.WithCancel(ctx)
+	g.done = make(chan struct{})
+	g.err = nil
+	go func() {
+		defer close(g.done)
+		g.err = g.Run(ctx, publisher)
+	}()
+}
+
+// Stop stops the generator started with Start and returns the error, which stopped it before, if any
+func (g *Generator) Stop() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel == nil {
+		return nil
+	}
+
+	g.cancel()
+	<-g.done
+	g.cancel = nil
+	if errors.Is(g.err, context.Canceled) {
+		return nil
+	}
+
+	return g.err
+}
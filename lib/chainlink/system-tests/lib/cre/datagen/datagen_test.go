@@ -0,0 +1,185 @@
+package datagen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *Config {
+	return &Config{
+		Seed: 42,
+		Feeds: []*FeedConfig{
+			{FeedID: "ETH/USD", InitialPrice: 2000, Decimals: 8, Trend: 0.001, Volatility: 0.01},
+			{FeedID: "BTC/USD", InitialPrice: 60000, Decimals: 8, Volatility: 0.02, GapProbability: 0.3},
+		},
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	t.Run("same seed generates same prices", func(t *testing.T) {
+		first, err := NewGenerator(testConfig())
+		require.NoError(t, err)
+		second, err := NewGenerator(testConfig())
+		require.NoError(t, err)
+
+		now := time.Now()
+		for range 20 {
+			assert.Equal(t, first.Next(now), second.Next(now))
+		}
+	})
+
+	t.Run("gaps don't change later prices", func(t *testing.T) {
+		withGaps := testConfig()
+		withoutGaps := testConfig()
+		withoutGaps.Feeds[1].GapProbability = 0
+		first, err := NewGenerator(withGaps)
+		require.NoError(t, err)
+		second, err := NewGenerator(withoutGaps)
+		require.NoError(t, err)
+
+		gaps := 0
+		for range 20 {
+			update := first.Next(time.Time{})
+			expected := second.Next(time.Time{})
+			// ETH/USD has no gaps, so its price must be the same as if BTC/USD had none either
+			assert.Equal(t, expected.Prices[0], update.Prices[0])
+			if len(update.Prices) == 1 {
+				gaps++
+			}
+		}
+		assert.Positive(t, gaps)
+	})
+
+	t.Run("feeds with gap probability 1 are never published", func(t *testing.T) {
+		config := testConfig()
+		config.Feeds[1].GapProbability = 1
+		generator, err := NewGenerator(config)
+		require.NoError(t, err)
+
+		for round := 1; round <= 10; round++ {
+			update := generator.Next(time.Time{})
+			assert.Equal(t, round, update.Round)
+			require.Len(t, update.Prices, 1)
+			assert.Equal(t, "ETH/USD", update.Prices[0].FeedID)
+		}
+	})
+
+	t.Run("invalid configs are rejected", func(t *testing.T) {
+		_, err := NewGenerator(nil)
+		require.Error(t, err)
+
+		for name, mutate := range map[string]func(c *Config){
+			"interval":        func(c *Config) { c.Interval = "soon" },
+			"updates":         func(c *Config) { c.Updates = -1 },
+			"no feeds":        func(c *Config) { c.Feeds = nil },
+			"duplicate feed":  func(c *Config) { c.Feeds[1].FeedID = c.Feeds[0].FeedID },
+			"initial price":   func(c *Config) { c.Feeds[0].InitialPrice = 0 },
+			"volatility":      func(c *Config) { c.Feeds[0].Volatility = -0.1 },
+			"gap probability": func(c *Config) { c.Feeds[0].GapProbability = 1.5 },
+			"missing feed ID": func(c *Config) { c.Feeds[0].FeedID = "" },
+		} {
+			config := testConfig()
+			mutate(config)
+			assert.Error(t, config.Validate(), name)
+		}
+	})
+}
+
+func TestFeedPriceScaled(t *testing.T) {
+	assert.Equal(t, "200050000000", FeedPrice{Price: 2000.5, Decimals: 8}.Scaled().String())
+	assert.Equal(t, "3", FeedPrice{Price: 3.7}.Scaled().String())
+}
+
+func TestRun(t *testing.T) {
+	config := testConfig()
+	config.Interval = "10ms"
+	config.Updates = 3
+	generator, err := NewGenerator(config)
+	require.NoError(t, err)
+
+	var rounds []int
+	require.NoError(t, generator.Run(t.Context(), PublisherFunc(func(_ context.Context, update Update) error {
+		rounds = append(rounds, update.Round)
+		return nil
+	})))
+	assert.Equal(t, []int{1, 2, 3}, rounds)
+
+	t.Run("stops with the context", func(t *testing.T) {
+		config := testConfig()
+		config.Interval = "1h"
+		generator, err := NewGenerator(config)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		err = generator.Run(ctx, PublisherFunc(func(_ context.Context, _ Update) error {
+			cancel()
+			return nil
+		}))
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestStartStop(t *testing.T) {
+	config := testConfig()
+	config.Interval = "10ms"
+	generator, err := NewGenerator(config)
+	require.NoError(t, err)
+
+	published := make(chan int, 100)
+	generator.Start(t.Context(), PublisherFunc(func(_ context.Context, update Update) error {
+		published <- update.Round
+		return nil
+	}))
+	require.Equal(t, 1, <-published)
+	require.Equal(t, 2, <-published)
+	require.NoError(t, generator.Stop())
+	require.NoError(t, generator.Stop(), "stopping a stopped generator is a no-op")
+
+	t.Run("returns the publishing error", func(t *testing.T) {
+		generator, err := NewGenerator(testConfig())
+		require.NoError(t, err)
+
+		generator.Start(t.Context(), PublisherFunc(func(_ context.Context, _ Update) error {
+			return errors.New("trigger is down")
+		}))
+		require.Eventually(t, func() bool {
+			select {
+			case <-generator.done:
+				return true
+			default:
+				return false
+			}
+		}, time.Second, 10*time.Millisecond)
+		require.ErrorContains(t, generator.Stop(), "trigger is down")
+	})
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	var received jsonUpdate
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&received)) {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	publisher := &HTTPPublisher{URL: server.URL, Headers: map[string]string{"X-Api-Key": "secret"}}
+	update := Update{Round: 7, Timestamp: time.Unix(1700000000, 0), Prices: []FeedPrice{{FeedID: "ETH/USD", Price: 2000.5, Decimals: 8}}}
+	require.NoError(t, publisher.Publish(t.Context(), update))
+	assert.Equal(t, jsonUpdate{Round: 7, Timestamp: 1700000000, Prices: []jsonPrice{{FeedID: "ETH/USD", Price: 2000.5, Scaled: "200050000000"}}}, received)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	require.ErrorContains(t, (&HTTPPublisher{URL: failing.URL}).Publish(t.Context(), update), "status 503")
+}
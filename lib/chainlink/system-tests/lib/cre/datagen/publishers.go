@@ -0,0 +1,111 @@
+package datagen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-protos/cre/go/values"
+
+	mockcapability "github.com/smartcontractkit/chainlink/system-tests/lib/cre/mock"
+	pb2 "github.com/smartcontractkit/chainlink/system-tests/lib/cre/mock/pb"
+)
+
+type jsonPrice struct {
+	FeedID string  `json:"feed_id"`
+	Price  float64 `json:"price"`
+	Scaled string  `json:"scaled"` // decimal string, so that large integers survive JSON decoding in any language
+}
+
+type jsonUpdate struct {
+	Round     int         `json:"round"`
+	Timestamp int64       `json:"timestamp"` // Unix seconds
+	Prices    []jsonPrice `json:"prices"`
+}
+
+func toJSONUpdate(update Update) jsonUpdate {
+	out := jsonUpdate{Round: update.Round, Timestamp: update.Timestamp.Unix(), Prices: make([]jsonPrice, 0, len(update.Prices))}
+	for _, price := range update.Prices {
+		out.Prices = append(out.Prices, jsonPrice{FeedID: price.FeedID, Price: price.Price, Scaled: price.Scaled().String()})
+	}
+
+	return out
+}
+
+// HTTPPublisher posts JSON-encoded updates to the URL, e.g. of a mock price API polled by workflows or of an HTTP trigger
+type HTTPPublisher struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client // http.DefaultClient is used if nil
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, update Update) error {
+	body, marshalErr := json.Marshal(toJSONUpdate(update))
+	if marshalErr != nil {
+		return errors.Wrap(marshalErr, "failed to encode update")
+	}
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if reqErr != nil {
+		return reqErr
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range p.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, respErr := client.Do(req)
+	if respErr != nil {
+		return respErr
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned status %d: %s", p.URL, resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
+// MockTriggerPublisher sends updates as events of a trigger of the mock capability to its subscribers. Outputs of
+// events contain 'round', 'timestamp' and 'prices', a list of maps with 'feed_id', 'price' and 'scaled'.
+type MockTriggerPublisher struct {
+	Controller  *mockcapability.Controller
+	TriggerID   string
+	TriggerType string
+}
+
+func (p *MockTriggerPublisher) Publish(ctx context.Context, update Update) error {
+	prices := make([]any, 0, len(update.Prices))
+	for _, price := range update.Prices {
+		prices = append(prices, map[string]any{"feed_id": price.FeedID, "price": price.Price, "scaled": price.Scaled()})
+	}
+	outputs, outputsErr := values.NewMap(map[string]any{
+		"round":     int64(update.Round),
+		"timestamp": update.Timestamp.Unix(),
+		"prices":    prices,
+	})
+	if outputsErr != nil {
+		return errors.Wrap(outputsErr, "failed to convert update to trigger outputs")
+	}
+	outputBytes, bytesErr := mockcapability.MapToBytes(outputs)
+	if bytesErr != nil {
+		return errors.Wrap(bytesErr, "failed to encode trigger outputs")
+	}
+
+	return p.Controller.SendTrigger(ctx, &pb2.SendTriggerEventRequest{
+		TriggerID:   p.TriggerID,
+		TriggerType: p.TriggerType,
+		ID:          p.TriggerID + "-" + strconv.Itoa(update.Round),
+		Outputs:     outputBytes,
+	})
+}
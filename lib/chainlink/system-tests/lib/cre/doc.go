@@ -0,0 +1,17 @@
+// Package cre describes CRE environments: topologies of DONs, their nodes, capabilities and configs, and clients of
+// running nodes.
+//
+// # API stability
+//
+// Types decoded from TOML configs (CapabilitiesAwareNodeSet, CapabilityConfig, ChainFinalityConfig, FeatureFlags and
+// types they embed), the running environment (Environment, Topology, Dons, Don, Node), extension points
+// (InstallableCapability, Feature, NodeConfigTransformerFn, JobSpecFn, CapabilityRegistryConfigFn) and capability flags
+// are stable: they change only in backwards-compatible ways, renamed or replaced identifiers are kept with a
+// "Deprecated:" paragraph for at least one release.
+//
+// Version matrices, version skew stages and read-only nodes are experimental, the "Experimental:" paragraph of their
+// doc comments says what may change. New APIs, which aren't settled yet, are added to packages under cre/experimental
+// (e.g. tracing) instead, which may change without deprecation. Everything else exported from this package is used
+// by other packages of the framework and isn't meant to be used by tests directly, prefer the stable types and the
+// environment package.
+package cre
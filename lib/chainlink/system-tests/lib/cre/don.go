@@ -3,7 +3,10 @@ package cre
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
@@ -33,6 +36,7 @@ import (
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains/solana"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/crypto"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
 )
 
 const (
@@ -156,6 +160,19 @@ func (d *Don) Gateway() (*Node, bool) {
 	return nil, false
 }
 
+// Gateways returns all gateway nodes of the DON ordered by node index
+func (d *Don) Gateways() []*Node {
+	gateways := make([]*Node, 0)
+	for _, node := range d.Nodes {
+		if node.Roles.Contains(RoleGateway) {
+			gateways = append(gateways, node)
+		}
+	}
+	slices.SortFunc(gateways, func(a, b *Node) int { return a.Index - b.Index })
+
+	return gateways
+}
+
 // Currently only one bootstrap node is supported.
 func (d *Don) Bootstrap() (*Node, bool) {
 	for _, node := range d.Nodes {
@@ -242,6 +259,7 @@ func NewDON(ctx context.Context, donMetadata *DonMetadata, ctfNodes []*clnode.Ou
 			if err != nil {
 				return fmt.Errorf("failed to create node %d: %w", idx, err)
 			}
+			node.executor = donMetadata.provider.NodeExecutor(nodeMetadata.Index, donMetadata.Name)
 
 			mu.Lock()
 			don.Nodes = append(don.Nodes, node)
@@ -312,6 +330,7 @@ func RegisterWithJD(ctx context.Context, d *Don, supportedChains []blockchains.B
 type Node struct {
 	Name                  string                 `toml:"name" json:"name"`
 	Host                  string                 `toml:"host" json:"host"`
+	Alias                 string                 `toml:"alias" json:"alias"`
 	Index                 int                    `toml:"index" json:"index"`
 	UUID                  string                 `toml:"uuid" json:"uuid"`
 	Keys                  *secrets.NodeKeys      `toml:"-" json:"-"`
@@ -321,6 +340,9 @@ type Node struct {
 
 	Clients NodeClients `toml:"-" json:"-"`
 	DON     Don         `toml:"-" json:"-"`
+
+	executor infra.Executor
+	readOnly bool
 }
 
 func (n *Node) Metadata() *NodeMetadata {
@@ -329,6 +351,7 @@ func (n *Node) Metadata() *NodeMetadata {
 		Keys:  n.Keys,
 		Roles: n.Roles.Strings(),
 		Host:  n.Host,
+		Alias: n.Alias,
 		UUID:  n.UUID,
 	}
 
@@ -347,15 +370,92 @@ func (n *Node) PeerID() string {
 	return n.Keys.PeerID()
 }
 
+// Exec runs a command in the container (or pod) the node is running in and returns its captured output and exit code.
+// Non-zero exit code is not an error, check ExecResult.Succeeded().
+func (n *Node) Exec(ctx context.Context, cmd ...string) (*infra.ExecResult, error) {
+	return n.ExecStream(ctx, infra.ExecOptions{}, cmd...)
+}
+
+// ExecStream works like Exec, but additionally streams output of the command to writers set in options
+func (n *Node) ExecStream(ctx context.Context, opts infra.ExecOptions, cmd ...string) (*infra.ExecResult, error) {
+	if len(cmd) == 0 {
+		return nil, errors.New("command cannot be empty")
+	}
+	if n.executor == nil {
+		return nil, fmt.Errorf("node %s was not created with NewDON, don't know where it runs", n.Name)
+	}
+
+	result, err := n.executor.Exec(ctx, cmd, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exec '%s' in node %s: %w", strings.Join(cmd, " "), n.Name, err)
+	}
+
+	return result, nil
+}
+
+// nodeStartTimeout is how long a node stopped by a scenario has to become healthy after it's started again
+const nodeStartTimeout = 2 * time.Minute
+
+// WaitHealthy waits until the node responds to health checks, e.g. after it was (re)started
+func (n *Node) WaitHealthy(ctx context.Context, timeout time.Duration) error {
+	healthErr := retry.Do(ctx, retry.WithMaxDuration(timeout, retry.NewConstant(2*time.Second)), func(ctx context.Context) error {
+		if _, _, err := n.Clients.RestClient.Health(); err != nil {
+			return retry.RetryableError(err)
+		}
+		return nil
+	})
+	if healthErr != nil {
+		return fmt.Errorf("node %s isn't healthy after %s: %w", n.Name, timeout, healthErr)
+	}
+
+	return nil
+}
+
+// FetchFile returns content of a file from the container (or pod) the node is running in. It uses 'cat' instead of
+// Docker's archive API or 'kubectl cp', so that it works the same way regardless of the infra type.
+func (n *Node) FetchFile(ctx context.Context, containerPath string) ([]byte, error) {
+	result, err := n.Exec(ctx, "cat", containerPath)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Succeeded() {
+		return nil, fmt.Errorf("failed to read file %s from node %s (exit code %d): %s", containerPath, n.Name, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	return []byte(result.Stdout), nil
+}
+
+// FetchFileTo copies a file from the node to <destDir>/<node name>/<file name> and returns the local path
+func (n *Node) FetchFileTo(ctx context.Context, containerPath, destDir string) (string, error) {
+	content, err := n.FetchFile(ctx, containerPath)
+	if err != nil {
+		return "", err
+	}
+
+	nodeDir := filepath.Join(destDir, n.Name)
+	if err := os.MkdirAll(nodeDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", nodeDir, err)
+	}
+
+	localPath := filepath.Join(nodeDir, filepath.Base(containerPath))
+	if err := os.WriteFile(localPath, content, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write file %s: %w", localPath, err)
+	}
+
+	return localPath, nil
+}
+
 func (n *Node) HasRole(role Role) bool {
 	return slices.Contains(n.Roles, role)
 }
 
 func NewNode(ctx context.Context, name string, nodeMetadata *NodeMetadata, ctfNode *clnode.Output) (*Node, error) {
+	// mutations are rejected by the transport, once the node is read-only, see SetReadOnly
+	gqlTransport := &readOnlyGQLTransport{wrapped: http.DefaultTransport}
 	gqlClient, gqErr := client.NewWithContext(ctx, ctfNode.Node.ExternalURL, client.Credentials{
 		Email:    ctfNode.Node.APIAuthUser,
 		Password: ctfNode.Node.APIAuthPassword,
-	})
+	}, client.WithHTTPClient(&http.Client{Transport: gqlTransport}))
 	if gqErr != nil {
 		return nil, fmt.Errorf("failed to create node graphql client: %w", gqErr)
 	}
@@ -381,8 +481,10 @@ func NewNode(ctx context.Context, name string, nodeMetadata *NodeMetadata, ctfNo
 		Keys:  nodeMetadata.Keys,
 		Roles: MustNewRoles(nodeMetadata.Roles),
 		Host:  nodeMetadata.Host,
+		Alias: nodeMetadata.Alias,
 		UUID:  nodeMetadata.UUID,
 	}
+	gqlTransport.node = node
 
 	for i, role := range nodeMetadata.Roles {
 		r, err := NewRole(role)
@@ -498,7 +600,7 @@ func CreateJDChainConfigs(ctx context.Context, n *Node, supportedChains []blockc
 		if chain.IsFamily(blockchain.FamilyTron) {
 			chainType = strings.ToUpper(blockchain.FamilyEVM)
 		}
-		ocr2BundleID, createErr := n.Clients.GQLClient.FetchOCR2KeyBundleID(ctx, chainType)
+		ocr2BundleID, createErr := n.ocr2KeyBundleID(ctx, chainType)
 		if createErr != nil {
 			return fmt.Errorf("failed to fetch OCR2 key bundle id for node %s: %w", n.Name, createErr)
 		}
@@ -808,6 +910,38 @@ func (n *Node) ExportOCR2Keys(id string) (*clclient.ExportedOCR2Key, error) {
 	return keys, nil
 }
 
+// ImportOCR2Key imports an OCR2 key bundle exported from another node and returns its ID
+func (n *Node) ImportOCR2Key(key *crypto.OCR2Key) (string, error) {
+	imported := &clclient.OCR2Key{}
+	resp, err := n.Clients.RestClient.APIClient.R().
+		SetBody(key.EncryptedJSON).
+		SetQueryParam("oldpassword", key.Password).
+		SetResult(imported).
+		Post("/v2/keys/ocr2/import")
+	if err != nil {
+		return "", fmt.Errorf("failed to import %s OCR2 key to node %s: %w", key.ChainType, n.Name, err)
+	}
+	if resp.IsError() {
+		return "", fmt.Errorf("failed to import %s OCR2 key to node %s: %s: %s", key.ChainType, n.Name, resp.Status(), resp.String())
+	}
+
+	return imported.Data.ID, nil
+}
+
+// ocr2KeyBundleID returns ID of the node's OCR2 key bundle for the chain type. Bundles from a keys file are imported
+// the first time they are needed, otherwise the bundle generated by the node is used.
+func (n *Node) ocr2KeyBundleID(ctx context.Context, chainType string) (string, error) {
+	key, ok := n.Keys.OCR2Keys[strings.ToLower(chainType)]
+	if !ok {
+		return n.Clients.GQLClient.FetchOCR2KeyBundleID(ctx, chainType)
+	}
+	if id, imported := n.Keys.OCR2BundleIDs[strings.ToLower(chainType)]; imported {
+		return id, nil
+	}
+
+	return n.ImportOCR2Key(key)
+}
+
 func LinkToJobDistributor(ctx context.Context, input *LinkDonsToJDInput) (*cldf.Environment, error) {
 	if input == nil {
 		return nil, errors.New("input is nil")
@@ -25,6 +25,7 @@ import (
 	evmconfigtoml "github.com/smartcontractkit/chainlink-evm/pkg/config/toml"
 	chainlinkbig "github.com/smartcontractkit/chainlink-evm/pkg/utils/big"
 	solcfg "github.com/smartcontractkit/chainlink-solana/pkg/solana/config"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
 	"github.com/smartcontractkit/chainlink-testing-framework/lib/utils/ptr"
 
 	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
@@ -114,6 +115,7 @@ func PrepareNodeTOMLs(
 					GatewayConnectorOutput:  topology.GatewayConnectors,
 					NodeSet:                 localNodeSets[i],
 					CapabilityConfigs:       creEnv.CapabilityConfigs,
+					ChainFinalityConfigs:    creEnv.ChainFinalityConfigs,
 				},
 				configFactoryFunctions,
 			)
@@ -156,6 +158,9 @@ func generateNodeTomlConfig(input cre.GenerateConfigsInput, nodeConfigTransforme
 
 	for nodeIdx, nodeMetadata := range input.DonMetadata.NodesMetadata {
 		nodeConfig := baseNodeConfig()
+		if input.DonMetadata.CapabilitiesAwareNodeSet().NodeProfile == cre.NodeProfileSlim {
+			applySlimProfile(&nodeConfig)
+		}
 		for _, role := range nodeMetadata.Roles {
 			switch role {
 			case cre.BootstrapNode:
@@ -228,6 +233,17 @@ func baseNodeConfig() corechainlink.Config {
 	}
 }
 
+// applySlimProfile trims node config down to what is needed to run workflows and capabilities.
+// Each node gets its own database in the shared Postgres container, so connection pools are the main limit of the node count.
+func applySlimProfile(nodeConfig *corechainlink.Config) {
+	nodeConfig.InsecureFastScrypt = ptr.Ptr(true)
+	nodeConfig.Database.MaxOpenConns = ptr.Ptr(int64(10))
+	nodeConfig.Database.MaxIdleConns = ptr.Ptr(int64(2))
+	nodeConfig.Log.Level = ptr.Ptr(coretoml.LogLevel(zapcore.WarnLevel))
+	nodeConfig.JobPipeline.MaxSuccessfulRuns = ptr.Ptr(uint64(0))
+	nodeConfig.Telemetry.Enabled = ptr.Ptr(false)
+}
+
 func addBootstrapNodeConfig(
 	existingConfig corechainlink.Config,
 	ocrPeeringData cre.OCRPeeringData,
@@ -265,16 +281,7 @@ func addBootstrapNodeConfig(
 	}
 
 	if commonInputs.solanaChain != nil {
-		existingConfig.Solana = append(existingConfig.Solana, &solcfg.TOMLConfig{
-			Enabled: ptr.Ptr(true),
-			ChainID: ptr.Ptr(commonInputs.solanaChain.ChainID),
-			Nodes: []*solcfg.Node{
-				{
-					Name: &commonInputs.solanaChain.Name,
-					URL:  commonconfig.MustParseURL(commonInputs.solanaChain.NodeURL),
-				},
-			},
-		})
+		appendSolanaChain(&existingConfig.Solana, commonInputs.solanaChain)
 	}
 
 	existingConfig.Capabilities.ExternalRegistry = coretoml.ExternalRegistry{
@@ -327,16 +334,7 @@ func addWorkerNodeConfig(
 	}
 
 	if commonInputs.solanaChain != nil {
-		existingConfig.Solana = append(existingConfig.Solana, &solcfg.TOMLConfig{
-			ChainID: ptr.Ptr(commonInputs.solanaChain.ChainID),
-			Enabled: ptr.Ptr(true),
-			Nodes: solcfg.Nodes{
-				{
-					Name: ptr.Ptr(commonInputs.solanaChain.Name),
-					URL:  commonconfig.MustParseURL(commonInputs.solanaChain.NodeURL),
-				},
-			},
-		})
+		appendSolanaChain(&existingConfig.Solana, commonInputs.solanaChain)
 	}
 
 	existingConfig.Capabilities.ExternalRegistry = coretoml.ExternalRegistry{
@@ -392,14 +390,7 @@ func addGatewayNodeConfig(
 	existingConfig corechainlink.Config,
 	commonInputs *commonInputs,
 ) (corechainlink.Config, error) {
-OUTER:
 	for _, evmChain := range commonInputs.evmChains {
-		// add only unconfigured chains, since other roles might have already added some chains
-		for _, existingEVM := range existingConfig.EVM {
-			if existingEVM.ChainID.Cmp(chainlinkbig.New(big.NewInt(libc.MustSafeInt64(evmChain.ChainID)))) == 0 {
-				continue OUTER
-			}
-		}
 		appendEVMChain(&existingConfig.EVM, evmChain)
 	}
 
@@ -480,6 +471,14 @@ func gatherCommonInputs(input cre.GenerateConfigsInput) (*commonInputs, error) {
 type evmChain struct {
 	Name    string
 	ChainID uint64
+	Type    string // simulator type, e.g. anvil or geth
+	Nodes   []evmChainNode
+	// optional, overrides finality settings derived from simulator type
+	Finality *cre.ChainFinalityConfig
+}
+
+type evmChainNode struct {
+	Name    string
 	HTTPRPC string
 	WSRPC   string
 }
@@ -497,20 +496,33 @@ func findEVMChains(input cre.GenerateConfigsInput) []*evmChain {
 			continue
 		}
 
-		evmChains = append(evmChains, &evmChain{
-			Name:    fmt.Sprintf("node-%d", chainSelector),
-			ChainID: bcOut.ChainID(),
-			HTTPRPC: bcOut.CtfOutput().Nodes[0].InternalHTTPUrl,
-			WSRPC:   bcOut.CtfOutput().Nodes[0].InternalWSUrl,
-		})
+		chain := &evmChain{
+			Name:     fmt.Sprintf("node-%d", chainSelector),
+			ChainID:  bcOut.ChainID(),
+			Type:     bcOut.CtfOutput().Type,
+			Finality: input.ChainFinalityConfigs[strconv.FormatUint(bcOut.ChainID(), 10)],
+		}
+		// every RPC node of the simulator becomes a node in the [[EVM.Nodes]] section, first one keeps the legacy name
+		for idx, node := range bcOut.CtfOutput().Nodes {
+			name := chain.Name
+			if idx > 0 {
+				name = fmt.Sprintf("%s-%d", chain.Name, idx)
+			}
+			chain.Nodes = append(chain.Nodes, evmChainNode{
+				Name:    name,
+				HTTPRPC: node.InternalHTTPUrl,
+				WSRPC:   node.InternalWSUrl,
+			})
+		}
+		evmChains = append(evmChains, chain)
 	}
 	return evmChains
 }
 
 type solanaChain struct {
-	Name    string
-	ChainID string
-	NodeURL string
+	Name     string
+	ChainID  string
+	NodeURLs []string
 }
 
 func findOneSolanaChain(input cre.GenerateConfigsInput) (*solanaChain, error) {
@@ -540,52 +552,108 @@ func findOneSolanaChain(input cre.GenerateConfigsInput) (*solanaChain, error) {
 		solChain = &solanaChain{
 			Name:    fmt.Sprintf("node-%d", solBc.ChainSelector()),
 			ChainID: chainID.String(),
-			NodeURL: bcOut.CtfOutput().Nodes[0].InternalHTTPUrl,
+		}
+		for _, node := range bcOut.CtfOutput().Nodes {
+			solChain.NodeURLs = append(solChain.NodeURLs, node.InternalHTTPUrl)
 		}
 	}
 
 	return solChain, nil
 }
 
+// simulatedChainConfig returns chain settings matching behaviour of the simulator, so that nodes don't wait for
+// confirmations or finality depth suitable for live networks. Types without known behaviour keep node defaults.
+func simulatedChainConfig(chainType string) evmconfigtoml.Chain {
+	switch chainType {
+	case blockchain.TypeAnvil, blockchain.TypeGeth, blockchain.TypeBesu:
+		// simulators mine blocks instantly and never reorg, same as 'Simulated' chain defaults
+		return evmconfigtoml.Chain{
+			FinalityDepth:            ptr.Ptr(uint32(10)),
+			FinalityTagEnabled:       ptr.Ptr(false),
+			MinIncomingConfirmations: ptr.Ptr(uint32(1)),
+		}
+	default:
+		return evmconfigtoml.Chain{}
+	}
+}
+
+// applyFinalityConfig overrides chain finality settings with user-provided ones, that the simulator was also configured with.
+// With finality tag enabled the safe depth is the one of the simulator, see cre.ChainFinalityConfig.SimulatedSafeDepth.
+func applyFinalityConfig(chain *evmconfigtoml.Chain, finality *cre.ChainFinalityConfig) {
+	if finality == nil {
+		return
+	}
+
+	chain.FinalityTagEnabled = ptr.Ptr(finality.FinalityTagEnabled)
+	if finality.FinalityDepth > 0 {
+		chain.FinalityDepth = ptr.Ptr(finality.FinalityDepth)
+	}
+	if safeDepth := finality.SimulatedSafeDepth(); safeDepth > 0 {
+		chain.SafeDepth = ptr.Ptr(safeDepth)
+	}
+	if finality.MinIncomingConfirmations > 0 {
+		chain.MinIncomingConfirmations = ptr.Ptr(finality.MinIncomingConfirmations)
+	}
+}
+
 func buildTronEVMConfig(evmChain *evmChain) evmconfigtoml.EVMConfig {
-	tronRPC := strings.Replace(evmChain.HTTPRPC, "jsonrpc", "wallet", 1)
+	nodes := make([]*evmconfigtoml.Node, 0, len(evmChain.Nodes))
+	for _, node := range evmChain.Nodes {
+		tronRPC := strings.Replace(node.HTTPRPC, "jsonrpc", "wallet", 1)
+		nodes = append(nodes, &evmconfigtoml.Node{
+			Name:              ptr.Ptr(node.Name),
+			HTTPURL:           commonconfig.MustParseURL(node.HTTPRPC),
+			HTTPURLExtraWrite: commonconfig.MustParseURL(tronRPC),
+		})
+	}
+
+	chain := evmconfigtoml.Chain{
+		AutoCreateKey:         ptr.Ptr(false),
+		ChainType:             chaintype.NewConfig("tron"),
+		LogBroadcasterEnabled: ptr.Ptr(false),
+		NodePool: evmconfigtoml.NodePool{
+			NewHeadsPollInterval: commonconfig.MustNewDuration(10 * time.Second),
+		},
+	}
+	applyFinalityConfig(&chain, evmChain.Finality)
+
 	return evmconfigtoml.EVMConfig{
 		ChainID: chainlinkbig.New(big.NewInt(libc.MustSafeInt64(evmChain.ChainID))),
-		Chain: evmconfigtoml.Chain{
-			AutoCreateKey:         ptr.Ptr(false),
-			ChainType:             chaintype.NewConfig("tron"),
-			LogBroadcasterEnabled: ptr.Ptr(false),
-			NodePool: evmconfigtoml.NodePool{
-				NewHeadsPollInterval: commonconfig.MustNewDuration(10 * time.Second),
-			},
-		},
-		Nodes: []*evmconfigtoml.Node{
-			{
-				Name:              ptr.Ptr(evmChain.Name),
-				HTTPURL:           commonconfig.MustParseURL(evmChain.HTTPRPC),
-				HTTPURLExtraWrite: commonconfig.MustParseURL(tronRPC),
-			},
-		},
+		Chain:   chain,
+		Nodes:   nodes,
 	}
 }
 
 func buildEVMConfig(evmChain *evmChain) evmconfigtoml.EVMConfig {
+	nodes := make([]*evmconfigtoml.Node, 0, len(evmChain.Nodes))
+	for _, node := range evmChain.Nodes {
+		nodes = append(nodes, &evmconfigtoml.Node{
+			Name:    ptr.Ptr(node.Name),
+			WSURL:   commonconfig.MustParseURL(node.WSRPC),
+			HTTPURL: commonconfig.MustParseURL(node.HTTPRPC),
+		})
+	}
+
+	chain := simulatedChainConfig(evmChain.Type)
+	chain.AutoCreateKey = ptr.Ptr(false)
+	applyFinalityConfig(&chain, evmChain.Finality)
+
 	return evmconfigtoml.EVMConfig{
 		ChainID: chainlinkbig.New(big.NewInt(libc.MustSafeInt64(evmChain.ChainID))),
-		Chain: evmconfigtoml.Chain{
-			AutoCreateKey: ptr.Ptr(false),
-		},
-		Nodes: []*evmconfigtoml.Node{
-			{
-				Name:    ptr.Ptr(evmChain.Name),
-				WSURL:   commonconfig.MustParseURL(evmChain.WSRPC),
-				HTTPURL: commonconfig.MustParseURL(evmChain.HTTPRPC),
-			},
-		},
+		Chain:   chain,
+		Nodes:   nodes,
 	}
 }
 
+// appendEVMChain adds EVM config for the chain, unless it is already configured (e.g. by another node role)
 func appendEVMChain(existingConfig *evmconfigtoml.EVMConfigs, evmChain *evmChain) {
+	chainID := chainlinkbig.New(big.NewInt(libc.MustSafeInt64(evmChain.ChainID)))
+	for _, existingEVM := range *existingConfig {
+		if existingEVM.ChainID.Cmp(chainID) == 0 {
+			return
+		}
+	}
+
 	var cfg evmconfigtoml.EVMConfig
 	if evmChain.ChainID == TronEVMChainID {
 		cfg = buildTronEVMConfig(evmChain)
@@ -594,3 +662,30 @@ func appendEVMChain(existingConfig *evmconfigtoml.EVMConfigs, evmChain *evmChain
 	}
 	*existingConfig = append(*existingConfig, &cfg)
 }
+
+// appendSolanaChain adds Solana config for the chain, unless it is already configured (e.g. by another node role)
+func appendSolanaChain(existingConfig *solcfg.TOMLConfigs, solChain *solanaChain) {
+	for _, existingSol := range *existingConfig {
+		if existingSol.ChainID != nil && *existingSol.ChainID == solChain.ChainID {
+			return
+		}
+	}
+
+	nodes := make(solcfg.Nodes, 0, len(solChain.NodeURLs))
+	for idx, url := range solChain.NodeURLs {
+		name := solChain.Name
+		if idx > 0 {
+			name = fmt.Sprintf("%s-%d", solChain.Name, idx)
+		}
+		nodes = append(nodes, &solcfg.Node{
+			Name: ptr.Ptr(name),
+			URL:  commonconfig.MustParseURL(url),
+		})
+	}
+
+	*existingConfig = append(*existingConfig, &solcfg.TOMLConfig{
+		Enabled: ptr.Ptr(true),
+		ChainID: ptr.Ptr(solChain.ChainID),
+		Nodes:   nodes,
+	})
+}
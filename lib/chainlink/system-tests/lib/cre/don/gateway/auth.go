@@ -0,0 +1,286 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	jsonrpc "github.com/smartcontractkit/chainlink-common/pkg/jsonrpc2"
+	gateway_common "github.com/smartcontractkit/chainlink-common/pkg/types/gateway"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/logs"
+	coregateway "github.com/smartcontractkit/chainlink/v2/core/services/gateway"
+	"github.com/smartcontractkit/chainlink/v2/core/services/gateway/config"
+	"github.com/smartcontractkit/chainlink/v2/core/utils"
+)
+
+// AuthConfig exposes authentication options of gateways. Zero values keep defaults used by JobConfigs(), call Apply once
+// all handlers were added to gateway job configs.
+type AuthConfig struct {
+	// AuthTimestampToleranceSec is how much timestamps used by nodes to authenticate with gateways can drift
+	AuthTimestampToleranceSec uint32 `toml:"auth_timestamp_tolerance_sec"`
+	AuthChallengeLen          uint32 `toml:"auth_challenge_len"`
+	// MaxAllowedMessageAgeSec is how old signed messages of web-api triggers can be before they are rejected, it's set on
+	// web-api handlers of all DONs. Signed HTTP trigger requests are limited by the lifetime of their JWT instead.
+	MaxAllowedMessageAgeSec uint `toml:"max_allowed_message_age_sec"`
+	// AllowedSenders are keys allowed to trigger HTTP workflows, use AuthorizedKeys() in HTTP trigger configs of workflows.
+	// AssertAuthRejected requires the authorized key to be one of them.
+	AllowedSenders []AllowedSender `toml:"allowed_senders"`
+}
+
+type AllowedSender struct {
+	KeyType   string `toml:"key_type"` // only "ecdsa_evm" is supported by gateways
+	PublicKey string `toml:"public_key"`
+}
+
+func (c *AuthConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for _, sender := range c.AllowedSenders {
+		if gateway_common.KeyType(sender.KeyType) != gateway_common.KeyTypeECDSAEVM {
+			return fmt.Errorf("unsupported key type '%s' of allowed sender %s, only '%s' is supported", sender.KeyType, sender.PublicKey, gateway_common.KeyTypeECDSAEVM)
+		}
+		if !common.IsHexAddress(sender.PublicKey) {
+			return fmt.Errorf("allowed sender %s is not a valid EVM address", sender.PublicKey)
+		}
+	}
+
+	return nil
+}
+
+// AuthorizedKeys returns allowed senders in the format expected by HTTP trigger configs
+func (c *AuthConfig) AuthorizedKeys() []gateway_common.AuthorizedKey {
+	if c == nil {
+		return nil
+	}
+
+	keys := make([]gateway_common.AuthorizedKey, 0, len(c.AllowedSenders))
+	for _, sender := range c.AllowedSenders {
+		keys = append(keys, gateway_common.AuthorizedKey{
+			KeyType:   gateway_common.KeyType(sender.KeyType),
+			PublicKey: strings.ToLower(sender.PublicKey),
+		})
+	}
+
+	return keys
+}
+
+// IsAllowedSender returns true if no allowed senders are configured or if address is one of them
+func (c *AuthConfig) IsAllowedSender(address common.Address) bool {
+	if c == nil || len(c.AllowedSenders) == 0 {
+		return true
+	}
+
+	for _, sender := range c.AllowedSenders {
+		if common.HexToAddress(sender.PublicKey) == address {
+			return true
+		}
+	}
+
+	return false
+}
+
+var maxAllowedMessageAgeRe = regexp.MustCompile(`(?m)^[ \t]*maxAllowedMessageAgeSec[ \t]*=.*$`)
+
+// Apply sets the connection manager options of all gateways and the max message age of web-api handlers of all DONs.
+// Features add their handlers to gateway job configs before the environment starts, so Apply has to be called after
+// all of them did. It's a no-op on nil AuthConfig.
+func (c *AuthConfig) Apply(gatewayJobConfigs map[cre.NodeUUID]*config.GatewayConfig) {
+	if c == nil {
+		return
+	}
+
+	for _, gc := range gatewayJobConfigs {
+		if c.AuthTimestampToleranceSec > 0 {
+			gc.ConnectionManagerConfig.AuthTimestampToleranceSec = c.AuthTimestampToleranceSec
+		}
+		if c.AuthChallengeLen > 0 {
+			gc.ConnectionManagerConfig.AuthChallengeLen = c.AuthChallengeLen
+		}
+
+		if c.MaxAllowedMessageAgeSec == 0 {
+			continue
+		}
+		for donIdx := range gc.Dons {
+			for handlerIdx, handler := range gc.Dons[donIdx].Handlers {
+				if !strings.EqualFold(handler.Name, coregateway.WebAPICapabilitiesType) {
+					continue
+				}
+				maxAge := fmt.Sprintf("maxAllowedMessageAgeSec = %d", c.MaxAllowedMessageAgeSec)
+				if maxAllowedMessageAgeRe.Match(handler.Config) {
+					handler.Config = maxAllowedMessageAgeRe.ReplaceAll(handler.Config, []byte(maxAge))
+				} else {
+					handler.Config = append([]byte(maxAge+"\n"), handler.Config...)
+				}
+				gc.Dons[donIdx].Handlers[handlerIdx] = handler
+			}
+		}
+	}
+}
+
+// NewSignedTriggerRequest returns an HTTP trigger request for given workflow and a JWT signed with given key. The ID of
+// the request is a new correlation ID, which can be used to find the execution in logs, see logs.CorrelateLogs.
+func NewSignedTriggerRequest(workflowID string, input json.RawMessage, key *ecdsa.PrivateKey, opts ...utils.Option) (*jsonrpc.Request[gateway_common.HTTPTriggerRequest], string, error) {
+	return NewSignedTriggerRequestWithID(logs.NewCorrelationID(), workflowID, input, key, opts...)
+}
+
+// NewSignedTriggerRequestWithID works like NewSignedTriggerRequest, but uses given request ID, e.g. a correlation ID
+// shared by more test actions
+func NewSignedTriggerRequestWithID(requestID, workflowID string, input json.RawMessage, key *ecdsa.PrivateKey, opts ...utils.Option) (*jsonrpc.Request[gateway_common.HTTPTriggerRequest], string, error) {
+	req := &jsonrpc.Request[gateway_common.HTTPTriggerRequest]{
+		Version: jsonrpc.JsonRpcVersion,
+		ID:      requestID,
+		Method:  gateway_common.MethodWorkflowExecute,
+		Params: &gateway_common.HTTPTriggerRequest{
+			Input: input,
+			Key: gateway_common.AuthorizedKey{
+				KeyType:   gateway_common.KeyTypeECDSAEVM,
+				PublicKey: strings.ToLower(crypto.PubkeyToAddress(key.PublicKey).Hex()),
+			},
+			Workflow: gateway_common.WorkflowSelector{WorkflowID: workflowID},
+		},
+	}
+
+	token, tErr := utils.CreateRequestJWT(*req, opts...)
+	if tErr != nil {
+		return nil, "", errors.Wrap(tErr, "failed to create request JWT")
+	}
+
+	signed, sErr := token.SignedString(key)
+	if sErr != nil {
+		return nil, "", errors.Wrap(sErr, "failed to sign request JWT")
+	}
+
+	return req, signed, nil
+}
+
+// SendTriggerRequest sends the request to gateway's user endpoint with token in the Authorization header.
+// Rejected requests are not an error, check Error of the response. Accepted requests are recorded, if a recorder is set
+// with RecordTriggerRequests.
+func SendTriggerRequest(ctx context.Context, gatewayURL string, req *jsonrpc.Request[gateway_common.HTTPTriggerRequest], token string) (*jsonrpc.Response[json.RawMessage], error) {
+	body, mErr := json.Marshal(req)
+	if mErr != nil {
+		return nil, errors.Wrap(mErr, "failed to marshal trigger request")
+	}
+
+	httpReq, rErr := http.NewRequestWithContext(ctx, http.MethodPost, gatewayURL, bytes.NewReader(body))
+	if rErr != nil {
+		return nil, errors.Wrap(rErr, "failed to create HTTP request")
+	}
+	httpReq.Header.Set("Content-Type", "application/jsonrpc")
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	httpResp, dErr := http.DefaultClient.Do(httpReq)
+	if dErr != nil {
+		return nil, errors.Wrapf(dErr, "failed to send trigger request to %s", gatewayURL)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, readErr := io.ReadAll(httpResp.Body)
+	if readErr != nil {
+		return nil, errors.Wrap(readErr, "failed to read trigger response")
+	}
+
+	resp := &jsonrpc.Response[json.RawMessage]{}
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal trigger response (HTTP status %d): %s", httpResp.StatusCode, string(respBody))
+	}
+	recordTriggerRequest(req, resp)
+
+	return resp, nil
+}
+
+// AuthNegativeCase builds a request, which gateway must reject. authorizedKey is a key allowed to trigger the workflow.
+type AuthNegativeCase struct {
+	Name  string
+	Build func(workflowID string, input json.RawMessage, authorizedKey *ecdsa.PrivateKey) (*jsonrpc.Request[gateway_common.HTTPTriggerRequest], string, error)
+}
+
+// AuthNegativeCases returns requests that gateway must reject: signed by an unauthorized sender, with an expired signature,
+// with a signature not matching the request and without any signature
+func AuthNegativeCases() []AuthNegativeCase {
+	return []AuthNegativeCase{
+		{
+			Name: "unauthorized sender",
+			Build: func(workflowID string, input json.RawMessage, _ *ecdsa.PrivateKey) (*jsonrpc.Request[gateway_common.HTTPTriggerRequest], string, error) {
+				unauthorizedKey, kErr := crypto.GenerateKey()
+				if kErr != nil {
+					return nil, "", errors.Wrap(kErr, "failed to generate key")
+				}
+				return NewSignedTriggerRequest(workflowID, input, unauthorizedKey)
+			},
+		},
+		{
+			Name: "expired signature",
+			Build: func(workflowID string, input json.RawMessage, authorizedKey *ecdsa.PrivateKey) (*jsonrpc.Request[gateway_common.HTTPTriggerRequest], string, error) {
+				return NewSignedTriggerRequest(workflowID, input, authorizedKey, utils.WithExpiry(-time.Minute))
+			},
+		},
+		{
+			Name: "tampered request",
+			Build: func(workflowID string, input json.RawMessage, authorizedKey *ecdsa.PrivateKey) (*jsonrpc.Request[gateway_common.HTTPTriggerRequest], string, error) {
+				req, token, err := NewSignedTriggerRequest(workflowID, input, authorizedKey)
+				if err != nil {
+					return nil, "", err
+				}
+				req.ID = uuid.NewString() // digest no longer matches the signed one
+				return req, token, nil
+			},
+		},
+		{
+			Name: "missing signature",
+			Build: func(workflowID string, input json.RawMessage, authorizedKey *ecdsa.PrivateKey) (*jsonrpc.Request[gateway_common.HTTPTriggerRequest], string, error) {
+				req, _, err := NewSignedTriggerRequest(workflowID, input, authorizedKey)
+				return req, "", err
+			},
+		},
+	}
+}
+
+// AssertAuthRejected sends all negative-path requests to the gateway and returns an error listing the ones that were not
+// rejected. authorizedKey must be one of the allowed senders of authConfig (if any are configured), otherwise requests
+// could be rejected only because of the sender and the other cases wouldn't be checked.
+func AssertAuthRejected(ctx context.Context, gatewayURL, workflowID string, input json.RawMessage, authorizedKey *ecdsa.PrivateKey, authConfig *AuthConfig) error {
+	if authorizedAddress := crypto.PubkeyToAddress(authorizedKey.PublicKey); !authConfig.IsAllowedSender(authorizedAddress) {
+		return fmt.Errorf("authorized key %s is not one of the allowed senders", authorizedAddress.Hex())
+	}
+
+	var accepted []string
+	for _, tc := range AuthNegativeCases() {
+		req, token, bErr := tc.Build(workflowID, input, authorizedKey)
+		if bErr != nil {
+			return errors.Wrapf(bErr, "failed to build request for case '%s'", tc.Name)
+		}
+
+		resp, sErr := SendTriggerRequest(ctx, gatewayURL, req, token)
+		if sErr != nil {
+			return errors.Wrapf(sErr, "failed to send request for case '%s'", tc.Name)
+		}
+
+		if resp.Error == nil {
+			accepted = append(accepted, tc.Name)
+		}
+	}
+
+	if len(accepted) > 0 {
+		return fmt.Errorf("gateway accepted requests that should have been rejected: %s", strings.Join(accepted, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jsonrpc "github.com/smartcontractkit/chainlink-common/pkg/jsonrpc2"
+	gateway_common "github.com/smartcontractkit/chainlink-common/pkg/types/gateway"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	coregateway "github.com/smartcontractkit/chainlink/v2/core/services/gateway"
+	"github.com/smartcontractkit/chainlink/v2/core/services/gateway/config"
+)
+
+func TestAuthConfigApply(t *testing.T) {
+	webAPIHandler, err := HandlerConfig(coregateway.WebAPICapabilitiesType)
+	require.NoError(t, err)
+	httpHandler, err := HandlerConfig(coregateway.HTTPCapabilityType)
+	require.NoError(t, err)
+
+	gatewayJobConfigs := map[cre.NodeUUID]*config.GatewayConfig{
+		"gateway": {
+			ConnectionManagerConfig: config.ConnectionManagerConfig{AuthChallengeLen: 10, AuthTimestampToleranceSec: 5},
+			Dons: []config.DONConfig{
+				{DonId: "workflow", Handlers: []config.Handler{webAPIHandler}},
+				// web-api handlers added by features to other DONs get the max age too
+				{DonId: "trigger", Handlers: []config.Handler{httpHandler, webAPIHandler}},
+			},
+		},
+	}
+
+	authConfig := &AuthConfig{AuthTimestampToleranceSec: 30, MaxAllowedMessageAgeSec: 60}
+	authConfig.Apply(gatewayJobConfigs)
+
+	gc := gatewayJobConfigs["gateway"]
+	assert.Equal(t, uint32(30), gc.ConnectionManagerConfig.AuthTimestampToleranceSec)
+	assert.Equal(t, uint32(10), gc.ConnectionManagerConfig.AuthChallengeLen, "unset option wasn't kept")
+	assert.Contains(t, string(gc.Dons[0].Handlers[0].Config), "maxAllowedMessageAgeSec = 60\n")
+	assert.Contains(t, string(gc.Dons[1].Handlers[1].Config), "maxAllowedMessageAgeSec = 60\n")
+	assert.NotContains(t, string(gc.Dons[1].Handlers[1].Config), "1_000")
+	assert.Equal(t, httpHandler, gc.Dons[1].Handlers[0], "handler of another type was changed")
+	assert.Contains(t, string(webAPIHandler.Config), "maxAllowedMessageAgeSec = 1_000", "default handler config was changed")
+}
+
+func TestAuthConfigValidate(t *testing.T) {
+	address := crypto.PubkeyToAddress(mustGenerateKey(t).PublicKey).Hex()
+
+	require.NoError(t, (*AuthConfig)(nil).Validate())
+	require.NoError(t, (&AuthConfig{AllowedSenders: []AllowedSender{{KeyType: string(gateway_common.KeyTypeECDSAEVM), PublicKey: address}}}).Validate())
+	require.ErrorContains(t, (&AuthConfig{AllowedSenders: []AllowedSender{{KeyType: "ed25519", PublicKey: address}}}).Validate(), "unsupported key type")
+	require.ErrorContains(t, (&AuthConfig{AllowedSenders: []AllowedSender{{KeyType: string(gateway_common.KeyTypeECDSAEVM), PublicKey: "0x123"}}}).Validate(), "not a valid EVM address")
+}
+
+func TestAssertAuthRejected(t *testing.T) {
+	authorizedKey := mustGenerateKey(t)
+	authConfig := &AuthConfig{AllowedSenders: []AllowedSender{{
+		KeyType:   string(gateway_common.KeyTypeECDSAEVM),
+		PublicKey: crypto.PubkeyToAddress(authorizedKey.PublicKey).Hex(),
+	}}}
+	input := json.RawMessage(`{}`)
+
+	rejectingGateway := newTestGateway(t, true)
+	require.NoError(t, AssertAuthRejected(t.Context(), rejectingGateway.URL, "workflow", input, authorizedKey, authConfig))
+
+	acceptingGateway := newTestGateway(t, false)
+	err := AssertAuthRejected(t.Context(), acceptingGateway.URL, "workflow", input, authorizedKey, authConfig)
+	require.ErrorContains(t, err, "unauthorized sender, expired signature, tampered request, missing signature")
+
+	err = AssertAuthRejected(t.Context(), rejectingGateway.URL, "workflow", input, mustGenerateKey(t), authConfig)
+	require.ErrorContains(t, err, "is not one of the allowed senders")
+}
+
+func newTestGateway(t *testing.T, reject bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := jsonrpc.Request[gateway_common.HTTPTriggerRequest]{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		resp := jsonrpc.Response[json.RawMessage]{Version: jsonrpc.JsonRpcVersion, ID: req.ID}
+		if reject {
+			resp.Error = &jsonrpc.WireError{Code: -32600, Message: "Auth failure"}
+		} else {
+			result := json.RawMessage(`{"accepted":true}`)
+			resp.Result = &result
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func mustGenerateKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	return key
+}
@@ -0,0 +1,80 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sethvargo/go-retry"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// LoadBalancerUpstreams returns internal addresses (host:port) of incoming endpoints of all gateways in the topology
+func LoadBalancerUpstreams(connectors *cre.GatewayConnectors) []string {
+	if connectors == nil {
+		return nil
+	}
+
+	upstreams := make([]string, 0, len(connectors.Configurations))
+	for _, configuration := range connectors.Configurations {
+		upstreams = append(upstreams, net.JoinHostPort(configuration.Outgoing.Host, strconv.Itoa(configuration.Incoming.InternalPort)))
+	}
+
+	return upstreams
+}
+
+// KillGateway kills the gateway node with given position (in the order returned by Dons.Gateways()) and returns it
+func KillGateway(ctx context.Context, provider infra.Provider, dons *cre.Dons, position int) (*cre.Node, error) {
+	current := 0
+	for _, don := range dons.List() {
+		for _, gatewayNode := range don.Gateways() {
+			if current != position {
+				current++
+				continue
+			}
+
+			if err := provider.KillNode(ctx, gatewayNode.Index, don.Name); err != nil {
+				return nil, errors.Wrapf(err, "failed to kill gateway node %s", gatewayNode.Name)
+			}
+
+			return gatewayNode, nil
+		}
+	}
+
+	return nil, fmt.Errorf("gateway at position %d not found, topology has %d gateways", position, current)
+}
+
+// VerifyFailover checks that capabilities relying on gateways (e.g. web-api) keep working after one of the gateways is killed.
+// The check is first run with all gateways up, then the first gateway is killed and the check is retried until it passes
+// or the timeout is reached. Topology needs at least two gateways and requests should be sent through the load balancer.
+func VerifyFailover(ctx context.Context, provider infra.Provider, dons *cre.Dons, timeout time.Duration, check func(ctx context.Context) error) error {
+	if gateways := dons.Gateways(); len(gateways) < 2 {
+		return fmt.Errorf("at least 2 gateways are required to verify failover, but topology has %d", len(gateways))
+	}
+
+	if err := check(ctx); err != nil {
+		return errors.Wrap(err, "check failed before killing the gateway")
+	}
+
+	killed, killErr := KillGateway(ctx, provider, dons, 0)
+	if killErr != nil {
+		return killErr
+	}
+
+	retryErr := retry.Do(ctx, retry.WithMaxDuration(timeout, retry.NewConstant(2*time.Second)), func(ctx context.Context) error {
+		if err := check(ctx); err != nil {
+			return retry.RetryableError(err)
+		}
+		return nil
+	})
+	if retryErr != nil {
+		return errors.Wrapf(retryErr, "requests didn't fail over within %s after killing gateway %s", timeout, killed.Name)
+	}
+
+	return nil
+}
@@ -29,6 +29,8 @@ var (
 	DefaultAllowedPorts = []int{80, 443}
 )
 
+const defaultMaxAllowedMessageAgeSec = 1_000
+
 type WhitelistConfig struct {
 	ExtraAllowedPorts                    []int
 	ExtraAllowedIPs, ExtraAllowedIPsCIDR []string
@@ -55,94 +57,91 @@ func JobConfigs(
 		return nil, nil
 	}
 
-	// for each gateway node prepare GatewayConfig, which will be later used in a job spec
+	// for each gateway node (there might be more of them in HA setups) prepare GatewayConfig, which will be later used in a job spec
 	// by default we add only add web-api handler to the workflow DON (so that it can download workflows)
 	// all other handlers should be added by capabilities/features that require them
 	// gateway configurations that contain networking data are added, when a new topology is created
 	result := make(map[string]*config.GatewayConfig)
 	for _, donMetadata := range topology.DonsMetadata.List() {
-		gateway, hasGateway := donMetadata.Gateway()
-		if !hasGateway {
-			continue
-		}
-
-		configuration, cErr := topology.GatewayConnectors.FindByNodeUUID(gateway.UUID)
-		if cErr != nil {
-			return nil, errors.Wrapf(cErr, "failed to find gateway configuration for node UUID %s", gateway.UUID)
-		}
+		for _, gateway := range donMetadata.Gateways() {
+			configuration, cErr := topology.GatewayConnectors.FindByNodeUUID(gateway.UUID)
+			if cErr != nil {
+				return nil, errors.Wrapf(cErr, "failed to find gateway configuration for node UUID %s", gateway.UUID)
+			}
 
-		c := config.GatewayConfig{
-			ConnectionManagerConfig: config.ConnectionManagerConfig{
-				AuthGatewayId:             configuration.AuthGatewayID,
-				AuthChallengeLen:          10,
-				AuthTimestampToleranceSec: 5,
-				HeartbeatIntervalSec:      20,
-			},
-			NodeServerConfig: gw_net.WebSocketServerConfig{
-				HandshakeTimeoutMillis: 1000,
-				HTTPServerConfig: gw_net.HTTPServerConfig{
+			c := config.GatewayConfig{
+				ConnectionManagerConfig: config.ConnectionManagerConfig{
+					AuthGatewayId:             configuration.AuthGatewayID,
+					AuthChallengeLen:          10,
+					AuthTimestampToleranceSec: 5,
+					HeartbeatIntervalSec:      20,
+				},
+				NodeServerConfig: gw_net.WebSocketServerConfig{
+					HandshakeTimeoutMillis: 1000,
+					HTTPServerConfig: gw_net.HTTPServerConfig{
+						MaxRequestBytes:      100_000,
+						ReadTimeoutMillis:    1_000,
+						RequestTimeoutMillis: 10_000,
+						WriteTimeoutMillis:   1_000,
+						Path:                 configuration.Outgoing.Path,
+						Port:                 uint16(configuration.Outgoing.Port), //nolint:gosec //should never happen unless someone uses an incorrect negative port
+					},
+				},
+				UserServerConfig: gw_net.HTTPServerConfig{
+					ContentTypeHeader:    "application/jsonrpc",
 					MaxRequestBytes:      100_000,
-					ReadTimeoutMillis:    1_000,
-					RequestTimeoutMillis: 10_000,
-					WriteTimeoutMillis:   1_000,
-					Path:                 configuration.Outgoing.Path,
-					Port:                 uint16(configuration.Outgoing.Port), //nolint:gosec //should never happen unless someone uses an incorrect negative port
+					ReadTimeoutMillis:    80_000,
+					RequestTimeoutMillis: 80_000,
+					WriteTimeoutMillis:   80_000,
+					CORSEnabled:          false,
+					CORSAllowedOrigins:   []string{},
+					Path:                 configuration.Incoming.Path,
+					Port:                 uint16(configuration.Incoming.InternalPort), //nolint:gosec //should never happen unless someone uses an incorrect negative port
 				},
-			},
-			UserServerConfig: gw_net.HTTPServerConfig{
-				ContentTypeHeader:    "application/jsonrpc",
-				MaxRequestBytes:      100_000,
-				ReadTimeoutMillis:    80_000,
-				RequestTimeoutMillis: 80_000,
-				WriteTimeoutMillis:   80_000,
-				CORSEnabled:          false,
-				CORSAllowedOrigins:   []string{},
-				Path:                 configuration.Incoming.Path,
-				Port:                 uint16(configuration.Incoming.InternalPort), //nolint:gosec //should never happen unless someone uses an incorrect negative port
-			},
-			HTTPClientConfig: gw_net.HTTPClientConfig{
-				MaxResponseBytes: 100_000_000,
-				AllowedPorts:     append(whitelistConfig.ExtraAllowedPorts, DefaultAllowedPorts...),
-				AllowedIPs:       whitelistConfig.ExtraAllowedIPs,
-				AllowedIPsCIDR:   whitelistConfig.ExtraAllowedIPsCIDR,
-			},
-		}
+				HTTPClientConfig: gw_net.HTTPClientConfig{
+					MaxResponseBytes: 100_000_000,
+					AllowedPorts:     append(whitelistConfig.ExtraAllowedPorts, DefaultAllowedPorts...),
+					AllowedIPs:       whitelistConfig.ExtraAllowedIPs,
+					AllowedIPsCIDR:   whitelistConfig.ExtraAllowedIPsCIDR,
+				},
+			}
 
-		workflowDON, donErr := topology.DonsMetadata.WorkflowDON()
-		if donErr != nil {
-			return nil, errors.Wrap(donErr, "failed to find workflow DON")
-		}
+			workflowDON, donErr := topology.DonsMetadata.WorkflowDON()
+			if donErr != nil {
+				return nil, errors.Wrap(donErr, "failed to find workflow DON")
+			}
 
-		workerNodes, wErr := workflowDON.Workers()
-		if wErr != nil {
-			return nil, errors.Wrap(wErr, "failed to find worker nodes")
-		}
+			workerNodes, wErr := workflowDON.Workers()
+			if wErr != nil {
+				return nil, errors.Wrap(wErr, "failed to find worker nodes")
+			}
 
-		donConfig := config.DONConfig{
-			DonId:   workflowDON.Name,
-			F:       1,
-			Members: make([]config.NodeConfig, len(workerNodes)),
-		}
+			donConfig := config.DONConfig{
+				DonId:   workflowDON.Name,
+				F:       1,
+				Members: make([]config.NodeConfig, len(workerNodes)),
+			}
 
-		for i, workerNode := range workerNodes {
-			evmKey, ok := workerNode.Keys.EVM[chainID]
-			if !ok {
-				return nil, fmt.Errorf("failed to get EVM key (chainID %d, node index %d)", chainID, workerNode.Index)
+			for i, workerNode := range workerNodes {
+				evmKey, ok := workerNode.Keys.EVM[chainID]
+				if !ok {
+					return nil, fmt.Errorf("failed to get EVM key (chainID %d, node index %d)", chainID, workerNode.Index)
+				}
+				donConfig.Members[i] = config.NodeConfig{
+					Address: evmKey.PublicAddress.Hex(),
+					Name:    fmt.Sprintf("%s-node-%d", workflowDON.Name, i),
+				}
 			}
-			donConfig.Members[i] = config.NodeConfig{
-				Address: evmKey.PublicAddress.Hex(),
-				Name:    fmt.Sprintf("%s-node-%d", workflowDON.Name, i),
+
+			handlerConfig, hErr := HandlerConfig(coregateway.WebAPICapabilitiesType)
+			if hErr != nil {
+				return nil, errors.Wrap(hErr, "failed to get web-api capability handler config")
 			}
-		}
 
-		handlerConfig, hErr := HandlerConfig(coregateway.WebAPICapabilitiesType)
-		if hErr != nil {
-			return nil, errors.Wrap(hErr, "failed to get web-api capability handler config")
+			donConfig.Handlers = []config.Handler{handlerConfig}
+			c.Dons = append(c.Dons, donConfig)
+			result[gateway.UUID] = &c
 		}
-
-		donConfig.Handlers = []config.Handler{handlerConfig}
-		c.Dons = append(c.Dons, donConfig)
-		result[gateway.UUID] = &c
 	}
 
 	if len(result) == 0 {
@@ -344,17 +343,7 @@ perSenderBurst = 10
 perSenderRPS = 10`),
 		}, nil
 	case coregateway.WebAPICapabilitiesType:
-		return config.Handler{
-			Name: coregateway.WebAPICapabilitiesType,
-			Config: []byte(`
-maxAllowedMessageAgeSec = 1_000
-[NodeRateLimiter]
-globalBurst = 10
-globalRPS = 50
-perSenderBurst = 10
-perSenderRPS = 10
-`),
-		}, nil
+		return webAPIHandlerConfig(defaultMaxAllowedMessageAgeSec), nil
 	case coregateway.VaultHandlerType:
 		return config.Handler{
 			Name:        coregateway.VaultHandlerType,
@@ -372,6 +361,20 @@ perSenderRPS = 10
 	}
 }
 
+func webAPIHandlerConfig(maxAllowedMessageAgeSec uint) config.Handler {
+	return config.Handler{
+		Name: coregateway.WebAPICapabilitiesType,
+		Config: []byte(fmt.Sprintf(`
+maxAllowedMessageAgeSec = %d
+[NodeRateLimiter]
+globalBurst = 10
+globalRPS = 50
+perSenderBurst = 10
+perSenderRPS = 10
+`, maxAllowedMessageAgeSec)),
+	}
+}
+
 // ExpandConfigByteArray finds lines like `Config = [10, 109, ...]` and replaces them
 // with TOML tables under the given path, using the bytes as TOML text.
 // Example path: []string{"gatewayConfig","Dons","Handlers","Config"}
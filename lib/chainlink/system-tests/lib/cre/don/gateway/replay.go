@@ -0,0 +1,77 @@
+package gateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"sync"
+
+	jsonrpc "github.com/smartcontractkit/chainlink-common/pkg/jsonrpc2"
+	gateway_common "github.com/smartcontractkit/chainlink-common/pkg/types/gateway"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/replay"
+)
+
+var (
+	triggerRecorderMu sync.RWMutex
+	triggerRecorder   *replay.Recorder
+)
+
+// RecordTriggerRequests makes SendTriggerRequest record HTTP trigger requests accepted by gateways with the recorder,
+// until it's called with nil, so that requests sent by tests and helpers (e.g. fuzz.Harness) are recorded without
+// changing them. Rejected requests aren't recorded, because ReplaySender signs replayed requests with a valid key and
+// they would trigger workflows, which weren't triggered in the recorded run.
+func RecordTriggerRequests(recorder *replay.Recorder) {
+	triggerRecorderMu.Lock()
+	defer triggerRecorderMu.Unlock()
+	triggerRecorder = recorder
+}
+
+// recordTriggerRequest records the request with the recorder set by RecordTriggerRequests, if any. Workflows selected
+// by name are recorded with the ID the gateway responded with, requests without any workflow ID are skipped.
+func recordTriggerRequest(req *jsonrpc.Request[gateway_common.HTTPTriggerRequest], resp *jsonrpc.Response[json.RawMessage]) {
+	triggerRecorderMu.RLock()
+	recorder := triggerRecorder
+	triggerRecorderMu.RUnlock()
+	if recorder == nil || resp.Error != nil || req.Params == nil {
+		return
+	}
+
+	workflowID := req.Params.Workflow.WorkflowID
+	if workflowID == "" && resp.Result != nil {
+		result := gateway_common.HTTPTriggerResponse{}
+		if err := json.Unmarshal(*resp.Result, &result); err == nil {
+			workflowID = result.WorkflowID
+		}
+	}
+	if workflowID == "" {
+		return
+	}
+
+	recorder.Record(replay.Event{
+		Kind:      replay.KindHTTPTrigger,
+		TriggerID: workflowID,
+		EventID:   req.ID,
+		Payload:   req.Params.Input,
+	})
+}
+
+// ReplaySender sends recorded HTTP trigger events as requests signed with given key to workflows of their trigger IDs
+func ReplaySender(gatewayURL string, key *ecdsa.PrivateKey) replay.SendFunc {
+	return func(ctx context.Context, event replay.Event) error {
+		req, token, err := NewSignedTriggerRequest(event.TriggerID, json.RawMessage(event.Payload), key)
+		if err != nil {
+			return err
+		}
+
+		resp, err := SendTriggerRequest(ctx, gatewayURL, req, token)
+		if err != nil {
+			return err
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jsonrpc "github.com/smartcontractkit/chainlink-common/pkg/jsonrpc2"
+	gateway_common "github.com/smartcontractkit/chainlink-common/pkg/types/gateway"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/replay"
+)
+
+func TestRecordTriggerRequests(t *testing.T) {
+	recorder := replay.NewRecorder()
+	RecordTriggerRequests(recorder)
+	t.Cleanup(func() { RecordTriggerRequests(nil) })
+	key := mustGenerateKey(t)
+
+	accepted, token, err := NewSignedTriggerRequest("workflow-1", json.RawMessage(`{"n":1}`), key)
+	require.NoError(t, err)
+	_, err = SendTriggerRequest(t.Context(), newTestGateway(t, false).URL, accepted, token)
+	require.NoError(t, err)
+
+	rejected, token, err := NewSignedTriggerRequest("workflow-1", json.RawMessage(`{"n":2}`), key)
+	require.NoError(t, err)
+	_, err = SendTriggerRequest(t.Context(), newTestGateway(t, true).URL, rejected, token)
+	require.NoError(t, err)
+
+	// workflows selected by name are recorded with the ID the gateway responded with
+	byName := &jsonrpc.Request[gateway_common.HTTPTriggerRequest]{ID: "by-name", Params: &gateway_common.HTTPTriggerRequest{
+		Input:    json.RawMessage(`{"n":3}`),
+		Workflow: gateway_common.WorkflowSelector{WorkflowName: "workflow", WorkflowOwner: "0x01"},
+	}}
+	namedGateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		result := json.RawMessage(`{"workflow_id":"workflow-2","status":"ACCEPTED"}`)
+		assert.NoError(t, json.NewEncoder(w).Encode(jsonrpc.Response[json.RawMessage]{Version: jsonrpc.JsonRpcVersion, ID: "by-name", Result: &result}))
+	}))
+	t.Cleanup(namedGateway.Close)
+	_, err = SendTriggerRequest(t.Context(), namedGateway.URL, byName, "")
+	require.NoError(t, err)
+
+	events := recorder.Recording().Events
+	require.Len(t, events, 2, "rejected request was recorded")
+	assert.Equal(t, replay.KindHTTPTrigger, events[0].Kind)
+	assert.Equal(t, "workflow-1", events[0].TriggerID)
+	assert.Equal(t, accepted.ID, events[0].EventID)
+	assert.JSONEq(t, `{"n":1}`, string(events[0].Payload))
+	assert.Equal(t, "workflow-2", events[1].TriggerID)
+	assert.JSONEq(t, `{"n":3}`, string(events[1].Payload))
+
+	RecordTriggerRequests(nil)
+	_, err = SendTriggerRequest(t.Context(), newTestGateway(t, false).URL, accepted, token)
+	require.NoError(t, err)
+	assert.Len(t, recorder.Recording().Events, 2, "request was recorded after recording stopped")
+}
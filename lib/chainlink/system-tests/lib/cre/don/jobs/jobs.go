@@ -2,15 +2,17 @@ package jobs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/pkg/errors"
+	pkgerrors "github.com/pkg/errors"
 	"go.uber.org/ratelimit"
-	"golang.org/x/sync/errgroup"
 
 	cldf_offchain "github.com/smartcontractkit/chainlink-deployments-framework/offchain"
+	jobv1 "github.com/smartcontractkit/chainlink-protos/job-distributor/v1/job"
 
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
 )
@@ -20,51 +22,72 @@ func Create(ctx context.Context, offChainClient cldf_offchain.Client, dons *cre.
 		return nil
 	}
 
-	eg := &errgroup.Group{}
+	nodesByJDID := make(map[string]*cre.Node)
+	for _, don := range dons.List() {
+		for _, node := range don.Nodes {
+			nodesByJDID[node.JobDistributorDetails.NodeID] = node
+		}
+	}
+
+	var wg sync.WaitGroup
 	jobRateLimit := ratelimit.New(5)
 
-	for _, jobReq := range jobSpecs {
-		eg.Go(func() error {
-			jobRateLimit.Take()
-			timeout := time.Second * 60
-			ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
-			defer cancel()
-			_, pErr := offChainClient.ProposeJob(ctxWithTimeout, jobReq)
-			if pErr != nil {
-				return fmt.Errorf("failed to propose job for node %s: %w", jobReq.NodeId, pErr)
-			}
+	// errors of all nodes are collected, so that if the phase times out, all nodes that didn't get their jobs are named
+	var errsMu sync.Mutex
+	var errs []error
 
-			for _, don := range dons.List() {
-				for _, node := range don.Nodes {
-					if node.JobDistributorDetails.NodeID != jobReq.NodeId {
-						continue
-					}
-
-					// TODO: is there a way to accept the job with proposal id?
-					if err := node.AcceptJob(ctx, jobReq.Spec); err != nil {
-						// Workflow specs get auto approved
-						// TODO: Narrow down scope by checking type == workflow
-						if strings.Contains(err.Error(), "cannot approve an approved spec") {
-							return nil
-						}
-						fmt.Println("Failed jobspec proposal:")
-						fmt.Println(jobReq)
-
-						return fmt.Errorf("failed to accept job. err: %w", err)
-					}
+	for _, jobReq := range jobSpecs {
+		wg.Go(func() {
+			if err := createJob(ctx, offChainClient, jobRateLimit, nodesByJDID[jobReq.NodeId], jobReq); err != nil {
+				target := "node " + jobReq.NodeId
+				if node, ok := nodesByJDID[jobReq.NodeId]; ok {
+					target = "node " + node.Name
 				}
+				errsMu.Lock()
+				errs = append(errs, cre.PhaseTarget(target, err))
+				errsMu.Unlock()
 			}
+		})
+	}
 
-			if ctx.Err() != nil {
-				return errors.Wrapf(pErr, "timed out after %s proposing job for node %s", timeout.String(), jobReq.NodeId)
-			}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return pkgerrors.Wrap(err, "failed to create at least one job for DON")
+	}
 
-			return nil
-		})
+	return nil
+}
+
+func createJob(ctx context.Context, offChainClient cldf_offchain.Client, jobRateLimit ratelimit.Limiter, node *cre.Node, jobReq *jobv1.ProposeJobRequest) error {
+	jobRateLimit.Take()
+	// the rate limiter doesn't respect the context, don't propose the job if the phase timed out in the meantime
+	if ctx.Err() != nil {
+		return pkgerrors.Wrap(ctx.Err(), "job wasn't proposed")
+	}
+
+	timeout := time.Second * 60
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, pErr := offChainClient.ProposeJob(ctxWithTimeout, jobReq)
+	if pErr != nil {
+		return fmt.Errorf("failed to propose job for node %s: %w", jobReq.NodeId, pErr)
 	}
 
-	if err := eg.Wait(); err != nil {
-		return errors.Wrap(err, "failed to create at least one job for DON")
+	if node == nil {
+		return nil
+	}
+
+	// TODO: is there a way to accept the job with proposal id?
+	if err := node.AcceptJob(ctx, jobReq.Spec); err != nil {
+		// Workflow specs get auto approved
+		// TODO: Narrow down scope by checking type == workflow
+		if strings.Contains(err.Error(), "cannot approve an approved spec") {
+			return nil
+		}
+		fmt.Println("Failed jobspec proposal:")
+		fmt.Println(jobReq)
+
+		return fmt.Errorf("failed to accept job. err: %w", err)
 	}
 
 	return nil
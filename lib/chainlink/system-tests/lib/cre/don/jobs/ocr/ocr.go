@@ -20,6 +20,7 @@ import (
 	"github.com/smartcontractkit/chainlink-testing-framework/framework"
 
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
 	crecapabilities "github.com/smartcontractkit/chainlink/system-tests/lib/cre/capabilities"
 	standardcapability "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability"
 	"github.com/smartcontractkit/chainlink/v2/core/services/job"
@@ -70,7 +71,7 @@ func GenerateJobSpecsForStandardCapabilityWithOCR(
 		return nil, errors.Wrapf(pathErr, "failed to get default container directory for infra type %s", creEnv.Provider.Type)
 	}
 
-	binaryPath := filepath.Join(containerPath, filepath.Base(capabilityConfig.BinaryPath))
+	binaryPath := filepath.Join(containerPath, binaries.Name(capabilityConfig.BinaryPath))
 
 	workerNodes, wErr := don.Workers()
 	if wErr != nil {
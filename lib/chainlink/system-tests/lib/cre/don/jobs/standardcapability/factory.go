@@ -9,6 +9,7 @@ import (
 
 	ptypes "github.com/smartcontractkit/chainlink-protos/job-distributor/v1/shared/ptypes"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
 	crecapabilities "github.com/smartcontractkit/chainlink/system-tests/lib/cre/capabilities"
 	credon "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don"
 )
@@ -48,7 +49,7 @@ var BinaryPathBuilder CommandBuilder = func(input *cre.JobSpecInput, capabilityC
 		return "", errors.Wrapf(pathErr, "failed to get default container directory for infra type %s", input.CreEnvironment.Provider.Type)
 	}
 
-	return filepath.Join(containerPath, filepath.Base(capabilityConfig.BinaryPath)), nil
+	return filepath.Join(containerPath, binaries.Name(capabilityConfig.BinaryPath)), nil
 }
 
 // CapabilityJobSpecFactory is a unified factory that uses strategy functions to handle
@@ -0,0 +1,42 @@
+package standardcapability
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability/chainlevel"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability/donlevel"
+)
+
+// DonLevelJobSpecFactory returns a job spec factory of a DON-level capability, which creates its jobs on DONs with the
+// flag and can be registered with capabilities.JobSpecFactories
+func DonLevelJobSpecFactory(flag cre.CapabilityFlag, configTemplate string, runtimeValuesExtractor RuntimeValuesExtractor, commandBuilder CommandBuilder) cre.JobSpecFn {
+	return jobSpecFactory(flag, configTemplate, runtimeValuesExtractor, commandBuilder, donlevel.CapabilityEnabler, donlevel.EnabledChainsProvider, donlevel.ConfigResolver, donlevel.JobNamer)
+}
+
+// ChainLevelJobSpecFactory returns a job spec factory of a chain-specific capability, which creates a job for each
+// chain the capability is enabled for in chain_capabilities of the nodeset
+func ChainLevelJobSpecFactory(flag cre.CapabilityFlag, configTemplate string, runtimeValuesExtractor RuntimeValuesExtractor, commandBuilder CommandBuilder) cre.JobSpecFn {
+	return jobSpecFactory(flag, configTemplate, runtimeValuesExtractor, commandBuilder, chainlevel.CapabilityEnabler, chainlevel.EnabledChainsProvider, chainlevel.ConfigResolver, chainlevel.JobNamer)
+}
+
+// jobSpecFactory creates the CapabilityJobSpecFactory when called, because the registry chain is known only then
+func jobSpecFactory(
+	flag cre.CapabilityFlag,
+	configTemplate string,
+	runtimeValuesExtractor RuntimeValuesExtractor,
+	commandBuilder CommandBuilder,
+	capabilityEnabler CapabilityEnabler,
+	enabledChainsProvider EnabledChainsProvider,
+	configResolver ConfigResolver,
+	jobNamer JobNamer,
+) cre.JobSpecFn {
+	return func(input *cre.JobSpecInput) (cre.DonJobs, error) {
+		factory, fErr := NewCapabilityJobSpecFactory(input.CreEnvironment.RegistryChainSelector, capabilityEnabler, enabledChainsProvider, configResolver, jobNamer)
+		if fErr != nil {
+			return nil, errors.Wrap(fErr, "failed to create capability job spec factory")
+		}
+
+		return factory.BuildJobSpec(flag, configTemplate, runtimeValuesExtractor, commandBuilder)(input)
+	}
+}
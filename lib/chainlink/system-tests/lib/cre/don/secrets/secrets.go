@@ -64,6 +64,8 @@ type NodeKeys struct {
 	P2PKey        *crypto.P2PKey
 	DKGKey        *crypto.DKGRecipientKey
 	OCR2BundleIDs map[ChainFamily]string
+	// OCR2Keys are imported to the node instead of using bundles it generated, keyed by lowercase chain type
+	OCR2Keys map[ChainFamily]*crypto.OCR2Key
 }
 
 func (n NodeKeys) PeerID() string {
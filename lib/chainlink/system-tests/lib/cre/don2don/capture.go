@@ -0,0 +1,150 @@
+package don2don
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	dc "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+const (
+	// DefaultCaptureImage is used for capture sidecars, it has to provide tcpdump
+	DefaultCaptureImage = "nicolaka/netshoot:v0.13"
+	captureDir          = "/capture"
+	captureStopTimeout  = 10 // seconds, tcpdump flushes the capture file on SIGTERM
+)
+
+// DefaultCapturePorts are P2P ports of nodes: OCR peering and capabilities peering, which carries remote capability
+// messages between DONs
+var DefaultCapturePorts = []int{5001, 6690}
+
+type CaptureOptions struct {
+	Image string // DefaultCaptureImage is used if not set
+	Ports []int  // DefaultCapturePorts are used if not set
+}
+
+// NetworkCapture records P2P traffic of node containers into pcap files (one per node), see StartNetworkCapture
+type NetworkCapture struct {
+	Dir        string
+	files      map[string]string // node container name -> capture file
+	containers map[string]string // node container name -> sidecar container ID
+}
+
+// StartNetworkCapture is the Docker hook for capturing traffic between DONs, which doesn't require changing nodes: it
+// starts a tcpdump sidecar in the network namespace of each node container, writing <node container>.pcap to dir.
+// P2P traffic is encrypted, so captures show which peers exchanged messages, when and how big they were, but not their
+// bodies. Record bodies with RecordingDispatcher, when the dispatcher runs in-process, to replay them later.
+func StartNetworkCapture(ctx context.Context, nodeContainers []string, dir string, opts CaptureOptions) (capture *NetworkCapture, err error) {
+	if len(nodeContainers) == 0 {
+		return nil, errors.New("at least one node container is required")
+	}
+	if opts.Image == "" {
+		opts.Image = DefaultCaptureImage
+	}
+	if len(opts.Ports) == 0 {
+		opts.Ports = DefaultCapturePorts
+	}
+
+	absDir, absErr := filepath.Abs(dir)
+	if absErr != nil {
+		return nil, errors.Wrapf(absErr, "failed to get absolute path of %s", dir)
+	}
+	if mkErr := os.MkdirAll(absDir, 0o755); mkErr != nil {
+		return nil, errors.Wrap(mkErr, "failed to create directory for captures")
+	}
+	if pullErr := infra.PullDockerImage(ctx, opts.Image); pullErr != nil {
+		return nil, pullErr
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return nil, errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	capture = &NetworkCapture{Dir: absDir, files: make(map[string]string), containers: make(map[string]string)}
+	defer func() {
+		if err != nil {
+			_ = capture.Stop(context.WithoutCancel(ctx))
+		}
+	}()
+
+	for _, nodeContainer := range nodeContainers {
+		created, createErr := dockerClient.ContainerCreate(ctx,
+			&container.Config{
+				Image:  opts.Image,
+				Cmd:    captureCommand(captureDir+"/"+captureFile(nodeContainer), opts.Ports),
+				Labels: infra.DockerLabels(nil),
+			},
+			&container.HostConfig{
+				NetworkMode: container.NetworkMode("container:" + nodeContainer),
+				CapAdd:      []string{"NET_ADMIN", "NET_RAW"},
+				Binds:       []string{absDir + ":" + captureDir},
+			},
+			nil, nil, nodeContainer+"-capture")
+		if createErr != nil {
+			return nil, errors.Wrapf(createErr, "failed to create capture sidecar of %s", nodeContainer)
+		}
+		capture.containers[nodeContainer] = created.ID
+		capture.files[nodeContainer] = filepath.Join(absDir, captureFile(nodeContainer))
+
+		if startErr := dockerClient.ContainerStart(ctx, created.ID, container.StartOptions{}); startErr != nil {
+			return nil, errors.Wrapf(startErr, "failed to start capture sidecar of %s", nodeContainer)
+		}
+	}
+
+	return capture, nil
+}
+
+func captureFile(nodeContainer string) string {
+	return nodeContainer + ".pcap"
+}
+
+// captureCommand returns the tcpdump command capturing TCP traffic on given ports of all interfaces of the node.
+// Packets are written as they come (-U), so that the file is usable, even if the sidecar is killed.
+func captureCommand(file string, ports []int) []string {
+	filters := make([]string, 0, len(ports))
+	for _, port := range ports {
+		filters = append(filters, fmt.Sprintf("tcp port %d", port))
+	}
+
+	return []string{"tcpdump", "-i", "any", "-U", "-w", file, strings.Join(filters, " or ")}
+}
+
+// Files returns paths of capture files on the host by node container name, they can be opened e.g. with Wireshark
+func (c *NetworkCapture) Files() map[string]string {
+	return maps.Clone(c.files)
+}
+
+// Stop stops and removes capture sidecars, capture files are kept
+func (c *NetworkCapture) Stop(ctx context.Context) error {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	// all sidecars are removed, even if some of them fail, the first error is returned
+	var firstErr error
+	timeout := captureStopTimeout
+	for nodeContainer, containerID := range c.containers {
+		if err := dockerClient.ContainerStop(ctx, containerID, container.StopOptions{Timeout: &timeout}); err != nil && !dc.IsErrNotFound(err) {
+			firstErr = cmp.Or(firstErr, errors.Wrapf(err, "failed to stop capture sidecar of %s", nodeContainer))
+		}
+		if err := dockerClient.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true}); err != nil && !dc.IsErrNotFound(err) {
+			firstErr = cmp.Or(firstErr, errors.Wrapf(err, "failed to remove capture sidecar of %s", nodeContainer))
+		}
+		delete(c.containers, nodeContainer)
+	}
+
+	return firstErr
+}
@@ -0,0 +1,21 @@
+package don2don
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureCommand(t *testing.T) {
+	assert.Equal(t,
+		[]string{"tcpdump", "-i", "any", "-U", "-w", "/capture/workflow-node1.pcap", "tcp port 5001 or tcp port 6690"},
+		captureCommand("/capture/"+captureFile("workflow-node1"), DefaultCapturePorts),
+	)
+}
+
+func TestStartNetworkCaptureRequiresNodes(t *testing.T) {
+	_, err := StartNetworkCapture(context.Background(), nil, t.TempDir(), CaptureOptions{})
+	require.ErrorContains(t, err, "at least one node container is required")
+}
@@ -0,0 +1,194 @@
+package don2don
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/v2/core/capabilities/remote/types"
+	p2ptypes "github.com/smartcontractkit/chainlink/v2/core/services/p2p/types"
+)
+
+// RecordingDispatcher wraps a dispatcher and records all messages sent through it and delivered to its receivers.
+// Use it in place of the regular dispatcher to capture traffic between DONs, which can be replayed later with IsolatedDispatcher.
+type RecordingDispatcher struct {
+	types.Dispatcher
+	recording *Recording
+}
+
+var _ types.Dispatcher = &RecordingDispatcher{}
+
+func NewRecordingDispatcher(dispatcher types.Dispatcher, recording *Recording) *RecordingDispatcher {
+	return &RecordingDispatcher{Dispatcher: dispatcher, recording: recording}
+}
+
+func (d *RecordingDispatcher) SetReceiver(capabilityID string, donID uint32, receiver types.Receiver) error {
+	return d.Dispatcher.SetReceiver(capabilityID, donID, &recordingReceiver{Receiver: receiver, recording: d.recording})
+}
+
+func (d *RecordingDispatcher) SetReceiverForMethod(capabilityID string, donID uint32, method string, receiver types.Receiver) error {
+	return d.Dispatcher.SetReceiverForMethod(capabilityID, donID, method, &recordingReceiver{Receiver: receiver, recording: d.recording})
+}
+
+func (d *RecordingDispatcher) Send(peerID p2ptypes.PeerID, msgBody *types.MessageBody) error {
+	// record after sending, so that fields set by the dispatcher (sender, timestamp, version) are captured
+	if err := d.Dispatcher.Send(peerID, msgBody); err != nil {
+		return err
+	}
+
+	return d.recording.Record(DirectionOutbound, msgBody)
+}
+
+type recordingReceiver struct {
+	types.Receiver
+	recording *Recording
+}
+
+func (r *recordingReceiver) Receive(ctx context.Context, msg *types.MessageBody) {
+	_ = r.recording.Record(DirectionInbound, msg) // recording must never affect message delivery
+	r.Receiver.Receive(ctx, msg)
+}
+
+type receiverKey struct {
+	capabilityID string
+	donID        uint32
+	method       string
+}
+
+// IsolatedDispatcher is a dispatcher that isn't connected to any peers. Components under test register their receivers
+// with it as usual, then recorded inbound messages are replayed to them and whatever they send is recorded instead
+// of being sent, which allows reproducing cross-DON bugs with a single DON.
+type IsolatedDispatcher struct {
+	receivers map[receiverKey]types.Receiver
+	sent      *Recording
+	mu        sync.RWMutex
+}
+
+var _ types.Dispatcher = &IsolatedDispatcher{}
+
+func NewIsolatedDispatcher() *IsolatedDispatcher {
+	return &IsolatedDispatcher{
+		receivers: make(map[receiverKey]types.Receiver),
+		sent:      NewRecording(),
+	}
+}
+
+func (d *IsolatedDispatcher) Start(context.Context) error { return nil }
+func (d *IsolatedDispatcher) Close() error                { return nil }
+func (d *IsolatedDispatcher) Ready() error                { return nil }
+func (d *IsolatedDispatcher) Name() string                { return "IsolatedDispatcher" }
+func (d *IsolatedDispatcher) HealthReport() map[string]error {
+	return map[string]error{d.Name(): nil}
+}
+
+func (d *IsolatedDispatcher) SetReceiver(capabilityID string, donID uint32, receiver types.Receiver) error {
+	return d.SetReceiverForMethod(capabilityID, donID, "", receiver)
+}
+
+func (d *IsolatedDispatcher) RemoveReceiver(capabilityID string, donID uint32) {
+	d.RemoveReceiverForMethod(capabilityID, donID, "")
+}
+
+func (d *IsolatedDispatcher) SetReceiverForMethod(capabilityID string, donID uint32, method string, receiver types.Receiver) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	k := receiverKey{capabilityID, donID, method}
+	if _, ok := d.receivers[k]; ok {
+		return fmt.Errorf("receiver for capability %s, DON %d and method '%s' already exists", capabilityID, donID, method)
+	}
+	d.receivers[k] = receiver
+
+	return nil
+}
+
+func (d *IsolatedDispatcher) RemoveReceiverForMethod(capabilityID string, donID uint32, method string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.receivers, receiverKey{capabilityID, donID, method})
+}
+
+func (d *IsolatedDispatcher) Send(peerID p2ptypes.PeerID, msgBody *types.MessageBody) error {
+	msgBody.Receiver = peerID[:]
+	msgBody.Timestamp = time.Now().UnixMilli()
+
+	return d.sent.Record(DirectionOutbound, msgBody)
+}
+
+// Sent returns messages sent by components under test during replay
+func (d *IsolatedDispatcher) Sent() *Recording {
+	return d.sent
+}
+
+type ReplayOptions struct {
+	// Speed scales delays between messages, 1 keeps original timing, 0 delivers messages without any delays
+	Speed float64
+	// Filter selects inbound messages to replay, nil replays all of them
+	Filter func(m *RecordedMessage) bool
+	// ReceiverTimeout is how long to wait for a receiver to be registered before failing, because components under test
+	// usually register them asynchronously after start
+	ReceiverTimeout time.Duration
+}
+
+// Replay delivers recorded inbound messages to registered receivers in the original order. Waiting for receivers and
+// delivering messages one by one makes the replay deterministic, regardless of timing of the original run.
+func (d *IsolatedDispatcher) Replay(ctx context.Context, recording *Recording, opts ReplayOptions) error {
+	messages := recording.Filter(DirectionInbound, opts.Filter)
+	if len(messages) == 0 {
+		return errors.New("no inbound messages to replay")
+	}
+	if opts.ReceiverTimeout == 0 {
+		opts.ReceiverTimeout = 30 * time.Second
+	}
+
+	for idx, m := range messages {
+		if idx > 0 && opts.Speed > 0 {
+			delay := time.Duration(float64(m.RecordedAt.Sub(messages[idx-1].RecordedAt)) / opts.Speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		body, bErr := m.MessageBody()
+		if bErr != nil {
+			return bErr
+		}
+
+		receiver, rErr := d.waitForReceiver(ctx, m, opts.ReceiverTimeout)
+		if rErr != nil {
+			return rErr
+		}
+		receiver.Receive(ctx, body)
+	}
+
+	return nil
+}
+
+func (d *IsolatedDispatcher) waitForReceiver(ctx context.Context, m *RecordedMessage, timeout time.Duration) (types.Receiver, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		d.mu.RLock()
+		receiver, ok := d.receivers[receiverKey{m.CapabilityID, m.CapabilityDonID, m.CapabilityMethod}]
+		d.mu.RUnlock()
+		if ok {
+			return receiver, nil
+		}
+
+		select {
+		case <-timeoutCtx.Done():
+			return nil, fmt.Errorf("no receiver registered for capability %s, DON %d and method '%s' within %s", m.CapabilityID, m.CapabilityDonID, m.CapabilityMethod, timeout)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package don2don
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink/v2/core/capabilities/remote/types"
+	p2ptypes "github.com/smartcontractkit/chainlink/v2/core/services/p2p/types"
+)
+
+// echoReceiver responds to every received message, like a remote capability server would
+type echoReceiver struct {
+	dispatcher types.Dispatcher
+}
+
+func (r *echoReceiver) Receive(_ context.Context, msg *types.MessageBody) {
+	_ = r.dispatcher.Send(p2ptypes.PeerID{}, &types.MessageBody{
+		CapabilityId:    msg.CapabilityId,
+		CapabilityDonId: msg.CapabilityDonId,
+		MessageId:       msg.MessageId,
+		Payload:         msg.Payload,
+	})
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	// traffic of the original run, as delivered by the remote DON
+	remoteTraffic := NewRecording()
+	require.NoError(t, remoteTraffic.Record(DirectionInbound, &types.MessageBody{CapabilityId: "write@1.0.0", CapabilityDonId: 2, MessageId: []byte("msg-1"), Payload: []byte("payload-1")}))
+	require.NoError(t, remoteTraffic.Record(DirectionInbound, &types.MessageBody{CapabilityId: "write@1.0.0", CapabilityDonId: 2, MessageId: []byte("msg-2"), Payload: []byte("payload-2")}))
+
+	// record it with the recording dispatcher
+	original := NewRecording()
+	inner := NewIsolatedDispatcher()
+	recordingDispatcher := NewRecordingDispatcher(inner, original)
+	require.NoError(t, recordingDispatcher.SetReceiver("write@1.0.0", 2, &echoReceiver{dispatcher: recordingDispatcher}))
+	require.NoError(t, inner.Replay(context.Background(), remoteTraffic, ReplayOptions{}))
+	require.Len(t, original.Filter(DirectionInbound, nil), 2)
+	require.Len(t, original.Filter(DirectionOutbound, nil), 2)
+
+	require.NoError(t, original.Record(DirectionInbound, &types.MessageBody{CapabilityId: "ignored@1.0.0", CapabilityDonId: 3, MessageId: []byte("filtered-out")}))
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	require.NoError(t, original.Store(path))
+	loaded, err := LoadRecording(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Messages, 5)
+
+	// replay them against the DON in isolation
+	isolated := NewIsolatedDispatcher()
+	go func() {
+		time.Sleep(200 * time.Millisecond) // receivers are usually registered after replay starts
+		_ = isolated.SetReceiver("write@1.0.0", 2, &echoReceiver{dispatcher: isolated})
+	}()
+
+	require.NoError(t, isolated.Replay(context.Background(), loaded, ReplayOptions{
+		Filter:          func(m *RecordedMessage) bool { return m.CapabilityID == "write@1.0.0" },
+		ReceiverTimeout: 5 * time.Second,
+	}))
+
+	sent := isolated.Sent().Filter(DirectionOutbound, nil)
+	require.Len(t, sent, 2)
+	require.Equal(t, "msg-1", sent[0].MessageID)
+	require.Equal(t, "msg-2", sent[1].MessageID)
+
+	body, err := sent[1].MessageBody()
+	require.NoError(t, err)
+	require.Equal(t, []byte("payload-2"), body.Payload)
+}
+
+func TestReplayFailsWithoutReceiver(t *testing.T) {
+	recording := NewRecording()
+	require.NoError(t, recording.Record(DirectionInbound, &types.MessageBody{CapabilityId: "write@1.0.0", CapabilityDonId: 2}))
+
+	err := NewIsolatedDispatcher().Replay(context.Background(), recording, ReplayOptions{ReceiverTimeout: 200 * time.Millisecond})
+	require.ErrorContains(t, err, "no receiver registered for capability write@1.0.0")
+}
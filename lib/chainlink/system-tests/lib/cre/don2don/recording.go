@@ -0,0 +1,117 @@
+package don2don
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/smartcontractkit/chainlink/v2/core/capabilities/remote/types"
+)
+
+type Direction = string
+
+const (
+	DirectionInbound  Direction = "inbound"  // received from a remote DON
+	DirectionOutbound Direction = "outbound" // sent to a remote DON
+)
+
+// RecordedMessage is a remote capability message with metadata, which allows filtering without decoding the body
+type RecordedMessage struct {
+	RecordedAt       time.Time `json:"recorded_at"`
+	Direction        Direction `json:"direction"`
+	CapabilityID     string    `json:"capability_id"`
+	CapabilityDonID  uint32    `json:"capability_don_id"`
+	CallerDonID      uint32    `json:"caller_don_id"`
+	CapabilityMethod string    `json:"capability_method"`
+	MessageID        string    `json:"message_id"`
+	Body             []byte    `json:"body"` // protobuf-encoded types.MessageBody
+}
+
+func (m *RecordedMessage) MessageBody() (*types.MessageBody, error) {
+	body := &types.MessageBody{}
+	if err := proto.Unmarshal(m.Body, body); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal message %s", m.MessageID)
+	}
+
+	return body, nil
+}
+
+// Recording is an ordered list of messages exchanged between DONs
+type Recording struct {
+	Messages []*RecordedMessage `json:"messages"`
+
+	mu sync.Mutex
+}
+
+func NewRecording() *Recording {
+	return &Recording{}
+}
+
+func (r *Recording) Record(direction Direction, body *types.MessageBody) error {
+	raw, err := proto.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal message body")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Messages = append(r.Messages, &RecordedMessage{
+		RecordedAt:       time.Now(),
+		Direction:        direction,
+		CapabilityID:     body.CapabilityId,
+		CapabilityDonID:  body.CapabilityDonId,
+		CallerDonID:      body.CallerDonId,
+		CapabilityMethod: body.CapabilityMethod,
+		MessageID:        string(body.MessageId),
+		Body:             raw,
+	})
+
+	return nil
+}
+
+// Filter returns messages in given direction matching the predicate (nil matches all)
+func (r *Recording) Filter(direction Direction, predicate func(m *RecordedMessage) bool) []*RecordedMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.DeleteFunc(slices.Clone(r.Messages), func(m *RecordedMessage) bool {
+		return m.Direction != direction || (predicate != nil && !predicate(m))
+	})
+}
+
+func (r *Recording) Store(absPath string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create directory for the recording")
+	}
+
+	d, mErr := json.MarshalIndent(r, "", "  ")
+	if mErr != nil {
+		return errors.Wrap(mErr, "failed to marshal recording")
+	}
+
+	return os.WriteFile(absPath, d, 0o600)
+}
+
+func LoadRecording(absPath string) (*Recording, error) {
+	d, rErr := os.ReadFile(absPath)
+	if rErr != nil {
+		return nil, errors.Wrapf(rErr, "failed to read recording from %s", absPath)
+	}
+
+	recording := NewRecording()
+	if uErr := json.Unmarshal(d, recording); uErr != nil {
+		return nil, errors.Wrapf(uErr, "failed to unmarshal recording from %s", absPath)
+	}
+
+	return recording, nil
+}
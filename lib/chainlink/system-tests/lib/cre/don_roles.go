@@ -0,0 +1,110 @@
+package cre
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// DONRole is the role of a DON in a CRE network, using the vocabulary of production deployments: workflow DONs run
+// workflows, capabilities DONs host capabilities called by them and gateway DONs expose HTTP gateways. A DON can have
+// many roles. Roles are set with DON types (don_types) of nodesets. They aren't DON families of the workflow registry.
+type DONRole string
+
+const (
+	DONRoleWorkflow     DONRole = DONRole(WorkflowDON)
+	DONRoleCapabilities DONRole = DONRole(CapabilitiesDON)
+	DONRoleGateway      DONRole = DONRole(GatewayDON)
+)
+
+// DONRoles lists all roles in their canonical order, which is used in reports
+var DONRoles = []DONRole{DONRoleWorkflow, DONRoleCapabilities, DONRoleGateway}
+
+// RolesFromFlags returns roles of a DON with given flags (or DON types) in the canonical order
+func RolesFromFlags(flags []CapabilityFlag) []DONRole {
+	roles := make([]DONRole, 0)
+	for _, role := range DONRoles {
+		if slices.Contains(flags, CapabilityFlag(role)) {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+// ValidateDONTypes checks that all DON types of the nodeset are known roles, so that typos don't silently produce
+// a DON without a role
+func (c *CapabilitiesAwareNodeSet) ValidateDONTypes() error {
+	for _, donType := range c.DONTypes {
+		if !slices.Contains(DONRoles, DONRole(donType)) {
+			return fmt.Errorf("unknown DON type '%s' of nodeset %s. Valid ones are: %v", donType, c.Name, DONRoles)
+		}
+	}
+
+	return nil
+}
+
+func (c *CapabilitiesAwareNodeSet) DONRoles() []DONRole {
+	return RolesFromFlags(c.DONTypes)
+}
+
+func (m *DonMetadata) DONRoles() []DONRole {
+	return RolesFromFlags(m.Flags)
+}
+
+func (d *Don) DONRoles() []DONRole {
+	return RolesFromFlags(d.Flags)
+}
+
+func (d *Don) HasDONRole(role DONRole) bool {
+	return slices.Contains(d.DONRoles(), role)
+}
+
+// DonsWithRole returns DONs with the role in the order of the topology
+func (d *Dons) DonsWithRole(role DONRole) []*Don {
+	found := make([]*Don, 0)
+	for _, don := range d.List() {
+		if don.HasDONRole(role) {
+			found = append(found, don)
+		}
+	}
+
+	return found
+}
+
+// DonsWithRole returns metadata of DONs with the role in the order of the topology
+func (m DonsMetadata) DonsWithRole(role DONRole) []*DonMetadata {
+	found := make([]*DonMetadata, 0)
+	for _, don := range m.List() {
+		if slices.Contains(don.DONRoles(), role) {
+			found = append(found, don)
+		}
+	}
+
+	return found
+}
+
+// DefaultEnvironmentName is used if no name is set. Names identify environments in artifacts and reports, similarly
+// to names of production networks, e.g. "nightly-multichain".
+const DefaultEnvironmentName = "local-cre"
+
+var environmentNameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidateEnvironmentName checks that the name can be used in resource names and labels, an empty name is valid and
+// means DefaultEnvironmentName
+func ValidateEnvironmentName(name string) error {
+	if name != "" && !environmentNameRegexp.MatchString(name) {
+		return fmt.Errorf("environment name '%s' must consist of at most 63 lowercase alphanumeric characters or '-', and start and end with an alphanumeric character", name)
+	}
+
+	return nil
+}
+
+// EnvironmentNameOrDefault returns the name or DefaultEnvironmentName, if it's empty
+func EnvironmentNameOrDefault(name string) string {
+	if name == "" {
+		return DefaultEnvironmentName
+	}
+
+	return name
+}
@@ -0,0 +1,370 @@
+// Package drift detects changes of node configs during long runs in shared environments. Effective configs are
+// re-fetched from nodes periodically and compared with configs generated for them and with the configs nodes had when
+// the detector started, so that manual edits and env var overrides show up in the run report instead of silently
+// changing what the run measures.
+package drift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+)
+
+const DefaultInterval = 10 * time.Minute
+
+type Kind string
+
+const (
+	// KindIntended is a difference between the generated config and the config the node runs with
+	KindIntended Kind = "differs from generated config"
+	// KindChanged is a change of the node's config since the detector started
+	KindChanged Kind = "changed since start"
+)
+
+// Target is a node and configs generated for it, i.e. TOML overrides passed to the node on top of the base config,
+// later ones take precedence
+type Target struct {
+	Node     *cre.Node
+	Intended []string
+}
+
+// TargetsFromNodeSets returns targets for all nodes of all DONs with configs generated for them in their nodesets
+func TargetsFromNodeSets(dons *cre.Dons, nodeSets []*cre.CapabilitiesAwareNodeSet) []Target {
+	var targets []Target
+	for _, don := range dons.List() {
+		idx := slices.IndexFunc(nodeSets, func(nodeSet *cre.CapabilitiesAwareNodeSet) bool { return nodeSet.Name == don.Name })
+		if idx == -1 {
+			continue
+		}
+		for _, node := range don.Nodes {
+			target := Target{Node: node}
+			if node.Index < len(nodeSets[idx].NodeSpecs) {
+				spec := nodeSets[idx].NodeSpecs[node.Index].Node
+				target.Intended = []string{spec.TestConfigOverrides, spec.UserConfigOverrides}
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+type Drift struct {
+	Node       string
+	Path       string // dotted path of the config option, e.g. Feature.LogPoller
+	Kind       Kind
+	Expected   any // nil, if the option wasn't set
+	Actual     any // nil, if the option isn't set anymore
+	DetectedAt time.Time
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s: %s %s (expected %s, got %s, detected at %s)", d.Node, d.Path, d.Kind, formatValue(d.Expected), formatValue(d.Actual), d.DetectedAt.Format(time.RFC3339))
+}
+
+func formatValue(value any) string {
+	if value == nil {
+		return "unset"
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+type Config struct {
+	Targets  []Target
+	Interval time.Duration // DefaultInterval is used if not set
+	// IgnoredPaths are prefixes of dotted paths, which are expected to change, e.g. options changed by the test on purpose
+	IgnoredPaths []string
+}
+
+// Detector checks configs of nodes in the background. Each drift is reported once, when it's detected for the first time.
+type Detector struct {
+	config Config
+	logger zerolog.Logger
+
+	baselines map[string]map[string]any // node name -> flattened config at start
+	drifts    map[string]Drift          // node name + kind + path -> first detection
+
+	mu       sync.Mutex
+	stopOnce sync.Once
+	stop     context.CancelFunc
+	done     chan struct{}
+}
+
+func New(logger zerolog.Logger, config Config) *Detector {
+	if config.Interval == 0 {
+		config.Interval = DefaultInterval
+	}
+
+	return &Detector{
+		config:    config,
+		logger:    logger,
+		baselines: make(map[string]map[string]any),
+		drifts:    make(map[string]Drift),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start records current configs of nodes as the baseline and checks them every interval until Stop is called
+func (d *Detector) Start(ctx context.Context) error {
+	if err := d.Check(ctx); err != nil {
+		return pkgerrors.Wrap(err, "failed to read baseline configs of nodes")
+	}
+
+	loopCtx, stop := context.WithCancel(ctx)
+	d.stop = stop
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-loopCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// nodes might be restarted by the test, they are checked again in the next round
+			if err := d.Check(loopCtx); err != nil && loopCtx.Err() == nil {
+				d.logger.Warn().Err(err).Msg("Failed to check node configs for drift")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the checks and returns all drifts detected during the run
+func (d *Detector) Stop() []Drift {
+	d.stopOnce.Do(func() {
+		if d.stop != nil {
+			d.stop()
+			<-d.done
+		}
+	})
+
+	return d.Drifts()
+}
+
+// Check fetches configs of all nodes and records new drifts. The first successful fetch of a node becomes its baseline.
+func (d *Detector) Check(ctx context.Context) error {
+	var errs []error
+	for _, target := range d.config.Targets {
+		effective, fetchErr := fetchUserConfig(ctx, target.Node)
+		if fetchErr != nil {
+			errs = append(errs, fetchErr)
+			continue
+		}
+
+		intended := make(map[string]any)
+		var intendedErr error
+		for _, document := range target.Intended {
+			flattened, flattenErr := flattenTOML(document)
+			if flattenErr != nil {
+				intendedErr = pkgerrors.Wrapf(flattenErr, "failed to parse generated config of node %s", target.Node.Name)
+				break
+			}
+			maps.Copy(intended, flattened)
+		}
+		if intendedErr != nil {
+			errs = append(errs, intendedErr)
+			continue
+		}
+
+		d.mu.Lock()
+		baseline, ok := d.baselines[target.Node.Name]
+		if !ok {
+			d.baselines[target.Node.Name] = effective
+		}
+		d.record(target.Node.Name, KindIntended, intended, effective, false)
+		if ok {
+			d.record(target.Node.Name, KindChanged, baseline, effective, true)
+		}
+		d.mu.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+// record compares expected options with actual ones. If bidirectional is true, options added to actual are drifts as well.
+func (d *Detector) record(node string, kind Kind, expected, actual map[string]any, bidirectional bool) {
+	paths := make([]string, 0, len(expected))
+	for path := range expected {
+		paths = append(paths, path)
+	}
+	if bidirectional {
+		for path := range actual {
+			if _, ok := expected[path]; !ok {
+				paths = append(paths, path)
+			}
+		}
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		if d.ignored(path) {
+			continue
+		}
+		expectedValue, actualValue := expected[path], actual[path]
+		if valuesEqual(expectedValue, actualValue) {
+			continue
+		}
+		key := node + "/" + string(kind) + "/" + path
+		if _, reported := d.drifts[key]; reported {
+			continue
+		}
+		drift := Drift{Node: node, Path: path, Kind: kind, Expected: expectedValue, Actual: actualValue, DetectedAt: time.Now()}
+		d.drifts[key] = drift
+		d.logger.Warn().Msgf("Config drift: %s", drift)
+	}
+}
+
+func (d *Detector) ignored(path string) bool {
+	for _, prefix := range d.config.IgnoredPaths {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Drifts returns drifts detected so far, sorted by node and path
+func (d *Detector) Drifts() []Drift {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	drifts := make([]Drift, 0, len(d.drifts))
+	for _, drift := range d.drifts {
+		drifts = append(drifts, drift)
+	}
+	slices.SortFunc(drifts, func(a, b Drift) int {
+		if a.Node != b.Node {
+			return strings.Compare(a.Node, b.Node)
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return drifts
+}
+
+// Report returns a section for the run report, which is empty if no drift was detected
+func Report(drifts []Drift) string {
+	if len(drifts) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Node config drift (%d):\n", len(drifts))
+	for _, drift := range drifts {
+		fmt.Fprintf(&sb, "- %s\n", drift)
+	}
+
+	return sb.String()
+}
+
+// WriteReport writes the report to path, e.g. next to other artifacts of the run. Nothing is written without drifts.
+func WriteReport(path string, drifts []Drift) error {
+	report := Report(drifts)
+	if report == "" {
+		return nil
+	}
+
+	return pkgerrors.Wrap(os.WriteFile(path, []byte(report), 0o644), "failed to write config drift report")
+}
+
+// fetchUserConfig returns the flattened config the node was started with: all config files and env var overrides,
+// without defaults of options that weren't set
+func fetchUserConfig(ctx context.Context, node *cre.Node) (map[string]any, error) {
+	var response struct {
+		Data struct {
+			Attributes struct {
+				Config string `json:"config"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	resp, err := node.Clients.RestClient.APIClient.R().SetContext(ctx).SetResult(&response).Get("/v2/config/v2?userOnly=true")
+	if err != nil {
+		return nil, pkgerrors.Wrapf(err, "failed to fetch config of node %s", node.Name)
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("fetching config of node %s failed with status %d", node.Name, resp.StatusCode())
+	}
+
+	flattened, flattenErr := flattenTOML(response.Data.Attributes.Config)
+	if flattenErr != nil {
+		return nil, pkgerrors.Wrapf(flattenErr, "failed to parse config of node %s", node.Name)
+	}
+
+	return flattened, nil
+}
+
+// flattenTOML returns options of the TOML document keyed by dotted paths, elements of arrays of tables are keyed by index
+func flattenTOML(document string) (map[string]any, error) {
+	parsed := make(map[string]any)
+	if err := toml.Unmarshal([]byte(document), &parsed); err != nil {
+		return nil, err
+	}
+
+	flattened := make(map[string]any)
+	flatten("", parsed, flattened)
+
+	return flattened, nil
+}
+
+func flatten(prefix string, value any, into map[string]any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			flatten(joinPath(prefix, key), nested, into)
+		}
+	case []any:
+		if !slices.ContainsFunc(v, func(element any) bool { _, isTable := element.(map[string]any); return isTable }) {
+			into[prefix] = v
+			return
+		}
+		for i, element := range v {
+			flatten(joinPath(prefix, strconv.Itoa(i)), element, into)
+		}
+	default:
+		into[prefix] = v
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+// valuesEqual compares config values, durations are compared by value, because nodes normalize them (e.g. 1m -> 1m0s)
+func valuesEqual(a, b any) bool {
+	if aString, ok := a.(string); ok {
+		if bString, ok := b.(string); ok {
+			aDuration, aErr := time.ParseDuration(aString)
+			bDuration, bErr := time.ParseDuration(bString)
+			if aErr == nil && bErr == nil {
+				return aDuration == bDuration
+			}
+		}
+	}
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,50 @@
+package environment
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	envconfig "github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/config"
+)
+
+// AttachReadOnly attaches to a running long-lived environment (e.g. the shared staging DON) strictly read-only, so that
+// diagnostic queries can use the same Go API as tests. Unlike BuildFromSavedState it doesn't start blockchains and
+// doesn't link nodes to the Job Distributor. Clients of all nodes reject writes, the infra provider rejects killing,
+// stopping and starting nodes and only read-only commands can be executed in nodes, see cre.Node.SetReadOnly.
+// envArtifact is optional, it's only used to read gateway connectors.
+func AttachReadOnly(ctx context.Context, cachedInput *envconfig.Config, envArtifact *EnvArtifact) (*cre.Dons, error) {
+	if cachedInput == nil || cachedInput.Infra == nil {
+		return nil, errors.New("cached input with infra config is required")
+	}
+
+	provider := *cachedInput.Infra
+	provider.ReadOnly = true
+
+	topology, topologyErr := cre.NewTopology(cachedInput.NodeSets, provider)
+	if topologyErr != nil {
+		return nil, errors.Wrap(topologyErr, "failed to recreate topology from cached input")
+	}
+
+	donsSlice := make([]*cre.Don, 0, len(cachedInput.NodeSets))
+	for idx, nodeSet := range cachedInput.NodeSets {
+		if nodeSet.Out == nil {
+			return nil, errors.Errorf("nodeset %s has no output, is the environment running?", nodeSet.Name)
+		}
+
+		don, donErr := cre.NewDON(ctx, topology.DonsMetadata.List()[idx], nodeSet.Out.CLNodes)
+		if donErr != nil {
+			return nil, errors.Wrapf(donErr, "failed to attach to DON %s", nodeSet.Name)
+		}
+		don.SetReadOnly()
+		donsSlice = append(donsSlice, don)
+	}
+
+	var gatewayConnectors *cre.GatewayConnectors
+	if envArtifact != nil {
+		gatewayConnectors = envArtifact.GatewayConnectors
+	}
+
+	return cre.NewDons(donsSlice, gatewayConnectors), nil
+}
@@ -0,0 +1,261 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/pelletier/go-toml/v2"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	chipingressset "github.com/smartcontractkit/chainlink-testing-framework/framework/components/dockercompose/chip_ingress_set"
+	"github.com/smartcontractkit/chainlink-testing-framework/lib/utils/ptr"
+
+	corechainlink "github.com/smartcontractkit/chainlink/v2/core/services/chainlink"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/config"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// DefaultOTelCollectorPort is the gRPC port of the OTel collector of the CTF observability stack, published on the host
+const DefaultOTelCollectorPort = "4317"
+
+var DefaultBeholderTopics = []string{"cre"}
+
+// BeholderInput provisions the Beholder pipeline used in production: nodes send custom messages (workflow user logs,
+// capability events) to Chip Ingress, which publishes them to Kafka (Redpanda), and metrics, traces and logs to an OTel collector.
+// Messages can be asserted on by consuming the Kafka topics, see the Chip Ingress state file.
+type BeholderInput struct {
+	ChipIngress *chipingressset.Input `toml:"chip_ingress"`
+	Topics      []string              `toml:"topics"` // Kafka topics to create, DefaultBeholderTopics are used if not set
+	// OTelEndpoint is host:port of the OTel collector reachable from nodes, defaults to the collector
+	// of the CTF observability stack running on the host
+	OTelEndpoint string `toml:"otel_endpoint"`
+	// LogStreaming streams node logs (including workflow user logs) through Beholder
+	LogStreaming bool `toml:"log_streaming"`
+}
+
+func (b *BeholderInput) Validate() error {
+	if b.ChipIngress == nil {
+		return pkgerrors.New("chip ingress input is required to provision Beholder")
+	}
+	if b.OTelEndpoint != "" {
+		if _, _, err := net.SplitHostPort(b.OTelEndpoint); err != nil {
+			return pkgerrors.Wrap(err, "OTel endpoint must be in host:port format")
+		}
+	}
+
+	return nil
+}
+
+// startBeholder starts Chip Ingress with Kafka, unless it's already running (started by a previous run or by the CLI),
+// creates topics and stores the Chip Ingress state file, which is used by tests to consume messages.
+// Returns the node config transformer, which points nodes to the pipeline, and a cleanup, which removes the stack and
+// its state file, if it was started by this call (a reused stack is left running). If it fails, it cleans up by itself.
+func startBeholder(ctx context.Context, testLogger zerolog.Logger, input *BeholderInput, relativePathToRepoRoot string) (output *chipingressset.Output, transformer cre.NodeConfigTransformerFn, cleanup func(context.Context) error, err error) {
+	topics := input.Topics
+	if len(topics) == 0 {
+		topics = DefaultBeholderTopics
+	}
+
+	stateFile := config.MustChipIngressStateFileAbsPath(relativePathToRepoRoot)
+	if config.ChipIngressStateFileExists(relativePathToRepoRoot) {
+		existing := &config.ChipIngressConfig{}
+		if loadErr := existing.Load(stateFile); loadErr != nil {
+			return nil, nil, nil, pkgerrors.Wrap(loadErr, "failed to load Chip Ingress state file")
+		}
+		if existing.ChipIngress != nil && existing.ChipIngress.Output != nil {
+			testLogger.Info().Msg("Reusing running Beholder stack")
+			input.ChipIngress = existing.ChipIngress
+			input.ChipIngress.UseCache = true
+		}
+	}
+
+	cleanup = func(context.Context) error { return nil }
+	if !input.ChipIngress.UseCache || input.ChipIngress.Output == nil {
+		// CTF gives the stack an identifier with a random suffix, it's found by comparing projects before and after the start
+		projectPrefix := chipingressset.DEFAULT_STACK_NAME + "-"
+		before, projectsErr := infra.DockerComposeProjects(ctx, projectPrefix)
+		if projectsErr != nil {
+			return nil, nil, nil, pkgerrors.Wrap(projectsErr, "failed to list docker compose projects")
+		}
+		cleanup = func(ctx context.Context) error {
+			after, afterErr := infra.DockerComposeProjects(ctx, projectPrefix)
+			if afterErr != nil {
+				return pkgerrors.Wrap(afterErr, "failed to list docker compose projects")
+			}
+			for _, project := range after {
+				if slices.Contains(before, project) {
+					continue
+				}
+				if removeErr := infra.RemoveDockerComposeProject(ctx, project); removeErr != nil {
+					return pkgerrors.Wrap(removeErr, "failed to remove Chip Ingress stack")
+				}
+			}
+			if removeErr := os.Remove(stateFile); removeErr != nil && !os.IsNotExist(removeErr) {
+				return pkgerrors.Wrap(removeErr, "failed to remove Chip Ingress state file")
+			}
+
+			return nil
+		}
+		defer func() {
+			if err == nil {
+				return
+			}
+			if cleanupErr := cleanup(context.WithoutCancel(ctx)); cleanupErr != nil {
+				testLogger.Warn().Err(cleanupErr).Msg("Failed to remove Beholder stack, which failed to start")
+			}
+		}()
+	}
+
+	output, startErr := chipingressset.New(input.ChipIngress)
+	if startErr != nil {
+		return nil, nil, nil, pkgerrors.Wrap(startErr, "failed to start Chip Ingress")
+	}
+	// stored in the state file, so that the next run or the CLI reuse the stack
+	input.ChipIngress.Output = output
+	input.ChipIngress.UseCache = true
+
+	if topicsErr := chipingressset.CreateTopics(ctx, output.RedPanda.KafkaExternalURL, topics); topicsErr != nil {
+		return nil, nil, nil, pkgerrors.Wrap(topicsErr, "failed to create Beholder Kafka topics")
+	}
+
+	state := &config.ChipIngressConfig{ChipIngress: input.ChipIngress, Kafka: &config.KafkaConfig{Topics: topics}}
+	if storeErr := state.Store(stateFile); storeErr != nil {
+		return nil, nil, nil, pkgerrors.Wrap(storeErr, "failed to store Chip Ingress state file")
+	}
+
+	otelEndpoint := input.OTelEndpoint
+	if otelEndpoint == "" {
+		host, hostErr := infra.DockerHostAddress(ctx)
+		if hostErr != nil {
+			return nil, nil, nil, pkgerrors.Wrap(hostErr, "failed to resolve address of the OTel collector")
+		}
+		otelEndpoint = net.JoinHostPort(host, DefaultOTelCollectorPort)
+	}
+	chipIngressEndpoint := strings.TrimPrefix(output.ChipIngress.GRPCInternalURL, "http://")
+
+	return output, beholderNodeConfigTransformer(otelEndpoint, chipIngressEndpoint, input.LogStreaming), cleanup, nil
+}
+
+func beholderNodeConfigTransformer(otelEndpoint, chipIngressEndpoint string, logStreaming bool) cre.NodeConfigTransformerFn {
+	return func(input cre.GenerateConfigsInput, existingConfigs cre.NodeIndexToConfigOverride) (cre.NodeIndexToConfigOverride, error) {
+		// slim nodes have telemetry disabled on purpose
+		if input.DonMetadata.CapabilitiesAwareNodeSet().NodeProfile == cre.NodeProfileSlim {
+			return existingConfigs, nil
+		}
+
+		configs := make(cre.NodeIndexToConfigOverride, len(existingConfigs))
+		for nodeIdx, existingConfig := range existingConfigs {
+			var nodeConfig corechainlink.Config
+			if err := toml.Unmarshal([]byte(existingConfig), &nodeConfig); err != nil {
+				return nil, pkgerrors.Wrapf(err, "failed to unmarshal config of node %d in DON %s", nodeIdx, input.DonMetadata.Name)
+			}
+
+			nodeConfig.Telemetry.Enabled = ptr.Ptr(true)
+			nodeConfig.Telemetry.Endpoint = ptr.Ptr(otelEndpoint)
+			nodeConfig.Telemetry.InsecureConnection = ptr.Ptr(true)
+			nodeConfig.Telemetry.ChipIngressEndpoint = ptr.Ptr(chipIngressEndpoint)
+			nodeConfig.Telemetry.ChipIngressInsecureConnection = ptr.Ptr(true)
+			nodeConfig.Telemetry.TraceSampleRatio = ptr.Ptr(1.0)
+			nodeConfig.Telemetry.LogStreamingEnabled = ptr.Ptr(logStreaming)
+
+			marshalled, mErr := toml.Marshal(nodeConfig)
+			if mErr != nil {
+				return nil, pkgerrors.Wrapf(mErr, "failed to marshal config of node %d in DON %s", nodeIdx, input.DonMetadata.Name)
+			}
+			configs[nodeIdx] = string(marshalled)
+		}
+
+		return configs, nil
+	}
+}
+
+func beholderSummary(output *chipingressset.Output) string {
+	return fmt.Sprintf("Beholder pipeline ready: Chip Ingress at %s, Kafka at %s, Redpanda console at %s",
+		output.ChipIngress.GRPCExternalURL, output.RedPanda.KafkaExternalURL, output.RedPanda.ConsoleExternalURL)
+}
+
+const (
+	// beholderCloudEventsOffset is the number of bytes of CloudEvents metadata, which precede the protobuf payload of
+	// messages published by Chip Ingress in binary content mode
+	beholderCloudEventsOffset = 6
+	beholderTypeHeader        = "ce_type"
+	beholderReadInterval      = 500 * time.Millisecond
+)
+
+// BeholderMessage is a custom message (e.g. a workflow user log or a capability event) consumed from a Beholder
+// Kafka topic
+type BeholderMessage struct {
+	Type    string // CloudEvents type of the message, e.g. workflows.v1.UserLogs or BaseMessage
+	Payload []byte // protobuf payload, unmarshal it with the proto type of the message
+}
+
+// WaitForBeholderMessage consumes the topic from the beginning until match returns true for a message and returns it.
+// If topic is empty, the first of DefaultBeholderTopics is used. Messages are read by a new consumer group, so waits
+// don't affect each other. It fails, if ctx is done before a message matches.
+func WaitForBeholderMessage(ctx context.Context, output *chipingressset.Output, topic string, match func(*BeholderMessage) bool) (*BeholderMessage, error) {
+	if output == nil || output.RedPanda == nil {
+		return nil, pkgerrors.New("Beholder output is required, was Beholder provisioned?")
+	}
+	if topic == "" {
+		topic = DefaultBeholderTopics[0]
+	}
+
+	consumer, consumerErr := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers":  output.RedPanda.KafkaExternalURL,
+		"group.id":           fmt.Sprintf("cre-assert-%d", time.Now().UnixNano()),
+		"auto.offset.reset":  "earliest",
+		"enable.auto.commit": false,
+	})
+	if consumerErr != nil {
+		return nil, pkgerrors.Wrap(consumerErr, "failed to create Kafka consumer")
+	}
+	defer consumer.Close()
+
+	if err := consumer.SubscribeTopics([]string{topic}, nil); err != nil {
+		return nil, pkgerrors.Wrapf(err, "failed to subscribe to Kafka topic %s", topic)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, pkgerrors.Wrapf(ctx.Err(), "no matching Beholder message in Kafka topic %s", topic)
+		default:
+		}
+
+		msg, readErr := consumer.ReadMessage(beholderReadInterval)
+		if readErr != nil {
+			var kafkaErr kafka.Error
+			if pkgerrors.As(readErr, &kafkaErr) && kafkaErr.Code() == kafka.ErrTimedOut {
+				continue
+			}
+			return nil, pkgerrors.Wrapf(readErr, "failed to read from Kafka topic %s", topic)
+		}
+
+		if message, ok := toBeholderMessage(msg); ok && match(message) {
+			return message, nil
+		}
+	}
+}
+
+// toBeholderMessage strips CloudEvents metadata of a Kafka message, messages without a type or payload are skipped
+func toBeholderMessage(msg *kafka.Message) (*BeholderMessage, bool) {
+	if len(msg.Value) <= beholderCloudEventsOffset {
+		return nil, false
+	}
+	for _, header := range msg.Headers {
+		if header.Key == beholderTypeHeader {
+			return &BeholderMessage{Type: string(header.Value), Payload: msg.Value[beholderCloudEventsOffset:]}, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,28 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToBeholderMessage(t *testing.T) {
+	metadata := []byte{0, 0, 0, 0, 0, 1}
+	msg := &kafka.Message{
+		Value:   append(metadata, []byte("payload")...),
+		Headers: []kafka.Header{{Key: "ce_source", Value: []byte("workflow")}, {Key: "ce_type", Value: []byte("workflows.v1.UserLogs")}},
+	}
+
+	message, ok := toBeholderMessage(msg)
+	require.True(t, ok)
+	assert.Equal(t, "workflows.v1.UserLogs", message.Type)
+	assert.Equal(t, []byte("payload"), message.Payload, "CloudEvents metadata wasn't stripped")
+
+	_, ok = toBeholderMessage(&kafka.Message{Value: msg.Value})
+	assert.False(t, ok, "message without a type wasn't skipped")
+
+	_, ok = toBeholderMessage(&kafka.Message{Value: metadata, Headers: msg.Headers})
+	assert.False(t, ok, "message without a payload wasn't skipped")
+}
@@ -54,6 +54,7 @@ type Blockchain struct {
 	chainID       uint64
 	ctfOutput     *blockchain.Output
 	SethClient    *seth.Client
+	txManager     *TxManager
 }
 
 func (e *Blockchain) ChainSelector() uint64 {
@@ -67,6 +68,11 @@ func (e *Blockchain) CtfOutput() *blockchain.Output {
 	return e.ctfOutput
 }
 
+// TxManager allocates nonces of the root key, use it for all transactions sent by the framework to avoid nonce races
+func (e *Blockchain) TxManager() *TxManager {
+	return e.txManager
+}
+
 func (e *Blockchain) IsFamily(chainFamily string) bool {
 	return strings.EqualFold(e.ctfOutput.Family, chainFamily)
 }
@@ -82,9 +88,13 @@ func (e *Blockchain) Fund(ctx context.Context, address string, amount uint64) er
 		ToAddress:  common.HexToAddress(address),
 		Amount:     big.NewInt(libc.MustSafeInt64(amount)),
 		PrivateKey: e.SethClient.MustGetRootPrivateKey(),
+		TXOpts: func(o ...seth.TransactOpt) (*bind.TransactOpts, error) {
+			return e.txManager.SethTXOpts(ctx, e.SethClient, o...)
+		},
 	})
 
 	if fundingErr != nil {
+		e.txManager.Resync() // funding might have failed after the transaction was sent
 		return pkgerrors.Wrapf(fundingErr, "failed to fund node %s", address)
 	}
 	e.testLogger.Info().Msgf("Successfully funded EVM account %s", address)
@@ -133,7 +143,7 @@ func (e *Blockchain) ToCldfChain() (cldf_chain.BlockChain, error) {
 		}
 		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
 		defer cancel()
-		receipt, rErr := bind.WaitMined(ctx, ec, tx)
+		receipt, rErr := e.txManager.WaitMined(ctx, tx)
 		if rErr != nil {
 			return blockNumber, fmt.Errorf("failed to get confirmed receipt for chain %s: %w", chainInfo.ChainName, rErr)
 		}
@@ -153,8 +163,8 @@ func (e *Blockchain) ToCldfChain() (cldf_chain.BlockChain, error) {
 
 	return cldf_evm.Chain{
 		Selector:    chainDetails.ChainSelector,
-		Client:      ec,
-		DeployerKey: e.SethClient.NewTXOpts(seth.WithNonce(nil)), // ensure nonce fetched from chain at use time
+		Client:      e.txManager.WrapClient(ec),
+		DeployerKey: e.txManager.TransactOpts(), // nonces are allocated by the tx manager shared with other helpers
 		Confirm:     confirmFn,
 	}, nil
 }
@@ -216,6 +226,7 @@ func (e *Deployer) Deploy(input *blockchain.Input) (blockchains.Blockchain, erro
 		chainID:       chainID,
 		ctfOutput:     bcOut,
 		SethClient:    sethClient,
+		txManager:     NewTxManager(sethClient.Client, sethClient.NewTXOpts(seth.WithNonce(nil))),
 	}, nil
 }
 
@@ -0,0 +1,397 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	pkgerrors "github.com/pkg/errors"
+
+	cldf_evm "github.com/smartcontractkit/chainlink-deployments-framework/chain/evm"
+	"github.com/smartcontractkit/chainlink-testing-framework/seth"
+)
+
+const (
+	DefaultBumpAfter       = 30 * time.Second
+	DefaultBumpPercent     = 20
+	DefaultMaxBumps        = 5
+	DefaultSendSlotTimeout = time.Minute
+)
+
+// TxManager allocates nonces of the deployer key for all transactions sent by the framework (contract deployments, funding,
+// contract configuration) and bumps gas of transactions that are not mined in time. Without it concurrent helpers fetch
+// the same pending nonce from the chain and replace or reject each other's transactions.
+type TxManager struct {
+	From        common.Address
+	BumpAfter   time.Duration
+	BumpPercent int64
+	MaxBumps    int
+	// SendSlotTimeout limits how long a bound transaction may take from its nonce query to its send, see WrapClient.
+	// Transactions, which aren't sent in time, e.g. because they were built with NoSend, don't consume their nonce.
+	SendSlotTimeout time.Duration
+
+	client    txClient
+	signer    bind.SignerFn
+	nextNonce *uint64
+	mu        sync.Mutex
+	// sendSlot is held by a single transaction from the moment its nonce is allocated until it's sent, so that
+	// nonces are consumed only by transactions, which were sent
+	sendSlot chan struct{}
+	slot     *sendSlot
+}
+
+// sendSlot is the nonce of a bound transaction, which is about to be sent
+type sendSlot struct {
+	nonce uint64
+	timer *time.Timer
+}
+
+type txClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+func NewTxManager(client txClient, opts *bind.TransactOpts) *TxManager {
+	return &TxManager{
+		From:            opts.From,
+		BumpAfter:       DefaultBumpAfter,
+		BumpPercent:     DefaultBumpPercent,
+		MaxBumps:        DefaultMaxBumps,
+		SendSlotTimeout: DefaultSendSlotTimeout,
+		client:          client,
+		signer:          opts.Signer,
+		sendSlot:        make(chan struct{}, 1),
+	}
+}
+
+// ReserveNonce returns the next nonce of the deployer key for a transaction the caller sends itself. It's fetched from
+// the chain only once, after that nonces are allocated locally. Release it with ReleaseNonce, if the transaction isn't
+// sent.
+func (m *TxManager) ReserveNonce(ctx context.Context) (uint64, error) {
+	slot, err := m.beginSend(ctx)
+	if err != nil {
+		return 0, err
+	}
+	m.endSend(slot, true)
+
+	return slot.nonce, nil
+}
+
+// ReleaseNonce must be called if a transaction with reserved nonce wasn't sent. If it was the last reserved nonce,
+// it's reused, otherwise nonces are fetched from the chain again, so that the gap doesn't block following transactions.
+func (m *TxManager) ReleaseNonce(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.nextNonce != nil && *m.nextNonce == nonce+1 {
+		*m.nextNonce = nonce
+		return
+	}
+	m.nextNonce = nil
+}
+
+// Resync makes the next reservation fetch the nonce from the chain again. Call it when it's unknown, whether a transaction
+// with reserved nonce was sent.
+func (m *TxManager) Resync() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextNonce = nil
+}
+
+// beginSend waits for the send slot and allocates the next nonce to it, without consuming it
+func (m *TxManager) beginSend(ctx context.Context) (*sendSlot, error) {
+	select {
+	case m.sendSlot <- struct{}{}:
+	case <-ctx.Done():
+		return nil, pkgerrors.Wrapf(ctx.Err(), "timed out waiting for a nonce of %s", m.From)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.nextNonce == nil {
+		pending, err := m.client.PendingNonceAt(ctx, m.From)
+		if err != nil {
+			<-m.sendSlot
+			return nil, pkgerrors.Wrapf(err, "failed to get pending nonce of %s", m.From)
+		}
+		m.nextNonce = &pending
+	}
+	slot := &sendSlot{nonce: *m.nextNonce}
+	slot.timer = time.AfterFunc(m.SendSlotTimeout, func() { m.endSend(slot, false) })
+	m.slot = slot
+
+	return slot, nil
+}
+
+// endSend frees the send slot, its nonce is consumed only if the transaction was sent. Slots, which were already freed,
+// e.g. because they timed out, are ignored.
+func (m *TxManager) endSend(slot *sendSlot, sent bool) {
+	m.mu.Lock()
+	if m.slot != slot {
+		m.mu.Unlock()
+		return
+	}
+	m.slot = nil
+	slot.timer.Stop()
+	if sent && m.nextNonce != nil && *m.nextNonce == slot.nonce {
+		*m.nextNonce++
+	}
+	m.mu.Unlock()
+
+	<-m.sendSlot
+}
+
+// pendingSlot returns the send slot of a transaction with given nonce, nil if there's none
+func (m *TxManager) pendingSlot(nonce uint64) *sendSlot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.slot != nil && m.slot.nonce == nonce {
+		return m.slot
+	}
+
+	return nil
+}
+
+// currentSlot returns the send slot, nil if no transaction holds it
+func (m *TxManager) currentSlot() *sendSlot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.slot
+}
+
+// TransactOpts returns transact options, which can be shared by concurrent helpers. They must be used with a client
+// wrapped by WrapClient, which allocates the nonce when a transaction is built and consumes it only once it's sent.
+func (m *TxManager) TransactOpts() *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From:    m.From,
+		Context: context.Background(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			signed, sErr := m.signer(address, tx)
+			if sErr != nil {
+				// the transaction won't be sent
+				if slot := m.pendingSlot(tx.Nonce()); slot != nil {
+					m.endSend(slot, false)
+				}
+				return nil, sErr
+			}
+
+			return signed, nil
+		},
+	}
+}
+
+// SethTXOpts returns Seth transact options with a reserved nonce. Seth keeps its own nonce counter, so transactions of the same
+// key sent with plain SethClient.NewTXOpts() would race with the ones sent by other helpers. Use SendWithSeth, which releases
+// the nonce, if the transaction isn't sent.
+func (m *TxManager) SethTXOpts(ctx context.Context, sethClient *seth.Client, o ...seth.TransactOpt) (*bind.TransactOpts, error) {
+	nonce, err := m.ReserveNonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sethClient.NewTXOpts(append(o, seth.WithNonce(new(big.Int).SetUint64(nonce)))...), nil
+}
+
+// SendWithSeth sends a transaction with Seth transact options returned by SethTXOpts. The nonce is released, if send
+// returns no transaction, i.e. it failed before the transaction was sent.
+func (m *TxManager) SendWithSeth(ctx context.Context, sethClient *seth.Client, send func(opts *bind.TransactOpts) (*types.Transaction, error), o ...seth.TransactOpt) (*types.Transaction, error) {
+	opts, optsErr := m.SethTXOpts(ctx, sethClient, o...)
+	if optsErr != nil {
+		return nil, optsErr
+	}
+
+	tx, sendErr := send(opts)
+	if tx == nil {
+		m.ReleaseNonce(opts.Nonce.Uint64())
+	}
+
+	return tx, sendErr
+}
+
+type txManagerKey struct{}
+
+// ContextWithTxManager returns a context, with which helpers sending transactions of the deployer key outside of the
+// environment (e.g. of the workflow package) allocate nonces with the tx manager, see SendWithSeth
+func ContextWithTxManager(ctx context.Context, m *TxManager) context.Context {
+	return context.WithValue(ctx, txManagerKey{}, m)
+}
+
+// SendWithSeth sends a transaction with the tx manager of the context, see ContextWithTxManager. Without it Seth
+// allocates the nonce, as it would with plain SethClient.NewTXOpts().
+func SendWithSeth(ctx context.Context, sethClient *seth.Client, send func(opts *bind.TransactOpts) (*types.Transaction, error), o ...seth.TransactOpt) (*types.Transaction, error) {
+	m, _ := ctx.Value(txManagerKey{}).(*TxManager)
+	if m == nil {
+		return send(sethClient.NewTXOpts(o...))
+	}
+
+	return m.SendWithSeth(ctx, sethClient, send, o...)
+}
+
+// WrapClient wraps the client to allocate nonces of the deployer key, when bound contracts query them, and to consume
+// them, when transactions are sent. Transactions of the key are sent one by one, from the nonce query to the send.
+func (m *TxManager) WrapClient(client cldf_evm.OnchainClient) cldf_evm.OnchainClient {
+	return &txManagerClient{OnchainClient: client, txManager: m}
+}
+
+type txManagerClient struct {
+	cldf_evm.OnchainClient
+	txManager *TxManager
+}
+
+func (c *txManagerClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	if account != c.txManager.From {
+		return c.OnchainClient.PendingNonceAt(ctx, account)
+	}
+
+	slot, err := c.txManager.beginSend(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return slot.nonce, nil
+}
+
+// EstimateGas frees the send slot of the key, if the estimation fails, because the transaction won't be sent. Otherwise
+// callers, which query the nonce before estimating gas, would hold the slot until SendSlotTimeout.
+func (c *txManagerClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	if call.From != c.txManager.From {
+		return c.OnchainClient.EstimateGas(ctx, call)
+	}
+
+	slot := c.txManager.currentSlot()
+	gas, err := c.OnchainClient.EstimateGas(ctx, call)
+	if err != nil && slot != nil {
+		c.txManager.endSend(slot, false)
+	}
+
+	return gas, err
+}
+
+func (c *txManagerClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	sendErr := c.OnchainClient.SendTransaction(ctx, tx)
+	if from, senderErr := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); senderErr == nil && from == c.txManager.From {
+		if slot := c.txManager.pendingSlot(tx.Nonce()); slot != nil {
+			c.txManager.endSend(slot, sendErr == nil)
+		}
+	}
+
+	return sendErr
+}
+
+// WaitMined waits until the transaction or one of its gas-bumped replacements is mined. A replacement with gas price
+// increased by BumpPercent is sent every BumpAfter, at most MaxBumps times.
+func (m *TxManager) WaitMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	sent := []*types.Transaction{tx}
+	lastSentAt := time.Now()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		for _, candidate := range sent {
+			receipt, err := m.client.TransactionReceipt(ctx, candidate.Hash())
+			if err == nil && receipt != nil {
+				return receipt, nil
+			}
+		}
+
+		if len(sent)-1 < m.MaxBumps && time.Since(lastSentAt) > m.BumpAfter {
+			bumped, bErr := m.bump(ctx, sent[len(sent)-1])
+			if bErr != nil {
+				return nil, bErr
+			}
+			if bumped != nil {
+				sent = append(sent, bumped)
+			}
+			lastSentAt = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, pkgerrors.Wrapf(ctx.Err(), "transaction %s with nonce %d wasn't mined after %d gas bumps", tx.Hash(), tx.Nonce(), len(sent)-1)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *TxManager) bump(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	rebuilt, rErr := rebuildTx(tx, tx.Nonce(), m.BumpPercent)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	signed, sErr := m.signer(m.From, rebuilt)
+	if sErr != nil {
+		return nil, pkgerrors.Wrap(sErr, "failed to sign gas-bumped transaction")
+	}
+
+	// sending directly to the client, because the nonce of a replacement must not be released
+	if err := m.client.SendTransaction(ctx, signed); err != nil {
+		// one of the previous transactions was mined in the meantime
+		if strings.Contains(strings.ToLower(err.Error()), "nonce too low") {
+			return nil, nil
+		}
+		return nil, pkgerrors.Wrapf(err, "failed to send gas-bumped replacement of transaction %s", tx.Hash())
+	}
+
+	return signed, nil
+}
+
+// rebuildTx returns an unsigned copy of the transaction with given nonce and gas price increased by bumpPercent
+func rebuildTx(tx *types.Transaction, nonce uint64, bumpPercent int64) (*types.Transaction, error) {
+	bump := func(v *big.Int) *big.Int {
+		if v == nil {
+			return nil
+		}
+		bumped := new(big.Int).Mul(v, big.NewInt(100+bumpPercent))
+		return bumped.Div(bumped, big.NewInt(100))
+	}
+
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: bump(tx.GasPrice()),
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}), nil
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      nonce,
+			GasPrice:   bump(tx.GasPrice()),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}), nil
+	case types.DynamicFeeTxType:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      nonce,
+			GasTipCap:  bump(tx.GasTipCap()),
+			GasFeeCap:  bump(tx.GasFeeCap()),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type())
+	}
+}
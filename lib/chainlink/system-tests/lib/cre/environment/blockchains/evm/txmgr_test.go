@@ -0,0 +1,214 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cldf_evm "github.com/smartcontractkit/chainlink-deployments-framework/chain/evm"
+)
+
+// fakeClient implements methods of the client used by the tx manager, calls of other methods panic
+type fakeClient struct {
+	cldf_evm.OnchainClient
+
+	mu           sync.Mutex
+	pendingNonce uint64
+	pendingCalls int
+	estimateErr  error
+	sendErr      error
+	sent         []*types.Transaction
+}
+
+func (c *fakeClient) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pendingCalls++
+
+	return c.pendingNonce, nil
+}
+
+func (c *fakeClient) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 21000, c.estimateErr
+}
+
+func (c *fakeClient) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sendErr != nil {
+		return c.sendErr
+	}
+	c.sent = append(c.sent, tx)
+
+	return nil
+}
+
+func (c *fakeClient) TransactionReceipt(context.Context, common.Hash) (*types.Receipt, error) {
+	return nil, errors.New("not found")
+}
+
+func newTestTxManager(t *testing.T, client *fakeClient) *TxManager {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	require.NoError(t, err)
+
+	return NewTxManager(client, opts)
+}
+
+func TestReserveNonceFetchesPendingNonceOnce(t *testing.T) {
+	client := &fakeClient{pendingNonce: 7}
+	m := newTestTxManager(t, client)
+
+	for _, expected := range []uint64{7, 8, 9} {
+		nonce, err := m.ReserveNonce(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, expected, nonce)
+	}
+	assert.Equal(t, 1, client.pendingCalls)
+}
+
+func TestReleaseNonce(t *testing.T) {
+	client := &fakeClient{pendingNonce: 7}
+	m := newTestTxManager(t, client)
+
+	first, err := m.ReserveNonce(context.Background())
+	require.NoError(t, err)
+	second, err := m.ReserveNonce(context.Background())
+	require.NoError(t, err)
+
+	m.ReleaseNonce(second)
+	nonce, err := m.ReserveNonce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, second, nonce, "the last reserved nonce is reused")
+	assert.Equal(t, 1, client.pendingCalls)
+
+	// releasing a nonce before the last one leaves a gap, so nonces are fetched again
+	m.ReleaseNonce(first)
+	client.pendingNonce = 9
+	nonce, err = m.ReserveNonce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), nonce)
+	assert.Equal(t, 2, client.pendingCalls)
+}
+
+func TestResync(t *testing.T) {
+	client := &fakeClient{pendingNonce: 7}
+	m := newTestTxManager(t, client)
+
+	_, err := m.ReserveNonce(context.Background())
+	require.NoError(t, err)
+	m.Resync()
+	client.pendingNonce = 20
+	nonce, err := m.ReserveNonce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), nonce)
+}
+
+func TestWrappedClientConsumesNonceOnlyOnSend(t *testing.T) {
+	client := &fakeClient{pendingNonce: 7}
+	m := newTestTxManager(t, client)
+	m.SendSlotTimeout = 50 * time.Millisecond
+	wrapped := m.WrapClient(client)
+	opts := m.TransactOpts()
+
+	// what bound contracts do: query the nonce, sign and send
+	send := func() (uint64, error) {
+		nonce, err := wrapped.PendingNonceAt(context.Background(), m.From)
+		require.NoError(t, err)
+		signed, err := opts.Signer(m.From, types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1337), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000}))
+		require.NoError(t, err)
+
+		return nonce, wrapped.SendTransaction(context.Background(), signed)
+	}
+
+	client.sendErr = errors.New("connection refused")
+	nonce, err := send()
+	require.Error(t, err)
+	assert.Equal(t, uint64(7), nonce)
+
+	client.sendErr = nil
+	nonce, err = send()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), nonce, "the failed transaction didn't consume the nonce")
+	nonce, err = send()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), nonce)
+
+	// a transaction, which is never sent, frees its nonce once the slot times out
+	_, err = wrapped.PendingNonceAt(context.Background(), m.From)
+	require.NoError(t, err)
+	reserved, err := m.ReserveNonce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), reserved)
+}
+
+func TestWrappedClientFreesSlotWhenEstimationFails(t *testing.T) {
+	client := &fakeClient{pendingNonce: 7, estimateErr: errors.New("execution reverted")}
+	m := newTestTxManager(t, client)
+	m.SendSlotTimeout = time.Hour
+	wrapped := m.WrapClient(client)
+
+	nonce, err := wrapped.PendingNonceAt(context.Background(), m.From)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), nonce)
+	_, err = wrapped.EstimateGas(context.Background(), ethereum.CallMsg{From: m.From})
+	require.ErrorContains(t, err, "execution reverted")
+
+	// the slot is free right away, without waiting for the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reserved, err := m.ReserveNonce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), reserved, "the transaction, which failed estimation, didn't consume the nonce")
+
+	// estimations of other accounts don't free the slot
+	_, err = wrapped.PendingNonceAt(context.Background(), m.From)
+	require.NoError(t, err)
+	_, err = wrapped.EstimateGas(context.Background(), ethereum.CallMsg{From: common.HexToAddress("0x01")})
+	require.Error(t, err)
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = m.ReserveNonce(ctx)
+	require.ErrorContains(t, err, "timed out waiting for a nonce")
+}
+
+func TestBump(t *testing.T) {
+	client := &fakeClient{}
+	m := newTestTxManager(t, client)
+
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1337), Nonce: 3, GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(1000), Gas: 21000})
+	bumped, err := m.bump(context.Background(), tx)
+	require.NoError(t, err)
+	require.NotNil(t, bumped)
+	assert.Equal(t, uint64(3), bumped.Nonce())
+	assert.Equal(t, big.NewInt(120), bumped.GasTipCap())
+	assert.Equal(t, big.NewInt(1200), bumped.GasFeeCap())
+	require.Len(t, client.sent, 1)
+
+	legacy, err := rebuildTx(types.NewTx(&types.LegacyTx{Nonce: 3, GasPrice: big.NewInt(50), Gas: 21000}), 3, 20)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(60), legacy.GasPrice())
+
+	// the original transaction was mined in the meantime
+	client.sendErr = errors.New("nonce too low")
+	bumped, err = m.bump(context.Background(), tx)
+	require.NoError(t, err)
+	assert.Nil(t, bumped)
+
+	client.sendErr = errors.New("insufficient funds")
+	_, err = m.bump(context.Background(), tx)
+	require.ErrorContains(t, err, "insufficient funds")
+}
@@ -0,0 +1,232 @@
+package environment
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	pkgerrors "github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-deployments-framework/datastore"
+	cldf "github.com/smartcontractkit/chainlink-deployments-framework/deployment"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/jd"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	crecontracts "github.com/smartcontractkit/chainlink/system-tests/lib/cre/contracts"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains"
+)
+
+type ProvisioningPhase = string
+
+// Phases, whose results are checkpointed. Phases executed after DONs are started (linking with JD, creating jobs, funding,
+// configuring contracts and applying features) are always executed again when resuming.
+const (
+	PhaseBlockchains    ProvisioningPhase = "blockchains"
+	PhaseContracts      ProvisioningPhase = "contracts"
+	PhaseJobDistributor ProvisioningPhase = "job_distributor"
+	PhaseDONs           ProvisioningPhase = "dons"
+)
+
+// ProvisioningCheckpoint persists outputs of each completed provisioning phase, so that after a failure setup can be resumed
+// without starting chains, deploying contracts and booting nodes again. Resuming works, because CTF components reuse
+// cached outputs instead of starting new containers and contracts are restored from saved addresses. Keys of nodes are
+// saved with their outputs, because reused nodes keep the keys they were started with.
+type ProvisioningCheckpoint struct {
+	Completed   []ProvisioningPhase                       `json:"completed"`
+	Blockchains []*blockchain.Output                      `json:"blockchains,omitempty"`
+	AddressBook map[uint64]map[string]cldf.TypeAndVersion `json:"address_book,omitempty"`
+	AddressRefs []datastore.AddressRef                    `json:"address_refs,omitempty"`
+	JD          *jd.Output                                `json:"jd,omitempty"`
+	NodeSets    []*ns.Output                              `json:"nodesets,omitempty"`
+	// Keys of started nodes, imported on resume, so that peer IDs and accounts match the running nodes
+	Keys *cre.KeysFile `json:"keys,omitempty"`
+	// Fingerprint of the environment definition the checkpoint was created for, see Fingerprint
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	absPath string
+	mu      sync.Mutex
+}
+
+// NewProvisioningCheckpoint returns an empty checkpoint stored at absPath, with an empty path it isn't stored at all
+func NewProvisioningCheckpoint(absPath string) *ProvisioningCheckpoint {
+	return &ProvisioningCheckpoint{absPath: absPath}
+}
+
+// LoadProvisioningCheckpoint reads checkpoint from disk. If it doesn't exist, an empty checkpoint is returned, so that setup starts from scratch.
+func LoadProvisioningCheckpoint(absPath string) (*ProvisioningCheckpoint, error) {
+	checkpoint := NewProvisioningCheckpoint(absPath)
+
+	content, readErr := os.ReadFile(absPath)
+	if os.IsNotExist(readErr) {
+		return checkpoint, nil
+	}
+	if readErr != nil {
+		return nil, pkgerrors.Wrapf(readErr, "failed to read provisioning checkpoint from %s", absPath)
+	}
+
+	if err := json.Unmarshal(content, checkpoint); err != nil {
+		return nil, pkgerrors.Wrap(err, "failed to unmarshal provisioning checkpoint")
+	}
+
+	return checkpoint, nil
+}
+
+func (c *ProvisioningCheckpoint) IsCompleted(phase ProvisioningPhase) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Contains(c.Completed, phase)
+}
+
+// Complete saves phase outputs using the update function and persists the checkpoint
+func (c *ProvisioningCheckpoint) Complete(phase ProvisioningPhase, update func(c *ProvisioningCheckpoint)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	update(c)
+	if !slices.Contains(c.Completed, phase) {
+		c.Completed = append(c.Completed, phase)
+	}
+
+	if c.absPath == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.absPath), 0o755); err != nil {
+		return pkgerrors.Wrap(err, "failed to create directory for the provisioning checkpoint")
+	}
+
+	if err := WriteJSONFile(c.absPath, c); err != nil {
+		return pkgerrors.Wrapf(err, "failed to store provisioning checkpoint after phase %s", phase)
+	}
+	framework.L.Info().Msgf("Provisioning phase '%s' checkpointed to %s", phase, c.absPath)
+
+	return nil
+}
+
+// Remove deletes the checkpoint file, it should be called once the whole setup succeeds
+func (c *ProvisioningCheckpoint) Remove() error {
+	if c.absPath == "" {
+		return nil
+	}
+	if err := os.Remove(c.absPath); err != nil && !os.IsNotExist(err) {
+		return pkgerrors.Wrap(err, "failed to remove provisioning checkpoint")
+	}
+
+	return nil
+}
+
+// ApplyTo sets cached outputs of completed phases on inputs, which makes CTF reuse existing containers.
+// Checkpoints created for a different environment definition (e.g. with rebuilt binaries) are rejected.
+func (c *ProvisioningCheckpoint) ApplyTo(input *SetupInput, fingerprint string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Fingerprint != "" && c.Fingerprint != fingerprint {
+		return pkgerrors.Errorf("checkpoint was created for environment %s, but the current one is %s. Remove the checkpoint to start from scratch", c.Fingerprint, fingerprint)
+	}
+	c.Fingerprint = fingerprint
+
+	if slices.Contains(c.Completed, PhaseBlockchains) {
+		if len(c.Blockchains) != len(input.BlockchainsInput) {
+			return pkgerrors.Errorf("checkpoint has %d blockchains, but %d are configured. Remove the checkpoint to start from scratch", len(c.Blockchains), len(input.BlockchainsInput))
+		}
+		for idx, out := range c.Blockchains {
+			input.BlockchainsInput[idx].Out = out
+		}
+	}
+
+	if slices.Contains(c.Completed, PhaseJobDistributor) {
+		input.JdInput.Out = c.JD
+	}
+
+	if slices.Contains(c.Completed, PhaseDONs) {
+		if len(c.NodeSets) != len(input.CapabilitiesAwareNodeSets) {
+			return pkgerrors.Errorf("checkpoint has %d nodesets, but %d are configured. Remove the checkpoint to start from scratch", len(c.NodeSets), len(input.CapabilitiesAwareNodeSets))
+		}
+		if c.Keys == nil {
+			return pkgerrors.New("checkpoint has outputs of nodesets, but not keys of their nodes. Remove the checkpoint to start from scratch")
+		}
+		// reused nodes keep their keys, new ones would change peer IDs and accounts registered on-chain
+		if err := c.Keys.Apply(input.CapabilitiesAwareNodeSets); err != nil {
+			return pkgerrors.Wrap(err, "failed to apply keys of nodes from checkpoint")
+		}
+		for idx, out := range c.NodeSets {
+			input.CapabilitiesAwareNodeSets[idx].Out = out
+		}
+	}
+
+	return nil
+}
+
+func checkpointBlockchains(deployed []blockchains.Blockchain) func(c *ProvisioningCheckpoint) {
+	return func(c *ProvisioningCheckpoint) {
+		c.Blockchains = make([]*blockchain.Output, 0, len(deployed))
+		for _, bc := range deployed {
+			out := *bc.CtfOutput()
+			out.Container = nil // not serializable, CTF doesn't need it to reuse the chain
+			c.Blockchains = append(c.Blockchains, &out)
+		}
+	}
+}
+
+// checkpointDONs saves outputs of started nodesets and secrets with keys of their nodes, see cre.KeysFile
+func checkpointDONs(startedDONs *StartedDONs, nodeSets []*cre.CapabilitiesAwareNodeSet) func(c *ProvisioningCheckpoint) {
+	return func(c *ProvisioningCheckpoint) {
+		c.NodeSets = make([]*ns.Output, 0, len(*startedDONs))
+		for _, nodeOutput := range startedDONs.NodeOutputs() {
+			c.NodeSets = append(c.NodeSets, nodeOutput.Output)
+		}
+
+		c.Keys = &cre.KeysFile{}
+		for _, nodeSet := range nodeSets {
+			nodeSetKeys := &cre.KeysFileNodeSet{Name: nodeSet.Name}
+			for idx, nodeSpec := range nodeSet.NodeSpecs {
+				nodeSetKeys.Nodes = append(nodeSetKeys.Nodes, &cre.KeysFileNode{Index: idx, Secrets: nodeSpec.Node.TestSecretsOverrides})
+			}
+			c.Keys.NodeSets = append(c.Keys.NodeSets, nodeSetKeys)
+		}
+	}
+}
+
+func checkpointContracts(output *crecontracts.DeployKeystoneContractsOutput) (func(c *ProvisioningCheckpoint), error) {
+	addressBook, abErr := output.Env.ExistingAddresses.Addresses() //nolint:staticcheck // won't migrate now
+	if abErr != nil {
+		return nil, pkgerrors.Wrap(abErr, "failed to get addresses from address book")
+	}
+
+	addressRefs, refsErr := output.MemoryDataStore.Addresses().Fetch()
+	if refsErr != nil {
+		return nil, pkgerrors.Wrap(refsErr, "failed to get address refs from datastore")
+	}
+
+	return func(c *ProvisioningCheckpoint) {
+		c.AddressBook = addressBook
+		c.AddressRefs = addressRefs
+	}, nil
+}
+
+// restoreContracts recreates output of contracts deployment from saved addresses
+func (c *ProvisioningCheckpoint) restoreContracts(env *cldf.Environment) (*crecontracts.DeployKeystoneContractsOutput, error) {
+	if err := env.ExistingAddresses.Merge(cldf.NewMemoryAddressBookFromMap(c.AddressBook)); err != nil { //nolint:staticcheck // won't migrate now
+		return nil, pkgerrors.Wrap(err, "failed to restore address book")
+	}
+
+	memoryDatastore := datastore.NewMemoryDataStore()
+	for _, addrRef := range c.AddressRefs {
+		if err := memoryDatastore.AddressRefStore.Add(addrRef); err != nil {
+			return nil, pkgerrors.Wrapf(err, "failed to restore address ref %v", addrRef)
+		}
+	}
+	env.DataStore = memoryDatastore.Seal()
+
+	return &crecontracts.DeployKeystoneContractsOutput{
+		Env:             env,
+		MemoryDataStore: memoryDatastore,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/jd"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+)
+
+func TestCheckpointWithoutPathIsNotStored(t *testing.T) {
+	checkpoint := NewProvisioningCheckpoint("")
+	require.NoError(t, checkpoint.Complete(PhaseJobDistributor, func(*ProvisioningCheckpoint) {}))
+	require.True(t, checkpoint.IsCompleted(PhaseJobDistributor))
+	require.NoError(t, checkpoint.Remove())
+}
+
+func TestCheckpointOfDONsRequiresKeys(t *testing.T) {
+	absPath := filepath.Join(t.TempDir(), "checkpoint.json")
+	checkpoint := NewProvisioningCheckpoint(absPath)
+	require.NoError(t, checkpoint.Complete(PhaseDONs, func(c *ProvisioningCheckpoint) { c.NodeSets = []*ns.Output{{}} }))
+	_, statErr := os.Stat(absPath)
+	require.NoError(t, statErr)
+
+	loaded, err := LoadProvisioningCheckpoint(absPath)
+	require.NoError(t, err)
+	input := &SetupInput{CapabilitiesAwareNodeSets: []*cre.CapabilitiesAwareNodeSet{{Input: &ns.Input{Name: "workflow"}}}}
+	require.ErrorContains(t, loaded.ApplyTo(input, ""), "not keys of their nodes")
+}
+
+func TestCheckpointIsStoredAfterEachPhase(t *testing.T) {
+	absPath := filepath.Join(t.TempDir(), "checkpoint.json")
+	checkpoint := NewProvisioningCheckpoint(absPath)
+	checkpoint.Fingerprint = "3f1c"
+
+	require.NoError(t, checkpoint.Complete(PhaseBlockchains, func(c *ProvisioningCheckpoint) {
+		c.Blockchains = []*blockchain.Output{{ChainID: "1337"}}
+	}))
+	loaded, err := LoadProvisioningCheckpoint(absPath)
+	require.NoError(t, err)
+	require.Equal(t, []ProvisioningPhase{PhaseBlockchains}, loaded.Completed)
+	require.Equal(t, "3f1c", loaded.Fingerprint)
+
+	require.NoError(t, checkpoint.Complete(PhaseJobDistributor, func(c *ProvisioningCheckpoint) { c.JD = &jd.Output{} }))
+	loaded, err = LoadProvisioningCheckpoint(absPath)
+	require.NoError(t, err)
+	require.Equal(t, []ProvisioningPhase{PhaseBlockchains, PhaseJobDistributor}, loaded.Completed)
+
+	input := &SetupInput{BlockchainsInput: []*blockchain.Input{{ChainID: "1337"}}, JdInput: &jd.Input{}}
+	require.ErrorContains(t, loaded.ApplyTo(input, "other"), "checkpoint was created for environment 3f1c")
+	require.NoError(t, loaded.ApplyTo(input, "3f1c"))
+	require.Equal(t, "1337", input.BlockchainsInput[0].Out.ChainID)
+	require.NotNil(t, input.JdInput.Out)
+
+	require.NoError(t, checkpoint.Remove())
+	_, statErr := os.Stat(absPath)
+	require.True(t, os.IsNotExist(statErr))
+}
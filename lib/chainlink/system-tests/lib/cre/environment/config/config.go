@@ -23,6 +23,13 @@ import (
 
 	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	crecapabilities "github.com/smartcontractkit/chainlink/system-tests/lib/cre/capabilities"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/datagen"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/gateway"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/metrics"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/notify"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/watchdog"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
 )
 
@@ -34,13 +41,49 @@ type Config struct {
 	Fake              *fake.Input                     `toml:"fake" validate:"required"`
 	S3ProviderInput   *s3provider.Input               `toml:"s3provider"`
 	CapabilityConfigs map[string]cre.CapabilityConfig `toml:"capability_configs"` // capability flag -> capability config
+	ChainFinality     cre.ChainFinalityConfigs        `toml:"chain_finality"`     // chain ID -> finality config
+	// GatewayLoadBalancer puts all gateways behind a load balancer, use it with nodesets that have multiple gateway nodes
+	GatewayLoadBalancer *infra.GatewayLoadBalancerInput `toml:"gateway_load_balancer"`
+	// GatewayAuth sets authentication options of gateways and senders allowed to trigger HTTP workflows, see
+	// environment.SetupInput.GatewayAuthConfig
+	GatewayAuth *gateway.AuthConfig `toml:"gateway_auth"`
+	// CustomContainers are extra containers (e.g. Kafka, Redis, custom mocks) started in the same Docker network as nodes
+	CustomContainers []*infra.CustomContainerInput `toml:"custom_containers"`
+	// HostProcesses are components running as processes on the host (e.g. a mock server started in an IDE), reachable by nodes
+	HostProcesses []*infra.HostProcessInput `toml:"host_processes"`
+	// ContractVersions pins versions of contracts to deploy (contract type -> semver), defaults are used for contracts that aren't set
+	ContractVersions map[string]string `toml:"contract_versions"`
+	// BinaryCache skips copies of capability binaries nodes already have, it's enabled by default
+	BinaryCache *crecapabilities.BinaryCacheConfig `toml:"binary_cache"`
+	// PhaseTimeouts limits duration of provisioning phases (image pull, node readiness, registry config, job propagation, component readiness)
+	PhaseTimeouts *cre.PhaseTimeouts `toml:"phase_timeouts"`
+	// MetricsRemoteWrite writes metrics of the local observability stack to a central store, e.g. in CI runs
+	MetricsRemoteWrite *metrics.RemoteWriteConfig `toml:"metrics_remote_write"`
+	// LeakDetection samples heap and goroutine counts of nodes and capability plugins during soak scenarios
+	LeakDetection *metrics.LeakDetectionConfig `toml:"leak_detection"`
+	// Notifications configures webhook and Slack hooks notified about milestones and failures of long-running scenarios,
+	// see environment.SetupInput.Notifications
+	Notifications *notify.Config `toml:"notifications"`
+	// Watchdog fails long-running tests fast, when nodes, chains or gateways die, see environment.SetupInput.Watchdog
+	Watchdog *watchdog.Options `toml:"watchdog"`
+	// DataGenerator posts synthetic feed prices to its url once the environment is ready until it's torn down, see datagen.Generator
+	DataGenerator *datagen.Config `toml:"data_generator"`
+	// FeatureFlags enable experimental node and capability features in the whole environment
+	FeatureFlags *cre.FeatureFlags `toml:"feature_flags"`
+	// EnvironmentName identifies the environment in artifacts and reports, cre.DefaultEnvironmentName is used if empty
+	EnvironmentName string `toml:"environment_name"`
+	// KeysFile is a path to pre-generated keys of nodes (P2P, EVM, Solana and OCR2 keys), see cre.KeysFile
+	KeysFile string `toml:"keys_file"`
+	// SchemaVersion is the version of the state file format, it's set when the state is stored, see StateSchemaVersion
+	SchemaVersion int `toml:"schema_version"`
 
 	mu     sync.Mutex
 	loaded bool
 }
 
 // Validate performs validation checks on the configuration, ensuring all required fields
-// are present and all referenced capabilities are known to the system.
+// are present and all referenced capabilities are known to the system. Dependencies of capabilities enabled on nodesets
+// are enabled on them too, see CapabilitiesAwareNodeSet.ResolveCapabilityDependencies.
 func (c *Config) Validate(envDependencies cre.CLIEnvironmentDependencies) error {
 	if c.JD.CSAEncryptionKey == "" {
 		return errors.New("jd.csa_encryption_key must be provided")
@@ -58,6 +101,26 @@ func (c *Config) Validate(envDependencies cre.CLIEnvironmentDependencies) error
 		return errors.New("infra configuration must be provided")
 	}
 
+	if err := cre.ValidateEnvironmentName(c.EnvironmentName); err != nil {
+		return err
+	}
+
+	knownFlags := slices.Concat(envDependencies.GlobalCapabilityFlags(), envDependencies.ChainSpecificCapabilityFlags())
+	for flag, capabilityConfig := range c.CapabilityConfigs {
+		if !slices.Contains(knownFlags, flag) {
+			return errors.New("capability_configs has config of unknown capability: " + flag + ". Valid ones are: " + strings.Join(knownFlags, ", "))
+		}
+		if err := capabilityConfig.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid config of capability %s", flag)
+		}
+		if capabilityConfig.BinaryPath == "" {
+			continue
+		}
+		if _, err := binaries.ParseRef(capabilityConfig.BinaryPath); err != nil {
+			return errors.Wrapf(err, "invalid binary_path of capability %s", flag)
+		}
+	}
+
 	for _, nodeSet := range c.NodeSets {
 		for _, capability := range nodeSet.Capabilities {
 			if !slices.Contains(envDependencies.GlobalCapabilityFlags(), capability) {
@@ -65,6 +128,47 @@ func (c *Config) Validate(envDependencies cre.CLIEnvironmentDependencies) error
 			}
 		}
 
+		for flag := range nodeSet.CapabilityOverrides {
+			if !slices.Contains(knownFlags, flag) {
+				return errors.New("capability_overrides of nodeset " + nodeSet.Name + " override config of unknown capability: " + flag + ". Valid ones are: " + strings.Join(knownFlags, ", "))
+			}
+		}
+
+		// resolved before other checks, so that they see capabilities enabled as dependencies too
+		if _, err := nodeSet.ResolveCapabilityDependencies(c.CapabilityConfigs, envDependencies); err != nil {
+			return errors.Wrapf(err, "failed to resolve capability dependencies of nodeset %s", nodeSet.Name)
+		}
+
+		if err := nodeSet.ValidateDONTypes(); err != nil {
+			return err
+		}
+
+		if nodeSet.NodeProfile != cre.NodeProfileDefault && nodeSet.NodeProfile != cre.NodeProfileSlim {
+			return fmt.Errorf("unknown node profile '%s' for nodeset %s. Valid ones are: '%s' (default), '%s'", nodeSet.NodeProfile, nodeSet.Name, cre.NodeProfileDefault, cre.NodeProfileSlim)
+		}
+
+		for _, gatewayIdx := range nodeSet.GatewayNodeIndexes {
+			if gatewayIdx < 0 || gatewayIdx >= len(nodeSet.NodeSpecs) {
+				return fmt.Errorf("gateway node index %d is out of range for nodeset %s with %d nodes", gatewayIdx, nodeSet.Name, len(nodeSet.NodeSpecs))
+			}
+		}
+
+		if err := nodeSet.ValidateLocales(); err != nil {
+			return err
+		}
+
+		if err := nodeSet.ValidateNodeCapabilityBinaries(c.CapabilityConfigs); err != nil {
+			return err
+		}
+
+		if err := nodeSet.ValidateCapabilityPolicies(); err != nil {
+			return errors.Wrapf(err, "invalid capability policies of nodeset %s", nodeSet.Name)
+		}
+
+		if _, _, _, err := nodeSet.CapabilityBinariesOwnerIDs(); err != nil {
+			return err
+		}
+
 		for capability := range nodeSet.ChainCapabilities {
 			if !slices.Contains(envDependencies.ChainSpecificCapabilityFlags(), capability) {
 				return errors.New("unknown chain-specific capability: " + capability + ". Valid ones are: " + strings.Join(envDependencies.ChainSpecificCapabilityFlags(), ", ") + ". If it is a new capability make sure you have added it to the capabilityFlagsProvider. If it's a global capability add it under 'capabilities' TOML key.")
@@ -72,26 +176,103 @@ func (c *Config) Validate(envDependencies cre.CLIEnvironmentDependencies) error
 		}
 	}
 
-	if err := validateContractVersions(envDependencies); err != nil {
+	if c.GatewayAuth != nil {
+		if err := c.GatewayAuth.Validate(); err != nil {
+			return fmt.Errorf("invalid gateway auth config: %w", err)
+		}
+	}
+
+	for _, customContainer := range c.CustomContainers {
+		if err := customContainer.Validate(); err != nil {
+			return fmt.Errorf("invalid custom container config: %w", err)
+		}
+	}
+
+	for _, hostProcess := range c.HostProcesses {
+		if err := hostProcess.Validate(); err != nil {
+			return fmt.Errorf("invalid host process config: %w", err)
+		}
+	}
+
+	if err := c.PhaseTimeouts.Validate(); err != nil {
+		return fmt.Errorf("invalid phase timeouts config: %w", err)
+	}
+
+	if c.MetricsRemoteWrite != nil {
+		if err := c.MetricsRemoteWrite.Validate(); err != nil {
+			return fmt.Errorf("invalid metrics remote write config: %w", err)
+		}
+	}
+
+	if c.LeakDetection != nil {
+		if err := c.LeakDetection.Validate(); err != nil {
+			return fmt.Errorf("invalid leak detection config: %w", err)
+		}
+	}
+
+	if c.Notifications != nil {
+		if err := c.Notifications.Validate(); err != nil {
+			return fmt.Errorf("invalid notifications config: %w", err)
+		}
+	}
+
+	if c.Watchdog != nil {
+		if err := c.Watchdog.Validate(); err != nil {
+			return fmt.Errorf("invalid watchdog config: %w", err)
+		}
+	}
+
+	if c.DataGenerator != nil {
+		if err := c.DataGenerator.Validate(); err != nil {
+			return fmt.Errorf("invalid data generator config: %w", err)
+		}
+		// publishers other than HTTP can be passed only programmatically, see environment.SetupInput.DataPublisher
+		if c.DataGenerator.URL == "" {
+			return errors.New("data_generator.url must be provided")
+		}
+	}
+
+	if c.FeatureFlags != nil {
+		if err := c.FeatureFlags.Validate(); err != nil {
+			return fmt.Errorf("invalid feature flags: %w", err)
+		}
+	}
+
+	if err := c.validateContractVersions(envDependencies); err != nil {
 		return fmt.Errorf("failed to validate initial contract set: %w", err)
 	}
 
 	return nil
 }
 
-func validateContractVersions(envDependencies cre.CLIEnvironmentDependencies) error {
-	supportedSet := DefaultContractSet(envDependencies.WithV2Registries())
-	cv := envDependencies.ContractVersions()
-	for k, v := range supportedSet {
-		version, ok := cv[k]
+func (c *Config) validateContractVersions(envDependencies cre.CLIEnvironmentDependencies) error {
+	withV2Registries := envDependencies.WithV2Registries()
+
+	return ValidateContractVersions(ResolveContractVersions(withV2Registries, envDependencies.ContractVersions(), c.ContractVersions), withV2Registries)
+}
+
+// ValidateContractVersions checks that all required contracts have a deployable version and that versions of registries
+// match the V2 registries flag
+func ValidateContractVersions(contractVersions map[string]string, withV2Registries bool) error {
+	for k := range DefaultContractSet(withV2Registries) {
+		version, ok := contractVersions[k]
 		if !ok {
 			return fmt.Errorf("required contract %s not configured for deployment", k)
 		}
 
-		if version != v {
-			return fmt.Errorf("requested version %s for contract %s yet expected %s", version, k, v)
+		if !slices.Contains(DeployableContractVersions[k], version) {
+			return fmt.Errorf("requested version %s for contract %s can't be deployed, available versions are: %s", version, k, strings.Join(DeployableContractVersions[k], ", "))
 		}
 	}
+
+	// registries are deployed and configured together, either both in v1 or both in v2
+	for _, registry := range []string{keystone_changeset.WorkflowRegistry.String(), keystone_changeset.CapabilitiesRegistry.String()} {
+		isV2 := strings.HasPrefix(contractVersions[registry], "2.")
+		if isV2 != withV2Registries {
+			return fmt.Errorf("requested version %s for contract %s doesn't match the registries version selected with the V2 registries flag (enabled: %t)", contractVersions[registry], registry, withV2Registries)
+		}
+	}
+
 	return nil
 }
 
@@ -101,6 +282,15 @@ const (
 	DefaultDONFamily           = "test-don-family"
 )
 
+// DeployableContractVersions lists versions of contracts, which have embedded artifacts (gethwrappers) and can be deployed.
+// Pin an older version with ContractVersions in the TOML config to test nodes against previously deployed contracts.
+var DeployableContractVersions = map[string][]string{
+	keystone_changeset.OCR3Capability.String():       {"1.0.0"},
+	keystone_changeset.WorkflowRegistry.String():     {"1.0.0", WorkflowRegistryV2Semver},
+	keystone_changeset.CapabilitiesRegistry.String(): {"1.1.0", CapabilityRegistryV2Semver},
+	keystone_changeset.KeystoneForwarder.String():    {"1.0.0"},
+}
+
 func DefaultContractSet(withV2Registries bool) map[string]string {
 	supportedSet := map[string]string{
 		keystone_changeset.OCR3Capability.String():       "1.0.0",
@@ -117,6 +307,26 @@ func DefaultContractSet(withV2Registries bool) map[string]string {
 	return supportedSet
 }
 
+// ResolveContractVersions returns the default contract set with pinned versions applied on top of it, later pins take
+// precedence and blank pins are ignored
+func ResolveContractVersions(withV2Registries bool, pinned ...map[string]string) map[string]string {
+	versions := DefaultContractSet(withV2Registries)
+	for _, pins := range pinned {
+		for contract, version := range pins {
+			if version != "" {
+				versions[contract] = version
+			}
+		}
+	}
+
+	return versions
+}
+
+// ContractVersionsProvider returns the default contract set with versions pinned in the config
+func (c *Config) ContractVersionsProvider(withV2Registries bool) cre.ContractVersionsProvider {
+	return cre.NewContractVersionsProvider(ResolveContractVersions(withV2Registries, c.ContractVersions))
+}
+
 func (c *Config) Load(absPath string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -130,7 +340,13 @@ func (c *Config) Load(absPath string) error {
 		_ = os.Setenv("CTF_CONFIGS", previousCTFconfigs)
 	}()
 
-	_ = os.Setenv("CTF_CONFIGS", absPath)
+	migratedPaths, cleanup, migrateErr := migrateConfigFiles(absPath)
+	if migrateErr != nil {
+		return errors.Wrap(migrateErr, "failed to migrate environment configuration")
+	}
+	defer cleanup()
+
+	_ = os.Setenv("CTF_CONFIGS", migratedPaths)
 
 	in, loadErr := framework.Load[Config](nil)
 	if loadErr != nil {
@@ -138,6 +354,10 @@ func (c *Config) Load(absPath string) error {
 	}
 
 	for _, nodeSet := range in.NodeSets {
+		if err := nodeSet.ExpandRoleTemplates(); err != nil {
+			return errors.Wrap(err, "failed to expand role templates")
+		}
+
 		if err := nodeSet.ParseChainCapabilities(); err != nil {
 			return errors.Wrap(err, "failed to parse chain capabilities")
 		}
@@ -147,6 +367,16 @@ func (c *Config) Load(absPath string) error {
 		}
 	}
 
+	if in.KeysFile != "" {
+		keysFile, keysErr := cre.LoadKeysFile(in.KeysFile)
+		if keysErr != nil {
+			return errors.Wrap(keysErr, "failed to load keys file")
+		}
+		if err := keysFile.Apply(in.NodeSets); err != nil {
+			return errors.Wrap(err, "failed to apply keys file")
+		}
+	}
+
 	copyExportedFields(c, in)
 	c.loaded = true
 
@@ -168,6 +398,8 @@ func (c *Config) Store(absPath string) error {
 		}
 	}
 
+	c.SchemaVersion = StateSchemaVersion
+
 	framework.L.Info().Msgf("Storing local CRE state file: %s", absPath)
 	return storeLocalArtifact(c, absPath)
 }
@@ -290,6 +522,9 @@ const (
 	ChipIngressStateFilename      = "chip_ingress.toml"
 	BillingStateFilename          = "billing-platform-service.toml"
 	WorkflowRegistryStateFilename = "workflow_registry.toml"
+	ResourcesStateFilename        = "resources.toml"
+	CheckpointStateFilename       = "provisioning_checkpoint.json"
+	ContractCacheStateFilename    = "contract_deployments_cache.json"
 )
 
 func (c *ChipIngressConfig) Store(absPath string) error {
@@ -412,3 +647,30 @@ func MustWorkflowRegistryStateFileAbsPath(relativePathToRepoRoot string) string
 
 	return absPath
 }
+
+func MustResourcesStateFileAbsPath(relativePathToRepoRoot string) string {
+	absPath, err := filepath.Abs(filepath.Join(relativePathToRepoRoot, StateDirname, ResourcesStateFilename))
+	if err != nil {
+		panic(fmt.Errorf("failed to get absolute path for local CRE state file: %w", err))
+	}
+
+	return absPath
+}
+
+func MustContractCacheStateFileAbsPath(relativePathToRepoRoot string) string {
+	absPath, err := filepath.Abs(filepath.Join(relativePathToRepoRoot, StateDirname, ContractCacheStateFilename))
+	if err != nil {
+		panic(fmt.Errorf("failed to get absolute path for local CRE state file: %w", err))
+	}
+
+	return absPath
+}
+
+func MustCheckpointStateFileAbsPath(relativePathToRepoRoot string) string {
+	absPath, err := filepath.Abs(filepath.Join(relativePathToRepoRoot, StateDirname, CheckpointStateFilename))
+	if err != nil {
+		panic(fmt.Errorf("failed to get absolute path for local CRE state file: %w", err))
+	}
+
+	return absPath
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
+)
+
+func TestResolveContractVersions(t *testing.T) {
+	workflowRegistry := keystone_changeset.WorkflowRegistry.String()
+	capabilitiesRegistry := keystone_changeset.CapabilitiesRegistry.String()
+
+	t.Run("without pinned versions defaults are deployed", func(t *testing.T) {
+		for _, withV2Registries := range []bool{false, true} {
+			versions := ResolveContractVersions(withV2Registries, nil)
+			assert.Equal(t, DefaultContractSet(withV2Registries), versions)
+			require.NoError(t, ValidateContractVersions(versions, withV2Registries))
+		}
+	})
+
+	t.Run("pinned versions take precedence over defaults", func(t *testing.T) {
+		pinned := map[string]string{workflowRegistry: "1.0.0", capabilitiesRegistry: "1.1.0"}
+		flags := map[string]string{workflowRegistry: WorkflowRegistryV2Semver, capabilitiesRegistry: ""}
+		versions := ResolveContractVersions(false, flags, pinned)
+
+		assert.Equal(t, "1.0.0", versions[workflowRegistry])
+		assert.Equal(t, "1.1.0", versions[capabilitiesRegistry])
+		assert.Equal(t, "1.0.0", versions[keystone_changeset.KeystoneForwarder.String()], "contracts, which aren't pinned, get defaults")
+		assert.Equal(t, WorkflowRegistryV2Semver, flags[workflowRegistry], "pins aren't modified")
+		require.NoError(t, ValidateContractVersions(versions, false))
+	})
+
+	t.Run("blank pins are ignored", func(t *testing.T) {
+		versions := ResolveContractVersions(true, map[string]string{workflowRegistry: ""})
+		assert.Equal(t, WorkflowRegistryV2Semver, versions[workflowRegistry])
+	})
+}
+
+func TestValidateContractVersions(t *testing.T) {
+	workflowRegistry := keystone_changeset.WorkflowRegistry.String()
+
+	versions := ResolveContractVersions(true, map[string]string{workflowRegistry: "1.0.0"})
+	require.ErrorContains(t, ValidateContractVersions(versions, true), "doesn't match the registries version selected with the V2 registries flag")
+
+	versions = ResolveContractVersions(false, map[string]string{workflowRegistry: "0.9.0"})
+	require.ErrorContains(t, ValidateContractVersions(versions, false), "can't be deployed, available versions are: 1.0.0, 2.0.0")
+
+	versions = DefaultContractSet(false)
+	delete(versions, keystone_changeset.OCR3Capability.String())
+	require.ErrorContains(t, ValidateContractVersions(versions, false), "not configured for deployment")
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/tomlschema"
+)
+
+// JSONSchema returns the JSON Schema of the environment configuration, generated from Config and its toml tags.
+// Editors can use it to validate configs while they are written, e.g. with a '#:schema ./config.schema.json' directive.
+func JSONSchema() ([]byte, error) {
+	return tomlschema.GenerateJSON(&Config{}, "CRE environment configuration")
+}
+
+// CheckFiles decodes configuration files strictly, like Load does, but each one separately and without applying them,
+// so that CI can reject configs with unknown keys (e.g. a typo in binary_path) before the environment is started.
+// All unknown keys are returned at once with their positions. Values of free-form tables, e.g. config of capabilities,
+// are not checked.
+func CheckFiles(paths ...string) error {
+	var problems []string
+	for _, path := range paths {
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return errors.Wrapf(readErr, "failed to read %s", path)
+		}
+		migrated, _, migrateErr := stateMigrator.MigrateTOML(content)
+		if migrateErr != nil {
+			return errors.Wrapf(migrateErr, "failed to migrate %s", path)
+		}
+
+		decoder := toml.NewDecoder(bytes.NewReader(migrated))
+		decoder.DisallowUnknownFields()
+		decodeErr := decoder.Decode(&Config{})
+		if decodeErr == nil {
+			continue
+		}
+
+		var strictErr *toml.StrictMissingError
+		if !errors.As(decodeErr, &strictErr) {
+			var tomlErr *toml.DecodeError
+			if errors.As(decodeErr, &tomlErr) {
+				row, column := tomlErr.Position()
+				problems = append(problems, fmt.Sprintf("%s:%d:%d: %s", path, row, column, tomlErr.Error()))
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("%s: %s", path, decodeErr))
+			continue
+		}
+		for _, unknown := range strictErr.Errors {
+			row, column := unknown.Position()
+			problems = append(problems, fmt.Sprintf("%s:%d:%d: unknown key '%s'", path, row, column, strings.Join(unknown.Key(), ".")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("configuration is invalid:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/schema"
+)
+
+// StateSchemaVersion is the version of the local CRE state file format. Bump it and add a migration to
+// StateMigrations, whenever a change to Config would make older state files unreadable (renamed or moved fields, changed types).
+const StateSchemaVersion = 1
+
+var StateMigrations = []schema.Migration{
+	{From: 0, Description: "add schema version to state files written before versioning"},
+}
+
+var stateMigrator = &schema.Migrator{
+	Name:       "local CRE state file",
+	Current:    StateSchemaVersion,
+	Migrations: StateMigrations,
+}
+
+// migrateConfigFiles migrates all comma-separated config files to the current schema version. Files that had to be
+// changed are written to temporary files next to the originals (originals stay untouched), so returned paths
+// should be used for loading and cleanup must be called afterwards.
+func migrateConfigFiles(absPaths string) (string, func(), error) {
+	var tempFiles []string
+	cleanup := func() {
+		for _, tempFile := range tempFiles {
+			_ = os.Remove(tempFile)
+		}
+	}
+
+	paths := strings.Split(absPaths, ",")
+	for idx, path := range paths {
+		path = strings.TrimSpace(path)
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			// let the framework report missing files
+			continue
+		}
+
+		migrated, applied, migrateErr := stateMigrator.MigrateTOML(content)
+		if migrateErr != nil {
+			cleanup()
+			return "", func() {}, errors.Wrapf(migrateErr, "failed to migrate %s", path)
+		}
+		if len(applied) > 0 {
+			framework.L.Debug().Msgf("Migrated %s: %s", path, strings.Join(applied, ", "))
+		}
+		if string(migrated) == string(content) {
+			continue
+		}
+
+		tempFile, tempErr := os.CreateTemp(filepath.Dir(path), ".migrated-*-"+filepath.Base(path))
+		if tempErr != nil {
+			cleanup()
+			return "", func() {}, errors.Wrapf(tempErr, "failed to create file for migrated %s", path)
+		}
+		tempFiles = append(tempFiles, tempFile.Name())
+		_, writeErr := tempFile.Write(migrated)
+		_ = tempFile.Close()
+		if writeErr != nil {
+			cleanup()
+			return "", func() {}, errors.Wrapf(writeErr, "failed to write migrated %s", path)
+		}
+		paths[idx] = tempFile.Name()
+	}
+
+	return strings.Join(paths, ","), cleanup, nil
+}
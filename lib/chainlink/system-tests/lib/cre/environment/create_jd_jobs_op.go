@@ -11,6 +11,7 @@ import (
 
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/golden"
 )
 
 type CreateJobsWithJdOpDeps struct {
@@ -22,6 +23,7 @@ type CreateJobsWithJdOpDeps struct {
 	Dons                      *cre.Dons
 	CapabilitiesAwareNodeSets []*cre.CapabilitiesAwareNodeSet
 	Capabilities              []cre.InstallableCapability
+	Snapshot                  *golden.Snapshot // optional, records generated job specs
 }
 
 type CreateJobsWithJdOpInput struct {
@@ -55,6 +57,10 @@ func CreateJobsWithJdOpFactory(id string, version string) *operations.Operation[
 						return CreateJobsWithJdOpOutput{}, pkgerrors.Wrap(jobSpecsErr, "failed to generate job specs")
 					}
 
+					if deps.Snapshot != nil {
+						deps.Snapshot.AddJobSpecs(don, jobSpecs)
+					}
+
 					createErr := jobs.Create(b.GetContext(), deps.CreEnvironment.CldfEnvironment.Offchain, deps.Dons, jobSpecs)
 					if createErr != nil {
 						return CreateJobsWithJdOpOutput{}, pkgerrors.Wrapf(createErr, "failed to create jobs for DON %d", don.ID)
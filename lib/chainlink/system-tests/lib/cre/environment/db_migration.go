@@ -0,0 +1,235 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	dc "github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	crecapabilities "github.com/smartcontractkit/chainlink/system-tests/lib/cre/capabilities"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/nodedb"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// UpgradeNodeSet recreates all nodes of the nodeset (and its Postgres) with new images, but keeps their volumes, so that
+// the new version starts on the populated databases and runs its migrations. images maps node index to the image,
+// nodes without an entry run defaultImage, which makes it possible to upgrade only some nodes. Nodes listen on the same
+// ports and keep their keys and sessions, so the DON and its clients can be used as before. Only Docker is supported.
+func UpgradeNodeSet(ctx context.Context, lggr zerolog.Logger, nodeSet *cre.CapabilitiesAwareNodeSet, don *cre.Don, registryChainBlockchainOutput *blockchain.Output, defaultImage string, images map[int]string) (*ns.Output, error) {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return nil, pkgerrors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	containers := []string{nodedb.PostgresContainerName(nodeSet.Name)}
+	for nodeIdx := range nodeSet.NodeSpecs {
+		containers = append(containers, fmt.Sprintf("%s-node%d", nodeSet.Name, nodeIdx))
+	}
+	for _, containerName := range containers {
+		if safeErr := infra.CheckSafeDockerContainer(ctx, containerName); safeErr != nil && !errdefs.IsNotFound(safeErr) {
+			return nil, safeErr
+		}
+		// volumes are kept, they hold databases of nodes
+		removeErr := dockerClient.ContainerRemove(ctx, containerName, container.RemoveOptions{Force: true, RemoveVolumes: false})
+		if removeErr != nil && !errdefs.IsNotFound(removeErr) {
+			return nil, pkgerrors.Wrapf(removeErr, "failed to remove container %s", containerName)
+		}
+	}
+
+	for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+		image := defaultImage
+		if nodeImage, ok := images[nodeIdx]; ok {
+			image = nodeImage
+		}
+		if image != "" {
+			nodeSpec.Node.Image = image
+		}
+	}
+	lggr.Info().Msgf("Upgrading nodes of nodeset %s to %s", nodeSet.Name, strings.Join(nodeSetImages(nodeSet), ", "))
+
+	nodeSet.Out = nil
+	nodeSetOutput, nodeSetErr := ns.NewSharedDBNodeSet(nodeSet.Input, registryChainBlockchainOutput)
+	if nodeSetErr != nil {
+		return nil, pkgerrors.Wrapf(nodeSetErr, "failed to start upgraded nodeset %s", nodeSet.Name)
+	}
+
+	for nodeIdx, node := range don.Nodes {
+		ipAddress, aliasErr := infra.AddDockerNetworkAliases(ctx, nodeSetOutput.CLNodes[nodeIdx].Node.ContainerName, node.Alias)
+		if aliasErr != nil {
+			return nil, pkgerrors.Wrapf(aliasErr, "failed to add network alias to node %d of nodeset %s", nodeIdx, nodeSet.Name)
+		}
+		nodeSetOutput.CLNodes[nodeIdx].Node.InternalIP = ipAddress
+	}
+	if readyErr := waitForNodesReady(ctx, nodeSet.Name, nodeSetOutput.CLNodes, nil); readyErr != nil {
+		return nil, readyErr
+	}
+	// capability configs aren't known here, copies are reported without their capabilities
+	if chownErr := chownCapabilityBinaries(ctx, crecapabilities.NewBinaryPreparer(0, crecapabilities.LogBinaryProgress(lggr)), nodeSet, nodeSetOutput.CLNodes, nil); chownErr != nil {
+		return nil, pkgerrors.Wrapf(chownErr, "failed to set owner of capability binaries of nodeset %s", nodeSet.Name)
+	}
+
+	return nodeSetOutput, nil
+}
+
+func nodeSetImages(nodeSet *cre.CapabilitiesAwareNodeSet) []string {
+	var images []string
+	for _, nodeSpec := range nodeSet.NodeSpecs {
+		if !slices.Contains(images, nodeSpec.Node.Image) {
+			images = append(images, nodeSpec.Node.Image)
+		}
+	}
+
+	return images
+}
+
+// NodePersistedState is what a node has to keep across upgrades: its jobs (capabilities, OCR, workflow registry syncer)
+// and workflows it synced from the workflow registry
+type NodePersistedState struct {
+	Jobs      []string // job names
+	Workflows []string // workflow IDs
+}
+
+// PersistedState is the persisted state of all nodes of a DON keyed by node name
+type PersistedState map[string]*NodePersistedState
+
+// CapturePersistedState reads jobs of nodes through their API and workflow specs from their databases
+func CapturePersistedState(ctx context.Context, don *cre.Don, nodeSet *cre.CapabilitiesAwareNodeSet) (PersistedState, error) {
+	state := make(PersistedState, len(don.Nodes))
+	for _, node := range don.Nodes {
+		nodeState := &NodePersistedState{}
+
+		jobs, _, jobsErr := node.Clients.RestClient.ReadJobs()
+		if jobsErr != nil {
+			return nil, pkgerrors.Wrapf(jobsErr, "failed to read jobs of node %s", node.Name)
+		}
+		for _, job := range jobs.Data {
+			if attributes, ok := job["attributes"].(map[string]any); ok {
+				nodeState.Jobs = append(nodeState.Jobs, fmt.Sprint(attributes["name"]))
+			}
+		}
+		slices.Sort(nodeState.Jobs)
+
+		db, dbErr := nodedb.Open(ctx, node.Index, nodeSet.DbInput.Port)
+		if dbErr != nil {
+			return nil, dbErr
+		}
+		specs, specsErr := db.WorkflowSpecs(ctx)
+		_ = db.Close()
+		if specsErr != nil {
+			return nil, pkgerrors.Wrapf(specsErr, "failed to read workflow specs of node %s", node.Name)
+		}
+		for _, spec := range specs {
+			nodeState.Workflows = append(nodeState.Workflows, spec.WorkflowID)
+		}
+		slices.Sort(nodeState.Workflows)
+
+		state[node.Name] = nodeState
+	}
+
+	return state, nil
+}
+
+// Missing returns jobs and workflows, which were present in the state, but are missing in the later one
+func (s PersistedState) Missing(later PersistedState) []string {
+	var missing []string
+	for nodeName, before := range s {
+		after, ok := later[nodeName]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("node %s is missing", nodeName))
+			continue
+		}
+		for _, job := range before.Jobs {
+			if !slices.Contains(after.Jobs, job) {
+				missing = append(missing, fmt.Sprintf("node %s lost job '%s'", nodeName, job))
+			}
+		}
+		for _, workflowID := range before.Workflows {
+			if !slices.Contains(after.Workflows, workflowID) {
+				missing = append(missing, fmt.Sprintf("node %s lost workflow %s", nodeName, workflowID))
+			}
+		}
+	}
+	slices.Sort(missing)
+
+	return missing
+}
+
+// DBMigrationCheck upgrades a running DON from the version it runs to ToImage and checks that its persisted state
+// survived the migration. Snapshots (e.g. taken with cre.SnapshotNodeDatabase in a run of the old version with more
+// history) are restored to nodes before the upgrade, so that migrations run on realistic data.
+type DBMigrationCheck struct {
+	Provider         infra.Provider
+	NodeSet          *cre.CapabilitiesAwareNodeSet
+	Don              *cre.Don
+	BlockchainOutput *blockchain.Output // of the registry chain
+	ToImage          string
+	NodeImages       map[int]string // optional, node index -> image, for upgrading nodes to different versions
+	Snapshots        []*nodedb.Snapshot
+	// optional, run after the upgrade, e.g. to assert that workflows still execute
+	Verify func(ctx context.Context) error
+}
+
+// Run performs the upgrade and returns an error listing all jobs and workflows lost in the migration
+func (c *DBMigrationCheck) Run(ctx context.Context, lggr zerolog.Logger) error {
+	if !c.Provider.IsDocker() {
+		return fmt.Errorf("database migration checks are supported only with Docker, not %s", c.Provider.Type)
+	}
+
+	for _, snapshot := range c.Snapshots {
+		if snapshot.NodeSet != c.NodeSet.Name {
+			return fmt.Errorf("snapshot of nodeset %s can't be restored to nodeset %s", snapshot.NodeSet, c.NodeSet.Name)
+		}
+		if err := c.Provider.StopNode(ctx, snapshot.NodeIndex, c.NodeSet.Name); err != nil {
+			return pkgerrors.Wrapf(err, "failed to stop node %d before restoring its database", snapshot.NodeIndex)
+		}
+		if err := nodedb.Restore(ctx, snapshot); err != nil {
+			return err
+		}
+		if err := c.Provider.StartNode(ctx, snapshot.NodeIndex, c.NodeSet.Name); err != nil {
+			return pkgerrors.Wrapf(err, "failed to start node %d after restoring its database", snapshot.NodeIndex)
+		}
+	}
+	if len(c.Snapshots) > 0 {
+		if err := waitForNodesReady(ctx, c.NodeSet.Name, c.NodeSet.Out.CLNodes, nil); err != nil {
+			return err
+		}
+	}
+
+	before, beforeErr := CapturePersistedState(ctx, c.Don, c.NodeSet)
+	if beforeErr != nil {
+		return pkgerrors.Wrap(beforeErr, "failed to capture state before the upgrade")
+	}
+
+	fromImages := nodeSetImages(c.NodeSet)
+	if _, err := UpgradeNodeSet(ctx, lggr, c.NodeSet, c.Don, c.BlockchainOutput, c.ToImage, c.NodeImages); err != nil {
+		return err
+	}
+
+	after, afterErr := CapturePersistedState(ctx, c.Don, c.NodeSet)
+	if afterErr != nil {
+		return pkgerrors.Wrap(afterErr, "failed to capture state after the upgrade")
+	}
+	if missing := before.Missing(after); len(missing) > 0 {
+		return fmt.Errorf("state of nodeset %s didn't survive upgrade from %s to %s:\n%s", c.NodeSet.Name,
+			strings.Join(fromImages, ", "), strings.Join(nodeSetImages(c.NodeSet), ", "), strings.Join(missing, "\n"))
+	}
+
+	if c.Verify != nil {
+		if err := c.Verify(ctx); err != nil {
+			return pkgerrors.Wrap(err, "verification after the upgrade failed")
+		}
+	}
+
+	return nil
+}
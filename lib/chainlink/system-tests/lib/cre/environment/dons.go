@@ -3,7 +3,10 @@ package environment
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"path"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -14,6 +17,7 @@ import (
 	chainselectors "github.com/smartcontractkit/chain-selectors"
 
 	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
 	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
 
 	ctfconfig "github.com/smartcontractkit/chainlink-testing-framework/lib/config"
@@ -22,6 +26,7 @@ import (
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/crib"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains/solana"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/features/sets"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/flags"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
 )
@@ -58,6 +63,10 @@ func StartDONs(
 	capabilityConfigs cre.CapabilityConfigs,
 	copyCapabilityBinaries bool,
 	capabilitiesAwareNodeSets []*cre.CapabilitiesAwareNodeSet,
+	maxConcurrentDONStarts int, // 0 means no limit
+	maxConcurrentBinaries int, // 0 means crecapabilities.DefaultMaxConcurrentBinaries
+	binaryCache *crecapabilities.BinaryCache, // optional, skips copies of binaries nodes already have
+	phaseTimeouts *cre.PhaseTimeouts,
 ) (*StartedDONs, error) {
 	if infraInput.Type == infra.CRIB {
 		lggr.Info().Msg("Saving node configs and secret overrides")
@@ -75,39 +84,57 @@ func StartDONs(
 		}
 	}
 
-	for donIdx, donMetadata := range topology.DonsMetadata.List() {
-		if !copyCapabilityBinaries {
-			continue
+	topologyConfig := crecapabilities.CapabilitiesConfig{CapabilityConfigs: capabilityConfigs, InfraType: infraInput.Type}
+	if err := crecapabilities.ValidateTopology(topology, topologyConfig); err != nil {
+		return nil, err
+	}
+
+	binaryPreparer := crecapabilities.NewBinaryPreparer(maxConcurrentBinaries, crecapabilities.LogBinaryProgress(lggr))
+	if binaryCache != nil {
+		binaryPreparer.UseCache(binaryCache)
+	}
+	if copyCapabilityBinaries {
+		// binaries of all DONs are prepared at once, so that binaries shared by DONs are verified only once
+		var binariesToPrepare []crecapabilities.Binary
+		donBinariesPaths := make([]map[cre.CapabilityFlag]string, len(topology.DonsMetadata.List()))
+		for donIdx, donMetadata := range topology.DonsMetadata.List() {
+			customBinariesPaths := crecapabilities.DONBinariesPaths(donMetadata.Flags, capabilityConfigs)
+			donBinariesPaths[donIdx] = customBinariesPaths
+			binariesToPrepare = append(binariesToPrepare, crecapabilities.BinariesToPrepare(customBinariesPaths, capabilityConfigs)...)
 		}
 
-		customBinariesPaths := make(map[cre.CapabilityFlag]string)
-		for flag, config := range capabilityConfigs {
-			if flags.HasFlagForAnyChain(donMetadata.Flags, flag) && config.BinaryPath != "" {
-				customBinariesPaths[flag] = config.BinaryPath
-			}
+		if err := binaryPreparer.Prepare(ctx, binariesToPrepare); err != nil {
+			return nil, pkgerrors.Wrap(err, "failed to make binaries executable")
 		}
 
-		executableErr := crecapabilities.MakeBinariesExecutable(customBinariesPaths)
-		if executableErr != nil {
-			return nil, pkgerrors.Wrap(executableErr, "failed to make binaries executable")
+		for donIdx, donMetadata := range topology.DonsMetadata.List() {
+			// binaries, which couldn't be made executable in place, are installed from the staging directory
+			ns, err := crecapabilities.AppendBinariesPathsNodeSpecWithPreparer(ctx, binaryPreparer, capabilitiesAwareNodeSets[donIdx], donMetadata, binaryPreparer.PreparedPaths(donBinariesPaths[donIdx]), capabilityConfigs, nil)
+			if err != nil {
+				return nil, pkgerrors.Wrapf(err, "failed to append binaries paths to node spec for DON %d", donMetadata.ID)
+			}
+			capabilitiesAwareNodeSets[donIdx] = ns
 		}
+	}
 
-		var err error
-		ns, err := crecapabilities.AppendBinariesPathsNodeSpec(capabilitiesAwareNodeSets[donIdx], donMetadata, customBinariesPaths)
-		if err != nil {
-			return nil, pkgerrors.Wrapf(err, "failed to append binaries paths to node spec for DON %d", donMetadata.ID)
+	for _, nodeSet := range capabilitiesAwareNodeSets {
+		if err := crecapabilities.ValidateNodeSet(nodeSet, infraInput.Type, capabilityConfigs); err != nil {
+			return nil, err
 		}
-		capabilitiesAwareNodeSets[donIdx] = ns
 	}
 
 	// Add env vars, which were provided programmatically, to the node specs
-	// or fail, if node specs already had some env vars set in the TOML config
+	// or fail, if node specs already had some env vars set in the TOML config.
+	// Node specs of nodesets started before already have them, together with env vars of locales and engine settings.
 	for donIdx, donMetadata := range topology.DonsMetadata.List() {
 		hasEnvVarsInTomlConfig := false
 		for nodeIdx, nodeSpec := range capabilitiesAwareNodeSets[donIdx].NodeSpecs {
 			if len(nodeSpec.Node.EnvVars) > 0 {
-				hasEnvVarsInTomlConfig = true
-				break
+				if !hasEnvVars(nodeSpec.Node.EnvVars, capabilitiesAwareNodeSets[donIdx].EnvVars) {
+					hasEnvVarsInTomlConfig = true
+					break
+				}
+				continue
 			}
 
 			capabilitiesAwareNodeSets[donIdx].NodeSpecs[nodeIdx].Node.EnvVars = capabilitiesAwareNodeSets[donIdx].EnvVars
@@ -116,6 +143,11 @@ func StartDONs(
 		if hasEnvVarsInTomlConfig && len(capabilitiesAwareNodeSets[donIdx].EnvVars) > 0 {
 			return nil, fmt.Errorf("extra env vars for Chainlink Nodes are provided in the TOML config for the %s DON, but you tried to provide them programatically. Please set them only in one place", donMetadata.Name)
 		}
+
+		capabilitiesAwareNodeSets[donIdx].ApplyLocales()
+		if err := capabilitiesAwareNodeSets[donIdx].ApplyEngineSettings(); err != nil {
+			return nil, pkgerrors.Wrapf(err, "failed to apply engine settings to %s DON", donMetadata.Name)
+		}
 	}
 
 	// Hack for CI that allows us to dynamically set the chainlink image and version
@@ -134,7 +166,38 @@ func StartDONs(
 		}
 	}
 
+	if infraInput.IsDocker() {
+		if err := pullNodeImages(ctx, lggr, capabilitiesAwareNodeSets, phaseTimeouts); err != nil {
+			return nil, err
+		}
+		for _, nodeSet := range capabilitiesAwareNodeSets {
+			if err := validateNodeConfigs(ctx, lggr, nodeSet); err != nil {
+				return nil, err
+			}
+		}
+		// images exist once pulled, so capabilities they ship are checked against TOML before any node is started
+		sets.RegisterImagePlugins()
+		images, discoverErr := crecapabilities.DiscoverImageCapabilities(ctx, infraInput, topology)
+		if discoverErr != nil {
+			return nil, pkgerrors.Wrap(discoverErr, "failed to discover capabilities of node images")
+		}
+		if err := crecapabilities.CheckImageCapabilities(topology, capabilityConfigs, images); err != nil {
+			return nil, err
+		}
+		if copyCapabilityBinaries {
+			if err := binaryPreparer.StageInDockerVolumes(ctx, capabilitiesAwareNodeSets, capabilityConfigs); err != nil {
+				return nil, pkgerrors.Wrap(err, "failed to stage capability binaries in volumes of nodes")
+			}
+		}
+	}
+
 	errGroup, _ := errgroup.WithContext(ctx)
+	if maxConcurrentDONStarts > 0 {
+		// all nodes of a DON are started at once, so with large topologies starting all DONs in parallel
+		// could exhaust CPU and memory of the host, which makes nodes fail their health checks
+		lggr.Info().Msgf("Starting DONs in batches of %d", maxConcurrentDONStarts)
+		errGroup.SetLimit(maxConcurrentDONStarts)
+	}
 	var resultMap sync.Map
 
 	for idx, nodeSetInput := range capabilitiesAwareNodeSets {
@@ -146,7 +209,34 @@ func StartDONs(
 				return pkgerrors.Wrapf(nodesetErr, "failed to start nodeSet named %s", nodeSetInput.Name)
 			}
 
-			don, donErr := cre.NewDON(ctx, topology.DonsMetadata.List()[idx], nodeset.CLNodes)
+			donMetadata := topology.DonsMetadata.List()[idx]
+			if infraInput.IsDocker() {
+				for nodeIdx, nodeMetadata := range donMetadata.NodesMetadata {
+					// the node is restarted and may get another IP, multi addresses of bootstrap nodes are built from it
+					ipAddress, aliasErr := infra.AddDockerNetworkAliases(ctx, nodeset.CLNodes[nodeIdx].Node.ContainerName, nodeMetadata.Alias)
+					if aliasErr != nil {
+						return pkgerrors.Wrapf(aliasErr, "failed to add network alias to node %d of nodeSet named %s", nodeIdx, nodeSetInput.Name)
+					}
+					nodeset.CLNodes[nodeIdx].Node.InternalIP = ipAddress
+				}
+			}
+
+			if readyErr := waitForNodesReady(ctx, nodeSetInput.Name, nodeset.CLNodes, phaseTimeouts); readyErr != nil {
+				return readyErr
+			}
+
+			if infraInput.IsDocker() {
+				if chownErr := chownCapabilityBinaries(ctx, binaryPreparer, nodeSetInput, nodeset.CLNodes, capabilityConfigs); chownErr != nil {
+					return pkgerrors.Wrapf(chownErr, "failed to set owner of capability binaries of nodeSet named %s", nodeSetInput.Name)
+				}
+			}
+			if infraInput.IsKubernetes() || (infraInput.IsCRIB() && copyCapabilityBinaries) {
+				if copyErr := copyCapabilityBinariesToPods(ctx, binaryPreparer, infraInput, nodeSetInput, capabilityConfigs); copyErr != nil {
+					return pkgerrors.Wrapf(copyErr, "failed to copy capability binaries to pods of nodeSet named %s", nodeSetInput.Name)
+				}
+			}
+
+			don, donErr := cre.NewDON(ctx, donMetadata, nodeset.CLNodes)
 			if donErr != nil {
 				return pkgerrors.Wrapf(donErr, "failed to create DON from node set named %s", nodeSetInput.Name)
 			}
@@ -171,6 +261,11 @@ func StartDONs(
 		return nil, err
 	}
 
+	if binaryCache != nil {
+		stats := binaryCache.Stats()
+		lggr.Info().Msgf("Binary cache: %d hits (%.1f MiB skipped), %d misses (%.1f MiB copied)", stats.Hits, float64(stats.BytesSkipped)/(1<<20), stats.Misses, float64(stats.BytesCopied)/(1<<20))
+	}
+
 	startedDONs := make(StartedDONs, len(capabilitiesAwareNodeSets))
 	resultMap.Range(func(key, value any) bool {
 		// key is index in the original slice
@@ -181,6 +276,147 @@ func StartDONs(
 	return &startedDONs, nil
 }
 
+// chownCapabilityBinaries copies capability binaries to nodes again, owned by the configured user. Binaries are executed
+// only once capability jobs are created, so replacing them after the node has started is safe.
+func chownCapabilityBinaries(ctx context.Context, binaryPreparer *crecapabilities.BinaryPreparer, nodeSet *cre.CapabilitiesAwareNodeSet, nodes []*clnode.Output, capabilityConfigs cre.CapabilityConfigs) error {
+	uid, gid, ok, ownerErr := nodeSet.CapabilityBinariesOwnerIDs()
+	if ownerErr != nil || !ok {
+		return ownerErr
+	}
+
+	var copies []crecapabilities.BinaryCopy
+	for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+		containerDir := nodeSpec.Node.CapabilityContainerDir
+		if containerDir == "" {
+			containerDir = clnode.DefaultCapabilitiesDir
+		}
+		containerName := nodes[nodeIdx].Node.ContainerName
+		executor := &infra.DockerExecutor{ContainerName: containerName}
+		for _, binaryPath := range nodeSpec.Node.CapabilitiesBinaryPaths {
+			copies = append(copies, crecapabilities.BinaryCopy{
+				Path:       binaryPath,
+				Capability: crecapabilities.BinaryCapability(binaryPath, capabilityConfigs),
+				Target:     fmt.Sprintf("node %d of nodeset %s", nodeIdx, nodeSet.Name),
+				Copy: func(ctx context.Context) error {
+					return infra.CopyFileToDockerContainer(ctx, containerName, binaryPath, containerDir, uid, gid)
+				},
+				// binaries copied by CTF have the same content, but are owned by root
+				RemoteDigest: func(ctx context.Context) (string, error) {
+					digest, owner, err := infra.FileDigest(ctx, executor, path.Join(containerDir, filepath.Base(binaryPath)))
+					if owner != fmt.Sprintf("%d:%d", uid, gid) {
+						return "", err
+					}
+					return digest, err
+				},
+			})
+		}
+	}
+
+	return binaryPreparer.Copy(ctx, copies)
+}
+
+// copyCapabilityBinariesToPods copies capability binaries to pods of nodes deployed to a Kubernetes cluster or CRIB. Nodes
+// have to be deployed beforehand, so binaries are copied to running pods, which is safe, because binaries are executed
+// only once capability jobs are created. With a binary cache binaries are staged on the persistent volume of the node's
+// home directory, see BinaryPreparer.PodBinaryCopy, otherwise pods recreated later lose them.
+func copyCapabilityBinariesToPods(ctx context.Context, binaryPreparer *crecapabilities.BinaryPreparer, provider infra.Provider, nodeSet *cre.CapabilitiesAwareNodeSet, capabilityConfigs cre.CapabilityConfigs) error {
+	containerDir, err := crecapabilities.DefaultContainerDirectory(provider.Type)
+	if err != nil {
+		return err
+	}
+
+	var copies []crecapabilities.BinaryCopy
+	for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+		if len(nodeSpec.Node.CapabilitiesBinaryPaths) == 0 {
+			continue
+		}
+		executor, ok := provider.NodeExecutor(nodeIdx, nodeSet.Name).(*infra.KubernetesExecutor)
+		if !ok {
+			return fmt.Errorf("node %d of nodeset %s doesn't run in Kubernetes", nodeIdx, nodeSet.Name)
+		}
+		for _, binaryPath := range nodeSpec.Node.CapabilitiesBinaryPaths {
+			binaryCopy, copyErr := binaryPreparer.PodBinaryCopy(executor, binaryPath, containerDir)
+			if copyErr != nil {
+				return copyErr
+			}
+			binaryCopy.Capability = crecapabilities.BinaryCapability(binaryPath, capabilityConfigs)
+			binaryCopy.Target = fmt.Sprintf("node %d of nodeset %s", nodeIdx, nodeSet.Name)
+			copies = append(copies, binaryCopy)
+		}
+	}
+
+	return binaryPreparer.Copy(ctx, copies)
+}
+
+// pullNodeImages pulls images of all nodes, which aren't built locally, before any node is started
+func pullNodeImages(ctx context.Context, lggr zerolog.Logger, nodeSets []*cre.CapabilitiesAwareNodeSet, phaseTimeouts *cre.PhaseTimeouts) error {
+	imageNodes := make(map[string]string) // image -> first node using it, used in timeout errors
+	images := make([]string, 0)
+	for _, nodeSet := range nodeSets {
+		for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+			if nodeSpec.Node.Image == "" || nodeSpec.Node.DockerContext != "" {
+				continue
+			}
+			if _, ok := imageNodes[nodeSpec.Node.Image]; !ok {
+				imageNodes[nodeSpec.Node.Image] = fmt.Sprintf("node %d of nodeset %s (image %s)", nodeIdx, nodeSet.Name, nodeSpec.Node.Image)
+				images = append(images, nodeSpec.Node.Image)
+			}
+		}
+	}
+
+	for _, image := range images {
+		lggr.Info().Msgf("Pulling image %s", image)
+		if err := cre.RunPhase(ctx, phaseTimeouts, cre.PhaseImagePull, func(ctx context.Context) error {
+			return cre.PhaseTarget(imageNodes[image], infra.PullDockerImage(ctx, image))
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasEnvVars returns true, if envVars contain all expected env vars with the same values
+func hasEnvVars(envVars, expected map[string]string) bool {
+	for key, value := range expected {
+		if current, ok := envVars[key]; !ok || current != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// waitForNodesReady waits until health endpoints of all nodes report healthy (2xx), so that a node that fails to start is named in the error
+func waitForNodesReady(ctx context.Context, nodeSetName string, nodes []*clnode.Output, phaseTimeouts *cre.PhaseTimeouts) error {
+	urls := make(map[string]string, len(nodes))
+	names := make([]string, 0, len(nodes))
+	for idx, node := range nodes {
+		name := fmt.Sprintf("%s-node%d", nodeSetName, idx)
+		urls[name] = node.Node.ExternalURL + "/health"
+		names = append(names, name)
+	}
+
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+
+	return cre.WaitForPhase(ctx, phaseTimeouts, cre.PhaseNodeReadiness, names, func(ctx context.Context, name string) error {
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, urls[name], nil)
+		if reqErr != nil {
+			return reqErr
+		}
+		resp, respErr := httpClient.Do(req)
+		if respErr != nil {
+			return respErr
+		}
+		_ = resp.Body.Close()
+		// the node reports 503 until all of its health checks pass, it isn't ready before that
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("health endpoint returned status %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
+
 func FundNodes(ctx context.Context, testLogger zerolog.Logger, dons *cre.Dons, blockchains []blockchains.Blockchain, fundingAmountPerChainFamily map[string]uint64) error {
 	for _, don := range dons.List() {
 		testLogger.Info().Msgf("Funding nodes for DON %s", don.Name)
@@ -0,0 +1,268 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/nodedb"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// nodeConfigFlags are config and secrets files of nodes started by CTF, commands run in node containers need them
+// to connect to the database
+var nodeConfigFlags = []string{
+	"-c", "/config/config", "-c", "/config/overrides", "-c", "/config/user-overrides",
+	"-s", "/config/secrets", "-s", "/config/secrets-overrides", "-s", "/config/user-secrets-overrides",
+}
+
+// DowngradeCheck rolls selected nodes of a running DON back to an older image, like a release rollback does. Database
+// migrations newer than MigrationVersion are rolled back with the binary the nodes run, because the older one doesn't
+// know them and refuses to start. It fails clearly, if a migration is irreversible, and checks that jobs and workflows
+// of nodes survived the downgrade like DBMigrationCheck does for upgrades. If it fails partway, nodes are restored:
+// their databases are migrated up again with the binary they run, or, if nodes downgraded to ToImage don't start, they
+// are recreated with their original images, which migrate databases on start. Only Docker is supported.
+type DowngradeCheck struct {
+	Provider         infra.Provider
+	NodeSet          *cre.CapabilitiesAwareNodeSet
+	Don              *cre.Don
+	BlockchainOutput *blockchain.Output // of the registry chain
+	ToImage          string
+	// Nodes are indexes of nodes to downgrade, all nodes are downgraded if it's empty
+	Nodes []int
+	// MigrationVersion is the last database migration of ToImage, e.g. read with nodedb.DB.MigrationVersion while nodes ran it
+	MigrationVersion int64
+	// optional, run after the downgrade, e.g. to assert that workflows still execute
+	Verify func(ctx context.Context) error
+}
+
+// Run rolls back databases of the nodes, recreates them with ToImage and returns an error listing all jobs and workflows
+// lost in the downgrade. Errors of failed downgrades say, whether nodes were restored.
+func (c *DowngradeCheck) Run(ctx context.Context, lggr zerolog.Logger) error {
+	if !c.Provider.IsDocker() {
+		return fmt.Errorf("downgrade checks are supported only with Docker, not %s", c.Provider.Type)
+	}
+	if c.ToImage == "" {
+		return pkgerrors.New("image to downgrade to is not set")
+	}
+	if c.MigrationVersion <= 0 {
+		return pkgerrors.New("migration version of the image to downgrade to must be positive")
+	}
+
+	nodeIndexes := c.Nodes
+	if len(nodeIndexes) == 0 {
+		for nodeIdx := range c.NodeSet.NodeSpecs {
+			nodeIndexes = append(nodeIndexes, nodeIdx)
+		}
+	}
+	nodes := make([]*cre.Node, 0, len(nodeIndexes))
+	for _, nodeIdx := range nodeIndexes {
+		nodePos := slices.IndexFunc(c.Don.Nodes, func(node *cre.Node) bool { return node.Index == nodeIdx })
+		if nodePos == -1 {
+			return fmt.Errorf("DON %s has no node with index %d", c.Don.Name, nodeIdx)
+		}
+		nodes = append(nodes, c.Don.Nodes[nodePos])
+	}
+
+	before, beforeErr := CapturePersistedState(ctx, c.Don, c.NodeSet)
+	if beforeErr != nil {
+		return pkgerrors.Wrap(beforeErr, "failed to capture state before the downgrade")
+	}
+
+	fromImages := nodeSetImages(c.NodeSet)
+	if err := downgradeNodes(ctx, &dockerDowngradeSteps{check: c, lggr: lggr}, nodes, c.ToImage, nodeImages(c.NodeSet)); err != nil {
+		return err
+	}
+
+	after, afterErr := CapturePersistedState(ctx, c.Don, c.NodeSet)
+	if afterErr != nil {
+		return pkgerrors.Wrap(afterErr, "failed to capture state after the downgrade")
+	}
+	if missing := before.Missing(after); len(missing) > 0 {
+		return fmt.Errorf("state of nodeset %s didn't survive downgrade from %s to %s:\n%s", c.NodeSet.Name,
+			strings.Join(fromImages, ", "), c.ToImage, strings.Join(missing, "\n"))
+	}
+
+	if c.Verify != nil {
+		if err := c.Verify(ctx); err != nil {
+			return pkgerrors.Wrap(err, "verification after the downgrade failed")
+		}
+	}
+
+	return nil
+}
+
+// downgradeSteps change nodes during the downgrade, see dockerDowngradeSteps
+type downgradeSteps interface {
+	// rollBack rolls the node's database back, it returns false, if there was nothing to roll back
+	rollBack(ctx context.Context, node *cre.Node) (bool, error)
+	// restore migrates the node's database up again with the binary the node runs
+	restore(ctx context.Context, node *cre.Node) error
+	// recreate recreates all nodes of the nodeset, nodes with given indexes get given images
+	recreate(ctx context.Context, images map[int]string) error
+}
+
+// downgradeNodes rolls back databases of nodes one by one and recreates them with toImage. Nodes are never left with a
+// rolled back database and the newer image: if a rollback fails, databases rolled back so far (including the failed
+// one, which may be rolled back partially) are migrated up again, and if nodes don't start with toImage, they are
+// recreated with fromImages (images of all nodes by index).
+func downgradeNodes(ctx context.Context, steps downgradeSteps, nodes []*cre.Node, toImage string, fromImages map[int]string) error {
+	images := make(map[int]string, len(nodes))
+	var rolledBack []*cre.Node
+	for _, node := range nodes {
+		changed, rollBackErr := steps.rollBack(ctx, node)
+		if changed || rollBackErr != nil {
+			rolledBack = append(rolledBack, node)
+		}
+		if rollBackErr != nil {
+			return restoreNodes(ctx, steps, rolledBack, rollBackErr)
+		}
+		images[node.Index] = toImage
+	}
+
+	if recreateErr := steps.recreate(ctx, images); recreateErr != nil {
+		downgradeErr := pkgerrors.Wrapf(recreateErr, "nodes downgraded to %s didn't start, check their logs for database version errors", toImage)
+		if restoreErr := steps.recreate(ctx, fromImages); restoreErr != nil {
+			return fmt.Errorf("%w. Recreating nodes with their original images failed too, recreate the environment: %w", downgradeErr, restoreErr)
+		}
+		return fmt.Errorf("%w. Nodes were recreated with their original images, which migrated their databases again", downgradeErr)
+	}
+
+	return nil
+}
+
+// restoreNodes migrates databases of rolled back nodes up again and returns downgradeErr with the outcome
+func restoreNodes(ctx context.Context, steps downgradeSteps, rolledBack []*cre.Node, downgradeErr error) error {
+	var failed []string
+	for _, node := range rolledBack {
+		if err := steps.restore(ctx, node); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", node.Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w. Restoring databases of nodes failed, they may run the newer image with a rolled back database, recreate the environment:\n%s", downgradeErr, strings.Join(failed, "\n"))
+	}
+	if len(rolledBack) > 0 {
+		return fmt.Errorf("%w. Databases of nodes rolled back so far were migrated up again", downgradeErr)
+	}
+
+	return downgradeErr
+}
+
+// nodeImages returns images of nodes of the nodeset by index
+func nodeImages(nodeSet *cre.CapabilitiesAwareNodeSet) map[int]string {
+	images := make(map[int]string, len(nodeSet.NodeSpecs))
+	for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+		images[nodeIdx] = nodeSpec.Node.Image
+	}
+
+	return images
+}
+
+// dockerDowngradeSteps run migrations in node containers and recreate nodes with UpgradeNodeSet
+type dockerDowngradeSteps struct {
+	check *DowngradeCheck
+	lggr  zerolog.Logger
+}
+
+// rollBack rolls the node's database back to MigrationVersion with 'chainlink node db rollback' run in the node's
+// container, because only its binary has down migrations of versions newer than the ones of ToImage
+func (s *dockerDowngradeSteps) rollBack(ctx context.Context, node *cre.Node) (bool, error) {
+	c := s.check
+	versions, versionsErr := c.migrationVersions(ctx, node)
+	if versionsErr != nil {
+		return false, versionsErr
+	}
+	if len(versions) == 0 {
+		return false, fmt.Errorf("database of node %s has no applied migrations", node.Name)
+	}
+
+	current := versions[len(versions)-1]
+	switch {
+	case current == c.MigrationVersion:
+		s.lggr.Info().Msgf("Database of node %s is at migration %d already, nothing to roll back", node.Name, current)
+		return false, nil
+	case current < c.MigrationVersion:
+		return false, fmt.Errorf("database of node %s is at migration %d, which is older than %d of %s. Use UpgradeNodeSet to upgrade nodes", node.Name, current, c.MigrationVersion, c.ToImage)
+	case !slices.Contains(versions, c.MigrationVersion):
+		return false, fmt.Errorf("migration %d was never applied to database of node %s, it can't be rolled back to it. Applied migrations after it: %s", c.MigrationVersion, node.Name, formatVersions(versions, c.MigrationVersion))
+	}
+
+	s.lggr.Info().Msgf("Rolling back database of node %s from migration %d to %d", node.Name, current, c.MigrationVersion)
+	if err := runNodeDBCommand(ctx, node, "rollback", "--version", strconv.FormatInt(c.MigrationVersion, 10)); err != nil {
+		return true, pkgerrors.Wrapf(err, "failed to roll back database of node %s from migration %d to %d, a migration is probably irreversible", node.Name, current, c.MigrationVersion)
+	}
+
+	rolledBack, rolledBackErr := c.migrationVersions(ctx, node)
+	if rolledBackErr != nil {
+		return true, rolledBackErr
+	}
+	if len(rolledBack) == 0 || rolledBack[len(rolledBack)-1] != c.MigrationVersion {
+		return true, fmt.Errorf("database of node %s wasn't rolled back to migration %d, these migrations are irreversible: %s", node.Name, c.MigrationVersion, formatVersions(rolledBack, c.MigrationVersion))
+	}
+
+	return true, nil
+}
+
+// restore migrates the node's database up with 'chainlink node db migrate' run in the node's container
+func (s *dockerDowngradeSteps) restore(ctx context.Context, node *cre.Node) error {
+	s.lggr.Info().Msgf("Restoring database of node %s, migrating it up again", node.Name)
+	return runNodeDBCommand(ctx, node, "migrate")
+}
+
+func (s *dockerDowngradeSteps) recreate(ctx context.Context, images map[int]string) error {
+	c := s.check
+	_, err := UpgradeNodeSet(ctx, s.lggr, c.NodeSet, c.Don, c.BlockchainOutput, "", images)
+	return err
+}
+
+// runNodeDBCommand runs 'chainlink node db <args>' in the node's container
+func runNodeDBCommand(ctx context.Context, node *cre.Node, args ...string) error {
+	cmd := append([]string{"chainlink"}, nodeConfigFlags...)
+	cmd = append(cmd, "node", "db")
+	cmd = append(cmd, args...)
+	result, execErr := node.Exec(ctx, cmd...)
+	if execErr != nil {
+		return execErr
+	}
+	if !result.Succeeded() {
+		return fmt.Errorf("exit code %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr+"\n"+result.Stdout))
+	}
+
+	return nil
+}
+
+func (c *DowngradeCheck) migrationVersions(ctx context.Context, node *cre.Node) ([]int64, error) {
+	db, dbErr := nodedb.Open(ctx, node.Index, c.NodeSet.DbInput.Port)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	defer db.Close()
+
+	versions, versionsErr := db.MigrationVersions(ctx)
+	if versionsErr != nil {
+		return nil, pkgerrors.Wrapf(versionsErr, "failed to read migration versions of node %s", node.Name)
+	}
+
+	return versions, nil
+}
+
+// formatVersions lists versions newer than the given one
+func formatVersions(versions []int64, newerThan int64) string {
+	var newer []string
+	for _, version := range versions {
+		if version > newerThan {
+			newer = append(newer, strconv.FormatInt(version, 10))
+		}
+	}
+
+	return strings.Join(newer, ", ")
+}
@@ -0,0 +1,97 @@
+package environment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+)
+
+// fakeDowngradeSteps records calls, steps of nodes in failing fail, rollBack of nodes in current has nothing to do
+type fakeDowngradeSteps struct {
+	failing    map[string]bool
+	current    map[string]bool
+	recreateFn func(images map[int]string) error
+	calls      []string
+}
+
+func (f *fakeDowngradeSteps) rollBack(_ context.Context, node *cre.Node) (bool, error) {
+	f.calls = append(f.calls, "rollback "+node.Name)
+	if f.failing[node.Name] {
+		return false, errors.New("irreversible migration")
+	}
+
+	return !f.current[node.Name], nil
+}
+
+func (f *fakeDowngradeSteps) restore(_ context.Context, node *cre.Node) error {
+	f.calls = append(f.calls, "restore "+node.Name)
+	if f.failing["restore "+node.Name] {
+		return errors.New("migration failed")
+	}
+
+	return nil
+}
+
+func (f *fakeDowngradeSteps) recreate(_ context.Context, images map[int]string) error {
+	f.calls = append(f.calls, "recreate "+images[0]+" "+images[1])
+	if f.recreateFn != nil {
+		return f.recreateFn(images)
+	}
+
+	return nil
+}
+
+func downgradeTestNodes() []*cre.Node {
+	return []*cre.Node{{Name: "node0", Index: 0}, {Name: "node1", Index: 1}, {Name: "node2", Index: 2}}
+}
+
+var downgradeFromImages = map[int]string{0: "chainlink:new", 1: "chainlink:new", 2: "chainlink:new"}
+
+func TestDowngradeNodes(t *testing.T) {
+	steps := &fakeDowngradeSteps{current: map[string]bool{"node1": true}}
+	require.NoError(t, downgradeNodes(context.Background(), steps, downgradeTestNodes()[:2], "chainlink:old", downgradeFromImages))
+	assert.Equal(t, []string{"rollback node0", "rollback node1", "recreate chainlink:old chainlink:old"}, steps.calls)
+}
+
+func TestDowngradeNodesRestoresDatabasesWhenRollbackFails(t *testing.T) {
+	steps := &fakeDowngradeSteps{failing: map[string]bool{"node2": true}, current: map[string]bool{"node1": true}}
+	err := downgradeNodes(context.Background(), steps, downgradeTestNodes(), "chainlink:old", downgradeFromImages)
+	require.ErrorContains(t, err, "irreversible migration")
+	require.ErrorContains(t, err, "Databases of nodes rolled back so far were migrated up again")
+	// node1 had nothing to roll back, node2 may be rolled back partially
+	assert.Equal(t, []string{"rollback node0", "rollback node1", "rollback node2", "restore node0", "restore node2"}, steps.calls)
+	assert.NotContains(t, steps.calls, "recreate chainlink:old chainlink:old", "nodes were recreated after a failed rollback")
+
+	steps = &fakeDowngradeSteps{failing: map[string]bool{"node1": true, "restore node0": true}}
+	err = downgradeNodes(context.Background(), steps, downgradeTestNodes(), "chainlink:old", downgradeFromImages)
+	require.ErrorContains(t, err, "Restoring databases of nodes failed")
+	require.ErrorContains(t, err, "node0: migration failed")
+	assert.Equal(t, []string{"rollback node0", "rollback node1", "restore node0", "restore node1"}, steps.calls)
+}
+
+func TestDowngradeNodesRestoresImagesWhenNodesDontStart(t *testing.T) {
+	steps := &fakeDowngradeSteps{recreateFn: func(images map[int]string) error {
+		if images[0] == "chainlink:old" {
+			return errors.New("database version is too new")
+		}
+		return nil
+	}}
+	err := downgradeNodes(context.Background(), steps, downgradeTestNodes()[:2], "chainlink:old", downgradeFromImages)
+	require.ErrorContains(t, err, "nodes downgraded to chainlink:old didn't start")
+	require.ErrorContains(t, err, "Nodes were recreated with their original images")
+	assert.Equal(t, []string{"rollback node0", "rollback node1", "recreate chainlink:old chainlink:old", "recreate chainlink:new chainlink:new"}, steps.calls)
+
+	steps = &fakeDowngradeSteps{recreateFn: func(map[int]string) error { return errors.New("docker is down") }}
+	err = downgradeNodes(context.Background(), steps, downgradeTestNodes()[:2], "chainlink:old", downgradeFromImages)
+	require.ErrorContains(t, err, "Recreating nodes with their original images failed too")
+}
+
+func TestNodeImages(t *testing.T) {
+	nodeSet := fingerprintInput("cron").CapabilitiesAwareNodeSets[0]
+	assert.Equal(t, map[int]string{0: "chainlink:test"}, nodeImages(nodeSet))
+}
@@ -20,6 +20,7 @@ import (
 	libc "github.com/smartcontractkit/chainlink/system-tests/lib/conversions"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
 	envconfig "github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/config"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/schema"
 )
 
 const (
@@ -27,7 +28,22 @@ const (
 	NOPAdminPrefix   = "0xaadd000000000000000000000000000000"
 )
 
+// EnvArtifactSchemaVersion is the version of the environment artifact format. Bump it and add a migration to
+// EnvArtifactMigrations, whenever a change to EnvArtifact would make stored artifacts unreadable.
+const EnvArtifactSchemaVersion = 1
+
+var EnvArtifactMigrations = []schema.Migration{
+	{From: 0, Description: "add schema version to artifacts written before versioning"},
+}
+
+var envArtifactMigrator = &schema.Migrator{
+	Name:       "environment artifact",
+	Current:    EnvArtifactSchemaVersion,
+	Migrations: EnvArtifactMigrations,
+}
+
 type EnvArtifact struct {
+	SchemaVersion         int                                                  `json:"schema_version"`
 	RegistryChainSelector uint64                                               `json:"home_chain_selector"`
 	AddressRefs           []datastore.AddressRef                               `json:"address_refs"`
 	AddressBook           map[uint64]map[string]cldf_deployment.TypeAndVersion `json:"address_book"`
@@ -39,6 +55,9 @@ type EnvArtifact struct {
 	ContractVersions      map[string]string                                    `json:"contract_versions"`
 	CapabilityConfigs     map[cre.CapabilityFlag]cre.CapabilityConfig          `json:"capability_configs"`
 	GatewayConnectors     *cre.GatewayConnectors                               `json:"gateway_connectors,omitempty"`
+	FeatureFlags          *cre.FeatureFlags                                    `json:"feature_flags,omitempty"`
+	Fingerprint           string                                               `json:"fingerprint,omitempty"` // of the environment definition, see Fingerprint
+	Environment           string                                               `json:"environment,omitempty"` // see cre.DefaultEnvironmentName
 }
 
 type NodesArtifact struct {
@@ -53,6 +72,7 @@ type DonArtifact struct {
 	DonName        string                  `json:"don_name"`
 	DonID          uint64                  `json:"don_id"`
 	F              uint8                   `json:"f"`
+	Roles          []cre.DONRole           `json:"roles,omitempty"`
 	BootstrapNodes []string                `json:"bootstrap_nodes"`
 	Capabilities   []DONCapabilityArtifact `json:"capabilities,omitempty"`
 	Nodes          []FullNodeArtifact      `json:"nodes"`
@@ -235,6 +255,7 @@ func GenerateArtifact(
 	}
 
 	artifact := EnvArtifact{
+		SchemaVersion:         EnvArtifactSchemaVersion,
 		RegistryChainSelector: creEnv.RegistryChainSelector,
 		JdConfig:              jdOutput,
 		AddressBook:           addresses,
@@ -246,6 +267,9 @@ func GenerateArtifact(
 		ContractVersions:      creEnv.ContractVersions,
 		CapabilityConfigs:     creEnv.CapabilityConfigs,
 		GatewayConnectors:     dons.GatewayConnectors,
+		FeatureFlags:          creEnv.FeatureFlags,
+		Fingerprint:           creEnv.Fingerprint,
+		Environment:           creEnv.Name,
 	}
 
 	for donIdx, don := range dons.List() {
@@ -253,6 +277,7 @@ func GenerateArtifact(
 			DonName:        don.Name,
 			DonID:          don.ID,
 			F:              0, // F will be calculated based on the number of worker nodes
+			Roles:          don.DONRoles(),
 			BootstrapNodes: make([]string, 0),
 			Nodes:          make([]FullNodeArtifact, 0),
 			Capabilities:   make([]DONCapabilityArtifact, 0),
@@ -361,7 +386,12 @@ func ReadEnvArtifact(absPath string) (*EnvArtifact, error) {
 		return nil, pkgerrors.Wrapf(readErr, "failed to read environment artifact from %s. Make sure that local CRE environment is running", absPath)
 	}
 
-	if err := json.Unmarshal(content, &artifact); err != nil {
+	migrated, _, migrateErr := envArtifactMigrator.MigrateJSON(content)
+	if migrateErr != nil {
+		return nil, pkgerrors.Wrapf(migrateErr, "failed to migrate environment artifact from %s", absPath)
+	}
+
+	if err := json.Unmarshal(migrated, &artifact); err != nil {
 		return nil, pkgerrors.Wrap(err, "failed to unmarshal environment artifact")
 	}
 
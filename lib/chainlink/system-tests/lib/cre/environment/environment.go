@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
 	pkgerrors "github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
@@ -19,21 +23,34 @@ import (
 	focr "github.com/smartcontractkit/chainlink-deployments-framework/offchain/ocr"
 	"github.com/smartcontractkit/chainlink-deployments-framework/operations"
 
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
 	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	chipingressset "github.com/smartcontractkit/chainlink-testing-framework/framework/components/dockercompose/chip_ingress_set"
 	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/jd"
 	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/s3provider"
 
 	"github.com/smartcontractkit/chainlink-testing-framework/lib/utils/ptr"
 	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	crecapabilities "github.com/smartcontractkit/chainlink/system-tests/lib/cre/capabilities"
 	crecontracts "github.com/smartcontractkit/chainlink/system-tests/lib/cre/contracts"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/crib"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/datagen"
 	donconfig "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/config"
 	gateway "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/gateway"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains/evm"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/config"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/stagegen"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/experimental/tracing"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/features/sets"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/flags"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/golden"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/logs"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/metrics"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/notify"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/watchdog"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/workflow"
 	libformat "github.com/smartcontractkit/chainlink/system-tests/lib/format"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
@@ -53,6 +70,16 @@ type SetupOutput struct {
 	NodeOutput                          []*cre.WrappedNodeOutput
 	S3ProviderOutput                    *s3provider.Output
 	GatewayConnectors                   *cre.GatewayConnectors
+	GatewayLoadBalancer                 *infra.GatewayLoadBalancerOutput // set only if a load balancer was requested
+	CustomContainers                    []*infra.CustomContainerOutput
+	HostProcesses                       []*infra.HostProcess   // call Stop() on them at the end of the test
+	MetricsRemoteWriter                 *metrics.RemoteWriter  // set only if remote write was requested, call Stop() on it at the end of the test
+	LeakDetector                        *metrics.LeakDetector  // set only if leak detection was requested, call Stop() on it at the end of the test and check the report
+	DataGenerator                       *datagen.Generator     // set only if a data generator was requested, call Stop() on it at the end of the test
+	Notifier                            *notify.Notifier       // nil if notifications weren't requested (it's safe to use), call Finished() on it at the end of the test
+	Watchdog                            *watchdog.Watchdog     // set only if a watchdog was requested, use its Context() in assertions and call Stop() on it at the end of the test
+	Beholder                            *chipingressset.Output // set only if Beholder was requested
+	Resources                           *infra.ResourceIndex
 }
 
 type SetupInput struct {
@@ -67,11 +94,24 @@ type SetupInput struct {
 	VaultOCR3Config           *keystone_changeset.OracleConfig
 	S3ProviderInput           *s3provider.Input
 	CapabilityConfigs         cre.CapabilityConfigs
+	ChainFinalityConfigs      cre.ChainFinalityConfigs
 	CopyCapabilityBinaries    bool // if true, copy capability binaries to the containers (if false, we assume that the plugins image already has them)
+	MaxConcurrentDONStarts    int  // if > 0, DONs are started in batches of this size, use it with large topologies
+	MaxConcurrentBinaries     int  // of capability binaries prepared or copied at once by all DONs, capabilities.DefaultMaxConcurrentBinaries is used if not set
 	Capabilities              []cre.InstallableCapability
 	Features                  cre.Features
+	JobSpecFactories          *crecapabilities.JobSpecFactories // optional, sets up capabilities enabled in TOML without a feature in Features, sets.JobSpecFactories() is used if not set
 	GatewayWhitelistConfig    gateway.WhitelistConfig
+	GatewayAuthConfig         *gateway.AuthConfig                // optional, authentication options of gateways, defaults are used if not set
+	GatewayLoadBalancer       *infra.GatewayLoadBalancerInput    // optional, puts all gateways behind a load balancer (Docker only)
+	CustomContainers          []*infra.CustomContainerInput      // optional, extra containers started in the Docker network (Docker only)
+	HostProcesses             []*infra.HostProcessInput          // optional, components running as host processes, reachable from Docker containers (Docker only)
+	ReadinessChecks           []cre.ReadinessCheck               // optional, custom checks gating readiness of custom containers, host processes or the whole environment
+	BinaryCache               *crecapabilities.BinaryCache       // optional, skips copies of capability binaries nodes already have, its Stats show hits and misses
+	BinaryCacheConfig         *crecapabilities.BinaryCacheConfig // optional, used if BinaryCache is not set, the cache is enabled by default
+	Tracer                    tracing.Tracer                     // optional, stages of the setup are reported to it, e.g. tracing.StageTimings
 	BlockchainDeployers       map[blockchain.ChainFamily]blockchains.Deployer
+	CapabilityFlagsProvider   cre.CapabilityFlagsProvider // optional, capabilities dependencies can enable, flags.NewDefaultCapabilityFlagsProvider() is used if not set
 
 	// allow to pass custom transformers for extensibility
 	ConfigFactoryFunctions               []cre.NodeConfigTransformerFn
@@ -79,6 +119,69 @@ type SetupInput struct {
 	CapabilitiesContractFactoryFunctions []cre.CapabilityRegistryConfigFn
 
 	StageGen *stagegen.StageGen
+
+	// optional, generated node configs and job specs are compared with golden files at the end of the setup
+	GoldenFiles *golden.Options
+
+	// optional, limits duration of provisioning phases, defaults are used if not set
+	PhaseTimeouts *cre.PhaseTimeouts
+
+	// optional, provisions the Beholder pipeline (Chip Ingress, Kafka) and points nodes to it (Docker only)
+	Beholder *BeholderInput
+
+	// optional, experimental node and capability features enabled in all nodes and capability configs
+	FeatureFlags *cre.FeatureFlags
+
+	// optional, pre-generated keys of nodes, see cre.KeysFile. Nodesets loaded with config.Config.Load already have
+	// them, applying them again is a no-op.
+	KeysFile *cre.KeysFile
+
+	// optional, metrics of the local observability stack are remote-written to a central store, tagged with run ID and commit
+	MetricsRemoteWrite *metrics.RemoteWriteConfig
+
+	// optional, heap and goroutine counts of nodes and capability plugins are sampled to detect leaks in soak scenarios
+	LeakDetection *metrics.LeakDetectionConfig
+
+	// optional, synthetic feed prices are published to workflows under test once the environment is ready. They are
+	// posted to DataGenerator.URL, unless DataPublisher is set (e.g. to a datagen.MockTriggerPublisher).
+	DataGenerator *datagen.Config
+	DataPublisher datagen.Publisher
+
+	// optional, hooks are notified when the setup starts and fails, and at the end of the run, see SetupOutput.Notifier
+	Notifications *notify.Config
+
+	// optional, nodes, chains and gateways are watched once the environment is ready, so that tests fail fast when it
+	// dies, see SetupOutput.Watchdog. Failures are reported to notification hooks.
+	Watchdog *watchdog.Options
+
+	// optional, limits logs of pods downloaded by DownloadPodLogs, when setup in CRIB or Kubernetes fails. Only logs written
+	// since the setup started are downloaded, unless Since is set.
+	PodLogs *logs.KubernetesLogsConfig
+
+	// optional, where binaries referenced by https://, s3:// and oci:// URLs in capability configs are downloaded and
+	// binaries of capability configs with a source are built, binaries.DefaultCacheDir() is used if not set
+	BinaryCacheDir string
+
+	// if true, outputs of phases completed by a previous failed run are reused, see ProvisioningCheckpoint. Outputs of
+	// completed phases are checkpointed by every run, so that a failed run can be resumed by the next one.
+	ResumeFromCheckpoint bool
+
+	// if true, smoke checks of features implementing cre.SmokeChecker are not run after the environment is ready
+	SkipSmokeChecks bool
+
+	// if true, the environment is ready only once every worker is connected to all workers of its DON and to bootstrap nodes,
+	// DONs whose workers don't peer with each other (e.g. gateway-only DONs) shouldn't be part of such topologies
+	VerifyP2PMesh        bool
+	P2PMeshVerifyTimeout time.Duration // cre.DefaultP2PMeshTimeout is used if not set
+
+	// if true, registries deployed by a previous run on the same chain are reused, use it only with persistent chains
+	CacheContractDeployments bool
+
+	// used to label all created resources, RunID is generated if empty
+	RunID    string
+	TestName string
+	// EnvironmentName identifies the environment in artifacts and reports, cre.DefaultEnvironmentName is used if empty
+	EnvironmentName string
 }
 
 func (s *SetupInput) Validate() error {
@@ -90,6 +193,16 @@ func (s *SetupInput) Validate() error {
 		return pkgerrors.New("at least one nodeSet is required")
 	}
 
+	if err := cre.ValidateEnvironmentName(s.EnvironmentName); err != nil {
+		return err
+	}
+
+	for _, nodeSet := range s.CapabilitiesAwareNodeSets {
+		if err := nodeSet.ValidateDONTypes(); err != nil {
+			return err
+		}
+	}
+
 	if len(s.BlockchainsInput) == 0 {
 		return pkgerrors.New("at least one blockchain is required")
 	}
@@ -98,6 +211,135 @@ func (s *SetupInput) Validate() error {
 		return pkgerrors.New("jd input is nil")
 	}
 
+	if s.Provider.IsKubernetes() {
+		if s.Provider.Kubernetes == nil || s.Provider.Kubernetes.Namespace == "" {
+			return pkgerrors.New("namespace of the Kubernetes cluster is required")
+		}
+		for _, nodeSet := range s.CapabilitiesAwareNodeSets {
+			if nodeSet.Out == nil || !nodeSet.Out.UseCache {
+				return pkgerrors.Errorf("nodeset %s must be deployed to the Kubernetes cluster beforehand and have its output set with use_cache = true", nodeSet.Name)
+			}
+		}
+	}
+
+	for _, customContainer := range s.CustomContainers {
+		if !s.Provider.IsDocker() {
+			return pkgerrors.New("custom containers are supported only with Docker")
+		}
+		if err := customContainer.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, hostProcess := range s.HostProcesses {
+		if !s.Provider.IsDocker() {
+			return pkgerrors.New("host processes are supported only with Docker")
+		}
+		if err := hostProcess.Validate(); err != nil {
+			return err
+		}
+	}
+
+	components := make([]string, 0, len(s.CustomContainers)+len(s.HostProcesses))
+	for _, customContainer := range s.CustomContainers {
+		components = append(components, customContainer.Name)
+	}
+	for _, hostProcess := range s.HostProcesses {
+		components = append(components, hostProcess.Name)
+	}
+	for _, check := range s.ReadinessChecks {
+		if check.Name == "" || check.Check == nil {
+			return pkgerrors.New("readiness checks must have a name and a check function")
+		}
+		if check.Component != "" && !slices.Contains(components, check.Component) {
+			return pkgerrors.Errorf("readiness check %s belongs to component %s, which isn't a custom container or a host process", check.Name, check.Component)
+		}
+	}
+
+	if err := s.PhaseTimeouts.Validate(); err != nil {
+		return pkgerrors.Wrap(err, "invalid phase timeouts")
+	}
+
+	if err := s.GatewayAuthConfig.Validate(); err != nil {
+		return pkgerrors.Wrap(err, "invalid gateway auth config")
+	}
+
+	if s.Beholder != nil {
+		if !s.Provider.IsDocker() {
+			return pkgerrors.New("Beholder provisioning is supported only with Docker")
+		}
+		if err := s.Beholder.Validate(); err != nil {
+			return pkgerrors.Wrap(err, "invalid Beholder input")
+		}
+	}
+
+	if s.MetricsRemoteWrite != nil {
+		if err := s.MetricsRemoteWrite.Validate(); err != nil {
+			return pkgerrors.Wrap(err, "invalid metrics remote write config")
+		}
+	}
+
+	if s.LeakDetection != nil {
+		if err := s.LeakDetection.Validate(); err != nil {
+			return pkgerrors.Wrap(err, "invalid leak detection config")
+		}
+	}
+
+	if s.Notifications != nil {
+		if err := s.Notifications.Validate(); err != nil {
+			return pkgerrors.Wrap(err, "invalid notifications config")
+		}
+	}
+
+	if s.Watchdog != nil {
+		if err := s.Watchdog.Validate(); err != nil {
+			return pkgerrors.Wrap(err, "invalid watchdog config")
+		}
+	}
+
+	if s.DataGenerator != nil {
+		if err := s.DataGenerator.Validate(); err != nil {
+			return pkgerrors.Wrap(err, "invalid data generator config")
+		}
+		if s.DataGenerator.URL == "" && s.DataPublisher == nil {
+			return pkgerrors.New("data generator needs either a url or a publisher")
+		}
+	}
+
+	if s.FeatureFlags != nil {
+		if err := s.FeatureFlags.Validate(); err != nil {
+			return pkgerrors.Wrap(err, "invalid feature flags")
+		}
+	}
+
+	if s.KeysFile != nil {
+		if err := s.KeysFile.Validate(); err != nil {
+			return pkgerrors.Wrap(err, "invalid keys file")
+		}
+	}
+
+	if err := cre.ValidateCapabilityDependencies(s.CapabilityConfigs); err != nil {
+		return err
+	}
+
+	for flag, capabilityConfig := range s.CapabilityConfigs {
+		if err := capabilityConfig.Validate(); err != nil {
+			return pkgerrors.Wrapf(err, "invalid config of capability %s", flag)
+		}
+		if capabilityConfig.BinaryPath == "" {
+			continue
+		}
+		if _, err := binaries.ParseRef(capabilityConfig.BinaryPath); err != nil {
+			return pkgerrors.Wrapf(err, "invalid binary path of capability %s", flag)
+		}
+		// local binaries are verified early, so that stale ones fail before any component is started
+		if s.CopyCapabilityBinaries && !binaries.IsRemote(capabilityConfig.BinaryPath) {
+			if err := crecapabilities.VerifyBinary(flag, capabilityConfig.BinaryPath, capabilityConfig); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -107,16 +349,123 @@ func SetupTestEnvironment(
 	singleFileLogger logger.Logger,
 	input *SetupInput,
 	relativePathToRepoRoot string,
-) (*SetupOutput, error) {
+) (output *SetupOutput, err error) {
 	if input == nil {
 		return nil, pkgerrors.New("input is nil")
 	}
+	if input.Tracer != nil {
+		ctx = tracing.ContextWithTracer(ctx, input.Tracer)
+	}
+
+	for _, nodeSet := range input.CapabilitiesAwareNodeSets {
+		if err := nodeSet.ExpandRoleTemplates(); err != nil {
+			return nil, pkgerrors.Wrap(err, "failed to expand role templates")
+		}
+	}
 
 	if err := input.Validate(); err != nil {
 		return nil, pkgerrors.Wrap(err, "input validation failed")
 	}
 
+	// nodesets loaded from TOML were already resolved by config.Validate, programmatic ones are resolved only here
+	flagsProvider := input.CapabilityFlagsProvider
+	if flagsProvider == nil {
+		flagsProvider = flags.NewDefaultCapabilityFlagsProvider()
+	}
+	for _, nodeSet := range input.CapabilitiesAwareNodeSets {
+		added, resolveErr := nodeSet.ResolveCapabilityDependencies(input.CapabilityConfigs, flagsProvider)
+		if resolveErr != nil {
+			return nil, pkgerrors.Wrapf(resolveErr, "failed to resolve capability dependencies of nodeset %s", nodeSet.Name)
+		}
+		if len(added) > 0 {
+			testLogger.Info().Msgf("Enabled capabilities %s on nodeset %s, because its other capabilities depend on them", strings.Join(added, ", "), nodeSet.Name)
+		}
+	}
+
+	// applied before the fingerprint is computed, because keys change identities of nodes
+	if input.KeysFile != nil {
+		if err := input.KeysFile.Apply(input.CapabilitiesAwareNodeSets); err != nil {
+			return nil, pkgerrors.Wrap(err, "failed to apply keys file")
+		}
+	}
+
+	// contracts, which aren't pinned, are deployed in their default versions
+	input.ContractVersions = config.ResolveContractVersions(input.WithV2Registries, input.ContractVersions)
+	if err := config.ValidateContractVersions(input.ContractVersions, input.WithV2Registries); err != nil {
+		return nil, pkgerrors.Wrap(err, "invalid contract versions")
+	}
+
+	fingerprint, fingerprintErr := Fingerprint(input)
+	if fingerprintErr != nil {
+		return nil, pkgerrors.Wrap(fingerprintErr, "failed to compute fingerprint of the environment")
+	}
+	testLogger.Info().Msgf("Environment %s fingerprint: %s", cre.EnvironmentNameOrDefault(input.EnvironmentName), fingerprint)
+
+	// created before any resource, so that containers started by the framework get its labels
+	runID := input.RunID
+	if runID == "" {
+		runID = uuid.NewString()
+	}
+	resources := infra.NewResourceIndex(input.Provider.Type, runID, input.TestName)
+
+	scenario := input.TestName
+	if scenario == "" {
+		scenario = cre.EnvironmentNameOrDefault(input.EnvironmentName)
+	}
+	notifier, notifierErr := notify.New(testLogger, input.Notifications, scenario)
+	if notifierErr != nil {
+		return nil, pkgerrors.Wrap(notifierErr, "failed to create notifier")
+	}
+	notifier.Started(ctx, map[string]string{"run_id": runID})
+	setupStartedAt := time.Now()
+	defer func() {
+		if err == nil {
+			return
+		}
+		// the caller reports the end of the run, see SetupOutput.Notifier
+		notifier.Failure(context.WithoutCancel(ctx), pkgerrors.Wrap(err, "setup failed"), map[string]string{"run_id": runID})
+
+		podLogsConfig := logs.KubernetesLogsConfig{Since: setupStartedAt}
+		if input.PodLogs != nil {
+			podLogsConfig = *input.PodLogs
+			if podLogsConfig.Since.IsZero() {
+				podLogsConfig.Since = setupStartedAt
+			}
+		}
+		if logsErr := DownloadPodLogs(context.WithoutCancel(ctx), testLogger, input.Provider, podLogsConfig); logsErr != nil {
+			testLogger.Warn().Err(logsErr).Msg("Failed to download logs of pods of the failed setup")
+		}
+	}()
+
+	// without resume, the checkpoint of a previous run is replaced by the one of this run once its first phase completes
+	checkpointPath := config.MustCheckpointStateFileAbsPath(relativePathToRepoRoot)
+	checkpoint := NewProvisioningCheckpoint(checkpointPath)
+	checkpoint.Fingerprint = fingerprint
+	if input.ResumeFromCheckpoint {
+		var loadErr error
+		checkpoint, loadErr = LoadProvisioningCheckpoint(checkpointPath)
+		if loadErr != nil {
+			return nil, pkgerrors.Wrap(loadErr, "failed to load provisioning checkpoint")
+		}
+		if err := checkpoint.ApplyTo(input, fingerprint); err != nil {
+			return nil, pkgerrors.Wrap(err, "failed to apply provisioning checkpoint")
+		}
+		testLogger.Info().Msgf("Resuming setup, already completed phases: %v", checkpoint.Completed)
+	}
+
+	if input.Provider.IsDocker() {
+		if _, archErr := infra.EnsureNativeImages(ctx, testLogger, environmentImages(input)); archErr != nil {
+			return nil, pkgerrors.Wrap(archErr, "failed to check architectures of images")
+		}
+	}
+
 	if input.Provider.Type == infra.CRIB {
+		estimate := crib.EstimateResources(input.CapabilitiesAwareNodeSets, len(input.BlockchainsInput), input.Provider.CRIB.Budget)
+		testLogger.Info().Msgf("Estimated CRIB resources: %s", estimate)
+		if err := crib.CheckBudget(estimate, input.Provider.CRIB.Budget); err != nil {
+			return nil, err
+		}
+
 		cribErr := crib.Bootstrap(input.Provider)
 		if cribErr != nil {
 			return nil, pkgerrors.Wrap(cribErr, "failed to bootstrap CRIB")
@@ -128,8 +477,48 @@ func SetupTestEnvironment(
 		return nil, pkgerrors.Wrap(s3Err, "failed to start S3 provider")
 	}
 
+	if input.FeatureFlags != nil {
+		input.CapabilityConfigs = input.FeatureFlags.ApplyToCapabilityConfigs(input.CapabilityConfigs)
+		if err := input.FeatureFlags.ApplyToNodeSpecs(input.CapabilitiesAwareNodeSets); err != nil {
+			return nil, pkgerrors.Wrap(err, "failed to apply node feature flags")
+		}
+		for _, flag := range input.FeatureFlags.Summary() {
+			testLogger.Info().Msgf("Feature flag enabled: %s", flag)
+		}
+	}
+
+	if input.CopyCapabilityBinaries {
+		builtConfigs, buildErr := crecapabilities.BuildBinaries(ctx, binaries.NewBuilder(input.BinaryCacheDir), input.Provider, input.CapabilityConfigs, input.CapabilitiesAwareNodeSets)
+		if buildErr != nil {
+			return nil, pkgerrors.Wrap(buildErr, "failed to build capability binaries from source")
+		}
+		input.CapabilityConfigs = builtConfigs
+
+		resolvedConfigs, resolveErr := crecapabilities.ResolveBinaries(ctx, binaries.NewResolver(input.BinaryCacheDir), input.CapabilityConfigs, input.CapabilitiesAwareNodeSets)
+		if resolveErr != nil {
+			return nil, pkgerrors.Wrap(resolveErr, "failed to resolve capability binaries")
+		}
+		input.CapabilityConfigs = resolvedConfigs
+
+		if err := crecapabilities.VerifyBinariesPlatform(ctx, input.Provider, input.CapabilityConfigs, input.CapabilitiesAwareNodeSets); err != nil {
+			return nil, pkgerrors.Wrap(err, "failed to verify platforms of capability binaries")
+		}
+
+		if input.BinaryCache == nil {
+			binaryCache, cacheErr := input.BinaryCacheConfig.NewBinaryCache()
+			if cacheErr != nil {
+				return nil, pkgerrors.Wrap(cacheErr, "failed to create capability binary cache")
+			}
+			input.BinaryCache = binaryCache
+		}
+	}
+
 	fmt.Print(libformat.PurpleText("%s", input.StageGen.Wrap("Starting %d blockchain(s)", len(input.BlockchainsInput))))
 
+	if err := cre.ApplyChainFinalityConfigs(input.ChainFinalityConfigs, input.BlockchainsInput); err != nil {
+		return nil, pkgerrors.Wrap(err, "failed to apply chain finality configs")
+	}
+
 	deployedBlockchains, startErr := blockchains.Start(
 		testLogger,
 		singleFileLogger,
@@ -139,31 +528,146 @@ func SetupTestEnvironment(
 	if startErr != nil {
 		return nil, pkgerrors.Wrap(startErr, "failed to start blockchains")
 	}
+	if err := checkpoint.Complete(PhaseBlockchains, checkpointBlockchains(deployedBlockchains.Outputs)); err != nil {
+		return nil, err
+	}
+
+	// started once chains are up, so that they can use them, and removed if a later step fails. Reused containers
+	// (with cached outputs) are kept.
+	customContainersOutput := make([]*infra.CustomContainerOutput, 0, len(input.CustomContainers))
+	startedCustomContainers := make([]*infra.CustomContainerOutput, 0, len(input.CustomContainers))
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, out := range startedCustomContainers {
+			if removeErr := infra.RemoveCustomContainer(context.WithoutCancel(ctx), out); removeErr != nil {
+				testLogger.Warn().Err(removeErr).Msgf("Failed to remove custom container %s of the failed setup", out.ContainerName)
+			}
+		}
+	}()
+	for _, customContainer := range input.CustomContainers {
+		reused := customContainer.Out != nil && customContainer.Out.UseCache
+		customContainer.Labels = resources.Labels.With(infra.Labels{infra.LabelRole: ResourceRoleCustomContainer})
+		out, ccErr := infra.StartCustomContainer(ctx, customContainer)
+		if ccErr != nil {
+			return nil, pkgerrors.Wrapf(ccErr, "failed to start custom container %s", customContainer.Name)
+		}
+		testLogger.Info().Msgf("Custom container %s started, reachable by nodes at %s", customContainer.Name, out.InternalHost)
+		customContainersOutput = append(customContainersOutput, out)
+		if !reused {
+			startedCustomContainers = append(startedCustomContainers, out)
+		}
+	}
+
+	// same for host processes, external ones aren't stopped by Stop()
+	hostProcesses := make([]*infra.HostProcess, 0, len(input.HostProcesses))
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, hostProcess := range hostProcesses {
+			if stopErr := hostProcess.Stop(); stopErr != nil {
+				testLogger.Warn().Err(stopErr).Msgf("Failed to stop host process %s of the failed setup", hostProcess.Input.Name)
+			}
+		}
+	}()
+	for _, hostProcessInput := range input.HostProcesses {
+		hostProcess, hpErr := infra.StartHostProcess(ctx, hostProcessInput)
+		if hpErr != nil {
+			return nil, pkgerrors.Wrapf(hpErr, "failed to start host process %s", hostProcessInput.Name)
+		}
+		testLogger.Info().Msgf("Host process %s started, reachable by nodes at %s", hostProcessInput.Name, hostProcess.Output.InternalURL)
+		hostProcesses = append(hostProcesses, hostProcess)
+	}
+
+	if err := cre.WaitForReadinessChecks(ctx, input.PhaseTimeouts, componentReadinessChecks(input)); err != nil {
+		return nil, pkgerrors.Wrap(err, "custom containers or host processes are not ready")
+	}
+
+	var beholderOutput *chipingressset.Output
+	if input.Beholder != nil {
+		var beholderTransformer cre.NodeConfigTransformerFn
+		var removeBeholder func(context.Context) error
+		var beholderErr error
+		beholderOutput, beholderTransformer, removeBeholder, beholderErr = startBeholder(ctx, testLogger, input.Beholder, relativePathToRepoRoot)
+		if beholderErr != nil {
+			return nil, pkgerrors.Wrap(beholderErr, "failed to provision Beholder")
+		}
+		defer func() {
+			if err == nil {
+				return
+			}
+			if removeErr := removeBeholder(context.WithoutCancel(ctx)); removeErr != nil {
+				testLogger.Warn().Err(removeErr).Msg("Failed to remove Beholder stack of the failed setup")
+			}
+		}()
+		input.ConfigFactoryFunctions = append(input.ConfigFactoryFunctions, beholderTransformer)
+		testLogger.Info().Msg(beholderSummary(beholderOutput))
+	}
 
 	creEnvironment := &cre.Environment{
+		Name:                  cre.EnvironmentNameOrDefault(input.EnvironmentName),
 		Blockchains:           deployedBlockchains.Outputs,
 		ContractVersions:      input.ContractVersions,
 		Provider:              input.Provider,
 		CapabilityConfigs:     input.CapabilityConfigs,
+		ChainFinalityConfigs:  input.ChainFinalityConfigs,
 		RegistryChainSelector: deployedBlockchains.RegistryChain().ChainSelector(),
+		FeatureFlags:          input.FeatureFlags,
+		Fingerprint:           fingerprint,
 	}
 
 	fmt.Print(libformat.PurpleText("%s", input.StageGen.WrapAndNext("Blockchains started in %.2f seconds", input.StageGen.Elapsed().Seconds())))
 	fmt.Print(libformat.PurpleText("%s", input.StageGen.Wrap("Deploying Workflow and Capability Registry contracts")))
 
-	deployKeystoneContractsOutput, deployErr := crecontracts.DeployKeystoneContracts(
-		ctx,
-		testLogger,
-		singleFileLogger,
-		crecontracts.DeployKeystoneContractsInput{
-			CldfEnvironment:  newCldfEnvironment(ctx, singleFileLogger, deployedBlockchains.CldfBlockChains),
-			CtfBlockchains:   deployedBlockchains.Outputs,
-			ContractVersions: input.ContractVersions,
-			WithV2Registries: input.WithV2Registries,
-		},
-	)
-	if deployErr != nil {
-		return nil, pkgerrors.Wrap(deployErr, "failed to deploy Keystone contracts")
+	var deployKeystoneContractsOutput *crecontracts.DeployKeystoneContractsOutput
+	if checkpoint.IsCompleted(PhaseContracts) {
+		var restoreErr error
+		deployKeystoneContractsOutput, restoreErr = checkpoint.restoreContracts(newCldfEnvironment(ctx, singleFileLogger, deployedBlockchains.CldfBlockChains))
+		if restoreErr != nil {
+			return nil, pkgerrors.Wrap(restoreErr, "failed to restore Keystone contracts from checkpoint")
+		}
+	} else {
+		var deploymentCache *crecontracts.DeploymentCache
+		var contractsFingerprint string
+		if input.CacheContractDeployments {
+			var cacheErr error
+			deploymentCache, cacheErr = crecontracts.LoadDeploymentCache(config.MustContractCacheStateFileAbsPath(relativePathToRepoRoot))
+			if cacheErr != nil {
+				return nil, pkgerrors.Wrap(cacheErr, "failed to load contract deployment cache")
+			}
+			var fingerprintErr error
+			if contractsFingerprint, fingerprintErr = ContractsFingerprint(input); fingerprintErr != nil {
+				return nil, pkgerrors.Wrap(fingerprintErr, "failed to compute fingerprint of contracts of the environment")
+			}
+		}
+
+		var deployErr error
+		deployKeystoneContractsOutput, deployErr = crecontracts.DeployKeystoneContracts(
+			ctx,
+			testLogger,
+			singleFileLogger,
+			crecontracts.DeployKeystoneContractsInput{
+				CldfEnvironment:  newCldfEnvironment(ctx, singleFileLogger, deployedBlockchains.CldfBlockChains),
+				CtfBlockchains:   deployedBlockchains.Outputs,
+				ContractVersions: input.ContractVersions,
+				WithV2Registries: input.WithV2Registries,
+				Cache:            deploymentCache,
+				Fingerprint:      contractsFingerprint,
+			},
+		)
+		if deployErr != nil {
+			return nil, pkgerrors.Wrap(deployErr, "failed to deploy Keystone contracts")
+		}
+
+		update, cErr := checkpointContracts(deployKeystoneContractsOutput)
+		if cErr != nil {
+			return nil, pkgerrors.Wrap(cErr, "failed to checkpoint Keystone contracts")
+		}
+		if err := checkpoint.Complete(PhaseContracts, update); err != nil {
+			return nil, err
+		}
 	}
 	creEnvironment.CldfEnvironment = deployKeystoneContractsOutput.Env
 
@@ -196,8 +700,32 @@ func SetupTestEnvironment(
 		return nil, pkgerrors.Wrap(gErr, "failed to build gateway job config")
 	}
 	topology.GatewayJobConfigs = gatewayJobConfigs
+	var snapshot *golden.Snapshot
+	if input.GoldenFiles != nil {
+		snapshot = golden.NewSnapshot()
+	}
+
 	fmt.Print(libformat.PurpleText("%s", input.StageGen.WrapAndNext("DONs configuration prepared in %.2f seconds", input.StageGen.Elapsed().Seconds())))
 
+	// features of capabilities enabled in TOML, which weren't passed to the setup, are applied as well
+	jobSpecFactories := input.JobSpecFactories
+	if jobSpecFactories == nil {
+		jobSpecFactories = sets.JobSpecFactories()
+	}
+	handledFlags := make([]cre.CapabilityFlag, 0, len(input.Capabilities)+len(input.Features.List()))
+	for _, capability := range input.Capabilities {
+		handledFlags = append(handledFlags, capability.Flag())
+	}
+	for _, feature := range input.Features.List() {
+		handledFlags = append(handledFlags, feature.Flag())
+	}
+	if enabledFeatures := jobSpecFactories.EnabledFeatures(input.CapabilitiesAwareNodeSets, handledFlags); len(enabledFeatures) > 0 {
+		input.Features = cre.NewFeatures(slices.Concat(input.Features.List(), enabledFeatures)...)
+		for _, feature := range enabledFeatures {
+			handledFlags = append(handledFlags, feature.Flag())
+		}
+	}
+
 	fmt.Print(libformat.PurpleText("%s", input.StageGen.Wrap("Applying Features before environment startup")))
 	var donsCapabilities = make(map[uint64][]keystone_changeset.DONCapabilityWithConfig)
 	for _, feature := range input.Features.List() {
@@ -222,6 +750,13 @@ func SetupTestEnvironment(
 			testLogger.Info().Msgf("PreEnvStartup for feature %s executed successfully", feature.Flag())
 		}
 	}
+	// features have added their handlers by now, so auth options are applied to all of them
+	input.GatewayAuthConfig.Apply(topology.GatewayJobConfigs)
+	for _, donMetadata := range topology.DonsMetadata.List() {
+		if err := donMetadata.CapabilitiesAwareNodeSet().ApplyCapabilityPolicies(donsCapabilities[donMetadata.ID]); err != nil {
+			return nil, fmt.Errorf("failed to apply capability policies to DON '%s': %w", donMetadata.Name, err)
+		}
+	}
 	fmt.Print(libformat.PurpleText("%s", input.StageGen.WrapAndNext("Applied Features in %.2f seconds", input.StageGen.Elapsed().Seconds())))
 
 	queue := worker.New(10)
@@ -234,7 +769,7 @@ func SetupTestEnvironment(
 	})
 
 	donsStartedFuture := queue.SubmitAny(func() (any, error) {
-		nodeSetOutput, startDonsErr := StartDONs(ctx, testLogger, topology, input.Provider, deployedBlockchains.RegistryChain().CtfOutput(), input.CapabilityConfigs, input.CopyCapabilityBinaries, updatedNodeSets)
+		nodeSetOutput, startDonsErr := StartDONs(ctx, testLogger, topology, input.Provider, deployedBlockchains.RegistryChain().CtfOutput(), input.CapabilityConfigs, input.CopyCapabilityBinaries, updatedNodeSets, input.MaxConcurrentDONStarts, input.MaxConcurrentBinaries, input.BinaryCache, input.PhaseTimeouts)
 		if startDonsErr != nil {
 			return nil, pkgerrors.Wrap(startDonsErr, "failed to start DONs")
 		}
@@ -247,11 +782,21 @@ func SetupTestEnvironment(
 	if jdStartErr != nil {
 		return nil, pkgerrors.Wrap(jdStartErr, "failed to start Job Distributor")
 	}
+	if err := checkpoint.Complete(PhaseJobDistributor, func(c *ProvisioningCheckpoint) { c.JD = startedJD.JDOutput }); err != nil {
+		return nil, err
+	}
 
 	startedDONs, donStartErr := worker.AwaitAs[*StartedDONs](ctx, donsStartedFuture)
 	if donStartErr != nil {
 		return nil, pkgerrors.Wrap(donStartErr, "failed to start DONs")
 	}
+	if err := checkpoint.Complete(PhaseDONs, checkpointDONs(startedDONs, updatedNodeSets)); err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		// features and StartDONs modify node configs until the very last moment, so they are snapshotted once nodes run them
+		snapshot.AddNodeConfigs(updatedNodeSets)
+	}
 	dons := cre.NewDons(startedDONs.DONs(), topology.GatewayConnectors)
 
 	linkDonsToJDInput := &cre.LinkDonsToJDInput{
@@ -275,12 +820,30 @@ func SetupTestEnvironment(
 		return nil, pkgerrors.Wrap(gErr, "failed to create gateway jobs with Job Distributor")
 	}
 
+	var gatewayLBOutput *infra.GatewayLoadBalancerOutput
+	if input.GatewayLoadBalancer != nil && topology.GatewayConnectors != nil && len(topology.GatewayConnectors.Configurations) > 0 {
+		if !input.Provider.IsDocker() {
+			return nil, pkgerrors.New("gateway load balancer is supported only with Docker, in CRIB put gateways behind the ingress")
+		}
+
+		input.GatewayLoadBalancer.Labels = resources.Labels.With(infra.Labels{infra.LabelRole: ResourceRoleGatewayLoadBalancer})
+		var lbErr error
+		gatewayLBOutput, lbErr = infra.StartGatewayLoadBalancer(ctx, input.GatewayLoadBalancer, gateway.LoadBalancerUpstreams(topology.GatewayConnectors), topology.GatewayConnectors.Configurations[0].Incoming.Path)
+		if lbErr != nil {
+			return nil, pkgerrors.Wrap(lbErr, "failed to start gateway load balancer")
+		}
+		testLogger.Info().Msgf("Gateway load balancer started at %s", gatewayLBOutput.ExternalURL)
+	}
+
 	// Deprecated: use Features instead. Support for InstallableCapability will be removed in the future.
 	jobSpecFactoryFunctions := make([]cre.JobSpecFn, 0)
 	for _, capability := range input.Capabilities {
 		jobSpecFactoryFunctions = append(jobSpecFactoryFunctions, capability.JobSpecFn())
 	}
 
+	// capabilities enabled in TOML, whose jobs nothing above creates, get jobs of their registered factories
+	jobSpecFactoryFunctions = append(jobSpecFactoryFunctions, jobSpecFactories.Enabled(input.CapabilitiesAwareNodeSets, handledFlags)...)
+
 	// allow to pass custom job spec factories for extensibility
 	jobSpecFactoryFunctions = append(jobSpecFactoryFunctions, input.JobSpecFactoryFunctions...)
 	createJobsDeps := CreateJobsWithJdOpDeps{
@@ -292,8 +855,15 @@ func SetupTestEnvironment(
 		Dons:                      dons,
 		CapabilitiesAwareNodeSets: input.CapabilitiesAwareNodeSets,
 		Capabilities:              input.Capabilities,
+		Snapshot:                  snapshot,
 	}
-	_, createJobsErr := operations.ExecuteOperation(deployKeystoneContractsOutput.Env.OperationsBundle, CreateJobsWithJdOp, createJobsDeps, CreateJobsWithJdOpInput{})
+	createJobsErr := cre.RunPhase(ctx, input.PhaseTimeouts, cre.PhaseJobPropagation, func(phaseCtx context.Context) error {
+		// jobs are proposed with the phase context, so that proposals still in flight are cancelled when the phase times out
+		phaseBundle := deployKeystoneContractsOutput.Env.OperationsBundle
+		phaseBundle.GetContext = func() context.Context { return phaseCtx }
+		_, opErr := operations.ExecuteOperation(phaseBundle, CreateJobsWithJdOp, createJobsDeps, CreateJobsWithJdOpInput{})
+		return opErr
+	})
 	if createJobsErr != nil {
 		return nil, pkgerrors.Wrap(createJobsErr, "failed to create jobs with Job Distributor")
 	}
@@ -379,7 +949,12 @@ func SetupTestEnvironment(
 
 	maps.Copy(capRegInput.DONCapabilityWithConfigs, donsCapabilities)
 
-	_, capRegErr := crecontracts.ConfigureCapabilityRegistry(capRegInput)
+	capRegErr := cre.RunPhase(ctx, input.PhaseTimeouts, cre.PhaseRegistryConfig, func(phaseCtx context.Context) error {
+		_, endSpan := tracing.StartSpan(phaseCtx, tracing.Span{Stage: crecapabilities.BinaryStageRegistrySetup})
+		_, configureErr := crecontracts.ConfigureCapabilityRegistry(capRegInput)
+		endSpan(configureErr)
+		return configureErr
+	})
 	if capRegErr != nil {
 		return nil, pkgerrors.Wrap(capRegErr, "failed to configure Capability Registry contracts")
 	}
@@ -404,6 +979,32 @@ func SetupTestEnvironment(
 	}
 	fmt.Print(libformat.PurpleText("%s", input.StageGen.WrapAndNext("Features applied in %.2f seconds", input.StageGen.Elapsed().Seconds())))
 
+	var environmentChecks []cre.ReadinessCheck
+	for _, check := range input.ReadinessChecks {
+		if check.Component == "" {
+			environmentChecks = append(environmentChecks, check)
+		}
+	}
+	if err := cre.WaitForReadinessChecks(ctx, input.PhaseTimeouts, environmentChecks); err != nil {
+		return nil, pkgerrors.Wrap(err, "environment is not ready")
+	}
+
+	if input.VerifyP2PMesh {
+		fmt.Print(libformat.PurpleText("%s", input.StageGen.Wrap("Verifying P2P mesh")))
+		if _, err := cre.VerifyP2PMesh(ctx, dons, input.P2PMeshVerifyTimeout); err != nil {
+			return nil, err
+		}
+		fmt.Print(libformat.PurpleText("%s", input.StageGen.WrapAndNext("P2P mesh verified in %.2f seconds", input.StageGen.Elapsed().Seconds())))
+	}
+
+	if !input.SkipSmokeChecks {
+		fmt.Print(libformat.PurpleText("%s", input.StageGen.Wrap("Running capability smoke checks")))
+		if err := cre.RunSmokeChecks(ctx, testLogger, input.Features, dons, creEnvironment); err != nil {
+			return nil, err
+		}
+		fmt.Print(libformat.PurpleText("%s", input.StageGen.WrapAndNext("Capability smoke checks passed in %.2f seconds", input.StageGen.Elapsed().Seconds())))
+	}
+
 	if err := worker.AwaitErr(ctx, wfFiltersFuture); err != nil {
 		return nil, pkgerrors.Wrap(err, "failed while waiting for workflow registry filters registration")
 	}
@@ -415,6 +1016,89 @@ func SetupTestEnvironment(
 		return nil, pkgerrors.Wrap(err, "failed to store workflow registry configuration output")
 	}
 
+	if snapshot != nil {
+		if err := snapshot.Assert(*input.GoldenFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkpoint.Remove(); err != nil {
+		return nil, err
+	}
+
+	indexResources(resources, input.Provider, topology, deployedBlockchains.Outputs, startedJD.JDOutput)
+	if gatewayLBOutput != nil {
+		resources.Add(infra.ResourceContainer, gatewayLBOutput.ContainerName, infra.Labels{infra.LabelRole: ResourceRoleGatewayLoadBalancer})
+	}
+	for _, customContainer := range customContainersOutput {
+		resources.Add(infra.ResourceContainer, customContainer.ContainerName, infra.Labels{infra.LabelRole: ResourceRoleCustomContainer})
+	}
+	if input.Provider.IsDocker() {
+		if err := infra.RecordDockerSessions(ctx, resources); err != nil {
+			return nil, pkgerrors.Wrap(err, "failed to record sessions of Docker resources")
+		}
+	}
+	if err := resources.Store(config.MustResourcesStateFileAbsPath(relativePathToRepoRoot)); err != nil {
+		return nil, pkgerrors.Wrap(err, "failed to store resource index")
+	}
+
+	var remoteWriter *metrics.RemoteWriter
+	if input.MetricsRemoteWrite != nil {
+		var rwErr error
+		remoteWriter, rwErr = metrics.NewRemoteWriter(testLogger, framework.LocalPrometheusBaseURL, runID, input.MetricsRemoteWrite)
+		if rwErr != nil {
+			return nil, pkgerrors.Wrap(rwErr, "failed to create metrics remote writer")
+		}
+		// must outlive the setup context, it's stopped by the caller
+		remoteWriter.Start(context.WithoutCancel(ctx))
+		testLogger.Info().Msgf("Remote-writing metrics to %s", input.MetricsRemoteWrite.URL)
+	}
+
+	var leakDetector *metrics.LeakDetector
+	if input.LeakDetection != nil {
+		var ldErr error
+		leakTargets := cre.LeakTargets(ctx, testLogger, dons)
+		leakDetector, ldErr = metrics.NewLeakDetector(testLogger, input.LeakDetection, leakTargets)
+		if ldErr != nil {
+			return nil, pkgerrors.Wrap(ldErr, "failed to create leak detector")
+		}
+		// must outlive the setup context, it's stopped by the caller
+		leakDetector.Start(context.WithoutCancel(ctx))
+		testLogger.Info().Msgf("Sampling heap and goroutines of %d nodes and capability plugins for leak detection", len(leakTargets))
+	}
+
+	var dataGenerator *datagen.Generator
+	if input.DataGenerator != nil {
+		var dgErr error
+		dataGenerator, dgErr = datagen.NewGenerator(input.DataGenerator)
+		if dgErr != nil {
+			return nil, pkgerrors.Wrap(dgErr, "failed to create data generator")
+		}
+		publisher := input.DataPublisher
+		if publisher == nil {
+			publisher = &datagen.HTTPPublisher{URL: input.DataGenerator.URL, Headers: input.DataGenerator.Headers}
+		}
+		// must outlive the setup context, it's stopped by the caller
+		dataGenerator.Start(context.WithoutCancel(ctx), publisher)
+		testLogger.Info().Msgf("Publishing synthetic prices of %d feeds every %s", len(input.DataGenerator.Feeds), input.DataGenerator.IntervalDuration())
+	}
+
+	var environmentWatchdog *watchdog.Watchdog
+	if input.Watchdog != nil {
+		watchdogConfig, wdErr := watchdog.ConfigFromEnvironment(dons, deployedBlockchains.Outputs)
+		if wdErr != nil {
+			return nil, pkgerrors.Wrap(wdErr, "failed to configure watchdog")
+		}
+		input.Watchdog.Apply(watchdogConfig)
+		watchdogConfig.OnFailure = func(err error) {
+			notifier.Failure(context.WithoutCancel(ctx), err, map[string]string{"run_id": runID})
+		}
+		environmentWatchdog = watchdog.New(testLogger, *watchdogConfig)
+		// must outlive the setup context, it's stopped by the caller
+		environmentWatchdog.Start(context.WithoutCancel(ctx))
+		testLogger.Info().Msgf("Watching %d nodes, %d chains and %d gateways", len(watchdogConfig.Nodes), len(watchdogConfig.Chains), len(watchdogConfig.GatewayURLs))
+	}
+
 	return &SetupOutput{
 		WorkflowRegistryConfigurationOutput: workflowRegistryConfigurationOutput, // pass to caller, so that it can be optionally attached to TestConfig and saved to disk
 		Dons:                                dons,
@@ -422,9 +1106,61 @@ func SetupTestEnvironment(
 		CreEnvironment:                      creEnvironment,
 		S3ProviderOutput:                    s3Output,
 		GatewayConnectors:                   topology.GatewayConnectors,
+		GatewayLoadBalancer:                 gatewayLBOutput,
+		CustomContainers:                    customContainersOutput,
+		HostProcesses:                       hostProcesses,
+		MetricsRemoteWriter:                 remoteWriter,
+		LeakDetector:                        leakDetector,
+		DataGenerator:                       dataGenerator,
+		Notifier:                            notifier,
+		Watchdog:                            environmentWatchdog,
+		Beholder:                            beholderOutput,
+		Resources:                           resources,
 	}, nil
 }
 
+// componentReadinessChecks returns readiness probes of custom containers and custom checks of components
+func componentReadinessChecks(input *SetupInput) []cre.ReadinessCheck {
+	checks := make([]cre.ReadinessCheck, 0)
+	for _, customContainer := range input.CustomContainers {
+		if probeURL := customContainer.ReadinessProbeURL(); probeURL != "" {
+			checks = append(checks, cre.HTTPReadinessCheck("readiness probe", customContainer.Name, probeURL))
+		}
+	}
+	for _, check := range input.ReadinessChecks {
+		if check.Component != "" {
+			checks = append(checks, check)
+		}
+	}
+
+	return checks
+}
+
+// environmentImages returns images of all components, which are pulled from registries. Images built locally are skipped.
+func environmentImages(input *SetupInput) []infra.ImageRef {
+	images := make([]infra.ImageRef, 0)
+	for _, bc := range input.BlockchainsInput {
+		images = append(images, infra.ImageRef{Image: bc.Image, Component: fmt.Sprintf("blockchain %s", bc.ChainID)})
+	}
+	images = append(images, infra.ImageRef{Image: input.JdInput.Image, Component: "job distributor"})
+	for _, nodeSet := range input.CapabilitiesAwareNodeSets {
+		if nodeSet.DbInput != nil {
+			images = append(images, infra.ImageRef{Image: nodeSet.DbInput.Image, Component: fmt.Sprintf("database of nodeset %s", nodeSet.Name)})
+		}
+		for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+			if nodeSpec.Node.DockerContext != "" {
+				continue
+			}
+			images = append(images, infra.ImageRef{Image: nodeSpec.Node.Image, Component: fmt.Sprintf("node %d of nodeset %s", nodeIdx, nodeSet.Name)})
+		}
+	}
+	for _, customContainer := range input.CustomContainers {
+		images = append(images, infra.ImageRef{Image: customContainer.Image, Component: fmt.Sprintf("custom container %s", customContainer.Name)})
+	}
+
+	return images
+}
+
 func appendOutputsToInput(input *SetupInput, nodeSetOutput []*cre.WrappedNodeOutput, blockchains []blockchains.Blockchain, jdOutput *jd.Output) {
 	// append the nodeset output, so that later it can be stored in the cached output, so that we can use the environment again without running setup
 	for idx, nsOut := range nodeSetOutput {
@@ -0,0 +1,159 @@
+package environment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+)
+
+// fingerprintVersion is part of the fingerprint, bump it when the set of fingerprinted inputs changes
+const fingerprintVersion = 1
+
+// fingerprintIgnoredKeys are keys of JSON objects, which don't define the environment: cached outputs of components
+var fingerprintIgnoredKeys = []string{"Out"}
+
+// Fingerprint returns a stable SHA-256 fingerprint of the environment definition: topology, images, capability binaries
+// and configs of nodes, chains and contracts. Local binaries contribute their content instead of their paths, so copying
+// the same binaries elsewhere keeps the fingerprint, while rebuilding them changes it. Outputs of components cached by
+// previous runs, run IDs and test names are not part of it. Provisioning checkpoints and nodeset snapshots are reused
+// only by environments with the same fingerprint, see ContractsFingerprint for the contract deployment cache.
+func Fingerprint(input *SetupInput) (string, error) {
+	capabilities := make([]cre.CapabilityFlag, 0, len(input.Capabilities))
+	for _, capability := range input.Capabilities {
+		capabilities = append(capabilities, capability.Flag())
+	}
+	slices.Sort(capabilities)
+
+	definition := map[string]any{
+		"version":                fingerprintVersion,
+		"infra":                  input.Provider.Type,
+		"nodesets":               input.CapabilitiesAwareNodeSets,
+		"blockchains":            input.BlockchainsInput,
+		"jd":                     input.JdInput,
+		"contract_versions":      input.ContractVersions,
+		"v2_registries":          input.WithV2Registries,
+		"ocr3_config":            input.OCR3Config,
+		"don_time_config":        input.DONTimeConfig,
+		"vault_ocr3_config":      input.VaultOCR3Config,
+		"capability_configs":     input.CapabilityConfigs,
+		"chain_finality":         input.ChainFinalityConfigs,
+		"feature_flags":          input.FeatureFlags,
+		"capabilities":           capabilities,
+		"copy_capability_binary": input.CopyCapabilityBinaries,
+	}
+
+	digests, digestsErr := binaryDigests(input)
+	if digestsErr != nil {
+		return "", digestsErr
+	}
+
+	return fingerprintOf(definition, digests)
+}
+
+// ContractsFingerprint returns the fingerprint of the part of the environment definition, which determines deployed
+// contracts: chains and versions of contracts. Unlike Fingerprint it doesn't change with nodes, so that the contract
+// deployment cache reuses contracts across runs, which differ only in their nodes.
+func ContractsFingerprint(input *SetupInput) (string, error) {
+	return fingerprintOf(map[string]any{
+		"version":           fingerprintVersion,
+		"blockchains":       input.BlockchainsInput,
+		"contract_versions": input.ContractVersions,
+		"v2_registries":     input.WithV2Registries,
+	}, nil)
+}
+
+// fingerprintOf hashes the canonical JSON encoding of the definition, strings found in digests are replaced by them
+func fingerprintOf(definition map[string]any, digests map[string]string) (string, error) {
+	encoded, marshalErr := json.Marshal(definition)
+	if marshalErr != nil {
+		return "", pkgerrors.Wrap(marshalErr, "failed to encode environment definition")
+	}
+	var tree any
+	if err := json.Unmarshal(encoded, &tree); err != nil {
+		return "", pkgerrors.Wrap(err, "failed to decode environment definition")
+	}
+
+	// maps are encoded with sorted keys, so the canonical encoding is deterministic
+	canonical, canonicalErr := json.Marshal(canonicalizeDefinition(tree, digests))
+	if canonicalErr != nil {
+		return "", pkgerrors.Wrap(canonicalErr, "failed to encode canonical environment definition")
+	}
+	sum := sha256.Sum256(canonical)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// binaryDigests maps paths of local capability binaries to digests of their content
+func binaryDigests(input *SetupInput) (map[string]string, error) {
+	paths := make([]string, 0)
+	for _, config := range input.CapabilityConfigs {
+		paths = append(paths, config.BinaryPath)
+	}
+	for _, nodeSet := range input.CapabilitiesAwareNodeSets {
+		for _, nodeBinaries := range nodeSet.NodeCapabilityBinaries {
+			for _, binaryPath := range nodeBinaries {
+				paths = append(paths, binaryPath)
+			}
+		}
+		for _, labelBinaries := range nodeSet.LabelCapabilityBinaries {
+			for _, binaryPath := range labelBinaries {
+				paths = append(paths, binaryPath)
+			}
+		}
+		for _, nodeSpec := range nodeSet.NodeSpecs {
+			paths = append(paths, nodeSpec.Node.CapabilitiesBinaryPaths...)
+		}
+	}
+
+	digests := make(map[string]string)
+	for _, binaryPath := range paths {
+		if binaryPath == "" || binaries.IsRemote(binaryPath) {
+			continue
+		}
+		if _, ok := digests[binaryPath]; ok {
+			continue
+		}
+		digest, digestErr := binaries.FileDigest(binaryPath)
+		if digestErr != nil {
+			return nil, pkgerrors.Wrapf(digestErr, "failed to compute digest of capability binary %s", binaryPath)
+		}
+		// the name is kept, because job specs reference binaries by it
+		digests[binaryPath] = binaries.Name(binaryPath) + "@sha256:" + digest
+	}
+
+	return digests, nil
+}
+
+func canonicalizeDefinition(value any, digests map[string]string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		canonical := make(map[string]any, len(v))
+		for key, nested := range v {
+			if slices.ContainsFunc(fingerprintIgnoredKeys, func(ignored string) bool { return strings.EqualFold(key, ignored) }) {
+				continue
+			}
+			canonical[key] = canonicalizeDefinition(nested, digests)
+		}
+		return canonical
+	case []any:
+		canonical := make([]any, len(v))
+		for i, nested := range v {
+			canonical[i] = canonicalizeDefinition(nested, digests)
+		}
+		return canonical
+	case string:
+		if digest, ok := digests[v]; ok {
+			return digest
+		}
+		return v
+	default:
+		return v
+	}
+}
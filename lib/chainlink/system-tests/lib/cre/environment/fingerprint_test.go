@@ -0,0 +1,98 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+)
+
+func fingerprintInput(binaryPath string) *SetupInput {
+	return &SetupInput{
+		CapabilitiesAwareNodeSets: []*cre.CapabilitiesAwareNodeSet{{Input: &ns.Input{
+			Name:      "workflow",
+			NodeSpecs: []*clnode.Input{{Node: &clnode.NodeInput{Image: "chainlink:test", CapabilitiesBinaryPaths: []string{binaryPath}}}},
+		}}},
+		BlockchainsInput: []*blockchain.Input{{Type: blockchain.TypeAnvil, ChainID: "1337"}},
+		ContractVersions: map[string]string{"CapabilitiesRegistry": "1.1.0"},
+		CapabilityConfigs: cre.CapabilityConfigs{
+			cre.CronCapability: {BinaryPath: binaryPath},
+		},
+	}
+}
+
+func writeBinary(t *testing.T, dir, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "cron")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestFingerprintUsesContentOfBinaries(t *testing.T) {
+	first := writeBinary(t, filepath.Join(t.TempDir(), "first"), "cron v1")
+	copied := writeBinary(t, filepath.Join(t.TempDir(), "copied"), "cron v1")
+	rebuilt := writeBinary(t, filepath.Join(t.TempDir(), "rebuilt"), "cron v2")
+
+	fingerprint, err := Fingerprint(fingerprintInput(first))
+	require.NoError(t, err)
+	require.Len(t, fingerprint, 64)
+
+	copiedFingerprint, err := Fingerprint(fingerprintInput(copied))
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, copiedFingerprint, "binaries with the same content at another path changed the fingerprint")
+
+	rebuiltFingerprint, err := Fingerprint(fingerprintInput(rebuilt))
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, rebuiltFingerprint, "rebuilt binary didn't change the fingerprint")
+}
+
+func TestFingerprintIgnoresOutputsOfComponents(t *testing.T) {
+	binaryPath := writeBinary(t, t.TempDir(), "cron v1")
+	input := fingerprintInput(binaryPath)
+	fingerprint, err := Fingerprint(input)
+	require.NoError(t, err)
+
+	input.CapabilitiesAwareNodeSets[0].Out = &ns.Output{CLNodes: []*clnode.Output{{Node: &clnode.NodeOut{ExternalURL: "http://localhost:6688"}}}}
+	input.BlockchainsInput[0].Out = &blockchain.Output{ChainID: "1337"}
+	input.RunID = "other-run"
+	withOutputs, err := Fingerprint(input)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, withOutputs)
+
+	input.CapabilitiesAwareNodeSets[0].NodeSpecs[0].Node.Image = "chainlink:other"
+	otherImage, err := Fingerprint(input)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, otherImage)
+}
+
+func TestFingerprintFailsForMissingLocalBinaries(t *testing.T) {
+	_, err := Fingerprint(fingerprintInput(filepath.Join(t.TempDir(), "missing")))
+	require.ErrorContains(t, err, "failed to compute digest of capability binary")
+}
+
+func TestContractsFingerprintIgnoresNodes(t *testing.T) {
+	input := fingerprintInput(writeBinary(t, t.TempDir(), "cron v1"))
+	fingerprint, err := ContractsFingerprint(input)
+	require.NoError(t, err)
+
+	input.CapabilitiesAwareNodeSets[0].NodeSpecs[0].Node.Image = "chainlink:other"
+	otherNodes, err := ContractsFingerprint(input)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, otherNodes)
+
+	input.ContractVersions["CapabilitiesRegistry"] = "2.0.0"
+	otherContracts, err := ContractsFingerprint(input)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, otherContracts)
+}
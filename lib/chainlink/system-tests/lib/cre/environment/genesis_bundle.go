@@ -0,0 +1,218 @@
+package environment
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	envconfig "github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/config"
+)
+
+const (
+	// GenesisBundleFormatVersion is increased only on breaking changes of the format, new fields can be added at any time
+	GenesisBundleFormatVersion = 1
+	GenesisBundleFileName      = "genesis_bundle.json"
+)
+
+// GenesisBundle describes a provisioned environment for external tooling (frontends, explorers, monitoring), which
+// needs to know where contracts are deployed, which DONs exist and how to reach them, but not how they were provisioned.
+// Unlike EnvArtifact, which is an internal format used to recreate the environment, it contains only public data
+// and its format is stable: consumers should check FormatVersion and ignore unknown fields.
+type GenesisBundle struct {
+	FormatVersion int       `json:"format_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	// RegistryChainSelector is the chain selector of the chain with the capabilities and workflow registries
+	RegistryChainSelector uint64            `json:"registry_chain_selector"`
+	Chains                []GenesisChain    `json:"chains"`
+	Contracts             []GenesisContract `json:"contracts"`
+	DONs                  []GenesisDON      `json:"dons"`
+	Gateways              []GenesisGateway  `json:"gateways"`
+}
+
+type GenesisChain struct {
+	ChainSelector uint64 `json:"chain_selector"`
+	ChainID       string `json:"chain_id"`
+	Family        string `json:"family"`   // e.g. evm, solana, tron
+	HTTPURL       string `json:"http_url"` // RPC endpoint reachable from the host
+	WSURL         string `json:"ws_url,omitempty"`
+}
+
+type GenesisContract struct {
+	ChainSelector uint64 `json:"chain_selector"`
+	Type          string `json:"type"` // e.g. CapabilitiesRegistry, WorkflowRegistry, KeystoneForwarder
+	Version       string `json:"version"`
+	Address       string `json:"address"`
+	Qualifier     string `json:"qualifier,omitempty"`
+}
+
+type GenesisDON struct {
+	Name  string        `json:"name"`
+	ID    uint64        `json:"id"` // DON ID in the capabilities registry
+	F     uint8         `json:"f"`  // number of faulty nodes tolerated
+	Flags []string      `json:"flags"`
+	Nodes []GenesisNode `json:"nodes"`
+}
+
+type GenesisNode struct {
+	Name  string   `json:"name"`
+	Alias string   `json:"alias"` // infra-independent name, see infra.NodeAlias
+	Roles []string `json:"roles"`
+	// PeerID is the libp2p peer ID used for OCR and DON-to-DON communication, without the p2p_ prefix
+	PeerID string `json:"peer_id,omitempty"`
+	// CSAPublicKey identifies the node in the Job Distributor
+	CSAPublicKey string `json:"csa_public_key,omitempty"`
+	// EVMAddresses are transmitter addresses keyed by chain ID
+	EVMAddresses map[string]string `json:"evm_addresses,omitempty"`
+	// SolanaAddresses are transmitter public keys keyed by chain ID
+	SolanaAddresses map[string]string `json:"solana_addresses,omitempty"`
+}
+
+type GenesisGateway struct {
+	URL string `json:"url"` // incoming endpoint reachable from the host, e.g. for HTTP trigger requests
+	// AuthGatewayID is the ID used in requests signed for this gateway
+	AuthGatewayID string `json:"auth_gateway_id,omitempty"`
+}
+
+// NewGenesisBundle collects public data of the environment into a genesis bundle
+func NewGenesisBundle(dons *cre.Dons, creEnv *cre.Environment) (*GenesisBundle, error) {
+	bundle := &GenesisBundle{
+		FormatVersion:         GenesisBundleFormatVersion,
+		GeneratedAt:           time.Now().UTC(),
+		RegistryChainSelector: creEnv.RegistryChainSelector,
+		Chains:                make([]GenesisChain, 0, len(creEnv.Blockchains)),
+		Contracts:             make([]GenesisContract, 0),
+		DONs:                  make([]GenesisDON, 0, len(dons.List())),
+		Gateways:              make([]GenesisGateway, 0),
+	}
+
+	for _, chain := range creEnv.Blockchains {
+		genesisChain := GenesisChain{
+			ChainSelector: chain.ChainSelector(),
+			ChainID:       fmt.Sprint(chain.ChainID()),
+			Family:        chain.ChainFamily(),
+		}
+		if out := chain.CtfOutput(); out != nil {
+			genesisChain.ChainID = out.ChainID
+			if len(out.Nodes) > 0 {
+				genesisChain.HTTPURL = out.Nodes[0].ExternalHTTPUrl
+				genesisChain.WSURL = out.Nodes[0].ExternalWSUrl
+			}
+		}
+		bundle.Chains = append(bundle.Chains, genesisChain)
+	}
+
+	if creEnv.CldfEnvironment != nil && creEnv.CldfEnvironment.DataStore != nil {
+		addressRefs, fetchErr := creEnv.CldfEnvironment.DataStore.Addresses().Fetch()
+		if fetchErr != nil {
+			return nil, pkgerrors.Wrap(fetchErr, "failed to fetch addresses from datastore")
+		}
+		for _, ref := range addressRefs {
+			contract := GenesisContract{
+				ChainSelector: ref.ChainSelector,
+				Type:          string(ref.Type),
+				Address:       ref.Address,
+				Qualifier:     ref.Qualifier,
+			}
+			if ref.Version != nil {
+				contract.Version = ref.Version.String()
+			}
+			bundle.Contracts = append(bundle.Contracts, contract)
+		}
+		slices.SortFunc(bundle.Contracts, func(a, b GenesisContract) int {
+			if a.ChainSelector != b.ChainSelector {
+				return cmp.Compare(a.ChainSelector, b.ChainSelector)
+			}
+			return strings.Compare(a.Type, b.Type)
+		})
+	}
+
+	for _, don := range dons.List() {
+		genesisDON := GenesisDON{
+			Name:  don.Name,
+			ID:    don.ID,
+			F:     don.F,
+			Flags: slices.Clone(don.Flags),
+			Nodes: make([]GenesisNode, 0, len(don.Nodes)),
+		}
+		for _, node := range don.Nodes {
+			genesisDON.Nodes = append(genesisDON.Nodes, genesisNode(node))
+		}
+		slices.SortFunc(genesisDON.Nodes, func(a, b GenesisNode) int { return strings.Compare(a.Name, b.Name) })
+		bundle.DONs = append(bundle.DONs, genesisDON)
+	}
+
+	if dons.GatewayConnectors != nil {
+		for _, configuration := range dons.GatewayConnectors.Configurations {
+			incoming := configuration.Incoming
+			bundle.Gateways = append(bundle.Gateways, GenesisGateway{
+				URL:           fmt.Sprintf("%s://%s:%d%s", incoming.Protocol, incoming.Host, incoming.ExternalPort, incoming.Path),
+				AuthGatewayID: configuration.AuthGatewayID,
+			})
+		}
+	}
+
+	return bundle, nil
+}
+
+func genesisNode(node *cre.Node) GenesisNode {
+	genesisNode := GenesisNode{
+		Name:  node.Name,
+		Alias: node.Alias,
+		Roles: node.Roles.Strings(),
+	}
+	if node.Keys == nil {
+		return genesisNode
+	}
+
+	genesisNode.PeerID = strings.TrimPrefix(node.Keys.PeerID(), "p2p_")
+	if node.Keys.CSAKey != nil {
+		genesisNode.CSAPublicKey = node.Keys.CSAKey.Key
+	}
+	if len(node.Keys.EVM) > 0 {
+		genesisNode.EVMAddresses = make(map[string]string, len(node.Keys.EVM))
+		for chainID, key := range node.Keys.EVM {
+			genesisNode.EVMAddresses[fmt.Sprint(chainID)] = key.PublicAddress.Hex()
+		}
+	}
+	if len(node.Keys.Solana) > 0 {
+		genesisNode.SolanaAddresses = make(map[string]string, len(node.Keys.Solana))
+		for chainID, key := range node.Keys.Solana {
+			genesisNode.SolanaAddresses[chainID] = key.PublicAddress.String()
+		}
+	}
+
+	return genesisNode
+}
+
+// ExportGenesisBundle writes the genesis bundle of the environment to absPath and returns it
+func ExportGenesisBundle(absPath string, dons *cre.Dons, creEnv *cre.Environment) (string, error) {
+	bundle, bundleErr := NewGenesisBundle(dons, creEnv)
+	if bundleErr != nil {
+		return "", pkgerrors.Wrap(bundleErr, "failed to generate genesis bundle")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
+		return "", pkgerrors.Wrap(err, "failed to create directory for the genesis bundle")
+	}
+	if err := WriteJSONFile(absPath, bundle); err != nil {
+		return "", pkgerrors.Wrap(err, "failed to write genesis bundle to file")
+	}
+
+	return absPath, nil
+}
+
+func MustGenesisBundleAbsPath(relativePathToRepoRoot string) string {
+	absPath, err := filepath.Abs(filepath.Join(relativePathToRepoRoot, envconfig.StateDirname, GenesisBundleFileName))
+	if err != nil {
+		panic(fmt.Errorf("failed to get absolute path for genesis bundle file: %w", err))
+	}
+
+	return absPath
+}
@@ -0,0 +1,130 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/clclient"
+
+	crecapabilities "github.com/smartcontractkit/chainlink/system-tests/lib/cre/capabilities"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/logs"
+)
+
+// chainHealthCheckPrefixes start names of health checks of chain connections nodes report at /health
+var chainHealthCheckPrefixes = []string{"EVM.", "Solana.", "Aptos.", "Tron."}
+
+// maxFatalLinesPerNode limits panic and fatal lines of a node included in the verdict, the rest are in node logs
+const maxFatalLinesPerNode = 3
+
+// HealthVerdict is the result of end-of-test health checks of all DONs, see CheckHealthAtEnd
+type HealthVerdict struct {
+	Problems []string
+	// Skipped lists checks, which aren't supported by the infra
+	Skipped []string
+}
+
+func (v *HealthVerdict) Healthy() bool {
+	return len(v.Problems) == 0
+}
+
+// CheckHealthAtEnd checks that the environment stayed healthy during the test: no node was restarted by its infra after
+// it exited, no node logged a panic or a fatal error, plugins of all capability jobs are running and chain connections
+// of all nodes are healthy. Nodes restarted by the test with infra.Provider.RestartNode aren't counted as restarted.
+// Logs are checked only in Docker.
+func (o *SetupOutput) CheckHealthAtEnd(ctx context.Context) *HealthVerdict {
+	verdict := &HealthVerdict{}
+	provider := &o.CreEnvironment.Provider
+
+	var nodeLogs map[string][]byte
+	if provider.IsDocker() {
+		var logsErr error
+		if nodeLogs, logsErr = logs.ReadDockerNodeLogs(""); logsErr != nil {
+			verdict.Problems = append(verdict.Problems, fmt.Sprintf("failed to read node logs: %s", logsErr))
+		}
+	} else {
+		verdict.Skipped = append(verdict.Skipped, fmt.Sprintf("panic and fatal logs aren't checked in %s", provider.Type))
+	}
+
+	for _, don := range o.Dons.List() {
+		for _, node := range don.Nodes {
+			restarts, restartsErr := provider.NodeRestartCount(ctx, node.Index, don.Name)
+			switch {
+			case restartsErr != nil:
+				verdict.Problems = append(verdict.Problems, fmt.Sprintf("%s: failed to check restarts: %s", node.Name, restartsErr))
+			case restarts > 0:
+				verdict.Problems = append(verdict.Problems, fmt.Sprintf("%s: restarted %d time(s) after it exited", node.Name, restarts))
+			}
+
+			if nodeLogs != nil {
+				fatalLines := logs.FatalLines(nodeLogs[node.Name])
+				for _, line := range fatalLines[:min(len(fatalLines), maxFatalLinesPerNode)] {
+					verdict.Problems = append(verdict.Problems, fmt.Sprintf("%s: logged %s", node.Name, line))
+				}
+				if len(fatalLines) > maxFatalLinesPerNode {
+					verdict.Problems = append(verdict.Problems, fmt.Sprintf("%s: logged %d more panic or fatal lines", node.Name, len(fatalLines)-maxFatalLinesPerNode))
+				}
+			}
+
+			if node.Clients.RestClient == nil {
+				verdict.Problems = append(verdict.Problems, fmt.Sprintf("%s: node has no API client, chain connections can't be checked", node.Name))
+				continue
+			}
+			health, _, healthErr := node.Clients.RestClient.Health()
+			if healthErr != nil {
+				verdict.Problems = append(verdict.Problems, fmt.Sprintf("%s: failed to read health: %s", node.Name, healthErr))
+				continue
+			}
+			for _, check := range failingChainChecks(health) {
+				verdict.Problems = append(verdict.Problems, fmt.Sprintf("%s: chain connection %s isn't healthy", node.Name, check))
+			}
+		}
+
+		for _, status := range crecapabilities.JobsReadiness(ctx, don).NotReady() {
+			line := fmt.Sprintf("%s: capability %s is %s", status.Node, status.Capability, status.Status)
+			if status.Detail != "" {
+				line += " (" + status.Detail + ")"
+			}
+			verdict.Problems = append(verdict.Problems, line)
+		}
+	}
+
+	return verdict
+}
+
+// AssertHealthyAtEnd fails the test, if the environment didn't stay healthy, see CheckHealthAtEnd. Call it at the end
+// of the test, e.g. with t.Cleanup, before the environment is torn down.
+func (o *SetupOutput) AssertHealthyAtEnd(t testing.TB) {
+	t.Helper()
+
+	verdict := o.CheckHealthAtEnd(context.Background())
+	for _, skipped := range verdict.Skipped {
+		t.Logf("health check skipped: %s", skipped)
+	}
+	if !verdict.Healthy() {
+		t.Errorf("environment isn't healthy at the end of the test:\n%s", strings.Join(verdict.Problems, "\n"))
+	}
+}
+
+// failingChainChecks returns names and outputs of health checks of chain connections, which aren't passing
+func failingChainChecks(health *clclient.HealthResponse) []string {
+	var failing []string
+	for _, check := range health.Data {
+		name := check.Attributes.Name
+		if !slices.ContainsFunc(chainHealthCheckPrefixes, func(prefix string) bool { return strings.HasPrefix(name, prefix) }) {
+			continue
+		}
+		if check.Attributes.Status == "passing" {
+			continue
+		}
+		if output := strings.TrimSpace(check.Attributes.Output); output != "" {
+			name += ": " + output
+		}
+		failing = append(failing, name)
+	}
+	slices.Sort(failing)
+
+	return failing
+}
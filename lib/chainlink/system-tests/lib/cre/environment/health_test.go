@@ -0,0 +1,22 @@
+package environment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/clclient"
+)
+
+func TestFailingChainChecks(t *testing.T) {
+	health := &clclient.HealthResponse{}
+	require.NoError(t, json.Unmarshal([]byte(`{"data":[
+		{"attributes":{"name":"EVM.1337.HeadTracker","status":"passing"}},
+		{"attributes":{"name":"EVM.2337.Txm","status":"failing","output":"no new heads received for 5m"}},
+		{"attributes":{"name":"Solana.devnet.Relayer","status":"failing"}},
+		{"attributes":{"name":"JobSpawner","status":"failing"}}
+	]}`), health))
+
+	require.Equal(t, []string{"EVM.2337.Txm: no new heads received for 5m", "Solana.devnet.Relayer"}, failingChainChecks(health))
+}
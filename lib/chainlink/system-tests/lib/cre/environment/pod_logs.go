@@ -0,0 +1,46 @@
+package environment
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/logs"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// DefaultPodLogsDir is where DownloadPodLogs stores logs of pods, it's inside DefaultLogsDir, so that PublishArtifacts
+// stores them with other logs of the run
+var DefaultPodLogsDir = filepath.Join(DefaultLogsDir, "pods")
+
+// DownloadPodLogs downloads logs of all pods in the namespace of CRIB or Kubernetes to DefaultPodLogsDir, it does nothing
+// for other providers. Namespace and Dir of the config are set by the function, its other fields limit the download.
+// Call it at teardown before the namespace is removed. A failed download is resumed, when it's called again.
+func DownloadPodLogs(ctx context.Context, testLogger zerolog.Logger, provider infra.Provider, config logs.KubernetesLogsConfig) error {
+	if !provider.IsCRIB() && !provider.IsKubernetes() {
+		return nil
+	}
+
+	config.Namespace = provider.KubernetesNamespace()
+	config.Dir = DefaultPodLogsDir
+	podLogs, downloadErr := logs.DownloadKubernetesLogs(ctx, config)
+	if downloadErr != nil {
+		return pkgerrors.Wrap(downloadErr, "failed to download logs of pods")
+	}
+
+	var failed []string
+	for _, pod := range podLogs {
+		testLogger.Info().Msgf("Logs of pod %s", pod)
+		if pod.Err != nil {
+			failed = append(failed, pod.Pod)
+		}
+	}
+	if len(failed) > 0 {
+		return pkgerrors.Errorf("failed to download logs of pods %s, call DownloadPodLogs again to resume", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package environment
+
+import (
+	"context"
+	"maps"
+	"os"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/artifacts"
+	envconfig "github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/config"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/grafana"
+)
+
+// DefaultLogsDir is where CTF saves logs of containers
+const DefaultLogsDir = "logs"
+
+// DashboardSnapshots selects Grafana panels rendered for the time range of the run, see grafana.RenderPanels
+type DashboardSnapshots struct {
+	Config   grafana.SnapshotConfig
+	From, To time.Time
+}
+
+// PublishArtifacts stores the environment artifact, local CRE state and logs of the run (container logs, host process logs
+// and logs of pods downloaded by DownloadPodLogs) in the configured storage. Extra files, e.g. reports or profiles produced
+// by tests, are stored under the given kinds.
+// If dashboards are given, their panels are rendered and stored as dashboards, so call it at teardown, while the
+// observability stack still runs. Missing files and directories are skipped, so it's safe to call it also after a failed setup.
+func PublishArtifacts(ctx context.Context, testLogger zerolog.Logger, config *artifacts.Config, relativePathToRepoRoot string, extra map[artifacts.Kind][]string, dashboards *DashboardSnapshots) (artifacts.Layout, error) {
+	store, layout, storeErr := artifacts.New(config)
+	if storeErr != nil {
+		return layout, pkgerrors.Wrap(storeErr, "failed to create artifact storage")
+	}
+
+	if dashboards != nil {
+		snapshotsDir, dirErr := os.MkdirTemp("", "dashboards-")
+		if dirErr != nil {
+			return layout, pkgerrors.Wrap(dirErr, "failed to create directory for dashboard snapshots")
+		}
+		defer os.RemoveAll(snapshotsDir)
+
+		// rendering failures are logged by RenderPanels, a missing summary must not fail the teardown
+		if _, renderErr := grafana.RenderPanels(ctx, testLogger, dashboards.Config, dashboards.From, dashboards.To, snapshotsDir); renderErr != nil {
+			testLogger.Warn().Err(renderErr).Msg("Failed to render dashboard snapshots")
+		}
+		extra = maps.Clone(extra)
+		if extra == nil {
+			extra = make(map[artifacts.Kind][]string)
+		}
+		extra[artifacts.KindDashboards] = append(extra[artifacts.KindDashboards], snapshotsDir)
+	}
+
+	files := map[artifacts.Kind][]string{
+		artifacts.KindState: {
+			MustEnvArtifactAbsPath(relativePathToRepoRoot),
+			envconfig.MustLocalCREStateFileAbsPath(relativePathToRepoRoot),
+		},
+	}
+	for kind, paths := range extra {
+		files[kind] = append(files[kind], paths...)
+	}
+
+	for kind, paths := range files {
+		for _, path := range paths {
+			stat, statErr := os.Stat(path)
+			if statErr != nil {
+				testLogger.Debug().Msgf("Skipping missing artifact %s", path)
+				continue
+			}
+
+			if stat.IsDir() {
+				count, uploadErr := artifacts.UploadDir(ctx, store, layout, kind, path)
+				if uploadErr != nil {
+					return layout, uploadErr
+				}
+				testLogger.Info().Msgf("Stored %d %s artifact(s) from %s", count, kind, path)
+				continue
+			}
+
+			location, uploadErr := artifacts.UploadFile(ctx, store, layout, kind, path)
+			if uploadErr != nil {
+				return layout, uploadErr
+			}
+			testLogger.Info().Msgf("Stored %s artifact %s", kind, location)
+		}
+	}
+
+	// host process logs are also there, see infra.DefaultHostProcessLogsDir
+	if _, statErr := os.Stat(DefaultLogsDir); statErr == nil {
+		count, uploadErr := artifacts.UploadDir(ctx, store, layout, artifacts.KindLogs, DefaultLogsDir)
+		if uploadErr != nil {
+			return layout, uploadErr
+		}
+		testLogger.Info().Msgf("Stored %d log file(s) from %s", count, DefaultLogsDir)
+	}
+
+	return layout, nil
+}
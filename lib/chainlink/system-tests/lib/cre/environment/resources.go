@@ -0,0 +1,73 @@
+package environment
+
+import (
+	"fmt"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/jd"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/postgres"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+const (
+	ResourceRoleDatabase       = "database"
+	ResourceRoleBlockchain     = "blockchain"
+	ResourceRoleJobDistributor = "job-distributor"
+
+	ResourceRoleGatewayLoadBalancer = "gateway-load-balancer"
+	ResourceRoleCustomContainer     = "custom-container"
+)
+
+// indexResources registers all containers, volumes and pods of the environment in the resource index. Resources backing DONs
+// are labelled with DON name, node role and node alias. Names follow the conventions used by CTF's simple node set and by CRIB node components.
+func indexResources(index *infra.ResourceIndex, provider infra.Provider, topology *cre.Topology, deployedBlockchains []blockchains.Blockchain, jdOutput *jd.Output) {
+	if provider.IsCRIB() {
+		index.Add(infra.ResourceNamespace, provider.CRIB.Namespace, nil)
+	}
+
+	for _, donMetadata := range topology.DonsMetadata.List() {
+		donLabels := infra.Labels{infra.LabelDON: donMetadata.Name}
+
+		for _, nodeMetadata := range donMetadata.NodesMetadata {
+			isBootstrap := nodeMetadata.HasRole(cre.BootstrapNode)
+			role := infra.NodeAliasRole(isBootstrap, nodeMetadata.HasRole(cre.GatewayNode))
+			nodeLabels := donLabels.With(infra.Labels{infra.LabelRole: role, infra.LabelNode: nodeMetadata.Alias})
+
+			if provider.IsCRIB() {
+				index.Add(infra.ResourcePod, infra.CRIBNodeName(nodeMetadata.Index, isBootstrap, donMetadata.Name), nodeLabels)
+				continue
+			}
+
+			containerName := ns.NodeNamePrefix(donMetadata.Name) + fmt.Sprint(nodeMetadata.Index)
+			index.Add(infra.ResourceContainer, containerName, nodeLabels)
+			index.Add(infra.ResourceVolume, clnode.ConfigVolumeName+"-"+containerName, nodeLabels)
+			index.Add(infra.ResourceVolume, clnode.HomeVolumeName+"-"+containerName, nodeLabels)
+		}
+
+		if provider.IsDocker() {
+			dbLabels := donLabels.With(infra.Labels{infra.LabelRole: ResourceRoleDatabase})
+			index.Add(infra.ResourceContainer, fmt.Sprintf("%s-%s", donMetadata.Name, "ns-postgresql"), dbLabels)
+			index.Add(infra.ResourceVolume, postgres.DBVolumeName+donMetadata.Name, dbLabels)
+		}
+	}
+
+	if !provider.IsDocker() {
+		return
+	}
+
+	// the CTF network isn't indexed, it's shared by all environments on the host
+	for _, bc := range deployedBlockchains {
+		if bcOut := bc.CtfOutput(); bcOut != nil && bcOut.ContainerName != "" {
+			index.Add(infra.ResourceContainer, bcOut.ContainerName, infra.Labels{infra.LabelRole: ResourceRoleBlockchain})
+		}
+	}
+	if jdOutput != nil {
+		jdLabels := infra.Labels{infra.LabelRole: ResourceRoleJobDistributor}
+		index.Add(infra.ResourceContainer, jdOutput.ContainerName, jdLabels)
+		index.Add(infra.ResourceContainer, jdOutput.DBContainerName, jdLabels)
+	}
+}
@@ -0,0 +1,137 @@
+// Package schema versions files that outlive a single run (environment state, exported artifacts) and migrates files
+// written by older versions of the framework, so that reusable environments and stored CI artifacts stay readable.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// VersionKey is the top-level key holding the schema version. Files without it have version 0.
+const VersionKey = "schema_version"
+
+// Migration changes a document from version From to From+1. Apply can be nil, if only the version number changes.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(doc map[string]any) error
+}
+
+type Migrator struct {
+	Name       string // used in errors, e.g. "environment artifact"
+	Current    int
+	Migrations []Migration
+}
+
+// Version returns the schema version of the document
+func (m *Migrator) Version(doc map[string]any) (int, error) {
+	raw, ok := doc[VersionKey]
+	if !ok {
+		return 0, nil
+	}
+
+	switch v := raw.(type) {
+	case int64:
+		return int(v), nil
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s of %s: %w", VersionKey, m.Name, err)
+		}
+		return int(i), nil
+	default:
+		return 0, fmt.Errorf("invalid %s of %s: %v", VersionKey, m.Name, raw)
+	}
+}
+
+// Migrate migrates the document to the current version in place. It returns descriptions of applied migrations and
+// whether any of them changed the document beyond the version number.
+func (m *Migrator) Migrate(doc map[string]any) ([]string, bool, error) {
+	version, versionErr := m.Version(doc)
+	if versionErr != nil {
+		return nil, false, versionErr
+	}
+	if version > m.Current {
+		return nil, false, fmt.Errorf("%s has schema version %d, but only versions up to %d are supported. It was written by a newer version of the framework, upgrade it", m.Name, version, m.Current)
+	}
+
+	var applied []string
+	changed := false
+	for version < m.Current {
+		migration, ok := m.migrationFrom(version)
+		if !ok {
+			return nil, false, fmt.Errorf("no migration of %s from schema version %d", m.Name, version)
+		}
+		if migration.Apply != nil {
+			if err := migration.Apply(doc); err != nil {
+				return nil, false, fmt.Errorf("failed to migrate %s from schema version %d (%s): %w", m.Name, version, migration.Description, err)
+			}
+			changed = true
+		}
+		applied = append(applied, fmt.Sprintf("%d -> %d: %s", version, version+1, migration.Description))
+		version++
+	}
+	doc[VersionKey] = m.Current
+
+	return applied, changed, nil
+}
+
+func (m *Migrator) migrationFrom(version int) (Migration, bool) {
+	for _, migration := range m.Migrations {
+		if migration.From == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// MigrateJSON migrates a JSON document. The original content is returned, if no migration changed it, so that
+// formatting is preserved. Numbers are decoded as json.Number to keep precision of large integers (e.g. chain selectors).
+func (m *Migrator) MigrateJSON(content []byte) ([]byte, []string, error) {
+	doc := make(map[string]any)
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s: %w", m.Name, err)
+	}
+
+	applied, changed, err := m.Migrate(doc)
+	if err != nil || !changed {
+		return content, applied, err
+	}
+
+	migrated, mErr := json.MarshalIndent(doc, "", "  ")
+	if mErr != nil {
+		return nil, nil, fmt.Errorf("failed to encode migrated %s: %w", m.Name, mErr)
+	}
+
+	return migrated, applied, nil
+}
+
+// MigrateTOML migrates a TOML document, see MigrateJSON
+func (m *Migrator) MigrateTOML(content []byte) ([]byte, []string, error) {
+	doc := make(map[string]any)
+	if err := toml.Unmarshal(content, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s: %w", m.Name, err)
+	}
+
+	applied, changed, err := m.Migrate(doc)
+	if err != nil || !changed {
+		return content, applied, err
+	}
+
+	migrated, mErr := toml.Marshal(doc)
+	if mErr != nil {
+		return nil, nil, fmt.Errorf("failed to encode migrated %s: %w", m.Name, mErr)
+	}
+
+	return migrated, applied, nil
+}
@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMigrator() *Migrator {
+	return &Migrator{
+		Name:    "test file",
+		Current: 2,
+		Migrations: []Migration{
+			{From: 0, Description: "add schema version"},
+			{From: 1, Description: "rename jd_config to jd", Apply: func(doc map[string]any) error {
+				if jd, ok := doc["jd_config"]; ok {
+					doc["jd"] = jd
+					delete(doc, "jd_config")
+				}
+				return nil
+			}},
+		},
+	}
+}
+
+func TestMigrateJSON(t *testing.T) {
+	migrated, applied, err := testMigrator().MigrateJSON([]byte(`{"home_chain_selector": 16015286601757825753, "jd_config": {"url": "localhost"}}`))
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+
+	content := string(migrated)
+	assert.Contains(t, content, `"schema_version": 2`)
+	assert.Contains(t, content, `"jd": {`)
+	assert.NotContains(t, content, "jd_config")
+	// large integers must not lose precision
+	assert.Contains(t, content, "16015286601757825753")
+}
+
+func TestMigrateKeepsUnchangedContent(t *testing.T) {
+	m := testMigrator()
+	m.Current = 1
+
+	content := []byte("[jd]\nurl = 'localhost'\n")
+	migrated, applied, err := m.MigrateTOML(content)
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, content, migrated)
+}
+
+func TestMigrateTOML(t *testing.T) {
+	migrated, _, err := testMigrator().MigrateTOML([]byte("schema_version = 1\n[jd_config]\nurl = 'localhost'\n"))
+	require.NoError(t, err)
+	assert.Contains(t, string(migrated), "schema_version = 2")
+	assert.Contains(t, string(migrated), "[jd]")
+}
+
+func TestMigrateRejectsNewerVersion(t *testing.T) {
+	_, _, err := testMigrator().MigrateJSON([]byte(`{"schema_version": 3}`))
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "newer version of the framework"), err.Error())
+}
+
+func TestMigrateFailsOnMissingMigration(t *testing.T) {
+	m := testMigrator()
+	m.Migrations = m.Migrations[1:]
+
+	_, _, err := m.MigrateJSON([]byte(`{}`))
+	require.ErrorContains(t, err, "no migration of test file from schema version 0")
+}
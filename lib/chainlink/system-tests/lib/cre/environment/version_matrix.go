@@ -0,0 +1,135 @@
+package environment
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// VersionMatrixTest runs against the environment set up for a case of the version matrix
+type VersionMatrixTest = func(ctx context.Context, matrixCase cre.VersionMatrixCase, output *SetupOutput) error
+
+// RunVersionMatrix sets up the environment for each case of the matrix, with nodesets and capability configs of the
+// input changed by cre.VersionMatrixCase.Apply, runs the test against it and removes the environment before the next
+// case, so cases don't share any state. Failed cases don't stop the others, the error lists all of them. Only Docker
+// is supported, because environments are removed between cases.
+//
+// Experimental: see cre.VersionMatrix.
+func RunVersionMatrix(ctx context.Context, testLogger zerolog.Logger, singleFileLogger logger.Logger, input *SetupInput, relativePathToRepoRoot string, matrix *cre.VersionMatrix, test VersionMatrixTest) error {
+	if input == nil {
+		return pkgerrors.New("input is nil")
+	}
+	if !input.Provider.IsDocker() {
+		return pkgerrors.Errorf("only %s infra is supported for version matrix runs, got %s", infra.Docker, input.Provider.Type)
+	}
+	cases, casesErr := matrix.Cases()
+	if casesErr != nil {
+		return pkgerrors.Wrap(casesErr, "invalid version matrix")
+	}
+
+	return cre.RunVersionMatrix(ctx, cases, func(ctx context.Context, matrixCase cre.VersionMatrixCase) error {
+		testLogger.Info().Msgf("Running version matrix case '%s'", matrixCase.Name)
+		nodeSets, capabilityConfigs, applyErr := matrixCase.Apply(input.CapabilitiesAwareNodeSets, input.CapabilityConfigs)
+		if applyErr != nil {
+			return applyErr
+		}
+
+		return runVersionMatrixCase(ctx, testLogger, singleFileLogger, versionMatrixCaseInput(input, nodeSets, capabilityConfigs), relativePathToRepoRoot, matrixCase, test)
+	})
+}
+
+// versionMatrixCaseInput returns a copy of the input for a case, in which inputs the setup sets outputs or labels on
+// are copied without outputs of previous cases. Each case gets its own run ID, so that its resources can be told apart
+// from resources of other cases. Checkpoints aren't resumed, they would come from a different case.
+func versionMatrixCaseInput(input *SetupInput, nodeSets []*cre.CapabilitiesAwareNodeSet, capabilityConfigs cre.CapabilityConfigs) *SetupInput {
+	caseInput := *input
+	caseInput.RunID = uuid.NewString()
+	caseInput.ResumeFromCheckpoint = false
+	caseInput.CapabilityConfigs = capabilityConfigs
+
+	// nodesets are clones, which share the input of outputs only
+	for _, nodeSet := range nodeSets {
+		nodeSetInput := *nodeSet.Input
+		nodeSetInput.Out = nil
+		nodeSet.Input = &nodeSetInput
+	}
+	caseInput.CapabilitiesAwareNodeSets = nodeSets
+
+	caseInput.BlockchainsInput = make([]*blockchain.Input, len(input.BlockchainsInput))
+	for idx, blockchainInput := range input.BlockchainsInput {
+		blockchainCopy := *blockchainInput
+		blockchainCopy.Out = nil
+		caseInput.BlockchainsInput[idx] = &blockchainCopy
+	}
+	if input.JdInput != nil {
+		jdCopy := *input.JdInput
+		jdCopy.Out = nil
+		caseInput.JdInput = &jdCopy
+	}
+	caseInput.CustomContainers = make([]*infra.CustomContainerInput, len(input.CustomContainers))
+	for idx, customContainer := range input.CustomContainers {
+		containerCopy := *customContainer
+		containerCopy.Out = nil
+		caseInput.CustomContainers[idx] = &containerCopy
+	}
+	if input.GatewayLoadBalancer != nil {
+		lbCopy := *input.GatewayLoadBalancer
+		caseInput.GatewayLoadBalancer = &lbCopy
+	}
+
+	return &caseInput
+}
+
+func runVersionMatrixCase(ctx context.Context, testLogger zerolog.Logger, singleFileLogger logger.Logger, input *SetupInput, relativePathToRepoRoot string, matrixCase cre.VersionMatrixCase, test VersionMatrixTest) (err error) {
+	// resources existing before the case are never removed, even if the setup fails before it indexes its own
+	existing, listErr := infra.DockerResourceNames(ctx)
+	if listErr != nil {
+		return pkgerrors.Wrap(listErr, "failed to list existing Docker resources")
+	}
+
+	output, setupErr := SetupTestEnvironment(ctx, testLogger, singleFileLogger, input, relativePathToRepoRoot)
+	defer func() {
+		// use a fresh context, so that the environment is removed even if the case timed out
+		teardownCtx, teardownCancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer teardownCancel()
+		if teardownErr := removeEnvironment(teardownCtx, output, existing, input.RunID); teardownErr != nil {
+			if err != nil {
+				// don't hide the original error
+				testLogger.Error().Err(teardownErr).Msgf("Failed to remove environment of version matrix case '%s'", matrixCase.Name)
+				return
+			}
+			err = pkgerrors.Wrap(teardownErr, "failed to remove environment")
+		}
+	}()
+	if setupErr != nil {
+		return pkgerrors.Wrap(setupErr, "failed to set up environment")
+	}
+
+	return test(ctx, matrixCase, output)
+}
+
+func removeEnvironment(ctx context.Context, output *SetupOutput, existing map[infra.ResourceKind][]string, runID string) error {
+	var resources *infra.ResourceIndex
+	if output != nil {
+		resources = output.Resources
+	}
+	if resources == nil {
+		// setup failed before resources were indexed, remove what was created by the case
+		var indexErr error
+		resources, indexErr = infra.IndexNewDockerResources(ctx, existing, runID)
+		if indexErr != nil {
+			return indexErr
+		}
+	}
+
+	return infra.RemoveDockerResources(ctx, resources, resources.Labels)
+}
@@ -0,0 +1,40 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/jd"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+)
+
+func TestVersionMatrixCaseInputDropsOutputsOfPreviousCases(t *testing.T) {
+	input := &SetupInput{
+		RunID:                "run",
+		ResumeFromCheckpoint: true,
+		BlockchainsInput:     []*blockchain.Input{{ChainID: "1337", Out: &blockchain.Output{}}},
+		JdInput:              &jd.Input{Out: &jd.Output{}},
+	}
+	nodeSets := []*cre.CapabilitiesAwareNodeSet{{Input: &ns.Input{Name: "workflow", Out: &ns.Output{}}}}
+
+	first := versionMatrixCaseInput(input, []*cre.CapabilitiesAwareNodeSet{nodeSets[0].Clone()}, nil)
+	second := versionMatrixCaseInput(input, []*cre.CapabilitiesAwareNodeSet{nodeSets[0].Clone()}, nil)
+
+	require.Len(t, first.BlockchainsInput, 1)
+	assert.Nil(t, first.BlockchainsInput[0].Out)
+	assert.Equal(t, "1337", first.BlockchainsInput[0].ChainID)
+	assert.Nil(t, first.JdInput.Out)
+	assert.Nil(t, first.CapabilitiesAwareNodeSets[0].Out)
+	assert.False(t, first.ResumeFromCheckpoint)
+	assert.NotEqual(t, first.RunID, second.RunID)
+	assert.NotSame(t, first.BlockchainsInput[0], second.BlockchainsInput[0])
+
+	assert.NotNil(t, input.BlockchainsInput[0].Out, "input isn't changed")
+	assert.NotNil(t, input.JdInput.Out)
+	assert.NotNil(t, nodeSets[0].Out)
+}
@@ -0,0 +1,76 @@
+// Command cre-run starts the minimal DON of an ephemeral run, registers a workflow (if given), runs smoke checks of
+// capabilities enabled on the DON and tears everything down. It exits with a non-zero code if any step failed.
+//
+//	go run ./cre/ephemeral/cmd -capabilities web-api-target -workflow-name smoke -workflow-wasm ./workflow.br64
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/ephemeral"
+)
+
+func main() {
+	var (
+		nodeImage      = flag.String("image", ephemeral.DefaultNodeImage, "image of DON nodes")
+		capabilities   = flag.String("capabilities", "", "comma-separated capability flags enabled on the DON besides consensus and cron")
+		workflowName   = flag.String("workflow-name", "", "name of the workflow to register, requires -workflow-wasm")
+		workflowWasm   = flag.String("workflow-wasm", "", "path to the compressed workflow binary")
+		workflowConfig = flag.String("workflow-config", "", "path to the workflow config file (optional)")
+		timeout        = flag.Duration("timeout", ephemeral.DefaultTimeout, "limit of the whole run including setup")
+		keep           = flag.Bool("keep", false, "keep the environment after the run, use it to debug failures")
+		repoRoot       = flag.String("repo-root", ".", "path to the root of the repository, relative to the working directory")
+	)
+	flag.Parse()
+
+	if err := run(*nodeImage, *capabilities, *workflowName, *workflowWasm, *workflowConfig, *repoRoot, *timeout, *keep); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(nodeImage, capabilities, workflowName, workflowWasm, workflowConfig, repoRoot string, timeout time.Duration, keep bool) error {
+	testLogger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
+	singleFileLogger, loggerErr := logger.New()
+	if loggerErr != nil {
+		return fmt.Errorf("failed to create logger: %w", loggerErr)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var flags []string
+	if capabilities != "" {
+		flags = strings.Split(capabilities, ",")
+	}
+	setupInput := ephemeral.DefaultSetupInput(testLogger, nodeImage, flags...)
+
+	input := &ephemeral.RunInput{
+		Setup:           setupInput,
+		Invoke:          ephemeral.SmokeCheckInvoke(testLogger, setupInput.Features),
+		Timeout:         timeout,
+		KeepEnvironment: keep,
+	}
+	if workflowName != "" || workflowWasm != "" {
+		input.Workflow = &ephemeral.WorkflowInput{Name: workflowName, CompressedWasmPath: workflowWasm, ConfigFilePath: workflowConfig}
+	}
+
+	result, runErr := ephemeral.Run(ctx, testLogger, singleFileLogger, input, repoRoot)
+	if runErr != nil {
+		return runErr
+	}
+	testLogger.Info().Msgf("Ephemeral run passed in %s", result.Duration)
+
+	return nil
+}
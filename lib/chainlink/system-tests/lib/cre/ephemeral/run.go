@@ -0,0 +1,227 @@
+// Package ephemeral runs a single workflow or capability invocation against a freshly started, minimal DON and tears
+// everything down afterwards. It's meant for smoke-testing capability binaries in CI without writing a full test.
+package ephemeral
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/logger"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	crecontracts "github.com/smartcontractkit/chainlink/system-tests/lib/cre/contracts"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains/evm"
+	creworkflow "github.com/smartcontractkit/chainlink/system-tests/lib/cre/workflow"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+const DefaultTimeout = 10 * time.Minute
+
+// WorkflowInput describes a workflow to register with the workflow DON before invocation
+type WorkflowInput struct {
+	Name               string
+	CompressedWasmPath string // output of creworkflow.CompileWorkflow
+	ConfigFilePath     string // optional
+}
+
+// InvokeFn triggers a workflow execution or a capability and returns its result. WorkflowID is empty, if no workflow was registered.
+type InvokeFn = func(ctx context.Context, setupOutput *environment.SetupOutput, workflowID string) ([]byte, error)
+
+type RunInput struct {
+	// Setup should describe a minimal topology, usually a single workflow DON with the tested capability
+	Setup    *environment.SetupInput
+	Workflow *WorkflowInput // optional, capabilities can be invoked directly by Invoke
+	Invoke   InvokeFn
+	Timeout  time.Duration // limits the whole run including setup, DefaultTimeout is used if not set
+	// KeepEnvironment skips the teardown, use it to debug failed runs
+	KeepEnvironment bool
+}
+
+func (r *RunInput) Validate() error {
+	if r.Setup == nil {
+		return errors.New("setup input is required")
+	}
+	if r.Invoke == nil {
+		return errors.New("invoke function is required")
+	}
+	if r.Setup.Provider.Type != infra.Docker {
+		return errors.Errorf("only %s infra is supported for ephemeral runs, got %s", infra.Docker, r.Setup.Provider.Type)
+	}
+	if r.Workflow != nil && (r.Workflow.Name == "" || r.Workflow.CompressedWasmPath == "") {
+		return errors.New("workflow name and compressed wasm path are required")
+	}
+
+	return nil
+}
+
+type RunResult struct {
+	WorkflowID string
+	Output     []byte
+	Duration   time.Duration // of the invocation only, without setup and teardown
+}
+
+// Run starts the environment, registers the workflow (if any), invokes it and removes all resources created for
+// the run, regardless of whether the invocation succeeded.
+func Run(ctx context.Context, testLogger zerolog.Logger, singleFileLogger logger.Logger, input *RunInput, relativePathToRepoRoot string) (result *RunResult, err error) {
+	if err := input.Validate(); err != nil {
+		return nil, errors.Wrap(err, "input validation failed")
+	}
+
+	timeout := input.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// resources are removed by run ID, so that ones of other environments on the host aren't touched
+	if input.Setup.RunID == "" {
+		input.Setup.RunID = uuid.NewString()
+	}
+	existingResources, namesErr := infra.DockerResourceNames(ctx)
+	if namesErr != nil {
+		return nil, errors.Wrap(namesErr, "failed to list existing Docker resources")
+	}
+
+	setupOutput, setupErr := environment.SetupTestEnvironment(ctx, testLogger, singleFileLogger, input.Setup, relativePathToRepoRoot)
+	// deferred first, so that it reports failures of the teardown as well, failures of the setup are reported by it
+	defer func() {
+		if setupErr == nil {
+			setupOutput.Notifier.Finished(context.WithoutCancel(ctx), err)
+		}
+	}()
+	if !input.KeepEnvironment {
+		defer func() {
+			// use a fresh context, so that resources are removed even if the run timed out
+			teardownCtx, teardownCancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer teardownCancel()
+			if teardownErr := teardown(teardownCtx, setupOutput, existingResources, input.Setup.RunID); teardownErr != nil {
+				if err != nil {
+					// don't hide the original error
+					testLogger.Error().Err(teardownErr).Msg("Failed to tear down ephemeral environment")
+					return
+				}
+				err = errors.Wrap(teardownErr, "failed to tear down ephemeral environment")
+			}
+		}()
+	}
+	if setupErr != nil {
+		return nil, errors.Wrap(setupErr, "failed to set up ephemeral environment")
+	}
+
+	result = &RunResult{}
+	if input.Workflow != nil {
+		workflowID, registerErr := registerWorkflow(ctx, setupOutput, input.Workflow)
+		if registerErr != nil {
+			return nil, registerErr
+		}
+		result.WorkflowID = workflowID
+		testLogger.Info().Msgf("Workflow '%s' registered with ID %s", input.Workflow.Name, workflowID)
+	}
+
+	start := time.Now()
+	output, invokeErr := input.Invoke(ctx, setupOutput, result.WorkflowID)
+	result.Duration = time.Since(start)
+	if invokeErr != nil {
+		return result, errors.Wrap(invokeErr, "invocation failed")
+	}
+	result.Output = output
+
+	return result, nil
+}
+
+func registerWorkflow(ctx context.Context, setupOutput *environment.SetupOutput, workflow *WorkflowInput) (string, error) {
+	workflowDON := setupOutput.Dons.MustWorkflowDON()
+
+	filesToCopy := []string{workflow.CompressedWasmPath}
+	configURL := (*string)(nil)
+	if workflow.ConfigFilePath != "" {
+		filesToCopy = append(filesToCopy, workflow.ConfigFilePath)
+		url := "file://" + workflow.ConfigFilePath
+		configURL = &url
+	}
+	if err := creworkflow.CopyArtifactsToDockerContainers(creworkflow.DefaultWorkflowTargetDir, ns.NodeNamePrefix(workflowDON.Name), filesToCopy...); err != nil {
+		return "", errors.Wrap(err, "failed to copy workflow artifacts to Docker containers")
+	}
+
+	registryChain, ok := setupOutput.CreEnvironment.Blockchains[0].(*evm.Blockchain)
+	if !ok {
+		return "", errors.New("registry chain must be an EVM chain")
+	}
+
+	//lint:ignore SA1019 ignoring deprecation warning for this usage
+	workflowRegistryAddress, tv, findErr := crecontracts.FindAddressesForChain(
+		setupOutput.CreEnvironment.CldfEnvironment.ExistingAddresses, //nolint:staticcheck // SA1019 ignoring deprecation warning for this usage
+		setupOutput.CreEnvironment.RegistryChainSelector,
+		keystone_changeset.WorkflowRegistry.String(),
+	)
+	if findErr != nil {
+		return "", errors.Wrap(findErr, "failed to find workflow registry address")
+	}
+
+	containerTargetDir := creworkflow.DefaultWorkflowTargetDir
+	workflowID, registerErr := creworkflow.RegisterWithContract(
+		evm.ContextWithTxManager(ctx, registryChain.TxManager()),
+		registryChain.SethClient,
+		workflowRegistryAddress,
+		tv,
+		workflowDON.ID,
+		workflow.Name,
+		"file://"+workflow.CompressedWasmPath,
+		configURL,
+		nil, // no secrets
+		&containerTargetDir,
+	)
+	if registerErr != nil {
+		return "", errors.Wrapf(registerErr, "failed to register workflow '%s'", workflow.Name)
+	}
+
+	return workflowID, nil
+}
+
+// teardown stops the data generator and removes resources of the run only. If setup failed before it indexed them,
+// resources created by the run since existingResources were listed are removed instead.
+func teardown(ctx context.Context, setupOutput *environment.SetupOutput, existingResources map[infra.ResourceKind][]string, runID string) error {
+	var resources *infra.ResourceIndex
+	var stopErr error
+	if setupOutput != nil {
+		resources = setupOutput.Resources
+		if setupOutput.DataGenerator != nil {
+			stopErr = errors.Wrap(setupOutput.DataGenerator.Stop(), "data generator failed")
+		}
+	}
+	if resources == nil {
+		var indexErr error
+		resources, indexErr = infra.IndexNewDockerResources(ctx, existingResources, runID)
+		if indexErr != nil {
+			return errors.Wrap(indexErr, "failed to index Docker resources of the run")
+		}
+	}
+
+	// an index of another run (e.g. one restored from a stale state file) would remove resources the run doesn't own
+	if !strings.EqualFold(resources.Provider, infra.Docker) || resources.Labels[infra.LabelRunID] != runID {
+		return errors.Wrapf(infra.ErrUnsafeTarget, "resources indexed for run '%s' in %s don't belong to ephemeral run '%s'", resources.Labels[infra.LabelRunID], resources.Provider, runID)
+	}
+
+	if err := infra.RemoveDockerResources(ctx, resources, resources.Labels); err != nil {
+		return err
+	}
+
+	return stopErr
+}
+
+// SmokeCheckInvoke runs smoke checks of given features on all DONs, see cre.RunSmokeChecks. It returns no output and is
+// used by the CLI to smoke-test capability binaries.
+func SmokeCheckInvoke(testLogger zerolog.Logger, features cre.Features) InvokeFn {
+	return func(ctx context.Context, setupOutput *environment.SetupOutput, _ string) ([]byte, error) {
+		return nil, cre.RunSmokeChecks(ctx, testLogger, features, setupOutput.Dons, setupOutput.CreEnvironment)
+	}
+}
@@ -0,0 +1,78 @@
+package ephemeral
+
+import (
+	"slices"
+
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/jd"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/postgres"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment"
+	blockchain_sets "github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains/sets"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/features/sets"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// Defaults of the minimal topology, see DefaultSetupInput
+const (
+	DefaultNodeImage     = "localhost:5001/chainlink:develop"
+	DefaultJDImage       = "job-distributor:0.22.1"
+	DefaultPostgresImage = "postgres:12.0"
+	DefaultChainID       = "1337"
+	DefaultDONSize       = 4
+)
+
+// defaultCSAEncryptionKey is any 32 byte hex string, JD of an ephemeral run doesn't outlive it
+const defaultCSAEncryptionKey = "d1093c0060d50a3c89c189b2e485da5a3ce57f3dcb38ab7e2c0d5f0bb2314a44"
+
+// DefaultSetupInput returns the minimal topology of an ephemeral run in Docker: a single Anvil chain and one workflow DON
+// of DefaultDONSize nodes with consensus, cron and given capabilities, the first node of the DON is its bootstrap node.
+// DefaultNodeImage is used if nodeImage is empty.
+func DefaultSetupInput(testLogger zerolog.Logger, nodeImage string, capabilities ...string) *environment.SetupInput {
+	if nodeImage == "" {
+		nodeImage = DefaultNodeImage
+	}
+
+	nodeSpecs := make([]*clnode.Input, 0, DefaultDONSize)
+	for range DefaultDONSize {
+		nodeSpecs = append(nodeSpecs, &clnode.Input{Node: &clnode.NodeInput{Image: nodeImage}})
+	}
+
+	donCapabilities := []string{cre.ConsensusCapability, cre.CronCapability}
+	for _, capability := range capabilities {
+		if !slices.Contains(donCapabilities, capability) {
+			donCapabilities = append(donCapabilities, capability)
+		}
+	}
+
+	provider := infra.Provider{Type: infra.Docker}
+
+	return &environment.SetupInput{
+		CapabilitiesAwareNodeSets: []*cre.CapabilitiesAwareNodeSet{
+			{
+				Input: &ns.Input{
+					Name:               "workflow",
+					Nodes:              DefaultDONSize,
+					HTTPPortRangeStart: 10100,
+					OverrideMode:       "each",
+					DbInput:            &postgres.Input{Image: DefaultPostgresImage, Port: 13000},
+					NodeSpecs:          nodeSpecs,
+				},
+				Capabilities:       donCapabilities,
+				DONTypes:           []string{cre.WorkflowDON},
+				BootstrapNodeIndex: 0,
+				GatewayNodeIndex:   -1,
+			},
+		},
+		BlockchainsInput:    []*blockchain.Input{{Type: blockchain.TypeAnvil, ChainID: DefaultChainID}},
+		JdInput:             &jd.Input{Image: DefaultJDImage, CSAEncryptionKey: defaultCSAEncryptionKey},
+		Provider:            provider,
+		Features:            sets.New(),
+		BlockchainDeployers: blockchain_sets.NewDeployerSet(testLogger, &provider, infra.CribConfigsDir),
+	}
+}
@@ -0,0 +1,140 @@
+// Package tracing reports stages of environment setup, e.g. resolution, build and copies of capability binaries, to a
+// tracer passed with the context, see ContextWithTracer. StageTimings is a tracer, which records how long each stage
+// took.
+//
+// The package is experimental: stages, their spans and the Tracer interface may change in any release, without
+// deprecation. Once they settle, they move to a stable package and this one keeps aliases of them for a release.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Stage names a stage of the setup, stages of capability binaries are constants of the capabilities package, e.g.
+// capabilities.BinaryStageCopy
+type Stage = string
+
+// Span is a stage of the setup for a capability, a node or both
+type Span struct {
+	Stage      Stage
+	Capability string // capability flag, empty for stages of all capabilities, e.g. node-spec, or binaries without a capability config
+	Target     string // node, nodeset or DON, e.g. "node 1 of nodeset workflow", empty for stages on the host
+	Path       string // of the binary, if any
+}
+
+// Tracer records stages of the setup, e.g. as OpenTelemetry spans. The returned function ends the span, it's called
+// once with the error of the stage.
+type Tracer interface {
+	Start(ctx context.Context, span Span) (context.Context, func(err error))
+}
+
+type tracerKey struct{}
+
+// ContextWithTracer returns a context, with which stages of the setup are reported to the tracer. The environment
+// passes SetupInput.Tracer this way, so that concurrent setups of a process report to their own tracers.
+func ContextWithTracer(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// StartSpan starts the span with the tracer of the context, if any, see ContextWithTracer. Use it to report custom
+// stages of the setup.
+func StartSpan(ctx context.Context, span Span) (context.Context, func(err error)) {
+	t, _ := ctx.Value(tracerKey{}).(Tracer)
+	if t == nil {
+		return ctx, func(error) {}
+	}
+
+	return t.Start(ctx, span)
+}
+
+// StageTiming is a finished span recorded by StageTimings
+type StageTiming struct {
+	Span
+	Duration time.Duration
+	Err      error
+}
+
+// StageTimings is a tracer, which records how long each stage took, use it to find out which stages slow setup down
+type StageTimings struct {
+	mu      sync.Mutex
+	timings []StageTiming
+}
+
+func (s *StageTimings) Start(ctx context.Context, span Span) (context.Context, func(err error)) {
+	startTime := time.Now()
+
+	return ctx, func(err error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.timings = append(s.timings, StageTiming{Span: span, Duration: time.Since(startTime), Err: err})
+	}
+}
+
+// Timings returns finished spans in the order they finished
+func (s *StageTimings) Timings() []StageTiming {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.timings)
+}
+
+// Summary returns the count, total and longest duration of each stage in a table, the longest span of each stage is
+// named, as it's usually the one to look at. Stages run concurrently, so totals can exceed the time setup took.
+func (s *StageTimings) Summary() string {
+	type stageSummary struct {
+		count   int
+		total   time.Duration
+		longest StageTiming
+		failed  int
+	}
+	summaries := make(map[Stage]*stageSummary)
+	var stages []Stage
+	for _, timing := range s.Timings() {
+		summary, ok := summaries[timing.Stage]
+		if !ok {
+			summary = &stageSummary{}
+			summaries[timing.Stage] = summary
+			stages = append(stages, timing.Stage)
+		}
+		summary.count++
+		summary.total += timing.Duration
+		if timing.Duration > summary.longest.Duration {
+			summary.longest = timing
+		}
+		if timing.Err != nil {
+			summary.failed++
+		}
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tCOUNT\tFAILED\tTOTAL\tLONGEST\tLONGEST SPAN")
+	for _, stage := range stages {
+		summary := summaries[stage]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", stage, summary.count, summary.failed, summary.total.Round(time.Millisecond), summary.longest.Duration.Round(time.Millisecond), describeSpan(summary.longest.Span))
+	}
+	_ = w.Flush()
+
+	return sb.String()
+}
+
+func describeSpan(span Span) string {
+	var parts []string
+	if span.Capability != "" {
+		parts = append(parts, span.Capability)
+	}
+	if span.Target != "" {
+		parts = append(parts, span.Target)
+	}
+	if len(parts) == 0 && span.Path != "" {
+		parts = append(parts, span.Path)
+	}
+
+	return strings.Join(parts, " on ")
+}
@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStageTimingsSummary(t *testing.T) {
+	timings := &StageTimings{}
+	ctx := ContextWithTracer(context.Background(), timings)
+
+	_, endSpan := StartSpan(ctx, Span{Stage: "copy", Capability: "cron", Target: "node 0", Path: "/tmp/cron"})
+	endSpan(errors.New("container is gone"))
+	_, endSpan = StartSpan(ctx, Span{Stage: "copy", Capability: "cron", Target: "node 1", Path: "/tmp/cron"})
+	endSpan(nil)
+	_, endSpan = StartSpan(ctx, Span{Stage: "registry-setup"})
+	endSpan(nil)
+
+	recorded := timings.Timings()
+	require.Len(t, recorded, 3)
+	assert.Equal(t, "node 0", recorded[0].Target)
+	require.Error(t, recorded[0].Err)
+
+	summary := timings.Summary()
+	assert.Contains(t, summary, "STAGE")
+	assert.Regexp(t, `copy\s+2\s+1\s+`, summary)
+	assert.Regexp(t, `registry-setup\s+1\s+0\s+`, summary)
+	assert.Contains(t, summary, "cron on node")
+}
+
+func TestStartSpanWithoutTracer(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, endSpan := StartSpan(ctx, Span{Stage: "copy"})
+	endSpan(nil)
+	assert.Equal(t, ctx, spanCtx)
+}
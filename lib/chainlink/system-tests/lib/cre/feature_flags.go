@@ -0,0 +1,154 @@
+package cre
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+)
+
+// FeatureFlags enable experimental node and capability features for the whole environment in a single place, so that
+// an experiment is described by one TOML section instead of overrides scattered over node specs and capability configs.
+// Flags are recorded in the environment artifact, which makes the experiment reproducible from the run's artifacts.
+// Example:
+//
+//	[feature_flags.node]
+//	"Feature.LogPoller" = true
+//	"CRE.UseLocalTimeProvider" = false
+//	[feature_flags.capabilities.consensus]
+//	EnableExperimentalAggregation = true
+type FeatureFlags struct {
+	// Node holds dotted paths of node config options and their values, they take precedence over all other node configs
+	Node map[string]any `toml:"node" json:"node,omitempty"`
+	// Capabilities are merged into configs of capabilities (and their job specs), keyed by capability flag
+	Capabilities map[CapabilityFlag]map[string]any `toml:"capabilities" json:"capabilities,omitempty"`
+}
+
+func (f *FeatureFlags) Validate() error {
+	for path := range f.Node {
+		if slices.Contains(strings.Split(path, "."), "") {
+			return fmt.Errorf("invalid node feature flag '%s', expected a dotted path to a node config option, e.g. Feature.LogPoller", path)
+		}
+	}
+	for capability, flags := range f.Capabilities {
+		if capability == "" {
+			return errors.New("capability of feature flags can't be empty")
+		}
+		if len(flags) == 0 {
+			return fmt.Errorf("feature flags of capability %s are empty", capability)
+		}
+	}
+
+	return nil
+}
+
+// ApplyToCapabilityConfigs returns capability configs with capability feature flags merged into their configs.
+// Configs passed in are not modified.
+func (f *FeatureFlags) ApplyToCapabilityConfigs(configs CapabilityConfigs) CapabilityConfigs {
+	if f == nil || len(f.Capabilities) == 0 {
+		return configs
+	}
+
+	merged := maps.Clone(configs)
+	if merged == nil {
+		merged = make(CapabilityConfigs, len(f.Capabilities))
+	}
+	for capability, flags := range f.Capabilities {
+		capabilityConfig := merged[capability]
+		config := maps.Clone(capabilityConfig.Config)
+		if config == nil {
+			config = make(map[string]any, len(flags))
+		}
+		maps.Copy(config, flags)
+		capabilityConfig.Config = config
+		merged[capability] = capabilityConfig
+	}
+
+	return merged
+}
+
+// ApplyToNodeSpecs sets node feature flags in user config overrides of all nodes of the nodesets. Nodes apply user
+// config overrides on top of configs generated for them, so flags take precedence over all other node configs,
+// including user config overrides set in node specs. Applying flags again doesn't change the overrides.
+func (f *FeatureFlags) ApplyToNodeSpecs(nodeSets []*CapabilitiesAwareNodeSet) error {
+	if f == nil || len(f.Node) == 0 {
+		return nil
+	}
+
+	for _, nodeSet := range nodeSets {
+		for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+			if nodeSpec.Node == nil {
+				continue
+			}
+			overrides, err := f.applyToNodeConfig(nodeSpec.Node.UserConfigOverrides)
+			if err != nil {
+				return errors.Wrapf(err, "failed to apply feature flags to node %d in nodeset %s", nodeIdx, nodeSet.Name)
+			}
+			nodeSpec.Node.UserConfigOverrides = overrides
+		}
+	}
+
+	return nil
+}
+
+func (f *FeatureFlags) applyToNodeConfig(existingConfig string) (string, error) {
+	nodeConfig := make(map[string]any)
+	if err := toml.Unmarshal([]byte(existingConfig), &nodeConfig); err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal user config overrides")
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(f.Node)) {
+		if err := setConfigPath(nodeConfig, strings.Split(path, "."), f.Node[path]); err != nil {
+			return "", errors.Wrapf(err, "failed to set feature flag %s", path)
+		}
+	}
+
+	marshalled, mErr := toml.Marshal(nodeConfig)
+	if mErr != nil {
+		return "", errors.Wrap(mErr, "failed to marshal user config overrides")
+	}
+
+	return string(marshalled), nil
+}
+
+func setConfigPath(config map[string]any, path []string, value any) error {
+	if len(path) == 1 {
+		config[path[0]] = value
+		return nil
+	}
+
+	next, ok := config[path[0]]
+	if !ok {
+		next = make(map[string]any)
+		config[path[0]] = next
+	}
+	table, isTable := next.(map[string]any)
+	if !isTable {
+		return fmt.Errorf("%s is not a table, but %T", path[0], next)
+	}
+
+	return setConfigPath(table, path[1:], value)
+}
+
+// Summary returns active flags sorted by name, one per line, e.g. for logs and run reports
+func (f *FeatureFlags) Summary() []string {
+	if f == nil {
+		return nil
+	}
+
+	summary := make([]string, 0, len(f.Node)+len(f.Capabilities))
+	for path, value := range f.Node {
+		summary = append(summary, fmt.Sprintf("node %s = %v", path, value))
+	}
+	for capability, flags := range f.Capabilities {
+		for name, value := range flags {
+			summary = append(summary, fmt.Sprintf("capability %s %s = %v", capability, name, value))
+		}
+	}
+	slices.Sort(summary)
+
+	return summary
+}
@@ -0,0 +1,56 @@
+package cre
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+)
+
+func TestFeatureFlagsApplyToNodeSpecs(t *testing.T) {
+	nodeSet := &CapabilitiesAwareNodeSet{Input: &ns.Input{Name: "workflow", NodeSpecs: []*clnode.Input{
+		{Node: &clnode.NodeInput{UserConfigOverrides: "[Feature]\nLogPoller = false\nUICSAKeys = true\n"}},
+		{Node: &clnode.NodeInput{}},
+	}}}
+	flags := &FeatureFlags{Node: map[string]any{"Feature.LogPoller": true, "CRE.UseLocalTimeProvider": false}}
+
+	require.NoError(t, flags.ApplyToNodeSpecs([]*CapabilitiesAwareNodeSet{nodeSet}))
+	first := nodeSet.NodeSpecs[0].Node.UserConfigOverrides
+	require.NoError(t, flags.ApplyToNodeSpecs([]*CapabilitiesAwareNodeSet{nodeSet}))
+	assert.Equal(t, first, nodeSet.NodeSpecs[0].Node.UserConfigOverrides, "applying flags again changed the overrides")
+
+	var overrides map[string]any
+	require.NoError(t, toml.Unmarshal([]byte(first), &overrides))
+	assert.Equal(t, map[string]any{
+		"Feature": map[string]any{"LogPoller": true, "UICSAKeys": true},
+		"CRE":     map[string]any{"UseLocalTimeProvider": false},
+	}, overrides, "flags must take precedence over user config overrides and keep the other options")
+
+	require.NoError(t, toml.Unmarshal([]byte(nodeSet.NodeSpecs[1].Node.UserConfigOverrides), &overrides))
+	assert.Equal(t, true, overrides["Feature"].(map[string]any)["LogPoller"])
+}
+
+func TestFeatureFlagsApplyToNodeSpecsFailsForNonTables(t *testing.T) {
+	nodeSet := &CapabilitiesAwareNodeSet{Input: &ns.Input{Name: "workflow", NodeSpecs: []*clnode.Input{
+		{Node: &clnode.NodeInput{UserConfigOverrides: "Feature = 1\n"}},
+	}}}
+	flags := &FeatureFlags{Node: map[string]any{"Feature.LogPoller": true}}
+
+	err := flags.ApplyToNodeSpecs([]*CapabilitiesAwareNodeSet{nodeSet})
+	require.ErrorContains(t, err, "failed to apply feature flags to node 0 in nodeset workflow")
+	require.ErrorContains(t, err, "Feature is not a table")
+}
+
+func TestFeatureFlagsApplyToCapabilityConfigs(t *testing.T) {
+	configs := CapabilityConfigs{CronCapability: {BinaryPath: "./cron", Config: map[string]any{"Schedule": "*/5"}}}
+	flags := &FeatureFlags{Capabilities: map[CapabilityFlag]map[string]any{CronCapability: {"Experimental": true}}}
+
+	merged := flags.ApplyToCapabilityConfigs(configs)
+	assert.Equal(t, map[string]any{"Schedule": "*/5", "Experimental": true}, merged[CronCapability].Config)
+	assert.Equal(t, "./cron", merged[CronCapability].BinaryPath)
+	assert.Equal(t, map[string]any{"Schedule": "*/5"}, configs[CronCapability].Config, "configs passed in were modified")
+}
@@ -29,12 +29,20 @@ import (
 
 const flag = cre.ConsensusCapability
 
+// PluginCommand is the OCR3 capability plugin shipped by node images, which worker jobs run
+const PluginCommand = "/usr/local/bin/chainlink-ocr3-capability"
+
 type Consensus struct{}
 
 func (c *Consensus) Flag() cre.CapabilityFlag {
 	return flag
 }
 
+// ImagePlugin returns the plugin of the capability node images ship, see capabilities.RegisterImagePlugin
+func (c *Consensus) ImagePlugin() string {
+	return PluginCommand
+}
+
 func (c *Consensus) PreEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -188,7 +196,7 @@ func WorkerJobSpec(nodeID string, ocr3CapabilityAddress, nodeEthAddress, offchai
 	[relayConfig]
 	chainID = "%d"
 	[pluginConfig]
-	command = "/usr/local/bin/chainlink-ocr3-capability"
+	command = "%s"
 	ocrVersion = 3
 	pluginName = "ocr-capability"
 	providerType = "ocr3-capability"
@@ -205,6 +213,7 @@ func WorkerJobSpec(nodeID string, ocr3CapabilityAddress, nodeEthAddress, offchai
 		fmt.Sprintf("%s:%d", ocrPeeringData.OCRBootstraperHost, ocrPeeringData.Port),
 		nodeEthAddress,
 		chainID,
+		PluginCommand,
 	)
 	for family, key := range ocr2KeyBundles {
 		spec += fmt.Sprintf(`
@@ -35,6 +35,12 @@ func (c *Consensus) Flag() cre.CapabilityFlag {
 	return flag
 }
 
+// ImagePlugin returns the plugin of the capability, which node images built with private plugins ship, see
+// capabilities.RegisterImagePlugin
+func (c *Consensus) ImagePlugin() string {
+	return "/usr/local/bin/consensus"
+}
+
 func (c *Consensus) PreEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
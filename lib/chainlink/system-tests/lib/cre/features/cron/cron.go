@@ -3,29 +3,55 @@ package cron
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	capabilitiespb "github.com/smartcontractkit/chainlink-common/pkg/capabilities/pb"
+	cldf "github.com/smartcontractkit/chainlink-deployments-framework/deployment"
 	kcr "github.com/smartcontractkit/chainlink-evm/gethwrappers/keystone/generated/capabilities_registry_1_1_0"
 	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
 	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
 
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	crecontracts "github.com/smartcontractkit/chainlink/system-tests/lib/cre/contracts"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs"
 	factory "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability/donlevel"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains/evm"
+	creworkflow "github.com/smartcontractkit/chainlink/system-tests/lib/cre/workflow"
 )
 
 const flag = cre.CronCapability
 
-type Cron struct{}
+type Cron struct {
+	// SmokeWorkflow is registered by the smoke check to verify that the cron trigger fires. Without it, the smoke check
+	// can only verify the cron trigger jobs.
+	SmokeWorkflow *SmokeWorkflow
+}
+
+// SmokeWorkflow is a compiled workflow with a cron trigger, which is registered on the workflow DON
+type SmokeWorkflow struct {
+	Name               string
+	CompressedWasmPath string
+	ConfigFilePath     string
+	// Interval of the cron schedule, the first execution has to start within 2 intervals after the workflow is registered
+	Interval time.Duration
+}
 
 func (c *Cron) Flag() cre.CapabilityFlag {
 	return flag
 }
 
+// ImagePlugin returns the plugin of the capability, which node images built with private plugins ship, see
+// capabilities.RegisterImagePlugin
+func (c *Cron) ImagePlugin() string {
+	return "/usr/local/bin/cron"
+}
+
 func (c *Cron) PreEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -49,6 +75,16 @@ func (c *Cron) PreEnvStartup(
 
 const configTemplate = `""` // Empty config by default
 
+// JobSpecFactory returns the factory of jobs of the capability, it can be registered with capabilities.JobSpecFactories
+func (c *Cron) JobSpecFactory() cre.JobSpecFn {
+	return factory.DonLevelJobSpecFactory(
+		flag,
+		configTemplate,
+		factory.NoOpExtractor,
+		factory.BinaryPathBuilder,
+	)
+}
+
 func (c *Cron) PostEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -56,18 +92,6 @@ func (c *Cron) PostEnvStartup(
 	dons *cre.Dons,
 	creEnv *cre.Environment,
 ) error {
-	perDonJobSpecFactory, fErr := factory.NewCapabilityJobSpecFactory(
-		creEnv.RegistryChainSelector,
-		donlevel.CapabilityEnabler,
-		donlevel.EnabledChainsProvider,
-		donlevel.ConfigResolver,
-		donlevel.JobNamer,
-	)
-
-	if fErr != nil {
-		return errors.Wrap(fErr, "failed to create capability job spec factory")
-	}
-
 	bcOuts := make([]*blockchain.Output, len(creEnv.Blockchains))
 	for i, b := range creEnv.Blockchains {
 		bcOuts[i] = b.CtfOutput()
@@ -84,12 +108,7 @@ func (c *Cron) PostEnvStartup(
 		return fmt.Errorf("could not find node set for Don named '%s'", don.Name)
 	}
 
-	jobSpecs, specErr := perDonJobSpecFactory.BuildJobSpec(
-		flag,
-		configTemplate,
-		factory.NoOpExtractor,
-		factory.BinaryPathBuilder,
-	)(&cre.JobSpecInput{
+	jobSpecs, specErr := c.JobSpecFactory()(&cre.JobSpecInput{
 		CreEnvironment: creEnv,
 		Don:            don,
 		NodeSet:        nodeSet,
@@ -109,3 +128,111 @@ func (c *Cron) PostEnvStartup(
 
 	return nil
 }
+
+// SmokeCheck verifies that the cron trigger job was accepted and started without errors on all worker nodes and, if
+// the smoke workflow is set, that it's executed within 2 intervals of its schedule after it's registered. The workflow is
+// deleted once the check is done. Executions are read from logs of Docker containers.
+func (c *Cron) SmokeCheck(
+	ctx context.Context,
+	testLogger zerolog.Logger,
+	don *cre.Don,
+	dons *cre.Dons,
+	creEnv *cre.Environment,
+) error {
+	if err := cre.WaitForCapabilityJobs(ctx, don, donlevel.JobNamer(0, flag), cre.DefaultSmokeCheckTimeout); err != nil {
+		return err
+	}
+
+	if c.SmokeWorkflow == nil {
+		testLogger.Warn().Msgf("No smoke workflow set for capability %s, executions of the cron trigger won't be verified", flag)
+		return nil
+	}
+	if c.SmokeWorkflow.Interval <= 0 {
+		return errors.New("interval of the smoke workflow must be positive")
+	}
+
+	var workflowDON *cre.Don
+	for _, d := range dons.List() {
+		if d.HasFlag(cre.WorkflowDON) {
+			workflowDON = d
+			break
+		}
+	}
+	if workflowDON == nil {
+		return errors.New("no workflow DON found")
+	}
+
+	registryChain, registryAddress, tv, registryErr := workflowRegistry(creEnv)
+	if registryErr != nil {
+		return registryErr
+	}
+
+	filesToCopy := []string{c.SmokeWorkflow.CompressedWasmPath}
+	var configURL *string
+	if c.SmokeWorkflow.ConfigFilePath != "" {
+		filesToCopy = append(filesToCopy, c.SmokeWorkflow.ConfigFilePath)
+		url := "file://" + c.SmokeWorkflow.ConfigFilePath
+		configURL = &url
+	}
+	if err := creworkflow.CopyArtifactsToDockerContainers(creworkflow.DefaultWorkflowTargetDir, ns.NodeNamePrefix(workflowDON.Name), filesToCopy...); err != nil {
+		return errors.Wrap(err, "failed to copy smoke workflow artifacts to Docker containers")
+	}
+
+	txCtx := evm.ContextWithTxManager(ctx, registryChain.TxManager())
+	containerTargetDir := creworkflow.DefaultWorkflowTargetDir
+	registeredAt := time.Now()
+	workflowID, registerErr := creworkflow.RegisterWithContract(
+		txCtx,
+		registryChain.SethClient,
+		registryAddress,
+		tv,
+		workflowDON.ID,
+		c.SmokeWorkflow.Name,
+		"file://"+c.SmokeWorkflow.CompressedWasmPath,
+		configURL,
+		nil, // no secrets
+		&containerTargetDir,
+	)
+	if registerErr != nil {
+		return errors.Wrapf(registerErr, "failed to register smoke workflow '%s'", c.SmokeWorkflow.Name)
+	}
+	defer func() {
+		// the workflow shouldn't keep running in the environment used by tests, even if the check failed
+		if err := creworkflow.DeleteWithContract(context.WithoutCancel(txCtx), registryChain.SethClient, registryAddress, tv, c.SmokeWorkflow.Name); err != nil {
+			testLogger.Warn().Err(err).Msgf("Failed to delete smoke workflow '%s'", c.SmokeWorkflow.Name)
+		}
+	}()
+
+	start, execErr := cre.WaitForWorkflowExecution(ctx, workflowDON, workflowID, registeredAt, 2*c.SmokeWorkflow.Interval)
+	if execErr != nil {
+		return errors.Wrapf(execErr, "cron trigger didn't fire for smoke workflow '%s'", c.SmokeWorkflow.Name)
+	}
+	testLogger.Info().Msgf("Smoke workflow '%s' was executed on node %s %s after it was registered", c.SmokeWorkflow.Name, start.Node, start.Timestamp.Sub(registeredAt))
+
+	return nil
+}
+
+func workflowRegistry(creEnv *cre.Environment) (*evm.Blockchain, common.Address, cldf.TypeAndVersion, error) {
+	var registryChain *evm.Blockchain
+	for _, bc := range creEnv.Blockchains {
+		if evmChain, ok := bc.(*evm.Blockchain); ok && bc.ChainSelector() == creEnv.RegistryChainSelector {
+			registryChain = evmChain
+			break
+		}
+	}
+	if registryChain == nil {
+		return nil, common.Address{}, cldf.TypeAndVersion{}, errors.New("registry chain must be an EVM chain")
+	}
+
+	//lint:ignore SA1019 ignoring deprecation warning for this usage
+	registryAddress, tv, findErr := crecontracts.FindAddressesForChain(
+		creEnv.CldfEnvironment.ExistingAddresses, //nolint:staticcheck // SA1019 ignoring deprecation warning for this usage
+		creEnv.RegistryChainSelector,
+		keystone_changeset.WorkflowRegistry.String(),
+	)
+	if findErr != nil {
+		return nil, common.Address{}, cldf.TypeAndVersion{}, errors.Wrap(findErr, "failed to find workflow registry address")
+	}
+
+	return registryChain, registryAddress, tv, nil
+}
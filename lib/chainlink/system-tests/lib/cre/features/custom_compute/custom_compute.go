@@ -19,7 +19,6 @@ import (
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/gateway"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs"
 	factory "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability"
-	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability/donlevel"
 	"github.com/smartcontractkit/chainlink/v2/core/services/gateway/config"
 )
 
@@ -83,6 +82,18 @@ perSenderRPS = {{.PerSenderRPS}}
 perSenderBurst = {{.PerSenderBurst}}
 """`
 
+// JobSpecFactory returns the factory of jobs of the capability, it can be registered with capabilities.JobSpecFactories
+func (o *CustomCompute) JobSpecFactory() cre.JobSpecFn {
+	return factory.DonLevelJobSpecFactory(
+		flag,
+		configTemplate,
+		factory.NoOpExtractor,
+		func(_ *cre.JobSpecInput, _ cre.CapabilityConfig) (string, error) {
+			return "__builtin_custom-compute-action", nil
+		},
+	)
+}
+
 func (o *CustomCompute) PostEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -90,18 +101,6 @@ func (o *CustomCompute) PostEnvStartup(
 	dons *cre.Dons,
 	creEnv *cre.Environment,
 ) error {
-	perDonJobSpecFactory, fErr := factory.NewCapabilityJobSpecFactory(
-		creEnv.RegistryChainSelector,
-		donlevel.CapabilityEnabler,
-		donlevel.EnabledChainsProvider,
-		donlevel.ConfigResolver,
-		donlevel.JobNamer,
-	)
-
-	if fErr != nil {
-		return errors.Wrap(fErr, "failed to create capability job spec factory")
-	}
-
 	bcOuts := make([]*blockchain.Output, len(creEnv.Blockchains))
 	for i, b := range creEnv.Blockchains {
 		bcOuts[i] = b.CtfOutput()
@@ -118,14 +117,7 @@ func (o *CustomCompute) PostEnvStartup(
 		return fmt.Errorf("could not find node set for Don named '%s'", don.Name)
 	}
 
-	jobSpecs, specErr := perDonJobSpecFactory.BuildJobSpec(
-		flag,
-		configTemplate,
-		factory.NoOpExtractor,
-		func(_ *cre.JobSpecInput, _ cre.CapabilityConfig) (string, error) {
-			return "__builtin_custom-compute-action", nil
-		},
-	)(&cre.JobSpecInput{
+	jobSpecs, specErr := o.JobSpecFactory()(&cre.JobSpecInput{
 		CreEnvironment: creEnv,
 		Don:            don,
 		NodeSet:        nodeSet,
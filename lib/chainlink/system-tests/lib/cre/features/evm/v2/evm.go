@@ -55,6 +55,12 @@ func (o *EVM) Flag() cre.CapabilityFlag {
 	return flag
 }
 
+// ImagePlugin returns the plugin of the capability, which node images built with private plugins ship, see
+// capabilities.RegisterImagePlugin
+func (o *EVM) ImagePlugin() string {
+	return "/usr/local/bin/evm"
+}
+
 func (o *EVM) PreEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -19,7 +19,6 @@ import (
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/gateway"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs"
 	factory "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability"
-	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability/donlevel"
 	"github.com/smartcontractkit/chainlink/v2/core/services/gateway/config"
 )
 
@@ -31,6 +30,12 @@ func (o *HTTPAction) Flag() cre.CapabilityFlag {
 	return flag
 }
 
+// ImagePlugin returns the plugin of the capability, which node images built with private plugins ship, see
+// capabilities.RegisterImagePlugin
+func (o *HTTPAction) ImagePlugin() string {
+	return "/usr/local/bin/http_action"
+}
+
 func (o *HTTPAction) PreEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -92,6 +97,16 @@ const configTemplate = `"""
 }
 """`
 
+// JobSpecFactory returns the factory of jobs of the capability, it can be registered with capabilities.JobSpecFactories
+func (o *HTTPAction) JobSpecFactory() cre.JobSpecFn {
+	return factory.DonLevelJobSpecFactory(
+		flag,
+		configTemplate,
+		factory.NoOpExtractor,
+		factory.BinaryPathBuilder,
+	)
+}
+
 func (o *HTTPAction) PostEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -99,17 +114,6 @@ func (o *HTTPAction) PostEnvStartup(
 	dons *cre.Dons,
 	creEnv *cre.Environment,
 ) error {
-	perDonJobSpecFactory, fErr := factory.NewCapabilityJobSpecFactory(
-		creEnv.RegistryChainSelector,
-		donlevel.CapabilityEnabler,
-		donlevel.EnabledChainsProvider,
-		donlevel.ConfigResolver,
-		donlevel.JobNamer,
-	)
-	if fErr != nil {
-		return errors.Wrap(fErr, "failed to create capability job spec factory")
-	}
-
 	bcOuts := make([]*blockchain.Output, len(creEnv.Blockchains))
 	for i, b := range creEnv.Blockchains {
 		bcOuts[i] = b.CtfOutput()
@@ -126,12 +130,7 @@ func (o *HTTPAction) PostEnvStartup(
 		return fmt.Errorf("could not find node set for Don named '%s'", don.Name)
 	}
 
-	jobSpecs, specErr := perDonJobSpecFactory.BuildJobSpec(
-		flag,
-		configTemplate,
-		factory.NoOpExtractor,
-		factory.BinaryPathBuilder,
-	)(&cre.JobSpecInput{
+	jobSpecs, specErr := o.JobSpecFactory()(&cre.JobSpecInput{
 		CreEnvironment: creEnv,
 		Don:            don,
 		NodeSet:        nodeSet,
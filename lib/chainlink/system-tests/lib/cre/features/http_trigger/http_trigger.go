@@ -19,7 +19,6 @@ import (
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/gateway"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs"
 	factory "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability"
-	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability/donlevel"
 	"github.com/smartcontractkit/chainlink/v2/core/services/gateway/config"
 )
 
@@ -31,6 +30,12 @@ func (o *HTTPTrigger) Flag() cre.CapabilityFlag {
 	return flag
 }
 
+// ImagePlugin returns the plugin of the capability, which node images built with private plugins ship, see
+// capabilities.RegisterImagePlugin
+func (o *HTTPTrigger) ImagePlugin() string {
+	return "/usr/local/bin/http_trigger"
+}
+
 func (o *HTTPTrigger) PreEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -91,6 +96,16 @@ const configTemplate = `"""
 }
 """`
 
+// JobSpecFactory returns the factory of jobs of the capability, it can be registered with capabilities.JobSpecFactories
+func (o *HTTPTrigger) JobSpecFactory() cre.JobSpecFn {
+	return factory.DonLevelJobSpecFactory(
+		flag,
+		configTemplate,
+		factory.NoOpExtractor,
+		factory.BinaryPathBuilder,
+	)
+}
+
 func (o *HTTPTrigger) PostEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -98,17 +113,6 @@ func (o *HTTPTrigger) PostEnvStartup(
 	dons *cre.Dons,
 	creEnv *cre.Environment,
 ) error {
-	perDonJobSpecFactory, fErr := factory.NewCapabilityJobSpecFactory(
-		creEnv.RegistryChainSelector,
-		donlevel.CapabilityEnabler,
-		donlevel.EnabledChainsProvider,
-		donlevel.ConfigResolver,
-		donlevel.JobNamer,
-	)
-	if fErr != nil {
-		return errors.Wrap(fErr, "failed to create capability job spec factory")
-	}
-
 	bcOuts := make([]*blockchain.Output, len(creEnv.Blockchains))
 	for i, b := range creEnv.Blockchains {
 		bcOuts[i] = b.CtfOutput()
@@ -125,12 +129,7 @@ func (o *HTTPTrigger) PostEnvStartup(
 		return fmt.Errorf("could not find node set for Don named '%s'", don.Name)
 	}
 
-	jobSpecs, specErr := perDonJobSpecFactory.BuildJobSpec(
-		flag,
-		configTemplate,
-		factory.NoOpExtractor,
-		factory.BinaryPathBuilder,
-	)(&cre.JobSpecInput{
+	jobSpecs, specErr := o.JobSpecFactory()(&cre.JobSpecInput{
 		CreEnvironment: creEnv,
 		Don:            don,
 		NodeSet:        nodeSet,
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	capabilitiespb "github.com/smartcontractkit/chainlink-common/pkg/capabilities/pb"
@@ -15,7 +14,6 @@ import (
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs"
 	factory "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability"
-	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability/chainlevel"
 )
 
 const flag = cre.LogEventTriggerCapability
@@ -26,6 +24,12 @@ func (o *LogEventTrigger) Flag() cre.CapabilityFlag {
 	return flag
 }
 
+// ImagePlugin returns the plugin of the capability, which node images built from plugins/chainlink.Dockerfile ship,
+// see capabilities.RegisterImagePlugin
+func (o *LogEventTrigger) ImagePlugin() string {
+	return "/usr/local/bin/log-event-trigger"
+}
+
 func (o *LogEventTrigger) PreEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -61,6 +65,21 @@ const configTemplate = `"""
 }
 """`
 
+// JobSpecFactory returns the factory of jobs of the capability, it can be registered with capabilities.JobSpecFactories
+func (o *LogEventTrigger) JobSpecFactory() cre.JobSpecFn {
+	return factory.ChainLevelJobSpecFactory(
+		flag,
+		configTemplate,
+		func(chainID uint64, _ *cre.Node) map[string]any {
+			return map[string]any{
+				"ChainID":       chainID,
+				"NetworkFamily": "evm",
+			}
+		},
+		factory.BinaryPathBuilder,
+	)
+}
+
 func (o *LogEventTrigger) PostEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -68,18 +87,6 @@ func (o *LogEventTrigger) PostEnvStartup(
 	dons *cre.Dons,
 	creEnv *cre.Environment,
 ) error {
-	perDonJobSpecFactory, fErr := factory.NewCapabilityJobSpecFactory(
-		creEnv.RegistryChainSelector,
-		chainlevel.CapabilityEnabler,
-		chainlevel.EnabledChainsProvider,
-		chainlevel.ConfigResolver,
-		chainlevel.JobNamer,
-	)
-
-	if fErr != nil {
-		return errors.Wrap(fErr, "failed to create capability job spec factory")
-	}
-
 	bcOuts := make([]*blockchain.Output, len(creEnv.Blockchains))
 	for i, b := range creEnv.Blockchains {
 		bcOuts[i] = b.CtfOutput()
@@ -96,17 +103,7 @@ func (o *LogEventTrigger) PostEnvStartup(
 		return fmt.Errorf("could not find node set for Don named '%s'", don.Name)
 	}
 
-	jobSpecs, specErr := perDonJobSpecFactory.BuildJobSpec(
-		flag,
-		configTemplate,
-		func(chainID uint64, _ *cre.Node) map[string]any {
-			return map[string]any{
-				"ChainID":       chainID,
-				"NetworkFamily": "evm",
-			}
-		},
-		factory.BinaryPathBuilder,
-	)(&cre.JobSpecInput{
+	jobSpecs, specErr := o.JobSpecFactory()(&cre.JobSpecInput{
 		CreEnvironment: creEnv,
 		Don:            don,
 		NodeSet:        nodeSet,
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	capabilitiespb "github.com/smartcontractkit/chainlink-common/pkg/capabilities/pb"
@@ -15,7 +14,6 @@ import (
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs"
 	factory "github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability"
-	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/jobs/standardcapability/chainlevel"
 )
 
 const flag = cre.ReadContractCapability
@@ -26,6 +24,12 @@ func (o *ReadContract) Flag() cre.CapabilityFlag {
 	return flag
 }
 
+// ImagePlugin returns the plugin of the capability, which node images built with private plugins ship, see
+// capabilities.RegisterImagePlugin
+func (o *ReadContract) ImagePlugin() string {
+	return "/usr/local/bin/readcontract"
+}
+
 func (o *ReadContract) PreEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -52,6 +56,21 @@ func (o *ReadContract) PreEnvStartup(
 
 const configTemplate = `'{"chainId":{{.ChainID}},"network":"{{.NetworkFamily}}"}'`
 
+// JobSpecFactory returns the factory of jobs of the capability, it can be registered with capabilities.JobSpecFactories
+func (o *ReadContract) JobSpecFactory() cre.JobSpecFn {
+	return factory.ChainLevelJobSpecFactory(
+		flag,
+		configTemplate,
+		func(chainID uint64, _ *cre.Node) map[string]any {
+			return map[string]any{
+				"ChainID":       chainID,
+				"NetworkFamily": "evm",
+			}
+		},
+		factory.BinaryPathBuilder,
+	)
+}
+
 func (o *ReadContract) PostEnvStartup(
 	ctx context.Context,
 	testLogger zerolog.Logger,
@@ -59,18 +78,6 @@ func (o *ReadContract) PostEnvStartup(
 	dons *cre.Dons,
 	creEnv *cre.Environment,
 ) error {
-	perDonJobSpecFactory, fErr := factory.NewCapabilityJobSpecFactory(
-		creEnv.RegistryChainSelector,
-		chainlevel.CapabilityEnabler,
-		chainlevel.EnabledChainsProvider,
-		chainlevel.ConfigResolver,
-		chainlevel.JobNamer,
-	)
-
-	if fErr != nil {
-		return errors.Wrap(fErr, "failed to create capability job spec factory")
-	}
-
 	bcOuts := make([]*blockchain.Output, len(creEnv.Blockchains))
 	for i, b := range creEnv.Blockchains {
 		bcOuts[i] = b.CtfOutput()
@@ -87,17 +94,7 @@ func (o *ReadContract) PostEnvStartup(
 		return fmt.Errorf("could not find node set for Don named '%s'", don.Name)
 	}
 
-	jobSpecs, specErr := perDonJobSpecFactory.BuildJobSpec(
-		flag,
-		configTemplate,
-		func(chainID uint64, _ *cre.Node) map[string]any {
-			return map[string]any{
-				"ChainID":       chainID,
-				"NetworkFamily": "evm",
-			}
-		},
-		factory.BinaryPathBuilder,
-	)(&cre.JobSpecInput{
+	jobSpecs, specErr := o.JobSpecFactory()(&cre.JobSpecInput{
 		CreEnvironment: creEnv,
 		Don:            don,
 		NodeSet:        nodeSet,
@@ -2,6 +2,7 @@ package sets
 
 import (
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	crecapabilities "github.com/smartcontractkit/chainlink/system-tests/lib/cre/capabilities"
 	consensus_v1_feature "github.com/smartcontractkit/chainlink/system-tests/lib/cre/features/consensus/v1"
 	consensus_v2_feature "github.com/smartcontractkit/chainlink/system-tests/lib/cre/features/consensus/v2"
 	cron_feature "github.com/smartcontractkit/chainlink/system-tests/lib/cre/features/cron"
@@ -40,3 +41,26 @@ func New() cre.Features {
 		&solana_feature.Solana{},
 	)
 }
+
+// JobSpecFactories returns a registry of all features, so that capabilities enabled in TOML are set up also when their
+// features aren't passed to the environment. It's the default of environment.SetupInput.JobSpecFactories.
+func JobSpecFactories() *crecapabilities.JobSpecFactories {
+	factories := crecapabilities.NewJobSpecFactories()
+	features := New()
+	for _, feature := range features.List() {
+		factories.RegisterFeature(feature)
+	}
+
+	return factories
+}
+
+// RegisterImagePlugins registers plugins of features, whose jobs run a plugin shipped by node images, so that
+// DiscoverImageCapabilities finds out which images have them
+func RegisterImagePlugins() {
+	features := New()
+	for _, feature := range features.List() {
+		if provider, ok := feature.(crecapabilities.ImagePluginProvider); ok {
+			crecapabilities.RegisterImagePlugin(provider.Flag(), provider.ImagePlugin())
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package cre
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+)
+
+const anvilSlotsInEpochFlag = "--slots-in-an-epoch"
+
+// ChainFinalityConfigs maps chain ID to its finality configuration
+type ChainFinalityConfigs = map[string]*ChainFinalityConfig
+
+// ChainFinalityConfig describes how a simulated chain finalizes blocks. It's applied both to the simulator (so that it actually
+// behaves like that) and to EVM configs of all nodes (so that they expect it to behave like that). Zero values keep defaults.
+type ChainFinalityConfig struct {
+	// FinalityDepth is the number of blocks after which a block is considered final. With finality tag enabled it's used
+	// to configure the simulator, which will report as 'finalized' the block that is FinalityDepth blocks behind the latest one.
+	FinalityDepth      uint32 `toml:"finality_depth"`
+	FinalityTagEnabled bool   `toml:"finality_tag_enabled"`
+	// SafeDepth is the number of blocks after which a block is considered safe, used only if finality tag is enabled.
+	// Anvil reports as 'safe' the block one epoch behind the latest one, so it must be half of finality depth (rounded
+	// up), which is also the default, see SimulatedSafeDepth.
+	SafeDepth                uint32 `toml:"safe_depth"`
+	MinIncomingConfirmations uint32 `toml:"min_incoming_confirmations"`
+}
+
+func (c *ChainFinalityConfig) Validate(chainType string) error {
+	if c.FinalityTagEnabled {
+		if chainType != blockchain.TypeAnvil {
+			return fmt.Errorf("finality tag can only be enabled for %s chains, but chain type is %s", blockchain.TypeAnvil, chainType)
+		}
+		if c.FinalityDepth == 0 {
+			return errors.New("finality depth must be set when finality tag is enabled, because the simulator needs to know when to finalize blocks")
+		}
+		if c.SafeDepth > 0 && c.SafeDepth != halfRoundedUp(c.FinalityDepth) {
+			return fmt.Errorf("safe depth %d doesn't match finality depth %d, %s finalizes blocks two epochs and marks them safe one epoch behind the latest one, so safe depth must be %d", c.SafeDepth, c.FinalityDepth, blockchain.TypeAnvil, halfRoundedUp(c.FinalityDepth))
+		}
+	}
+
+	if !c.FinalityTagEnabled && c.SafeDepth > 0 {
+		return errors.New("safe depth can only be set when finality tag is enabled")
+	}
+
+	return nil
+}
+
+// SimulatedSafeDepth returns the depth of blocks the simulator reports as 'safe' with finality tag enabled, 0 otherwise.
+// Nodes are configured with it, so that they agree with the simulator on safe blocks, even if SafeDepth isn't set.
+func (c *ChainFinalityConfig) SimulatedSafeDepth() uint32 {
+	if !c.FinalityTagEnabled {
+		return 0
+	}
+	if c.SafeDepth > 0 {
+		return c.SafeDepth
+	}
+
+	return halfRoundedUp(c.FinalityDepth)
+}
+
+func halfRoundedUp(depth uint32) uint32 {
+	return (depth + 1) / 2
+}
+
+// ApplyToSimulator modifies blockchain input, so that the simulator finalizes blocks as configured. Anvil reports as 'finalized'
+// the block that is two epochs behind the latest one and as 'safe' the one that is an epoch behind it, so the epoch length
+// is set to the safe depth, see SimulatedSafeDepth.
+func (c *ChainFinalityConfig) ApplyToSimulator(input *blockchain.Input) error {
+	if err := c.Validate(input.Type); err != nil {
+		return errors.Wrapf(err, "invalid finality config for chain %s", input.ChainID)
+	}
+
+	if !c.FinalityTagEnabled {
+		return nil
+	}
+
+	slotsInEpoch := strconv.FormatUint(uint64(c.SimulatedSafeDepth()), 10)
+	if idx := slices.Index(input.DockerCmdParamsOverrides, anvilSlotsInEpochFlag); idx != -1 {
+		if idx+1 < len(input.DockerCmdParamsOverrides) && input.DockerCmdParamsOverrides[idx+1] == slotsInEpoch {
+			return nil // already applied
+		}
+		return fmt.Errorf("chain %s has both finality config and %s docker command parameter set, remove one of them", input.ChainID, anvilSlotsInEpochFlag)
+	}
+
+	input.DockerCmdParamsOverrides = append(input.DockerCmdParamsOverrides, anvilSlotsInEpochFlag, slotsInEpoch)
+
+	return nil
+}
+
+// ApplyChainFinalityConfigs applies finality configs to simulators of matching blockchain inputs
+func ApplyChainFinalityConfigs(configs ChainFinalityConfigs, inputs []*blockchain.Input) error {
+	for chainID, cfg := range configs {
+		if cfg == nil {
+			continue
+		}
+
+		idx := slices.IndexFunc(inputs, func(input *blockchain.Input) bool { return input.ChainID == chainID })
+		if idx == -1 {
+			return fmt.Errorf("finality config provided for chain %s, but no such blockchain is configured", chainID)
+		}
+
+		if err := cfg.ApplyToSimulator(inputs[idx]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
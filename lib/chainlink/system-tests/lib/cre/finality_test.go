@@ -0,0 +1,43 @@
+package cre
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+)
+
+func TestChainFinalityConfigApplyToSimulator(t *testing.T) {
+	t.Run("epoch length is the safe depth", func(t *testing.T) {
+		input := &blockchain.Input{Type: blockchain.TypeAnvil, ChainID: "1337"}
+		config := &ChainFinalityConfig{FinalityDepth: 6, FinalityTagEnabled: true, SafeDepth: 3}
+		require.NoError(t, config.ApplyToSimulator(input))
+		assert.Equal(t, []string{anvilSlotsInEpochFlag, "3"}, input.DockerCmdParamsOverrides)
+		require.NoError(t, config.ApplyToSimulator(input), "applying the config again must be a no-op")
+	})
+
+	t.Run("nodes get the safe depth of the simulator by default", func(t *testing.T) {
+		input := &blockchain.Input{Type: blockchain.TypeAnvil, ChainID: "1337"}
+		config := &ChainFinalityConfig{FinalityDepth: 5, FinalityTagEnabled: true}
+		require.NoError(t, config.ApplyToSimulator(input))
+		assert.Equal(t, []string{anvilSlotsInEpochFlag, "3"}, input.DockerCmdParamsOverrides)
+		assert.Equal(t, uint32(3), config.SimulatedSafeDepth())
+		assert.Zero(t, (&ChainFinalityConfig{FinalityDepth: 5}).SimulatedSafeDepth())
+	})
+
+	t.Run("safe depth the simulator can't report is rejected", func(t *testing.T) {
+		input := &blockchain.Input{Type: blockchain.TypeAnvil, ChainID: "1337"}
+		config := &ChainFinalityConfig{FinalityDepth: 10, FinalityTagEnabled: true, SafeDepth: 2}
+		require.ErrorContains(t, config.ApplyToSimulator(input), "safe depth must be 5")
+		assert.Empty(t, input.DockerCmdParamsOverrides)
+	})
+
+	t.Run("finality depth of other chains doesn't change the simulator", func(t *testing.T) {
+		input := &blockchain.Input{Type: blockchain.TypeTron, ChainID: "3360022319"}
+		require.NoError(t, (&ChainFinalityConfig{FinalityDepth: 20}).ApplyToSimulator(input))
+		assert.Empty(t, input.DockerCmdParamsOverrides)
+		require.ErrorContains(t, (&ChainFinalityConfig{FinalityDepth: 20, FinalityTagEnabled: true}).ApplyToSimulator(input), "finality tag can only be enabled")
+	})
+}
@@ -0,0 +1,161 @@
+package fuzz
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/gateway"
+)
+
+const DefaultProbeTimeout = 30 * time.Second
+
+// SendFn sends payload through a normal trigger path. Rejections are expected for malformed payloads, so returned errors
+// are only recorded in the report and don't fail the scenario.
+type SendFn = func(ctx context.Context, payload []byte) error
+
+// Harness sends fuzzed payloads to nodes and asserts, that after each of them nodes neither crashed (health endpoint
+// doesn't respond) nor stalled (Probe, which should send a valid request and wait for its result, doesn't succeed in time).
+type Harness struct {
+	Send  SendFn
+	Probe func(ctx context.Context) error // optional
+	Nodes []*cre.Node
+	// ProbeTimeout limits how long Probe can take before nodes are considered stalled
+	ProbeTimeout time.Duration
+}
+
+type ScenarioResult struct {
+	Scenario       Scenario
+	SendErr        error
+	UnhealthyNodes map[string]error // node name -> error
+	ProbeErr       error
+}
+
+func (r *ScenarioResult) Failed() bool {
+	return len(r.UnhealthyNodes) > 0 || r.ProbeErr != nil
+}
+
+type Report struct {
+	Results []*ScenarioResult
+}
+
+func (r *Report) Failures() []*ScenarioResult {
+	var failures []*ScenarioResult
+	for _, result := range r.Results {
+		if result.Failed() {
+			failures = append(failures, result)
+		}
+	}
+
+	return failures
+}
+
+// Run executes all scenarios one by one. It stops at the first scenario after which nodes are unhealthy, since
+// results of the following ones wouldn't be meaningful.
+func (h *Harness) Run(ctx context.Context, scenarios []Scenario) (*Report, error) {
+	if h.Send == nil {
+		return nil, errors.New("send function is required")
+	}
+
+	report := &Report{}
+	for _, scenario := range scenarios {
+		result := h.runScenario(ctx, scenario)
+		report.Results = append(report.Results, result)
+		if result.Failed() {
+			return report, fmt.Errorf("nodes are unhealthy after scenario '%s': %s", scenario.Name, describe(result))
+		}
+	}
+
+	return report, nil
+}
+
+func (h *Harness) runScenario(ctx context.Context, scenario Scenario) *ScenarioResult {
+	result := &ScenarioResult{
+		Scenario:       scenario,
+		UnhealthyNodes: make(map[string]error),
+	}
+	result.SendErr = h.Send(ctx, scenario.Payload)
+
+	for _, node := range h.Nodes {
+		if _, _, err := node.Clients.RestClient.Health(); err != nil {
+			result.UnhealthyNodes[node.Name] = err
+		}
+	}
+
+	if h.Probe != nil {
+		timeout := h.ProbeTimeout
+		if timeout == 0 {
+			timeout = DefaultProbeTimeout
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		result.ProbeErr = h.Probe(probeCtx)
+	}
+
+	return result
+}
+
+func describe(result *ScenarioResult) string {
+	description := ""
+	for name, err := range result.UnhealthyNodes {
+		description += fmt.Sprintf("node %s doesn't respond (%s); ", name, err)
+	}
+	if result.ProbeErr != nil {
+		description += fmt.Sprintf("probe failed, nodes might be stalled (%s)", result.ProbeErr)
+	}
+
+	return description
+}
+
+// AddSeeds adds scenarios to the seed corpus of a Go native fuzz test
+func AddSeeds(f *testing.F, scenarios []Scenario) {
+	for _, scenario := range scenarios {
+		f.Add(scenario.Payload)
+	}
+}
+
+// FuzzFunc adapts the harness to Go native fuzzing, use it as: f.Fuzz(harness.FuzzFunc(ctx))
+func (h *Harness) FuzzFunc(ctx context.Context) func(t *testing.T, payload []byte) {
+	return func(t *testing.T, payload []byte) {
+		result := h.runScenario(ctx, Scenario{Name: "fuzz", Payload: payload})
+		if result.Failed() {
+			t.Fatalf("nodes are unhealthy after payload %q: %s", payload, describe(result))
+		}
+	}
+}
+
+// HTTPTriggerSender sends payloads as inputs of HTTP trigger requests signed with given key. Payloads that are not valid
+// JSON are sent as JSON strings, so that they still reach the workflow instead of being rejected by the gateway.
+func HTTPTriggerSender(gatewayURL, workflowID string, key *ecdsa.PrivateKey) SendFn {
+	return func(ctx context.Context, payload []byte) error {
+		input := json.RawMessage(payload)
+		if !json.Valid(payload) {
+			encoded, err := json.Marshal(string(payload))
+			if err != nil {
+				return errors.Wrap(err, "failed to encode payload as JSON string")
+			}
+			input = encoded
+		}
+
+		req, token, err := gateway.NewSignedTriggerRequest(workflowID, input, key)
+		if err != nil {
+			return err
+		}
+
+		resp, err := gateway.SendTriggerRequest(ctx, gatewayURL, req, token)
+		if err != nil {
+			return err
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,133 @@
+package fuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+const (
+	DefaultOversizedPayloadSize = 1 << 20 // 1 MiB
+	DefaultRandomScenariosCount = 10
+	DefaultNestingDepth         = 10_000
+)
+
+// Scenario is a single malformed or boundary payload
+type Scenario struct {
+	Name    string
+	Payload []byte
+}
+
+type GeneratorOptions struct {
+	// Seed makes generation deterministic, so that a failing scenario can be reproduced
+	Seed                 uint64
+	OversizedPayloadSize int
+	RandomScenariosCount int
+	NestingDepth         int
+}
+
+func (o *GeneratorOptions) setDefaults() {
+	if o.OversizedPayloadSize == 0 {
+		o.OversizedPayloadSize = DefaultOversizedPayloadSize
+	}
+	if o.RandomScenariosCount == 0 {
+		o.RandomScenariosCount = DefaultRandomScenariosCount
+	}
+	if o.NestingDepth == 0 {
+		o.NestingDepth = DefaultNestingDepth
+	}
+}
+
+// GenerateScenarios derives malformed and boundary payloads from a valid one (JSON or protobuf-encoded):
+// empty and oversized inputs, truncated and bit-flipped payloads, random bytes, invalid UTF-8, deeply nested JSON
+// and, if the valid payload is a JSON object, each of its fields replaced with a value of a wrong type.
+func GenerateScenarios(valid []byte, opts GeneratorOptions) []Scenario {
+	opts.setDefaults()
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed)) //nolint:gosec // reproducibility is more important than randomness here
+
+	scenarios := []Scenario{
+		{Name: "empty", Payload: []byte{}},
+		{Name: "null byte", Payload: []byte{0}},
+		{Name: "json null", Payload: []byte("null")},
+		{Name: "invalid utf-8", Payload: []byte{0xff, 0xfe, 0xfd}},
+		{Name: "oversized", Payload: bytes.Repeat([]byte("A"), opts.OversizedPayloadSize)},
+		{Name: "oversized json string", Payload: []byte(`"` + strings.Repeat("A", opts.OversizedPayloadSize) + `"`)},
+		{Name: "deeply nested json", Payload: []byte(strings.Repeat("[", opts.NestingDepth) + strings.Repeat("]", opts.NestingDepth))},
+	}
+
+	if len(valid) > 1 {
+		scenarios = append(scenarios,
+			Scenario{Name: "truncated by one byte", Payload: slices.Clone(valid[:len(valid)-1])},
+			Scenario{Name: "truncated in half", Payload: slices.Clone(valid[:len(valid)/2])},
+			Scenario{Name: "duplicated", Payload: append(slices.Clone(valid), valid...)},
+		)
+	}
+
+	for i := range opts.RandomScenariosCount {
+		if len(valid) > 0 {
+			flipped := slices.Clone(valid)
+			pos := rng.IntN(len(flipped))
+			flipped[pos] ^= byte(1 << rng.IntN(8))
+			scenarios = append(scenarios, Scenario{Name: fmt.Sprintf("bit flip %d (byte %d)", i, pos), Payload: flipped})
+		}
+
+		random := make([]byte, rng.IntN(1024)+1)
+		for j := range random {
+			random[j] = byte(rng.UintN(256))
+		}
+		scenarios = append(scenarios, Scenario{Name: fmt.Sprintf("random bytes %d", i), Payload: random})
+	}
+
+	return append(scenarios, wrongTypeScenarios(valid)...)
+}
+
+// wrongTypeScenarios replaces each top-level field of a JSON object with a value of a different type
+func wrongTypeScenarios(valid []byte) []Scenario {
+	var object map[string]any
+	if err := json.Unmarshal(valid, &object); err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys) // map iteration order is random, but scenarios must be deterministic
+
+	scenarios := make([]Scenario, 0, len(keys))
+	for _, key := range keys {
+		mutated := make(map[string]any, len(object))
+		for k, v := range object {
+			mutated[k] = v
+		}
+		mutated[key] = wrongType(object[key])
+
+		payload, err := json.Marshal(mutated)
+		if err != nil {
+			continue
+		}
+		scenarios = append(scenarios, Scenario{Name: fmt.Sprintf("wrong type of '%s'", key), Payload: payload})
+	}
+
+	return scenarios
+}
+
+func wrongType(v any) any {
+	switch v.(type) {
+	case string:
+		return 12345
+	case float64:
+		return "not a number"
+	case bool:
+		return "true"
+	case []any:
+		return map[string]any{"not": "an array"}
+	case map[string]any:
+		return []any{"not", "an", "object"}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,26 @@
+package fuzz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateScenarios(t *testing.T) {
+	valid := []byte(`{"amount":10,"recipient":"0xabc","flags":[true]}`)
+	opts := GeneratorOptions{Seed: 42, OversizedPayloadSize: 1024, RandomScenariosCount: 3}
+
+	scenarios := GenerateScenarios(valid, opts)
+	require.Equal(t, scenarios, GenerateScenarios(valid, opts), "generation must be deterministic for the same seed")
+	require.NotEqual(t, scenarios, GenerateScenarios(valid, GeneratorOptions{Seed: 43, OversizedPayloadSize: 1024, RandomScenariosCount: 3}))
+
+	names := make(map[string][]byte, len(scenarios))
+	for _, scenario := range scenarios {
+		names[scenario.Name] = scenario.Payload
+	}
+	require.Len(t, names["oversized"], 1024)
+	require.Equal(t, valid[:len(valid)/2], names["truncated in half"])
+	require.JSONEq(t, `{"amount":"not a number","recipient":"0xabc","flags":[true]}`, string(names["wrong type of 'amount'"]))
+	require.JSONEq(t, `{"amount":10,"recipient":12345,"flags":[true]}`, string(names["wrong type of 'recipient'"]))
+	require.JSONEq(t, `{"amount":10,"recipient":"0xabc","flags":{"not":"an array"}}`, string(names["wrong type of 'flags'"]))
+}
@@ -0,0 +1,269 @@
+// Package golden snapshots generated node configs and job specs and compares them with snapshots committed to the repo,
+// so that unintended changes to config generation are caught by a diff in CI instead of by runtime breakage.
+package golden
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+)
+
+// UpdateEnvVar makes Assert overwrite snapshots instead of comparing them, when it's set to "true"
+const UpdateEnvVar = "CRE_UPDATE_GOLDEN"
+
+// manifestFile lists files written by the last update, only these are removed, when they are no longer generated
+const manifestFile = ".golden-files"
+
+type Options struct {
+	Dir    string // directory with committed snapshots, files not written by the snapshot are left untouched
+	Update bool   // if true, snapshots are overwritten instead of compared
+}
+
+// Normalizer replaces values, which differ between runs (keys, addresses, IDs), with stable placeholders
+type Normalizer struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+var DefaultNormalizers = []Normalizer{
+	{Pattern: regexp.MustCompile(`0x[0-9a-fA-F]{40}\b`), Replacement: "<address>"},
+	{Pattern: regexp.MustCompile(`\b0x[0-9a-fA-F]{64}\b`), Replacement: "<hash>"},
+	{Pattern: regexp.MustCompile(`\b12D3Koo[1-9A-HJ-NP-Za-km-z]{44,}\b`), Replacement: "<peer-id>"},
+	{Pattern: regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`), Replacement: "<uuid>"},
+	{Pattern: regexp.MustCompile(`\b(csa|ocr2?|p2p)_[0-9a-zA-Z_]+\b`), Replacement: "<key-id>"},
+	{Pattern: regexp.MustCompile(`\b[0-9a-f]{64}\b`), Replacement: "<key>"},
+}
+
+var jobNamePattern = regexp.MustCompile(`(?m)^\s*name\s*=\s*['"]([^'"]+)['"]`)
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+type Snapshot struct {
+	Normalizers []Normalizer
+
+	files map[string]string // relative path -> normalized content
+	mu    sync.Mutex
+}
+
+func NewSnapshot() *Snapshot {
+	return &Snapshot{Normalizers: DefaultNormalizers, files: make(map[string]string)}
+}
+
+func (s *Snapshot) Add(relativePath, content string) {
+	for _, normalizer := range s.Normalizers {
+		content = normalizer.Pattern.ReplaceAllString(content, normalizer.Replacement)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[filepath.ToSlash(relativePath)] = strings.TrimSpace(content) + "\n"
+}
+
+// AddNodeConfigs adds generated TOML configs of all nodes. Secrets are never snapshotted.
+func (s *Snapshot) AddNodeConfigs(nodeSets []*cre.CapabilitiesAwareNodeSet) {
+	for _, nodeSet := range nodeSets {
+		for nodeIdx, nodeSpec := range nodeSet.NodeSpecs {
+			s.Add(filepath.Join("nodesets", sanitize(nodeSet.Name), fmt.Sprintf("node%d.toml", nodeIdx)), nodeSpec.Node.TestConfigOverrides)
+		}
+	}
+}
+
+// AddJobSpecs adds job specs proposed to nodes of the DON, grouped by node and named after jobs
+func (s *Snapshot) AddJobSpecs(don *cre.Don, jobs cre.DonJobs) {
+	nodeNames := make(map[string]string, len(don.Nodes))
+	for _, node := range don.Nodes {
+		if node.JobDistributorDetails != nil {
+			nodeNames[node.JobDistributorDetails.NodeID] = node.Name
+		}
+	}
+
+	for idx, job := range jobs {
+		nodeName, ok := nodeNames[job.NodeId]
+		if !ok {
+			nodeName = "unknown-node"
+		}
+		jobName := fmt.Sprintf("job%d", idx)
+		if match := jobNamePattern.FindStringSubmatch(job.Spec); match != nil {
+			jobName = match[1]
+		}
+		s.Add(filepath.Join("jobs", sanitize(don.Name), sanitize(nodeName), sanitize(jobName)+".toml"), job.Spec)
+	}
+}
+
+// Assert compares the snapshot with committed files or overwrites them, if updating was requested by the options or UpdateEnvVar
+func (s *Snapshot) Assert(opts Options) error {
+	if opts.Dir == "" {
+		return errors.New("golden files directory is required")
+	}
+
+	if opts.Update || os.Getenv(UpdateEnvVar) == "true" {
+		return s.write(opts.Dir)
+	}
+
+	return s.compare(opts.Dir)
+}
+
+func (s *Snapshot) write(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// remove stale snapshots (e.g. of jobs that are no longer generated), but only files written by a previous update,
+	// the directory is supplied by the user and can contain other files
+	previous, manifestErr := readManifest(dir)
+	if manifestErr != nil {
+		return manifestErr
+	}
+	for _, relativePath := range previous {
+		if _, ok := s.files[relativePath]; ok {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(relativePath))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to remove stale golden file %s", path)
+		}
+		removeEmptyParents(dir, filepath.Dir(path))
+	}
+
+	for relativePath, content := range s.files {
+		path := filepath.Join(dir, relativePath)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return errors.Wrapf(err, "failed to create directory for golden file %s", path)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			return errors.Wrapf(err, "failed to write golden file %s", path)
+		}
+	}
+
+	manifest := strings.Join(slices.Sorted(maps.Keys(s.files)), "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), []byte(manifest), 0o600); err != nil {
+		return errors.Wrapf(err, "failed to write list of golden files to %s", dir)
+	}
+
+	return nil
+}
+
+// readManifest returns relative paths of files written by the last update, if any
+func readManifest(dir string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read list of golden files from %s", dir)
+	}
+
+	var paths []string
+	for _, line := range strings.Split(string(content), "\n") {
+		// paths outside of the directory are never removed, even if someone edited the list
+		if line = strings.TrimSpace(line); line != "" && filepath.IsLocal(filepath.FromSlash(line)) {
+			paths = append(paths, line)
+		}
+	}
+
+	return paths, nil
+}
+
+// removeEmptyParents removes empty directories from path up to (but excluding) root
+func removeEmptyParents(root, path string) {
+	for path != root && strings.HasPrefix(path, root) {
+		if os.Remove(path) != nil {
+			return
+		}
+		path = filepath.Dir(path)
+	}
+}
+
+func (s *Snapshot) compare(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	committed := make(map[string]string)
+	walkErr := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if filepath.Dir(path) == filepath.Clean(dir) && d.Name() == manifestFile {
+			return nil
+		}
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		relativePath, relErr := filepath.Rel(dir, path)
+		if relErr != nil {
+			return relErr
+		}
+		committed[filepath.ToSlash(relativePath)] = string(content)
+		return nil
+	})
+	if walkErr != nil {
+		return errors.Wrapf(walkErr, "failed to read golden files from %s", dir)
+	}
+
+	var diffs []string
+	for _, relativePath := range slices.Sorted(maps.Keys(s.files)) {
+		expected, ok := committed[relativePath]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: not in golden files", relativePath))
+			continue
+		}
+		if diff := firstDifference(expected, s.files[relativePath]); diff != "" {
+			diffs = append(diffs, fmt.Sprintf("%s: %s", relativePath, diff))
+		}
+	}
+	// if the list of written files exists, other files in the directory don't belong to the snapshot
+	written, manifestErr := readManifest(dir)
+	if manifestErr != nil {
+		return manifestErr
+	}
+	for _, relativePath := range slices.Sorted(maps.Keys(committed)) {
+		if written != nil && !slices.Contains(written, relativePath) {
+			continue
+		}
+		if _, ok := s.files[relativePath]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: no longer generated", relativePath))
+		}
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("generated configs differ from golden files in %s (set %s=true to update them):\n%s", dir, UpdateEnvVar, strings.Join(diffs, "\n"))
+	}
+
+	return nil
+}
+
+func firstDifference(expected, actual string) string {
+	if expected == actual {
+		return ""
+	}
+
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+	for i := range max(len(expectedLines), len(actualLines)) {
+		var e, a string
+		if i < len(expectedLines) {
+			e = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			a = actualLines[i]
+		}
+		if e != a {
+			return fmt.Sprintf("line %d differs\n  - %s\n  + %s", i+1, e, a)
+		}
+	}
+
+	return "content differs"
+}
+
+func sanitize(name string) string {
+	return unsafePathChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,47 @@
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotUpdateKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	readme := filepath.Join(dir, "README.md")
+	require.NoError(t, os.WriteFile(readme, []byte("golden files of the smoke test"), 0o600))
+
+	first := NewSnapshot()
+	first.Add("jobs/workflow/node0/cron.toml", `name = "cron"`)
+	first.Add("jobs/workflow/node0/removed.toml", `name = "removed"`)
+	require.NoError(t, first.Assert(Options{Dir: dir, Update: true}))
+
+	second := NewSnapshot()
+	second.Add("jobs/workflow/node0/cron.toml", `name = "cron"`)
+	require.NoError(t, second.Assert(Options{Dir: dir, Update: true}))
+
+	require.FileExists(t, readme, "file not written by the snapshot was removed")
+	require.NoFileExists(t, filepath.Join(dir, "jobs/workflow/node0/removed.toml"), "stale golden file wasn't removed")
+	require.FileExists(t, filepath.Join(dir, "jobs/workflow/node0/cron.toml"))
+
+	require.NoError(t, second.Assert(Options{Dir: dir}), "other files in the directory aren't part of the snapshot")
+	require.ErrorContains(t, first.Assert(Options{Dir: dir}), "removed.toml: not in golden files")
+}
+
+func TestSnapshotUpdateDoesNotRemoveFilesOutsideOfDir(t *testing.T) {
+	parent := t.TempDir()
+	outside := filepath.Join(parent, "outside.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("keep"), 0o600))
+
+	dir := filepath.Join(parent, "golden")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, manifestFile), []byte("../outside.txt\n"), 0o600))
+
+	snapshot := NewSnapshot()
+	snapshot.Add("nodesets/workflow/node0.toml", "[Feature]")
+	require.NoError(t, snapshot.Assert(Options{Dir: dir, Update: true}))
+
+	require.FileExists(t, outside)
+}
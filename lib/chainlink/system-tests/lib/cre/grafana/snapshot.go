@@ -0,0 +1,311 @@
+// Package grafana renders panels of Grafana dashboards to PNG files, so that a visual summary of the run
+// can be attached to artifacts and reviewed without starting the observability stack again.
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+)
+
+// UIDs of dashboards provisioned by the CTF observability stack
+const (
+	WorkflowEngineDashboardUID = "ce589a98-b4be-4f80-bed1-bc62f3e4414a"
+	CadvisorDashboardUID       = "pMEd7m0Mz"
+
+	// OCRDashboardUID is the dashboard with OCR panels, which the stack doesn't provision, RenderPanels uploads it
+	OCRDashboardUID = "cre-ocr"
+	// PrometheusDatasourceUID is the UID Grafana derives from the name of the Prometheus datasource of the stack
+	PrometheusDatasourceUID = "PBFA97CFB590B2093"
+
+	DefaultWidth  = 1200
+	DefaultHeight = 600
+)
+
+type Panel struct {
+	Name         string `toml:"name"` // used as the file name
+	DashboardUID string `toml:"dashboard_uid"`
+	// Title of the panel in the dashboard, its ID is looked up by it, unless PanelID is set. IDs of panels change, when
+	// dashboards are edited, titles don't.
+	Title   string            `toml:"title"`
+	PanelID int               `toml:"panel_id"`
+	Vars    map[string]string `toml:"vars"` // dashboard variables, passed as var-<name>=<value>
+}
+
+// DefaultPanels summarize OCR rounds, workflow and capability success and resource usage of nodes
+var DefaultPanels = []Panel{
+	{Name: "ocr-rounds", DashboardUID: OCRDashboardUID, Title: OCRRoundsPanelTitle},
+	{Name: "workflow-executions-completed", DashboardUID: WorkflowEngineDashboardUID, Title: "Workflows Execution Rate | Status: Completed"},
+	{Name: "workflow-errors", DashboardUID: WorkflowEngineDashboardUID, Title: "Workflow Errors over time"},
+	{Name: "workflow-latency-p95", DashboardUID: WorkflowEngineDashboardUID, Title: "Workflows Execution Latency (p95) | Status: Completed"},
+	{Name: "capability-invocations", DashboardUID: WorkflowEngineDashboardUID, Title: "Capabilities"},
+	{Name: "capability-failures", DashboardUID: WorkflowEngineDashboardUID, Title: "Capability Failures Increase per Workflow"},
+	{Name: "node-cpu-usage", DashboardUID: CadvisorDashboardUID, Title: "CPU Usage"},
+	{Name: "node-memory-usage", DashboardUID: CadvisorDashboardUID, Title: "Memory Usage"},
+}
+
+type SnapshotConfig struct {
+	GrafanaURL string  `toml:"grafana_url"` // framework.LocalGrafanaBaseURL is used if not set
+	Panels     []Panel `toml:"panels"`      // DefaultPanels are used if not set
+	Width      int     `toml:"width"`
+	Height     int     `toml:"height"`
+	// TokenEnvVar is the name of env var with a service account token, not needed for the local stack with anonymous access
+	TokenEnvVar string `toml:"token_env_var"`
+}
+
+// RenderPanels renders panels for the time range of the run to PNG files in outputDir and returns their paths.
+// Rendering requires the Grafana image renderer (plugin or remote service). If it's not available, a warning is logged
+// and no files are returned, because a missing summary must not fail the test in teardown.
+func RenderPanels(ctx context.Context, logger zerolog.Logger, config SnapshotConfig, from, to time.Time, outputDir string) ([]string, error) {
+	if config.GrafanaURL == "" {
+		config.GrafanaURL = framework.LocalGrafanaBaseURL
+	}
+	if len(config.Panels) == 0 {
+		config.Panels = DefaultPanels
+	}
+	if config.Width == 0 {
+		config.Width = DefaultWidth
+	}
+	if config.Height == 0 {
+		config.Height = DefaultHeight
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create directory %s for dashboard snapshots", outputDir)
+	}
+
+	client := &http.Client{Timeout: 2 * time.Minute} // rendering is slow, especially on the first request
+	if slices.ContainsFunc(config.Panels, func(panel Panel) bool { return panel.DashboardUID == OCRDashboardUID }) {
+		if err := uploadDashboard(ctx, client, config, ocrDashboard()); err != nil {
+			logger.Warn().Err(err).Msg("Failed to upload OCR dashboard, its panels won't be rendered")
+		}
+	}
+
+	panelIDs := make(map[string]map[string]int) // by dashboard UID and panel title
+	files := make([]string, 0, len(config.Panels))
+	for _, panel := range config.Panels {
+		if panel.PanelID == 0 {
+			if _, ok := panelIDs[panel.DashboardUID]; !ok {
+				ids, idsErr := dashboardPanelIDs(ctx, client, config, panel.DashboardUID)
+				if idsErr != nil {
+					logger.Warn().Err(idsErr).Msgf("Failed to look up panels of dashboard %s", panel.DashboardUID)
+				}
+				panelIDs[panel.DashboardUID] = ids
+			}
+			id, ok := panelIDs[panel.DashboardUID][panel.Title]
+			if !ok {
+				logger.Warn().Msgf("Panel '%s' not found in dashboard %s, skipping panel %s", panel.Title, panel.DashboardUID, panel.Name)
+				continue
+			}
+			panel.PanelID = id
+		}
+
+		png, renderErr := renderPanel(ctx, client, config, panel, from, to)
+		if renderErr != nil {
+			if errors.Is(renderErr, errRendererUnavailable) {
+				logger.Warn().Msgf("Grafana image renderer is not available at %s, skipping dashboard snapshots", config.GrafanaURL)
+				return files, nil
+			}
+			logger.Warn().Err(renderErr).Msgf("Failed to render panel %s", panel.Name)
+			continue
+		}
+
+		path := filepath.Join(outputDir, panel.Name+".png")
+		if err := os.WriteFile(path, png, 0o600); err != nil {
+			return files, errors.Wrapf(err, "failed to write snapshot of panel %s", panel.Name)
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+var errRendererUnavailable = errors.New("grafana image renderer is unavailable")
+
+type dashboardPanel struct {
+	ID         int                 `json:"id"`
+	Title      string              `json:"title"`
+	Type       string              `json:"type"`
+	GridPos    map[string]int      `json:"gridPos,omitempty"`
+	Datasource map[string]string   `json:"datasource,omitempty"`
+	Targets    []map[string]string `json:"targets,omitempty"`
+	Panels     []dashboardPanel    `json:"panels,omitempty"` // of collapsed rows
+}
+
+type dashboard struct {
+	UID           string           `json:"uid"`
+	Title         string           `json:"title"`
+	SchemaVersion int              `json:"schemaVersion"`
+	Panels        []dashboardPanel `json:"panels"`
+}
+
+// OCRRoundsPanelTitle is the title of the panel with OCR rounds committed by each node, see OCRDashboardUID
+const OCRRoundsPanelTitle = "OCR Rounds"
+
+// ocrDashboard returns the OCR dashboard, IDs of its panels are generated from their order
+func ocrDashboard() dashboard {
+	panels := []dashboardPanel{
+		{
+			Title:      OCRRoundsPanelTitle,
+			Type:       "timeseries",
+			Datasource: map[string]string{"type": "prometheus", "uid": PrometheusDatasourceUID},
+			Targets: []map[string]string{{
+				"refId":        "A",
+				"expr":         "sum by (instance) (delta(ocr3_committed_sequence_number[1m]))",
+				"legendFormat": "{{instance}}",
+			}},
+		},
+		{
+			Title:      "OCR Epoch",
+			Type:       "timeseries",
+			Datasource: map[string]string{"type": "prometheus", "uid": PrometheusDatasourceUID},
+			Targets: []map[string]string{{
+				"refId":        "A",
+				"expr":         "max by (instance) (ocr3_epoch)",
+				"legendFormat": "{{instance}}",
+			}},
+		},
+	}
+	for idx := range panels {
+		panels[idx].ID = idx + 1
+		panels[idx].GridPos = map[string]int{"h": 8, "w": 24, "x": 0, "y": idx * 8}
+	}
+
+	return dashboard{UID: OCRDashboardUID, Title: "CRE OCR", SchemaVersion: 38, Panels: panels}
+}
+
+// uploadDashboard creates or replaces the dashboard in Grafana
+func uploadDashboard(ctx context.Context, client *http.Client, config SnapshotConfig, d dashboard) error {
+	body, marshalErr := json.Marshal(map[string]any{"dashboard": d, "overwrite": true})
+	if marshalErr != nil {
+		return errors.Wrapf(marshalErr, "failed to encode dashboard %s", d.UID)
+	}
+	req, reqErr := newGrafanaRequest(ctx, config, http.MethodPost, "/api/dashboards/db", bytes.NewReader(body))
+	if reqErr != nil {
+		return reqErr
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err := doGrafanaRequest(client, req)
+
+	return errors.Wrapf(err, "failed to upload dashboard %s", d.UID)
+}
+
+// dashboardPanelIDs returns IDs of panels of the dashboard by their titles, including panels of collapsed rows
+func dashboardPanelIDs(ctx context.Context, client *http.Client, config SnapshotConfig, dashboardUID string) (map[string]int, error) {
+	req, reqErr := newGrafanaRequest(ctx, config, http.MethodGet, "/api/dashboards/uid/"+url.PathEscape(dashboardUID), nil)
+	if reqErr != nil {
+		return nil, reqErr
+	}
+	body, err := doGrafanaRequest(client, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get dashboard %s", dashboardUID)
+	}
+
+	var resp struct {
+		Dashboard dashboard `json:"dashboard"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode dashboard %s", dashboardUID)
+	}
+
+	ids := make(map[string]int)
+	var collect func(panels []dashboardPanel)
+	collect = func(panels []dashboardPanel) {
+		for _, panel := range panels {
+			// the first panel with the title wins, like in Grafana's search
+			if _, ok := ids[panel.Title]; !ok && panel.Type != "row" {
+				ids[panel.Title] = panel.ID
+			}
+			collect(panel.Panels)
+		}
+	}
+	collect(resp.Dashboard.Panels)
+
+	return ids, nil
+}
+
+func newGrafanaRequest(ctx context.Context, config SnapshotConfig, method, path string, body io.Reader) (*http.Request, error) {
+	req, reqErr := http.NewRequestWithContext(ctx, method, config.GrafanaURL+path, body)
+	if reqErr != nil {
+		return nil, errors.Wrap(reqErr, "failed to create Grafana request")
+	}
+	if config.TokenEnvVar != "" {
+		req.Header.Set("Authorization", "Bearer "+os.Getenv(config.TokenEnvVar))
+	}
+
+	return req, nil
+}
+
+func doGrafanaRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, errors.Wrap(readErr, "failed to read Grafana response")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("grafana returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
+
+func renderPanel(ctx context.Context, client *http.Client, config SnapshotConfig, panel Panel, from, to time.Time) ([]byte, error) {
+	query := url.Values{}
+	query.Set("orgId", "1")
+	query.Set("panelId", strconv.Itoa(panel.PanelID))
+	query.Set("from", strconv.FormatInt(from.UnixMilli(), 10))
+	query.Set("to", strconv.FormatInt(to.UnixMilli(), 10))
+	query.Set("width", strconv.Itoa(config.Width))
+	query.Set("height", strconv.Itoa(config.Height))
+	query.Set("tz", "UTC")
+	for name, value := range panel.Vars {
+		query.Set("var-"+name, value)
+	}
+	// slug is ignored by Grafana, but it's a required part of the path
+	req, reqErr := newGrafanaRequest(ctx, config, http.MethodGet, fmt.Sprintf("/render/d-solo/%s/_?%s", panel.DashboardUID, query.Encode()), nil)
+	if reqErr != nil {
+		return nil, reqErr
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render panel %d of dashboard %s", panel.PanelID, panel.DashboardUID)
+	}
+	defer resp.Body.Close()
+
+	body, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, errors.Wrap(readErr, "failed to read rendered panel")
+	}
+
+	if resp.StatusCode == http.StatusOK && resp.Header.Get("Content-Type") == "image/png" {
+		return body, nil
+	}
+	// Grafana without a renderer responds with an error mentioning the renderer, or with a non-image page
+	if resp.StatusCode == http.StatusOK || strings.Contains(strings.ToLower(string(body)), "renderer") {
+		return nil, errRendererUnavailable
+	}
+
+	return nil, fmt.Errorf("rendering panel %d of dashboard %s failed with status %d: %s", panel.PanelID, panel.DashboardUID, resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,99 @@
+package grafana
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPanels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.URL.Path, "/render/d-solo/"+WorkflowEngineDashboardUID+"/"))
+		if r.URL.Query().Get("panelId") == "404" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"panel not found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	files, err := RenderPanels(t.Context(), zerolog.Nop(), SnapshotConfig{
+		GrafanaURL: server.URL,
+		Panels: []Panel{
+			{Name: "executions", DashboardUID: WorkflowEngineDashboardUID, PanelID: 9},
+			{Name: "missing", DashboardUID: WorkflowEngineDashboardUID, PanelID: 404},
+		},
+	}, time.Now().Add(-time.Hour), time.Now(), dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "executions.png")}, files)
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(content))
+}
+
+func TestRenderPanelsWithoutRenderer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"message":"No image renderer available/installed"}`))
+	}))
+	defer server.Close()
+
+	files, err := RenderPanels(t.Context(), zerolog.Nop(), SnapshotConfig{GrafanaURL: server.URL}, time.Now().Add(-time.Hour), time.Now(), t.TempDir())
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestRenderPanelsByTitle(t *testing.T) {
+	var uploaded map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/dashboards/db":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&uploaded))
+			_, _ = w.Write([]byte(`{"status":"success"}`))
+		case r.URL.Path == "/api/dashboards/uid/"+WorkflowEngineDashboardUID:
+			_, _ = w.Write([]byte(`{"dashboard":{"panels":[{"id":57,"title":"Workflow","type":"row","panels":[{"id":7,"title":"Workflow Errors over time","type":"timeseries"}]}]}}`))
+		case r.URL.Path == "/api/dashboards/uid/"+OCRDashboardUID:
+			body, err := json.Marshal(map[string]any{"dashboard": uploaded["dashboard"]})
+			assert.NoError(t, err)
+			_, _ = w.Write(body)
+		case strings.HasPrefix(r.URL.Path, "/render/d-solo/"):
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("panel " + r.URL.Query().Get("panelId")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	files, err := RenderPanels(t.Context(), zerolog.Nop(), SnapshotConfig{
+		GrafanaURL: server.URL,
+		Panels: []Panel{
+			{Name: "ocr-rounds", DashboardUID: OCRDashboardUID, Title: OCRRoundsPanelTitle},
+			{Name: "errors", DashboardUID: WorkflowEngineDashboardUID, Title: "Workflow Errors over time"},
+			{Name: "missing", DashboardUID: WorkflowEngineDashboardUID, Title: "Removed panel"},
+		},
+	}, time.Now().Add(-time.Hour), time.Now(), dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "ocr-rounds.png"), filepath.Join(dir, "errors.png")}, files)
+	require.NotNil(t, uploaded, "OCR dashboard wasn't uploaded")
+
+	for file, expected := range map[string]string{files[0]: "panel 1", files[1]: "panel 7"} {
+		content, readErr := os.ReadFile(file)
+		require.NoError(t, readErr)
+		assert.Equal(t, expected, string(content))
+	}
+}
@@ -0,0 +1,198 @@
+package cre
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/secrets"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/crypto"
+)
+
+// KeysFile holds pre-generated identities of nodes, so that topologies are reproducible: peer IDs and accounts match
+// on-chain fixtures generated by other tooling and pre-funded accounts can be used. Nodes missing in the file get
+// generated keys. Example:
+//
+//	[[nodesets]]
+//	name = "workflow"
+//	[[nodesets.nodes]]
+//	index = 1
+//	secrets = '''<P2PKey, DKGRecipientKey, EVM and Solana keys in the format of node secrets>'''
+//	[[nodesets.nodes.ocr2_keys]]
+//	chain_type = "evm"
+//	json = '''<OCR2 key bundle exported from a node>'''
+//	password = "..."
+type KeysFile struct {
+	NodeSets []*KeysFileNodeSet `toml:"nodesets"`
+}
+
+type KeysFileNodeSet struct {
+	Name  string          `toml:"name"`
+	Nodes []*KeysFileNode `toml:"nodes"`
+}
+
+// KeysFileNode has keys of the node with Index in the nodeset. Secrets use the same format as test_secrets_overrides of
+// node specs and replace them, OCR2 keys are imported to the node once it runs instead of bundles it generated.
+type KeysFileNode struct {
+	Index    int                `toml:"index"`
+	Secrets  string             `toml:"secrets"`
+	OCR2Keys []*KeysFileOCR2Key `toml:"ocr2_keys"`
+}
+
+type KeysFileOCR2Key struct {
+	ChainType string `toml:"chain_type"`
+	JSON      string `toml:"json"`
+	Password  string `toml:"password"`
+}
+
+// LoadKeysFile reads and validates the keys file
+func LoadKeysFile(path string) (*KeysFile, error) {
+	content, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, errors.Wrapf(readErr, "failed to read keys file %s", path)
+	}
+
+	keysFile := &KeysFile{}
+	if err := toml.Unmarshal(content, keysFile); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse keys file %s", path)
+	}
+	if err := keysFile.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid keys file %s", path)
+	}
+
+	return keysFile, nil
+}
+
+// Validate checks that secrets of all nodes can be imported and that no two nodes share a P2P key. All problems are
+// returned at once.
+func (f *KeysFile) Validate() error {
+	var problems []string
+	nodeSetNames := make(map[string]struct{}, len(f.NodeSets))
+	peerIDs := make(map[string]string)
+
+	for _, nodeSet := range f.NodeSets {
+		if nodeSet.Name == "" {
+			problems = append(problems, "nodeset without name")
+			continue
+		}
+		if _, ok := nodeSetNames[nodeSet.Name]; ok {
+			problems = append(problems, fmt.Sprintf("nodeset %s is listed twice", nodeSet.Name))
+			continue
+		}
+		nodeSetNames[nodeSet.Name] = struct{}{}
+
+		indexes := make(map[int]struct{}, len(nodeSet.Nodes))
+		for _, node := range nodeSet.Nodes {
+			target := fmt.Sprintf("node %d of nodeset %s", node.Index, nodeSet.Name)
+			if node.Index < 0 {
+				problems = append(problems, target+" has a negative index")
+				continue
+			}
+			if _, ok := indexes[node.Index]; ok {
+				problems = append(problems, target+" is listed twice")
+				continue
+			}
+			indexes[node.Index] = struct{}{}
+
+			if node.Secrets == "" && len(node.OCR2Keys) == 0 {
+				problems = append(problems, target+" has neither secrets nor OCR2 keys")
+			}
+			if node.Secrets != "" {
+				keys, importErr := secrets.ImportNodeKeys(node.Secrets)
+				if importErr != nil {
+					problems = append(problems, fmt.Sprintf("%s has invalid secrets: %s", target, importErr))
+				} else if previous, ok := peerIDs[keys.PeerID()]; ok {
+					problems = append(problems, fmt.Sprintf("%s has the same P2P key as %s", target, previous))
+				} else {
+					peerIDs[keys.PeerID()] = target
+				}
+			}
+
+			chainTypes := make(map[string]struct{}, len(node.OCR2Keys))
+			for _, key := range node.OCR2Keys {
+				chainType := strings.ToLower(key.ChainType)
+				switch {
+				case chainType == "":
+					problems = append(problems, target+" has an OCR2 key without chain_type")
+				case key.JSON == "":
+					problems = append(problems, fmt.Sprintf("%s has an empty %s OCR2 key", target, chainType))
+				default:
+					if _, ok := chainTypes[chainType]; ok {
+						problems = append(problems, fmt.Sprintf("%s has two %s OCR2 keys", target, chainType))
+					}
+					chainTypes[chainType] = struct{}{}
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("keys file is invalid:\n%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// Apply sets keys of nodes of matching nodesets, replacing their test_secrets_overrides. It should be called after
+// role templates are expanded and chain capabilities are parsed, because imported secrets need an EVM key for every
+// chain of the nodeset, no keys are generated for nodes with imported secrets. Applying the same file again is a no-op.
+func (f *KeysFile) Apply(nodeSets []*CapabilitiesAwareNodeSet) error {
+	for _, nodeSetKeys := range f.NodeSets {
+		idx := slices.IndexFunc(nodeSets, func(nodeSet *CapabilitiesAwareNodeSet) bool {
+			return nodeSet.Name == nodeSetKeys.Name
+		})
+		if idx == -1 {
+			return fmt.Errorf("keys file has keys of nodeset %s, which doesn't exist", nodeSetKeys.Name)
+		}
+		nodeSet := nodeSets[idx]
+
+		for _, node := range nodeSetKeys.Nodes {
+			if node.Index >= len(nodeSet.NodeSpecs) {
+				return fmt.Errorf("keys file has keys of node %d of nodeset %s, which has only %d nodes", node.Index, nodeSet.Name, len(nodeSet.NodeSpecs))
+			}
+			nodeSpec := nodeSet.NodeSpecs[node.Index]
+			if nodeSpec == nil || nodeSpec.Node == nil {
+				return fmt.Errorf("node %d of nodeset %s has no node spec", node.Index, nodeSet.Name)
+			}
+
+			if node.Secrets != "" {
+				keys, importErr := secrets.ImportNodeKeys(node.Secrets)
+				if importErr != nil {
+					return errors.Wrapf(importErr, "failed to import secrets of node %d of nodeset %s", node.Index, nodeSet.Name)
+				}
+				for _, chainID := range nodeSet.EVMChains() {
+					if _, ok := keys.EVM[chainID]; !ok {
+						return fmt.Errorf("secrets of node %d of nodeset %s in keys file have no EVM key for chain %d", node.Index, nodeSet.Name, chainID)
+					}
+				}
+				for _, chainID := range nodeSet.SupportedSolChains {
+					if _, ok := keys.Solana[chainID]; !ok {
+						return fmt.Errorf("secrets of node %d of nodeset %s in keys file have no Solana key for chain %s", node.Index, nodeSet.Name, chainID)
+					}
+				}
+				nodeSpec.Node.TestSecretsOverrides = node.Secrets
+			}
+
+			if len(node.OCR2Keys) > 0 {
+				if nodeSet.NodeOCR2Keys == nil {
+					nodeSet.NodeOCR2Keys = make(map[int][]*crypto.OCR2Key)
+				}
+				ocr2Keys := make([]*crypto.OCR2Key, 0, len(node.OCR2Keys))
+				for _, key := range node.OCR2Keys {
+					ocr2Keys = append(ocr2Keys, &crypto.OCR2Key{
+						ChainType:     strings.ToLower(key.ChainType),
+						EncryptedJSON: []byte(key.JSON),
+						Password:      key.Password,
+					})
+				}
+				nodeSet.NodeOCR2Keys[node.Index] = ocr2Keys
+			}
+		}
+	}
+
+	return nil
+}
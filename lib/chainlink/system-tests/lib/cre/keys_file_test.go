@@ -0,0 +1,136 @@
+package cre
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/secrets"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/crypto"
+)
+
+// nodeSecrets returns secrets of a node with new P2P and DKG recipient keys and EVM keys for given chains
+func nodeSecrets(t *testing.T, evmChainIDs ...uint64) string {
+	t.Helper()
+
+	p2pKey, err := crypto.NewP2PKey("password")
+	require.NoError(t, err)
+	dkgKey, err := crypto.NewDKGRecipientKey("password")
+	require.NoError(t, err)
+	keys := &secrets.NodeKeys{P2PKey: p2pKey, DKGKey: dkgKey, EVM: make(map[uint64]*crypto.EVMKey)}
+	for _, chainID := range evmChainIDs {
+		keys.EVM[chainID], err = crypto.NewEVMKey("password", chainID)
+		require.NoError(t, err)
+	}
+	secretsTOML, err := keys.ToNodeSecretsTOML()
+	require.NoError(t, err)
+
+	return secretsTOML
+}
+
+func TestLoadKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.toml")
+	content := "[[nodesets]]\nname = \"workflow\"\n[[nodesets.nodes]]\nindex = 1\nsecrets = '''" + nodeSecrets(t, 1337) + "'''\n" +
+		"[[nodesets.nodes.ocr2_keys]]\nchain_type = \"EVM\"\njson = '{\"keyBundleID\":\"1\"}'\npassword = \"password\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	keysFile, err := LoadKeysFile(path)
+	require.NoError(t, err)
+	require.Len(t, keysFile.NodeSets, 1)
+	assert.Equal(t, "workflow", keysFile.NodeSets[0].Name)
+	require.Len(t, keysFile.NodeSets[0].Nodes, 1)
+	assert.Equal(t, 1, keysFile.NodeSets[0].Nodes[0].Index)
+	require.Len(t, keysFile.NodeSets[0].Nodes[0].OCR2Keys, 1)
+	assert.Equal(t, "EVM", keysFile.NodeSets[0].Nodes[0].OCR2Keys[0].ChainType)
+
+	_, err = LoadKeysFile(filepath.Join(t.TempDir(), "missing.toml"))
+	require.ErrorContains(t, err, "failed to read keys file")
+}
+
+func TestKeysFileValidate(t *testing.T) {
+	shared := nodeSecrets(t)
+	keysFile := &KeysFile{NodeSets: []*KeysFileNodeSet{
+		{Name: "workflow", Nodes: []*KeysFileNode{
+			{Index: 0, Secrets: shared},
+			{Index: 1, Secrets: shared},
+			{Index: 1, Secrets: shared},
+			{Index: -1, Secrets: shared},
+			{Index: 2},
+			{Index: 3, Secrets: "P2PKey = 1"},
+			{Index: 4, OCR2Keys: []*KeysFileOCR2Key{{JSON: "{}"}, {ChainType: "evm"}, {ChainType: "EVM", JSON: "{}"}, {ChainType: "evm", JSON: "{}"}}},
+		}},
+		{Name: "workflow"},
+		{},
+	}}
+
+	err := keysFile.Validate()
+	require.Error(t, err)
+	for _, problem := range []string{
+		"node 1 of nodeset workflow has the same P2P key as node 0 of nodeset workflow",
+		"node 1 of nodeset workflow is listed twice",
+		"node -1 of nodeset workflow has a negative index",
+		"node 2 of nodeset workflow has neither secrets nor OCR2 keys",
+		"node 3 of nodeset workflow has invalid secrets",
+		"node 4 of nodeset workflow has an OCR2 key without chain_type",
+		"node 4 of nodeset workflow has an empty evm OCR2 key",
+		"node 4 of nodeset workflow has two evm OCR2 keys",
+		"nodeset workflow is listed twice",
+		"nodeset without name",
+	} {
+		assert.ErrorContains(t, err, problem)
+	}
+}
+
+func TestKeysFileApply(t *testing.T) {
+	nodeSet := &CapabilitiesAwareNodeSet{
+		Input:              &ns.Input{Name: "workflow", NodeSpecs: []*clnode.Input{{Node: &clnode.NodeInput{}}, {Node: &clnode.NodeInput{}}}},
+		SupportedEVMChains: []uint64{1337},
+	}
+	imported := nodeSecrets(t, 1337)
+	keysFile := &KeysFile{NodeSets: []*KeysFileNodeSet{{Name: "workflow", Nodes: []*KeysFileNode{
+		{Index: 1, Secrets: imported, OCR2Keys: []*KeysFileOCR2Key{{ChainType: "EVM", JSON: "{}", Password: "password"}}},
+	}}}}
+	require.NoError(t, keysFile.Validate())
+
+	require.NoError(t, keysFile.Apply([]*CapabilitiesAwareNodeSet{nodeSet}))
+	assert.Empty(t, nodeSet.NodeSpecs[0].Node.TestSecretsOverrides, "nodes missing in the keys file must keep generated keys")
+	assert.Equal(t, imported, nodeSet.NodeSpecs[1].Node.TestSecretsOverrides)
+	expectedOCR2Keys := map[int][]*crypto.OCR2Key{1: {{ChainType: "evm", EncryptedJSON: []byte("{}"), Password: "password"}}}
+	assert.Equal(t, expectedOCR2Keys, nodeSet.NodeOCR2Keys)
+
+	require.NoError(t, keysFile.Apply([]*CapabilitiesAwareNodeSet{nodeSet}))
+	assert.Equal(t, imported, nodeSet.NodeSpecs[1].Node.TestSecretsOverrides, "applying the keys file again must be a no-op")
+	assert.Equal(t, expectedOCR2Keys, nodeSet.NodeOCR2Keys, "applying the keys file again must be a no-op")
+}
+
+func TestKeysFileApplyFailures(t *testing.T) {
+	newNodeSet := func() *CapabilitiesAwareNodeSet {
+		return &CapabilitiesAwareNodeSet{
+			Input:              &ns.Input{Name: "workflow", NodeSpecs: []*clnode.Input{{Node: &clnode.NodeInput{}}}},
+			SupportedEVMChains: []uint64{1337, 2337},
+		}
+	}
+	allChains := nodeSecrets(t, 1337, 2337)
+	tests := map[string]struct {
+		nodeSet  string
+		node     *KeysFileNode
+		expected string
+	}{
+		"missing nodeset":  {nodeSet: "other", node: &KeysFileNode{Secrets: allChains}, expected: "keys file has keys of nodeset other, which doesn't exist"},
+		"missing node":     {nodeSet: "workflow", node: &KeysFileNode{Index: 1, Secrets: allChains}, expected: "keys file has keys of node 1 of nodeset workflow, which has only 1 nodes"},
+		"missing EVM keys": {nodeSet: "workflow", node: &KeysFileNode{Secrets: nodeSecrets(t, 1337)}, expected: "have no EVM key for chain 2337"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			nodeSet := newNodeSet()
+			keysFile := &KeysFile{NodeSets: []*KeysFileNodeSet{{Name: test.nodeSet, Nodes: []*KeysFileNode{test.node}}}}
+			require.ErrorContains(t, keysFile.Apply([]*CapabilitiesAwareNodeSet{nodeSet}), test.expected)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cre
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/metrics"
+)
+
+// LeakTargets returns leak detection targets for all nodes of all DONs and for capability plugins running on them.
+// Profiles are fetched only for nodes, from the authenticated pprof endpoint. Heap profiles require
+// `InsecurePPROFHeap = true` in the node config, otherwise only goroutine profiles are available.
+func LeakTargets(ctx context.Context, lggr zerolog.Logger, dons *Dons) []metrics.LeakTarget {
+	var targets []metrics.LeakTarget
+	for _, don := range dons.List() {
+		for _, node := range don.Nodes {
+			if node.Clients.RestClient == nil {
+				continue
+			}
+			nodeURL := node.Clients.RestClient.URL()
+			targets = append(targets, metrics.LeakTarget{
+				Node:       node.Name,
+				MetricsURL: nodeURL + "/metrics",
+				Profiles:   nodeProfileFetcher(node),
+			})
+
+			paths, discoverErr := metrics.DiscoverPluginMetricsPaths(ctx, nodeURL)
+			if discoverErr != nil {
+				lggr.Warn().Err(discoverErr).Msgf("Failed to discover plugins of node %s, only the node will be checked for leaks", node.Name)
+				continue
+			}
+			plugins := make([]string, 0, len(paths))
+			for plugin := range paths {
+				plugins = append(plugins, plugin)
+			}
+			slices.Sort(plugins)
+			for _, plugin := range plugins {
+				targets = append(targets, metrics.LeakTarget{Node: node.Name, Plugin: plugin, MetricsURL: nodeURL + paths[plugin]})
+			}
+		}
+	}
+
+	return targets
+}
+
+func nodeProfileFetcher(node *Node) metrics.ProfileFetcher {
+	return func(ctx context.Context, profile string) ([]byte, error) {
+		resp, err := node.Clients.RestClient.APIClient.R().SetContext(ctx).Get("/v2/debug/pprof/" + profile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch %s profile of node %s", profile, node.Name)
+		}
+		if resp.IsError() {
+			return nil, fmt.Errorf("fetching %s profile of node %s failed with status %d", profile, node.Name, resp.StatusCode())
+		}
+
+		return resp.Body(), nil
+	}
+}
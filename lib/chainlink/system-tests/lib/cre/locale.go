@@ -0,0 +1,98 @@
+package cre
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultNodeTimezone = "UTC"
+	DefaultNodeLocale   = "C.UTF-8"
+
+	envTZ    = "TZ"
+	envLang  = "LANG"
+	envLCAll = "LC_ALL"
+)
+
+// NodeLocaleConfig sets timezone and locale of node containers. Empty fields fall back to the nodeset's config and then to
+// UTC and C.UTF-8, so that cron schedules are evaluated the same way regardless of the host the test runs on.
+// Timezones other than UTC require tzdata to be present in the node image.
+type NodeLocaleConfig struct {
+	Timezone string `toml:"timezone"` // IANA name, e.g. "America/New_York"
+	Locale   string `toml:"locale"`   // e.g. "en_US.UTF-8"
+}
+
+func (c *NodeLocaleConfig) Validate() error {
+	if c.Timezone == "" {
+		return nil
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone '%s': %w", c.Timezone, err)
+	}
+
+	return nil
+}
+
+// ValidateLocales validates nodeset-wide and per-node locale configs
+func (c *CapabilitiesAwareNodeSet) ValidateLocales() error {
+	if c.Locale != nil {
+		if err := c.Locale.Validate(); err != nil {
+			return fmt.Errorf("invalid locale config for nodeset %s: %w", c.Name, err)
+		}
+	}
+
+	for nodeIdx, override := range c.NodeLocaleOverrides {
+		idx, err := strconv.Atoi(nodeIdx)
+		if err != nil || idx < 0 || idx >= len(c.NodeSpecs) {
+			return fmt.Errorf("invalid node index '%s' in locale overrides for nodeset %s, it must be between 0 and %d", nodeIdx, c.Name, len(c.NodeSpecs)-1)
+		}
+		if override == nil {
+			continue
+		}
+		if err := override.Validate(); err != nil {
+			return fmt.Errorf("invalid locale override for node %d in nodeset %s: %w", idx, c.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// nodeLocale returns the effective locale config of the node with given index
+func (c *CapabilitiesAwareNodeSet) nodeLocale(nodeIdx int) NodeLocaleConfig {
+	out := NodeLocaleConfig{Timezone: DefaultNodeTimezone, Locale: DefaultNodeLocale}
+
+	for _, cfg := range []*NodeLocaleConfig{c.Locale, c.NodeLocaleOverrides[strconv.Itoa(nodeIdx)]} {
+		if cfg == nil {
+			continue
+		}
+		if cfg.Timezone != "" {
+			out.Timezone = cfg.Timezone
+		}
+		if cfg.Locale != "" {
+			out.Locale = cfg.Locale
+		}
+	}
+
+	return out
+}
+
+// ApplyLocales sets TZ, LANG and LC_ALL env vars of each node. Env vars set explicitly in node specs take precedence.
+// Env vars maps are copied, because node specs of a nodeset often share the same map.
+func (c *CapabilitiesAwareNodeSet) ApplyLocales() {
+	for nodeIdx, nodeSpec := range c.NodeSpecs {
+		locale := c.nodeLocale(nodeIdx)
+
+		envVars := make(map[string]string, len(nodeSpec.Node.EnvVars)+3)
+		maps.Copy(envVars, nodeSpec.Node.EnvVars)
+		for key, value := range map[string]string{envTZ: locale.Timezone, envLang: locale.Locale, envLCAll: locale.Locale} {
+			if _, ok := envVars[key]; !ok {
+				envVars[key] = value
+			}
+		}
+
+		c.NodeSpecs[nodeIdx].Node.EnvVars = envVars
+	}
+}
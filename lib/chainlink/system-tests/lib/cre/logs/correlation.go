@@ -0,0 +1,176 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CorrelationIDPrefix marks IDs generated by tests, so that they are easy to find in logs of nodes and gateways
+const CorrelationIDPrefix = "cre-test-"
+
+// NewCorrelationID returns a unique ID, which ties a test action to executions it triggered. It's used as the ID of
+// HTTP trigger requests and of mock trigger events fired by the framework. It contains no '/', which gateways reject.
+func NewCorrelationID() string {
+	random := make([]byte, 12)
+	_, _ = rand.Read(random) // never fails, see crypto/rand.Read
+
+	return CorrelationIDPrefix + hex.EncodeToString(random)
+}
+
+// ExecutionIDFor returns the execution ID the workflow engine derives from the workflow ID and the trigger event ID
+func ExecutionIDFor(workflowID, triggerEventID string) string {
+	sum := sha256.Sum256([]byte(workflowID + triggerEventID))
+	return hex.EncodeToString(sum[:])
+}
+
+// CorrelatedLogs are log lines of all nodes, which belong to a single correlation ID
+type CorrelatedLogs struct {
+	CorrelationID string
+	// ExecutionIDs are IDs of executions the correlation ID resulted in
+	ExecutionIDs []string
+	// Lines maps node names to their lines, which mention the correlation ID or one of the executions
+	Lines map[string][]string
+	// First and Last are timestamps of the earliest and the latest line, e.g. to read metrics of that window
+	First, Last time.Time
+}
+
+// Nodes returns sorted names of nodes, which logged something about the correlation ID
+func (c *CorrelatedLogs) Nodes() []string {
+	return sortedKeys(c.Lines)
+}
+
+func (c *CorrelatedLogs) Empty() bool {
+	return len(c.Lines) == 0
+}
+
+type correlatedLogLine struct {
+	Ts          string `json:"ts"`
+	ExecutionID string `json:"executionID"`
+}
+
+// CorrelateLogs finds lines of node logs, which mention the correlation ID, and lines of executions it resulted in.
+// Executions are those, whose ID the engine derived from workflowID and the correlation ID as the trigger event ID
+// (which is the case for mock triggers), and those mentioned on lines with the correlation ID (e.g. of HTTP trigger
+// requests, whose request ID is the correlation ID). workflowID is optional, if the latter are enough.
+func CorrelateLogs(nodeLogs map[string][]byte, workflowID, correlationID string) *CorrelatedLogs {
+	executionIDs := make(map[string]struct{})
+	if workflowID != "" {
+		executionIDs[ExecutionIDFor(workflowID, correlationID)] = struct{}{}
+	}
+	for _, content := range nodeLogs {
+		forEachLine(content, func(line []byte) {
+			if !bytes.Contains(line, []byte(correlationID)) {
+				return
+			}
+			if parsed, ok := parseCorrelatedLine(line); ok && parsed.ExecutionID != "" {
+				executionIDs[parsed.ExecutionID] = struct{}{}
+			}
+		})
+	}
+
+	correlated := &CorrelatedLogs{CorrelationID: correlationID, Lines: make(map[string][]string)}
+	seenExecutions := make(map[string]struct{})
+	for _, node := range sortedKeys(nodeLogs) {
+		forEachLine(nodeLogs[node], func(line []byte) {
+			mentioned := bytes.Contains(line, []byte(correlationID))
+			if !mentioned && !containsAny(line, executionIDs) {
+				return
+			}
+			parsed, isJSON := parseCorrelatedLine(line)
+			_, ofExecution := executionIDs[parsed.ExecutionID]
+			if !mentioned && !ofExecution {
+				return
+			}
+			if ofExecution {
+				seenExecutions[parsed.ExecutionID] = struct{}{}
+			}
+			correlated.Lines[node] = append(correlated.Lines[node], string(line))
+
+			if !isJSON {
+				return
+			}
+			if ts, tsErr := time.Parse(nodeLogTimeLayout, parsed.Ts); tsErr == nil {
+				if correlated.First.IsZero() || ts.Before(correlated.First) {
+					correlated.First = ts
+				}
+				if ts.After(correlated.Last) {
+					correlated.Last = ts
+				}
+			}
+		})
+	}
+	correlated.ExecutionIDs = slices.Sorted(maps.Keys(seenExecutions))
+
+	return correlated
+}
+
+// LookupCorrelationID reads logs of given Docker node containers and returns their lines of the correlation ID, see
+// CorrelateLogs. It fails, if no node logged anything about it.
+func LookupCorrelationID(containerNames []string, workflowID, correlationID string) (*CorrelatedLogs, error) {
+	if correlationID == "" {
+		return nil, errors.New("correlation ID is empty")
+	}
+
+	nodeLogs, logsErr := ReadDockerNodeLogs("")
+	if logsErr != nil {
+		return nil, errors.Wrap(logsErr, "failed to read node logs")
+	}
+	selected := make(map[string][]byte, len(containerNames))
+	for _, name := range containerNames {
+		content, ok := nodeLogs[name]
+		if !ok {
+			return nil, fmt.Errorf("no logs found for container %s", name)
+		}
+		selected[name] = content
+	}
+
+	correlated := CorrelateLogs(selected, workflowID, correlationID)
+	if correlated.Empty() {
+		return correlated, fmt.Errorf("no logs of %s mention correlation ID %s", strings.Join(containerNames, ", "), correlationID)
+	}
+
+	return correlated, nil
+}
+
+func forEachLine(content []byte, fn func(line []byte)) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			fn(line)
+		}
+	}
+}
+
+func containsAny(line []byte, ids map[string]struct{}) bool {
+	for id := range ids {
+		if bytes.Contains(line, []byte(id)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseCorrelatedLine(line []byte) (correlatedLogLine, bool) {
+	var parsed correlatedLogLine
+	if line[0] != '{' {
+		return parsed, false
+	}
+	if err := json.Unmarshal(line, &parsed); err != nil {
+		return correlatedLogLine{}, false
+	}
+
+	return parsed, true
+}
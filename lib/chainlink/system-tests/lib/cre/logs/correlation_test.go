@@ -0,0 +1,53 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelateLogs(t *testing.T) {
+	correlationID := NewCorrelationID()
+	require.True(t, strings.HasPrefix(correlationID, CorrelationIDPrefix))
+	require.NotContains(t, correlationID, "/")
+	require.NotEqual(t, correlationID, NewCorrelationID())
+
+	workflowID := "0011aabb"
+	executionID := ExecutionIDFor(workflowID, correlationID)
+	otherExecutionID := ExecutionIDFor(workflowID, "other-event")
+	httpExecutionID := ExecutionIDFor(workflowID, "http-event")
+
+	nodeLogs := map[string][]byte{
+		"workflow-node1": []byte(strings.Join([]string{
+			`{"ts":"2025-01-01T10:00:01.000Z","msg":"Workflow execution starting ...","executionID":"` + executionID + `"}`,
+			`{"ts":"2025-01-01T10:00:02.000Z","msg":"Workflow execution starting ...","executionID":"` + otherExecutionID + `"}`,
+			`{"ts":"2025-01-01T10:00:03.500Z","msg":"Workflow execution finished","executionID":"` + executionID + `"}`,
+		}, "\n")),
+		"workflow-node2": []byte(strings.Join([]string{
+			`{"ts":"2025-01-01T10:00:00.500Z","msg":"Received trigger request","requestID":"` + correlationID + `","executionID":"` + httpExecutionID + `"}`,
+			`{"ts":"2025-01-01T10:00:04.000Z","msg":"Step finished","executionID":"` + httpExecutionID + `"}`,
+			`plain text line without the ID`,
+		}, "\n")),
+		"workflow-node3": []byte(`{"ts":"2025-01-01T10:00:05.000Z","msg":"Workflow execution starting ...","executionID":"` + otherExecutionID + `"}`),
+	}
+
+	correlated := CorrelateLogs(nodeLogs, workflowID, correlationID)
+	assert.Equal(t, []string{"workflow-node1", "workflow-node2"}, correlated.Nodes())
+	assert.ElementsMatch(t, []string{executionID, httpExecutionID}, correlated.ExecutionIDs)
+	assert.Len(t, correlated.Lines["workflow-node1"], 2)
+	assert.Len(t, correlated.Lines["workflow-node2"], 2, "lines of executions mentioned with the correlation ID are included")
+	assert.Equal(t, "2025-01-01T10:00:00.5Z", correlated.First.UTC().Format("2006-01-02T15:04:05.999Z"))
+	assert.Equal(t, "2025-01-01T10:00:04Z", correlated.Last.UTC().Format("2006-01-02T15:04:05.999Z"))
+
+	t.Run("without workflow ID only mentioned executions are found", func(t *testing.T) {
+		correlated := CorrelateLogs(nodeLogs, "", correlationID)
+		assert.Equal(t, []string{httpExecutionID}, correlated.ExecutionIDs)
+		assert.Equal(t, []string{"workflow-node2"}, correlated.Nodes())
+	})
+
+	t.Run("unknown IDs find nothing", func(t *testing.T) {
+		assert.True(t, CorrelateLogs(nodeLogs, workflowID, NewCorrelationID()).Empty())
+	})
+}
@@ -0,0 +1,67 @@
+package logs
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	dfilter "github.com/docker/docker/api/types/filters"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+)
+
+// ReadDockerNodeLogs returns full logs (stdout and stderr) of all CTF containers, whose name contains containerNamePattern.
+// Keys of the returned map are container names without the leading slash.
+func ReadDockerNodeLogs(containerNamePattern string) (map[string][]byte, error) {
+	logStream, lErr := framework.StreamContainerLogs(container.ListOptions{
+		All: true,
+		Filters: dfilter.NewArgs(dfilter.KeyValuePair{
+			Key:   "label",
+			Value: "framework=ctf",
+		}),
+	}, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if lErr != nil {
+		return nil, errors.Wrap(lErr, "failed to stream Docker container logs")
+	}
+
+	result := make(map[string][]byte)
+	for cName, ioReader := range logStream {
+		name := strings.TrimPrefix(cName, "/")
+		if !strings.Contains(name, containerNamePattern) {
+			_ = ioReader.Close()
+			continue
+		}
+
+		content, readErr := demuxDockerLogs(ioReader)
+		_ = ioReader.Close() // can't do much about the error here
+		if readErr != nil {
+			return nil, errors.Wrapf(readErr, "failed to read logs of container %s", name)
+		}
+		result[name] = content
+	}
+
+	return result, nil
+}
+
+// demuxDockerLogs strips the 8-byte headers Docker prefixes each log frame with, when container has no TTY attached
+func demuxDockerLogs(r io.Reader) ([]byte, error) {
+	var content []byte
+	header := make([]byte, 8)
+	for {
+		_, err := io.ReadFull(r, header)
+		if err == io.EOF {
+			return content, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read log stream header")
+		}
+
+		msg := make([]byte, binary.BigEndian.Uint32(header[4:8]))
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return nil, errors.Wrap(err, "failed to read log message")
+		}
+		content = append(content, msg...)
+	}
+}
@@ -0,0 +1,207 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ExecutionStartedMessage is logged by the workflow engine, when it starts executing a workflow for a trigger event
+	ExecutionStartedMessage = "Workflow execution starting ..."
+	// zapcore.ISO8601TimeEncoder layout used by Chainlink nodes
+	nodeLogTimeLayout = "2006-01-02T15:04:05.000Z0700"
+)
+
+// ExecutionStart is a single workflow execution start observed in node logs.
+// Execution ID is derived from workflow ID and trigger event ID, so all nodes executing the same event log the same execution ID.
+type ExecutionStart struct {
+	Node        string
+	WorkflowID  string
+	ExecutionID string
+	TriggerID   string
+	Timestamp   time.Time
+}
+
+type nodeLogLine struct {
+	Msg         string `json:"msg"`
+	Ts          string `json:"ts"`
+	WorkflowID  string `json:"workflowID"`
+	ExecutionID string `json:"executionID"`
+	TriggerID   string `json:"triggerID"`
+}
+
+// ParseExecutionStarts extracts execution starts from JSON logs of a single node. Lines that are not JSON are ignored.
+func ParseExecutionStarts(node string, content []byte) ([]ExecutionStart, error) {
+	var starts []ExecutionStart
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' || !bytes.Contains(line, []byte(ExecutionStartedMessage)) {
+			continue
+		}
+
+		var l nodeLogLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			continue
+		}
+		if l.Msg != ExecutionStartedMessage || l.ExecutionID == "" {
+			continue
+		}
+
+		start := ExecutionStart{
+			Node:        node,
+			WorkflowID:  l.WorkflowID,
+			ExecutionID: l.ExecutionID,
+			TriggerID:   l.TriggerID,
+		}
+		if ts, tsErr := time.Parse(nodeLogTimeLayout, l.Ts); tsErr == nil {
+			start.Timestamp = ts
+		}
+		starts = append(starts, start)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to scan logs of node %s", node)
+	}
+
+	return starts, nil
+}
+
+// ExecutionCorrelation holds on which nodes and how many times a single execution ID was observed
+type ExecutionCorrelation struct {
+	ExecutionID string
+	WorkflowID  string
+	// Starts is the number of times execution was started per node
+	Starts map[string]int
+}
+
+// DeduplicationReport is the result of correlating execution IDs across all worker nodes
+type DeduplicationReport struct {
+	Nodes      []string
+	Executions []ExecutionCorrelation
+	// Duplicates maps execution ID to nodes that started it more than once
+	Duplicates map[string][]string
+	// Misses maps execution ID to nodes that never started it
+	Misses map[string][]string
+}
+
+// OK returns true when every execution was started exactly once on every node
+func (r *DeduplicationReport) OK() bool {
+	return len(r.Duplicates) == 0 && len(r.Misses) == 0
+}
+
+// Err returns an error describing all duplicated and missed executions, or nil if there are none
+func (r *DeduplicationReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "trigger deduplication violated (%d duplicated, %d missed executions out of %d)", len(r.Duplicates), len(r.Misses), len(r.Executions))
+	for _, id := range sortedKeys(r.Duplicates) {
+		fmt.Fprintf(&sb, "\nexecution %s started more than once on: %s", id, strings.Join(r.Duplicates[id], ", "))
+	}
+	for _, id := range sortedKeys(r.Misses) {
+		fmt.Fprintf(&sb, "\nexecution %s never started on: %s", id, strings.Join(r.Misses[id], ", "))
+	}
+
+	return errors.New(sb.String())
+}
+
+// CorrelateExecutions groups execution starts by execution ID and checks that each one was started exactly once by each node.
+// If nodes is empty, the set of nodes is taken from the observed execution starts. If workflowID is not empty, only executions
+// of that workflow are taken into account.
+func CorrelateExecutions(starts []ExecutionStart, nodes []string, workflowID string) *DeduplicationReport {
+	byID := make(map[string]*ExecutionCorrelation)
+	observedNodes := make(map[string]struct{})
+	for _, s := range starts {
+		if workflowID != "" && s.WorkflowID != workflowID {
+			continue
+		}
+		observedNodes[s.Node] = struct{}{}
+		c, ok := byID[s.ExecutionID]
+		if !ok {
+			c = &ExecutionCorrelation{ExecutionID: s.ExecutionID, WorkflowID: s.WorkflowID, Starts: make(map[string]int)}
+			byID[s.ExecutionID] = c
+		}
+		c.Starts[s.Node]++
+	}
+
+	if len(nodes) == 0 {
+		nodes = sortedKeys(observedNodes)
+	} else {
+		nodes = slices.Sorted(slices.Values(nodes))
+	}
+
+	report := &DeduplicationReport{
+		Nodes:      nodes,
+		Duplicates: make(map[string][]string),
+		Misses:     make(map[string][]string),
+	}
+	for _, id := range sortedKeys(byID) {
+		c := byID[id]
+		report.Executions = append(report.Executions, *c)
+		for _, node := range nodes {
+			switch count := c.Starts[node]; {
+			case count == 0:
+				report.Misses[id] = append(report.Misses[id], node)
+			case count > 1:
+				report.Duplicates[id] = append(report.Duplicates[id], node)
+			}
+		}
+	}
+
+	return report
+}
+
+// VerifyTriggerDeduplication reads logs of given Docker worker node containers and verifies that each trigger event
+// resulted in exactly one execution on each of them. Bootstrap and gateway nodes should not be passed, since they do not run workflows.
+func VerifyTriggerDeduplication(workerContainerNames []string, workflowID string) (*DeduplicationReport, error) {
+	if len(workerContainerNames) == 0 {
+		return nil, errors.New("at least one worker container name is required")
+	}
+
+	nodeLogs, logsErr := ReadDockerNodeLogs("")
+	if logsErr != nil {
+		return nil, errors.Wrap(logsErr, "failed to read node logs")
+	}
+
+	var starts []ExecutionStart
+	for _, node := range workerContainerNames {
+		content, ok := nodeLogs[node]
+		if !ok {
+			return nil, fmt.Errorf("no logs found for container %s", node)
+		}
+		nodeStarts, parseErr := ParseExecutionStarts(node, content)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		starts = append(starts, nodeStarts...)
+	}
+
+	report := CorrelateExecutions(starts, workerContainerNames, workflowID)
+	if len(report.Executions) == 0 {
+		return report, errors.New("no workflow executions found in worker node logs")
+	}
+
+	return report, report.Err()
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
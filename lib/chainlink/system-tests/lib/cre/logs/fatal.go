@@ -0,0 +1,35 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+)
+
+// fatalLevels are levels of JSON log lines of nodes, which mean that a component crashed or the node is going down
+var fatalLevels = []string{"panic", "dpanic", "fatal", "crit"}
+
+// rawFatalPrefixes start lines Go runtime prints to stderr, when a process crashes outside of the logger
+var rawFatalPrefixes = [][]byte{[]byte("panic: "), []byte("fatal error: ")}
+
+// FatalLines returns lines of node logs, which report a panic or a fatal error: JSON lines of fatal levels and crash
+// messages of the Go runtime
+func FatalLines(content []byte) []string {
+	var lines []string
+	forEachLine(content, func(line []byte) {
+		if line[0] == '{' {
+			var parsed struct {
+				Level string `json:"level"`
+			}
+			if err := json.Unmarshal(line, &parsed); err == nil && slices.Contains(fatalLevels, parsed.Level) {
+				lines = append(lines, string(line))
+			}
+			return
+		}
+		if slices.ContainsFunc(rawFatalPrefixes, func(prefix []byte) bool { return bytes.HasPrefix(line, prefix) }) {
+			lines = append(lines, string(line))
+		}
+	})
+
+	return lines
+}
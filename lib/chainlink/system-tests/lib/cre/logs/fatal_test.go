@@ -0,0 +1,23 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFatalLines(t *testing.T) {
+	content := []byte(`{"level":"info","ts":"2025-01-01T10:00:00.000Z","msg":"started"}
+{"level":"crit","ts":"2025-01-01T10:00:01.000Z","msg":"Recovered goroutine panic","panic":"runtime error"}
+{"level":"error","ts":"2025-01-01T10:00:02.000Z","msg":"panic: not at the start of a raw line"}
+panic: runtime error: invalid memory address or nil pointer dereference
+goroutine 1 [running]:
+fatal error: concurrent map writes
+`)
+
+	require.Equal(t, []string{
+		`{"level":"crit","ts":"2025-01-01T10:00:01.000Z","msg":"Recovered goroutine panic","panic":"runtime error"}`,
+		"panic: runtime error: invalid memory address or nil pointer dereference",
+		"fatal error: concurrent map writes",
+	}, FatalLines(content))
+}
@@ -0,0 +1,378 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	DefaultLogChunkBytes  = 16 * 1024 * 1024
+	DefaultLogChunkRetry  = 5
+	maxLogChunkBytes      = 256 * 1024 * 1024
+	logProgressFileSuffix = ".progress"
+	rateLimiterBurst      = 64 * 1024
+)
+
+// KubernetesLogsConfig configures download of logs of pods, e.g. of nodes deployed to CRIB after a failed soak run.
+// Logs are downloaded in chunks and the progress is stored next to each log file, so that a download that timed out
+// or failed can be resumed by calling DownloadKubernetesLogs again with the same Dir.
+type KubernetesLogsConfig struct {
+	Namespace     string
+	LabelSelector string // selects pods, e.g. infra.NodeLabelSelector(0, "workflow")
+	Container     string // if empty, the default container of the pod is used
+	Dir           string // <Dir>/<pod name>.log
+
+	ChunkBytes     int64 // size of a single request, DefaultLogChunkBytes is used if not set
+	MaxBytesPerPod int64 // optional, the download stops once that many bytes of logs of a pod are written
+	BytesPerSecond int64 // optional, limits bandwidth used to download logs of all pods
+	MaxRetries     int   // of a failed chunk, DefaultLogChunkRetry is used if not set
+
+	// Since is applied by the API server, only newer lines are sent
+	Since time.Time
+	// Levels and Contains are applied to lines while they are streamed, only matching lines are written. The API server
+	// can't filter lines, so they don't reduce the download, only Since and MaxBytesPerPod do. Levels match the level of
+	// JSON log lines, Contains matches any of the fragments. Empty means all lines.
+	Levels   []string
+	Contains []string
+}
+
+// PodLogs is the result of downloading logs of a single pod
+type PodLogs struct {
+	Pod       string
+	Path      string
+	Bytes     int64 // written to the file, including previous attempts
+	Complete  bool  // false, if the download failed and can be resumed
+	Truncated bool  // true, if MaxBytesPerPod was reached
+	Err       error
+}
+
+// logProgress is stored in <log file>.progress. Since the API server accepts only second precision in sinceTime, lines
+// of the last second are requested again and skipped based on their timestamps.
+type logProgress struct {
+	LastTimestamp time.Time `json:"last_timestamp"`
+	SkipAtLast    int       `json:"skip_at_last"` // lines with LastTimestamp already written
+	Bytes         int64     `json:"bytes"`
+	Complete      bool      `json:"complete"`
+	Truncated     bool      `json:"truncated"`
+
+	linesAtLast int // lines with LastTimestamp seen so far
+}
+
+// DownloadKubernetesLogs downloads logs of all pods matching the label selector. Failure of a single pod doesn't stop
+// downloads of other pods, check PodLogs.Err. The error is returned only if pods can't be listed.
+func DownloadKubernetesLogs(ctx context.Context, config KubernetesLogsConfig) ([]*PodLogs, error) {
+	restConfig, restConfigErr := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).ClientConfig()
+	if restConfigErr != nil {
+		return nil, errors.Wrap(restConfigErr, "failed to load Kubernetes client config")
+	}
+
+	clientset, clientsetErr := kubernetes.NewForConfig(restConfig)
+	if clientsetErr != nil {
+		return nil, errors.Wrap(clientsetErr, "failed to create Kubernetes client")
+	}
+
+	pods, listErr := clientset.CoreV1().Pods(config.Namespace).List(ctx, metav1.ListOptions{LabelSelector: config.LabelSelector})
+	if listErr != nil {
+		return nil, errors.Wrapf(listErr, "failed to list pods matching %s in namespace %s", config.LabelSelector, config.Namespace)
+	}
+	if mkdirErr := os.MkdirAll(config.Dir, 0o755); mkdirErr != nil {
+		return nil, errors.Wrapf(mkdirErr, "failed to create directory %s", config.Dir)
+	}
+
+	if config.ChunkBytes <= 0 {
+		config.ChunkBytes = DefaultLogChunkBytes
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = DefaultLogChunkRetry
+	}
+	var limiter *rate.Limiter
+	if config.BytesPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(config.BytesPerSecond), rateLimiterBurst)
+	}
+
+	results := make([]*PodLogs, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		result := &PodLogs{Pod: pod.Name, Path: filepath.Join(config.Dir, pod.Name+".log")}
+		result.Err = downloadPodLogs(ctx, clientset, config, limiter, result)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func downloadPodLogs(ctx context.Context, clientset *kubernetes.Clientset, config KubernetesLogsConfig, limiter *rate.Limiter, result *PodLogs) error {
+	progress, progressErr := readLogProgress(result.Path)
+	if progressErr != nil {
+		return progressErr
+	}
+	defer func() {
+		result.Bytes, result.Complete, result.Truncated = progress.Bytes, progress.Complete, progress.Truncated
+	}()
+	if progress.Complete {
+		return nil
+	}
+
+	file, openErr := os.OpenFile(result.Path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if openErr != nil {
+		return errors.Wrapf(openErr, "failed to open %s", result.Path)
+	}
+	defer file.Close()
+	// whatever was written after the last stored progress is discarded, it will be downloaded again
+	if err := file.Truncate(progress.Bytes); err != nil {
+		return errors.Wrapf(err, "failed to truncate %s", result.Path)
+	}
+	if _, err := file.Seek(progress.Bytes, io.SeekStart); err != nil {
+		return errors.Wrapf(err, "failed to seek in %s", result.Path)
+	}
+
+	filter := lineFilter{levels: config.Levels, contains: config.Contains}
+	chunkBytes := config.ChunkBytes
+	retries := 0
+	for !progress.Complete {
+		since := config.Since
+		if !progress.LastTimestamp.IsZero() {
+			since = progress.LastTimestamp
+		}
+		options := &corev1.PodLogOptions{
+			Container:  config.Container,
+			Timestamps: true,
+			LimitBytes: &chunkBytes,
+		}
+		if !since.IsZero() {
+			options.SinceTime = &metav1.Time{Time: since}
+		}
+
+		chunkErr := func() error {
+			stream, streamErr := clientset.CoreV1().Pods(config.Namespace).GetLogs(result.Pod, options).Stream(ctx)
+			if streamErr != nil {
+				return streamErr
+			}
+			defer stream.Close()
+
+			var reader io.Reader = stream
+			if limiter != nil {
+				reader = &rateLimitedReader{ctx: ctx, reader: stream, limiter: limiter}
+			}
+			return appendLogChunk(reader, file, &progress, filter, chunkBytes, config.MaxBytesPerPod)
+		}()
+		if errors.Is(chunkErr, errNoProgress) {
+			// lines of a single second (or a single line) fill the whole chunk, a bigger one is needed to get past them
+			if chunkBytes >= maxLogChunkBytes {
+				return errors.Errorf("failed to download logs of pod %s, more than %d bytes of logs were written at %s", result.Pod, maxLogChunkBytes, progress.LastTimestamp)
+			}
+			chunkBytes = min(2*chunkBytes, maxLogChunkBytes)
+			continue
+		}
+		if chunkErr != nil {
+			if ctx.Err() != nil || retries >= config.MaxRetries {
+				return errors.Wrapf(chunkErr, "failed to download logs of pod %s, call DownloadKubernetesLogs again to resume", result.Pod)
+			}
+			retries++
+			select {
+			case <-ctx.Done():
+				return errors.Wrapf(ctx.Err(), "failed to download logs of pod %s, call DownloadKubernetesLogs again to resume", result.Pod)
+			case <-time.After(time.Duration(retries) * time.Second):
+			}
+			continue
+		}
+		retries, chunkBytes = 0, config.ChunkBytes
+
+		if err := writeLogProgress(result.Path, progress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// errNoProgress is returned by appendLogChunk, if a full chunk had no new lines, i.e. the same chunk would be sent again
+var errNoProgress = errors.New("chunk has no new lines")
+
+// appendLogChunk writes new lines of a chunk to w and advances the progress. The chunk is complete, if the server sent
+// less than chunkBytes, i.e. there are no more logs. A partial last line of a full chunk is requested again with the next
+// chunk. If a full chunk has no new lines, the progress is left as it was and errNoProgress is returned.
+func appendLogChunk(r io.Reader, w io.Writer, progress *logProgress, filter lineFilter, chunkBytes, maxBytes int64) error {
+	content, readErr := io.ReadAll(r)
+	if readErr != nil {
+		return readErr
+	}
+	fullChunk := int64(len(content)) >= chunkBytes
+	if fullChunk {
+		// without a newline the chunk is a part of a single line
+		content = content[:bytes.LastIndexByte(content, '\n')+1]
+	}
+	initial := *progress
+
+	newLines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), int(max(chunkBytes, 10*1024*1024)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		timestamp, text, ok := splitTimestamp(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case timestamp.Before(progress.LastTimestamp):
+			continue
+		case timestamp.Equal(progress.LastTimestamp):
+			if progress.SkipAtLast > 0 {
+				progress.SkipAtLast--
+				continue
+			}
+		}
+
+		if !filter.matches(text) {
+			progress.advance(timestamp)
+			newLines++
+			continue
+		}
+		if maxBytes > 0 && progress.Bytes+int64(len(text))+1 > maxBytes {
+			progress.Truncated, progress.Complete = true, true
+			return nil
+		}
+		n, writeErr := io.WriteString(w, text+"\n")
+		if writeErr != nil {
+			return writeErr
+		}
+		progress.Bytes += int64(n)
+		progress.advance(timestamp)
+		newLines++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if fullChunk && newLines == 0 {
+		*progress = initial
+		return errNoProgress
+	}
+
+	// lines of the last second are sent again with the next chunk
+	progress.SkipAtLast = progress.linesAtLast
+	if !fullChunk {
+		progress.Complete = true
+	}
+
+	return nil
+}
+
+// advance moves progress past a line with given timestamp
+func (p *logProgress) advance(timestamp time.Time) {
+	if timestamp.Equal(p.LastTimestamp) {
+		p.linesAtLast++
+		return
+	}
+	p.LastTimestamp = timestamp
+	p.linesAtLast = 1
+}
+
+// splitTimestamp splits the RFC3339 timestamp prefixed by the API server, when timestamps are requested
+func splitTimestamp(line string) (time.Time, string, bool) {
+	prefix, text, ok := strings.Cut(line, " ")
+	if !ok {
+		return time.Time{}, "", false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, prefix)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+
+	return timestamp, text, true
+}
+
+type lineFilter struct {
+	levels   []string
+	contains []string
+}
+
+func (f lineFilter) matches(line string) bool {
+	if len(f.levels) > 0 {
+		var l struct {
+			Level string `json:"level"`
+		}
+		if err := json.Unmarshal([]byte(line), &l); err != nil || !slices.Contains(f.levels, l.Level) {
+			return false
+		}
+	}
+	if len(f.contains) > 0 {
+		return slices.ContainsFunc(f.contains, func(fragment string) bool { return strings.Contains(line, fragment) })
+	}
+
+	return true
+}
+
+func readLogProgress(logPath string) (logProgress, error) {
+	var progress logProgress
+	content, readErr := os.ReadFile(logPath + logProgressFileSuffix)
+	if os.IsNotExist(readErr) {
+		return progress, nil
+	}
+	if readErr != nil {
+		return progress, errors.Wrapf(readErr, "failed to read download progress of %s", logPath)
+	}
+	if err := json.Unmarshal(content, &progress); err != nil {
+		return progress, errors.Wrapf(err, "failed to parse download progress of %s", logPath)
+	}
+	progress.linesAtLast = progress.SkipAtLast
+
+	return progress, nil
+}
+
+func writeLogProgress(logPath string, progress logProgress) error {
+	content, marshalErr := json.Marshal(progress)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	return errors.Wrapf(os.WriteFile(logPath+logProgressFileSuffix, content, 0o644), "failed to store download progress of %s", logPath)
+}
+
+type rateLimitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > rateLimiterBurst {
+		p = p[:rateLimiterBurst]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+func (p PodLogs) String() string {
+	status := "complete"
+	switch {
+	case p.Err != nil:
+		status = fmt.Sprintf("incomplete (%s)", p.Err)
+	case p.Truncated:
+		status = "truncated"
+	}
+
+	return fmt.Sprintf("%s: %d bytes, %s", p.Pod, p.Bytes, status)
+}
@@ -0,0 +1,76 @@
+package logs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendLogChunkResumes(t *testing.T) {
+	first := strings.Join([]string{
+		`2025-01-01T10:00:00.100000000Z {"level":"info","msg":"a"}`,
+		`2025-01-01T10:00:00.200000000Z {"level":"error","msg":"b"}`,
+		`2025-01-01T10:00:00.200000000Z {"level":"error","msg":"c"}`,
+		`2025-01-01T10:00:01.000000000Z {"level":"info","msg":"partial`,
+	}, "\n")
+
+	var out bytes.Buffer
+	progress := logProgress{}
+	require.NoError(t, appendLogChunk(strings.NewReader(first), &out, &progress, lineFilter{}, int64(len(first)), 0))
+	require.False(t, progress.Complete)
+	require.Equal(t, 2, progress.SkipAtLast)
+	require.Equal(t, time.Date(2025, 1, 1, 10, 0, 0, 200000000, time.UTC), progress.LastTimestamp)
+
+	// the server sends the whole last second again, lines already written are skipped
+	second := strings.Join([]string{
+		`2025-01-01T10:00:00.100000000Z {"level":"info","msg":"a"}`,
+		`2025-01-01T10:00:00.200000000Z {"level":"error","msg":"b"}`,
+		`2025-01-01T10:00:00.200000000Z {"level":"error","msg":"c"}`,
+		`2025-01-01T10:00:01.000000000Z {"level":"info","msg":"d"}`,
+	}, "\n") + "\n"
+	require.NoError(t, appendLogChunk(strings.NewReader(second), &out, &progress, lineFilter{}, int64(len(second))+1, 0))
+	require.True(t, progress.Complete)
+	require.Equal(t, `{"level":"info","msg":"a"}
+{"level":"error","msg":"b"}
+{"level":"error","msg":"c"}
+{"level":"info","msg":"d"}
+`, out.String())
+	require.Equal(t, int64(out.Len()), progress.Bytes)
+}
+
+func TestAppendLogChunkFiltersAndCaps(t *testing.T) {
+	chunk := strings.Join([]string{
+		`2025-01-01T10:00:00Z {"level":"info","msg":"a"}`,
+		`2025-01-01T10:00:01Z {"level":"error","msg":"b"}`,
+		`2025-01-01T10:00:02Z {"level":"error","msg":"c"}`,
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	progress := logProgress{}
+	filter := lineFilter{levels: []string{"error"}}
+	require.NoError(t, appendLogChunk(strings.NewReader(chunk), &out, &progress, filter, 1024, 30))
+	require.True(t, progress.Truncated)
+	require.Equal(t, "{\"level\":\"error\",\"msg\":\"b\"}\n", out.String())
+}
+
+func TestAppendLogChunkWithoutNewLinesIsNotComplete(t *testing.T) {
+	written := `2025-01-01T10:00:00.100000000Z {"level":"info","msg":"a"}` + "\n"
+	var out bytes.Buffer
+	progress := logProgress{}
+	require.NoError(t, appendLogChunk(strings.NewReader(written), &out, &progress, lineFilter{}, int64(len(written)), 0))
+	require.False(t, progress.Complete)
+
+	// the chunk is filled by the line written already, the download can't move past it with chunks of this size
+	before := progress
+	require.ErrorIs(t, appendLogChunk(strings.NewReader(written), &out, &progress, lineFilter{}, int64(len(written)), 0), errNoProgress)
+	require.Equal(t, before, progress)
+
+	// a part of a single line longer than the chunk isn't written
+	partial := `2025-01-01T10:00:01.000000000Z {"level":"info","msg":"very long`
+	require.ErrorIs(t, appendLogChunk(strings.NewReader(partial), &out, &progress, lineFilter{}, int64(len(partial)), 0), errNoProgress)
+	require.Equal(t, before, progress)
+	require.Equal(t, `{"level":"info","msg":"a"}`+"\n", out.String())
+}
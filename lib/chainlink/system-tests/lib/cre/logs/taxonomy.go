@@ -0,0 +1,214 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorCategory is a class of failures of capability executions, so that negative tests can assert on what went wrong
+// instead of matching error strings, which change between node and capability versions
+type ErrorCategory string
+
+const (
+	ErrorCategoryTimeout           ErrorCategory = "timeout"
+	ErrorCategoryConsensus         ErrorCategory = "consensus_failure"
+	ErrorCategoryRemoteUnavailable ErrorCategory = "remote_unavailable"
+	ErrorCategoryUserError         ErrorCategory = "user_error"
+	ErrorCategoryUnknown           ErrorCategory = "unknown"
+
+	// ExecutionFailedMessage prefixes messages logged by the workflow engine, when an execution fails
+	ExecutionFailedMessage = "Workflow execution failed"
+	// moduleExecutionErrorMessage is logged, when the workflow module itself returned an error or trapped
+	moduleExecutionErrorMessage = "Workflow execution failed with module execution error"
+	// CapabilityExecutionFailedMessage is logged by the workflow engine (at debug level) with the error of a capability
+	// call. Messages of failed executions don't carry the error, so it's taken from the last failed call of the execution.
+	CapabilityExecutionFailedMessage = "Capability execution failed"
+	executionStatusTimeout           = "timeout"
+)
+
+// errorTaxonomy maps lower-cased fragments of error messages of nodes, engines and capabilities to categories.
+// Categories are matched in order, so that e.g. a timeout of a remote call is a timeout, not an unavailable remote.
+var errorTaxonomy = []struct {
+	category  ErrorCategory
+	fragments []string
+}{
+	{ErrorCategoryTimeout, []string{"context deadline exceeded", "deadline exceeded", "timed out", "timeout"}},
+	{ErrorCategoryRemoteUnavailable, []string{
+		"capability not found", "capability not available", "no capability", "not registered", "unknown capability",
+		"connection refused", "no peers", "peer not found", "unavailable", "failed to dial", "no such host",
+	}},
+	{ErrorCategoryConsensus, []string{
+		"consensus", "quorum", "not enough responses", "not enough signatures", "not enough observations",
+		"insufficient observations", "insufficient signatures", "insufficient responses", "f+1", "2f+1",
+		"mismatched responses", "conflicting responses", "aggregation",
+	}},
+	{ErrorCategoryUserError, []string{
+		"invalid input", "invalid config", "validation", "failed to unmarshal", "failed to decode", "secret not found",
+		"wasm", "panic", "module execution error", "user error", "unauthorized", "limit exceeded", "exceeds limit",
+	}},
+}
+
+// ClassifyError returns the category of an error message, ErrorCategoryUnknown if it doesn't match any category
+func ClassifyError(message string) ErrorCategory {
+	lower := strings.ToLower(message)
+	for _, entry := range errorTaxonomy {
+		for _, fragment := range entry.fragments {
+			if strings.Contains(lower, fragment) {
+				return entry.category
+			}
+		}
+	}
+
+	return ErrorCategoryUnknown
+}
+
+// ExecutionFailure is a single failed workflow execution observed in node logs
+type ExecutionFailure struct {
+	Node        string
+	WorkflowID  string
+	ExecutionID string
+	Status      string // execution status, e.g. errored, timeout
+	Message     string
+	Error       string // empty, if the engine didn't log the error
+	Capability  string // ID of the capability, whose failed call is the error, empty if it's not known
+	Category    ErrorCategory
+	Timestamp   time.Time
+}
+
+// failureMessages are messages of log lines needed to find failed executions and their errors
+var failureMessages = map[string]struct{}{ExecutionFailedMessage: {}, ExecutionStartedMessage: {}, CapabilityExecutionFailedMessage: {}}
+
+type failureLogLine struct {
+	nodeLogLine
+	Status string `json:"status"`
+	Err    string `json:"err"`
+	CapID  string `json:"capID"`
+}
+
+// capabilityFailure is the last failed capability call of a workflow on the node
+type capabilityFailure struct {
+	capabilityID string
+	err          string
+	ts           time.Time
+}
+
+// ParseExecutionFailures extracts failed executions from JSON logs of a single node. Lines that are not JSON are ignored.
+// The error of a failed execution is the error of the last capability call of its workflow, which failed after the
+// execution started, see CapabilityExecutionFailedMessage. Logs must be collected at debug level to include them.
+func ParseExecutionFailures(node string, content []byte) ([]ExecutionFailure, error) {
+	var failures []ExecutionFailure
+	startedAt := make(map[string]time.Time)                     // by execution ID
+	lastCapabilityFailure := make(map[string]capabilityFailure) // by workflow ID
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' || !containsAny(line, failureMessages) {
+			continue
+		}
+
+		var l failureLogLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			continue
+		}
+		ts, tsErr := time.Parse(nodeLogTimeLayout, l.Ts)
+		switch {
+		case l.Msg == ExecutionStartedMessage:
+			if tsErr == nil && l.ExecutionID != "" {
+				startedAt[l.ExecutionID] = ts
+			}
+			continue
+		case l.Msg == CapabilityExecutionFailedMessage:
+			lastCapabilityFailure[l.WorkflowID] = capabilityFailure{capabilityID: l.CapID, err: l.Err, ts: ts}
+			continue
+		case !strings.HasPrefix(l.Msg, ExecutionFailedMessage):
+			continue
+		}
+
+		var capabilityID string
+		if l.Err == "" {
+			if capFailure, ok := lastCapabilityFailure[l.WorkflowID]; ok && !capFailure.ts.Before(startedAt[l.ExecutionID]) {
+				l.Err = capFailure.err
+				capabilityID = capFailure.capabilityID
+				delete(lastCapabilityFailure, l.WorkflowID)
+			}
+		}
+
+		failure := ExecutionFailure{
+			Node:        node,
+			WorkflowID:  l.WorkflowID,
+			ExecutionID: l.ExecutionID,
+			Status:      l.Status,
+			Message:     l.Msg,
+			Error:       l.Err,
+			Capability:  capabilityID,
+			Category:    classifyFailure(l),
+		}
+		if tsErr == nil {
+			failure.Timestamp = ts
+		}
+		failures = append(failures, failure)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to scan logs of node %s", node)
+	}
+
+	return failures, nil
+}
+
+func classifyFailure(l failureLogLine) ErrorCategory {
+	if l.Status == executionStatusTimeout {
+		return ErrorCategoryTimeout
+	}
+	if category := ClassifyError(l.Err); category != ErrorCategoryUnknown {
+		return category
+	}
+	if l.Msg == moduleExecutionErrorMessage {
+		return ErrorCategoryUserError
+	}
+
+	return ErrorCategoryUnknown
+}
+
+// CountFailuresByCategory returns the number of distinct failed executions of the workflow per category.
+// Each execution is counted once, even though all nodes executing it log the failure.
+func CountFailuresByCategory(failures []ExecutionFailure, workflowID string) map[ErrorCategory]int {
+	counts := make(map[ErrorCategory]int)
+	seen := make(map[string]bool)
+	for _, failure := range failures {
+		if failure.WorkflowID != workflowID || seen[failure.ExecutionID] {
+			continue
+		}
+		seen[failure.ExecutionID] = true
+		counts[failure.Category]++
+	}
+
+	return counts
+}
+
+// AssertFailureCategory checks that the workflow failed at least once and that all its failures are of the expected category
+func AssertFailureCategory(failures []ExecutionFailure, workflowID string, expected ErrorCategory) error {
+	counts := CountFailuresByCategory(failures, workflowID)
+	if len(counts) == 0 {
+		return fmt.Errorf("no failed executions of workflow %s found, expected failures of category %s", workflowID, expected)
+	}
+
+	var unexpected []string
+	for _, failure := range failures {
+		if failure.WorkflowID == workflowID && failure.Category != expected {
+			unexpected = append(unexpected, fmt.Sprintf("execution %s on %s: %s (status %s, error '%s')", failure.ExecutionID, failure.Node, failure.Category, failure.Status, failure.Error))
+		}
+	}
+	if len(unexpected) > 0 {
+		return fmt.Errorf("expected all failures of workflow %s to be of category %s, but %d are not:\n%s", workflowID, expected, len(unexpected), strings.Join(unexpected, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := map[string]ErrorCategory{
+		"failed to execute capability: context deadline exceeded":        ErrorCategoryTimeout,
+		"remote capability call timed out: capability not found":         ErrorCategoryTimeout,
+		"capability write_ethereum-testnet-sepolia@1.0.0 not registered": ErrorCategoryRemoteUnavailable,
+		"dial tcp 10.0.0.5:6690: connect: connection refused":            ErrorCategoryRemoteUnavailable,
+		"not enough responses to reach quorum: got 1, need 2":            ErrorCategoryConsensus,
+		"failed to unmarshal workflow config: invalid character":         ErrorCategoryUserError,
+		"insufficient observations for consensus round":                  ErrorCategoryConsensus,
+		"insufficient funds for gas * price + value":                     ErrorCategoryUnknown,
+		"something unexpected":                                           ErrorCategoryUnknown,
+	}
+	for message, expected := range tests {
+		require.Equal(t, expected, ClassifyError(message), message)
+	}
+}
+
+func TestParseExecutionFailures(t *testing.T) {
+	content := []byte(`not a JSON line
+{"level":"error","ts":"2025-01-01T10:00:00.000Z","msg":"Workflow execution failed","workflowID":"wf1","executionID":"e1","status":"errored","err":"not enough signatures"}
+{"level":"error","ts":"2025-01-01T10:00:05.000Z","msg":"Workflow execution failed with module execution error","workflowID":"wf1","executionID":"e2","status":"timeout"}
+{"level":"error","ts":"2025-01-01T10:00:10.000Z","msg":"Workflow execution failed with module execution error","workflowID":"wf2","executionID":"e3","status":"errored"}
+{"level":"info","ts":"2025-01-01T10:00:11.000Z","msg":"Workflow execution finished successfully","workflowID":"wf1","executionID":"e4"}
+`)
+
+	failures, err := ParseExecutionFailures("node1", content)
+	require.NoError(t, err)
+	require.Len(t, failures, 3)
+	require.Equal(t, ErrorCategoryConsensus, failures[0].Category)
+	require.Equal(t, ErrorCategoryTimeout, failures[1].Category)
+	require.Equal(t, ErrorCategoryUserError, failures[2].Category)
+	require.False(t, failures[0].Timestamp.IsZero())
+
+	// failures of other nodes executing the same execution are counted once
+	second, err := ParseExecutionFailures("node2", content)
+	require.NoError(t, err)
+	all := append(failures, second...)
+	require.Equal(t, map[ErrorCategory]int{ErrorCategoryConsensus: 1, ErrorCategoryTimeout: 1}, CountFailuresByCategory(all, "wf1"))
+
+	require.NoError(t, AssertFailureCategory(all, "wf2", ErrorCategoryUserError))
+	require.Error(t, AssertFailureCategory(all, "wf1", ErrorCategoryConsensus))
+	require.Error(t, AssertFailureCategory(all, "wf3", ErrorCategoryTimeout))
+}
+
+func TestParseExecutionFailuresTakesErrorsOfCapabilityCalls(t *testing.T) {
+	content := []byte(`{"level":"debug","ts":"2025-01-01T09:59:59.000Z","msg":"Capability execution failed","workflowID":"wf1","capID":"cron-trigger@1.0.0","err":"invalid config"}
+{"level":"info","ts":"2025-01-01T10:00:00.000Z","msg":"Workflow execution starting ...","workflowID":"wf1","executionID":"e1"}
+{"level":"debug","ts":"2025-01-01T10:00:01.000Z","msg":"Capability execution failed","workflowID":"wf1","capID":"write_geth-testnet@1.0.0","err":"failed to dial: connection refused"}
+{"level":"error","ts":"2025-01-01T10:00:02.000Z","msg":"Workflow execution failed","workflowID":"wf1","executionID":"e1","status":"errored"}
+{"level":"info","ts":"2025-01-01T10:00:10.000Z","msg":"Workflow execution starting ...","workflowID":"wf1","executionID":"e2"}
+{"level":"error","ts":"2025-01-01T10:00:12.000Z","msg":"Workflow execution failed","workflowID":"wf1","executionID":"e2","status":"errored"}
+`)
+
+	failures, err := ParseExecutionFailures("node1", content)
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	require.Equal(t, "failed to dial: connection refused", failures[0].Error)
+	require.Equal(t, "write_geth-testnet@1.0.0", failures[0].Capability)
+	require.Equal(t, ErrorCategoryRemoteUnavailable, failures[0].Category)
+
+	// the capability call failed in the previous execution, so the error of this one isn't known
+	require.Empty(t, failures[1].Error)
+	require.Equal(t, ErrorCategoryUnknown, failures[1].Category)
+}
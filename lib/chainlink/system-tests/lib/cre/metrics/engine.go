@@ -0,0 +1,289 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+)
+
+// Engine metrics are emitted by nodes through Beholder and end up in Prometheus via the OTel collector,
+// which replaces dots with underscores and appends the "_total" suffix to counters.
+const (
+	TriggerQueueUsageMetric        = "queue_PerWorkflow_TriggerEventQueueLimit_usage"
+	TriggerQueueLimitMetric        = "queue_PerWorkflow_TriggerEventQueueLimit_limit"
+	TriggerQueueFullMetric         = "platform_engine_workflow_trigger_event_queue_full"
+	TriggerEventErrorsMetric       = "platform_engine_workflow_trigger_event_errors"
+	ExecutionSucceededMetric       = "platform_engine_workflow_execution_succeeded_count"
+	ExecutionFailedMetric          = "platform_engine_workflow_execution_failed_count"
+	DefaultNodeLabel               = "instance"
+	defaultPrometheusQueryInterval = 5 * time.Second
+)
+
+// NodeEngineMetrics is a point-in-time view of workflow engine queueing metrics of a single node.
+// Queue values are summed across all workflows running on the node.
+type NodeEngineMetrics struct {
+	Node                string
+	TriggerQueueDepth   float64
+	TriggerQueueLimit   float64
+	DroppedTriggers     float64
+	TriggerEventErrors  float64
+	SucceededExecutions float64
+	FailedExecutions    float64
+}
+
+// EngineMetrics maps node identifier (value of the node label) to its engine metrics.
+type EngineMetrics map[string]*NodeEngineMetrics
+
+// Nodes returns sorted node identifiers.
+func (m EngineMetrics) Nodes() []string {
+	nodes := make([]string, 0, len(m))
+	for node := range m {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	return nodes
+}
+
+// Delta returns the increase of counters between two snapshots. Queue depth and limit are taken from the later snapshot,
+// because they are gauges. Nodes missing from the earlier snapshot are treated as if all their counters started at zero.
+func (m EngineMetrics) Delta(before EngineMetrics) EngineMetrics {
+	delta := make(EngineMetrics, len(m))
+	for node, after := range m {
+		prev, ok := before[node]
+		if !ok {
+			prev = &NodeEngineMetrics{}
+		}
+		delta[node] = &NodeEngineMetrics{
+			Node:                node,
+			TriggerQueueDepth:   after.TriggerQueueDepth,
+			TriggerQueueLimit:   after.TriggerQueueLimit,
+			DroppedTriggers:     after.DroppedTriggers - prev.DroppedTriggers,
+			TriggerEventErrors:  after.TriggerEventErrors - prev.TriggerEventErrors,
+			SucceededExecutions: after.SucceededExecutions - prev.SucceededExecutions,
+			FailedExecutions:    after.FailedExecutions - prev.FailedExecutions,
+		}
+	}
+
+	return delta
+}
+
+type EngineMetricsReader struct {
+	client    *framework.PrometheusQueryClient
+	nodeLabel string
+	selector  string
+}
+
+type EngineMetricsReaderOption func(*EngineMetricsReader)
+
+// WithNodeLabel sets the Prometheus label used to tell nodes apart. Defaults to DefaultNodeLabel.
+func WithNodeLabel(label string) EngineMetricsReaderOption {
+	return func(r *EngineMetricsReader) {
+		r.nodeLabel = label
+	}
+}
+
+// WithSelector adds an extra label selector (e.g. `workflowName="my-workflow"`) to every query.
+func WithSelector(selector string) EngineMetricsReaderOption {
+	return func(r *EngineMetricsReader) {
+		r.selector = selector
+	}
+}
+
+// NewEngineMetricsReader creates a reader that queries Prometheus available at prometheusURL.
+// Use framework.LocalPrometheusBaseURL for the local observability stack.
+func NewEngineMetricsReader(prometheusURL string, opts ...EngineMetricsReaderOption) *EngineMetricsReader {
+	r := &EngineMetricsReader{
+		client:    framework.NewPrometheusQueryClient(prometheusURL),
+		nodeLabel: DefaultNodeLabel,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Read returns engine metrics of all nodes at the given time.
+func (r *EngineMetricsReader) Read(at time.Time) (EngineMetrics, error) {
+	result := make(EngineMetrics)
+	for metricName, setter := range map[string]func(*NodeEngineMetrics, float64){
+		TriggerQueueUsageMetric:             func(m *NodeEngineMetrics, v float64) { m.TriggerQueueDepth = v },
+		TriggerQueueLimitMetric:             func(m *NodeEngineMetrics, v float64) { m.TriggerQueueLimit = v },
+		TriggerQueueFullMetric + "_total":   func(m *NodeEngineMetrics, v float64) { m.DroppedTriggers = v },
+		TriggerEventErrorsMetric + "_total": func(m *NodeEngineMetrics, v float64) { m.TriggerEventErrors = v },
+		ExecutionSucceededMetric + "_total": func(m *NodeEngineMetrics, v float64) { m.SucceededExecutions = v },
+		ExecutionFailedMetric + "_total":    func(m *NodeEngineMetrics, v float64) { m.FailedExecutions = v },
+	} {
+		values, err := r.queryPerNode(metricName, at)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", metricName)
+		}
+		for node, value := range values {
+			if _, ok := result[node]; !ok {
+				result[node] = &NodeEngineMetrics{Node: node}
+			}
+			setter(result[node], value)
+		}
+	}
+
+	return result, nil
+}
+
+// ReadBetween returns the increase of engine metrics between from and to, e.g. during an execution found with
+// logs.CorrelateLogs
+func (r *EngineMetricsReader) ReadBetween(from, to time.Time) (EngineMetrics, error) {
+	before, beforeErr := r.Read(from)
+	if beforeErr != nil {
+		return nil, beforeErr
+	}
+	after, afterErr := r.Read(to)
+	if afterErr != nil {
+		return nil, afterErr
+	}
+
+	return after.Delta(before), nil
+}
+
+func (r *EngineMetricsReader) queryPerNode(metricName string, at time.Time) (map[string]float64, error) {
+	selector := ""
+	if r.selector != "" {
+		selector = "{" + r.selector + "}"
+	}
+	query := fmt.Sprintf("sum by (%s) (%s%s)", r.nodeLabel, metricName, selector)
+
+	resp, err := r.client.Query(query, at)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64, len(resp.Data.Result))
+	for _, res := range resp.Data.Result {
+		node, ok := res.Metric[r.nodeLabel]
+		if !ok {
+			continue
+		}
+		value, valueErr := parseSampleValue(res.Value)
+		if valueErr != nil {
+			return nil, errors.Wrapf(valueErr, "failed to parse value for node %s", node)
+		}
+		values[node] = value
+	}
+
+	return values, nil
+}
+
+// parseSampleValue parses Prometheus instant vector sample, which is a [timestamp, "value"] pair.
+func parseSampleValue(sample []interface{}) (float64, error) {
+	if len(sample) != 2 {
+		return 0, fmt.Errorf("expected [timestamp, value] pair, got %d elements", len(sample))
+	}
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("expected value to be a string, got %T", sample[1])
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+// BackpressureExpectation describes how nodes are expected to behave while being flooded with trigger events.
+// Zero values disable a given check.
+type BackpressureExpectation struct {
+	// MaxQueueDepthRatio is the maximum allowed ratio of queue depth to queue limit (e.g. 0.9)
+	MaxQueueDepthRatio float64
+	// MinDroppedTriggers is the minimum number of dropped triggers per node, use it to verify that the queue limit is enforced
+	MinDroppedTriggers float64
+	// ExpectNoDrops requires that no trigger events were dropped
+	ExpectNoDrops bool
+	// MinSucceededExecutions is the minimum number of successful executions per node, use it to verify that nodes keep processing under load
+	MinSucceededExecutions float64
+	// Nodes limits checks to given nodes, all nodes are checked if empty
+	Nodes []string
+}
+
+// AssertBackpressure verifies that engine metrics (usually a Delta() taken around a trigger flood) meet the expectation.
+// All violations are reported in a single error.
+func AssertBackpressure(metrics EngineMetrics, expectation BackpressureExpectation) error {
+	nodes := expectation.Nodes
+	if len(nodes) == 0 {
+		nodes = metrics.Nodes()
+	}
+	if len(nodes) == 0 {
+		return errors.New("no engine metrics found for any node")
+	}
+
+	var violations []string
+	for _, node := range nodes {
+		m, ok := metrics[node]
+		if !ok {
+			violations = append(violations, fmt.Sprintf("%s: no engine metrics found", node))
+			continue
+		}
+
+		if expectation.MaxQueueDepthRatio > 0 && m.TriggerQueueLimit > 0 {
+			if ratio := m.TriggerQueueDepth / m.TriggerQueueLimit; ratio > expectation.MaxQueueDepthRatio {
+				violations = append(violations, fmt.Sprintf("%s: trigger queue depth %.0f/%.0f (%.2f) exceeds max ratio %.2f", node, m.TriggerQueueDepth, m.TriggerQueueLimit, ratio, expectation.MaxQueueDepthRatio))
+			}
+		}
+		if expectation.ExpectNoDrops && m.DroppedTriggers > 0 {
+			violations = append(violations, fmt.Sprintf("%s: %.0f trigger events were dropped, expected none", node, m.DroppedTriggers))
+		}
+		if expectation.MinDroppedTriggers > 0 && m.DroppedTriggers < expectation.MinDroppedTriggers {
+			violations = append(violations, fmt.Sprintf("%s: %.0f trigger events were dropped, expected at least %.0f", node, m.DroppedTriggers, expectation.MinDroppedTriggers))
+		}
+		if expectation.MinSucceededExecutions > 0 && m.SucceededExecutions < expectation.MinSucceededExecutions {
+			violations = append(violations, fmt.Sprintf("%s: %.0f successful executions, expected at least %.0f", node, m.SucceededExecutions, expectation.MinSucceededExecutions))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("backpressure expectation not met:\n%s", strings.Join(violations, "\n"))
+	}
+
+	return nil
+}
+
+// MeasureFlood reads engine metrics, runs the flood function (e.g. a WASP generator) and returns the difference
+// between metrics read before and after it. settle is the time to wait after the flood for counters to be scraped.
+// Queue depth and limit are sampled right after the flood, before settling, because the queue drains meanwhile.
+func (r *EngineMetricsReader) MeasureFlood(flood func() error, settle time.Duration) (EngineMetrics, error) {
+	before, err := r.Read(time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read engine metrics before the flood")
+	}
+
+	if floodErr := flood(); floodErr != nil {
+		return nil, errors.Wrap(floodErr, "trigger flood failed")
+	}
+
+	floodEnd, err := r.Read(time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read engine metrics at the end of the flood")
+	}
+
+	if settle <= 0 {
+		settle = defaultPrometheusQueryInterval
+	}
+	time.Sleep(settle)
+
+	after, err := r.Read(time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read engine metrics after the flood")
+	}
+
+	delta := after.Delta(before)
+	for node, m := range delta {
+		if atFloodEnd, ok := floodEnd[node]; ok {
+			m.TriggerQueueDepth = atFloodEnd.TriggerQueueDepth
+			m.TriggerQueueLimit = atFloodEnd.TriggerQueueLimit
+		}
+	}
+
+	return delta, nil
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineMetricsReader_Read(t *testing.T) {
+	values := map[string]map[string]string{
+		TriggerQueueUsageMetric:             {"node1": "90", "node2": "10"},
+		TriggerQueueLimitMetric:             {"node1": "100", "node2": "100"},
+		TriggerQueueFullMetric + "_total":   {"node1": "5"},
+		ExecutionSucceededMetric + "_total": {"node1": "20", "node2": "30"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		type result struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		}
+		var results []result
+		for metricName, perNode := range values {
+			if !strings.Contains(query, "("+metricName+")") {
+				continue
+			}
+			for node, value := range perNode {
+				results = append(results, result{Metric: map[string]string{DefaultNodeLabel: node}, Value: []interface{}{1700000000, value}})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status": "success",
+			"data":   map[string]any{"resultType": "vector", "result": results},
+		})
+	}))
+	defer server.Close()
+
+	got, err := NewEngineMetricsReader(server.URL).Read(time.Now())
+	require.NoError(t, err)
+	require.Equal(t, []string{"node1", "node2"}, got.Nodes())
+	require.InDelta(t, 90, got["node1"].TriggerQueueDepth, 0)
+	require.InDelta(t, 100, got["node1"].TriggerQueueLimit, 0)
+	require.InDelta(t, 5, got["node1"].DroppedTriggers, 0)
+	require.InDelta(t, 0, got["node2"].DroppedTriggers, 0)
+	require.InDelta(t, 30, got["node2"].SucceededExecutions, 0)
+}
+
+func TestAssertBackpressure(t *testing.T) {
+	before := EngineMetrics{
+		"node1": {Node: "node1", DroppedTriggers: 2, SucceededExecutions: 10},
+		"node2": {Node: "node2", SucceededExecutions: 10},
+	}
+	after := EngineMetrics{
+		"node1": {Node: "node1", TriggerQueueDepth: 95, TriggerQueueLimit: 100, DroppedTriggers: 12, SucceededExecutions: 30},
+		"node2": {Node: "node2", TriggerQueueDepth: 40, TriggerQueueLimit: 100, SucceededExecutions: 12},
+	}
+	delta := after.Delta(before)
+	require.InDelta(t, 10, delta["node1"].DroppedTriggers, 0)
+	require.InDelta(t, 20, delta["node1"].SucceededExecutions, 0)
+
+	t.Run("queue limit is enforced", func(t *testing.T) {
+		err := AssertBackpressure(delta, BackpressureExpectation{MinDroppedTriggers: 1, Nodes: []string{"node1"}})
+		require.NoError(t, err)
+	})
+
+	t.Run("all violations are reported", func(t *testing.T) {
+		err := AssertBackpressure(delta, BackpressureExpectation{MaxQueueDepthRatio: 0.9, ExpectNoDrops: true, MinSucceededExecutions: 5})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "node1: trigger queue depth 95/100")
+		require.Contains(t, err.Error(), "node1: 10 trigger events were dropped, expected none")
+		require.Contains(t, err.Error(), "node2: 2 successful executions, expected at least 5")
+	})
+
+	t.Run("missing node", func(t *testing.T) {
+		err := AssertBackpressure(delta, BackpressureExpectation{Nodes: []string{"node3"}})
+		require.ErrorContains(t, err, "node3: no engine metrics found")
+	})
+}
+
+func TestMeasureFloodSamplesQueueBeforeSettle(t *testing.T) {
+	// the queue is full right after the flood and drained by the time counters settle
+	queueDepths := []string{"0", "95", "0"}
+	var queueReads, executionReads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		value := "0"
+		switch {
+		case strings.Contains(query, "("+TriggerQueueUsageMetric+")"):
+			value = queueDepths[min(int(queueReads.Add(1))-1, len(queueDepths)-1)]
+		case strings.Contains(query, "("+TriggerQueueLimitMetric+")"):
+			value = "100"
+		case strings.Contains(query, "("+ExecutionSucceededMetric+"_total)"):
+			value = strconv.Itoa(10 * int(executionReads.Add(1)))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status": "success",
+			"data": map[string]any{"resultType": "vector", "result": []map[string]any{
+				{"metric": map[string]string{DefaultNodeLabel: "node1"}, "value": []interface{}{1700000000, value}},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	delta, err := NewEngineMetricsReader(server.URL).MeasureFlood(func() error { return nil }, time.Millisecond)
+	require.NoError(t, err)
+	require.InDelta(t, 95, delta["node1"].TriggerQueueDepth, 0, "queue depth wasn't sampled before settling")
+	require.InDelta(t, 100, delta["node1"].TriggerQueueLimit, 0)
+	require.InDelta(t, 20, delta["node1"].SucceededExecutions, 0, "counters weren't read after settling")
+}
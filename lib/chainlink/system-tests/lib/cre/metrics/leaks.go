@@ -0,0 +1,438 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultLeakSampleInterval    = time.Minute
+	DefaultLeakMinSamples        = 5
+	DefaultMaxHeapGrowth         = 0.5
+	DefaultMaxGoroutineGrowth    = 0.5
+	DefaultLeakMonotonicRatio    = 0.8
+	HeapInUseMetric              = "go_memstats_heap_inuse_bytes"
+	GoroutinesMetric             = "go_goroutines"
+	LeakResourceHeap             = "heap"
+	LeakResourceGoroutines       = "goroutine"
+	defaultLeakProfilesDirectory = "leak-profiles"
+)
+
+// LeakDetectionConfig configures sampling of heap and goroutine counts of nodes and their capability plugins
+// during long-running (soak) scenarios. Growth is reported as a leak only if it exceeds the threshold and is monotonic,
+// so that regular GC cycles and bursts of work don't trigger it.
+type LeakDetectionConfig struct {
+	Interval   string `toml:"interval"`    // Go duration, DefaultLeakSampleInterval is used if not set
+	MinSamples int    `toml:"min_samples"` // growth isn't evaluated with fewer samples, DefaultLeakMinSamples is used if not set
+	// relative growth between the first and the last sample, e.g. 0.5 is 50%, defaults are used if not set
+	MaxHeapGrowth      float64 `toml:"max_heap_growth"`
+	MaxGoroutineGrowth float64 `toml:"max_goroutine_growth"`
+	// MonotonicRatio is the share of consecutive samples, which must not decrease, DefaultLeakMonotonicRatio is used if not set
+	MonotonicRatio float64 `toml:"monotonic_ratio"`
+	// Fail makes the report return an error on detected leaks, otherwise they are only logged
+	Fail bool `toml:"fail"`
+	// ProfilesDir is where pprof profiles of leaking nodes are stored, defaults to ./leak-profiles
+	ProfilesDir string `toml:"profiles_dir"`
+}
+
+func (c *LeakDetectionConfig) Validate() error {
+	if c.Interval != "" {
+		if _, err := time.ParseDuration(c.Interval); err != nil {
+			return errors.Wrap(err, "invalid leak detection interval")
+		}
+	}
+	if c.MinSamples != 0 && c.MinSamples < 2 {
+		return fmt.Errorf("at least 2 samples are required to detect growth, got %d", c.MinSamples)
+	}
+	if c.MaxHeapGrowth < 0 || c.MaxGoroutineGrowth < 0 {
+		return errors.New("max heap and goroutine growth can't be negative")
+	}
+	if c.MonotonicRatio < 0 || c.MonotonicRatio > 1 {
+		return fmt.Errorf("monotonic ratio must be between 0 and 1, got %f", c.MonotonicRatio)
+	}
+
+	return nil
+}
+
+// ProfileFetcher returns a pprof profile of given type (heap, goroutine) of the target
+type ProfileFetcher func(ctx context.Context, profile string) ([]byte, error)
+
+// LeakTarget is a process, which is sampled: a node or a capability plugin running as a LOOP of the node
+type LeakTarget struct {
+	Node       string
+	Plugin     string // empty for the node itself
+	MetricsURL string
+	Profiles   ProfileFetcher // nil, if profiles of the target can't be fetched
+}
+
+func (t LeakTarget) Name() string {
+	if t.Plugin == "" {
+		return t.Node
+	}
+
+	return t.Node + "/" + t.Plugin
+}
+
+type LeakSample struct {
+	At         time.Time
+	HeapBytes  float64
+	Goroutines float64
+}
+
+type LeakFinding struct {
+	Target   LeakTarget
+	Resource string // LeakResourceHeap or LeakResourceGoroutines
+	First    float64
+	Last     float64
+	Samples  int
+	Profiles []string // paths of profiles fetched when the leak was detected
+}
+
+func (f LeakFinding) Growth() float64 {
+	if f.First == 0 {
+		return 0
+	}
+
+	return f.Last/f.First - 1
+}
+
+func (f LeakFinding) String() string {
+	return fmt.Sprintf("%s: %s grew monotonically by %.0f%% (%.0f -> %.0f) over %d samples", f.Target.Name(), f.Resource, f.Growth()*100, f.First, f.Last, f.Samples)
+}
+
+type LeakReport struct {
+	Findings []LeakFinding
+	Samples  map[string][]LeakSample // target name -> samples
+	fail     bool
+}
+
+// Err returns an error listing all findings, if leaks were found and detection is configured to fail
+func (r *LeakReport) Err() error {
+	if !r.fail || len(r.Findings) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(r.Findings))
+	for _, finding := range r.Findings {
+		lines = append(lines, finding.String())
+	}
+
+	return fmt.Errorf("detected %d possible leaks:\n%s", len(r.Findings), strings.Join(lines, "\n"))
+}
+
+// SortedTargetNames returns names of sampled targets, e.g. for stable output of reports
+func (r *LeakReport) SortedTargetNames() []string {
+	names := make([]string, 0, len(r.Samples))
+	for name := range r.Samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// LeakDetector periodically samples heap in use and goroutine counts of all targets from their Prometheus metrics
+type LeakDetector struct {
+	config   *LeakDetectionConfig
+	targets  []LeakTarget
+	interval time.Duration
+	http     *http.Client
+	logger   zerolog.Logger
+
+	samples   map[string][]LeakSample
+	samplesMu sync.Mutex // separate from mu, because Stop holds mu until background sampling stops
+	cancel    context.CancelFunc
+	done      chan struct{}
+	mu        sync.Mutex
+}
+
+func NewLeakDetector(logger zerolog.Logger, config *LeakDetectionConfig, targets []LeakTarget) (*LeakDetector, error) {
+	if config == nil {
+		return nil, errors.New("leak detection config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	d := &LeakDetector{
+		config:   config,
+		targets:  targets,
+		interval: DefaultLeakSampleInterval,
+		http:     &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+		samples:  make(map[string][]LeakSample, len(targets)),
+	}
+	if config.Interval != "" {
+		d.interval, _ = time.ParseDuration(config.Interval)
+	}
+
+	return d, nil
+}
+
+// Start samples all targets every interval in the background, until Stop is called or the context is cancelled.
+// Targets, which can't be sampled, are logged and skipped in that round, e.g. while a node is restarted by the scenario.
+func (d *LeakDetector) Start(ctx context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		return
+	}
+
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.done = make(chan struct{})
+	go func() {
+		defer close(d.done)
+		d.Sample(ctx)
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.Sample(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops background sampling, takes the final sample and returns the report
+func (d *LeakDetector) Stop(ctx context.Context) *LeakReport {
+	d.mu.Lock()
+	if d.cancel != nil {
+		d.cancel()
+		<-d.done
+		d.cancel = nil
+	}
+	d.mu.Unlock()
+
+	d.Sample(ctx)
+
+	return d.Report(ctx)
+}
+
+// Sample reads current heap and goroutine counts of all targets
+func (d *LeakDetector) Sample(ctx context.Context) {
+	for _, target := range d.targets {
+		sample, err := d.sample(ctx, target)
+		if err != nil {
+			if ctx.Err() == nil {
+				d.logger.Warn().Err(err).Msgf("Failed to sample %s for leak detection", target.Name())
+			}
+			continue
+		}
+
+		d.samplesMu.Lock()
+		d.samples[target.Name()] = append(d.samples[target.Name()], sample)
+		d.samplesMu.Unlock()
+	}
+}
+
+func (d *LeakDetector) sample(ctx context.Context, target LeakTarget) (LeakSample, error) {
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, target.MetricsURL, nil)
+	if reqErr != nil {
+		return LeakSample{}, errors.Wrap(reqErr, "failed to create metrics request")
+	}
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return LeakSample{}, errors.Wrapf(err, "failed to read metrics from %s", target.MetricsURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return LeakSample{}, fmt.Errorf("reading metrics from %s failed with status %d", target.MetricsURL, resp.StatusCode)
+	}
+
+	values, parseErr := parseGauges(resp.Body, HeapInUseMetric, GoroutinesMetric)
+	if parseErr != nil {
+		return LeakSample{}, parseErr
+	}
+
+	return LeakSample{At: time.Now(), HeapBytes: values[HeapInUseMetric], Goroutines: values[GoroutinesMetric]}, nil
+}
+
+// Report evaluates samples collected so far and fetches heap or goroutine profiles of leaking targets
+func (d *LeakDetector) Report(ctx context.Context) *LeakReport {
+	d.samplesMu.Lock()
+	samples := make(map[string][]LeakSample, len(d.samples))
+	for name, targetSamples := range d.samples {
+		samples[name] = slices.Clone(targetSamples)
+	}
+	d.samplesMu.Unlock()
+
+	report := &LeakReport{Samples: samples, fail: d.config.Fail}
+	for _, target := range d.targets {
+		for _, finding := range DetectLeaks(d.config, target, samples[target.Name()]) {
+			finding.Profiles = d.storeProfile(ctx, target, finding.Resource)
+			d.logger.Warn().Msgf("Possible leak: %s", finding)
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+
+	return report
+}
+
+func (d *LeakDetector) storeProfile(ctx context.Context, target LeakTarget, profile string) []string {
+	if target.Profiles == nil {
+		return nil
+	}
+
+	content, err := target.Profiles(ctx, profile)
+	if err != nil {
+		d.logger.Warn().Err(err).Msgf("Failed to fetch %s profile of %s", profile, target.Name())
+		return nil
+	}
+
+	dir := d.config.ProfilesDir
+	if dir == "" {
+		dir = defaultLeakProfilesDirectory
+	}
+	if mkdirErr := os.MkdirAll(dir, 0o755); mkdirErr != nil {
+		d.logger.Warn().Err(mkdirErr).Msgf("Failed to create directory %s for profiles", dir)
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-%s-%s.pb.gz", strings.ReplaceAll(target.Name(), "/", "-"), profile, time.Now().UTC().Format("20060102T150405"))
+	path := filepath.Join(dir, name)
+	if writeErr := os.WriteFile(path, content, 0o644); writeErr != nil {
+		d.logger.Warn().Err(writeErr).Msgf("Failed to store %s profile of %s", profile, target.Name())
+		return nil
+	}
+
+	return []string{path}
+}
+
+// DetectLeaks returns findings for resources of the target, which grew beyond the thresholds monotonically
+func DetectLeaks(config *LeakDetectionConfig, target LeakTarget, samples []LeakSample) []LeakFinding {
+	minSamples := config.MinSamples
+	if minSamples == 0 {
+		minSamples = DefaultLeakMinSamples
+	}
+	if len(samples) < minSamples {
+		return nil
+	}
+
+	monotonicRatio := config.MonotonicRatio
+	if monotonicRatio == 0 {
+		monotonicRatio = DefaultLeakMonotonicRatio
+	}
+	maxHeapGrowth := config.MaxHeapGrowth
+	if maxHeapGrowth == 0 {
+		maxHeapGrowth = DefaultMaxHeapGrowth
+	}
+	maxGoroutineGrowth := config.MaxGoroutineGrowth
+	if maxGoroutineGrowth == 0 {
+		maxGoroutineGrowth = DefaultMaxGoroutineGrowth
+	}
+
+	var findings []LeakFinding
+	resources := []struct {
+		name      string
+		maxGrowth float64
+		value     func(LeakSample) float64
+	}{
+		{LeakResourceHeap, maxHeapGrowth, func(s LeakSample) float64 { return s.HeapBytes }},
+		{LeakResourceGoroutines, maxGoroutineGrowth, func(s LeakSample) float64 { return s.Goroutines }},
+	}
+	for _, resource := range resources {
+		first, last := resource.value(samples[0]), resource.value(samples[len(samples)-1])
+		if first <= 0 || last/first-1 <= resource.maxGrowth {
+			continue
+		}
+
+		nonDecreasing := 0
+		for i := 1; i < len(samples); i++ {
+			if resource.value(samples[i]) >= resource.value(samples[i-1]) {
+				nonDecreasing++
+			}
+		}
+		if float64(nonDecreasing)/float64(len(samples)-1) < monotonicRatio {
+			continue
+		}
+
+		findings = append(findings, LeakFinding{Target: target, Resource: resource.name, First: first, Last: last, Samples: len(samples)})
+	}
+
+	return findings
+}
+
+// parseGauges returns values of given metrics in the Prometheus text format, summed across series with different labels
+func parseGauges(metrics io.Reader, names ...string) (map[string]float64, error) {
+	values := make(map[string]float64, len(names))
+	scanner := bufio.NewScanner(metrics)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest, _ := strings.Cut(line, " ")
+		if labelsStart := strings.Index(name, "{"); labelsStart != -1 {
+			name = line[:labelsStart]
+			rest = line[strings.LastIndex(line, "}")+1:]
+		}
+		if !slices.Contains(names, name) {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse value of %s", name)
+		}
+		values[name] += value
+	}
+
+	return values, errors.Wrap(scanner.Err(), "failed to parse metrics")
+}
+
+// DiscoverPluginMetricsPaths returns metrics paths of LOOP plugins (e.g. capabilities) of the node at nodeURL,
+// keyed by plugin name, as announced by its Prometheus service discovery endpoint
+func DiscoverPluginMetricsPaths(ctx context.Context, nodeURL string) (map[string]string, error) {
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, nodeURL+"/discovery", nil)
+	if reqErr != nil {
+		return nil, errors.Wrap(reqErr, "failed to create discovery request")
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read discovery endpoint of %s", nodeURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading discovery endpoint of %s failed with status %d", nodeURL, resp.StatusCode)
+	}
+
+	var groups []struct {
+		Labels map[string]string `json:"labels"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&groups); err != nil {
+		return nil, errors.Wrap(err, "failed to decode discovery response")
+	}
+
+	paths := make(map[string]string)
+	for _, group := range groups {
+		path := group.Labels["__metrics_path__"]
+		if plugin, ok := strings.CutPrefix(path, "/plugins/"); ok {
+			paths[strings.TrimSuffix(plugin, "/metrics")] = path
+		}
+	}
+
+	return paths, nil
+}
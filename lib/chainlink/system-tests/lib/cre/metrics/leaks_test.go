@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLeaks(t *testing.T) {
+	samples := func(heap, goroutines []float64) []LeakSample {
+		s := make([]LeakSample, len(heap))
+		for i := range heap {
+			s[i] = LeakSample{HeapBytes: heap[i], Goroutines: goroutines[i]}
+		}
+		return s
+	}
+	target := LeakTarget{Node: "node1"}
+	config := &LeakDetectionConfig{}
+
+	// heap grows monotonically, goroutines grow, but drop in between, which looks like a burst of work
+	findings := DetectLeaks(config, target, samples(
+		[]float64{100, 120, 140, 160, 200},
+		[]float64{10, 30, 10, 30, 20},
+	))
+	require.Len(t, findings, 1)
+	require.Equal(t, LeakResourceHeap, findings[0].Resource)
+	require.InDelta(t, 1, findings[0].Growth(), 0.001)
+
+	// growth below the threshold
+	require.Empty(t, DetectLeaks(config, target, samples(
+		[]float64{100, 110, 120, 130, 140},
+		[]float64{10, 11, 12, 13, 14},
+	)))
+
+	// not enough samples
+	require.Empty(t, DetectLeaks(config, target, samples([]float64{100, 300}, []float64{10, 30})))
+}
+
+func TestLeakDetector(t *testing.T) {
+	var goroutines atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := goroutines.Add(10)
+		fmt.Fprintf(w, "# TYPE go_goroutines gauge\n%s %d\n%s{source=\"x\"} 1000\n", GoroutinesMetric, count, HeapInUseMetric)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := &LeakDetectionConfig{MinSamples: 3, Fail: true, ProfilesDir: dir}
+	target := LeakTarget{
+		Node:       "node1",
+		MetricsURL: server.URL,
+		Profiles: func(_ context.Context, profile string) ([]byte, error) {
+			return []byte(profile), nil
+		},
+	}
+	detector, err := NewLeakDetector(zerolog.Nop(), config, []LeakTarget{target})
+	require.NoError(t, err)
+
+	for range 3 {
+		detector.Sample(t.Context())
+	}
+	report := detector.Report(t.Context())
+
+	require.Len(t, report.Findings, 1)
+	require.Equal(t, LeakResourceGoroutines, report.Findings[0].Resource)
+	require.Len(t, report.Findings[0].Profiles, 1)
+	content, readErr := os.ReadFile(report.Findings[0].Profiles[0])
+	require.NoError(t, readErr)
+	require.Equal(t, LeakResourceGoroutines, string(content))
+	require.Error(t, report.Err())
+	require.Equal(t, []string{"node1"}, report.SortedTargetNames())
+}
+
+func TestDiscoverPluginMetricsPaths(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/discovery", r.URL.Path)
+		_, _ = w.Write([]byte(`[{"targets":["node:6688"],"labels":{"__metrics_path__":"/metrics"}},{"targets":["node:6688"],"labels":{"__metrics_path__":"/plugins/cron/metrics"}}]`))
+	}))
+	defer server.Close()
+
+	paths, err := DiscoverPluginMetricsPaths(t.Context(), server.URL)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"cron": "/plugins/cron/metrics"}, paths)
+}
@@ -0,0 +1,286 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/artifacts"
+)
+
+const (
+	// DefaultRemoteWriteSelector forwards metrics of workflow engines and capabilities, which are the base of DON performance dashboards
+	DefaultRemoteWriteSelector = `{__name__=~"platform_.+|capabilities_.+|queue_.+"}`
+	DefaultRemoteWriteInterval = 30 * time.Second
+
+	RunIDLabel  = "cre_run_id"
+	CommitLabel = "cre_commit"
+)
+
+// RemoteWriteConfig describes a central Prometheus-compatible store (Mimir, Thanos, Cortex, Grafana Cloud),
+// to which metrics of the local Prometheus are written during the run
+type RemoteWriteConfig struct {
+	URL      string            `toml:"url"`      // remote-write endpoint, e.g. https://mimir.example.com/api/v1/push
+	Selector string            `toml:"selector"` // PromQL selector of forwarded series, DefaultRemoteWriteSelector is used if not set
+	Interval string            `toml:"interval"` // Go duration, DefaultRemoteWriteInterval is used if not set
+	Labels   map[string]string `toml:"labels"`   // added to all series on top of RunIDLabel and CommitLabel
+	// names of env vars with credentials, so that they are not stored in configs
+	BearerTokenEnvVar string `toml:"bearer_token_env_var"`
+	UsernameEnvVar    string `toml:"username_env_var"`
+	PasswordEnvVar    string `toml:"password_env_var"`
+}
+
+func (c *RemoteWriteConfig) Validate() error {
+	if c.URL == "" {
+		return errors.New("remote write URL is required")
+	}
+	if c.Interval != "" {
+		if _, err := time.ParseDuration(c.Interval); err != nil {
+			return errors.Wrap(err, "invalid remote write interval")
+		}
+	}
+
+	return nil
+}
+
+// RunLabels identify the run and the tested commit, so that series from different runs and releases can be compared.
+// runID should be the run ID of the environment, so that series match its artifacts and resources,
+// artifacts.DefaultRunID() is used if it's empty.
+func RunLabels(runID string) map[string]string {
+	if runID == "" {
+		runID = artifacts.DefaultRunID()
+	}
+	labels := map[string]string{RunIDLabel: runID}
+
+	commit := os.Getenv("GITHUB_SHA")
+	if commit == "" {
+		if out, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
+			commit = strings.TrimSpace(string(out))
+		}
+	}
+	if commit != "" {
+		labels[CommitLabel] = commit
+	}
+
+	return labels
+}
+
+// RemoteWriter periodically reads series matching the selector from the local Prometheus and writes them
+// to the remote store with the Prometheus remote-write protocol (v1)
+type RemoteWriter struct {
+	config   *RemoteWriteConfig
+	client   *framework.PrometheusQueryClient
+	http     *http.Client
+	labels   map[string]string
+	selector string
+	interval time.Duration
+	logger   zerolog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.Mutex
+}
+
+// NewRemoteWriter creates a writer reading from Prometheus at prometheusURL, use framework.LocalPrometheusBaseURL
+// for the local observability stack. Series are tagged with RunLabels of runID.
+func NewRemoteWriter(logger zerolog.Logger, prometheusURL, runID string, config *RemoteWriteConfig) (*RemoteWriter, error) {
+	if config == nil {
+		return nil, errors.New("remote write config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	labels := RunLabels(runID)
+	for key, value := range config.Labels {
+		labels[key] = value
+	}
+
+	w := &RemoteWriter{
+		config:   config,
+		client:   framework.NewPrometheusQueryClient(prometheusURL),
+		http:     &http.Client{Timeout: 30 * time.Second},
+		labels:   labels,
+		selector: config.Selector,
+		interval: DefaultRemoteWriteInterval,
+		logger:   logger,
+	}
+	if w.selector == "" {
+		w.selector = DefaultRemoteWriteSelector
+	}
+	if config.Interval != "" {
+		w.interval, _ = time.ParseDuration(config.Interval)
+	}
+
+	return w, nil
+}
+
+// Start writes metrics every interval in the background, until Stop is called or the context is cancelled.
+// Failed writes are logged and retried in the next round, so that an unavailable store doesn't break the test.
+func (w *RemoteWriter) Start(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.Write(ctx); err != nil && ctx.Err() == nil {
+					w.logger.Warn().Err(err).Msg("Failed to remote-write metrics")
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops background writes and writes the final values, so that short runs are also recorded
+func (w *RemoteWriter) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+		w.cancel = nil
+	}
+	w.mu.Unlock()
+
+	return w.Write(ctx)
+}
+
+// Write reads current values of matching series and writes them to the remote store
+func (w *RemoteWriter) Write(ctx context.Context) error {
+	resp, queryErr := w.client.Query(w.selector, time.Now())
+	if queryErr != nil {
+		return errors.Wrap(queryErr, "failed to query local Prometheus")
+	}
+
+	series := make([]timeSeries, 0, len(resp.Data.Result))
+	for _, res := range resp.Data.Result {
+		value, valueErr := parseSampleValue(res.Value)
+		if valueErr != nil {
+			return errors.Wrapf(valueErr, "failed to parse value of %v", res.Metric)
+		}
+		timestamp, ok := res.Value[0].(float64)
+		if !ok {
+			return fmt.Errorf("expected timestamp to be a number, got %T", res.Value[0])
+		}
+
+		labels := make(map[string]string, len(res.Metric)+len(w.labels))
+		for key, v := range res.Metric {
+			labels[key] = v
+		}
+		for key, v := range w.labels {
+			labels[key] = v
+		}
+		series = append(series, timeSeries{labels: labels, value: value, timestampMs: int64(timestamp * 1000)})
+	}
+	if len(series) == 0 {
+		return nil
+	}
+
+	return w.send(ctx, encodeWriteRequest(series))
+}
+
+func (w *RemoteWriter) send(ctx context.Context, writeRequest []byte) error {
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(snappy.Encode(nil, writeRequest)))
+	if reqErr != nil {
+		return errors.Wrap(reqErr, "failed to create remote write request")
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if w.config.BearerTokenEnvVar != "" {
+		req.Header.Set("Authorization", "Bearer "+os.Getenv(w.config.BearerTokenEnvVar))
+	} else if w.config.UsernameEnvVar != "" {
+		req.SetBasicAuth(os.Getenv(w.config.UsernameEnvVar), os.Getenv(w.config.PasswordEnvVar))
+	}
+
+	resp, sendErr := w.http.Do(req)
+	if sendErr != nil {
+		return errors.Wrapf(sendErr, "failed to send metrics to %s", w.config.URL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("remote write to %s failed with status %d: %s", w.config.URL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+type timeSeries struct {
+	labels      map[string]string
+	value       float64
+	timestampMs int64
+}
+
+// encodeWriteRequest encodes prometheus.WriteRequest protobuf message by hand, to avoid depending
+// on the whole Prometheus module for a few message types:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(series []timeSeries) []byte {
+	var request []byte
+	for _, s := range series {
+		// remote write requires labels sorted by name
+		names := make([]string, 0, len(s.labels))
+		for name := range s.labels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var ts []byte
+		for _, name := range names {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, s.labels[name])
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.timestampMs)) //nolint:gosec // timestamps are positive
+
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, ts)
+	}
+
+	return request
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestRunLabels(t *testing.T) {
+	assert.Equal(t, "run-1", RunLabels("run-1")[RunIDLabel])
+	assert.NotEmpty(t, RunLabels("")[RunIDLabel], "default run ID isn't used")
+}
+
+func TestRemoteWriter_Write(t *testing.T) {
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, DefaultRemoteWriteSelector, r.URL.Query().Get("query"))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status": "success",
+			"data": map[string]any{"resultType": "vector", "result": []map[string]any{{
+				"metric": map[string]string{"__name__": ExecutionSucceededMetric + "_total", DefaultNodeLabel: "node1"},
+				"value":  []any{1700000000.5, "42"},
+			}}},
+		})
+	}))
+	defer prometheus.Close()
+
+	var received []byte
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		received, err = snappy.Decode(nil, body)
+		assert.NoError(t, err)
+	}))
+	defer remote.Close()
+
+	t.Setenv("REMOTE_WRITE_TOKEN", "secret")
+	writer, err := NewRemoteWriter(zerolog.Nop(), prometheus.URL, "run-1", &RemoteWriteConfig{
+		URL:               remote.URL,
+		Labels:            map[string]string{"suite": "nightly"},
+		BearerTokenEnvVar: "REMOTE_WRITE_TOKEN",
+	})
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(t.Context()))
+
+	// WriteRequest -> TimeSeries
+	num, typ, n := protowire.ConsumeTag(received)
+	require.Equal(t, protowire.Number(1), num)
+	require.Equal(t, protowire.BytesType, typ)
+	ts, m := protowire.ConsumeBytes(received[n:])
+	require.Positive(t, m)
+
+	labels := map[string]string{}
+	var timestamp int64
+	for len(ts) > 0 {
+		fieldNum, _, tagLen := protowire.ConsumeTag(ts)
+		field, fieldLen := protowire.ConsumeBytes(ts[tagLen:])
+		ts = ts[tagLen+fieldLen:]
+		if fieldNum == 1 {
+			_, _, l := protowire.ConsumeTag(field)
+			name, nameLen := protowire.ConsumeString(field[l:])
+			field = field[l+nameLen:]
+			_, _, l = protowire.ConsumeTag(field)
+			value, _ := protowire.ConsumeString(field[l:])
+			labels[name] = value
+			continue
+		}
+		// sample: value (fixed64), then timestamp (varint)
+		_, _, l := protowire.ConsumeTag(field)
+		field = field[l+8:]
+		_, _, l = protowire.ConsumeTag(field)
+		v, _ := protowire.ConsumeVarint(field[l:])
+		timestamp = int64(v) //nolint:gosec // test data
+	}
+
+	assert.Equal(t, "node1", labels[DefaultNodeLabel])
+	assert.Equal(t, "run-1", labels[RunIDLabel])
+	assert.Equal(t, "nightly", labels["suite"])
+	assert.Equal(t, int64(1700000000500), timestamp)
+}
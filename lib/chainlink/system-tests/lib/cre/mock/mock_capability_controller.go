@@ -18,12 +18,15 @@ import (
 
 	"github.com/smartcontractkit/chainlink-common/pkg/capabilities"
 	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/logs"
 	pb2 "github.com/smartcontractkit/chainlink/system-tests/lib/cre/mock/pb"
 )
 
 type Controller struct {
 	lggr  zerolog.Logger
 	Nodes []MockClient
+	// optional, called with every trigger event sent to all nodes, e.g. to record it with replay.Recorder
+	OnTriggerSent func(message *pb2.SendTriggerEventRequest)
 }
 
 type MockClient struct {
@@ -120,7 +123,12 @@ func (c *Controller) CreateCapability(ctx context.Context, info *pb2.CapabilityI
 	return nil
 }
 
+// SendTrigger sends the event to subscribers of its trigger on all nodes. Events without an ID get a new correlation ID,
+// which is set to message.ID, so that their executions can be found in logs, see logs.CorrelateLogs.
 func (c *Controller) SendTrigger(ctx context.Context, message *pb2.SendTriggerEventRequest) error {
+	if message.ID == "" {
+		message.ID = logs.NewCorrelationID()
+	}
 	for _, client := range c.Nodes {
 		framework.L.Info().Msg(fmt.Sprintf("Sending trigger event %s to subscribers of %s", message.ID, message.TriggerID))
 
@@ -129,6 +137,9 @@ func (c *Controller) SendTrigger(ctx context.Context, message *pb2.SendTriggerEv
 			return err
 		}
 	}
+	if c.OnTriggerSent != nil {
+		c.OnTriggerSent(message)
+	}
 	return nil
 }
 
@@ -1247,6 +1247,7 @@ type ExecutableResponse struct {
 	ID             string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
 	CapabilityType CapabilityType         `protobuf:"varint,2,opt,name=CapabilityType,proto3,enum=mockcap.CapabilityType" json:"CapabilityType,omitempty"`
 	Value          []byte                 `protobuf:"bytes,3,opt,name=Value,proto3" json:"Value,omitempty"`
+	Error          string                 `protobuf:"bytes,4,opt,name=Error,proto3" json:"Error,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -1302,6 +1303,13 @@ func (x *ExecutableResponse) GetValue() []byte {
 	return nil
 }
 
+func (x *ExecutableResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 type RemoveCapabilityRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ID            string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
@@ -1436,11 +1444,12 @@ const file_proxy_proto_rawDesc = "" +
 	"\tSpendType\x18\x01 \x01(\tR\tSpendType\x12\x14\n" +
 	"\x05Limit\x18\x02 \x01(\tR\x05Limit\"*\n" +
 	"\x12CapabilityResponse\x12\x14\n" +
-	"\x05Value\x18\x01 \x01(\fR\x05Value\"{\n" +
+	"\x05Value\x18\x01 \x01(\fR\x05Value\"\x91\x01\n" +
 	"\x12ExecutableResponse\x12\x0e\n" +
 	"\x02ID\x18\x01 \x01(\tR\x02ID\x12?\n" +
 	"\x0eCapabilityType\x18\x02 \x01(\x0e2\x17.mockcap.CapabilityTypeR\x0eCapabilityType\x12\x14\n" +
-	"\x05Value\x18\x03 \x01(\fR\x05Value\")\n" +
+	"\x05Value\x18\x03 \x01(\fR\x05Value\x12\x14\n" +
+	"\x05Error\x18\x04 \x01(\tR\x05Error\")\n" +
 	"\x17RemoveCapabilityRequest\x12\x0e\n" +
 	"\x02ID\x18\x01 \x01(\tR\x02ID*Q\n" +
 	"\x0eCapabilityType\x12\v\n" +
@@ -0,0 +1,187 @@
+package mockcapability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/capabilities"
+	"github.com/smartcontractkit/chainlink-protos/cre/go/values"
+
+	pb2 "github.com/smartcontractkit/chainlink/system-tests/lib/cre/mock/pb"
+)
+
+// Responder returns the response of a stubbed capability to the request, an error fails the execution with it
+type Responder = func(request capabilities.CapabilityRequest) (*values.Map, error)
+
+// Stub replaces a capability, e.g. one backed by a paid data provider, with scripted responses. It's registered by the mock
+// capability running on nodes of a DON with the mock flag, so that DON must not run the real capability with the same ID.
+type Stub struct {
+	CapabilityID   string
+	CapabilityType pb2.CapabilityType
+	Description    string
+
+	responder    Responder
+	responses    []*values.Map
+	calls        []capabilities.CapabilityRequest
+	callsPerNode map[string]int
+	mu           sync.Mutex
+}
+
+func NewStub(capabilityID string, capabilityType pb2.CapabilityType) *Stub {
+	return &Stub{
+		CapabilityID:   capabilityID,
+		CapabilityType: capabilityType,
+		Description:    "stub of " + capabilityID,
+		callsPerNode:   make(map[string]int),
+	}
+}
+
+// RespondWith scripts responses returned in order, the last one is repeated once all were returned
+func (s *Stub) RespondWith(responses ...*values.Map) *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses = responses
+	s.responder = nil
+
+	return s
+}
+
+// RespondFunc makes the stub compute responses from requests, it takes precedence over scripted responses
+func (s *Stub) RespondFunc(responder Responder) *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responder = responder
+
+	return s
+}
+
+// Calls returns requests received by the stub so far, from all nodes
+func (s *Stub) Calls() []capabilities.CapabilityRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]capabilities.CapabilityRequest(nil), s.calls...)
+}
+
+// respond returns the response to the request. Each node executes the capability, so with scripted responses
+// the n-th execution on every node gets the n-th response.
+func (s *Stub) respond(node string, request capabilities.CapabilityRequest) (*values.Map, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, request)
+	if responder := s.responder; responder != nil {
+		// called without the lock, so that the responder can inspect previous calls
+		s.mu.Unlock()
+		defer s.mu.Lock()
+		return responder(request)
+	}
+	if len(s.responses) == 0 {
+		return nil, fmt.Errorf("stub of %s has no responses", s.CapabilityID)
+	}
+
+	idx := min(s.callsPerNode[node], len(s.responses)-1)
+	s.callsPerNode[node]++
+
+	return s.responses[idx], nil
+}
+
+// StubCapabilities creates stubbed capabilities on all nodes and starts answering their executions. Executions
+// of other mock capabilities are answered with their inputs, like with HookExecutables.
+func (c *Controller) StubCapabilities(ctx context.Context, stubs ...*Stub) error {
+	byID := make(map[string]*Stub, len(stubs))
+	for _, stub := range stubs {
+		if createErr := c.CreateCapability(ctx, &pb2.CapabilityInfo{
+			ID:             stub.CapabilityID,
+			CapabilityType: stub.CapabilityType,
+			Description:    stub.Description,
+		}); createErr != nil {
+			return fmt.Errorf("failed to create stub of %s: %w", stub.CapabilityID, createErr)
+		}
+		byID[stub.CapabilityID] = stub
+	}
+
+	for _, client := range c.Nodes {
+		hook, hookErr := client.API.HookExecutables(ctx)
+		if hookErr != nil {
+			return fmt.Errorf("cannot hook into executable at %s: %w", client.URL, hookErr)
+		}
+
+		go func() {
+			for {
+				req, recvErr := hook.Recv()
+				if errors.Is(recvErr, io.EOF) || ctx.Err() != nil {
+					return
+				}
+				if recvErr != nil {
+					c.lggr.Error().Err(recvErr).Msgf("Failed to receive execute event from %s", client.URL)
+					return
+				}
+
+				if sendErr := hook.Send(c.executableResponse(client.URL, byID, req)); sendErr != nil {
+					c.lggr.Error().Err(sendErr).Msgf("Failed to send response of %s to %s", req.ID, client.URL)
+					return
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+// executableResponse answers the execution with the response of its stub, or with its inputs, if it isn't stubbed.
+// If the stub fails, the execution fails with its error, instead of succeeding with an empty value.
+func (c *Controller) executableResponse(node string, byID map[string]*Stub, req *pb2.ExecutableRequest) *pb2.ExecutableResponse {
+	response := &pb2.ExecutableResponse{ID: req.ID, CapabilityType: req.CapabilityType, Value: req.Inputs}
+
+	stub, ok := byID[req.ID]
+	if !ok {
+		return response
+	}
+
+	value, respondErr := c.stubResponse(node, stub, req)
+	if respondErr != nil {
+		c.lggr.Error().Err(respondErr).Msgf("Stub of %s failed to respond, failing the execution", req.ID)
+		response.Value = nil
+		response.Error = respondErr.Error()
+
+		return response
+	}
+	response.Value = value
+
+	return response
+}
+
+func (c *Controller) stubResponse(node string, stub *Stub, req *pb2.ExecutableRequest) ([]byte, error) {
+	config, configErr := BytesToMap(req.Config)
+	if configErr != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", configErr)
+	}
+	inputs, inputsErr := BytesToMap(req.Inputs)
+	if inputsErr != nil {
+		return nil, fmt.Errorf("failed to decode inputs: %w", inputsErr)
+	}
+
+	request := capabilities.CapabilityRequest{Config: config, Inputs: inputs}
+	if req.RequestMetadata != nil {
+		request.Metadata = capabilities.RequestMetadata{
+			WorkflowID:          req.RequestMetadata.WorkflowID,
+			WorkflowOwner:       req.RequestMetadata.WorkflowOwner,
+			WorkflowExecutionID: req.RequestMetadata.WorkflowExecutionID,
+			WorkflowName:        req.RequestMetadata.WorkflowName,
+			ReferenceID:         req.RequestMetadata.ReferenceID,
+		}
+	}
+
+	response, respondErr := stub.respond(node, request)
+	if respondErr != nil {
+		return nil, respondErr
+	}
+
+	return MapToBytes(response)
+}
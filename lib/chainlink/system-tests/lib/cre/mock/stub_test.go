@@ -0,0 +1,75 @@
+package mockcapability
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-common/pkg/capabilities"
+	"github.com/smartcontractkit/chainlink-protos/cre/go/values"
+
+	pb2 "github.com/smartcontractkit/chainlink/system-tests/lib/cre/mock/pb"
+)
+
+func TestStubResponses(t *testing.T) {
+	first, err := values.NewMap(map[string]any{"price": 1})
+	require.NoError(t, err)
+	second, err := values.NewMap(map[string]any{"price": 2})
+	require.NoError(t, err)
+
+	stub := NewStub("data-provider@1.0.0", pb2.CapabilityType_Action).RespondWith(first, second)
+	controller := &Controller{lggr: zerolog.Nop()}
+	byID := map[string]*Stub{stub.CapabilityID: stub}
+
+	// each node gets the scripted responses in order, the last one is repeated
+	for _, expected := range []*values.Map{first, second, second} {
+		for _, node := range []string{"node-1", "node-2"} {
+			response := controller.executableResponse(node, byID, executableRequest(t, stub.CapabilityID))
+			require.Empty(t, response.Error)
+			value, decodeErr := BytesToMap(response.Value)
+			require.NoError(t, decodeErr)
+			assert.Equal(t, expected, value)
+		}
+	}
+	assert.Len(t, stub.Calls(), 6)
+}
+
+func TestStubErrorFailsExecution(t *testing.T) {
+	stub := NewStub("data-provider@1.0.0", pb2.CapabilityType_Action).RespondFunc(func(capabilities.CapabilityRequest) (*values.Map, error) {
+		return nil, errors.New("provider is down")
+	})
+	controller := &Controller{lggr: zerolog.Nop()}
+
+	response := controller.executableResponse("node-1", map[string]*Stub{stub.CapabilityID: stub}, executableRequest(t, stub.CapabilityID))
+	assert.Equal(t, "provider is down", response.Error)
+	assert.Nil(t, response.Value)
+
+	unscripted := NewStub("other@1.0.0", pb2.CapabilityType_Action)
+	response = controller.executableResponse("node-1", map[string]*Stub{unscripted.CapabilityID: unscripted}, executableRequest(t, unscripted.CapabilityID))
+	assert.Contains(t, response.Error, "has no responses")
+}
+
+func TestExecutableResponseOfOtherCapabilitiesEchoesInputs(t *testing.T) {
+	controller := &Controller{lggr: zerolog.Nop()}
+	req := executableRequest(t, "not-stubbed@1.0.0")
+
+	response := controller.executableResponse("node-1", map[string]*Stub{}, req)
+	assert.Empty(t, response.Error)
+	assert.Equal(t, req.Inputs, response.Value)
+}
+
+func executableRequest(t *testing.T, capabilityID string) *pb2.ExecutableRequest {
+	t.Helper()
+
+	inputs, err := values.NewMap(map[string]any{"feed": "ETH/USD"})
+	require.NoError(t, err)
+	inputBytes, err := MapToBytes(inputs)
+	require.NoError(t, err)
+	configBytes, err := MapToBytes(values.EmptyMap())
+	require.NoError(t, err)
+
+	return &pb2.ExecutableRequest{ID: capabilityID, CapabilityType: pb2.CapabilityType_Action, Inputs: inputBytes, Config: configBytes}
+}
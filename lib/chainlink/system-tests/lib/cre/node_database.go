@@ -0,0 +1,54 @@
+package cre
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/nodedb"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// SnapshotNodeDatabase dumps the database of the node of the DON to dir, the node keeps running. Only Docker is supported.
+func SnapshotNodeDatabase(ctx context.Context, provider infra.Provider, don *Don, node *Node, dir string) (*nodedb.Snapshot, error) {
+	if !provider.IsDocker() {
+		return nil, fmt.Errorf("database snapshots are supported only with Docker, not %s", provider.Type)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.dump", node.Name, time.Now().UTC().Format("20060102T150405")))
+
+	return nodedb.Dump(ctx, don.Name, node.Index, path)
+}
+
+// RestoreNodeDatabase stops the node, replaces its database with the snapshot and starts it again. The snapshot can be
+// older than the current state of the DON, in that case the node rejoins with stale state (e.g. workflow specs,
+// executions, nonces), which it has to recover from. It returns once the node is healthy again. If the restore fails,
+// the node stays stopped, so that it doesn't boot on a partially restored database.
+func RestoreNodeDatabase(ctx context.Context, provider infra.Provider, don *Don, node *Node, snapshot *nodedb.Snapshot) error {
+	if snapshot.NodeSet != don.Name || snapshot.NodeIndex != node.Index {
+		return fmt.Errorf("snapshot of node %d of nodeset %s can't be restored to node %s of DON %s", snapshot.NodeIndex, snapshot.NodeSet, node.Name, don.Name)
+	}
+	if err := provider.CheckSafeTarget(ctx, node.Index, don.Name); err != nil {
+		return err
+	}
+
+	if err := provider.StopNode(ctx, node.Index, don.Name); err != nil {
+		return errors.Wrapf(err, "failed to stop node %s", node.Name)
+	}
+
+	// the node is left stopped if the restore failed, its database may be dropped already and it would run migrations
+	// over an empty one
+	if err := nodedb.Restore(ctx, snapshot); err != nil {
+		return errors.Wrapf(err, "failed to restore database of node %s, the node is left stopped", node.Name)
+	}
+
+	startCtx := context.WithoutCancel(ctx)
+	if err := provider.StartNode(startCtx, node.Index, don.Name); err != nil {
+		return errors.Wrapf(err, "failed to start node %s", node.Name)
+	}
+
+	return node.WaitHealthy(startCtx, nodeStartTimeout)
+}
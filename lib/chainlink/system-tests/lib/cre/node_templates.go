@@ -0,0 +1,129 @@
+package cre
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+)
+
+// Roles of node spec templates (see RoleSpecTemplate) and of nodes capability binaries are installed on (see CapabilityConfig.NodeRoles)
+const (
+	TemplateRoleBootstrap = "bootstrap"
+	TemplateRoleWorker    = "worker"
+	TemplateRoleGateway   = "gateway" // workers, which also run the gateway, the DON needs the gateway DON type
+)
+
+// RoleSpecTemplate is a node spec used for Count nodes of one role in a DON, so that e.g. the bootstrap node can use
+// a different image or resources than workers. Templates are expanded in the declared order, see ExpandRoleTemplates.
+// Example: [[nodesets.role_templates]] role = "worker", count = 4, [nodesets.role_templates.spec.node] image = "..."
+type RoleSpecTemplate struct {
+	Role  string        `toml:"role"`
+	Count int           `toml:"count"`
+	Spec  *clnode.Input `toml:"spec"`
+}
+
+// ExpandRoleTemplates replaces role templates of the nodeset with NodeSpecs, one copy of the template's spec per node,
+// and derives bootstrap and gateway node indexes from the roles. Indexes of roles without a template are kept as set in
+// the nodeset, like with node_specs, but they must point to one of the nodes. Node names set in templates get a suffix
+// with the node's number within its role. Nodesets without templates are left unchanged, so calling it again is a no-op.
+func (c *CapabilitiesAwareNodeSet) ExpandRoleTemplates() error {
+	if len(c.RoleTemplates) == 0 {
+		return nil
+	}
+	if len(c.NodeSpecs) > 0 {
+		return fmt.Errorf("nodeset %s sets both node_specs and role_templates. Please use only one of them", c.Name)
+	}
+
+	bootstrapIndex := -1
+	var gatewayIndexes []int
+	var nodeSpecs []*clnode.Input
+	for templateIdx, template := range c.RoleTemplates {
+		if template.Count <= 0 {
+			return fmt.Errorf("count of role template %d (%s) of nodeset %s must be positive", templateIdx, template.Role, c.Name)
+		}
+		if template.Spec == nil || template.Spec.Node == nil {
+			return fmt.Errorf("role template %d (%s) of nodeset %s has no node spec", templateIdx, template.Role, c.Name)
+		}
+
+		switch template.Role {
+		case TemplateRoleBootstrap:
+			if bootstrapIndex != -1 || template.Count != 1 {
+				return fmt.Errorf("nodeset %s can have only one bootstrap node", c.Name)
+			}
+			bootstrapIndex = len(nodeSpecs)
+		case TemplateRoleGateway:
+			if !slices.Contains(c.DONTypes, GatewayDON) {
+				return fmt.Errorf("nodeset %s has a role template of gateway nodes, but its don_types don't contain %s", c.Name, GatewayDON)
+			}
+			for i := range template.Count {
+				gatewayIndexes = append(gatewayIndexes, len(nodeSpecs)+i)
+			}
+		case TemplateRoleWorker:
+		default:
+			return fmt.Errorf("unknown role '%s' of role template %d of nodeset %s. Valid ones are: %s, %s, %s", template.Role, templateIdx, c.Name, TemplateRoleBootstrap, TemplateRoleWorker, TemplateRoleGateway)
+		}
+
+		for i := range template.Count {
+			nodeSpec := cloneNodeSpec(template.Spec)
+			if nodeSpec.Node.Name != "" && template.Count > 1 {
+				nodeSpec.Node.Name += "-" + strconv.Itoa(i)
+			}
+			nodeSpecs = append(nodeSpecs, nodeSpec)
+		}
+	}
+	if bootstrapIndex != -1 && len(nodeSpecs) == 1 {
+		return errors.Errorf("role templates of nodeset %s have no worker or gateway nodes", c.Name)
+	}
+
+	if bootstrapIndex == -1 {
+		if c.BootstrapNodeIndex >= len(nodeSpecs) {
+			return fmt.Errorf("bootstrap_node_index %d of nodeset %s is out of range of %d nodes of its role templates", c.BootstrapNodeIndex, c.Name, len(nodeSpecs))
+		}
+		bootstrapIndex = c.BootstrapNodeIndex
+	}
+	if len(gatewayIndexes) == 0 {
+		for _, gatewayIndex := range c.AllGatewayNodeIndexes() {
+			if gatewayIndex >= len(nodeSpecs) {
+				return fmt.Errorf("gateway node index %d of nodeset %s is out of range of %d nodes of its role templates", gatewayIndex, c.Name, len(nodeSpecs))
+			}
+		}
+	} else {
+		c.GatewayNodeIndexes = gatewayIndexes
+		c.GatewayNodeIndex = gatewayIndexes[0]
+	}
+
+	c.NodeSpecs = nodeSpecs
+	c.Nodes = len(nodeSpecs)
+	c.BootstrapNodeIndex = bootstrapIndex
+	c.RoleTemplates = nil
+
+	return nil
+}
+
+// cloneNodeSpec deep copies fields, which are modified per node later, e.g. capability binaries appended to workers
+func cloneNodeSpec(spec *clnode.Input) *clnode.Input {
+	clone := *spec
+	clone.Out = nil
+	if spec.DbInput != nil {
+		dbInput := *spec.DbInput
+		clone.DbInput = &dbInput
+	}
+
+	node := *spec.Node
+	node.DockerBuildArgs = maps.Clone(spec.Node.DockerBuildArgs)
+	node.CapabilitiesBinaryPaths = slices.Clone(spec.Node.CapabilitiesBinaryPaths)
+	node.CustomPorts = slices.Clone(spec.Node.CustomPorts)
+	node.EnvVars = maps.Clone(spec.Node.EnvVars)
+	if spec.Node.ContainerResources != nil {
+		resources := *spec.Node.ContainerResources
+		node.ContainerResources = &resources
+	}
+	clone.Node = &node
+
+	return &clone
+}
@@ -0,0 +1,64 @@
+package cre
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+)
+
+func templatesNodeSet(templates ...*RoleSpecTemplate) *CapabilitiesAwareNodeSet {
+	return &CapabilitiesAwareNodeSet{
+		Input:         &ns.Input{Name: "workflow"},
+		DONTypes:      []string{WorkflowDON, GatewayDON},
+		RoleTemplates: templates,
+	}
+}
+
+func roleTemplate(role string, count int) *RoleSpecTemplate {
+	return &RoleSpecTemplate{Role: role, Count: count, Spec: &clnode.Input{Node: &clnode.NodeInput{Name: role}}}
+}
+
+func TestExpandRoleTemplates(t *testing.T) {
+	t.Run("indexes are derived from roles", func(t *testing.T) {
+		nodeSet := templatesNodeSet(roleTemplate(TemplateRoleBootstrap, 1), roleTemplate(TemplateRoleWorker, 2), roleTemplate(TemplateRoleGateway, 2))
+		nodeSet.BootstrapNodeIndex, nodeSet.GatewayNodeIndex = -1, -1
+		require.NoError(t, nodeSet.ExpandRoleTemplates())
+
+		assert.Equal(t, 5, nodeSet.Nodes)
+		assert.Equal(t, 0, nodeSet.BootstrapNodeIndex)
+		assert.Equal(t, []int{3, 4}, nodeSet.GatewayNodeIndexes)
+		assert.Equal(t, 3, nodeSet.GatewayNodeIndex)
+		assert.Equal(t, "worker-1", nodeSet.NodeSpecs[2].Node.Name)
+		assert.Nil(t, nodeSet.RoleTemplates)
+		require.NoError(t, nodeSet.ExpandRoleTemplates(), "expanding again must be a no-op")
+		assert.Equal(t, 5, nodeSet.Nodes)
+	})
+
+	t.Run("explicit indexes of roles without a template are kept", func(t *testing.T) {
+		nodeSet := templatesNodeSet(roleTemplate(TemplateRoleWorker, 4))
+		nodeSet.BootstrapNodeIndex, nodeSet.GatewayNodeIndex = 1, 3
+		require.NoError(t, nodeSet.ExpandRoleTemplates())
+		assert.Equal(t, 1, nodeSet.BootstrapNodeIndex)
+		assert.Equal(t, 3, nodeSet.GatewayNodeIndex)
+
+		nodeSet = templatesNodeSet(roleTemplate(TemplateRoleWorker, 4))
+		nodeSet.BootstrapNodeIndex, nodeSet.GatewayNodeIndex = -1, -1
+		require.NoError(t, nodeSet.ExpandRoleTemplates())
+		assert.Equal(t, -1, nodeSet.BootstrapNodeIndex)
+		assert.Empty(t, nodeSet.AllGatewayNodeIndexes())
+	})
+
+	t.Run("explicit indexes must point to nodes of templates", func(t *testing.T) {
+		nodeSet := templatesNodeSet(roleTemplate(TemplateRoleWorker, 2))
+		nodeSet.BootstrapNodeIndex, nodeSet.GatewayNodeIndex = 2, -1
+		require.ErrorContains(t, nodeSet.ExpandRoleTemplates(), "bootstrap_node_index 2 of nodeset workflow is out of range of 2 nodes")
+
+		nodeSet = templatesNodeSet(roleTemplate(TemplateRoleWorker, 2))
+		nodeSet.GatewayNodeIndexes = []int{1, 2}
+		require.ErrorContains(t, nodeSet.ExpandRoleTemplates(), "gateway node index 2 of nodeset workflow is out of range of 2 nodes")
+	})
+}
@@ -0,0 +1,96 @@
+package nodedb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/postgres"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// Snapshot is a dump of a node's database in pg_dump's custom format stored on the host
+type Snapshot struct {
+	NodeSet   string
+	NodeIndex int
+	Path      string
+	CreatedAt time.Time
+}
+
+// PostgresContainerName returns the name of the Docker container with the shared Postgres of the nodeset
+func PostgresContainerName(nodeSetName string) string {
+	return nodeSetName + "-ns-postgresql"
+}
+
+func databaseName(nodeIndex int) string {
+	return fmt.Sprintf("db_%d", nodeIndex)
+}
+
+// Dump stores the database of the node with given index in the nodeset at path on the host. The node can keep running,
+// pg_dump takes a consistent snapshot. Only Docker is supported.
+func Dump(ctx context.Context, nodeSetName string, nodeIndex int, path string) (*Snapshot, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, errors.Wrap(err, "failed to create directory for the snapshot")
+	}
+	file, createErr := os.Create(path)
+	if createErr != nil {
+		return nil, errors.Wrap(createErr, "failed to create snapshot file")
+	}
+	defer file.Close()
+
+	snapshot := &Snapshot{NodeSet: nodeSetName, NodeIndex: nodeIndex, Path: path, CreatedAt: time.Now()}
+	executor := &infra.DockerExecutor{ContainerName: PostgresContainerName(nodeSetName)}
+	result, execErr := executor.Exec(ctx, []string{"pg_dump", "-U", postgres.User, "--format=custom", databaseName(nodeIndex)}, infra.ExecOptions{Stdout: file})
+	if err := execResultErr(result, execErr); err != nil {
+		_ = os.Remove(path)
+		return nil, errors.Wrapf(err, "failed to dump database of node %d of nodeset %s", nodeIndex, nodeSetName)
+	}
+
+	return snapshot, nil
+}
+
+// Restore replaces the database of the snapshot's node with the snapshot. The node must be stopped, because the database
+// is dropped and created again, see cre.RestoreNodeDatabase. A snapshot can be restored any number of times,
+// also after the node made progress, e.g. to make it rejoin the DON with stale workflow state.
+func Restore(ctx context.Context, snapshot *Snapshot) error {
+	file, openErr := os.Open(snapshot.Path)
+	if openErr != nil {
+		return errors.Wrap(openErr, "failed to open snapshot file")
+	}
+	defer file.Close()
+
+	dbName := databaseName(snapshot.NodeIndex)
+	executor := &infra.DockerExecutor{ContainerName: PostgresContainerName(snapshot.NodeSet)}
+	for _, cmd := range [][]string{
+		{"dropdb", "-U", postgres.User, "--if-exists", "--force", dbName},
+		{"createdb", "-U", postgres.User, dbName},
+	} {
+		if err := execResultErr(executor.Exec(ctx, cmd, infra.ExecOptions{})); err != nil {
+			return errors.Wrapf(err, "failed to recreate database %s of nodeset %s", dbName, snapshot.NodeSet)
+		}
+	}
+
+	restore := []string{"pg_restore", "-U", postgres.User, "--no-owner", "--exit-on-error", "-d", dbName}
+	if err := execResultErr(executor.Exec(ctx, restore, infra.ExecOptions{Stdin: file})); err != nil {
+		return errors.Wrapf(err, "failed to restore database %s of nodeset %s from %s", dbName, snapshot.NodeSet, snapshot.Path)
+	}
+
+	return nil
+}
+
+func execResultErr(result *infra.ExecResult, err error) error {
+	if err != nil {
+		return err
+	}
+	if !result.Succeeded() {
+		return fmt.Errorf("exited with code %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package nodedb provides read-only access to databases of nodes, so that tests can assert on what the workflow engine
+// and capabilities persisted (workflow specs, execution states, queued transmissions), not only on their external effects.
+// Queries are written against the node's schema, which isn't a stable API, so they should be kept to columns, which
+// have been there for a while. Snapshots of databases can be taken and restored to test recovery of nodes.
+package nodedb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/postgres"
+)
+
+// DB is a read-only connection to the database of a single node, every transaction started on it is read-only
+type DB struct {
+	db *sqlx.DB
+}
+
+// Open connects to the database of the node with given index in a nodeset, whose Postgres is exposed at externalPort
+// on the host (nodeSet.DbInput.Port)
+func Open(ctx context.Context, nodeIndex, externalPort int) (*DB, error) {
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable default_transaction_read_only=on",
+		"127.0.0.1", externalPort, postgres.User, postgres.Password, fmt.Sprintf("db_%d", nodeIndex))
+	db, err := sqlx.Open("postgres", dsn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open database of node %d", nodeIndex)
+	}
+	if pingErr := db.PingContext(ctx); pingErr != nil {
+		_ = db.Close()
+		return nil, errors.Wrapf(pingErr, "failed to connect to database of node %d", nodeIndex)
+	}
+
+	return &DB{db: db}, nil
+}
+
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Select runs a custom query and scans all rows into dest, which must be a pointer to a slice, columns are mapped
+// to fields by `db` tags
+func (d *DB) Select(ctx context.Context, dest any, query string, args ...any) error {
+	return errors.Wrap(d.db.SelectContext(ctx, dest, query, args...), "failed to query node database")
+}
+
+// Count returns the number of rows matching the query, e.g. "SELECT count(*) FROM workflow_executions WHERE status = $1"
+func (d *DB) Count(ctx context.Context, query string, args ...any) (int64, error) {
+	var count int64
+	if err := d.db.GetContext(ctx, &count, query, args...); err != nil {
+		return 0, errors.Wrap(err, "failed to count rows in node database")
+	}
+
+	return count, nil
+}
+
+// migrationsTable is where goose keeps versions of applied migrations of the node's schema
+const migrationsTable = "goose_migrations"
+
+// MigrationVersions returns versions of database migrations applied to the node's database in ascending order. Nodes
+// don't start on databases with migrations they don't know, so they have to be rolled back before a downgrade.
+func (d *DB) MigrationVersions(ctx context.Context) ([]int64, error) {
+	var versions []int64
+	err := d.Select(ctx, &versions, `SELECT DISTINCT version_id FROM `+migrationsTable+` WHERE is_applied AND version_id > 0 ORDER BY version_id`)
+
+	return versions, err
+}
+
+// MigrationVersion returns the version of the last migration applied to the node's database, 0 if none was applied
+func (d *DB) MigrationVersion(ctx context.Context) (int64, error) {
+	versions, err := d.MigrationVersions(ctx)
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+
+	return versions[len(versions)-1], nil
+}
+
+type WorkflowSpec struct {
+	ID            int64     `db:"id"`
+	WorkflowID    string    `db:"workflow_id"`
+	WorkflowOwner string    `db:"workflow_owner"`
+	WorkflowName  string    `db:"workflow_name"`
+	WorkflowTag   string    `db:"workflow_tag"`
+	Status        string    `db:"status"`
+	BinaryURL     string    `db:"binary_url"`
+	ConfigURL     string    `db:"config_url"`
+	SpecType      string    `db:"spec_type"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+const workflowSpecColumns = `id, workflow_id, workflow_owner, workflow_name, workflow_tag, status, COALESCE(binary_url, '') AS binary_url,
+	COALESCE(config_url, '') AS config_url, COALESCE(spec_type, '') AS spec_type, created_at, updated_at`
+
+// WorkflowSpecs returns workflows, which the node's workflow registry syncer stored, i.e. which the node will run
+func (d *DB) WorkflowSpecs(ctx context.Context) ([]WorkflowSpec, error) {
+	var specs []WorkflowSpec
+	err := d.Select(ctx, &specs, `SELECT `+workflowSpecColumns+` FROM workflow_specs_v2 ORDER BY id`)
+
+	return specs, err
+}
+
+// WorkflowSpec returns the stored spec of the workflow, ok is false if the node doesn't have it
+func (d *DB) WorkflowSpec(ctx context.Context, workflowID string) (*WorkflowSpec, bool, error) {
+	var specs []WorkflowSpec
+	if err := d.Select(ctx, &specs, `SELECT `+workflowSpecColumns+` FROM workflow_specs_v2 WHERE workflow_id = $1`, workflowID); err != nil {
+		return nil, false, err
+	}
+	if len(specs) == 0 {
+		return nil, false, nil
+	}
+
+	return &specs[0], true, nil
+}
+
+type WorkflowExecution struct {
+	ID         string       `db:"id"`
+	WorkflowID string       `db:"workflow_id"`
+	Status     string       `db:"status"`
+	CreatedAt  sql.NullTime `db:"created_at"`
+	UpdatedAt  sql.NullTime `db:"updated_at"`
+	FinishedAt sql.NullTime `db:"finished_at"`
+}
+
+// WorkflowExecutions returns executions of the workflow persisted by the engine, oldest first. Only engines, which store
+// execution state (the legacy engine), write them.
+func (d *DB) WorkflowExecutions(ctx context.Context, workflowID string) ([]WorkflowExecution, error) {
+	var executions []WorkflowExecution
+	err := d.Select(ctx, &executions, `SELECT id, COALESCE(workflow_id, '') AS workflow_id, status, created_at, updated_at, finished_at
+		FROM workflow_executions WHERE workflow_id = $1 ORDER BY created_at`, workflowID)
+
+	return executions, err
+}
+
+// ExecutionStatusCounts returns the number of executions of the workflow per status, e.g. completed, errored, timeout
+func (d *DB) ExecutionStatusCounts(ctx context.Context, workflowID string) (map[string]int64, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int64  `db:"count"`
+	}
+	if err := d.Select(ctx, &rows, `SELECT status, count(*) AS count FROM workflow_executions WHERE workflow_id = $1 GROUP BY status`, workflowID); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
+type WorkflowStep struct {
+	ID        int64          `db:"id"`
+	Ref       string         `db:"ref"`
+	Status    string         `db:"status"`
+	OutputErr sql.NullString `db:"output_err"`
+	UpdatedAt sql.NullTime   `db:"updated_at"`
+}
+
+// WorkflowSteps returns persisted steps (capability calls) of the execution
+func (d *DB) WorkflowSteps(ctx context.Context, executionID string) ([]WorkflowStep, error) {
+	var steps []WorkflowStep
+	err := d.Select(ctx, &steps, `SELECT id, ref, status, output_err, updated_at FROM workflow_steps WHERE workflow_execution_id = $1 ORDER BY id`, executionID)
+
+	return steps, err
+}
+
+// Transmission is an EVM transaction of the node's transaction manager, e.g. a report written by the write capability
+type Transmission struct {
+	ID          int64          `db:"id"`
+	ChainID     string         `db:"evm_chain_id"`
+	FromAddress string         `db:"from_address"`
+	ToAddress   string         `db:"to_address"`
+	State       string         `db:"state"`
+	Error       sql.NullString `db:"error"`
+	CreatedAt   time.Time      `db:"created_at"`
+}
+
+// QueuedTransmissions returns transactions, which weren't broadcast yet or are waiting for confirmation
+func (d *DB) QueuedTransmissions(ctx context.Context) ([]Transmission, error) {
+	return d.Transmissions(ctx, "unstarted", "in_progress", "unconfirmed")
+}
+
+// Transmissions returns transactions in given states (all, if none is given), oldest first
+func (d *DB) Transmissions(ctx context.Context, states ...string) ([]Transmission, error) {
+	query := `SELECT id, evm_chain_id::text AS evm_chain_id, '0x' || encode(from_address, 'hex') AS from_address,
+		'0x' || encode(to_address, 'hex') AS to_address, state::text AS state, error, created_at FROM evm.txes`
+	args := make([]any, 0, 1)
+	if len(states) > 0 {
+		query += " WHERE state::text = ANY($1)"
+		args = append(args, pq.Array(states))
+	}
+	query += " ORDER BY id"
+
+	var transmissions []Transmission
+	err := d.Select(ctx, &transmissions, query, args...)
+
+	return transmissions, err
+}
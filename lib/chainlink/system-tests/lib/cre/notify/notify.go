@@ -0,0 +1,253 @@
+// Package notify sends notifications about milestones and failures of long-running scenarios (e.g. soak tests)
+// to webhooks and Slack, so that failures are noticed when they happen and not when the run ends.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type EventType string
+
+const (
+	EventStarted   EventType = "started"
+	EventMilestone EventType = "milestone"
+	EventFailure   EventType = "failure"
+	EventFinished  EventType = "finished"
+)
+
+type HookType string
+
+const (
+	HookTypeWebhook HookType = "webhook" // JSON-encoded Event is posted
+	HookTypeSlack   HookType = "slack"   // Slack incoming webhook
+)
+
+type Config struct {
+	Hooks []*HookConfig `toml:"hooks"`
+}
+
+type HookConfig struct {
+	Name      string            `toml:"name"`
+	Type      HookType          `toml:"type"`        // webhook (default) or slack
+	URL       string            `toml:"url"`         // use URLEnvVar for URLs, which contain secrets (e.g. Slack webhooks)
+	URLEnvVar string            `toml:"url_env_var"` // name of env var with the URL, takes precedence over URL
+	Events    []EventType       `toml:"events"`      // all events are sent if empty
+	Headers   map[string]string `toml:"headers"`
+}
+
+func (c *Config) Validate() error {
+	for idx, hook := range c.Hooks {
+		if hook.URL == "" && hook.URLEnvVar == "" {
+			return fmt.Errorf("url or url_env_var of notification hook %d is required", idx)
+		}
+		switch hook.Type {
+		case "", HookTypeWebhook, HookTypeSlack:
+		default:
+			return fmt.Errorf("unsupported type %q of notification hook %d, supported types: webhook, slack", hook.Type, idx)
+		}
+		for _, event := range hook.Events {
+			if !slices.Contains([]EventType{EventStarted, EventMilestone, EventFailure, EventFinished}, event) {
+				return fmt.Errorf("unsupported event %q of notification hook %d", event, idx)
+			}
+		}
+	}
+
+	return nil
+}
+
+type Event struct {
+	Type     EventType         `json:"type"`
+	Scenario string            `json:"scenario"`
+	Message  string            `json:"message"`
+	Time     time.Time         `json:"time"`
+	Elapsed  string            `json:"elapsed"` // since the scenario started
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+// Notifier sends events of a single scenario to all configured hooks. Failing hooks are logged
+// and never fail the scenario. A nil Notifier is valid and sends nothing, so callers don't need to check if notifications are enabled.
+type Notifier struct {
+	scenario string
+	hooks    []*HookConfig
+	fields   map[string]string // added to all events, e.g. run URL
+	started  time.Time
+	client   *http.Client
+	logger   zerolog.Logger
+}
+
+func New(logger zerolog.Logger, config *Config, scenario string) (*Notifier, error) {
+	if config == nil || len(config.Hooks) == 0 {
+		return nil, nil
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	if server, repo, runID := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && runID != "" {
+		fields["run"] = fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, runID)
+	}
+
+	return &Notifier{
+		scenario: scenario,
+		hooks:    config.Hooks,
+		fields:   fields,
+		started:  time.Now(),
+		client:   &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+	}, nil
+}
+
+func (n *Notifier) Started(ctx context.Context, fields map[string]string) {
+	n.Notify(ctx, EventStarted, "scenario started", fields)
+}
+
+// Milestone reports progress, e.g. "hour 2 of 8 completed"
+func (n *Notifier) Milestone(ctx context.Context, message string, fields map[string]string) {
+	n.Notify(ctx, EventMilestone, message, fields)
+}
+
+func (n *Notifier) Failure(ctx context.Context, err error, fields map[string]string) {
+	n.Notify(ctx, EventFailure, err.Error(), fields)
+}
+
+// Finished reports the end of the scenario, it's reported as a failure, if err is not nil
+func (n *Notifier) Finished(ctx context.Context, err error) {
+	if err != nil {
+		n.Failure(ctx, err, nil)
+		return
+	}
+	n.Notify(ctx, EventFinished, "scenario finished", nil)
+}
+
+// Heartbeat sends a milestone every interval until the returned function is called or the context is cancelled,
+// so that silence of a long-running scenario means that the test process itself died
+func (n *Notifier) Heartbeat(ctx context.Context, interval time.Duration) func() {
+	if n == nil || interval <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n.Milestone(ctx, "still running", nil)
+			}
+		}
+	}()
+
+	return cancel
+}
+
+func (n *Notifier) Notify(ctx context.Context, eventType EventType, message string, fields map[string]string) {
+	if n == nil {
+		return
+	}
+
+	event := Event{
+		Type:     eventType,
+		Scenario: n.scenario,
+		Message:  message,
+		Time:     time.Now().UTC(),
+		Elapsed:  time.Since(n.started).Round(time.Second).String(),
+		Fields:   make(map[string]string, len(n.fields)+len(fields)),
+	}
+	for key, value := range n.fields {
+		event.Fields[key] = value
+	}
+	for key, value := range fields {
+		event.Fields[key] = value
+	}
+
+	for _, hook := range n.hooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, eventType) {
+			continue
+		}
+		if err := n.send(ctx, hook, event); err != nil {
+			n.logger.Warn().Err(err).Msgf("Failed to send %s notification to hook %s", eventType, hook.Name)
+		}
+	}
+}
+
+func (n *Notifier) send(ctx context.Context, hook *HookConfig, event Event) error {
+	url := hook.URL
+	if hook.URLEnvVar != "" {
+		url = os.Getenv(hook.URLEnvVar)
+		if url == "" {
+			return fmt.Errorf("env var %s with URL of the hook is not set", hook.URLEnvVar)
+		}
+	}
+
+	var payload any = event
+	if hook.Type == HookTypeSlack {
+		payload = map[string]string{"text": slackText(event)}
+	}
+	body, mErr := json.Marshal(payload)
+	if mErr != nil {
+		return errors.Wrap(mErr, "failed to marshal notification")
+	}
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if reqErr != nil {
+		return errors.Wrap(reqErr, "failed to create notification request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range hook.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, sendErr := n.client.Do(req)
+	if sendErr != nil {
+		// URL is not included, because it might be a secret
+		return errors.New("failed to send notification, check if the hook's URL is reachable")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notification failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
+
+func slackText(event Event) string {
+	icon := map[EventType]string{
+		EventStarted:   ":rocket:",
+		EventMilestone: ":hourglass_flowing_sand:",
+		EventFailure:   ":rotating_light:",
+		EventFinished:  ":white_check_mark:",
+	}[event.Type]
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s *%s* %s after %s\n%s", icon, event.Scenario, event.Type, event.Elapsed, event.Message)
+
+	keys := make([]string, 0, len(event.Fields))
+	for key := range event.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&sb, "\n• %s: %s", key, event.Fields[key])
+	}
+
+	return sb.String()
+}
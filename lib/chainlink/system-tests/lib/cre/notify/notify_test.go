@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier(t *testing.T) {
+	var webhookEvents []Event
+	webhook := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var event Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		webhookEvents = append(webhookEvents, event)
+	}))
+	defer webhook.Close()
+
+	var slackMessages []string
+	slack := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var msg map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		slackMessages = append(slackMessages, msg["text"])
+	}))
+	defer slack.Close()
+
+	t.Setenv("SLACK_WEBHOOK_URL", slack.URL)
+	notifier, err := New(zerolog.Nop(), &Config{Hooks: []*HookConfig{
+		{Name: "ci", URL: webhook.URL},
+		{Name: "on-call", Type: HookTypeSlack, URLEnvVar: "SLACK_WEBHOOK_URL", Events: []EventType{EventFailure}},
+	}}, "soak")
+	require.NoError(t, err)
+
+	notifier.Started(t.Context(), nil)
+	notifier.Milestone(t.Context(), "hour 1 of 8 completed", map[string]string{"executions": "3600"})
+	notifier.Finished(t.Context(), errors.New("workflow stopped executing"))
+
+	require.Len(t, webhookEvents, 3)
+	assert.Equal(t, EventMilestone, webhookEvents[1].Type)
+	assert.Equal(t, "3600", webhookEvents[1].Fields["executions"])
+	assert.Equal(t, EventFailure, webhookEvents[2].Type)
+
+	require.Len(t, slackMessages, 1)
+	assert.Contains(t, slackMessages[0], "*soak* failure")
+	assert.Contains(t, slackMessages[0], "workflow stopped executing")
+}
+
+func TestNilNotifier(t *testing.T) {
+	notifier, err := New(zerolog.Nop(), nil, "soak")
+	require.NoError(t, err)
+	require.Nil(t, notifier)
+
+	// must not panic
+	notifier.Milestone(t.Context(), "hour 1", nil)
+	notifier.Heartbeat(t.Context(), 0)()
+}
@@ -0,0 +1,272 @@
+package cre
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultP2PMeshTimeout = 2 * time.Minute
+
+	// ragep2p exports counters per remote peer only, there is neither a gauge of active connections nor a counter of
+	// closed ones. A connection is live, if it's established and the peer sends data on it, see connectedPeers.
+	p2pConnEstablishedMetric = "ragep2p_peer_conn_established_total"
+	p2pConnReadBytesMetric   = "ragep2p_peer_conn_read_processed_bytes_total"
+)
+
+// NodeMeshStatus describes peers of a worker node: peers it's expected to be connected with and those it isn't connected with
+type NodeMeshStatus struct {
+	Node          *Node
+	DON           string
+	ExpectedPeers map[string]*Node // peer ID -> node
+	MissingPeers  []*Node
+	// Diagnostics contain results of TCP checks from the node to peering ports of missing peers
+	Diagnostics []string
+	Err         error // error of reading node's metrics
+
+	baseline map[string]peerConnSample // samples of the first scrape, see connectedPeers
+}
+
+func (s *NodeMeshStatus) Isolated() bool {
+	return s.Err != nil || len(s.MissingPeers) == len(s.ExpectedPeers)
+}
+
+type P2PMeshReport struct {
+	Nodes []*NodeMeshStatus
+}
+
+func (r *P2PMeshReport) Incomplete() []*NodeMeshStatus {
+	var incomplete []*NodeMeshStatus
+	for _, status := range r.Nodes {
+		if status.Err != nil || len(status.MissingPeers) > 0 {
+			incomplete = append(incomplete, status)
+		}
+	}
+
+	return incomplete
+}
+
+func (r *P2PMeshReport) String() string {
+	var sb strings.Builder
+	for _, status := range r.Incomplete() {
+		if status.Err != nil {
+			fmt.Fprintf(&sb, "node %s (DON %s): %s\n", status.Node.Name, status.DON, status.Err)
+			continue
+		}
+		state := "partially connected"
+		if status.Isolated() {
+			state = "isolated"
+		}
+		missing := make([]string, 0, len(status.MissingPeers))
+		for _, peer := range status.MissingPeers {
+			missing = append(missing, peer.Name)
+		}
+		fmt.Fprintf(&sb, "node %s (DON %s) is %s, not connected to %d of %d peers: %s\n", status.Node.Name, status.DON, state, len(status.MissingPeers), len(status.ExpectedPeers), strings.Join(missing, ", "))
+		for _, diagnostic := range status.Diagnostics {
+			fmt.Fprintf(&sb, "  %s\n", diagnostic)
+		}
+	}
+
+	return sb.String()
+}
+
+// VerifyP2PMesh waits until every worker node is connected to all other workers of its DON and to bootstrap nodes
+// of the topology. Connections are read from ragep2p metrics of nodes, a peer counts as connected once it sent data
+// on an established connection after the first scrape. Peers exchange discovery announcements every DeltaReconcile
+// (1 minute by default) even without OCR traffic, so the timeout must be longer than that. If the mesh isn't
+// complete within the timeout, TCP connectivity from nodes to peering ports of their missing peers is checked and the
+// report is returned with an error, since a partial mesh otherwise shows up much later as stalled OCR rounds or
+// capability requests without enough responses.
+func VerifyP2PMesh(ctx context.Context, dons *Dons, timeout time.Duration) (*P2PMeshReport, error) {
+	if timeout == 0 {
+		timeout = DefaultP2PMeshTimeout
+	}
+
+	var bootstraps []*Node
+	for _, don := range dons.List() {
+		if bootstrap, ok := don.Bootstrap(); ok {
+			bootstraps = append(bootstraps, bootstrap)
+		}
+	}
+
+	report := &P2PMeshReport{}
+	for _, don := range dons.List() {
+		workers, wErr := don.Workers()
+		if wErr != nil {
+			continue
+		}
+		for _, worker := range workers {
+			status := &NodeMeshStatus{Node: worker, DON: don.Name, ExpectedPeers: make(map[string]*Node)}
+			for _, peer := range slices.Concat(workers, bootstraps) {
+				if peer == worker || peer.Keys == nil || peer.Keys.P2PKey == nil {
+					continue
+				}
+				status.ExpectedPeers[peer.Keys.P2PKey.PeerID.Raw()] = peer
+			}
+			report.Nodes = append(report.Nodes, status)
+		}
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	deadline := time.Now().Add(timeout)
+	for {
+		for _, status := range report.Nodes {
+			refreshMeshStatus(ctx, client, status)
+		}
+		if len(report.Incomplete()) == 0 {
+			return report, nil
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	for _, status := range report.Incomplete() {
+		for _, peer := range status.MissingPeers {
+			status.Diagnostics = append(status.Diagnostics, diagnoseP2PConnectivity(ctx, status.Node, peer))
+		}
+	}
+
+	return report, fmt.Errorf("P2P mesh is incomplete after %s:\n%s", timeout, report)
+}
+
+func refreshMeshStatus(ctx context.Context, client *http.Client, status *NodeMeshStatus) {
+	samples, err := readPeerConnSamples(ctx, client, status.Node)
+	status.Err = err
+	status.MissingPeers = nil
+	if err != nil {
+		return
+	}
+	if status.baseline == nil {
+		status.baseline = samples
+	}
+	connected := connectedPeers(status.baseline, samples)
+
+	for peerID, peer := range status.ExpectedPeers {
+		if !connected[peerID] {
+			status.MissingPeers = append(status.MissingPeers, peer)
+		}
+	}
+	slices.SortFunc(status.MissingPeers, func(a, b *Node) int { return strings.Compare(a.Name, b.Name) })
+}
+
+// peerConnSample holds ragep2p counters of a remote peer
+type peerConnSample struct {
+	established float64
+	readBytes   float64
+}
+
+// connectedPeers returns IDs of remote peers, with which the node has an established connection, on which the peer
+// sent data since the baseline was sampled. The counter of established connections only grows, so a connection that
+// was dropped and not re-established could otherwise not be told apart from a live one. Counters lower than in the
+// baseline were reset by a restart of the node and are compared with zero.
+func connectedPeers(baseline, samples map[string]peerConnSample) map[string]bool {
+	connected := make(map[string]bool)
+	for peerID, sample := range samples {
+		base := baseline[peerID]
+		if sample.established < base.established || sample.readBytes < base.readBytes {
+			base = peerConnSample{}
+		}
+		if sample.established > 0 && sample.readBytes > base.readBytes {
+			connected[peerID] = true
+		}
+	}
+
+	return connected
+}
+
+func readPeerConnSamples(ctx context.Context, client *http.Client, node *Node) (map[string]peerConnSample, error) {
+	if node.Clients.RestClient == nil {
+		return nil, errors.New("node has no REST client")
+	}
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, node.Clients.RestClient.URL()+"/metrics", nil)
+	if reqErr != nil {
+		return nil, errors.Wrap(reqErr, "failed to create metrics request")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read metrics")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading metrics failed with status %d", resp.StatusCode)
+	}
+
+	return parsePeerConnSamples(resp.Body)
+}
+
+func parsePeerConnSamples(metrics io.Reader) (map[string]peerConnSample, error) {
+	samples := make(map[string]peerConnSample)
+	scanner := bufio.NewScanner(metrics)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		metric, _, found := strings.Cut(line, "{")
+		if !found || (metric != p2pConnEstablishedMetric && metric != p2pConnReadBytesMetric) {
+			continue
+		}
+		labelsEnd := strings.LastIndex(line, "}")
+		if labelsEnd == -1 {
+			continue
+		}
+		fields := strings.Fields(line[labelsEnd+1:])
+		if len(fields) == 0 {
+			continue
+		}
+		value, valueErr := strconv.ParseFloat(fields[0], 64)
+		if valueErr != nil {
+			continue
+		}
+		for _, label := range strings.Split(line[len(metric)+1:labelsEnd], ",") {
+			peerID, found := strings.CutPrefix(label, `remote_peer_id="`)
+			if !found {
+				continue
+			}
+			peerID = strings.TrimSuffix(peerID, `"`)
+			sample := samples[peerID]
+			if metric == p2pConnEstablishedMetric {
+				sample.established = value
+			} else {
+				sample.readBytes = value
+			}
+			samples[peerID] = sample
+		}
+	}
+
+	return samples, errors.Wrap(scanner.Err(), "failed to parse metrics")
+}
+
+// diagnoseP2PConnectivity checks from inside the node's container, whether peering ports of the peer accept TCP connections
+func diagnoseP2PConnectivity(ctx context.Context, node, peer *Node) string {
+	results := make([]string, 0, 2)
+	for _, port := range []int{OCRPeeringPort, CapabilitiesPeeringPort} {
+		check := fmt.Sprintf("timeout 3 bash -c '</dev/tcp/%s/%d' 2>&1", peer.Host, port)
+		result, err := node.Exec(ctx, "sh", "-c", check)
+		switch {
+		case err != nil:
+			results = append(results, fmt.Sprintf("%s:%d unknown (%s)", peer.Host, port, err))
+		case result.Succeeded():
+			results = append(results, fmt.Sprintf("%s:%d reachable", peer.Host, port))
+		default:
+			results = append(results, fmt.Sprintf("%s:%d unreachable (%s)", peer.Host, port, strings.TrimSpace(result.Stdout+result.Stderr)))
+		}
+	}
+
+	return fmt.Sprintf("%s -> %s: %s", node.Name, peer.Name, strings.Join(results, ", "))
+}
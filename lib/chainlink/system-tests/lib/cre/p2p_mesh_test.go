@@ -0,0 +1,39 @@
+package cre
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectedPeersRequireDataSinceBaseline(t *testing.T) {
+	baseline, err := parsePeerConnSamples(strings.NewReader(`# HELP ragep2p_peer_conn_established_total The number of secure connections established with the remote peer.
+# TYPE ragep2p_peer_conn_established_total counter
+ragep2p_peer_conn_established_total{peer_id="self",remote_peer_id="live"} 1
+ragep2p_peer_conn_established_total{peer_id="self",remote_peer_id="dropped"} 1
+ragep2p_peer_conn_established_total{peer_id="self",remote_peer_id="restarted"} 3
+ragep2p_peer_conn_established_total{peer_id="self",remote_peer_id="never"} 0
+ragep2p_peer_conn_read_processed_bytes_total{peer_id="self",remote_peer_id="live"} 100
+ragep2p_peer_conn_read_processed_bytes_total{peer_id="self",remote_peer_id="dropped"} 100
+ragep2p_peer_conn_read_processed_bytes_total{peer_id="self",remote_peer_id="restarted"} 500
+ragep2p_peer_conn_established_inbound_total{peer_id="self",remote_peer_id="never"} 1
+`))
+	require.NoError(t, err)
+	assert.Equal(t, peerConnSample{established: 1, readBytes: 100}, baseline["live"])
+	assert.Equal(t, peerConnSample{}, baseline["never"], "inbound counter was read as established connections")
+	assert.Empty(t, connectedPeers(baseline, baseline), "peers without data since the baseline are connected")
+
+	samples, err := parsePeerConnSamples(strings.NewReader(`ragep2p_peer_conn_established_total{peer_id="self",remote_peer_id="live"} 1
+ragep2p_peer_conn_established_total{peer_id="self",remote_peer_id="dropped"} 1
+ragep2p_peer_conn_established_total{peer_id="self",remote_peer_id="restarted"} 1
+ragep2p_peer_conn_established_total{peer_id="self",remote_peer_id="late"} 1
+ragep2p_peer_conn_read_processed_bytes_total{peer_id="self",remote_peer_id="live"} 150
+ragep2p_peer_conn_read_processed_bytes_total{peer_id="self",remote_peer_id="dropped"} 100
+ragep2p_peer_conn_read_processed_bytes_total{peer_id="self",remote_peer_id="restarted"} 20
+ragep2p_peer_conn_read_processed_bytes_total{peer_id="self",remote_peer_id="late"} 10
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"live": true, "restarted": true, "late": true}, connectedPeers(baseline, samples))
+}
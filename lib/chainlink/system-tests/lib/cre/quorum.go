@@ -0,0 +1,238 @@
+package cre
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sethvargo/go-retry"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+const (
+	DefaultQuorumCheckTimeout = 3 * time.Minute
+	// DefaultQuorumHaltedWindow is how long the check has to keep failing, before the DON is considered halted
+	DefaultQuorumHaltedWindow = 2 * time.Minute
+)
+
+type QuorumExpectation string
+
+const (
+	QuorumWorking QuorumExpectation = "working"
+	QuorumHalted  QuorumExpectation = "halted"
+)
+
+// QuorumStage is a single step of the degradation scenario with the number of worker nodes, which are down during it
+type QuorumStage struct {
+	Name      string
+	NodesDown int
+	Expected  QuorumExpectation
+}
+
+// QuorumDegradationStages return stages proving the fault tolerance of a DON of n worker nodes with given F: it keeps
+// working with all nodes up and with as many nodes down as leave a Byzantine quorum of OCR up, halts with one more
+// node down and recovers, when all nodes are back up. Only with n = 3F+1 the quorum is 2F+1 and F nodes can be down,
+// larger DONs tolerate more.
+func QuorumDegradationStages(n, f int) []QuorumStage {
+	tolerated := n - byzantineQuorum(n, f)
+
+	return []QuorumStage{
+		{Name: "all nodes up", NodesDown: 0, Expected: QuorumWorking},
+		{Name: fmt.Sprintf("%d nodes down (quorum left)", tolerated), NodesDown: tolerated, Expected: QuorumWorking},
+		{Name: fmt.Sprintf("%d nodes down (quorum lost)", tolerated+1), NodesDown: tolerated + 1, Expected: QuorumHalted},
+		{Name: "healed", NodesDown: 0, Expected: QuorumWorking},
+	}
+}
+
+// byzantineQuorum is the number of oracles OCR needs to make progress, the smallest number, whose sets overlap in
+// more than F oracles, as in libocr
+func byzantineQuorum(n, f int) int {
+	return (n+f)/2 + 1
+}
+
+type QuorumVerdict struct {
+	Stage        QuorumStage
+	StoppedNodes []string
+	Observed     QuorumExpectation
+	Duration     time.Duration
+	Err          error // last error of the check, or an error of stopping/starting nodes
+}
+
+func (v *QuorumVerdict) Passed() bool {
+	return v.Observed == v.Stage.Expected
+}
+
+type QuorumVerdicts []*QuorumVerdict
+
+func (v QuorumVerdicts) Failed() bool {
+	return slices.ContainsFunc(v, func(verdict *QuorumVerdict) bool { return !verdict.Passed() })
+}
+
+// Table formats verdicts as a plain text table, which can be printed or attached to release artifacts
+func (v QuorumVerdicts) Table() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tNODES DOWN\tEXPECTED\tOBSERVED\tVERDICT\tDURATION\tDETAILS")
+	for _, verdict := range v {
+		result := "PASS"
+		if !verdict.Passed() {
+			result = "FAIL"
+		}
+		details := strings.Join(verdict.StoppedNodes, ",")
+		if !verdict.Passed() && verdict.Err != nil {
+			details = verdict.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", verdict.Stage.Name, verdict.Stage.NodesDown, verdict.Stage.Expected, verdict.Observed, result, verdict.Duration.Round(time.Second), details)
+	}
+	_ = w.Flush()
+
+	return sb.String()
+}
+
+// QuorumDegradationScenario progressively stops worker nodes of the DON and after each stage asserts whether
+// the DON still works. Check should execute something requiring quorum of the DON (e.g. trigger a workflow
+// and wait for its report) and return an error, if it doesn't succeed. Only Docker is supported, see infra.StopNode.
+type QuorumDegradationScenario struct {
+	Provider infra.Provider
+	Don      *Don
+	Check    func(ctx context.Context) error
+	// Stages default to QuorumDegradationStages with the number of worker nodes and F of the DON, which is the F of
+	// its config in the capabilities registry
+	Stages []QuorumStage
+	// CheckTimeout limits how long check is retried in stages, in which the DON is expected to work
+	CheckTimeout time.Duration
+	// HaltedWindow is how long check is retried in stages, in which the DON is expected to halt
+	HaltedWindow time.Duration
+}
+
+// Run executes all stages and returns their verdicts. Nodes are stopped starting with the highest index and gateway
+// nodes are never stopped, so that requests can still reach the DON. All stopped nodes are started again before returning.
+// Returned error is not nil only if the scenario couldn't be executed, use QuorumVerdicts.Failed() to assert results.
+func (s *QuorumDegradationScenario) Run(ctx context.Context) (verdicts QuorumVerdicts, err error) {
+	if s.Check == nil {
+		return nil, errors.New("check function is required")
+	}
+	if s.Don == nil {
+		return nil, errors.New("DON is required")
+	}
+
+	stages := s.Stages
+	if len(stages) == 0 {
+		stages = QuorumDegradationStages(s.Don.WorkersCount(), int(s.Don.F))
+	}
+	checkTimeout := s.CheckTimeout
+	if checkTimeout == 0 {
+		checkTimeout = DefaultQuorumCheckTimeout
+	}
+	haltedWindow := s.HaltedWindow
+	if haltedWindow == 0 {
+		haltedWindow = DefaultQuorumHaltedWindow
+	}
+
+	candidates, candidatesErr := s.stoppableNodes()
+	if candidatesErr != nil {
+		return nil, candidatesErr
+	}
+	for _, stage := range stages {
+		if stage.NodesDown > len(candidates) {
+			return nil, fmt.Errorf("stage '%s' needs %d nodes down, but DON %s has only %d non-gateway worker nodes", stage.Name, stage.NodesDown, s.Don.Name, len(candidates))
+		}
+	}
+
+	var stopped []*Node
+	defer func() {
+		if _, startErr := s.resize(context.WithoutCancel(ctx), candidates, stopped, 0); startErr != nil && err == nil {
+			err = errors.Wrap(startErr, "failed to start stopped nodes after the scenario")
+		}
+	}()
+
+	for _, stage := range stages {
+		started := time.Now()
+		verdict := &QuorumVerdict{Stage: stage}
+		verdicts = append(verdicts, verdict)
+
+		var resizeErr error
+		stopped, resizeErr = s.resize(ctx, candidates, stopped, stage.NodesDown)
+		for _, node := range stopped {
+			verdict.StoppedNodes = append(verdict.StoppedNodes, node.Name)
+		}
+		if resizeErr != nil {
+			verdict.Err = resizeErr
+			verdict.Duration = time.Since(started)
+			return verdicts, errors.Wrapf(resizeErr, "failed to prepare stage '%s'", stage.Name)
+		}
+
+		window := checkTimeout
+		if stage.Expected == QuorumHalted {
+			window = haltedWindow
+		}
+		checkErr := retry.Do(ctx, retry.WithMaxDuration(window, retry.NewConstant(5*time.Second)), func(ctx context.Context) error {
+			if err := s.Check(ctx); err != nil {
+				return retry.RetryableError(err)
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return verdicts, ctx.Err()
+		}
+
+		verdict.Observed = QuorumWorking
+		if checkErr != nil {
+			verdict.Observed = QuorumHalted
+			verdict.Err = checkErr
+		} else if stage.Expected == QuorumHalted {
+			verdict.Err = fmt.Errorf("check succeeded with %d of %d worker nodes down", stage.NodesDown, s.Don.WorkersCount())
+		}
+		verdict.Duration = time.Since(started)
+	}
+
+	return verdicts, nil
+}
+
+func (s *QuorumDegradationScenario) stoppableNodes() ([]*Node, error) {
+	workers, wErr := s.Don.Workers()
+	if wErr != nil {
+		return nil, wErr
+	}
+
+	candidates := make([]*Node, 0, len(workers))
+	for _, worker := range workers {
+		if !worker.Roles.Contains(RoleGateway) {
+			candidates = append(candidates, worker)
+		}
+	}
+	slices.SortFunc(candidates, func(a, b *Node) int { return b.Index - a.Index })
+
+	return candidates, nil
+}
+
+// resize stops or starts nodes, so that exactly nodesDown of candidates are stopped, and returns the stopped ones.
+// Started nodes have to respond to health checks, before the stage begins.
+func (s *QuorumDegradationScenario) resize(ctx context.Context, candidates, stopped []*Node, nodesDown int) ([]*Node, error) {
+	for len(stopped) < nodesDown {
+		node := candidates[len(stopped)]
+		if err := s.Provider.StopNode(ctx, node.Index, s.Don.Name); err != nil {
+			return stopped, errors.Wrapf(err, "failed to stop node %s", node.Name)
+		}
+		stopped = append(stopped, node)
+	}
+
+	for len(stopped) > nodesDown {
+		node := stopped[len(stopped)-1]
+		if err := s.Provider.StartNode(ctx, node.Index, s.Don.Name); err != nil {
+			return stopped, errors.Wrapf(err, "failed to start node %s", node.Name)
+		}
+		stopped = stopped[:len(stopped)-1]
+
+		if err := node.WaitHealthy(ctx, nodeStartTimeout); err != nil {
+			return stopped, err
+		}
+	}
+
+	return stopped, nil
+}
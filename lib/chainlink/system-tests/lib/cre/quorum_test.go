@@ -0,0 +1,28 @@
+package cre
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuorumDegradationStages(t *testing.T) {
+	for _, tc := range []struct {
+		n, f      int
+		tolerated int
+		haltedAt  int
+	}{
+		{n: 4, f: 1, tolerated: 1, haltedAt: 2},
+		{n: 7, f: 2, tolerated: 2, haltedAt: 3},
+		// larger DONs than 3F+1 tolerate more nodes down than F
+		{n: 6, f: 1, tolerated: 2, haltedAt: 3},
+		{n: 10, f: 3, tolerated: 3, haltedAt: 4},
+		{n: 5, f: 1, tolerated: 1, haltedAt: 2},
+	} {
+		stages := QuorumDegradationStages(tc.n, tc.f)
+		assert.Equal(t, []QuorumExpectation{QuorumWorking, QuorumWorking, QuorumHalted, QuorumWorking}, []QuorumExpectation{stages[0].Expected, stages[1].Expected, stages[2].Expected, stages[3].Expected})
+		assert.Equal(t, tc.tolerated, stages[1].NodesDown, "N=%d, F=%d", tc.n, tc.f)
+		assert.Equal(t, tc.haltedAt, stages[2].NodesDown, "N=%d, F=%d", tc.n, tc.f)
+		assert.Zero(t, stages[3].NodesDown)
+	}
+}
@@ -0,0 +1,66 @@
+package cre
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ReadinessCheck is a custom check, which is part of the readiness gating of the environment, so that bespoke
+// components (e.g. custom containers) are waited for the same way as nodes. Check is polled until it returns nil
+// or the component-readiness phase times out.
+type ReadinessCheck struct {
+	Name string
+	// Component is the name of the custom container or host process the check belongs to, its checks gate the start of
+	// nodes. Checks without a component gate the whole environment and run once DONs and features are ready.
+	Component string
+	Check     func(ctx context.Context) error
+}
+
+// HTTPReadinessCheck returns a check, which succeeds once GET of the URL responds with a 2xx status
+func HTTPReadinessCheck(name, component, url string) ReadinessCheck {
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+
+	return ReadinessCheck{
+		Name:      name,
+		Component: component,
+		Check: func(ctx context.Context) error {
+			req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if reqErr != nil {
+				return reqErr
+			}
+			resp, respErr := httpClient.Do(req)
+			if respErr != nil {
+				return respErr
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+			}
+			return nil
+		},
+	}
+}
+
+// WaitForReadinessChecks polls all checks until they succeed, the returned PhaseTimeoutError names the first failing one
+func WaitForReadinessChecks(ctx context.Context, timeouts *PhaseTimeouts, checks []ReadinessCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	byTarget := make(map[string]ReadinessCheck, len(checks))
+	targets := make([]string, 0, len(checks))
+	for _, check := range checks {
+		target := check.Name
+		if check.Component != "" {
+			target = fmt.Sprintf("%s of %s", check.Name, check.Component)
+		}
+		byTarget[target] = check
+		targets = append(targets, target)
+	}
+
+	return WaitForPhase(ctx, timeouts, PhaseComponentReadiness, targets, func(ctx context.Context, target string) error {
+		return byTarget[target].Check(ctx)
+	})
+}
@@ -0,0 +1,131 @@
+package cre
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// SetReadOnly makes clients of all nodes of the DON read-only, see Node.SetReadOnly
+func (d *Don) SetReadOnly() {
+	for _, node := range d.Nodes {
+		node.SetReadOnly()
+	}
+}
+
+// SetReadOnly makes clients of the node read-only: REST requests other than GET, HEAD and OPTIONS and GraphQL mutations
+// fail with infra.ErrReadOnly, so that diagnostic code can't change jobs, keys or configs of a shared environment
+//
+// Experimental: requests allowed in read-only mode may change.
+func (n *Node) SetReadOnly() {
+	if n.readOnly {
+		return
+	}
+	n.readOnly = true
+
+	if n.Clients.RestClient != nil && n.Clients.RestClient.APIClient != nil {
+		n.Clients.RestClient.APIClient.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return nil
+			}
+			// sessions are renewed with POST /sessions, which doesn't change the node
+			if r.Method == http.MethodPost && r.URL == "/sessions" {
+				return nil
+			}
+			return fmt.Errorf("%w: %s %s to node %s is not allowed", infra.ErrReadOnly, r.Method, r.URL, n.Name)
+		})
+	}
+	if n.executor != nil {
+		if _, ok := n.executor.(*infra.ReadOnlyExecutor); !ok {
+			n.executor = &infra.ReadOnlyExecutor{Executor: n.executor}
+		}
+	}
+}
+
+// ReadOnly returns true, if clients of the node are read-only
+func (n *Node) ReadOnly() bool {
+	return n.readOnly
+}
+
+// readOnlyGQLTransport rejects GraphQL mutations sent to the node, once it's read-only. It's the transport of GraphQL clients
+// created by NewNode, so that all mutations are rejected, including ones of job proposals, which return types internal
+// to the client package.
+type readOnlyGQLTransport struct {
+	node    *Node // set once the node is created
+	wrapped http.RoundTripper
+}
+
+func (t *readOnlyGQLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.node == nil || !t.node.readOnly || req.Body == nil {
+		return t.wrapped.RoundTrip(req)
+	}
+
+	body, readErr := io.ReadAll(req.Body)
+	req.Body.Close()
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read GraphQL request to node %s: %w", t.node.Name, readErr)
+	}
+	var gqlRequest struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &gqlRequest); err != nil {
+		return nil, fmt.Errorf("%w: GraphQL request to node %s can't be parsed: %w", infra.ErrReadOnly, t.node.Name, err)
+	}
+	if isGraphQLMutation(gqlRequest.Query) {
+		return nil, fmt.Errorf("%w: GraphQL mutations of node %s are not allowed", infra.ErrReadOnly, t.node.Name)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return t.wrapped.RoundTrip(req)
+}
+
+// isGraphQLMutation returns true, if the GraphQL document defines a mutation, i.e. has the mutation keyword outside of
+// selection sets, strings and comments
+func isGraphQLMutation(document string) bool {
+	depth := 0
+	for i := 0; i < len(document); i++ {
+		switch c := document[i]; {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			for i++; i < len(document) && document[i] != '"'; i++ {
+				if document[i] == '\\' {
+					i++
+				}
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case depth == 0 && isNameStart(c) && (i == 0 || !isNamePart(document[i-1])):
+			end := i
+			for end < len(document) && isNamePart(document[end]) {
+				end++
+			}
+			if document[i:end] == "mutation" {
+				return true
+			}
+			i = end - 1
+		}
+	}
+
+	return false
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,215 @@
+// Package replay records trigger events sent to workflows during a run and feeds them back into a freshly provisioned
+// environment with the same payloads and the same intervals, so that intermittent execution bugs can be reproduced with
+// identical inputs. Events of the mock capability (including cron events it emulates) are recorded with
+// Controller.OnTriggerSent and HTTP trigger requests sent through gateways with gateway.RecordTriggerRequests, e.g.
+//
+//	recorder := replay.NewRecorder()
+//	controller.OnTriggerSent = recorder.RecordMockTrigger
+//	gateway.RecordTriggerRequests(recorder)
+//	defer gateway.RecordTriggerRequests(nil)
+//	...
+//	err := recorder.Save("triggers.json")
+//
+// and replayed with Replayer and MockTriggerSender (or gateway.ReplaySender for HTTP triggers). Cron triggers of the
+// cron capability fire inside nodes on their schedule, so they aren't recorded, they fire the same way in the fresh
+// environment. Emulate them with the mock capability to reproduce exact firing times.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	mockcapability "github.com/smartcontractkit/chainlink/system-tests/lib/cre/mock"
+	pb2 "github.com/smartcontractkit/chainlink/system-tests/lib/cre/mock/pb"
+)
+
+type Kind string
+
+const (
+	// KindMockTrigger is a trigger event sent by the mock capability, TriggerID is the ID of the trigger
+	KindMockTrigger Kind = "mock_trigger"
+	// KindHTTPTrigger is an HTTP trigger request sent through a gateway, TriggerID is the ID of the workflow
+	KindHTTPTrigger Kind = "http_trigger"
+)
+
+// Event is a recorded trigger event, Payload holds outputs of mock trigger events and inputs of HTTP trigger requests
+type Event struct {
+	Kind        Kind      `json:"kind"`
+	TriggerID   string    `json:"trigger_id"`
+	TriggerType string    `json:"trigger_type,omitempty"`
+	EventID     string    `json:"event_id,omitempty"`
+	Payload     []byte    `json:"payload"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+type Recording struct {
+	Events []Event `json:"events"`
+}
+
+// Duration returns the time between the first and the last event
+func (r *Recording) Duration() time.Duration {
+	if len(r.Events) < 2 {
+		return 0
+	}
+
+	return r.Events[len(r.Events)-1].Timestamp.Sub(r.Events[0].Timestamp)
+}
+
+// Load reads a recording saved with Recorder.Save
+func Load(path string) (*Recording, error) {
+	content, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, errors.Wrapf(readErr, "failed to read recording %s", path)
+	}
+
+	recording := &Recording{}
+	if err := json.Unmarshal(content, recording); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse recording %s", path)
+	}
+	for idx, event := range recording.Events {
+		if event.Kind != KindMockTrigger && event.Kind != KindHTTPTrigger {
+			return nil, fmt.Errorf("event %d of recording %s has unknown kind '%s'", idx, path, event.Kind)
+		}
+		if event.TriggerID == "" {
+			return nil, fmt.Errorf("event %d of recording %s has no trigger ID", idx, path)
+		}
+	}
+
+	return recording, nil
+}
+
+// Recorder collects trigger events, it's safe for concurrent use
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Record adds the event, events without a timestamp are recorded at the current time
+func (r *Recorder) Record(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	event.Payload = slices.Clone(event.Payload)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// RecordMockTrigger records a trigger event of the mock capability, it can be set as Controller.OnTriggerSent
+func (r *Recorder) RecordMockTrigger(message *pb2.SendTriggerEventRequest) {
+	r.Record(Event{
+		Kind:        KindMockTrigger,
+		TriggerID:   message.TriggerID,
+		TriggerType: message.TriggerType,
+		EventID:     message.ID,
+		Payload:     message.Outputs,
+	})
+}
+
+// RecordHTTPTrigger records the input of an HTTP trigger request of the workflow. Requests sent with
+// gateway.SendTriggerRequest are recorded automatically, see gateway.RecordTriggerRequests.
+func (r *Recorder) RecordHTTPTrigger(workflowID string, input json.RawMessage) {
+	r.Record(Event{Kind: KindHTTPTrigger, TriggerID: workflowID, Payload: input})
+}
+
+// Recording returns events recorded so far, ordered by their timestamps
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	events := slices.Clone(r.events)
+	r.mu.Unlock()
+
+	slices.SortStableFunc(events, func(a, b Event) int { return a.Timestamp.Compare(b.Timestamp) })
+
+	return &Recording{Events: events}
+}
+
+// Save writes events recorded so far to path as JSON
+func (r *Recorder) Save(path string) error {
+	content, marshalErr := json.MarshalIndent(r.Recording(), "", "  ")
+	if marshalErr != nil {
+		return errors.Wrap(marshalErr, "failed to encode recording")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create directory of recording %s", path)
+	}
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return errors.Wrapf(err, "failed to write recording %s", path)
+	}
+
+	return nil
+}
+
+// SendFunc sends the event to the environment the recording is replayed in
+type SendFunc = func(ctx context.Context, event Event) error
+
+// MockTriggerSender sends events as trigger events of the mock capability to subscribers of their triggers
+func MockTriggerSender(controller *mockcapability.Controller) SendFunc {
+	return func(ctx context.Context, event Event) error {
+		return controller.SendTrigger(ctx, &pb2.SendTriggerEventRequest{
+			TriggerID:   event.TriggerID,
+			TriggerType: event.TriggerType,
+			ID:          event.EventID,
+			Outputs:     event.Payload,
+		})
+	}
+}
+
+// Replayer sends recorded events to a freshly provisioned environment, waiting between them as long as the recorded
+// run did, so that events overlap with executions the same way.
+type Replayer struct {
+	// Senders send events of their kind, replaying an event of a kind without a sender fails
+	Senders map[Kind]SendFunc
+	// optional, replaces recorded trigger IDs, e.g. IDs of workflows, which changed in the new environment
+	TriggerIDs map[string]string
+	// optional, scales intervals between events: 0.5 replays twice as fast, 0 (the default) keeps recorded intervals
+	TimeScale float64
+	// if true, events are sent one after another without waiting
+	Immediate bool
+}
+
+// Replay sends events of the recording in order and returns the error of the first event that couldn't be sent
+func (r *Replayer) Replay(ctx context.Context, recording *Recording) error {
+	for _, event := range recording.Events {
+		if _, ok := r.Senders[event.Kind]; !ok {
+			return fmt.Errorf("no sender of %s events, add one to Senders", event.Kind)
+		}
+	}
+
+	timeScale := r.TimeScale
+	if timeScale <= 0 {
+		timeScale = 1
+	}
+	start := time.Now()
+	for idx, event := range recording.Events {
+		if !r.Immediate && idx > 0 {
+			due := start.Add(time.Duration(float64(event.Timestamp.Sub(recording.Events[0].Timestamp)) * timeScale))
+			select {
+			case <-ctx.Done():
+				return errors.Wrapf(ctx.Err(), "replay interrupted before event %d of %d", idx+1, len(recording.Events))
+			case <-time.After(time.Until(due)):
+			}
+		}
+
+		if replaced, ok := r.TriggerIDs[event.TriggerID]; ok {
+			event.TriggerID = replaced
+		}
+		if err := r.Senders[event.Kind](ctx, event); err != nil {
+			return errors.Wrapf(err, "failed to replay %s event %d of %d (trigger %s)", event.Kind, idx+1, len(recording.Events), event.TriggerID)
+		}
+	}
+
+	return nil
+}
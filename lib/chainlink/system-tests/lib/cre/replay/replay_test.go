@@ -0,0 +1,76 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb2 "github.com/smartcontractkit/chainlink/system-tests/lib/cre/mock/pb"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	start := time.Now()
+	recorder := NewRecorder()
+	recorder.Record(Event{Kind: KindHTTPTrigger, TriggerID: "workflow-1", Payload: []byte(`{"n":2}`), Timestamp: start.Add(200 * time.Millisecond)})
+	recorder.RecordMockTrigger(&pb2.SendTriggerEventRequest{TriggerID: "cron-trigger@1.0.0", TriggerType: "cron", ID: "event-1", Outputs: []byte{1, 2, 3}})
+	recording := recorder.Recording()
+	recording.Events[0].Timestamp = start
+	require.Equal(t, KindMockTrigger, recording.Events[0].Kind, "events are ordered by timestamps")
+
+	path := filepath.Join(t.TempDir(), "recordings", "triggers.json")
+	require.NoError(t, (&Recorder{events: recording.Events}).Save(path))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Events, 2)
+	assert.Equal(t, []byte{1, 2, 3}, loaded.Events[0].Payload)
+	assert.Equal(t, "event-1", loaded.Events[0].EventID)
+	assert.Equal(t, 200*time.Millisecond, loaded.Duration())
+
+	t.Run("events are replayed with recorded intervals", func(t *testing.T) {
+		var sent []Event
+		var sentAt []time.Time
+		send := func(_ context.Context, event Event) error {
+			sent = append(sent, event)
+			sentAt = append(sentAt, time.Now())
+			return nil
+		}
+		replayer := &Replayer{
+			Senders:    map[Kind]SendFunc{KindMockTrigger: send, KindHTTPTrigger: send},
+			TriggerIDs: map[string]string{"workflow-1": "workflow-2"},
+		}
+
+		require.NoError(t, replayer.Replay(context.Background(), loaded))
+		require.Len(t, sent, 2)
+		assert.Equal(t, "cron-trigger@1.0.0", sent[0].TriggerID)
+		assert.Equal(t, "workflow-2", sent[1].TriggerID, "trigger IDs are replaced")
+		assert.JSONEq(t, `{"n":2}`, string(json.RawMessage(sent[1].Payload)))
+		assert.GreaterOrEqual(t, sentAt[1].Sub(sentAt[0]), 150*time.Millisecond)
+	})
+
+	t.Run("events without a sender fail before any is sent", func(t *testing.T) {
+		sent := 0
+		replayer := &Replayer{Senders: map[Kind]SendFunc{KindMockTrigger: func(context.Context, Event) error {
+			sent++
+			return nil
+		}}}
+
+		require.ErrorContains(t, replayer.Replay(context.Background(), loaded), "no sender of http_trigger events")
+		assert.Zero(t, sent)
+	})
+
+	t.Run("cancelled replays stop", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		send := func(context.Context, Event) error {
+			cancel()
+			return nil
+		}
+		replayer := &Replayer{Senders: map[Kind]SendFunc{KindMockTrigger: send, KindHTTPTrigger: send}}
+
+		require.ErrorContains(t, replayer.Replay(ctx, loaded), "replay interrupted before event 2 of 2")
+	})
+}
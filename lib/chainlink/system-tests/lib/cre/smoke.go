@@ -0,0 +1,163 @@
+package cre
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/logs"
+)
+
+const DefaultSmokeCheckTimeout = 2 * time.Minute
+
+// SmokeChecker can be implemented by features to verify that the capability works, right after the environment is ready.
+// Checks are executed for every DON with the feature's flag, so that misconfigured capabilities are caught at environment
+// level instead of deep inside specific tests.
+type SmokeChecker interface {
+	SmokeCheck(
+		ctx context.Context,
+		testLogger zerolog.Logger,
+		don *Don,
+		dons *Dons,
+		creEnv *Environment,
+	) error
+}
+
+// RunSmokeChecks runs smoke checks of all features that implement SmokeChecker and returns an error listing all failed ones
+func RunSmokeChecks(ctx context.Context, testLogger zerolog.Logger, features Features, dons *Dons, creEnv *Environment) error {
+	var failures []string
+	for _, feature := range features.List() {
+		checker, ok := feature.(SmokeChecker)
+		if !ok {
+			continue
+		}
+
+		for _, don := range dons.DonsWithFlag(feature.Flag()) {
+			testLogger.Info().Msgf("Running smoke check of capability %s for don '%s'", feature.Flag(), don.Name)
+			if err := checker.SmokeCheck(ctx, testLogger, don, dons, creEnv); err != nil {
+				failures = append(failures, fmt.Sprintf("%s (don '%s'): %s", feature.Flag(), don.Name, err))
+				continue
+			}
+			testLogger.Info().Msgf("Smoke check of capability %s for don '%s' passed", feature.Flag(), don.Name)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("smoke checks failed for capabilities:\n%s", strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// WaitForCapabilityJobs waits until every worker node of the DON has a job with given name, which has no errors reported
+// by the node. It's a building block for smoke checks of capabilities that run as standard capability jobs.
+func WaitForCapabilityJobs(ctx context.Context, don *Don, jobName string, timeout time.Duration) error {
+	workers, wErr := don.Workers()
+	if wErr != nil {
+		return errors.Wrap(wErr, "failed to find worker nodes")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		var lastErr error
+		for _, worker := range workers {
+			if err := checkCapabilityJob(worker, jobName); err != nil {
+				lastErr = err
+				break
+			}
+		}
+		if lastErr == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(lastErr, "capability job '%s' isn't healthy after %s", jobName, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkCapabilityJob(node *Node, jobName string) error {
+	jobs, _, err := node.Clients.RestClient.ReadJobs()
+	if err != nil {
+		return errors.Wrapf(err, "failed to read jobs of node %s", node.Name)
+	}
+
+	for _, job := range jobs.Data {
+		attributes, _ := job["attributes"].(map[string]any)
+		if attributes == nil || attributes["name"] != jobName {
+			continue
+		}
+
+		if jobErrors, _ := attributes["errors"].([]any); len(jobErrors) > 0 {
+			return fmt.Errorf("job '%s' on node %s has errors: %v", jobName, node.Name, jobErrors)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("job '%s' not found on node %s", jobName, node.Name)
+}
+
+// WaitForWorkflowExecution waits until a node of the DON starts an execution of the workflow after since and returns the
+// earliest one. Executions are read from logs of Docker containers, so it can only be used in Docker environments.
+func WaitForWorkflowExecution(ctx context.Context, don *Don, workflowID string, since time.Time, timeout time.Duration) (*logs.ExecutionStart, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		nodeLogs, logsErr := logs.ReadDockerNodeLogs(ns.NodeNamePrefix(don.Name))
+		if logsErr != nil {
+			return nil, errors.Wrapf(logsErr, "failed to read logs of don '%s'", don.Name)
+		}
+
+		var starts []logs.ExecutionStart
+		for node, content := range nodeLogs {
+			nodeStarts, parseErr := logs.ParseExecutionStarts(node, content)
+			if parseErr != nil {
+				return nil, errors.Wrapf(parseErr, "failed to parse logs of node %s", node)
+			}
+			starts = append(starts, nodeStarts...)
+		}
+		if start := firstExecutionStart(starts, workflowID, since); start != nil {
+			return start, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no execution of workflow %s started on don '%s' within %s", workflowID, don.Name, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// firstExecutionStart returns the earliest execution of the workflow started after since, or nil if there's none
+func firstExecutionStart(starts []logs.ExecutionStart, workflowID string, since time.Time) *logs.ExecutionStart {
+	var first *logs.ExecutionStart
+	for i := range starts {
+		start := &starts[i]
+		if start.WorkflowID != workflowID || start.Timestamp.Before(since) {
+			continue
+		}
+		if first == nil || start.Timestamp.Before(first.Timestamp) {
+			first = start
+		}
+	}
+
+	return first
+}
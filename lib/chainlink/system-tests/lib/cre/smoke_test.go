@@ -0,0 +1,27 @@
+package cre
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/logs"
+)
+
+func TestFirstExecutionStart(t *testing.T) {
+	registeredAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	starts := []logs.ExecutionStart{
+		{Node: "workflow-node1", WorkflowID: "wf", ExecutionID: "before", Timestamp: registeredAt.Add(-time.Second)},
+		{Node: "workflow-node1", WorkflowID: "other", ExecutionID: "other", Timestamp: registeredAt.Add(time.Second)},
+		{Node: "workflow-node2", WorkflowID: "wf", ExecutionID: "second", Timestamp: registeredAt.Add(20 * time.Second)},
+		{Node: "workflow-node1", WorkflowID: "wf", ExecutionID: "first", Timestamp: registeredAt.Add(10 * time.Second)},
+	}
+
+	first := firstExecutionStart(starts, "wf", registeredAt)
+	require.NotNil(t, first)
+	require.Equal(t, "first", first.ExecutionID)
+
+	require.Nil(t, firstExecutionStart(starts, "wf", registeredAt.Add(time.Minute)))
+	require.Nil(t, firstExecutionStart(starts, "missing", registeredAt))
+}
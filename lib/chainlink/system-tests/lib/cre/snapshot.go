@@ -0,0 +1,257 @@
+package cre
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+// NodeSetSnapshotVersion is the version of the snapshot format, snapshots of other versions can't be restored
+const NodeSetSnapshotVersion = 1
+
+const snapshotHealthTimeout = 5 * time.Second
+
+// NodeSetSnapshot is a fully provisioned nodeset saved to a file, so that later runs can reuse its running nodes instead
+// of provisioning new ones. The nodeset includes its output and node secrets, which rehydrate keys of nodes, so the file
+// has to be treated as a secret. Nodes record what was running at the time of the snapshot, Verify compares it with
+// what's running now.
+type NodeSetSnapshot struct {
+	Version   int       `toml:"version"`
+	CreatedAt time.Time `toml:"created_at"`
+	// Fingerprint of the environment definition the nodeset was provisioned for, see environment.Fingerprint
+	Fingerprint string                    `toml:"fingerprint"`
+	DonID       uint64                    `toml:"don_id"`
+	Provider    infra.Provider            `toml:"provider"`
+	NodeSet     *CapabilitiesAwareNodeSet `toml:"nodeset"`
+	Nodes       []*NodeSnapshot           `toml:"nodes"`
+}
+
+type NodeSnapshot struct {
+	Index  int    `toml:"index"`
+	Alias  string `toml:"alias"`
+	PeerID string `toml:"peer_id"`
+	// Container is the name of the node's Docker container, empty in other infra
+	Container string `toml:"container"`
+	Image     string `toml:"image"`
+	// BinaryDigests maps paths of capability binaries in the node's container to their SHA-256 checksums
+	BinaryDigests map[string]string `toml:"binary_digests"`
+}
+
+// NewNodeSetSnapshot captures the nodeset after it was started and donMetadata created from it. Checksums of
+// capability binaries are computed from their files on the host, which have to be the ones copied to nodes.
+func NewNodeSetSnapshot(ctx context.Context, nodeSet *CapabilitiesAwareNodeSet, donMetadata *DonMetadata, provider infra.Provider, fingerprint string) (*NodeSetSnapshot, error) {
+	if nodeSet == nil || nodeSet.Input == nil {
+		return nil, errors.New("nodeset is empty")
+	}
+	if nodeSet.Out == nil || len(nodeSet.Out.CLNodes) != len(nodeSet.NodeSpecs) {
+		return nil, fmt.Errorf("nodeset %s has no output of all its nodes, only started nodesets can be snapshotted", nodeSet.Name)
+	}
+	if len(donMetadata.NodesMetadata) != len(nodeSet.NodeSpecs) {
+		return nil, fmt.Errorf("metadata of DON %s has %d nodes, but nodeset %s has %d", donMetadata.Name, len(donMetadata.NodesMetadata), nodeSet.Name, len(nodeSet.NodeSpecs))
+	}
+
+	snapshot := &NodeSetSnapshot{
+		Version:     NodeSetSnapshotVersion,
+		CreatedAt:   time.Now().UTC(),
+		Fingerprint: fingerprint,
+		DonID:       donMetadata.ID,
+		Provider:    provider,
+		NodeSet:     nodeSet,
+	}
+	for idx, nodeSpec := range nodeSet.NodeSpecs {
+		node := &NodeSnapshot{
+			Index:         idx,
+			Alias:         donMetadata.NodesMetadata[idx].Alias,
+			PeerID:        donMetadata.NodesMetadata[idx].PeerID(),
+			Image:         nodeSpec.Node.Image,
+			BinaryDigests: make(map[string]string, len(nodeSpec.Node.CapabilitiesBinaryPaths)),
+		}
+		if provider.IsDocker() {
+			node.Container = nodeSet.Out.CLNodes[idx].Node.ContainerName
+			state, stateErr := infra.InspectDockerContainer(ctx, node.Container)
+			if stateErr != nil {
+				return nil, errors.Wrapf(stateErr, "failed to inspect node %d of nodeset %s", idx, nodeSet.Name)
+			}
+			node.Image = state.Image
+		}
+
+		containerDir, dirErr := snapshotContainerDir(provider, nodeSpec.Node.CapabilityContainerDir)
+		if dirErr != nil {
+			return nil, dirErr
+		}
+		for _, binaryPath := range nodeSpec.Node.CapabilitiesBinaryPaths {
+			digest, digestErr := binaries.FileDigest(binaryPath)
+			if digestErr != nil {
+				return nil, errors.Wrapf(digestErr, "failed to compute checksum of capability binary %s", binaryPath)
+			}
+			node.BinaryDigests[path.Join(containerDir, filepath.Base(binaryPath))] = digest
+		}
+		snapshot.Nodes = append(snapshot.Nodes, node)
+	}
+
+	return snapshot, nil
+}
+
+// Save writes the snapshot to path as TOML, readable only by the current user
+func (s *NodeSetSnapshot) Save(path string) error {
+	content, marshalErr := toml.Marshal(s)
+	if marshalErr != nil {
+		return errors.Wrap(marshalErr, "failed to encode snapshot")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create directory of snapshot %s", path)
+	}
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return errors.Wrapf(err, "failed to write snapshot %s", path)
+	}
+
+	return nil
+}
+
+// LoadNodeSetSnapshot reads a snapshot saved with Save, it doesn't check the running infra, see Restore
+func LoadNodeSetSnapshot(path string) (*NodeSetSnapshot, error) {
+	content, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, errors.Wrapf(readErr, "failed to read snapshot %s", path)
+	}
+
+	snapshot := &NodeSetSnapshot{}
+	if err := toml.Unmarshal(content, snapshot); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse snapshot %s", path)
+	}
+	if snapshot.Version != NodeSetSnapshotVersion {
+		return nil, fmt.Errorf("snapshot %s has version %d, but only version %d is supported", path, snapshot.Version, NodeSetSnapshotVersion)
+	}
+	if snapshot.NodeSet == nil || snapshot.NodeSet.Input == nil || snapshot.NodeSet.Out == nil {
+		return nil, fmt.Errorf("snapshot %s has no started nodeset", path)
+	}
+	if len(snapshot.Nodes) != len(snapshot.NodeSet.NodeSpecs) || len(snapshot.NodeSet.Out.CLNodes) != len(snapshot.NodeSet.NodeSpecs) {
+		return nil, fmt.Errorf("snapshot %s has %d nodes and %d node outputs, but nodeset %s has %d node specs", path, len(snapshot.Nodes), len(snapshot.NodeSet.Out.CLNodes), snapshot.NodeSet.Name, len(snapshot.NodeSet.NodeSpecs))
+	}
+	if err := snapshot.NodeSet.ParseChainCapabilities(); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse chain capabilities of nodeset %s in snapshot %s", snapshot.NodeSet.Name, path)
+	}
+
+	return snapshot, nil
+}
+
+// Restore rehydrates the nodeset and metadata of its DON, so that they can be used like ones of a freshly provisioned
+// environment, e.g. with NewDON. Restoring fails, if the snapshot was taken for an environment with another fingerprint,
+// if keys rehydrated from node secrets don't match peer IDs in the snapshot or if the running infra doesn't match the
+// snapshot, see Verify.
+func (s *NodeSetSnapshot) Restore(ctx context.Context, fingerprint string) (*CapabilitiesAwareNodeSet, *DonMetadata, error) {
+	if s.Fingerprint != fingerprint {
+		return nil, nil, fmt.Errorf("snapshot of nodeset %s was taken for environment %s, but the current one is %s", s.NodeSet.Name, s.Fingerprint, fingerprint)
+	}
+
+	donMetadata, metadataErr := NewDonMetadata(s.NodeSet, s.DonID, s.Provider)
+	if metadataErr != nil {
+		return nil, nil, errors.Wrapf(metadataErr, "failed to restore metadata of DON %s", s.NodeSet.Name)
+	}
+
+	var problems []string
+	for idx, node := range s.Nodes {
+		if peerID := donMetadata.NodesMetadata[idx].PeerID(); peerID != node.PeerID {
+			problems = append(problems, fmt.Sprintf("node %d: restored peer ID %s differs from snapshotted %s, secrets of the node weren't saved", idx, peerID, node.PeerID))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, nil, fmt.Errorf("keys of nodeset %s can't be restored:\n%s", s.NodeSet.Name, strings.Join(problems, "\n"))
+	}
+
+	if err := s.Verify(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return s.NodeSet, donMetadata, nil
+}
+
+// Verify checks that nodes of the snapshot still run: in Docker their containers have to run the snapshotted images,
+// in any infra their APIs have to report that they are healthy and their capability binaries have to match snapshotted
+// checksums. All mismatches are returned at once.
+func (s *NodeSetSnapshot) Verify(ctx context.Context) error {
+	var problems []string
+	for idx, node := range s.Nodes {
+		if s.Provider.IsDocker() {
+			state, stateErr := infra.InspectDockerContainer(ctx, node.Container)
+			switch {
+			case stateErr != nil:
+				problems = append(problems, fmt.Sprintf("node %d: %s", idx, stateErr))
+				continue
+			case !state.Running:
+				problems = append(problems, fmt.Sprintf("node %d: container %s isn't running", idx, node.Container))
+				continue
+			case state.Image != node.Image:
+				problems = append(problems, fmt.Sprintf("node %d: container %s runs image %s, but %s was snapshotted", idx, node.Container, state.Image, node.Image))
+			}
+		}
+
+		if err := checkNodeAPI(ctx, s.NodeSet.Out.CLNodes[idx].Node.ExternalURL); err != nil {
+			problems = append(problems, fmt.Sprintf("node %d: %s", idx, err))
+		}
+
+		executor := s.Provider.NodeExecutor(idx, s.NodeSet.Name)
+		for path, digest := range node.BinaryDigests {
+			result, execErr := executor.Exec(ctx, []string{"sha256sum", path}, infra.ExecOptions{})
+			switch {
+			case execErr != nil:
+				problems = append(problems, fmt.Sprintf("node %d: failed to compute checksum of %s: %s", idx, path, execErr))
+			case !result.Succeeded():
+				problems = append(problems, fmt.Sprintf("node %d: capability binary %s is missing: %s", idx, path, strings.TrimSpace(result.Stderr)))
+			case !strings.HasPrefix(result.Stdout, digest):
+				problems = append(problems, fmt.Sprintf("node %d: capability binary %s was replaced since the snapshot", idx, path))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("running nodeset %s doesn't match the snapshot taken at %s:\n%s", s.NodeSet.Name, s.CreatedAt.Format(time.RFC3339), strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// checkNodeAPI expects a successful response of the node's health endpoint
+func checkNodeAPI(ctx context.Context, externalURL string) error {
+	healthCtx, cancel := context.WithTimeout(ctx, snapshotHealthTimeout)
+	defer cancel()
+
+	request, requestErr := http.NewRequestWithContext(healthCtx, http.MethodGet, strings.TrimSuffix(externalURL, "/")+"/health", nil)
+	if requestErr != nil {
+		return errors.Wrapf(requestErr, "invalid node URL %s", externalURL)
+	}
+	response, responseErr := http.DefaultClient.Do(request)
+	if responseErr != nil {
+		return errors.Wrapf(responseErr, "API at %s doesn't respond", externalURL)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("API at %s isn't healthy, it responded with %s", externalURL, response.Status)
+	}
+
+	return nil
+}
+
+// snapshotContainerDir returns the directory of capability binaries of the node spec in its container (or pod)
+func snapshotContainerDir(provider infra.Provider, configured string) (string, error) {
+	if configured != "" {
+		return strings.TrimSuffix(configured, "/"), nil
+	}
+	containerDir, ok := infra.CapabilitiesContainerDir(provider.Type)
+	if !ok {
+		return "", fmt.Errorf("unknown infra type: %s", provider.Type)
+	}
+
+	return containerDir, nil
+}
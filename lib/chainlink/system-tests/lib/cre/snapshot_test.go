@@ -0,0 +1,122 @@
+package cre
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+var snapshotProvider = infra.Provider{Type: infra.Kubernetes, Kubernetes: &infra.KubernetesInput{Namespace: "cre"}}
+
+const snapshotFingerprint = "3f1c"
+
+// startedNodeSet returns a started nodeset of two nodes, whose APIs respond with given status, and metadata of its DON.
+// Secrets of nodes are set, like the environment does.
+func startedNodeSet(t *testing.T, healthStatus int) (*CapabilitiesAwareNodeSet, *DonMetadata) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(healthStatus)
+	}))
+	t.Cleanup(server.Close)
+
+	nodeSet := &CapabilitiesAwareNodeSet{
+		Input:              &ns.Input{Name: "workflow", Out: &ns.Output{}},
+		DONTypes:           []string{WorkflowDON},
+		BootstrapNodeIndex: -1,
+		GatewayNodeIndex:   -1,
+	}
+	for range 2 {
+		nodeSet.NodeSpecs = append(nodeSet.NodeSpecs, &clnode.Input{Node: &clnode.NodeInput{Image: "chainlink:test"}})
+		nodeSet.Out.CLNodes = append(nodeSet.Out.CLNodes, &clnode.Output{Node: &clnode.NodeOut{ExternalURL: server.URL}})
+	}
+	nodeSet.Nodes = len(nodeSet.NodeSpecs)
+
+	donMetadata, err := NewDonMetadata(nodeSet, 1, snapshotProvider)
+	require.NoError(t, err)
+	for idx, node := range donMetadata.NodesMetadata {
+		nodeSecrets, secretsErr := node.Keys.ToNodeSecretsTOML()
+		require.NoError(t, secretsErr)
+		nodeSet.NodeSpecs[idx].Node.TestSecretsOverrides = nodeSecrets
+	}
+
+	return nodeSet, donMetadata
+}
+
+func TestNodeSetSnapshotRoundTrip(t *testing.T) {
+	nodeSet, donMetadata := startedNodeSet(t, http.StatusOK)
+	snapshot, err := NewNodeSetSnapshot(t.Context(), nodeSet, donMetadata, snapshotProvider, snapshotFingerprint)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "snapshots", "workflow.toml")
+	require.NoError(t, snapshot.Save(path))
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), stat.Mode().Perm(), "snapshot has secrets of nodes")
+
+	loaded, err := LoadNodeSetSnapshot(path)
+	require.NoError(t, err)
+	restoredNodeSet, restoredMetadata, err := loaded.Restore(t.Context(), snapshotFingerprint)
+	require.NoError(t, err)
+
+	assert.Equal(t, nodeSet.Name, restoredNodeSet.Name)
+	assert.Equal(t, donMetadata.ID, restoredMetadata.ID)
+	for idx, node := range restoredMetadata.NodesMetadata {
+		assert.Equal(t, donMetadata.NodesMetadata[idx].PeerID(), node.PeerID())
+		assert.Equal(t, donMetadata.NodesMetadata[idx].Alias, node.Alias)
+	}
+}
+
+func TestNodeSetSnapshotMismatch(t *testing.T) {
+	t.Run("secrets weren't saved", func(t *testing.T) {
+		nodeSet, donMetadata := startedNodeSet(t, http.StatusOK)
+		snapshot, err := NewNodeSetSnapshot(t.Context(), nodeSet, donMetadata, snapshotProvider, snapshotFingerprint)
+		require.NoError(t, err)
+		nodeSet.NodeSpecs[1].Node.TestSecretsOverrides = ""
+
+		_, _, err = snapshot.Restore(t.Context(), snapshotFingerprint)
+		require.ErrorContains(t, err, "node 1: restored peer ID")
+		assert.NotContains(t, err.Error(), "node 0:")
+	})
+
+	t.Run("other environment", func(t *testing.T) {
+		nodeSet, donMetadata := startedNodeSet(t, http.StatusOK)
+		snapshot, err := NewNodeSetSnapshot(t.Context(), nodeSet, donMetadata, snapshotProvider, snapshotFingerprint)
+		require.NoError(t, err)
+
+		_, _, err = snapshot.Restore(t.Context(), "9a0e")
+		require.ErrorContains(t, err, "was taken for environment 3f1c, but the current one is 9a0e")
+	})
+
+	t.Run("nodes aren't healthy", func(t *testing.T) {
+		nodeSet, donMetadata := startedNodeSet(t, http.StatusServiceUnavailable)
+		snapshot, err := NewNodeSetSnapshot(t.Context(), nodeSet, donMetadata, snapshotProvider, snapshotFingerprint)
+		require.NoError(t, err)
+
+		err = snapshot.Verify(t.Context())
+		require.ErrorContains(t, err, "node 0: API at")
+		require.ErrorContains(t, err, "responded with 503 Service Unavailable")
+	})
+
+	t.Run("other version", func(t *testing.T) {
+		nodeSet, donMetadata := startedNodeSet(t, http.StatusOK)
+		snapshot, err := NewNodeSetSnapshot(t.Context(), nodeSet, donMetadata, snapshotProvider, snapshotFingerprint)
+		require.NoError(t, err)
+		snapshot.Version = NodeSetSnapshotVersion + 1
+
+		path := filepath.Join(t.TempDir(), "workflow.toml")
+		require.NoError(t, snapshot.Save(path))
+		_, err = LoadNodeSetSnapshot(path)
+		require.ErrorContains(t, err, "only version 1 is supported")
+	})
+}
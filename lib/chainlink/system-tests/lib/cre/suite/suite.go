@@ -0,0 +1,332 @@
+// Package suite runs a configured list of topology and scenario combinations one after another, e.g. in a nightly job,
+// so that big topologies are covered regularly. Images are pulled once for the whole suite and results of all entries
+// are written to a single JSON report and a JUnit XML file, which CI systems can display.
+package suite
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/notify"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+const (
+	DefaultEntryTimeout = 2 * time.Hour
+	ReportFilename      = "report.json"
+	JUnitFilename       = "junit.xml"
+)
+
+type Config struct {
+	Name      string `toml:"name"`
+	ReportDir string `toml:"report_dir"` // report.json, junit.xml and logs of entries run with GoTestRun
+	// Images are pulled once before the first entry, entries find them in the local image cache. Images must not be
+	// removed between entries, e.g. by cleanup of the environment.
+	Images []string `toml:"images"`
+	// StopOnFailure skips remaining entries once an entry fails, by default all entries are run
+	StopOnFailure bool     `toml:"stop_on_failure"`
+	Entries       []*Entry `toml:"entries"`
+}
+
+type Entry struct {
+	Name     string `toml:"name"`
+	Topology string `toml:"topology"` // path of the environment TOML config (or configs, comma-separated)
+	Scenario string `toml:"scenario"` // e.g. name of the Go test run against the environment
+	Timeout  string `toml:"timeout"`  // Go duration, DefaultEntryTimeout is used if not set
+	Skip     bool   `toml:"skip"`     // keeps the entry in reports, e.g. while a known issue is fixed
+}
+
+func (c *Config) Validate() error {
+	if c.Name == "" {
+		return errors.New("name of the suite is required")
+	}
+	if c.ReportDir == "" {
+		return errors.New("report_dir of the suite is required")
+	}
+	if len(c.Entries) == 0 {
+		return errors.New("suite needs at least one entry")
+	}
+
+	seen := make(map[string]bool, len(c.Entries))
+	for idx, entry := range c.Entries {
+		if entry.Name == "" || entry.Topology == "" || entry.Scenario == "" {
+			return fmt.Errorf("entry %d must have a name, topology and scenario", idx)
+		}
+		if seen[entry.Name] {
+			return fmt.Errorf("entry %s is configured more than once", entry.Name)
+		}
+		seen[entry.Name] = true
+		if entry.Timeout != "" {
+			if timeout, err := time.ParseDuration(entry.Timeout); err != nil || timeout <= 0 {
+				return fmt.Errorf("timeout of entry %s must be a positive Go duration, got '%s'", entry.Name, entry.Timeout)
+			}
+		}
+	}
+
+	return nil
+}
+
+// TimeoutDuration returns the timeout of the entry, invalid values fall back to the default, call Validate to catch them
+func (e *Entry) TimeoutDuration() time.Duration {
+	if timeout, err := time.ParseDuration(e.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+
+	return DefaultEntryTimeout
+}
+
+// RunFunc starts the environment of the entry's topology, runs its scenario and cleans the environment up.
+// The context is cancelled once the entry's timeout elapses.
+type RunFunc func(ctx context.Context, entry *Entry) error
+
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+type Result struct {
+	Entry    string        `json:"entry"`
+	Topology string        `json:"topology"`
+	Scenario string        `json:"scenario"`
+	Status   Status        `json:"status"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+}
+
+type Report struct {
+	Suite    string        `json:"suite"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+	Results  []*Result     `json:"results"`
+}
+
+func (r *Report) Count(status Status) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Err returns an error listing failed entries, or nil if none failed
+func (r *Report) Err() error {
+	var failed []string
+	for _, result := range r.Results {
+		if result.Status == StatusFailed {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Entry, result.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d of %d entries of suite failed:\n%s", len(failed), len(r.Results), strings.Join(failed, "\n"))
+}
+
+type Runner struct {
+	config *Config
+	run    RunFunc
+	logger zerolog.Logger
+
+	// Notifier is optional, it's notified when entries start and finish, see notify.New
+	Notifier *notify.Notifier
+	// PullImage pulls images of Config.Images, infra.PullDockerImage is used by default
+	PullImage func(ctx context.Context, image string) error
+}
+
+func New(logger zerolog.Logger, config *Config, run RunFunc) (*Runner, error) {
+	if config == nil {
+		return nil, errors.New("suite config is nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid suite config")
+	}
+	if run == nil {
+		return nil, errors.New("run function of the suite is nil")
+	}
+
+	return &Runner{config: config, run: run, logger: logger, PullImage: infra.PullDockerImage}, nil
+}
+
+// Run runs all entries sequentially and writes the report, even if some entries failed or the context was cancelled.
+// Failed entries don't fail Run, check Report.Err. The error is returned only if images can't be pulled or the report
+// can't be written.
+func (r *Runner) Run(ctx context.Context) (*Report, error) {
+	report := &Report{Suite: r.config.Name, Started: time.Now().UTC()}
+	r.Notifier.Started(ctx, map[string]string{"entries": fmt.Sprintf("%d", len(r.config.Entries))})
+
+	for _, image := range r.config.Images {
+		r.logger.Info().Msgf("Pulling image %s for all entries of suite %s", image, r.config.Name)
+		if err := r.PullImage(ctx, image); err != nil {
+			pullErr := errors.Wrapf(err, "failed to pull image %s", image)
+			r.Notifier.Finished(ctx, pullErr)
+			return nil, pullErr
+		}
+	}
+
+	stopped := ""
+	for idx, entry := range r.config.Entries {
+		result := &Result{Entry: entry.Name, Topology: entry.Topology, Scenario: entry.Scenario, Status: StatusSkipped}
+		report.Results = append(report.Results, result)
+
+		switch {
+		case entry.Skip:
+			result.Error = "skipped in the suite config"
+			continue
+		case stopped != "":
+			result.Error = stopped
+			continue
+		case ctx.Err() != nil:
+			result.Error = "suite was cancelled"
+			continue
+		}
+
+		r.logger.Info().Msgf("Running entry %d/%d of suite %s: %s (topology %s, scenario %s)", idx+1, len(r.config.Entries), r.config.Name, entry.Name, entry.Topology, entry.Scenario)
+		startTime := time.Now()
+		entryCtx, cancel := context.WithTimeout(ctx, entry.TimeoutDuration())
+		runErr := r.run(entryCtx, entry)
+		if runErr == nil && entryCtx.Err() != nil && ctx.Err() == nil {
+			// run functions that ignore the context must not pass after their timeout
+			runErr = fmt.Errorf("entry didn't finish within %s", entry.TimeoutDuration())
+		}
+		cancel()
+		result.Duration = time.Since(startTime)
+
+		result.Status = StatusPassed
+		if runErr != nil {
+			result.Status, result.Error = StatusFailed, runErr.Error()
+			r.logger.Error().Err(runErr).Msgf("Entry %s of suite %s failed after %s", entry.Name, r.config.Name, result.Duration.Round(time.Second))
+			r.Notifier.Failure(ctx, fmt.Errorf("entry %s failed: %w", entry.Name, runErr), map[string]string{"topology": entry.Topology, "scenario": entry.Scenario})
+			if r.config.StopOnFailure {
+				stopped = fmt.Sprintf("skipped after failure of entry %s", entry.Name)
+			}
+			continue
+		}
+		r.logger.Info().Msgf("Entry %s of suite %s passed in %s", entry.Name, r.config.Name, result.Duration.Round(time.Second))
+		r.Notifier.Milestone(ctx, fmt.Sprintf("entry %s passed", entry.Name), map[string]string{"progress": fmt.Sprintf("%d/%d", idx+1, len(r.config.Entries))})
+	}
+	report.Duration = time.Since(report.Started)
+
+	if err := WriteReport(r.config.ReportDir, report); err != nil {
+		return report, err
+	}
+	r.logger.Info().Msgf("Suite %s finished: %d passed, %d failed, %d skipped. Report: %s", r.config.Name, report.Count(StatusPassed), report.Count(StatusFailed), report.Count(StatusSkipped), filepath.Join(r.config.ReportDir, ReportFilename))
+	r.Notifier.Finished(ctx, report.Err())
+
+	return report, nil
+}
+
+// WriteReport writes the report as JSON and JUnit XML to the directory
+func WriteReport(dir string, report *Report) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create report directory %s", dir)
+	}
+
+	encoded, marshalErr := json.MarshalIndent(report, "", "  ")
+	if marshalErr != nil {
+		return errors.Wrap(marshalErr, "failed to encode report")
+	}
+	if err := os.WriteFile(filepath.Join(dir, ReportFilename), encoded, 0o600); err != nil {
+		return errors.Wrap(err, "failed to write report")
+	}
+
+	junit, junitErr := xml.MarshalIndent(toJUnit(report), "", "  ")
+	if junitErr != nil {
+		return errors.Wrap(junitErr, "failed to encode JUnit report")
+	}
+	if err := os.WriteFile(filepath.Join(dir, JUnitFilename), append([]byte(xml.Header), junit...), 0o600); err != nil {
+		return errors.Wrap(err, "failed to write JUnit report")
+	}
+
+	return nil
+}
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+func toJUnit(report *Report) junitSuite {
+	suite := junitSuite{
+		Name:     report.Suite,
+		Tests:    len(report.Results),
+		Failures: report.Count(StatusFailed),
+		Skipped:  report.Count(StatusSkipped),
+		Time:     fmt.Sprintf("%.3f", report.Duration.Seconds()),
+	}
+	for _, result := range report.Results {
+		testCase := junitCase{Name: result.Entry, Classname: result.Scenario, Time: fmt.Sprintf("%.3f", result.Duration.Seconds())}
+		switch result.Status {
+		case StatusFailed:
+			testCase.Failure = &junitMessage{Message: result.Error}
+		case StatusSkipped:
+			testCase.Skipped = &junitMessage{Message: result.Error}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	return suite
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// GoTestRun returns a run function, which runs the entry's scenario as a Go test in the package directory, with
+// CTF_CONFIGS set to the entry's topology. Output of each entry is written to <logDir>/<entry>.log.
+func GoTestRun(packageDir, logDir string) RunFunc {
+	return func(ctx context.Context, entry *Entry) error {
+		if err := os.MkdirAll(logDir, 0o755); err != nil {
+			return errors.Wrapf(err, "failed to create log directory %s", logDir)
+		}
+		logPath := filepath.Join(logDir, unsafeFilenameChars.ReplaceAllString(entry.Name, "_")+".log")
+		logFile, createErr := os.Create(logPath)
+		if createErr != nil {
+			return errors.Wrapf(createErr, "failed to create log file %s", logPath)
+		}
+		defer logFile.Close()
+
+		// the timeout of go test is slightly longer, so that the context timeout kills the test and its output is kept
+		cmd := exec.CommandContext(ctx, "go", "test", "-v", "-count=1", "-timeout", (entry.TimeoutDuration() + time.Minute).String(), "-run", "^"+entry.Scenario+"$", ".")
+		cmd.Dir = packageDir
+		cmd.Env = append(os.Environ(), "CTF_CONFIGS="+entry.Topology)
+		cmd.Stdout, cmd.Stderr = logFile, logFile
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "scenario %s failed, see %s", entry.Scenario, logPath)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+package suite
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T) *Config {
+	return &Config{
+		Name:      "nightly",
+		ReportDir: t.TempDir(),
+		Images:    []string{"chainlink:nightly"},
+		Entries: []*Entry{
+			{Name: "big-workflow-don", Topology: "configs/big.toml", Scenario: "TestCRE_Proof"},
+			{Name: "multi-chain", Topology: "configs/multi.toml", Scenario: "TestCRE_Write"},
+			{Name: "known-issue", Topology: "configs/big.toml", Scenario: "TestCRE_Vault", Skip: true},
+			{Name: "gateway-ha", Topology: "configs/gateway.toml", Scenario: "TestCRE_HTTP"},
+		},
+	}
+}
+
+func TestRunner(t *testing.T) {
+	config := testConfig(t)
+	var ran []string
+	runner, err := New(zerolog.Nop(), config, func(_ context.Context, entry *Entry) error {
+		ran = append(ran, entry.Name)
+		if entry.Name == "multi-chain" {
+			return errors.New("write didn't reach quorum")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	var pulled []string
+	runner.PullImage = func(_ context.Context, image string) error {
+		pulled = append(pulled, image)
+		return nil
+	}
+
+	report, err := runner.Run(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chainlink:nightly"}, pulled)
+	assert.Equal(t, []string{"big-workflow-don", "multi-chain", "gateway-ha"}, ran)
+	assert.Equal(t, 2, report.Count(StatusPassed))
+	assert.Equal(t, 1, report.Count(StatusFailed))
+	assert.Equal(t, 1, report.Count(StatusSkipped))
+	require.ErrorContains(t, report.Err(), "multi-chain: write didn't reach quorum")
+
+	encoded, err := os.ReadFile(filepath.Join(config.ReportDir, ReportFilename))
+	require.NoError(t, err)
+	var stored Report
+	require.NoError(t, json.Unmarshal(encoded, &stored))
+	assert.Len(t, stored.Results, 4)
+
+	junit, err := os.ReadFile(filepath.Join(config.ReportDir, JUnitFilename))
+	require.NoError(t, err)
+	var suite junitSuite
+	require.NoError(t, xml.Unmarshal(junit, &suite))
+	assert.Equal(t, 4, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, 1, suite.Skipped)
+	require.NotNil(t, suite.Cases[1].Failure)
+	assert.Equal(t, "write didn't reach quorum", suite.Cases[1].Failure.Message)
+
+	t.Run("stop on failure skips remaining entries", func(t *testing.T) {
+		config := testConfig(t)
+		config.StopOnFailure = true
+		runner, err := New(zerolog.Nop(), config, func(_ context.Context, entry *Entry) error {
+			return errors.New("failed")
+		})
+		require.NoError(t, err)
+		runner.PullImage = func(context.Context, string) error { return nil }
+
+		report, err := runner.Run(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Count(StatusFailed))
+		assert.Equal(t, 3, report.Count(StatusSkipped))
+	})
+
+	t.Run("entries that outlive their timeout fail", func(t *testing.T) {
+		config := testConfig(t)
+		config.Images = nil
+		config.Entries = config.Entries[:1]
+		config.Entries[0].Timeout = "10ms"
+		runner, err := New(zerolog.Nop(), config, func(ctx context.Context, _ *Entry) error {
+			<-ctx.Done()
+			return nil
+		})
+		require.NoError(t, err)
+
+		report, err := runner.Run(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, report.Results[0].Status)
+		assert.Contains(t, report.Results[0].Error, "didn't finish within 10ms")
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	for name, mutate := range map[string]func(c *Config){
+		"name":            func(c *Config) { c.Name = "" },
+		"report dir":      func(c *Config) { c.ReportDir = "" },
+		"no entries":      func(c *Config) { c.Entries = nil },
+		"duplicate entry": func(c *Config) { c.Entries[1].Name = c.Entries[0].Name },
+		"no topology":     func(c *Config) { c.Entries[0].Topology = "" },
+		"timeout":         func(c *Config) { c.Entries[0].Timeout = "tomorrow" },
+	} {
+		config := testConfig(t)
+		mutate(config)
+		assert.Error(t, config.Validate(), name)
+	}
+	assert.NoError(t, testConfig(t).Validate())
+}
@@ -0,0 +1,238 @@
+package cre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Phase = string
+
+const (
+	PhaseImagePull          Phase = "image-pull"
+	PhaseNodeReadiness      Phase = "node-readiness"
+	PhaseRegistryConfig     Phase = "registry-config"
+	PhaseJobPropagation     Phase = "job-propagation"
+	PhaseComponentReadiness Phase = "component-readiness" // custom readiness checks, see ReadinessCheck
+)
+
+var DefaultPhaseTimeouts = map[Phase]time.Duration{
+	PhaseImagePull:          10 * time.Minute,
+	PhaseNodeReadiness:      3 * time.Minute,
+	PhaseRegistryConfig:     5 * time.Minute,
+	PhaseJobPropagation:     5 * time.Minute,
+	PhaseComponentReadiness: 3 * time.Minute,
+}
+
+// PhaseTimeouts limits duration of provisioning phases, so that a stuck phase fails fast with an error naming the phase
+// (and the node it waited for) instead of hitting the test-level timeout. Values are Go durations (e.g. "90s", "5m"),
+// defaults from DefaultPhaseTimeouts are used for phases that aren't set.
+type PhaseTimeouts struct {
+	ImagePull          string `toml:"image_pull"`
+	NodeReadiness      string `toml:"node_readiness"`
+	RegistryConfig     string `toml:"registry_config"`
+	JobPropagation     string `toml:"job_propagation"`
+	ComponentReadiness string `toml:"component_readiness"`
+}
+
+func (p *PhaseTimeouts) raw() map[Phase]string {
+	return map[Phase]string{
+		PhaseImagePull:          p.ImagePull,
+		PhaseNodeReadiness:      p.NodeReadiness,
+		PhaseRegistryConfig:     p.RegistryConfig,
+		PhaseJobPropagation:     p.JobPropagation,
+		PhaseComponentReadiness: p.ComponentReadiness,
+	}
+}
+
+func (p *PhaseTimeouts) Validate() error {
+	if p == nil {
+		return nil
+	}
+
+	for phase, value := range p.raw() {
+		if value == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid timeout '%s' of phase %s: %w", value, phase, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("timeout of phase %s must be positive, got %s", phase, value)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the timeout of the phase. It's safe to call on nil PhaseTimeouts, invalid values fall back to defaults,
+// call Validate to catch them.
+func (p *PhaseTimeouts) Get(phase Phase) time.Duration {
+	if p != nil {
+		if timeout, err := time.ParseDuration(p.raw()[phase]); err == nil && timeout > 0 {
+			return timeout
+		}
+	}
+
+	return DefaultPhaseTimeouts[phase]
+}
+
+// PhaseTimeoutError is returned, when a provisioning phase doesn't complete in time. Target is the node (or other resource)
+// that the phase was waiting for, if known.
+type PhaseTimeoutError struct {
+	Phase   Phase
+	Target  string
+	Timeout time.Duration
+	Err     error // last error returned for the target, if any
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	msg := fmt.Sprintf("phase %s timed out after %s", e.Phase, e.Timeout)
+	if e.Target != "" {
+		msg += fmt.Sprintf(" waiting for %s", e.Target)
+	}
+	if e.Err != nil {
+		msg += fmt.Sprintf(": %s", e.Err)
+	}
+
+	return msg
+}
+
+func (e *PhaseTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// phaseCancelGracePeriod is how long RunPhase waits for fn to return after cancelling it on timeout
+const phaseCancelGracePeriod = 10 * time.Second
+
+// PhaseTarget marks err as the error of the target (e.g. a node) that the phase is waiting for. If the phase times out,
+// RunPhase names all targets found in the error returned by fn (including errors joined with errors.Join).
+func PhaseTarget(target string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &phaseTargetError{target: target, err: err}
+}
+
+type phaseTargetError struct {
+	target string
+	err    error
+}
+
+func (e *phaseTargetError) Error() string {
+	return fmt.Sprintf("%s: %s", e.target, e.err)
+}
+
+func (e *phaseTargetError) Unwrap() error {
+	return e.err
+}
+
+// RunPhase runs fn with the phase timeout. If fn doesn't return in time, its context is cancelled and RunPhase waits up to
+// phaseCancelGracePeriod for it to return, so that goroutines started by fn stop and the targets that lagged are named
+// in the PhaseTimeoutError (see PhaseTarget). Functions that don't respect the context are abandoned after the grace period.
+func RunPhase(ctx context.Context, timeouts *PhaseTimeouts, phase Phase, fn func(ctx context.Context) error) error {
+	timeout := timeouts.Get(phase)
+	phaseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(phaseCtx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && errors.Is(err, context.DeadlineExceeded) && phaseCtx.Err() != nil && ctx.Err() == nil {
+			return newPhaseTimeoutError(phase, timeout, err)
+		}
+		return err
+	case <-phaseCtx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		select {
+		case err := <-done:
+			if err == nil {
+				return nil
+			}
+			return newPhaseTimeoutError(phase, timeout, err)
+		case <-time.After(phaseCancelGracePeriod):
+			return &PhaseTimeoutError{Phase: phase, Timeout: timeout}
+		}
+	}
+}
+
+func newPhaseTimeoutError(phase Phase, timeout time.Duration, err error) *PhaseTimeoutError {
+	timeoutErr := &PhaseTimeoutError{Phase: phase, Timeout: timeout, Err: err}
+
+	var targets []string
+	var targetErr error
+	var collect func(err error)
+	collect = func(err error) {
+		switch e := err.(type) { //nolint:errorlint // walks the error tree itself to find all targets, not only the first one
+		case nil:
+		case *phaseTargetError:
+			targets = append(targets, e.target)
+			if targetErr == nil {
+				targetErr = e.err
+			}
+		case interface{ Unwrap() []error }:
+			for _, wrapped := range e.Unwrap() {
+				collect(wrapped)
+			}
+		case interface{ Unwrap() error }:
+			collect(e.Unwrap())
+		}
+	}
+	collect(err)
+
+	if len(targets) > 0 {
+		timeoutErr.Target = strings.Join(targets, ", ")
+		timeoutErr.Err = targetErr
+	}
+
+	return timeoutErr
+}
+
+// WaitForPhase polls check for every target until it succeeds for all of them. If the phase timeout elapses,
+// the returned PhaseTimeoutError names the first target that is still failing.
+func WaitForPhase(ctx context.Context, timeouts *PhaseTimeouts, phase Phase, targets []string, check func(ctx context.Context, target string) error) error {
+	timeout := timeouts.Get(phase)
+	phaseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	pending := targets
+	for {
+		var stillPending []string
+		var lastErr error
+		for _, target := range pending {
+			if err := check(phaseCtx, target); err != nil {
+				if lastErr == nil {
+					lastErr = err
+				}
+				stillPending = append(stillPending, target)
+			}
+		}
+		if len(stillPending) == 0 {
+			return nil
+		}
+		pending = stillPending
+
+		select {
+		case <-phaseCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return &PhaseTimeoutError{Phase: phase, Target: pending[0], Timeout: timeout, Err: lastErr}
+		case <-ticker.C:
+		}
+	}
+}
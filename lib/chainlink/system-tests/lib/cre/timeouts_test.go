@@ -0,0 +1,44 @@
+package cre
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPhaseCancelsAndNamesLaggingTargets(t *testing.T) {
+	var stopped atomic.Int32
+	err := RunPhase(t.Context(), &PhaseTimeouts{JobPropagation: "50ms"}, PhaseJobPropagation, func(ctx context.Context) error {
+		errs := make(chan error, 2)
+		for _, node := range []string{"node-1", "node-2"} {
+			go func() {
+				<-ctx.Done()
+				stopped.Add(1)
+				errs <- PhaseTarget(node, ctx.Err())
+			}()
+		}
+
+		return errors.Join(<-errs, <-errs)
+	})
+
+	var timeoutErr *PhaseTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	require.Equal(t, PhaseJobPropagation, timeoutErr.Phase)
+	require.ElementsMatch(t, []string{"node-1", "node-2"}, strings.Split(timeoutErr.Target, ", "))
+	require.ErrorIs(t, timeoutErr, context.DeadlineExceeded)
+	require.Equal(t, int32(2), stopped.Load(), "goroutines of the phase weren't stopped")
+}
+
+func TestRunPhaseReturnsErrorOfTarget(t *testing.T) {
+	err := RunPhase(t.Context(), nil, PhaseImagePull, func(context.Context) error {
+		return PhaseTarget("node 0 of nodeset workflow", errors.New("image not found"))
+	})
+
+	require.EqualError(t, err, "node 0 of nodeset workflow: image not found")
+	var timeoutErr *PhaseTimeoutError
+	require.NotErrorAs(t, err, &timeoutErr)
+}
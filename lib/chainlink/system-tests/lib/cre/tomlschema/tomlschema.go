@@ -0,0 +1,173 @@
+// Package tomlschema generates JSON Schemas of TOML configs from Go structs and their toml tags, so that editors
+// (e.g. with the Even Better TOML extension) and CI can reject unknown keys and wrong types before a config is used.
+package tomlschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema version of generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a subset of JSON Schema, which is needed to describe TOML configs
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // false or *Schema
+	Items                *Schema            `json:"items,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	timeType            = reflect.TypeFor[time.Time]()
+)
+
+// Generate returns the schema of value's type, which has to be a struct or a pointer to it. Structs reject unknown
+// keys like strict TOML decoding does, fields with a 'required' validate tag are required. Named structs are put to
+// $defs, so that recursive types are supported.
+func Generate(value any, title string) (*Schema, error) {
+	t := reflect.TypeOf(value)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("schema can be generated only for structs, got %T", value)
+	}
+
+	g := &generator{defs: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+	root := g.structSchema(t)
+	root.Schema = Draft
+	root.Title = title
+	if len(g.defs) > 0 {
+		root.Defs = g.defs
+	}
+
+	return root, nil
+}
+
+// GenerateJSON returns the indented JSON of the schema generated by Generate
+func GenerateJSON(value any, title string) ([]byte, error) {
+	schema, err := Generate(value, title)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+type generator struct {
+	defs  map[string]*Schema
+	names map[reflect.Type]string
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: "#/$defs/" + g.define(t)}
+	default:
+		// interfaces (e.g. raw values parsed later) accept anything
+		return &Schema{}
+	}
+}
+
+// define adds the named struct to $defs, unless it's already there, and returns its name
+func (g *generator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := path.Base(t.PkgPath()) + "." + t.Name()
+	for i := 2; g.defs[name] != nil; i++ {
+		name = fmt.Sprintf("%s.%s%d", path.Base(t.PkgPath()), t.Name(), i)
+	}
+	g.names[t] = name
+	// placeholder, so that recursive references don't define the type again
+	g.defs[name] = &Schema{}
+	*g.defs[name] = *g.structSchema(t)
+
+	return name
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	g.addFields(s, t)
+	slices.Sort(s.Required)
+
+	return s
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, hasTag := field.Tag.Lookup("toml")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		// like go-toml, untagged embedded structs are flattened into the parent table
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if !hasTag || name == "" {
+			name = field.Name
+		}
+
+		s.Properties[name] = g.schema(field.Type)
+		if slices.Contains(strings.Split(field.Tag.Get("validate"), ","), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
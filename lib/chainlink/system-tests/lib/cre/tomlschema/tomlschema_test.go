@@ -0,0 +1,84 @@
+package tomlschema
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBase struct {
+	Version int `toml:"version"`
+}
+
+type testNode struct {
+	Name     string      `toml:"name" validate:"required"`
+	Children []*testNode `toml:"children"`
+}
+
+type testConfig struct {
+	testBase
+	Name      string            `toml:"name" validate:"required"`
+	Enabled   bool              `toml:"enabled,omitempty"`
+	Ratio     float64           `toml:"ratio"`
+	Tags      []string          `toml:"tags"`
+	Labels    map[string]string `toml:"labels"`
+	Root      *testNode         `toml:"root"`
+	Amount    *big.Int          `toml:"amount"`
+	StartedAt time.Time         `toml:"started_at"`
+	Raw       any               `toml:"raw"`
+	Inline    struct {
+		Key string `toml:"key"`
+	} `toml:"inline"`
+	Computed string `toml:"-"`
+	Untagged string
+	internal string
+}
+
+func TestGenerate(t *testing.T) {
+	schema, err := Generate(&testConfig{}, "test")
+	require.NoError(t, err)
+
+	assert.Equal(t, Draft, schema.Schema)
+	assert.Equal(t, "test", schema.Title)
+	assert.Equal(t, false, schema.AdditionalProperties)
+	assert.Equal(t, []string{"name"}, schema.Required)
+
+	assert.Equal(t, "integer", schema.Properties["version"].Type, "embedded struct is flattened")
+	assert.Equal(t, "boolean", schema.Properties["enabled"].Type, "tag options are ignored")
+	assert.Equal(t, "number", schema.Properties["ratio"].Type)
+	assert.Equal(t, "string", schema.Properties["tags"].Items.Type)
+	assert.Equal(t, &Schema{Type: "string"}, schema.Properties["labels"].AdditionalProperties)
+	assert.Equal(t, "string", schema.Properties["amount"].Type, "text unmarshalers are strings")
+	assert.Equal(t, "date-time", schema.Properties["started_at"].Format)
+	assert.Equal(t, &Schema{}, schema.Properties["raw"])
+	assert.Equal(t, "string", schema.Properties["inline"].Properties["key"].Type)
+	assert.Contains(t, schema.Properties, "Untagged")
+	assert.NotContains(t, schema.Properties, "Computed")
+	assert.NotContains(t, schema.Properties, "internal")
+
+	t.Run("named structs are defined once, also when recursive", func(t *testing.T) {
+		require.Equal(t, "#/$defs/tomlschema.testNode", schema.Properties["root"].Ref)
+		require.Len(t, schema.Defs, 1)
+		node := schema.Defs["tomlschema.testNode"]
+		assert.Equal(t, []string{"name"}, node.Required)
+		assert.Equal(t, "#/$defs/tomlschema.testNode", node.Properties["children"].Items.Ref)
+	})
+
+	t.Run("schema is valid JSON", func(t *testing.T) {
+		content, err := GenerateJSON(testConfig{}, "test")
+		require.NoError(t, err)
+
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal(content, &decoded))
+		assert.Equal(t, false, decoded["additionalProperties"])
+	})
+
+	t.Run("only structs are supported", func(t *testing.T) {
+		_, err := Generate("config", "test")
+		require.Error(t, err)
+	})
+}
@@ -56,11 +56,11 @@ func NewTopology(nodeSetInput []*CapabilitiesAwareNodeSet, provider infra.Provid
 		topology.GatewayConnectors = NewGatewayConnectorOutput()
 		for _, d := range donsMetadata.List() {
 			if _, hasGateway := d.Gateway(); hasGateway {
-				gc, err := d.GatewayConfig(provider)
+				gcs, err := d.GatewayConfigs(provider)
 				if err != nil {
-					return nil, fmt.Errorf("failed to get gateway config for DON %s: %w", d.Name, err)
+					return nil, fmt.Errorf("failed to get gateway configs for DON %s: %w", d.Name, err)
 				}
-				topology.GatewayConnectors.Configurations = append(topology.GatewayConnectors.Configurations, gc)
+				topology.GatewayConnectors.Configurations = append(topology.GatewayConnectors.Configurations, gcs...)
 			}
 		}
 	}
@@ -0,0 +1,354 @@
+package transmission
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-evm/gethwrappers/keystone/generated/forwarder"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/clclient"
+)
+
+const (
+	// reportMetadataLength is the length of the metadata prefix of a raw report as defined in the KeystoneForwarder contract
+	reportMetadataLength = 109
+	signatureLength      = 65
+	ocr2EVMKeyPrefix     = "ocr2on_evm_"
+)
+
+// ChainClient is a subset of ethclient.Client needed to analyse transmissions. Both *ethclient.Client and seth.Client.Client satisfy it.
+type ChainClient interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Attempt is a single transaction calling KeystoneForwarder.report() for the analysed report
+type Attempt struct {
+	TxHash            common.Hash
+	BlockNumber       uint64
+	Transmitter       common.Address
+	TransmitterNode   string
+	Signers           []common.Address
+	SignerNodes       []string
+	TxSucceeded       bool
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+	// ReportProcessed is true if the attempt emitted ReportProcessed event, i.e. the report was accepted by the forwarder
+	ReportProcessed bool
+	// ReceiverSuccess is the result of the call to the receiver contract, as reported by ReportProcessed event
+	ReceiverSuccess bool
+}
+
+// Analysis describes how a single report of a single workflow execution was transmitted on-chain
+type Analysis struct {
+	Forwarder   common.Address
+	Receiver    common.Address
+	ExecutionID [32]byte
+	ReportID    [2]byte
+	Attempts    []Attempt
+	// Accepted is the attempt that was accepted by the forwarder, nil if none was
+	Accepted     *Attempt
+	TotalGasUsed uint64
+}
+
+// Retries returns the number of attempts made after the first one
+func (a *Analysis) Retries() int {
+	if len(a.Attempts) == 0 {
+		return 0
+	}
+
+	return len(a.Attempts) - 1
+}
+
+// Transmitters returns unique names (or addresses, if node is unknown) of nodes that attempted the transmission, in order of attempts
+func (a *Analysis) Transmitters() []string {
+	var transmitters []string
+	for _, attempt := range a.Attempts {
+		name := nodeOrAddress(attempt.TransmitterNode, attempt.Transmitter)
+		if !slices.Contains(transmitters, name) {
+			transmitters = append(transmitters, name)
+		}
+	}
+
+	return transmitters
+}
+
+// AssertSignerQuorum checks that the accepted report was signed by at least minSigners distinct, known nodes
+func (a *Analysis) AssertSignerQuorum(minSigners int) error {
+	if a.Accepted == nil {
+		return fmt.Errorf("report %x of execution %x was not accepted by the forwarder after %d attempt(s)", a.ReportID, a.ExecutionID, len(a.Attempts))
+	}
+
+	var unknown []string
+	for i, signer := range a.Accepted.Signers {
+		if a.Accepted.SignerNodes[i] == "" {
+			unknown = append(unknown, signer.Hex())
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("report was signed by unknown signers: %s", strings.Join(unknown, ", "))
+	}
+	if len(a.Accepted.Signers) < minSigners {
+		return fmt.Errorf("report was signed by %d nodes (%s), expected at least %d", len(a.Accepted.Signers), strings.Join(a.Accepted.SignerNodes, ", "), minSigners)
+	}
+
+	return nil
+}
+
+// String returns human-readable summary of the analysis
+func (a *Analysis) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "execution %x, report %x: %d attempt(s), %d gas used in total", a.ExecutionID, a.ReportID, len(a.Attempts), a.TotalGasUsed)
+	for i, attempt := range a.Attempts {
+		signers := make([]string, len(attempt.Signers))
+		for j, signer := range attempt.Signers {
+			signers[j] = nodeOrAddress(attempt.SignerNodes[j], signer)
+		}
+		fmt.Fprintf(&sb, "\n  #%d tx %s by %s: succeeded=%t accepted=%t receiverSuccess=%t gasUsed=%d signers=[%s]",
+			i+1, attempt.TxHash.Hex(), nodeOrAddress(attempt.TransmitterNode, attempt.Transmitter), attempt.TxSucceeded,
+			attempt.ReportProcessed, attempt.ReceiverSuccess, attempt.GasUsed, strings.Join(signers, ", "))
+	}
+
+	return sb.String()
+}
+
+type Input struct {
+	Client      ChainClient
+	Forwarder   common.Address
+	Receiver    common.Address
+	ExecutionID [32]byte
+	ReportID    [2]byte
+	// FromBlock is the first block to scan, use block number from before the workflow was triggered
+	FromBlock uint64
+	// NodeAddresses maps signer and transmitter addresses to node names, see NodeAddressesFromClients
+	NodeAddresses map[common.Address]string
+}
+
+func (i *Input) Validate() error {
+	if i.Client == nil {
+		return errors.New("client must be set")
+	}
+	if i.Forwarder == (common.Address{}) {
+		return errors.New("forwarder address must be set")
+	}
+	if i.ExecutionID == ([32]byte{}) {
+		return errors.New("execution ID must be set")
+	}
+
+	return nil
+}
+
+// Analyse scans blocks starting at input.FromBlock for all forwarder report() calls carrying the given report
+// and returns information about all transmission attempts.
+func Analyse(ctx context.Context, input Input) (*Analysis, error) {
+	if err := input.Validate(); err != nil {
+		return nil, errors.Wrap(err, "input validation failed")
+	}
+
+	forwarderABI, abiErr := forwarder.KeystoneForwarderMetaData.GetAbi()
+	if abiErr != nil {
+		return nil, errors.Wrap(abiErr, "failed to get forwarder ABI")
+	}
+	reportMethod, ok := forwarderABI.Methods["report"]
+	if !ok {
+		return nil, errors.New("forwarder ABI has no report method")
+	}
+	reportProcessedEvent, ok := forwarderABI.Events["ReportProcessed"]
+	if !ok {
+		return nil, errors.New("forwarder ABI has no ReportProcessed event")
+	}
+
+	latest, latestErr := input.Client.BlockNumber(ctx)
+	if latestErr != nil {
+		return nil, errors.Wrap(latestErr, "failed to get latest block number")
+	}
+
+	analysis := &Analysis{
+		Forwarder:   input.Forwarder,
+		Receiver:    input.Receiver,
+		ExecutionID: input.ExecutionID,
+		ReportID:    input.ReportID,
+	}
+
+	for blockNumber := input.FromBlock; blockNumber <= latest; blockNumber++ {
+		block, blockErr := input.Client.BlockByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+		if blockErr != nil {
+			return nil, errors.Wrapf(blockErr, "failed to get block %d", blockNumber)
+		}
+
+		for _, tx := range block.Transactions() {
+			if tx.To() == nil || *tx.To() != input.Forwarder || len(tx.Data()) < 4 || !bytes.Equal(tx.Data()[:4], reportMethod.ID) {
+				continue
+			}
+
+			args, unpackErr := reportMethod.Inputs.Unpack(tx.Data()[4:])
+			if unpackErr != nil {
+				return nil, errors.Wrapf(unpackErr, "failed to unpack report() call in tx %s", tx.Hash())
+			}
+			receiver, rawReport, reportContext, signatures, argsErr := reportArgs(args)
+			if argsErr != nil {
+				return nil, errors.Wrapf(argsErr, "unexpected report() arguments in tx %s", tx.Hash())
+			}
+			if (input.Receiver != common.Address{} && receiver != input.Receiver) || !matchesReport(rawReport, input.ExecutionID, input.ReportID) {
+				continue
+			}
+
+			attempt, attemptErr := newAttempt(ctx, input, tx, block.NumberU64(), rawReport, reportContext, signatures, reportProcessedEvent)
+			if attemptErr != nil {
+				return nil, attemptErr
+			}
+			analysis.Attempts = append(analysis.Attempts, *attempt)
+			analysis.TotalGasUsed += attempt.GasUsed
+		}
+	}
+
+	for i := range analysis.Attempts {
+		if analysis.Attempts[i].ReportProcessed {
+			analysis.Accepted = &analysis.Attempts[i]
+			break
+		}
+	}
+
+	return analysis, nil
+}
+
+func newAttempt(ctx context.Context, input Input, tx *types.Transaction, blockNumber uint64, rawReport, reportContext []byte, signatures [][]byte, reportProcessedEvent abi.Event) (*Attempt, error) {
+	sender, senderErr := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if senderErr != nil {
+		return nil, errors.Wrapf(senderErr, "failed to get sender of tx %s", tx.Hash())
+	}
+
+	receipt, receiptErr := input.Client.TransactionReceipt(ctx, tx.Hash())
+	if receiptErr != nil {
+		return nil, errors.Wrapf(receiptErr, "failed to get receipt of tx %s", tx.Hash())
+	}
+
+	attempt := &Attempt{
+		TxHash:            tx.Hash(),
+		BlockNumber:       blockNumber,
+		Transmitter:       sender,
+		TransmitterNode:   input.NodeAddresses[sender],
+		TxSucceeded:       receipt.Status == types.ReceiptStatusSuccessful,
+		GasUsed:           receipt.GasUsed,
+		EffectiveGasPrice: receipt.EffectiveGasPrice,
+	}
+
+	signers, signersErr := RecoverSigners(rawReport, reportContext, signatures)
+	if signersErr != nil {
+		return nil, errors.Wrapf(signersErr, "failed to recover signers of tx %s", tx.Hash())
+	}
+	attempt.Signers = signers
+	attempt.SignerNodes = make([]string, len(signers))
+	for i, signer := range signers {
+		attempt.SignerNodes[i] = input.NodeAddresses[signer]
+	}
+
+	for _, log := range receipt.Logs {
+		if log.Address != input.Forwarder || len(log.Topics) == 0 || log.Topics[0] != reportProcessedEvent.ID {
+			continue
+		}
+		values, unpackErr := reportProcessedEvent.Inputs.NonIndexed().Unpack(log.Data)
+		if unpackErr != nil {
+			return nil, errors.Wrapf(unpackErr, "failed to unpack ReportProcessed event in tx %s", tx.Hash())
+		}
+		if len(values) != 1 {
+			return nil, fmt.Errorf("expected 1 non-indexed value in ReportProcessed event in tx %s, got %d", tx.Hash(), len(values))
+		}
+		attempt.ReportProcessed = true
+		attempt.ReceiverSuccess, _ = values[0].(bool)
+	}
+
+	return attempt, nil
+}
+
+// RecoverSigners returns addresses of nodes that signed the report, in the order of signatures.
+// Signed digest is keccak256(keccak256(rawReport) || reportContext), the same as verified by the KeystoneForwarder contract.
+func RecoverSigners(rawReport, reportContext []byte, signatures [][]byte) ([]common.Address, error) {
+	digest := crypto.Keccak256(crypto.Keccak256(rawReport), reportContext)
+
+	signers := make([]common.Address, 0, len(signatures))
+	for i, signature := range signatures {
+		if len(signature) != signatureLength {
+			return nil, fmt.Errorf("signature %d has invalid length %d, expected %d", i, len(signature), signatureLength)
+		}
+		pub, pubErr := crypto.SigToPub(digest, signature)
+		if pubErr != nil {
+			return nil, errors.Wrapf(pubErr, "failed to recover public key from signature %d", i)
+		}
+		signers = append(signers, crypto.PubkeyToAddress(*pub))
+	}
+
+	return signers, nil
+}
+
+// NodeAddressesFromClients maps EVM OCR2 signing addresses and transmitter (ETH key) addresses of given nodes to their names
+func NodeAddressesFromClients(clients map[string]*clclient.ChainlinkClient, chainID string) (map[common.Address]string, error) {
+	addresses := make(map[common.Address]string)
+	for name, client := range clients {
+		ocr2Keys, ocr2Err := client.MustReadOCR2Keys()
+		if ocr2Err != nil {
+			return nil, errors.Wrapf(ocr2Err, "failed to read OCR2 keys of node %s", name)
+		}
+		for _, key := range ocr2Keys.Data {
+			if key.Attributes.ChainType != "evm" {
+				continue
+			}
+			addresses[common.HexToAddress(strings.TrimPrefix(key.Attributes.OnChainPublicKey, ocr2EVMKeyPrefix))] = name
+		}
+
+		ethAddresses, ethErr := client.EthAddressesForChain(chainID)
+		if ethErr != nil {
+			return nil, errors.Wrapf(ethErr, "failed to read ETH keys of node %s", name)
+		}
+		for _, address := range ethAddresses {
+			addresses[common.HexToAddress(address)] = name
+		}
+	}
+
+	return addresses, nil
+}
+
+func reportArgs(args []interface{}) (common.Address, []byte, []byte, [][]byte, error) {
+	if len(args) != 4 {
+		return common.Address{}, nil, nil, nil, fmt.Errorf("expected 4 arguments, got %d", len(args))
+	}
+	receiver, ok1 := args[0].(common.Address)
+	rawReport, ok2 := args[1].([]byte)
+	reportContext, ok3 := args[2].([]byte)
+	signatures, ok4 := args[3].([][]byte)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return common.Address{}, nil, nil, nil, errors.New("unexpected argument types")
+	}
+
+	return receiver, rawReport, reportContext, signatures, nil
+}
+
+// matchesReport checks execution ID (bytes 1-32) and report ID (bytes 107-108) stored in raw report metadata
+func matchesReport(rawReport []byte, executionID [32]byte, reportID [2]byte) bool {
+	if len(rawReport) < reportMetadataLength {
+		return false
+	}
+	if !bytes.Equal(rawReport[1:33], executionID[:]) {
+		return false
+	}
+
+	return reportID == [2]byte{} || bytes.Equal(rawReport[107:109], reportID[:])
+}
+
+func nodeOrAddress(node string, address common.Address) string {
+	if node != "" {
+		return node
+	}
+
+	return address.Hex()
+}
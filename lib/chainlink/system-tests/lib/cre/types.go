@@ -2,6 +2,8 @@ package cre
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"maps"
 	"os"
@@ -24,6 +26,7 @@ import (
 
 	cldf_jd "github.com/smartcontractkit/chainlink-deployments-framework/offchain/jd"
 	keystone_changeset "github.com/smartcontractkit/chainlink/deployment/keystone/changeset"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/don/secrets"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/crypto"
@@ -197,6 +200,111 @@ type CapabilityConfig struct {
 	Config       map[string]any `toml:"config"`
 	Chains       []string       `toml:"chains"`
 	ChainConfigs map[string]any `toml:"chain_configs"`
+	// optional, hex-encoded SHA-256 checksum the binary must match before it's copied to nodes
+	SHA256 string `toml:"sha256"`
+	// optional, detached signature the binary must be verified with before it's copied to nodes
+	Signature *BinarySignature `toml:"signature"`
+	// optional, capabilities that are enabled on every DON that enables this one, see ResolveCapabilityDependencies.
+	// DefaultCapabilityDependencies are used if it's not set.
+	DependsOn []CapabilityFlag `toml:"depends_on"`
+	// optional, roles of nodes the binary is installed on: worker (default), bootstrap or gateway, see NodeTypes
+	NodeRoles []string `toml:"node_roles"`
+	// optional, Go source the binary is built from for the platform of node containers, instead of binary_path
+	Source *binaries.Source `toml:"source"`
+}
+
+// NodeTypes returns types of nodes the capability's binary is installed on, only workers if node_roles isn't set.
+// Gateway nodes are workers too, so 'gateway' is needed only for capabilities, which shouldn't run on other workers.
+func (c CapabilityConfig) NodeTypes() []NodeType {
+	if len(c.NodeRoles) == 0 {
+		return []NodeType{WorkerNode}
+	}
+
+	nodeTypes := make([]NodeType, 0, len(c.NodeRoles))
+	for _, role := range c.NodeRoles {
+		switch role {
+		case TemplateRoleWorker:
+			nodeTypes = append(nodeTypes, WorkerNode)
+		case TemplateRoleBootstrap:
+			nodeTypes = append(nodeTypes, BootstrapNode)
+		case TemplateRoleGateway:
+			nodeTypes = append(nodeTypes, GatewayNode)
+		}
+	}
+
+	return nodeTypes
+}
+
+// BinaryName returns the file name of the capability's binary, also of one yet to be built from source. It's empty,
+// if neither binary_path nor source is set.
+func (c CapabilityConfig) BinaryName() string {
+	switch {
+	case c.BinaryPath != "":
+		return binaries.Name(c.BinaryPath)
+	case c.Source != nil:
+		return c.Source.BinaryName()
+	default:
+		return ""
+	}
+}
+
+// Validate checks the format of the checksum and signature, it doesn't verify the binary
+func (c CapabilityConfig) Validate() error {
+	if c.SHA256 != "" {
+		if decoded, err := hex.DecodeString(c.SHA256); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("sha256 '%s' must be a hex-encoded SHA-256 checksum", c.SHA256)
+		}
+	}
+	if c.Signature != nil {
+		if err := c.Signature.Validate(); err != nil {
+			return errors.Wrap(err, "invalid signature")
+		}
+	}
+	if (c.SHA256 != "" || c.Signature != nil) && c.BinaryPath == "" {
+		return errors.New("sha256 and signature require binary_path to be set")
+	}
+	if c.Source != nil {
+		if c.BinaryPath != "" {
+			return errors.New("only one of binary_path and source can be set")
+		}
+		if err := c.Source.Validate(); err != nil {
+			return errors.Wrap(err, "invalid source")
+		}
+	}
+	for _, role := range c.NodeRoles {
+		if role != TemplateRoleWorker && role != TemplateRoleBootstrap && role != TemplateRoleGateway {
+			return fmt.Errorf("unknown node role '%s'. Valid ones are: %s, %s, %s", role, TemplateRoleWorker, TemplateRoleBootstrap, TemplateRoleGateway)
+		}
+	}
+
+	return nil
+}
+
+type SignatureType string
+
+const (
+	SignatureTypeGPG    SignatureType = "gpg"
+	SignatureTypeCosign SignatureType = "cosign"
+)
+
+type BinarySignature struct {
+	Type SignatureType `toml:"type"`
+	// Path of the detached signature, binaries.SignatureRef of the binary is used if not set. Signatures of remote
+	// binaries can be remote references too, they are downloaded next to the cached binary.
+	Path string `toml:"path"`
+	// PublicKey is a path of a GPG keyring (e.g. exported with gpg --export) or of a cosign public key
+	PublicKey string `toml:"public_key"`
+}
+
+func (s *BinarySignature) Validate() error {
+	if s.Type != SignatureTypeGPG && s.Type != SignatureTypeCosign {
+		return fmt.Errorf("unsupported signature type '%s', use %s or %s", s.Type, SignatureTypeGPG, SignatureTypeCosign)
+	}
+	if s.PublicKey == "" {
+		return errors.New("public_key of the signature must be set")
+	}
+
+	return nil
 }
 
 type WorkflowRegistryInput struct {
@@ -408,7 +516,8 @@ type GenerateConfigsInput struct {
 	AddressBook             cldf.AddressBook
 	NodeSet                 *CapabilitiesAwareNodeSet
 	CapabilityConfigs       CapabilityConfigs
-	GatewayConnectorOutput  *GatewayConnectors // optional, automatically set if some DON in the topology has the GatewayDON flag
+	GatewayConnectorOutput  *GatewayConnectors   // optional, automatically set if some DON in the topology has the GatewayDON flag
+	ChainFinalityConfigs    ChainFinalityConfigs // optional, chain ID -> finality config
 }
 
 func (g *GenerateConfigsInput) Validate() error {
@@ -452,8 +561,9 @@ type DonMetadata struct {
 	ID            uint64          `toml:"id" json:"id"`
 	Name          string          `toml:"name" json:"name"`
 
-	ns CapabilitiesAwareNodeSet // computed field, not serialized
-	gh GatewayHelper
+	ns       CapabilitiesAwareNodeSet // computed field, not serialized
+	gh       GatewayHelper
+	provider infra.Provider
 }
 
 func NewDonMetadata(c *CapabilitiesAwareNodeSet, id uint64, provider infra.Provider) (*DonMetadata, error) {
@@ -464,19 +574,22 @@ func NewDonMetadata(c *CapabilitiesAwareNodeSet, id uint64, provider infra.Provi
 			nodeType = BootstrapNode
 		}
 
+		isGateway := slices.Contains(c.DONTypes, GatewayDON) && slices.Contains(c.AllGatewayNodeIndexes(), i)
 		cfg := NodeMetadataConfig{
 			Keys: NodeKeyInput{
 				EVMChainIDs:     c.EVMChains(),
 				SolanaChainIDs:  c.SupportedSolChains,
 				Password:        "dev-password",
 				ImportedSecrets: nodeSpec.Node.TestSecretsOverrides,
+				OCR2Keys:        c.NodeOCR2Keys[i],
 			},
 			Host:  provider.InternalHost(i, nodeType == BootstrapNode, c.Name),
+			Alias: infra.NodeAlias(c.Name, infra.NodeAliasRole(nodeType == BootstrapNode, isGateway), i),
 			Roles: []string{nodeType},
 			Index: i,
 		}
 
-		if slices.Contains(c.DONTypes, GatewayDON) && c.GatewayNodeIndex != -1 && i == c.GatewayNodeIndex {
+		if isGateway {
 			cfg.Roles = append(cfg.Roles, GatewayNode)
 		}
 
@@ -493,20 +606,30 @@ func NewDonMetadata(c *CapabilitiesAwareNodeSet, id uint64, provider infra.Provi
 		NodesMetadata: nodes,
 		Name:          c.Name,
 		ns:            *c,
+		provider:      provider,
 	}
 
 	return out, nil
 }
 
-func (m *DonMetadata) GatewayConfig(p infra.Provider) (*DonGatewayConfiguration, error) {
-	gatewayNode, hasGateway := m.Gateway()
-	if !hasGateway {
+// GatewayConfigs returns configuration of each gateway node in the DON. Each gateway gets its own auth ID,
+// because nodes identify gateways they connect to by it.
+func (m *DonMetadata) GatewayConfigs(p infra.Provider) ([]*DonGatewayConfiguration, error) {
+	gatewayNodes := m.Gateways()
+	if len(gatewayNodes) == 0 {
 		return nil, errors.New("don does not have a gateway node")
 	}
 
-	return &DonGatewayConfiguration{
-		GatewayConfiguration: NewGatewayConfig(p, gatewayNode.Index, gatewayNode.HasRole(BootstrapNode), gatewayNode.UUID, m.Name),
-	}, nil
+	configs := make([]*DonGatewayConfiguration, 0, len(gatewayNodes))
+	for idx, gatewayNode := range gatewayNodes {
+		gc := NewGatewayConfig(p, gatewayNode.Index, gatewayNode.HasRole(BootstrapNode), gatewayNode.UUID, m.Name)
+		if idx > 0 {
+			gc.AuthGatewayID = fmt.Sprintf("%s-%d", gc.AuthGatewayID, gatewayNode.Index)
+		}
+		configs = append(configs, &DonGatewayConfiguration{GatewayConfiguration: gc})
+	}
+
+	return configs, nil
 }
 
 func (m *DonMetadata) Workers() ([]*NodeMetadata, error) {
@@ -524,6 +647,19 @@ func (m *DonMetadata) Workers() ([]*NodeMetadata, error) {
 	return workers, nil
 }
 
+// NodesWithTypes returns nodes, which have any of the node types, ordered by their index
+func (m *DonMetadata) NodesWithTypes(nodeTypes ...NodeType) []*NodeMetadata {
+	nodes := make([]*NodeMetadata, 0)
+	for _, node := range m.NodesMetadata {
+		if slices.ContainsFunc(nodeTypes, node.HasRole) {
+			nodes = append(nodes, node)
+		}
+	}
+	slices.SortFunc(nodes, func(a, b *NodeMetadata) int { return a.Index - b.Index })
+
+	return nodes
+}
+
 // Currently only one bootstrap node is supported.
 func (m *DonMetadata) Bootstrap() (*NodeMetadata, bool) {
 	for _, node := range m.NodesMetadata {
@@ -535,7 +671,7 @@ func (m *DonMetadata) Bootstrap() (*NodeMetadata, bool) {
 	return nil, false
 }
 
-// For now we support only one gateway node per DON
+// Gateway returns the first gateway node of the DON, use Gateways() for DONs with multiple gateways
 func (m *DonMetadata) Gateway() (*NodeMetadata, bool) {
 	for _, node := range m.NodesMetadata {
 		if slices.Contains(node.Roles, GatewayNode) {
@@ -545,6 +681,28 @@ func (m *DonMetadata) Gateway() (*NodeMetadata, bool) {
 	return nil, false
 }
 
+func (m *DonMetadata) Gateways() []*NodeMetadata {
+	gateways := make([]*NodeMetadata, 0)
+	for _, node := range m.NodesMetadata {
+		if slices.Contains(node.Roles, GatewayNode) {
+			gateways = append(gateways, node)
+		}
+	}
+
+	return gateways
+}
+
+// NodeByAlias returns node with given alias, see infra.NodeAlias()
+func (m *DonMetadata) NodeByAlias(alias string) (*NodeMetadata, bool) {
+	for _, node := range m.NodesMetadata {
+		if node.Alias == alias {
+			return node, true
+		}
+	}
+
+	return nil, false
+}
+
 func (m *DonMetadata) HasFlag(flag CapabilityFlag) bool {
 	return HasFlag(m.Flags, flag)
 }
@@ -646,6 +804,16 @@ func (d *Dons) Gateway() (*Node, bool) {
 	return nil, false
 }
 
+// Gateways returns all gateway nodes from all DONs
+func (d *Dons) Gateways() []*Node {
+	gateways := make([]*Node, 0)
+	for _, don := range d.List() {
+		gateways = append(gateways, don.Gateways()...)
+	}
+
+	return gateways
+}
+
 func (d *Dons) DonsWithFlag(flag CapabilityFlag) []*Don {
 	found := make([]*Don, 0)
 	for _, don := range d.List() {
@@ -809,6 +977,7 @@ func (m DonsMetadata) RequiresGateway() bool {
 type NodeMetadata struct {
 	Keys  *secrets.NodeKeys `toml:"keys" json:"keys"`
 	Host  string            `toml:"host" json:"host"`
+	Alias string            `toml:"alias" json:"alias"` // infra-independent node name, e.g. workflow-worker-3, see infra.NodeAlias()
 	Roles []string          `toml:"roles" json:"roles"`
 	Index int               `toml:"index" json:"index"` // hopefully we can remove it later, but for now we need it to construct urls in CRIB
 	UUID  string            `toml:"uuid" json:"uuid"`
@@ -829,6 +998,7 @@ func (n *NodeMetadata) PeerID() string {
 type NodeMetadataConfig struct {
 	Keys  NodeKeyInput
 	Host  string
+	Alias string
 	Roles []string
 	Index int
 }
@@ -842,6 +1012,7 @@ func NewNodeMetadata(c NodeMetadataConfig) (*NodeMetadata, error) {
 	return &NodeMetadata{
 		Keys:  keys,
 		Host:  c.Host,
+		Alias: c.Alias,
 		Roles: c.Roles,
 		Index: c.Index,
 		UUID:  uuid.NewString(),
@@ -862,6 +1033,14 @@ func newNodes(cfgs []NodeMetadataConfig) ([]*NodeMetadata, error) {
 	return nodes, nil
 }
 
+const (
+	NodeProfileDefault = ""
+	// NodeProfileSlim reduces resource usage of nodes, so that large topologies (30-50 nodes) fit on a single machine.
+	// It lowers DB connection pool limits, disables Beholder telemetry and keeps only warnings in logs, which means that
+	// log- and metrics-based assertions won't work for nodes using it.
+	NodeProfileSlim = "slim"
+)
+
 // CapabilitiesAwareNodeSet is the serialized form that declares nodesets in a topology.
 type CapabilitiesAwareNodeSet struct {
 	*ns.Input
@@ -872,7 +1051,9 @@ type CapabilitiesAwareNodeSet struct {
 	// TODO separate out bootstrap as a concept rather than index
 	BootstrapNodeIndex   int               `toml:"bootstrap_node_index"` // -1 -> no bootstrap, only used if the DON doesn't hae the GatewayDON flag
 	GatewayNodeIndex     int               `toml:"gateway_node_index"`   // -1 -> no gateway, only used if the DON has the GatewayDON flag
+	GatewayNodeIndexes   []int             `toml:"gateway_node_indexes"` // multiple gateway nodes for HA setups, takes precedence over GatewayNodeIndex
 	EnvVars              map[string]string `toml:"env_vars"`             // additional environment variables to be set on each node
+	NodeProfile          string            `toml:"node_profile"`         // empty (default) or "slim", see NodeProfileSlim
 	RawChainCapabilities any               `toml:"chain_capabilities"`
 	// ChainCapabilities allows enabling capabilities per chain with optional per-chain overrides.
 	// Example syntaxes accepted per capability key:
@@ -884,11 +1065,57 @@ type CapabilitiesAwareNodeSet struct {
 	// Example: [nodesets.capability_overrides.web-api-target] GlobalRPS = 2000.0
 	CapabilityOverrides map[string]map[string]any `toml:"capability_overrides"`
 
+	// Locale sets timezone and locale of all nodes, UTC and C.UTF-8 are used if not set.
+	// NodeLocaleOverrides allows setting different ones for selected nodes, keyed by node index.
+	// Example: [nodesets.node_locale_overrides.2] timezone = "Asia/Tokyo"
+	Locale              *NodeLocaleConfig            `toml:"locale"`
+	NodeLocaleOverrides map[string]*NodeLocaleConfig `toml:"node_locale_overrides"`
+
+	// NodeCapabilityBinaries allows selected nodes to run a different version of a capability binary, keyed by node index
+	// and capability flag. Use it to test protocol compatibility of capability versions, see VersionSkewMatrix.
+	// Example: [nodesets.node_capability_binaries.3] cron = "./binaries/v1.1.0/cron"
+	NodeCapabilityBinaries map[int]map[CapabilityFlag]string `toml:"node_capability_binaries"`
+	// NodeLabels assign labels to nodes, keyed by node index. LabelCapabilityBinaries override capability binaries of all
+	// nodes with a label, keyed by the label and capability flag. Overrides by node index take precedence over the ones by label.
+	// Example: [nodesets.node_labels] 1 = ["canary"], [nodesets.label_capability_binaries.canary] cron = "./binaries/v1.3.0/cron"
+	NodeLabels              map[int][]string                     `toml:"node_labels"`
+	LabelCapabilityBinaries map[string]map[CapabilityFlag]string `toml:"label_capability_binaries"`
+
+	// CapabilityPolicies set step-level timeouts and retries of capabilities hosted by the DON, keyed by labelled name.
+	// CapabilityCallTimeout limits how long the workflow engine waits for any capability call, see CapabilityPolicy.
+	// Example: [nodesets.capability_policies.evm] request_timeout = "45s"
+	CapabilityPolicies    map[string]*CapabilityPolicy `toml:"capability_policies"`
+	CapabilityCallTimeout string                       `toml:"capability_call_timeout"`
+
+	// CapabilityBinariesOwner is 'chainlink', '<uid>' or '<uid>:<gid>' of the user running the node in its image. If set,
+	// copied capability binaries are chowned to it, so that images running as a non-root user can execute them (Docker only).
+	CapabilityBinariesOwner string `toml:"capability_binaries_owner"`
+
+	// SkipConfigValidation disables validation of node configs with the node image before the DON is started (Docker only)
+	SkipConfigValidation bool `toml:"skip_config_validation"`
+
+	// RoleTemplates define node specs per role (bootstrap, worker, gateway) instead of node_specs, see ExpandRoleTemplates
+	RoleTemplates []*RoleSpecTemplate `toml:"role_templates"`
+	// NodeOCR2Keys are OCR2 key bundles imported to nodes once they run, keyed by node index, see KeysFile
+	NodeOCR2Keys map[int][]*crypto.OCR2Key `toml:"-"`
+
 	SupportedSolChains []string `toml:"supported_sol_chains"` // sol chain IDs that the DON supports
 	// Merged list of global and chain-specific capabilities. The latter ones are transformed to the format "capability-chainID", e.g. "evm-1337" for the evm capability on chain 1337.
 	ComputedCapabilities []string `toml:"computed_capabilities"`
 }
 
+// AllGatewayNodeIndexes returns indexes of all gateway nodes of the nodeset
+func (c *CapabilitiesAwareNodeSet) AllGatewayNodeIndexes() []int {
+	if len(c.GatewayNodeIndexes) > 0 {
+		return c.GatewayNodeIndexes
+	}
+	if c.GatewayNodeIndex != -1 {
+		return []int{c.GatewayNodeIndex}
+	}
+
+	return nil
+}
+
 func (c *CapabilitiesAwareNodeSet) Flags() []string {
 	var stringCaps []string
 
@@ -1036,6 +1263,11 @@ func (c *CapabilitiesAwareNodeSet) ParseChainCapabilities() error {
 			}
 		case map[string]any:
 			// Handle map syntax: capability = { enabled_chains = [...], chain_overrides = {...} }
+			for key := range v {
+				if key != "enabled_chains" && key != "chain_overrides" {
+					return fmt.Errorf("unknown key '%s' in %s. Valid ones are: enabled_chains, chain_overrides", key, capName)
+				}
+			}
 			if enabledChainsVal, ok := v["enabled_chains"]; ok {
 				enabledChains, ok := enabledChainsVal.([]any)
 				if !ok {
@@ -1123,6 +1355,7 @@ type NodeKeyInput struct {
 	Password       string
 
 	ImportedSecrets string // raw JSON string of secrets to import (usually from a previous run)
+	OCR2Keys        []*crypto.OCR2Key
 }
 
 func NewNodeKeys(input NodeKeyInput) (*secrets.NodeKeys, error) {
@@ -1136,6 +1369,7 @@ func NewNodeKeys(input NodeKeyInput) (*secrets.NodeKeys, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to parse imported secrets")
 		}
+		importedKeys.OCR2Keys = ocr2KeysByChainType(input.OCR2Keys)
 
 		return importedKeys, nil
 	}
@@ -1169,9 +1403,23 @@ func NewNodeKeys(input NodeKeyInput) (*secrets.NodeKeys, error) {
 		}
 		out.Solana[chainID] = k
 	}
+	out.OCR2Keys = ocr2KeysByChainType(input.OCR2Keys)
+
 	return out, nil
 }
 
+func ocr2KeysByChainType(keys []*crypto.OCR2Key) map[string]*crypto.OCR2Key {
+	if len(keys) == 0 {
+		return nil
+	}
+	out := make(map[string]*crypto.OCR2Key, len(keys))
+	for _, key := range keys {
+		out[strings.ToLower(key.ChainType)] = key
+	}
+
+	return out
+}
+
 type LinkDonsToJDInput struct {
 	JDClient        *cldf_jd.JobDistributor
 	Blockchains     []blockchains.Blockchain
@@ -1181,12 +1429,16 @@ type LinkDonsToJDInput struct {
 }
 
 type Environment struct {
+	Name                  string // see DefaultEnvironmentName
 	CldfEnvironment       *cldf.Environment
 	RegistryChainSelector uint64
 	Blockchains           []blockchains.Blockchain
 	ContractVersions      map[string]string
 	Provider              infra.Provider
 	CapabilityConfigs     map[CapabilityFlag]CapabilityConfig
+	ChainFinalityConfigs  ChainFinalityConfigs
+	FeatureFlags          *FeatureFlags
+	Fingerprint           string // of the environment definition, see environment.Fingerprint
 }
 
 type (
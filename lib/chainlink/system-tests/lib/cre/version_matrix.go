@@ -0,0 +1,263 @@
+package cre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+)
+
+// mixedVersions names the variant of an axis, in which nodes alternate between all its versions
+const mixedVersions = "mixed"
+
+// CapabilityVersion is a binary of a capability, Name labels it in names of matrix cases, e.g. "v1.2.0"
+type CapabilityVersion struct {
+	Name       string
+	BinaryPath string
+}
+
+// NodeVersion is an image of the core node, Name labels it in names of matrix cases
+type NodeVersion struct {
+	Name  string
+	Image string
+}
+
+// VersionMatrix lists versions of capabilities and of the core node to test against each other. Every combination of
+// versions is a case, an axis with more than one version also has a mixed variant, in which nodes alternate between its
+// versions. Binaries of a capability must have the same file name, because job specs reference binaries by name, keep
+// different versions in different directories. Axes without versions keep what the nodesets and capability configs have.
+//
+// Experimental: names and variants of cases may change.
+type VersionMatrix struct {
+	Capabilities map[CapabilityFlag][]CapabilityVersion
+	NodeImages   []NodeVersion
+}
+
+func (m *VersionMatrix) Validate() error {
+	for _, flag := range slices.Sorted(maps.Keys(m.Capabilities)) {
+		versions := m.Capabilities[flag]
+		names := make(map[string]struct{}, len(versions))
+		for _, version := range versions {
+			if version.Name == "" || version.Name == mixedVersions || version.BinaryPath == "" {
+				return fmt.Errorf("versions of capability %s need a binary path and a name other than '%s'", flag, mixedVersions)
+			}
+			if _, ok := names[version.Name]; ok {
+				return fmt.Errorf("capability %s has two versions named %s", flag, version.Name)
+			}
+			names[version.Name] = struct{}{}
+			if binaries.Name(version.BinaryPath) != binaries.Name(versions[0].BinaryPath) {
+				return fmt.Errorf("binaries of capability %s must have the same file name, because job specs reference binaries by name, got %s and %s", flag, versions[0].BinaryPath, version.BinaryPath)
+			}
+		}
+	}
+
+	names := make(map[string]struct{}, len(m.NodeImages))
+	for _, version := range m.NodeImages {
+		if version.Name == "" || version.Name == mixedVersions || version.Image == "" {
+			return fmt.Errorf("node versions need an image and a name other than '%s'", mixedVersions)
+		}
+		if _, ok := names[version.Name]; ok {
+			return fmt.Errorf("two node versions are named %s", version.Name)
+		}
+		names[version.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// VersionMatrixCase is a combination of versions, see VersionMatrix.Cases. Versions of an axis are assigned to nodes
+// round-robin, a single version means all nodes run it.
+type VersionMatrixCase struct {
+	Name         string
+	Capabilities map[CapabilityFlag][]CapabilityVersion
+	NodeImages   []NodeVersion
+}
+
+// Cases expands the matrix into all combinations of versions, ordered by capability flags and versions. Names of cases
+// list the version of each axis, e.g. "cron=v1 node=mixed".
+func (m *VersionMatrix) Cases() ([]VersionMatrixCase, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	cases := []VersionMatrixCase{{Capabilities: make(map[CapabilityFlag][]CapabilityVersion)}}
+	for _, flag := range slices.Sorted(maps.Keys(m.Capabilities)) {
+		variants := versionVariants(m.Capabilities[flag], func(v CapabilityVersion) string { return v.Name })
+		var expanded []VersionMatrixCase
+		for _, matrixCase := range cases {
+			for _, variant := range variants {
+				next := matrixCase
+				next.Capabilities = maps.Clone(matrixCase.Capabilities)
+				next.Capabilities[flag] = variant.versions
+				next.Name = strings.TrimSpace(matrixCase.Name + " " + flag + "=" + variant.name)
+				expanded = append(expanded, next)
+			}
+		}
+		cases = expanded
+	}
+
+	if len(m.NodeImages) > 0 {
+		var expanded []VersionMatrixCase
+		for _, matrixCase := range cases {
+			for _, variant := range versionVariants(m.NodeImages, func(v NodeVersion) string { return v.Name }) {
+				next := matrixCase
+				next.NodeImages = variant.versions
+				next.Name = strings.TrimSpace(matrixCase.Name + " node=" + variant.name)
+				expanded = append(expanded, next)
+			}
+		}
+		cases = expanded
+	}
+
+	if len(cases) == 1 && cases[0].Name == "" {
+		return nil, errors.New("version matrix has no versions")
+	}
+
+	return cases, nil
+}
+
+type versionVariant[V any] struct {
+	name     string
+	versions []V
+}
+
+// versionVariants returns each version alone and, if there are more of them, all of them mixed
+func versionVariants[V any](versions []V, name func(V) string) []versionVariant[V] {
+	variants := make([]versionVariant[V], 0, len(versions)+1)
+	for _, version := range versions {
+		variants = append(variants, versionVariant[V]{name: name(version), versions: []V{version}})
+	}
+	if len(versions) > 1 {
+		variants = append(variants, versionVariant[V]{name: mixedVersions, versions: versions})
+	}
+
+	return variants
+}
+
+// Apply returns copies of nodesets and capability configs, which run versions of the case. The first version of each
+// capability becomes its DON-wide binary, nodes of DONs hosting the capability, which it's installed on (see
+// CapabilityConfig.NodeTypes), get the other versions round-robin as node capability binaries. Overrides of the
+// capability set in the nodesets are replaced.
+func (c *VersionMatrixCase) Apply(nodeSets []*CapabilitiesAwareNodeSet, capabilityConfigs CapabilityConfigs) ([]*CapabilitiesAwareNodeSet, CapabilityConfigs, error) {
+	configs := maps.Clone(capabilityConfigs)
+	if configs == nil {
+		configs = make(CapabilityConfigs)
+	}
+	for _, flag := range slices.Sorted(maps.Keys(c.Capabilities)) {
+		config := configs[flag]
+		config.BinaryPath = c.Capabilities[flag][0].BinaryPath
+		// sources and checksums describe a different version
+		config.Source = nil
+		config.SHA256 = ""
+		config.Signature = nil
+		configs[flag] = config
+	}
+
+	out := make([]*CapabilitiesAwareNodeSet, len(nodeSets))
+	for idx, nodeSet := range nodeSets {
+		clone := nodeSet.Clone()
+		for _, flag := range slices.Sorted(maps.Keys(c.Capabilities)) {
+			clone.ResetCapabilityBinaries(flag)
+			if !clone.hostsCapability(flag) {
+				continue
+			}
+			versions := c.Capabilities[flag]
+			for position, nodeIdx := range clone.capabilityNodeIndexes(configs[flag].NodeTypes()) {
+				if version := versions[position%len(versions)]; version.BinaryPath != versions[0].BinaryPath {
+					clone.SetNodeCapabilityBinary(nodeIdx, flag, version.BinaryPath)
+				}
+			}
+		}
+		if len(c.NodeImages) > 0 {
+			for nodeIdx, nodeSpec := range clone.NodeSpecs {
+				nodeSpec.Node.Image = c.NodeImages[nodeIdx%len(c.NodeImages)].Image
+				// images are pulled, not built
+				nodeSpec.Node.DockerContext = ""
+				nodeSpec.Node.DockerFilePath = ""
+			}
+		}
+		if err := clone.ValidateNodeCapabilityBinaries(configs); err != nil {
+			return nil, nil, fmt.Errorf("version matrix case '%s' is invalid: %w", c.Name, err)
+		}
+		out[idx] = clone
+	}
+
+	return out, configs, nil
+}
+
+// capabilityNodeIndexes returns indexes of nodes with given types, as NewDonMetadata assigns them
+func (c *CapabilitiesAwareNodeSet) capabilityNodeIndexes(nodeTypes []NodeType) []int {
+	indexes := make([]int, 0, len(c.NodeSpecs))
+	for idx := range c.NodeSpecs {
+		isBootstrap := c.BootstrapNodeIndex != -1 && idx == c.BootstrapNodeIndex
+		isGateway := slices.Contains(c.DONTypes, GatewayDON) && slices.Contains(c.AllGatewayNodeIndexes(), idx)
+		if (isBootstrap && slices.Contains(nodeTypes, BootstrapNode)) ||
+			(!isBootstrap && slices.Contains(nodeTypes, WorkerNode)) ||
+			(isGateway && slices.Contains(nodeTypes, GatewayNode)) {
+			indexes = append(indexes, idx)
+		}
+	}
+
+	return indexes
+}
+
+// hostsCapability returns true, if the capability is enabled in capabilities or chain_capabilities of the nodeset
+func (c *CapabilitiesAwareNodeSet) hostsCapability(flag CapabilityFlag) bool {
+	if slices.Contains(c.Capabilities, flag) {
+		return true
+	}
+	if _, ok := c.ChainCapabilities[flag]; ok {
+		return true
+	}
+
+	return slices.ContainsFunc(c.ComputedCapabilities, func(computed string) bool {
+		return computed == flag || strings.HasPrefix(computed, flag+"-")
+	})
+}
+
+// Clone returns a copy of the nodeset, whose node specs and capability binaries can be changed without changing the
+// original. Other fields are shared with it.
+func (c *CapabilitiesAwareNodeSet) Clone() *CapabilitiesAwareNodeSet {
+	clone := *c
+	input := *c.Input
+	input.NodeSpecs = make([]*clnode.Input, len(c.NodeSpecs))
+	for idx, nodeSpec := range c.NodeSpecs {
+		specCopy := *nodeSpec
+		if nodeSpec.Node != nil {
+			nodeCopy := *nodeSpec.Node
+			nodeCopy.CapabilitiesBinaryPaths = slices.Clone(nodeSpec.Node.CapabilitiesBinaryPaths)
+			specCopy.Node = &nodeCopy
+		}
+		input.NodeSpecs[idx] = &specCopy
+	}
+	clone.Input = &input
+	clone.NodeCapabilityBinaries = cloneBinaryOverrides(c.NodeCapabilityBinaries)
+	clone.LabelCapabilityBinaries = cloneBinaryOverrides(c.LabelCapabilityBinaries)
+
+	return &clone
+}
+
+func cloneBinaryOverrides[K comparable](overrides map[K]map[CapabilityFlag]string) map[K]map[CapabilityFlag]string {
+	if overrides == nil {
+		return nil
+	}
+	clone := make(map[K]map[CapabilityFlag]string, len(overrides))
+	for key, binaries := range overrides {
+		clone[key] = maps.Clone(binaries)
+	}
+
+	return clone
+}
+
+// RunVersionMatrix runs all cases one by one and returns an error listing all failed ones, like RunVersionSkewMatrix.
+// The run function should start the environment for the case, e.g. with nodesets and capability configs returned by
+// VersionMatrixCase.Apply.
+func RunVersionMatrix(ctx context.Context, cases []VersionMatrixCase, run func(ctx context.Context, matrixCase VersionMatrixCase) error) error {
+	return runCases(ctx, "version matrix", cases, func(matrixCase VersionMatrixCase) string { return matrixCase.Name }, run)
+}
@@ -0,0 +1,197 @@
+package cre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/binaries"
+)
+
+// ValidateNodeCapabilityBinaries validates per-node and per-label capability binaries. Job specs are shared by all nodes of a DON and
+// reference binaries by their file name, so a per-node binary must have the same file name as the DON-wide one.
+func (c *CapabilitiesAwareNodeSet) ValidateNodeCapabilityBinaries(capabilityConfigs CapabilityConfigs) error {
+	for idx, nodeBinaries := range c.NodeCapabilityBinaries {
+		if idx < 0 || idx >= len(c.NodeSpecs) {
+			return fmt.Errorf("invalid node index %d in node capability binaries for nodeset %s, it must be between 0 and %d", idx, c.Name, len(c.NodeSpecs)-1)
+		}
+
+		for flag, binaryPath := range nodeBinaries {
+			config, ok := capabilityConfigs[flag]
+			if !ok || config.BinaryName() == "" {
+				return fmt.Errorf("node %d in nodeset %s overrides binary of capability %s, which has no binary path set in the capabilities TOML config", idx, c.Name, flag)
+			}
+			if binaries.Name(binaryPath) != config.BinaryName() {
+				return fmt.Errorf("binary '%s' of capability %s for node %d in nodeset %s must have the same file name as '%s', because job specs reference binaries by name. Keep different versions in different directories", binaryPath, flag, idx, c.Name, config.BinaryName())
+			}
+		}
+	}
+
+	for idx := range c.NodeLabels {
+		if idx < 0 || idx >= len(c.NodeSpecs) {
+			return fmt.Errorf("invalid node index %d in node labels for nodeset %s, it must be between 0 and %d", idx, c.Name, len(c.NodeSpecs)-1)
+		}
+	}
+
+	for label, labelBinaries := range c.LabelCapabilityBinaries {
+		if len(c.NodesWithLabel(label)) == 0 {
+			return fmt.Errorf("label '%s' in label capability binaries for nodeset %s isn't assigned to any node, set it in node labels", label, c.Name)
+		}
+		for flag, binaryPath := range labelBinaries {
+			config, ok := capabilityConfigs[flag]
+			if !ok || config.BinaryName() == "" {
+				return fmt.Errorf("label '%s' in nodeset %s overrides binary of capability %s, which has no binary path set in the capabilities TOML config", label, c.Name, flag)
+			}
+			if binaries.Name(binaryPath) != config.BinaryName() {
+				return fmt.Errorf("binary '%s' of capability %s for label '%s' in nodeset %s must have the same file name as '%s', because job specs reference binaries by name. Keep different versions in different directories", binaryPath, flag, label, c.Name, config.BinaryName())
+			}
+		}
+	}
+
+	// a node with more labels must not get different binaries of the same capability, unless its index overrides them
+	for nodeIdx, labels := range c.NodeLabels {
+		for _, flag := range slices.Sorted(maps.Keys(capabilityConfigs)) {
+			if _, ok := c.NodeCapabilityBinaries[nodeIdx][flag]; ok {
+				continue
+			}
+			binaryPaths := make(map[string]struct{})
+			for _, label := range labels {
+				if binaryPath, ok := c.LabelCapabilityBinaries[label][flag]; ok && binaryPath != "" {
+					binaryPaths[binaryPath] = struct{}{}
+				}
+			}
+			if len(binaryPaths) > 1 {
+				return fmt.Errorf("labels %v of node %d in nodeset %s override binary of capability %s with different binaries %v, override it by node index instead", labels, nodeIdx, c.Name, flag, slices.Sorted(maps.Keys(binaryPaths)))
+			}
+		}
+	}
+
+	return nil
+}
+
+// NodesWithLabel returns sorted indexes of nodes with given label
+func (c *CapabilitiesAwareNodeSet) NodesWithLabel(label string) []int {
+	var indexes []int
+	for idx, labels := range c.NodeLabels {
+		if slices.Contains(labels, label) {
+			indexes = append(indexes, idx)
+		}
+	}
+	slices.Sort(indexes)
+
+	return indexes
+}
+
+// NodeCapabilityBinaryPath returns the binary path of the capability for the node with given index. Overrides by node index
+// take precedence over overrides by node label, defaultPath is returned if there are none.
+func (c *CapabilitiesAwareNodeSet) NodeCapabilityBinaryPath(nodeIdx int, flag CapabilityFlag, defaultPath string) string {
+	if binaryPath, ok := c.NodeCapabilityBinaries[nodeIdx][flag]; ok && binaryPath != "" {
+		return binaryPath
+	}
+	for _, label := range c.NodeLabels[nodeIdx] {
+		if binaryPath, ok := c.LabelCapabilityBinaries[label][flag]; ok && binaryPath != "" {
+			return binaryPath
+		}
+	}
+
+	return defaultPath
+}
+
+// SetNodeCapabilityBinary makes the node with given index use a different binary (usually a different version) of the capability
+func (c *CapabilitiesAwareNodeSet) SetNodeCapabilityBinary(nodeIdx int, flag CapabilityFlag, binaryPath string) {
+	if c.NodeCapabilityBinaries == nil {
+		c.NodeCapabilityBinaries = make(map[int]map[CapabilityFlag]string)
+	}
+	if c.NodeCapabilityBinaries[nodeIdx] == nil {
+		c.NodeCapabilityBinaries[nodeIdx] = make(map[CapabilityFlag]string)
+	}
+	c.NodeCapabilityBinaries[nodeIdx][flag] = binaryPath
+}
+
+// VersionSkewCase describes a DON, in which some nodes run a candidate version of the capability binary
+// and the rest runs the baseline one
+type VersionSkewCase struct {
+	Name           string
+	Capability     CapabilityFlag
+	BaselinePath   string
+	CandidatePath  string
+	CandidateNodes []int
+}
+
+// VersionSkewMatrix returns cases for gating a capability release, which split nodes of the nodeset with given types
+// (workers, if none are given) between the baseline and the candidate version of the capability binary: all nodes
+// on the baseline version, the first and the second half of them on the candidate version and all of them on the
+// candidate version. If the number of nodes is odd, the second half has one node more.
+//
+// Split cases prove protocol compatibility only if neither half can reach quorum on its own. That depends on the size
+// of the DON: with 4 workers (F=1, quorum of 3) halves of 2 nodes can't, but with 5 workers the half of 3 nodes can,
+// so its case passes even if the other version is incompatible. Use DON sizes, whose halves are below quorum.
+//
+// Experimental: cases of the matrix may change.
+func VersionSkewMatrix(nodeSet *CapabilitiesAwareNodeSet, flag CapabilityFlag, baselinePath, candidatePath string, nodeTypes ...NodeType) []VersionSkewCase {
+	if len(nodeTypes) == 0 {
+		nodeTypes = []NodeType{WorkerNode}
+	}
+	nodeIndexes := nodeSet.capabilityNodeIndexes(nodeTypes)
+	half := len(nodeIndexes) / 2
+	newCase := func(name string, candidateNodes []int) VersionSkewCase {
+		return VersionSkewCase{
+			Name:           fmt.Sprintf("%s %s", flag, name),
+			Capability:     flag,
+			BaselinePath:   baselinePath,
+			CandidatePath:  candidatePath,
+			CandidateNodes: candidateNodes,
+		}
+	}
+
+	return []VersionSkewCase{
+		newCase("all baseline", nil),
+		newCase("first half candidate", nodeIndexes[:half]),
+		newCase("second half candidate", nodeIndexes[half:]),
+		newCase("all candidate", nodeIndexes),
+	}
+}
+
+// ApplyVersionSkew sets capability binaries of the nodeset's nodes according to the case. The baseline binary is
+// expected to be set as the DON-wide binary path in the capabilities TOML config.
+func (c *CapabilitiesAwareNodeSet) ApplyVersionSkew(skewCase VersionSkewCase) {
+	c.clearNodeCapabilityBinaries(skewCase.Capability)
+	for _, nodeIdx := range skewCase.CandidateNodes {
+		c.SetNodeCapabilityBinary(nodeIdx, skewCase.Capability, skewCase.CandidatePath)
+	}
+}
+
+// ResetCapabilityBinaries removes overrides of the capability's binary by node index and by node label, so that all
+// nodes run its DON-wide binary
+func (c *CapabilitiesAwareNodeSet) ResetCapabilityBinaries(flag CapabilityFlag) {
+	c.clearNodeCapabilityBinaries(flag)
+	for _, binaries := range c.LabelCapabilityBinaries {
+		delete(binaries, flag)
+	}
+}
+
+func (c *CapabilitiesAwareNodeSet) clearNodeCapabilityBinaries(flag CapabilityFlag) {
+	for _, binaries := range c.NodeCapabilityBinaries {
+		delete(binaries, flag)
+	}
+}
+
+// RunVersionSkewMatrix runs all cases one by one and returns an error listing all failed ones. The run function should
+// start the environment for the case and assert that workflow executions using the capability reach quorum.
+func RunVersionSkewMatrix(ctx context.Context, cases []VersionSkewCase, run func(ctx context.Context, skewCase VersionSkewCase) error) error {
+	return runCases(ctx, "version skew", cases, func(skewCase VersionSkewCase) string { return skewCase.Name }, run)
+}
+
+// runCases runs all cases one by one and returns an error listing all failed ones, kind names them in errors
+func runCases[C any](ctx context.Context, kind string, cases []C, name func(C) string, run func(ctx context.Context, c C) error) error {
+	var errs []error
+	for _, c := range cases {
+		if err := run(ctx, c); err != nil {
+			errs = append(errs, fmt.Errorf("%s case '%s' failed: %w", kind, name(c), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package cre
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
+	ns "github.com/smartcontractkit/chainlink-testing-framework/framework/components/simple_node_set"
+)
+
+func skewNodeSet(nodes int) *CapabilitiesAwareNodeSet {
+	specs := make([]*clnode.Input, nodes)
+	for idx := range specs {
+		specs[idx] = &clnode.Input{Node: &clnode.NodeInput{}}
+	}
+
+	return &CapabilitiesAwareNodeSet{Input: &ns.Input{Name: "workflow", Nodes: nodes, NodeSpecs: specs}, BootstrapNodeIndex: 0}
+}
+
+func TestVersionSkewMatrix(t *testing.T) {
+	t.Run("workers are split in halves", func(t *testing.T) {
+		cases := VersionSkewMatrix(skewNodeSet(5), CronCapability, "./v1/cron", "./v2/cron")
+		require.Len(t, cases, 4)
+		assert.Equal(t, "cron all baseline", cases[0].Name)
+		assert.Empty(t, cases[0].CandidateNodes)
+		assert.Equal(t, []int{1, 2}, cases[1].CandidateNodes, "bootstrap node isn't a worker")
+		assert.Equal(t, []int{3, 4}, cases[2].CandidateNodes)
+		assert.Equal(t, []int{1, 2, 3, 4}, cases[3].CandidateNodes)
+	})
+
+	t.Run("second half gets the odd node", func(t *testing.T) {
+		cases := VersionSkewMatrix(skewNodeSet(6), CronCapability, "./v1/cron", "./v2/cron")
+		assert.Equal(t, []int{1, 2}, cases[1].CandidateNodes)
+		assert.Equal(t, []int{3, 4, 5}, cases[2].CandidateNodes)
+	})
+
+	t.Run("nodes of given types", func(t *testing.T) {
+		cases := VersionSkewMatrix(skewNodeSet(3), CronCapability, "./v1/cron", "./v2/cron", BootstrapNode, WorkerNode)
+		assert.Equal(t, []int{0, 1, 2}, cases[3].CandidateNodes)
+	})
+}
+
+func TestApplyVersionSkew(t *testing.T) {
+	nodeSet := skewNodeSet(5)
+	nodeSet.NodeLabels = map[int][]string{4: {"canary"}}
+	nodeSet.LabelCapabilityBinaries = map[string]map[CapabilityFlag]string{"canary": {CronCapability: "./canary/cron"}}
+	cases := VersionSkewMatrix(nodeSet, CronCapability, "./v1/cron", "./v2/cron")
+
+	nodeSet.ApplyVersionSkew(cases[3])
+	nodeSet.ApplyVersionSkew(cases[1])
+	assert.Equal(t, "./v2/cron", nodeSet.NodeCapabilityBinaryPath(1, CronCapability, "./v1/cron"))
+	assert.Equal(t, "./v2/cron", nodeSet.NodeCapabilityBinaryPath(2, CronCapability, "./v1/cron"))
+	assert.Equal(t, "./v1/cron", nodeSet.NodeCapabilityBinaryPath(3, CronCapability, "./v1/cron"), "candidate of the previous case wasn't cleared")
+	assert.Equal(t, "./canary/cron", nodeSet.NodeCapabilityBinaryPath(4, CronCapability, "./v1/cron"), "label overrides are kept by cases")
+
+	nodeSet.ResetCapabilityBinaries(CronCapability)
+	assert.Equal(t, "./v1/cron", nodeSet.NodeCapabilityBinaryPath(4, CronCapability, "./v1/cron"))
+}
+
+func TestNodeCapabilityBinariesAreKeyedByNodeIndex(t *testing.T) {
+	nodeSet := skewNodeSet(4)
+	require.NoError(t, toml.Unmarshal([]byte(`
+[node_capability_binaries.3]
+cron = "./v2/cron"
+
+[node_labels]
+1 = ["canary"]
+`), nodeSet))
+	assert.Equal(t, map[int]map[CapabilityFlag]string{3: {CronCapability: "./v2/cron"}}, nodeSet.NodeCapabilityBinaries)
+	assert.Equal(t, []int{1}, nodeSet.NodesWithLabel("canary"))
+
+	configs := CapabilityConfigs{CronCapability: {BinaryPath: "./v1/cron"}}
+	require.NoError(t, nodeSet.ValidateNodeCapabilityBinaries(configs))
+
+	nodeSet.SetNodeCapabilityBinary(4, CronCapability, "./v2/cron")
+	require.ErrorContains(t, nodeSet.ValidateNodeCapabilityBinaries(configs), "invalid node index 4 in node capability binaries for nodeset workflow, it must be between 0 and 3")
+}
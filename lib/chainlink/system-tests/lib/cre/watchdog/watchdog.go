@@ -0,0 +1,351 @@
+// Package watchdog monitors the environment in the background during long tests. When nodes, chains or gateways die,
+// the test context is cancelled right away with diagnostics, instead of workflow assertions timing out much later.
+// The environment setup starts it, if environment.SetupInput.Watchdog is set, see environment.SetupOutput.Watchdog.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	solrpc "github.com/gagliardetto/solana-go/rpc"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/infra"
+)
+
+const (
+	DefaultInterval         = 15 * time.Second
+	DefaultFailureThreshold = 3
+)
+
+// BlockHeightFn returns the latest block (or slot) number of a chain
+type BlockHeightFn = func(ctx context.Context) (uint64, error)
+
+type Chain struct {
+	Name        string
+	BlockHeight BlockHeightFn
+}
+
+type Config struct {
+	Nodes       []*cre.Node
+	Chains      []Chain
+	GatewayURLs []string
+
+	Interval time.Duration // DefaultInterval is used if not set
+	// FailureThreshold is the number of consecutive failed checks of a node, gateway or chain RPC, after which the environment
+	// is considered dead. It tolerates restarts done on purpose by the test. DefaultFailureThreshold is used if not set.
+	FailureThreshold int
+	// MaxBlockStall is the longest time a chain may go without producing a block. Leave it at zero for chains that mine
+	// blocks only when there are transactions (Anvil default), then only their RPC endpoints are checked.
+	MaxBlockStall time.Duration
+	// if true, logs of exited containers are printed when the watchdog fires (Docker only)
+	PrintContainerLogs bool
+	// OnFailure is called when the watchdog fires, e.g. to send a notification with notify.Notifier.Failure
+	OnFailure func(err error)
+}
+
+// Options are settings of the watchdog started by the environment setup, which fills nodes, chains and gateways in
+type Options struct {
+	Interval         string `toml:"interval"`          // Go duration, DefaultInterval is used if not set
+	FailureThreshold int    `toml:"failure_threshold"` // DefaultFailureThreshold is used if not set
+	MaxBlockStall    string `toml:"max_block_stall"`   // Go duration, see Config.MaxBlockStall
+}
+
+func (o *Options) Validate() error {
+	for name, value := range map[string]string{"interval": o.Interval, "max block stall": o.MaxBlockStall} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid watchdog %s '%s', use a positive Go duration", name, value)
+		}
+	}
+	if o.FailureThreshold < 0 {
+		return fmt.Errorf("watchdog failure threshold can't be negative, got %d", o.FailureThreshold)
+	}
+
+	return nil
+}
+
+// Apply sets the options in the config, they must be valid
+func (o *Options) Apply(config *Config) {
+	if o.Interval != "" {
+		config.Interval, _ = time.ParseDuration(o.Interval)
+	}
+	if o.MaxBlockStall != "" {
+		config.MaxBlockStall, _ = time.ParseDuration(o.MaxBlockStall)
+	}
+	if o.FailureThreshold != 0 {
+		config.FailureThreshold = o.FailureThreshold
+	}
+}
+
+// ConfigFromEnvironment returns a config checking all nodes, chains and gateways of the environment
+func ConfigFromEnvironment(dons *cre.Dons, chains []blockchains.Blockchain) (*Config, error) {
+	config := &Config{PrintContainerLogs: true}
+	for _, don := range dons.List() {
+		config.Nodes = append(config.Nodes, don.Nodes...)
+	}
+
+	for _, bc := range chains {
+		chain, err := chainFromBlockchain(bc)
+		if err != nil {
+			return nil, err
+		}
+		config.Chains = append(config.Chains, chain)
+	}
+
+	if dons.GatewayConnectors != nil {
+		for _, gatewayConfig := range dons.GatewayConnectors.Configurations {
+			incoming := gatewayConfig.Incoming
+			config.GatewayURLs = append(config.GatewayURLs, fmt.Sprintf("%s://%s:%d%s", incoming.Protocol, incoming.Host, incoming.ExternalPort, incoming.Path))
+		}
+	}
+
+	return config, nil
+}
+
+func chainFromBlockchain(bc blockchains.Blockchain) (Chain, error) {
+	name := fmt.Sprintf("%s chain %d", bc.ChainFamily(), bc.ChainID())
+	out := bc.CtfOutput()
+	if out == nil || len(out.Nodes) == 0 {
+		return Chain{}, fmt.Errorf("%s has no nodes", name)
+	}
+	url := out.Nodes[0].ExternalHTTPUrl
+
+	switch {
+	case bc.IsFamily(blockchain.FamilyEVM), bc.IsFamily(blockchain.FamilyTron):
+		return Chain{Name: name, BlockHeight: func(ctx context.Context) (uint64, error) {
+			client, err := ethclient.DialContext(ctx, url)
+			if err != nil {
+				return 0, err
+			}
+			defer client.Close()
+			return client.BlockNumber(ctx)
+		}}, nil
+	case bc.IsFamily(blockchain.FamilySolana):
+		client := solrpc.New(url)
+		return Chain{Name: name, BlockHeight: func(ctx context.Context) (uint64, error) {
+			return client.GetSlot(ctx, solrpc.CommitmentProcessed)
+		}}, nil
+	default:
+		return Chain{}, fmt.Errorf("block production checks are not supported for %s", name)
+	}
+}
+
+type Watchdog struct {
+	config     Config
+	logger     zerolog.Logger
+	httpClient *http.Client
+
+	failures   map[string]int // consecutive failures per node, gateway or chain
+	lastErrors map[string]error
+	heights    map[string]uint64
+	progressAt map[string]time.Time
+
+	mu       sync.Mutex
+	err      error
+	ctx      context.Context
+	stopOnce sync.Once
+	stop     context.CancelFunc
+	done     chan struct{}
+}
+
+func New(logger zerolog.Logger, config Config) *Watchdog {
+	if config.Interval == 0 {
+		config.Interval = DefaultInterval
+	}
+	if config.FailureThreshold == 0 {
+		config.FailureThreshold = DefaultFailureThreshold
+	}
+
+	return &Watchdog{
+		config:     config,
+		logger:     logger,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		failures:   make(map[string]int),
+		lastErrors: make(map[string]error),
+		heights:    make(map[string]uint64),
+		progressAt: make(map[string]time.Time),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start runs the checks in the background and returns a context, which is cancelled when the environment dies.
+// Use it in workflow assertions, context.Cause returns the diagnostics. Stop must be called at the end of the test.
+func (w *Watchdog) Start(ctx context.Context) context.Context {
+	watchedCtx, cancel := context.WithCancelCause(ctx)
+	loopCtx, stop := context.WithCancel(watchedCtx)
+	w.stop = stop
+	w.mu.Lock()
+	w.ctx = watchedCtx
+	w.mu.Unlock()
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-loopCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := w.check(loopCtx); err != nil {
+				if loopCtx.Err() != nil {
+					// the test finished while the checks were running
+					return
+				}
+				w.mu.Lock()
+				w.err = err
+				w.mu.Unlock()
+
+				w.logger.Error().Err(err).Msg("Watchdog detected a dead environment, cancelling the test context")
+				if w.config.PrintContainerLogs {
+					infra.PrintFailedContainerLogs(w.logger, 30)
+				}
+				if w.config.OnFailure != nil {
+					w.config.OnFailure(err)
+				}
+				cancel(err)
+				return
+			}
+		}
+	}()
+
+	return watchedCtx
+}
+
+// Stop stops the checks and returns the error that made the watchdog fire, if any
+func (w *Watchdog) Stop() error {
+	w.stopOnce.Do(func() {
+		if w.stop != nil {
+			w.stop()
+			<-w.done
+		}
+	})
+
+	return w.Err()
+}
+
+// Context returns the context returned by Start, e.g. of the watchdog started by the environment setup. It's
+// context.Background() before Start is called.
+func (w *Watchdog) Context() context.Context {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ctx == nil {
+		return context.Background()
+	}
+
+	return w.ctx
+}
+
+func (w *Watchdog) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
+
+func (w *Watchdog) check(ctx context.Context) error {
+	var dead []string
+
+	for _, node := range w.config.Nodes {
+		_, _, err := node.Clients.RestClient.Health()
+		if w.record("node "+node.Name, err) {
+			dead = append(dead, fmt.Sprintf("node %s is unreachable: %s", node.Name, w.lastErrors["node "+node.Name]))
+		}
+	}
+
+	for _, url := range w.config.GatewayURLs {
+		if w.record("gateway "+url, w.checkGateway(ctx, url)) {
+			dead = append(dead, fmt.Sprintf("gateway %s is unreachable: %s", url, w.lastErrors["gateway "+url]))
+		}
+	}
+
+	for _, chain := range w.config.Chains {
+		if stalled := w.checkChain(ctx, chain); stalled != "" {
+			dead = append(dead, stalled)
+		}
+	}
+
+	if len(dead) > 0 {
+		return errors.Errorf("environment is dead:\n%s", strings.Join(dead, "\n"))
+	}
+
+	return nil
+}
+
+// record stores the result of a check and returns true, if the failure threshold was reached
+func (w *Watchdog) record(target string, err error) bool {
+	if err == nil {
+		w.failures[target] = 0
+		delete(w.lastErrors, target)
+		return false
+	}
+
+	w.failures[target]++
+	w.lastErrors[target] = err
+	w.logger.Warn().Err(err).Msgf("Watchdog check of %s failed (%d/%d)", target, w.failures[target], w.config.FailureThreshold)
+
+	return w.failures[target] >= w.config.FailureThreshold
+}
+
+func (w *Watchdog) checkGateway(ctx context.Context, url string) error {
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if reqErr != nil {
+		return reqErr
+	}
+	resp, respErr := w.httpClient.Do(req)
+	if respErr != nil {
+		return respErr
+	}
+	_ = resp.Body.Close()
+
+	// the gateway doesn't serve GET requests, any response below 500 means it's alive
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("gateway returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// checkChain returns a description of the problem, if the chain's RPC is unreachable or the chain didn't produce a block
+// for longer than MaxBlockStall
+func (w *Watchdog) checkChain(ctx context.Context, chain Chain) string {
+	height, err := chain.BlockHeight(ctx)
+	if w.record(chain.Name, err) {
+		return fmt.Sprintf("%s RPC is unreachable: %s", chain.Name, err)
+	}
+	if w.config.MaxBlockStall == 0 {
+		return ""
+	}
+
+	now := time.Now()
+	if _, seen := w.progressAt[chain.Name]; !seen {
+		w.progressAt[chain.Name] = now
+	}
+	if err == nil && height > w.heights[chain.Name] {
+		w.heights[chain.Name] = height
+		w.progressAt[chain.Name] = now
+		return ""
+	}
+
+	if stalledFor := now.Sub(w.progressAt[chain.Name]); stalledFor > w.config.MaxBlockStall {
+		return fmt.Sprintf("%s didn't produce a block for %s, last block: %d", chain.Name, stalledFor.Round(time.Second), w.heights[chain.Name])
+	}
+
+	return ""
+}
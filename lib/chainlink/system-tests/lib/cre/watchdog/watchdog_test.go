@@ -0,0 +1,56 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	options := &Options{Interval: "5s", FailureThreshold: 2, MaxBlockStall: "1m"}
+	require.NoError(t, options.Validate())
+
+	config := &Config{}
+	options.Apply(config)
+	assert.Equal(t, 5*time.Second, config.Interval)
+	assert.Equal(t, 2, config.FailureThreshold)
+	assert.Equal(t, time.Minute, config.MaxBlockStall)
+
+	config = &Config{Interval: time.Second, FailureThreshold: 5}
+	(&Options{}).Apply(config)
+	assert.Equal(t, &Config{Interval: time.Second, FailureThreshold: 5}, config, "unset options changed the config")
+
+	require.ErrorContains(t, (&Options{Interval: "soon"}).Validate(), "invalid watchdog interval 'soon'")
+	require.ErrorContains(t, (&Options{MaxBlockStall: "-1s"}).Validate(), "invalid watchdog max block stall '-1s'")
+	require.ErrorContains(t, (&Options{FailureThreshold: -1}).Validate(), "failure threshold can't be negative")
+}
+
+func TestWatchdogCancelsContextOfDeadEnvironment(t *testing.T) {
+	var reported error
+	w := New(zerolog.Nop(), Config{
+		Interval:         10 * time.Millisecond,
+		FailureThreshold: 2,
+		Chains: []Chain{{Name: "evm chain 1337", BlockHeight: func(context.Context) (uint64, error) {
+			return 0, errors.New("connection refused")
+		}}},
+		OnFailure: func(err error) { reported = err },
+	})
+	assert.Equal(t, context.Background(), w.Context(), "context of a watchdog, which wasn't started")
+
+	watchedCtx := w.Start(context.Background())
+	assert.Equal(t, watchedCtx, w.Context())
+	select {
+	case <-watchedCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchdog didn't cancel the context of the dead environment")
+	}
+
+	require.ErrorContains(t, context.Cause(watchedCtx), "evm chain 1337 RPC is unreachable: connection refused")
+	require.Equal(t, context.Cause(watchedCtx), w.Stop())
+	require.Equal(t, w.Err(), reported)
+}
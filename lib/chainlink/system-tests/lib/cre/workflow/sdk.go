@@ -0,0 +1,160 @@
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Language of the SDK a workflow is written with
+type Language string
+
+const (
+	LanguageGo         Language = "go"
+	LanguageTypeScript Language = "typescript"
+)
+
+// DefaultTypeScriptCompileCommand compiles a TypeScript workflow to WASM with the compiler shipped with the TypeScript SDK,
+// it's called with the entry file and the output path as the last two arguments
+var DefaultTypeScriptCompileCommand = []string{"bun", "x", "cre-compile"}
+
+// DefaultTypeScriptInstallCommand installs dependencies of a TypeScript workflow, if its package has no node_modules yet
+var DefaultTypeScriptInstallCommand = []string{"bun", "install"}
+
+// BuildInput describes a workflow written with any of the supported SDKs. Either SourcePath or PrebuiltPath must be set.
+type BuildInput struct {
+	Name string
+	// SourcePath is the entry file of the workflow, e.g. main.go or main.ts
+	SourcePath string
+	// Language is detected from the extension of SourcePath, if not set
+	Language Language
+	// PrebuiltPath is a WASM binary (.wasm) or an already compressed workflow (.br.b64) built by another toolchain,
+	// e.g. in the SDK's own CI, it's used instead of compiling SourcePath
+	PrebuiltPath string
+	// CompileCommand and InstallCommand override DefaultTypeScriptCompileCommand and DefaultTypeScriptInstallCommand,
+	// e.g. to use npm or a pinned compiler version
+	CompileCommand []string
+	InstallCommand []string
+}
+
+// DetectLanguage returns the language of the workflow based on the extension of its entry file
+func DetectLanguage(sourcePath string) (Language, error) {
+	switch filepath.Ext(sourcePath) {
+	case ".go":
+		return LanguageGo, nil
+	case ".ts", ".mts", ".js", ".mjs":
+		return LanguageTypeScript, nil
+	default:
+		return "", fmt.Errorf("can't detect SDK language of workflow %s, set it explicitly", sourcePath)
+	}
+}
+
+// BuildWorkflow compiles the workflow with its SDK's toolchain (or takes the prebuilt artifact) and returns the path
+// to the compressed workflow, which can be registered the same way regardless of the SDK it was written with
+func BuildWorkflow(input BuildInput) (string, error) {
+	if input.PrebuiltPath != "" {
+		return prebuiltWorkflow(input.PrebuiltPath)
+	}
+	if input.SourcePath == "" {
+		return "", errors.New("either source path or prebuilt path of the workflow must be set")
+	}
+
+	language := input.Language
+	if language == "" {
+		var detectErr error
+		language, detectErr = DetectLanguage(input.SourcePath)
+		if detectErr != nil {
+			return "", detectErr
+		}
+	}
+
+	switch language {
+	case LanguageGo:
+		return CompileWorkflow(input.SourcePath, input.Name)
+	case LanguageTypeScript:
+		compileCommand, installCommand := input.CompileCommand, input.InstallCommand
+		if len(compileCommand) == 0 {
+			compileCommand = DefaultTypeScriptCompileCommand
+		}
+		if len(installCommand) == 0 {
+			installCommand = DefaultTypeScriptInstallCommand
+		}
+		return compileTypeScriptWorkflow(input.SourcePath, input.Name, compileCommand, installCommand)
+	default:
+		return "", fmt.Errorf("unsupported workflow language '%s'", language)
+	}
+}
+
+func prebuiltWorkflow(prebuiltPath string) (string, error) {
+	absPath, absErr := filepath.Abs(prebuiltPath)
+	if absErr != nil {
+		return "", errors.Wrap(absErr, "failed to get absolute path of the prebuilt workflow")
+	}
+	if _, statErr := os.Stat(absPath); statErr != nil {
+		return "", errors.Wrap(statErr, "prebuilt workflow not found")
+	}
+
+	switch {
+	case strings.HasSuffix(absPath, ".br.b64"):
+		return absPath, nil
+	case strings.HasSuffix(absPath, ".wasm"):
+		return compressWorkflow(absPath)
+	default:
+		return "", fmt.Errorf("prebuilt workflow %s must be a .wasm or a .br.b64 file", prebuiltPath)
+	}
+}
+
+// compileTypeScriptWorkflow installs dependencies of the workflow's package, if they aren't installed yet, and compiles
+// it to <workflowName>.wasm next to the entry file
+func compileTypeScriptWorkflow(workflowFilePath, workflowName string, compileCommand, installCommand []string) (string, error) {
+	if len(workflowName) < 10 {
+		return "", errors.New("workflow name must be at least 10 characters long")
+	}
+	workflowDir := filepath.Dir(workflowFilePath)
+
+	_, packageErr := os.Stat(filepath.Join(workflowDir, "package.json"))
+	_, modulesErr := os.Stat(filepath.Join(workflowDir, "node_modules"))
+	if packageErr == nil && os.IsNotExist(modulesErr) {
+		if err := runToolchainCommand(workflowDir, installCommand[0], installCommand[1:]...); err != nil {
+			return "", errors.Wrap(err, "failed to install dependencies of the workflow")
+		}
+	}
+
+	workflowWasmAbsPath, absErr := filepath.Abs(filepath.Join(workflowDir, workflowName+".wasm"))
+	if absErr != nil {
+		return "", errors.Wrap(absErr, "failed to get absolute path of the workflow WASM file")
+	}
+	args := append(append([]string{}, compileCommand[1:]...), filepath.Base(workflowFilePath), workflowWasmAbsPath)
+	if err := runToolchainCommand(workflowDir, compileCommand[0], args...); err != nil {
+		return "", errors.Wrap(err, "failed to compile workflow")
+	}
+	defer func() {
+		_ = os.Remove(workflowWasmAbsPath)
+	}()
+
+	compressedWorkflowWasmPath, compressErr := compressWorkflow(workflowWasmAbsPath)
+	if compressErr != nil {
+		return "", errors.Wrap(compressErr, "failed to compress workflow")
+	}
+
+	return compressedWorkflowWasmPath, nil
+}
+
+func runToolchainCommand(dir, name string, args ...string) error {
+	buffer := bytes.Buffer{}
+	cmd := exec.Command(name, args...) // #nosec G204 -- the command comes from the test's configuration
+	cmd.Dir = dir
+	cmd.Stdout = &buffer
+	cmd.Stderr = &buffer
+	if err := cmd.Run(); err != nil {
+		fmt.Fprint(os.Stderr, buffer.String())
+		return errors.Wrapf(err, "'%s %s' failed", name, strings.Join(args, " "))
+	}
+
+	return nil
+}
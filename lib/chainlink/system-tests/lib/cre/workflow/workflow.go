@@ -15,7 +15,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/smartcontractkit/chainlink-deployments-framework/deployment"
@@ -27,6 +29,7 @@ import (
 
 	libc "github.com/smartcontractkit/chainlink/system-tests/lib/conversions"
 	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/contracts"
+	"github.com/smartcontractkit/chainlink/system-tests/lib/cre/environment/blockchains/evm"
 	libnet "github.com/smartcontractkit/chainlink/system-tests/lib/net"
 )
 
@@ -89,11 +92,11 @@ func RegisterWithContract(ctx context.Context, sc *seth.Client,
 	// Register workflow based on version
 	switch typeVersion.Version.Major() {
 	case 2:
-		if err := registerWorkflowV2(sc, workflowRegistryAddr, typeVersion, workflowName, workflowID, binaryURLToUse, configURLToUse); err != nil {
+		if err := registerWorkflowV2(ctx, sc, workflowRegistryAddr, typeVersion, workflowName, workflowID, binaryURLToUse, configURLToUse); err != nil {
 			return "", err
 		}
 	default:
-		if err := registerWorkflowV1(sc, workflowRegistryAddr, donID, workflowName, workflowID, binaryURLToUse, configURLToUse, secretsURLToUse); err != nil {
+		if err := registerWorkflowV1(ctx, sc, workflowRegistryAddr, donID, workflowName, workflowID, binaryURLToUse, configURLToUse, secretsURLToUse); err != nil {
 			return "", err
 		}
 	}
@@ -101,7 +104,16 @@ func RegisterWithContract(ctx context.Context, sc *seth.Client,
 	return workflowID, nil
 }
 
+// LinkOwner links the root key of the client to the default org in the workflow registry.
+//
+// Deprecated: use LinkOwnerWithContext, which sends the transaction with the tx manager of the context.
 func LinkOwner(sc *seth.Client, workflowRegistryAddr common.Address, tv deployment.TypeAndVersion) error {
+	return LinkOwnerWithContext(context.Background(), sc, workflowRegistryAddr, tv)
+}
+
+// LinkOwnerWithContext links the root key of the client to the default org in the workflow registry. The transaction
+// is sent with the tx manager of the context, if it has one, see evm.ContextWithTxManager.
+func LinkOwnerWithContext(ctx context.Context, sc *seth.Client, workflowRegistryAddr common.Address, tv deployment.TypeAndVersion) error {
 	switch tv.Version.Major() {
 	case 2:
 		validity := time.Now().UTC().Add(time.Hour * 24)
@@ -145,7 +157,9 @@ func LinkOwner(sc *seth.Client, workflowRegistryAddr common.Address, tv deployme
 
 		signature[64] += 27
 
-		_, err = sc.Decode(registry.LinkOwner(sc.NewTXOpts(), validityTimestamp, common.HexToHash(ownershipProof), signature))
+		_, err = sc.Decode(evm.SendWithSeth(ctx, sc, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return registry.LinkOwner(opts, validityTimestamp, common.HexToHash(ownershipProof), signature)
+		}))
 		if err != nil {
 			return err
 		}
@@ -216,7 +230,7 @@ func constructArtifactURL(originalURL string, artifactsDirInContainer *string) s
 }
 
 // registerWorkflowV2 handles workflow registration for v2 registry contracts
-func registerWorkflowV2(sc *seth.Client, workflowRegistryAddr common.Address, tv deployment.TypeAndVersion,
+func registerWorkflowV2(ctx context.Context, sc *seth.Client, workflowRegistryAddr common.Address, tv deployment.TypeAndVersion,
 	workflowName, workflowID, binaryURL, configURL string) error {
 	registry, err := getRegistryV2Instance(sc, workflowRegistryAddr, tv)
 	if err != nil {
@@ -226,24 +240,26 @@ func registerWorkflowV2(sc *seth.Client, workflowRegistryAddr common.Address, tv
 	// Check and link owner if needed using existing helper function
 	if verifyErr := verifyOwnerLinkedWithRegistry(registry, sc, workflowName); verifyErr != nil {
 		// If owner is not linked, try to link them
-		if linkErr := LinkOwner(sc, workflowRegistryAddr, tv); linkErr != nil {
+		if linkErr := LinkOwnerWithContext(ctx, sc, workflowRegistryAddr, tv); linkErr != nil {
 			return errors.Wrap(linkErr, "failed to link owner to org")
 		}
 	}
 
 	// Register workflow
-	_, err = sc.Decode(registry.UpsertWorkflow(
-		sc.NewTXOpts(),
-		workflowName,
-		defaultWorkflowTag,
-		[32]byte(common.Hex2Bytes(workflowID)),
-		defaultWorkflowStatus,
-		contracts.DonFamily,
-		binaryURL,
-		configURL,
-		nil,
-		false,
-	))
+	_, err = sc.Decode(evm.SendWithSeth(ctx, sc, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return registry.UpsertWorkflow(
+			opts,
+			workflowName,
+			defaultWorkflowTag,
+			[32]byte(common.Hex2Bytes(workflowID)),
+			defaultWorkflowStatus,
+			contracts.DonFamily,
+			binaryURL,
+			configURL,
+			nil,
+			false,
+		)
+	}))
 	if err != nil {
 		return errors.Wrap(err, "failed to register workflow")
 	}
@@ -252,7 +268,7 @@ func registerWorkflowV2(sc *seth.Client, workflowRegistryAddr common.Address, tv
 }
 
 // registerWorkflowV1 handles workflow registration for v1 registry contracts
-func registerWorkflowV1(sc *seth.Client, workflowRegistryAddr common.Address, donID uint64,
+func registerWorkflowV1(ctx context.Context, sc *seth.Client, workflowRegistryAddr common.Address, donID uint64,
 	workflowName, workflowID, binaryURL, configURL, secretsURL string) error {
 	registry, err := createRegistryV1Instance(sc, workflowRegistryAddr)
 	if err != nil {
@@ -260,16 +276,18 @@ func registerWorkflowV1(sc *seth.Client, workflowRegistryAddr common.Address, do
 	}
 
 	// Register workflow
-	_, err = sc.Decode(registry.RegisterWorkflow(
-		sc.NewTXOpts(),
-		workflowName,
-		[32]byte(common.Hex2Bytes(workflowID)),
-		libc.MustSafeUint32FromUint64(donID),
-		defaultWorkflowStatus,
-		binaryURL,
-		configURL,
-		secretsURL,
-	))
+	_, err = sc.Decode(evm.SendWithSeth(ctx, sc, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return registry.RegisterWorkflow(
+			opts,
+			workflowName,
+			[32]byte(common.Hex2Bytes(workflowID)),
+			libc.MustSafeUint32FromUint64(donID),
+			defaultWorkflowStatus,
+			binaryURL,
+			configURL,
+			secretsURL,
+		)
+	}))
 	if err != nil {
 		return errors.Wrap(err, "failed to register workflow")
 	}
@@ -298,7 +316,7 @@ func deleteAllWorkflowsV2(ctx context.Context, sc *seth.Client, workflowRegistry
 
 	// Delete each workflow using the same registry instance
 	for _, workflow := range workflows {
-		if _, err := sc.Decode(registry.DeleteWorkflow(sc.NewTXOpts(), workflow.WorkflowId)); err != nil {
+		if _, err := sc.Decode(deleteWorkflow(ctx, sc, registry.DeleteWorkflow, workflow.WorkflowId)); err != nil {
 			return errors.Wrapf(err, errDeleteWorkflow, workflow.WorkflowName)
 		}
 	}
@@ -323,7 +341,7 @@ func deleteAllWorkflowsV1(ctx context.Context, sc *seth.Client, workflowRegistry
 	// Delete each workflow using the same registry instance
 	for _, workflow := range workflows {
 		workflowHashKey := computeHashKey(sc.MustGetRootKeyAddress(), workflow.WorkflowName)
-		if _, err := sc.Decode(registry.DeleteWorkflow(sc.NewTXOpts(), workflowHashKey)); err != nil {
+		if _, err := sc.Decode(deleteWorkflow(ctx, sc, registry.DeleteWorkflow, workflowHashKey)); err != nil {
 			return errors.Wrapf(err, errDeleteWorkflow, workflow.WorkflowName)
 		}
 	}
@@ -331,6 +349,14 @@ func deleteAllWorkflowsV1(ctx context.Context, sc *seth.Client, workflowRegistry
 	return nil
 }
 
+// deleteWorkflow sends the deletion with the tx manager of the context, see LinkOwnerWithContext. The key is the
+// workflow ID (v2) or the hash key (v1).
+func deleteWorkflow(ctx context.Context, sc *seth.Client, deleteFn func(opts *bind.TransactOpts, key [32]byte) (*types.Transaction, error), key [32]byte) (*types.Transaction, error) {
+	return evm.SendWithSeth(ctx, sc, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return deleteFn(opts, key)
+	})
+}
+
 // computeHashKey generates a Keccak256 hash from owner address and workflow name.
 // This is used for v1 workflow registry contract operations.
 func computeHashKey(owner common.Address, workflowName string) [32]byte {
@@ -364,7 +390,7 @@ func deleteWorkflowV2(ctx context.Context, sc *seth.Client, workflowRegistryAddr
 	}
 
 	// Delete workflow using the same registry instance
-	if _, err := sc.Decode(registry.DeleteWorkflow(sc.NewTXOpts(), workflowID)); err != nil {
+	if _, err := sc.Decode(deleteWorkflow(ctx, sc, registry.DeleteWorkflow, workflowID)); err != nil {
 		return errors.Wrapf(err, "failed to delete workflow %q (ID: %x)", workflowName, workflowID)
 	}
 
@@ -381,7 +407,7 @@ func deleteWorkflowV1(ctx context.Context, sc *seth.Client,
 	}
 
 	workflowHashKey := computeHashKey(sc.MustGetRootKeyAddress(), workflowName)
-	if _, err := sc.Decode(registry.DeleteWorkflow(sc.NewTXOpts(), workflowHashKey)); err != nil {
+	if _, err := sc.Decode(deleteWorkflow(ctx, sc, registry.DeleteWorkflow, workflowHashKey)); err != nil {
 		return errors.Wrapf(err, "failed to delete workflow %q", workflowName)
 	}
 
@@ -0,0 +1,9 @@
+package crypto
+
+// OCR2Key is an OCR2 key bundle exported from a node (see clclient.ExportOCR2Key), which can be imported to another node.
+// Nodes generate bundles on their own, so there is no constructor.
+type OCR2Key struct {
+	ChainType     string // e.g. evm, solana, aptos
+	EncryptedJSON []byte
+	Password      string
+}
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/gagliardetto/solana-go"
@@ -30,6 +31,9 @@ type FundsToSend struct {
 	GasTipCap  *big.Int
 	TxTimeout  *time.Duration
 	Nonce      *uint64
+	// TXOpts is optional, e.g. evm.TxManager.SethTXOpts, the nonce and fees are taken from transact options it returns
+	// instead of the chain and SethClient.NewTXOpts(), so that the nonce doesn't race with other transactions of the key
+	TXOpts func(o ...seth.TransactOpt) (*bind.TransactOpts, error)
 }
 
 type FundsToSendSol struct {
@@ -103,14 +107,17 @@ func SendFunds(ctx context.Context, logger zerolog.Logger, client *seth.Client,
 	}
 
 	var nonce uint64
-	if payload.Nonce == nil {
+	switch {
+	case payload.TXOpts != nil:
+		// allocated with transact options, once the gas limit is known
+	case payload.Nonce == nil:
 		nonceCtx, cancel := context.WithTimeout(ctx, client.Cfg.Network.TxnTimeout.Duration())
 		nonce, err = client.Client.PendingNonceAt(nonceCtx, fromAddress)
 		defer cancel()
 		if err != nil {
 			return nil, err
 		}
-	} else {
+	default:
 		nonce = *payload.Nonce
 	}
 
@@ -134,11 +141,21 @@ func SendFunds(ctx context.Context, logger zerolog.Logger, client *seth.Client,
 		gasLimit = uint64(*payload.GasLimit)
 	}
 
+	newTXOpts := func() *bind.TransactOpts { return client.NewTXOpts(seth.WithGasLimit(gasLimit)) }
+	if payload.TXOpts != nil {
+		txOpts, txOptsErr := payload.TXOpts(seth.WithGasLimit(gasLimit))
+		if txOptsErr != nil {
+			return nil, errors.Wrap(txOptsErr, "failed to get transact options")
+		}
+		nonce = txOpts.Nonce.Uint64()
+		newTXOpts = func() *bind.TransactOpts { return txOpts }
+	}
+
 	if client.Cfg.Network.EIP1559DynamicFees {
 		// if any of the dynamic fees are not set, we need to either estimate them or read them from config
 		if payload.GasFeeCap == nil || payload.GasTipCap == nil {
 			// estimation or config reading happens here
-			txOptions := client.NewTXOpts(seth.WithGasLimit(gasLimit))
+			txOptions := newTXOpts()
 			gasFeeCap = txOptions.GasFeeCap
 			gasTipCap = txOptions.GasTipCap
 		}
@@ -153,7 +170,7 @@ func SendFunds(ctx context.Context, logger zerolog.Logger, client *seth.Client,
 		}
 	} else {
 		if payload.GasPrice == nil {
-			txOptions := client.NewTXOpts(seth.WithGasLimit(gasLimit))
+			txOptions := newTXOpts()
 			gasPrice = txOptions.GasPrice
 		} else {
 			gasPrice = payload.GasPrice
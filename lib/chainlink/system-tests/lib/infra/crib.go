@@ -3,23 +3,52 @@ package infra
 import (
 	"fmt"
 	"strings"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/clnode"
 )
 
 type Type = string
 type CribProvider = string
 
 const (
-	CRIB   Type         = "crib"
-	Docker Type         = "docker"
-	AWS    CribProvider = "aws"
-	Kind   CribProvider = "kind"
+	CRIB       Type         = "crib"
+	Docker     Type         = "docker"
+	Kubernetes Type         = "kubernetes"
+	AWS        CribProvider = "aws"
+	Kind       CribProvider = "kind"
 
 	CribConfigsDir = "crib-configs"
+
+	// CRIBCapabilitiesDir holds capability binaries in pods of CRIB nodes, the chainlink user always has access to it
+	CRIBCapabilitiesDir = "/home/chainlink"
 )
 
+// CapabilitiesContainerDir returns the default directory of capability binaries in containers (or pods) of nodes, which
+// node specs can override. It's false for unknown infra types.
+func CapabilitiesContainerDir(infraType Type) (string, bool) {
+	switch infraType {
+	case CRIB:
+		return CRIBCapabilitiesDir, true
+	case Docker:
+		// needs to match what CTFv2 uses by default
+		return clnode.DefaultCapabilitiesDir, true
+	case Kubernetes:
+		// binaries are copied there after pods of nodes are running, see CopyFileToKubernetesPod
+		return KubernetesCapabilitiesDir, true
+	default:
+		return "", false
+	}
+}
+
 type Provider struct {
-	Type string     `toml:"type" validate:"oneof=crib docker"`
-	CRIB *CRIBInput `toml:"crib"`
+	Type       string           `toml:"type" validate:"oneof=crib docker kubernetes"`
+	CRIB       *CRIBInput       `toml:"crib"`
+	Kubernetes *KubernetesInput `toml:"kubernetes" validate:"required_if=Type kubernetes"`
+	// ReadOnly is set when attaching to a shared environment for investigation: nodes can't be killed, stopped or started
+	// and only read-only commands can be executed in them
+	ReadOnly bool `toml:"read_only"`
+	// Safety restricts what destructive operations may target, defaults allow only local clusters, see CheckSafeTarget
+	Safety *SafetyInput `toml:"safety"`
 }
 
 func (i *Provider) IsCRIB() bool {
@@ -30,28 +59,29 @@ func (i *Provider) IsDocker() bool {
 	return strings.EqualFold(i.Type, Docker)
 }
 
+// CRIBNodeName returns the name Helm charts used by CRIB give to the node with given index in given DON, i.e. to its
+// service and app instance: <DON name>-<node index> or <DON name>-bt-<node index> for bootstrap nodes
+func CRIBNodeName(nodeIndex int, isBootstrap bool, donName string) string {
+	if isBootstrap {
+		return fmt.Sprintf("%s-bt-%d", donName, nodeIndex)
+	}
+
+	return fmt.Sprintf("%s-%d", donName, nodeIndex)
+}
+
 // Unfortunately, we need to construct some of these URLs before any environment is created, because they are used
 // in CL node configs. This introduces a coupling between Helm charts used by CRIB and Docker container names used by CTFv2.
 func (i *Provider) InternalHost(nodeIndex int, isBootstrap bool, donName string) string {
-	if i.IsCRIB() {
-		if isBootstrap {
-			return fmt.Sprintf("%s-bt-%d", donName, nodeIndex)
-		}
-		return fmt.Sprintf("%s-%d", donName, nodeIndex)
+	if i.IsCRIB() || i.IsKubernetes() {
+		return CRIBNodeName(nodeIndex, isBootstrap, donName)
 	}
 
 	return fmt.Sprintf("%s-node%d", donName, nodeIndex)
 }
 
 func (i *Provider) InternalGatewayHost(nodeIndex int, isBootstrap bool, donName string) string {
-	if i.IsCRIB() {
-		host := fmt.Sprintf("%s-%d", donName, nodeIndex)
-		if isBootstrap {
-			host = fmt.Sprintf("%s-bt-%d", donName, nodeIndex)
-		}
-		host += "-gtwnode"
-
-		return host
+	if i.IsCRIB() || i.IsKubernetes() {
+		return CRIBNodeName(nodeIndex, isBootstrap, donName) + "-gtwnode"
 	}
 
 	return fmt.Sprintf("%s-node%d", donName, nodeIndex)
@@ -80,6 +110,24 @@ type CRIBInput struct {
 	Provider       string `toml:"provider" validate:"oneof=aws kind"`
 	// required for cost attribution in AWS
 	TeamInput *Team `toml:"team_input" validate:"required_if=Provider aws"`
+	// optional, limits resources requested for the deployment, crib.DefaultBudget is used if not set, see crib.CheckBudget
+	Budget *CRIBBudget `toml:"budget"`
+	// optional, kubeconfig context of the cluster CRIB deploys to, destructive operations may target only it, if it's set.
+	// The current context is used if not set, see CheckSafeNamespace.
+	KubeContext string `toml:"kube_context"`
+}
+
+// CRIBBudget prevents accidental deployments of giant topologies to the shared cluster. Deployments whose estimated
+// resources exceed any of the limits are rejected, unless AllowOverBudget is set. CPU and memory limits, which aren't
+// set, default to crib.DefaultBudget (also used without a budget). The cost is checked only if MaxCost is set.
+type CRIBBudget struct {
+	MaxCPUCores       float64 `toml:"max_cpu_cores"`
+	MaxMemoryGiB      float64 `toml:"max_memory_gib"`
+	MaxCost           float64 `toml:"max_cost"`
+	DurationHours     float64 `toml:"duration_hours"`       // expected lifetime of the deployment, used for cost estimation, 1 hour if not set
+	CPUCoreHourCost   float64 `toml:"cpu_core_hour_cost"`   // optional, used for cost estimation
+	MemoryGiBHourCost float64 `toml:"memory_gib_hour_cost"` // optional, used for cost estimation
+	AllowOverBudget   bool    `toml:"allow_over_budget"`
 }
 
 // k8s cost attribution
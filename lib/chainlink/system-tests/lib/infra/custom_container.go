@@ -0,0 +1,203 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	dc "github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"github.com/pkg/errors"
+	tc "github.com/testcontainers/testcontainers-go"
+	tcwait "github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+)
+
+// CustomContainerInput describes an extra container (e.g. Kafka, Redis, a custom mock) started in the CTF Docker network
+// alongside the environment, so that it's reachable by nodes under its name.
+type CustomContainerInput struct {
+	Name    string            `toml:"name" validate:"required"`
+	Image   string            `toml:"image" validate:"required"`
+	Env     map[string]string `toml:"env"`
+	Command []string          `toml:"command"`
+	// Ports are given as "host_port:container_port" or "port", then the same port is used on the host
+	Ports []string `toml:"ports"`
+	// Networks the container is connected to in addition to the CTF network, they must already exist
+	Networks []string `toml:"networks"`
+	// if true, the container is considered started once all ports are listening
+	WaitForPorts bool `toml:"wait_for_ports"`
+	// optional, nodes are started only once the probe succeeds, see cre.ReadinessCheck
+	ReadinessProbe *HTTPProbeInput `toml:"readiness_probe"`
+	// set by the environment, e.g. to common labels of its ResourceIndex, so that cleanup can match the container
+	Labels Labels `toml:"-"`
+
+	Out *CustomContainerOutput `toml:"out"`
+}
+
+// HTTPProbeInput is an HTTP endpoint of a container, which responds with a 2xx status once the container is ready
+type HTTPProbeInput struct {
+	Port int    `toml:"port" validate:"required"` // container port, it must be one of the published ones
+	Path string `toml:"path"`
+}
+
+type CustomContainerOutput struct {
+	UseCache      bool           `toml:"use_cache" json:"use_cache"`
+	ContainerName string         `toml:"container_name" json:"container_name"`
+	InternalHost  string         `toml:"internal_host" json:"internal_host"` // host name inside the Docker network
+	ExternalHost  string         `toml:"external_host" json:"external_host"`
+	Ports         map[string]int `toml:"ports" json:"ports"` // container port -> host port
+}
+
+func (c *CustomContainerInput) Validate() error {
+	if c.Name == "" {
+		return errors.New("custom container name is required")
+	}
+	if c.Image == "" {
+		return fmt.Errorf("image of custom container %s is required", c.Name)
+	}
+	bindings, bindingsErr := c.portBindings()
+	if bindingsErr != nil {
+		return fmt.Errorf("invalid ports of custom container %s: %w", c.Name, bindingsErr)
+	}
+	if c.ReadinessProbe != nil {
+		if _, ok := bindings[c.ReadinessProbe.Port]; !ok {
+			return fmt.Errorf("readiness probe of custom container %s uses port %d, which isn't published, add it to ports", c.Name, c.ReadinessProbe.Port)
+		}
+	}
+
+	return nil
+}
+
+// portBindings returns host ports keyed by container ports
+func (c *CustomContainerInput) portBindings() (map[int]int, error) {
+	bindings := make(map[int]int, len(c.Ports))
+	for _, port := range c.Ports {
+		hostPart, containerPart, found := strings.Cut(port, ":")
+		if !found {
+			containerPart = hostPart
+		}
+
+		containerPort, cErr := strconv.Atoi(containerPart)
+		if cErr != nil {
+			return nil, fmt.Errorf("invalid container port in '%s'", port)
+		}
+		hostPort, hErr := strconv.Atoi(hostPart)
+		if hErr != nil {
+			return nil, fmt.Errorf("invalid host port in '%s'", port)
+		}
+		bindings[containerPort] = hostPort
+	}
+
+	return bindings, nil
+}
+
+// StartCustomContainer starts the container in the CTF Docker network. If the output is cached, nothing is started.
+func StartCustomContainer(ctx context.Context, in *CustomContainerInput) (*CustomContainerOutput, error) {
+	if in == nil {
+		return nil, errors.New("custom container input is nil")
+	}
+	if in.Out != nil && in.Out.UseCache {
+		return in.Out, nil
+	}
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+
+	bindings, _ := in.portBindings()
+	containerPorts := slices.Sorted(maps.Keys(bindings))
+
+	exposedPorts := make([]string, 0, len(bindings))
+	portMap := nat.PortMap{}
+	waitStrategies := make([]tcwait.Strategy, 0, len(bindings))
+	for _, containerPort := range containerPorts {
+		natPort := nat.Port(fmt.Sprintf("%d/tcp", containerPort))
+		exposedPorts = append(exposedPorts, string(natPort))
+		portMap[natPort] = []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: strconv.Itoa(bindings[containerPort])}}
+		waitStrategies = append(waitStrategies, tcwait.ForListeningPort(natPort))
+	}
+
+	containerName := framework.DefaultTCName(in.Name)
+	req := tc.ContainerRequest{
+		Name:     containerName,
+		Image:    in.Image,
+		Env:      in.Env,
+		Cmd:      in.Command,
+		Labels:   DockerLabels(in.Labels),
+		Networks: append([]string{framework.DefaultNetworkName}, in.Networks...),
+		NetworkAliases: map[string][]string{
+			framework.DefaultNetworkName: {containerName, in.Name},
+		},
+		ExposedPorts: exposedPorts,
+		HostConfigModifier: func(h *container.HostConfig) {
+			h.PortBindings = portMap
+		},
+	}
+	if in.WaitForPorts && len(waitStrategies) > 0 {
+		req.WaitingFor = tcwait.ForAll(waitStrategies...)
+	}
+
+	c, cErr := tc.GenericContainer(ctx, tc.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if cErr != nil {
+		// containers, which were created, but didn't become ready, aren't left behind
+		if c != nil {
+			_ = c.Terminate(context.WithoutCancel(ctx))
+		}
+		return nil, errors.Wrapf(cErr, "failed to start custom container %s", in.Name)
+	}
+
+	host, hErr := framework.GetHost(c)
+	if hErr != nil {
+		_ = c.Terminate(context.WithoutCancel(ctx))
+		return nil, errors.Wrapf(hErr, "failed to get host of custom container %s", in.Name)
+	}
+
+	out := &CustomContainerOutput{
+		UseCache:      true,
+		ContainerName: containerName,
+		InternalHost:  in.Name,
+		ExternalHost:  host,
+		Ports:         make(map[string]int, len(bindings)),
+	}
+	for containerPort, hostPort := range bindings {
+		out.Ports[strconv.Itoa(containerPort)] = hostPort
+	}
+	in.Out = out
+
+	return out, nil
+}
+
+// ReadinessProbeURL returns the URL of the readiness probe reachable from the host, or an empty string if the container has no probe
+func (c *CustomContainerInput) ReadinessProbeURL() string {
+	if c.ReadinessProbe == nil || c.Out == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("http://%s:%d/%s", c.Out.ExternalHost, c.Out.Ports[strconv.Itoa(c.ReadinessProbe.Port)], strings.TrimPrefix(c.ReadinessProbe.Path, "/"))
+}
+
+// RemoveCustomContainer removes the container started by StartCustomContainer together with its anonymous volumes
+func RemoveCustomContainer(ctx context.Context, out *CustomContainerOutput) error {
+	if out == nil || out.ContainerName == "" {
+		return nil
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	if err := dockerClient.ContainerRemove(ctx, out.ContainerName, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+		return errors.Wrapf(err, "failed to remove custom container %s", out.ContainerName)
+	}
+
+	return nil
+}
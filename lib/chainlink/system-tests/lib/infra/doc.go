@@ -0,0 +1,18 @@
+// Package infra abstracts infrastructure, on which environments run: Docker, CRIB and Kubernetes clusters.
+//
+// # API stability
+//
+// Provider and its inputs decoded from TOML, Executor and its implementations, and helpers of Docker containers used by
+// tests (PullDockerImage, CopyFileToDockerContainer, PrintFailedContainerLogs) are stable. Naming helpers (NodeAlias,
+// InternalHost, NodeLabelSelector) are stable as well, because deployments outside of the framework rely on them.
+// The Kubernetes infra type and ReadOnlyExecutor are still experimental: labels and services the former expects and
+// commands the latter allows may change in any release. Deprecated identifiers are kept for at least one release.
+//
+// # Guardrails
+//
+// Destructive operations (KillNode, StopNode, RestartNode, RemoveDockerResources) first check with CheckSafeTarget that
+// they target test infra: Docker containers created by the framework, or a local Kubernetes cluster, the cluster CRIB
+// deploys to or other clusters allowed by Provider.Safety, and a namespace carrying the label it requires. Operations
+// outside of this package, which replace binaries or databases of nodes, recreate their containers, remove resources
+// or inject chaos into pods, call CheckSafeTarget, CheckSafeNamespace or CheckSafeDockerContainer first.
+package infra
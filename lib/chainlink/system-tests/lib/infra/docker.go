@@ -1,15 +1,25 @@
 package infra
 
 import (
+	"archive/tar"
+	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"maps"
+	"os"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	dc "github.com/docker/docker/client"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 
 	"github.com/smartcontractkit/chainlink-testing-framework/framework"
@@ -63,3 +73,168 @@ func PrintFailedContainerLogs(logger zerolog.Logger, logLinesCount uint64) {
 		_ = ioReader.Close() // can't do much about the error here
 	}
 }
+
+// AddDockerNetworkAliases makes a container reachable by additional names in the CTF Docker network and returns its IP
+// address in the network. Docker doesn't allow adding aliases to an existing endpoint, so the container is reconnected,
+// which may change its IP address. A running container is stopped before and started after it, so that its process
+// starts with fresh connections instead of keeping ones broken by the reconnect. Callers have to wait until it's ready.
+func AddDockerNetworkAliases(ctx context.Context, containerName string, aliases ...string) (string, error) {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return "", errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	inspect, inspectErr := dockerClient.ContainerInspect(ctx, containerName)
+	if inspectErr != nil {
+		return "", errors.Wrapf(inspectErr, "failed to inspect container %s", containerName)
+	}
+
+	currentAliases := []string{containerName}
+	ipAddress := ""
+	if inspect.NetworkSettings != nil {
+		if endpoint, ok := inspect.NetworkSettings.Networks[framework.DefaultNetworkName]; ok && endpoint != nil {
+			currentAliases = append(currentAliases, endpoint.Aliases...)
+			ipAddress = endpoint.IPAddress
+		}
+	}
+
+	newAliases := slices.Clone(currentAliases)
+	for _, alias := range aliases {
+		if !slices.Contains(newAliases, alias) {
+			newAliases = append(newAliases, alias)
+		}
+	}
+	if len(newAliases) == len(currentAliases) {
+		return ipAddress, nil
+	}
+
+	running := inspect.State != nil && inspect.State.Running
+	if running {
+		if err := dockerClient.ContainerStop(ctx, inspect.ID, container.StopOptions{}); err != nil {
+			return "", errors.Wrapf(err, "failed to stop container %s before adding network aliases", containerName)
+		}
+	}
+	if err := dockerClient.NetworkDisconnect(ctx, framework.DefaultNetworkName, inspect.ID, false); err != nil {
+		return "", errors.Wrapf(err, "failed to disconnect container %s from network %s", containerName, framework.DefaultNetworkName)
+	}
+	if err := dockerClient.NetworkConnect(ctx, framework.DefaultNetworkName, inspect.ID, &network.EndpointSettings{Aliases: newAliases}); err != nil {
+		return "", errors.Wrapf(err, "failed to reconnect container %s to network %s with aliases %s", containerName, framework.DefaultNetworkName, strings.Join(aliases, ", "))
+	}
+	if running {
+		if err := dockerClient.ContainerStart(ctx, inspect.ID, container.StartOptions{}); err != nil {
+			return "", errors.Wrapf(err, "failed to start container %s after adding network aliases", containerName)
+		}
+	}
+
+	reconnected, reinspectErr := dockerClient.ContainerInspect(ctx, inspect.ID)
+	if reinspectErr != nil {
+		return "", errors.Wrapf(reinspectErr, "failed to inspect container %s", containerName)
+	}
+	if reconnected.NetworkSettings != nil {
+		if endpoint, ok := reconnected.NetworkSettings.Networks[framework.DefaultNetworkName]; ok && endpoint != nil {
+			return endpoint.IPAddress, nil
+		}
+	}
+
+	return "", fmt.Errorf("container %s isn't connected to network %s after adding network aliases", containerName, framework.DefaultNetworkName)
+}
+
+// PullDockerImage pulls the image, unless it's already present locally. Pulling it before containers are created allows
+// limiting the time spent on it separately from the container startup.
+func PullDockerImage(ctx context.Context, imageName string) error {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	if _, inspectErr := dockerClient.ImageInspect(ctx, imageName); inspectErr == nil {
+		return nil
+	}
+
+	reader, pullErr := dockerClient.ImagePull(ctx, imageName, image.PullOptions{})
+	if pullErr != nil {
+		return errors.Wrapf(pullErr, "failed to pull image %s", imageName)
+	}
+	defer reader.Close()
+
+	// the pull completes only once the progress stream is fully read
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return errors.Wrapf(err, "failed to pull image %s", imageName)
+	}
+
+	return nil
+}
+
+// DockerContainerState is the state of a container as reported by Docker, Image is the reference it was created from
+type DockerContainerState struct {
+	ID      string
+	Image   string
+	Running bool
+}
+
+// InspectDockerContainer returns the state of the container, it fails if there's no container of that name
+func InspectDockerContainer(ctx context.Context, containerName string) (*DockerContainerState, error) {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return nil, errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	inspected, inspectErr := dockerClient.ContainerInspect(ctx, containerName)
+	if inspectErr != nil {
+		return nil, errors.Wrapf(inspectErr, "failed to inspect container %s", containerName)
+	}
+
+	state := &DockerContainerState{ID: inspected.ID}
+	if inspected.Config != nil {
+		state.Image = inspected.Config.Image
+	}
+	if inspected.State != nil {
+		state.Running = inspected.State.Running
+	}
+
+	return state, nil
+}
+
+// CopyFileToDockerContainer copies an executable file to the directory in a running container, owned by given UID and GID.
+// Unlike files copied by CTF, which are always owned by root, it can be executed by a non-root user of hardened images.
+func CopyFileToDockerContainer(ctx context.Context, containerName, hostPath, containerDir string, uid, gid int) error {
+	content, readErr := os.ReadFile(hostPath)
+	if readErr != nil {
+		return errors.Wrapf(readErr, "failed to read file %s", hostPath)
+	}
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	header := &tar.Header{
+		Name:    filepath.Base(hostPath),
+		Mode:    0o755,
+		Size:    int64(len(content)),
+		Uid:     uid,
+		Gid:     gid,
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return errors.Wrap(err, "failed to write tar header")
+	}
+	if _, err := tw.Write(content); err != nil {
+		return errors.Wrap(err, "failed to write file to tar archive")
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close tar archive")
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	if err := dockerClient.CopyToContainer(ctx, containerName, containerDir, &archive, container.CopyToContainerOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to copy %s to %s in container %s", hostPath, containerDir, containerName)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package infra
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	dc "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// LabelComposeProject is set by docker compose on every container, volume and network of a project (stack)
+const LabelComposeProject = "com.docker.compose.project"
+
+// DockerComposeProjects returns sorted names of docker compose projects with given prefix, which have containers
+// (running or not). Stacks started by CTF get an identifier with a random suffix, so comparing projects before and
+// after a start is the only way to find the stack it created, see RemoveDockerComposeProject.
+func DockerComposeProjects(ctx context.Context, prefix string) ([]string, error) {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return nil, errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	containers, err := dockerClient.ContainerList(ctx, container.ListOptions{All: true, Filters: filters.NewArgs(filters.Arg("label", LabelComposeProject))})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list Docker containers")
+	}
+
+	projects := make([]string, 0)
+	for _, c := range containers {
+		project := c.Labels[LabelComposeProject]
+		if strings.HasPrefix(project, prefix) && !slices.Contains(projects, project) {
+			projects = append(projects, project)
+		}
+	}
+	slices.Sort(projects)
+
+	return projects, nil
+}
+
+// RemoveDockerComposeProject removes containers, volumes and networks of a docker compose project, like
+// `docker compose down --volumes` does. Containers are removed first, because volumes and networks can't be removed
+// while they are in use.
+func RemoveDockerComposeProject(ctx context.Context, project string) error {
+	if project == "" {
+		return errors.New("docker compose project is required")
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	projectFilter := filters.NewArgs(filters.Arg("label", LabelComposeProject+"="+project))
+	containers, listErr := dockerClient.ContainerList(ctx, container.ListOptions{All: true, Filters: projectFilter})
+	if listErr != nil {
+		return errors.Wrap(listErr, "failed to list Docker containers")
+	}
+	for _, c := range containers {
+		if err := dockerClient.ContainerRemove(ctx, c.ID, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			return errors.Wrapf(err, "failed to remove container %s of docker compose project %s", strings.Join(c.Names, ","), project)
+		}
+	}
+
+	volumes, volumesErr := dockerClient.VolumeList(ctx, volume.ListOptions{Filters: projectFilter})
+	if volumesErr != nil {
+		return errors.Wrap(volumesErr, "failed to list Docker volumes")
+	}
+	for _, v := range volumes.Volumes {
+		if err := dockerClient.VolumeRemove(ctx, v.Name, true); err != nil {
+			return errors.Wrapf(err, "failed to remove volume %s of docker compose project %s", v.Name, project)
+		}
+	}
+
+	networks, networksErr := dockerClient.NetworkList(ctx, network.ListOptions{Filters: projectFilter})
+	if networksErr != nil {
+		return errors.Wrap(networksErr, "failed to list Docker networks")
+	}
+	for _, n := range networks {
+		if err := dockerClient.NetworkRemove(ctx, n.ID); err != nil {
+			return errors.Wrapf(err, "failed to remove network %s of docker compose project %s", n.Name, project)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package infra
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	dc "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+)
+
+// DockerVolumeHasFile reports whether the named volume mounted at mountPath has a file at filePath. The volume is read
+// through a container, which is created from the image and removed without being started, so the image needs no tools.
+func DockerVolumeHasFile(ctx context.Context, volume, imageName, mountPath, filePath string) (bool, error) {
+	var found bool
+	err := withDockerVolume(ctx, volume, imageName, mountPath, func(dockerClient *dc.Client, containerID string) error {
+		_, statErr := dockerClient.ContainerStatPath(ctx, containerID, filePath)
+		if dc.IsErrNotFound(statErr) {
+			return nil
+		}
+		if statErr != nil {
+			return errors.Wrapf(statErr, "failed to stat %s in volume %s", filePath, volume)
+		}
+		found = true
+
+		return nil
+	})
+
+	return found, err
+}
+
+// CopyFileToDockerVolume copies an executable file to filePath in the named volume mounted at mountPath, creating its
+// parent directories. Like DockerVolumeHasFile it uses a container, which is never started. The file is streamed, so
+// binaries of any size can be copied without reading them into memory.
+func CopyFileToDockerVolume(ctx context.Context, volume, imageName, mountPath, hostPath, filePath string) error {
+	relPath, ok := strings.CutPrefix(path.Clean(filePath), path.Clean(mountPath)+"/")
+	if !ok {
+		return fmt.Errorf("%s isn't in volume %s mounted at %s", filePath, volume, mountPath)
+	}
+	file, openErr := os.Open(hostPath)
+	if openErr != nil {
+		return errors.Wrapf(openErr, "failed to open file %s", hostPath)
+	}
+	defer file.Close()
+	info, statErr := file.Stat()
+	if statErr != nil {
+		return errors.Wrapf(statErr, "failed to stat file %s", hostPath)
+	}
+
+	return withDockerVolume(ctx, volume, imageName, mountPath, func(dockerClient *dc.Client, containerID string) error {
+		reader, writer := io.Pipe()
+		go func() {
+			writer.CloseWithError(writeFileArchive(writer, file, relPath, info))
+		}()
+		defer reader.Close()
+
+		if err := dockerClient.CopyToContainer(ctx, containerID, mountPath, reader, container.CopyToContainerOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to copy %s to %s in volume %s", hostPath, filePath, volume)
+		}
+
+		return nil
+	})
+}
+
+// writeFileArchive writes a tar archive with the file at relPath and entries of all its parent directories
+func writeFileArchive(w io.Writer, file io.Reader, relPath string, info os.FileInfo) error {
+	tw := tar.NewWriter(w)
+	var dir string
+	for _, part := range strings.Split(path.Dir(relPath), "/") {
+		if part == "." {
+			break
+		}
+		dir = path.Join(dir, part)
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0o755, ModTime: info.ModTime()}); err != nil {
+			return errors.Wrap(err, "failed to write tar header")
+		}
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: relPath, Mode: 0o755, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return errors.Wrap(err, "failed to write tar header")
+	}
+	if _, err := io.Copy(tw, file); err != nil {
+		return errors.Wrap(err, "failed to write file to tar archive")
+	}
+
+	return errors.Wrap(tw.Close(), "failed to close tar archive")
+}
+
+func withDockerVolume(ctx context.Context, volume, imageName, mountPath string, fn func(dockerClient *dc.Client, containerID string) error) error {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	// the volume is mounted where nodes mount it, so that a new volume is populated from the image the same way
+	created, createErr := dockerClient.ContainerCreate(ctx,
+		&container.Config{Image: imageName, Labels: framework.DefaultTCLabels()},
+		&container.HostConfig{Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: volume, Target: mountPath}}},
+		nil, nil, "")
+	if createErr != nil {
+		return errors.Wrapf(createErr, "failed to create container with volume %s from image %s", volume, imageName)
+	}
+	defer func() {
+		// use a fresh context, so that the container is removed even if ctx was cancelled, the volume is kept
+		_ = dockerClient.ContainerRemove(context.Background(), created.ID, container.RemoveOptions{Force: true})
+	}()
+
+	return fn(dockerClient, created.ID)
+}
@@ -0,0 +1,243 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	dc "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// label set by Helm charts used by CRIB node components, its value is the app instance name
+const kubernetesInstanceLabel = "app.kubernetes.io/instance"
+
+// NodeLabelSelector returns the label selector of the pod of the node with given index in given DON (CRIB and Kubernetes).
+// Indexes of bootstrap and worker nodes don't overlap, so it matches both names the node can have, see CRIBNodeName.
+func NodeLabelSelector(nodeIndex int, donName string) string {
+	return fmt.Sprintf("%s in (%s,%s)", kubernetesInstanceLabel, CRIBNodeName(nodeIndex, false, donName), CRIBNodeName(nodeIndex, true, donName))
+}
+
+type ExecResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+// Succeeded returns true if the command exited with code 0
+func (r *ExecResult) Succeeded() bool {
+	return r.ExitCode == 0
+}
+
+// ExecOptions controls where the output of the command is streamed to. Output is always captured in ExecResult as well.
+type ExecOptions struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+	// User to run the command as (name or uid[:gid]), if empty the user of the container is used. Docker only, the user
+	// of a Kubernetes exec can't be changed.
+	User string
+}
+
+// Executor runs commands inside a running workload (Docker container or Kubernetes pod).
+// Non-zero exit code is not treated as an error, it's returned in ExecResult instead.
+type Executor interface {
+	Exec(ctx context.Context, cmd []string, opts ExecOptions) (*ExecResult, error)
+}
+
+// NodeExecutor returns an executor for the node with given index in given DON. It has to follow the same naming
+// conventions as InternalHost() (see CRIBNodeName), since there's no other way to find out where the node is running.
+func (i *Provider) NodeExecutor(nodeIndex int, donName string) Executor {
+	var executor Executor = &DockerExecutor{ContainerName: fmt.Sprintf("%s-node%d", donName, nodeIndex)}
+	if i.IsCRIB() || i.IsKubernetes() {
+		kubernetesExecutor := &KubernetesExecutor{
+			Namespace:     i.KubernetesNamespace(),
+			LabelSelector: NodeLabelSelector(nodeIndex, donName),
+		}
+		if i.IsKubernetes() {
+			kubernetesExecutor.Container = i.Kubernetes.Container
+		}
+		executor = kubernetesExecutor
+	}
+	if i.ReadOnly {
+		return &ReadOnlyExecutor{Executor: executor}
+	}
+
+	return executor
+}
+
+type DockerExecutor struct {
+	ContainerName string
+}
+
+func (e *DockerExecutor) Exec(ctx context.Context, cmd []string, opts ExecOptions) (*ExecResult, error) {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return nil, errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	execCreateResp, createErr := dockerClient.ContainerExecCreate(ctx, e.ContainerName, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+		AttachStdin:  opts.Stdin != nil,
+		User:         opts.User,
+	})
+	if createErr != nil {
+		return nil, errors.Wrapf(createErr, "failed to create exec in container %s", e.ContainerName)
+	}
+
+	attachResp, attachErr := dockerClient.ContainerExecAttach(ctx, execCreateResp.ID, container.ExecAttachOptions{})
+	if attachErr != nil {
+		return nil, errors.Wrapf(attachErr, "failed to attach to exec in container %s", e.ContainerName)
+	}
+	defer attachResp.Close()
+
+	if opts.Stdin != nil {
+		go func() {
+			_, _ = io.Copy(attachResp.Conn, opts.Stdin)
+			_ = attachResp.CloseWrite()
+		}()
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if _, err := stdcopy.StdCopy(teeWriter(stdout, opts.Stdout), teeWriter(stderr, opts.Stderr), attachResp.Reader); err != nil {
+		return nil, errors.Wrapf(err, "failed to read output of exec in container %s", e.ContainerName)
+	}
+
+	inspect, inspectErr := dockerClient.ContainerExecInspect(ctx, execCreateResp.ID)
+	if inspectErr != nil {
+		return nil, errors.Wrapf(inspectErr, "failed to inspect exec in container %s", e.ContainerName)
+	}
+
+	return &ExecResult{
+		ExitCode: inspect.ExitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+// KubernetesExecutor runs commands in the first running pod matching label selector. Cluster access is configured
+// the same way as for kubectl (KUBECONFIG or ~/.kube/config with current context).
+type KubernetesExecutor struct {
+	Namespace     string
+	LabelSelector string
+	// Container to exec in, if empty the default container of the pod is used
+	Container string
+}
+
+func (e *KubernetesExecutor) Exec(ctx context.Context, cmd []string, opts ExecOptions) (*ExecResult, error) {
+	if opts.User != "" {
+		return nil, fmt.Errorf("can't exec as user %s in pods matching %s, Kubernetes execs run as the user of the container", opts.User, e.LabelSelector)
+	}
+
+	restConfig, restConfigErr := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).ClientConfig()
+	if restConfigErr != nil {
+		return nil, errors.Wrap(restConfigErr, "failed to load Kubernetes client config")
+	}
+
+	clientset, clientsetErr := kubernetes.NewForConfig(restConfig)
+	if clientsetErr != nil {
+		return nil, errors.Wrap(clientsetErr, "failed to create Kubernetes client")
+	}
+
+	pods, listErr := clientset.CoreV1().Pods(e.Namespace).List(ctx, metav1.ListOptions{LabelSelector: e.LabelSelector})
+	if listErr != nil {
+		return nil, errors.Wrapf(listErr, "failed to list pods matching %s in namespace %s", e.LabelSelector, e.Namespace)
+	}
+
+	podName := ""
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			podName = pod.Name
+			break
+		}
+	}
+	if podName == "" {
+		return nil, fmt.Errorf("no running pod matching %s found in namespace %s", e.LabelSelector, e.Namespace)
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(e.Namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: e.Container,
+			Command:   cmd,
+			Stdin:     opts.Stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, executorErr := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if executorErr != nil {
+		return nil, errors.Wrapf(executorErr, "failed to create executor for pod %s", podName)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	result := &ExecResult{}
+	streamErr := executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  opts.Stdin,
+		Stdout: teeWriter(stdout, opts.Stdout),
+		Stderr: teeWriter(stderr, opts.Stderr),
+	})
+	if streamErr != nil {
+		var exitErr utilexec.ExitError
+		if !errors.As(streamErr, &exitErr) {
+			return nil, errors.Wrapf(streamErr, "failed to exec in pod %s", podName)
+		}
+		result.ExitCode = exitErr.ExitStatus()
+	}
+
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	return result, nil
+}
+
+// FileDigest returns the SHA-256 digest of the file in the workload and its owner as "uid:gid". Both are empty, if the
+// file doesn't exist or can't be read.
+func FileDigest(ctx context.Context, executor Executor, filePath string) (digest, owner string, err error) {
+	digestResult, digestErr := executor.Exec(ctx, []string{"sha256sum", filePath}, ExecOptions{})
+	if digestErr != nil {
+		return "", "", errors.Wrapf(digestErr, "failed to compute digest of %s", filePath)
+	}
+	if !digestResult.Succeeded() {
+		return "", "", nil
+	}
+	fields := strings.Fields(digestResult.Stdout)
+	if len(fields) == 0 {
+		return "", "", fmt.Errorf("sha256sum printed no digest of %s", filePath)
+	}
+
+	ownerResult, ownerErr := executor.Exec(ctx, []string{"stat", "-c", "%u:%g", filePath}, ExecOptions{})
+	if ownerErr != nil {
+		return "", "", errors.Wrapf(ownerErr, "failed to read owner of %s", filePath)
+	}
+	if !ownerResult.Succeeded() {
+		return "", "", nil
+	}
+
+	return fields[0], strings.TrimSpace(ownerResult.Stdout), nil
+}
+
+func teeWriter(buffer *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buffer
+	}
+
+	return io.MultiWriter(buffer, w)
+}
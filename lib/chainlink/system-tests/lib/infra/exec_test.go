@@ -0,0 +1,27 @@
+package infra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestNodeLabelSelectorMatchesBootstrapAndWorkerPods(t *testing.T) {
+	selector, parseErr := labels.Parse(NodeLabelSelector(0, "workflow"))
+	require.NoError(t, parseErr)
+
+	assert.True(t, selector.Matches(labels.Set{kubernetesInstanceLabel: "workflow-bt-0"}))
+	assert.True(t, selector.Matches(labels.Set{kubernetesInstanceLabel: "workflow-0"}))
+	assert.False(t, selector.Matches(labels.Set{kubernetesInstanceLabel: "workflow-bt-1"}))
+	assert.False(t, selector.Matches(labels.Set{kubernetesInstanceLabel: "capabilities-0"}))
+}
+
+func TestInternalHostUsesCRIBNodeName(t *testing.T) {
+	provider := &Provider{Type: CRIB}
+
+	assert.Equal(t, "workflow-bt-0", provider.InternalHost(0, true, "workflow"))
+	assert.Equal(t, "workflow-2", provider.InternalHost(2, false, "workflow"))
+	assert.Equal(t, "workflow-bt-0-gtwnode", provider.InternalGatewayHost(0, true, "workflow"))
+}
@@ -0,0 +1,134 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/pkg/errors"
+	tc "github.com/testcontainers/testcontainers-go"
+	tcwait "github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+)
+
+const (
+	DefaultGatewayLoadBalancerImage = "nginx:1.27-alpine"
+	DefaultGatewayLoadBalancerName  = "gateway-lb"
+	DefaultGatewayLoadBalancerPort  = 5010
+)
+
+// GatewayLoadBalancerInput configures a load balancer put in front of all gateway nodes, which mimics the HA deployment
+// recommended to operators. Requests are sent to the next gateway, if the current one doesn't respond.
+type GatewayLoadBalancerInput struct {
+	Image string `toml:"image"`
+	Name  string `toml:"name"`
+	Port  int    `toml:"port"` // port exposed on the host and used inside the Docker network
+	// set by the environment, e.g. to common labels of its ResourceIndex, so that cleanup can match the container
+	Labels Labels `toml:"-"`
+}
+
+type GatewayLoadBalancerOutput struct {
+	ContainerName string   `toml:"container_name" json:"container_name"`
+	ExternalURL   string   `toml:"external_url" json:"external_url"`
+	InternalURL   string   `toml:"internal_url" json:"internal_url"`
+	Upstreams     []string `toml:"upstreams" json:"upstreams"`
+}
+
+// StartGatewayLoadBalancer starts nginx in the CTF Docker network, which balances requests between upstreams given as host:port.
+// It's only supported with Docker, in CRIB gateways should be put behind the ingress instead.
+func StartGatewayLoadBalancer(ctx context.Context, in *GatewayLoadBalancerInput, upstreams []string, path string) (*GatewayLoadBalancerOutput, error) {
+	if in == nil {
+		return nil, errors.New("gateway load balancer input is nil")
+	}
+	if len(upstreams) == 0 {
+		return nil, errors.New("at least one gateway upstream is required")
+	}
+	if in.Image == "" {
+		in.Image = DefaultGatewayLoadBalancerImage
+	}
+	if in.Name == "" {
+		in.Name = DefaultGatewayLoadBalancerName
+	}
+	if in.Port == 0 {
+		in.Port = DefaultGatewayLoadBalancerPort
+	}
+
+	containerName := framework.DefaultTCName(in.Name)
+	bindPort := fmt.Sprintf("%d/tcp", in.Port)
+
+	req := tc.ContainerRequest{
+		Name:     containerName,
+		Image:    in.Image,
+		Labels:   DockerLabels(in.Labels),
+		Networks: []string{framework.DefaultNetworkName},
+		NetworkAliases: map[string][]string{
+			framework.DefaultNetworkName: {containerName, in.Name},
+		},
+		ExposedPorts: []string{bindPort},
+		Files: []tc.ContainerFile{
+			{
+				Reader:            strings.NewReader(gatewayLoadBalancerConfig(in.Port, upstreams)),
+				ContainerFilePath: "/etc/nginx/conf.d/default.conf",
+				FileMode:          0o644,
+			},
+		},
+		HostConfigModifier: func(h *container.HostConfig) {
+			h.PortBindings = nat.PortMap{
+				nat.Port(bindPort): []nat.PortBinding{
+					{
+						HostIP:   "0.0.0.0",
+						HostPort: strconv.Itoa(in.Port),
+					},
+				},
+			}
+		},
+		WaitingFor: tcwait.ForListeningPort(nat.Port(bindPort)),
+	}
+
+	c, cErr := tc.GenericContainer(ctx, tc.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if cErr != nil {
+		return nil, errors.Wrap(cErr, "failed to start gateway load balancer")
+	}
+
+	host, hErr := framework.GetHost(c)
+	if hErr != nil {
+		return nil, errors.Wrap(hErr, "failed to get gateway load balancer host")
+	}
+
+	return &GatewayLoadBalancerOutput{
+		ContainerName: containerName,
+		ExternalURL:   fmt.Sprintf("http://%s:%d%s", host, in.Port, path),
+		InternalURL:   fmt.Sprintf("http://%s:%d%s", containerName, in.Port, path),
+		Upstreams:     upstreams,
+	}, nil
+}
+
+func gatewayLoadBalancerConfig(port int, upstreams []string) string {
+	var sb strings.Builder
+	sb.WriteString("upstream gateways {\n")
+	for _, upstream := range upstreams {
+		// a gateway is taken out of rotation for a few seconds after the first failed request
+		fmt.Fprintf(&sb, "    server %s max_fails=1 fail_timeout=5s;\n", upstream)
+	}
+	sb.WriteString("}\n\n")
+
+	fmt.Fprintf(&sb, `server {
+    listen %d;
+    location / {
+        proxy_pass http://gateways;
+        proxy_next_upstream error timeout http_502 http_503 http_504 non_idempotent;
+        proxy_connect_timeout 2s;
+        proxy_read_timeout 90s;
+    }
+}
+`, port)
+
+	return sb.String()
+}
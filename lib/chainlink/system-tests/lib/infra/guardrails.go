@@ -0,0 +1,161 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	dc "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+)
+
+// ErrUnsafeTarget is returned by destructive operations (killing, stopping and restarting nodes, removing resources,
+// replacing binaries or databases of nodes, chaos experiments), when their target doesn't look like test infra
+var ErrUnsafeTarget = errors.New("target isn't test infra")
+
+// localKubeContextPrefixes are prefixes of kubeconfig contexts of local clusters, which are always safe to target
+var localKubeContextPrefixes = []string{"kind-", "k3d-", "minikube", "docker-desktop", "rancher-desktop", "orbstack", "colima"}
+
+// SafetyInput restricts targets of destructive operations in CRIB and Kubernetes. Docker containers are always required
+// to be created by the framework.
+type SafetyInput struct {
+	// AllowedKubeContexts are names of kubeconfig contexts or of their clusters, which may be targeted in addition to local
+	// clusters (kind, k3d, minikube, ...). Entries ending with '*' match by prefix.
+	AllowedKubeContexts []string `toml:"allowed_kube_contexts"`
+	// NamespaceLabel is a label ("key" or "key=value"), which the namespace of nodes has to carry, empty disables the check
+	NamespaceLabel string `toml:"namespace_label"`
+}
+
+// CheckSafeTarget returns ErrUnsafeTarget, if the node with given index in given DON doesn't run on test infra: in
+// Docker its container has to be created by the framework, in CRIB and Kubernetes the namespace of nodes has to be
+// safe, see CheckSafeNamespace. It's called by KillNode, StopNode and RestartNode, chaos experiments, which change
+// nodes in other ways, should call it first.
+func (i *Provider) CheckSafeTarget(ctx context.Context, nodeIndex int, donName string) error {
+	if i.IsCRIB() || i.IsKubernetes() {
+		return i.checkKubernetesTarget(ctx)
+	}
+
+	return CheckSafeDockerContainer(ctx, fmt.Sprintf("%s-node%d", donName, nodeIndex))
+}
+
+// CheckSafeNamespace returns ErrUnsafeTarget, if the namespace of nodes in CRIB or Kubernetes isn't test infra: the
+// current kubeconfig context has to be a local cluster, the context CRIB deploys to or a context allowed by Safety,
+// and the namespace has to carry the label Safety requires. Chaos experiments targeting pods by labels, e.g. network
+// partitions, should call it first. It does nothing in Docker.
+func (i *Provider) CheckSafeNamespace(ctx context.Context) error {
+	if !i.IsCRIB() && !i.IsKubernetes() {
+		return nil
+	}
+
+	return i.checkKubernetesTarget(ctx)
+}
+
+func (i *Provider) checkKubernetesTarget(ctx context.Context) error {
+	safety := i.Safety
+	if safety == nil {
+		safety = &SafetyInput{}
+	}
+
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
+	rawConfig, rawConfigErr := loader.RawConfig()
+	if rawConfigErr != nil {
+		return errors.Wrap(rawConfigErr, "failed to load Kubernetes client config")
+	}
+	contextName := rawConfig.CurrentContext
+	clusterName := ""
+	if kubeContext, ok := rawConfig.Contexts[contextName]; ok {
+		clusterName = kubeContext.Cluster
+	}
+	allowed := safety.AllowedKubeContexts
+	if i.IsCRIB() && i.CRIB != nil {
+		// CRIB deploys to the current context, unless it's pinned to another one
+		cribContext := i.CRIB.KubeContext
+		if cribContext == "" {
+			cribContext = contextName
+		}
+		allowed = append(slices.Clone(allowed), cribContext)
+	}
+	if !kubeContextAllowed(contextName, clusterName, allowed) {
+		return errors.Wrapf(ErrUnsafeTarget, "kubeconfig context '%s' (cluster '%s') isn't a local cluster, add it to allowed_kube_contexts of [infra.safety], if it's a test cluster", contextName, clusterName)
+	}
+
+	if safety.NamespaceLabel == "" {
+		return nil
+	}
+	restConfig, restConfigErr := loader.ClientConfig()
+	if restConfigErr != nil {
+		return errors.Wrap(restConfigErr, "failed to load Kubernetes client config")
+	}
+	clientset, clientsetErr := kubernetes.NewForConfig(restConfig)
+	if clientsetErr != nil {
+		return errors.Wrap(clientsetErr, "failed to create Kubernetes client")
+	}
+	namespace := i.KubernetesNamespace()
+	ns, nsErr := clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if nsErr != nil {
+		return errors.Wrapf(nsErr, "failed to get namespace %s", namespace)
+	}
+	if !hasLabel(ns.Labels, safety.NamespaceLabel) {
+		return errors.Wrapf(ErrUnsafeTarget, "namespace %s has no label %s", namespace, safety.NamespaceLabel)
+	}
+
+	return nil
+}
+
+// CheckSafeDockerContainer returns ErrUnsafeTarget for containers without labels CTF and this framework set on every
+// container they create. Operations removing or replacing containers, which aren't nodes, should call it first.
+func CheckSafeDockerContainer(ctx context.Context, containerName string) error {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	inspected, inspectErr := dockerClient.ContainerInspect(ctx, containerName)
+	if inspectErr != nil {
+		return errors.Wrapf(inspectErr, "failed to inspect container %s", containerName)
+	}
+	var labels map[string]string
+	if inspected.Config != nil {
+		labels = inspected.Config.Labels
+	}
+	for key, value := range framework.DefaultTCLabels() {
+		if !hasLabel(labels, key+"="+value) {
+			return errors.Wrapf(ErrUnsafeTarget, "container %s wasn't created by the framework, it has no label %s=%s", containerName, key, value)
+		}
+	}
+
+	return nil
+}
+
+func kubeContextAllowed(contextName, clusterName string, allowed []string) bool {
+	for _, name := range []string{contextName, clusterName} {
+		if name == "" {
+			continue
+		}
+		if slices.ContainsFunc(localKubeContextPrefixes, func(prefix string) bool { return strings.HasPrefix(name, prefix) }) {
+			return true
+		}
+		for _, pattern := range allowed {
+			if prefix, isPrefix := strings.CutSuffix(pattern, "*"); (isPrefix && strings.HasPrefix(name, prefix)) || name == pattern {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasLabel checks for a label given as "key" (any value) or "key=value"
+func hasLabel(labels map[string]string, label string) bool {
+	key, value, withValue := strings.Cut(label, "=")
+	actual, ok := labels[key]
+
+	return ok && (!withValue || actual == value)
+}
@@ -0,0 +1,43 @@
+package infra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubeContextAllowed(t *testing.T) {
+	tests := []struct {
+		name        string
+		contextName string
+		clusterName string
+		allowed     []string
+		want        bool
+	}{
+		{name: "local context", contextName: "kind-cre", want: true},
+		{name: "local cluster of a renamed context", contextName: "dev", clusterName: "k3d-cre", want: true},
+		{name: "remote context", contextName: "arn:aws:eks:us-west-2:1:cluster/main-stage", want: false},
+		{name: "allowed context", contextName: "main-stage", allowed: []string{"main-stage"}, want: true},
+		{name: "allowed cluster", contextName: "ci", clusterName: "main-stage", allowed: []string{"main-stage"}, want: true},
+		{name: "allowed prefix", contextName: "stage-crib-2", allowed: []string{"stage-crib-*"}, want: true},
+		{name: "pattern without star matches exactly", contextName: "stage-crib-2", allowed: []string{"stage-crib"}, want: false},
+		{name: "empty names never match", allowed: []string{""}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, kubeContextAllowed(tt.contextName, tt.clusterName, tt.allowed))
+		})
+	}
+}
+
+func TestHasLabel(t *testing.T) {
+	labels := map[string]string{"env": "test", "empty": ""}
+
+	assert.True(t, hasLabel(labels, "env"), "key only matches any value")
+	assert.True(t, hasLabel(labels, "env=test"))
+	assert.False(t, hasLabel(labels, "env=prod"))
+	assert.True(t, hasLabel(labels, "empty="), "empty value")
+	assert.True(t, hasLabel(labels, "empty"))
+	assert.False(t, hasLabel(labels, "missing"))
+	assert.False(t, hasLabel(nil, "env"))
+}
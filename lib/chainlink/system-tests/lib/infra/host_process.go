@@ -0,0 +1,245 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types/network"
+	dc "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+)
+
+const (
+	DefaultHostProcessLogsDir      = "logs/host-processes"
+	DefaultHostProcessReadyTimeout = time.Minute
+)
+
+// HostProcessInput describes a component that runs as a process on the host instead of a container, e.g. a local mock
+// server or a locally built gateway. Nodes running in Docker reach it at the address from HostProcessOutput.InternalHost.
+type HostProcessInput struct {
+	Name    string            `toml:"name" validate:"required"`
+	Command string            `toml:"command"` // path to the binary, not required if External is set
+	Args    []string          `toml:"args"`
+	Env     map[string]string `toml:"env"`
+	WorkDir string            `toml:"work_dir"`
+	Port    int               `toml:"port" validate:"required"` // the process must listen on it on all interfaces, it's used to check readiness
+	// External means that the process is started by the user (e.g. in an IDE debugger), the framework only waits until it listens on the port
+	External     bool   `toml:"external"`
+	ReadyTimeout string `toml:"ready_timeout"` // Go duration, DefaultHostProcessReadyTimeout is used if not set
+
+	Out *HostProcessOutput `toml:"out"`
+}
+
+type HostProcessOutput struct {
+	PID          int    `toml:"pid" json:"pid"` // 0 for external processes
+	InternalHost string `toml:"internal_host" json:"internal_host"`
+	InternalURL  string `toml:"internal_url" json:"internal_url"` // address reachable from Docker containers
+	ExternalURL  string `toml:"external_url" json:"external_url"` // address reachable from the host
+	LogFile      string `toml:"log_file" json:"log_file"`
+}
+
+func (h *HostProcessInput) Validate() error {
+	if h.Name == "" {
+		return errors.New("host process name is required")
+	}
+	if h.Command == "" && !h.External {
+		return fmt.Errorf("command of host process %s is required, unless it's external", h.Name)
+	}
+	if h.Port <= 0 {
+		return fmt.Errorf("port of host process %s is required", h.Name)
+	}
+	if h.ReadyTimeout != "" {
+		if _, err := time.ParseDuration(h.ReadyTimeout); err != nil {
+			return fmt.Errorf("invalid ready timeout of host process %s: %w", h.Name, err)
+		}
+	}
+
+	return nil
+}
+
+type HostProcess struct {
+	Input  *HostProcessInput
+	Output *HostProcessOutput
+
+	cmd     *exec.Cmd
+	logFile *os.File
+	exited  chan struct{}
+}
+
+// StartHostProcess starts the process (unless it's external) and waits until it listens on its port
+func StartHostProcess(ctx context.Context, in *HostProcessInput) (*HostProcess, error) {
+	if in == nil {
+		return nil, errors.New("host process input is nil")
+	}
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+
+	host, hostErr := DockerHostAddress(ctx)
+	if hostErr != nil {
+		return nil, errors.Wrap(hostErr, "failed to resolve address of the host in the Docker network")
+	}
+
+	process := &HostProcess{
+		Input: in,
+		Output: &HostProcessOutput{
+			InternalHost: host,
+			InternalURL:  fmt.Sprintf("http://%s:%d", host, in.Port),
+			ExternalURL:  fmt.Sprintf("http://localhost:%d", in.Port),
+		},
+		exited: make(chan struct{}),
+	}
+
+	// exited is never closed for external processes, because they aren't managed by the framework
+	if !in.External {
+		if err := process.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	readyTimeout := DefaultHostProcessReadyTimeout
+	if in.ReadyTimeout != "" {
+		readyTimeout, _ = time.ParseDuration(in.ReadyTimeout)
+	}
+	if err := process.waitForPort(ctx, readyTimeout); err != nil {
+		_ = process.Stop()
+		return nil, err
+	}
+
+	in.Out = process.Output
+
+	return process, nil
+}
+
+func (p *HostProcess) start() error {
+	if err := os.MkdirAll(DefaultHostProcessLogsDir, 0o755); err != nil {
+		return errors.Wrap(err, "failed to create host process logs directory")
+	}
+	logPath, absErr := filepath.Abs(filepath.Join(DefaultHostProcessLogsDir, p.Input.Name+".log"))
+	if absErr != nil {
+		return errors.Wrap(absErr, "failed to get absolute path of host process log file")
+	}
+	logFile, fErr := os.Create(logPath)
+	if fErr != nil {
+		return errors.Wrapf(fErr, "failed to create log file of host process %s", p.Input.Name)
+	}
+
+	// not using exec.CommandContext, because the process must outlive the setup context
+	cmd := exec.Command(p.Input.Command, p.Input.Args...) //nolint:gosec // command comes from the environment config
+	cmd.Dir = p.Input.WorkDir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Env = os.Environ()
+	for key, value := range p.Input.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	if err := cmd.Start(); err != nil {
+		_ = logFile.Close()
+		return errors.Wrapf(err, "failed to start host process %s", p.Input.Name)
+	}
+
+	p.cmd = cmd
+	p.logFile = logFile
+	p.Output.PID = cmd.Process.Pid
+	p.Output.LogFile = logPath
+
+	go func() {
+		_ = cmd.Wait()
+		_ = logFile.Close()
+		close(p.exited)
+	}()
+
+	return nil
+}
+
+func (p *HostProcess) waitForPort(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	address := net.JoinHostPort("localhost", strconv.Itoa(p.Input.Port))
+	for {
+		conn, dialErr := net.DialTimeout("tcp", address, time.Second)
+		if dialErr == nil {
+			_ = conn.Close()
+			return nil
+		}
+
+		select {
+		case <-p.exited:
+			return fmt.Errorf("host process %s exited before listening on port %d, see logs in %s", p.Input.Name, p.Input.Port, p.Output.LogFile)
+		case <-ctx.Done():
+			if p.Input.External {
+				return fmt.Errorf("external host process %s didn't start listening on port %d in %s, make sure it's running", p.Input.Name, p.Input.Port, timeout)
+			}
+			return fmt.Errorf("host process %s didn't start listening on port %d in %s, see logs in %s", p.Input.Name, p.Input.Port, timeout, p.Output.LogFile)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Stop terminates the process gracefully and kills it, if it doesn't exit in 10 seconds. External processes are left running.
+func (p *HostProcess) Stop() error {
+	if p.cmd == nil || p.cmd.Process == nil {
+		return nil
+	}
+
+	select {
+	case <-p.exited:
+		return nil
+	default:
+	}
+
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		return errors.Wrapf(err, "failed to terminate host process %s", p.Input.Name)
+	}
+
+	select {
+	case <-p.exited:
+		return nil
+	case <-time.After(10 * time.Second):
+		if err := p.cmd.Process.Kill(); err != nil {
+			return errors.Wrapf(err, "failed to kill host process %s", p.Input.Name)
+		}
+		<-p.exited
+		return nil
+	}
+}
+
+// DockerHostAddress returns the address, at which containers in the CTF Docker network reach processes running on the host.
+// On Linux it's the gateway of the network, because host.docker.internal isn't resolvable in containers by default.
+func DockerHostAddress(ctx context.Context) (string, error) {
+	if runtime.GOOS != "linux" {
+		return strings.TrimPrefix(framework.HostDockerInternal(), "http://"), nil
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return "", errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	networkResource, inspectErr := dockerClient.NetworkInspect(ctx, framework.DefaultNetworkName, network.InspectOptions{})
+	if inspectErr != nil {
+		return "", errors.Wrapf(inspectErr, "failed to inspect Docker network %s", framework.DefaultNetworkName)
+	}
+
+	for _, ipamConfig := range networkResource.IPAM.Config {
+		if ipamConfig.Gateway != "" {
+			return ipamConfig.Gateway, nil
+		}
+	}
+
+	return "", fmt.Errorf("no gateway found in Docker network %s", framework.DefaultNetworkName)
+}
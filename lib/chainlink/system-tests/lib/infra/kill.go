@@ -0,0 +1,189 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	dc "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/ptr"
+)
+
+// KillNode abruptly stops the node with given index in given DON, without giving it a chance to shut down gracefully.
+// In Docker the container is killed and stays stopped. In CRIB the pod is deleted and will be recreated by its controller,
+// pods of bootstrap nodes are found by their -bt- names as well, see NodeLabelSelector.
+func (i *Provider) KillNode(ctx context.Context, nodeIndex int, donName string) error {
+	if i.ReadOnly {
+		return errors.Wrap(ErrReadOnly, "nodes can't be killed")
+	}
+	if err := i.CheckSafeTarget(ctx, nodeIndex, donName); err != nil {
+		return err
+	}
+	if i.IsCRIB() || i.IsKubernetes() {
+		return killKubernetesPods(ctx, i.KubernetesNamespace(), NodeLabelSelector(nodeIndex, donName))
+	}
+
+	return killDockerContainer(ctx, fmt.Sprintf("%s-node%d", donName, nodeIndex))
+}
+
+// StopNode gracefully stops the node with given index in given DON and keeps it stopped until StartNode is called.
+// Only Docker is supported, because in CRIB and Kubernetes stopped pods are recreated by their controller.
+func (i *Provider) StopNode(ctx context.Context, nodeIndex int, donName string) error {
+	if i.ReadOnly {
+		return errors.Wrap(ErrReadOnly, "nodes can't be stopped")
+	}
+	if err := i.CheckSafeTarget(ctx, nodeIndex, donName); err != nil {
+		return err
+	}
+	if i.IsCRIB() || i.IsKubernetes() {
+		return errors.New("stopping nodes is not supported in Kubernetes, use KillNode instead")
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	containerName := fmt.Sprintf("%s-node%d", donName, nodeIndex)
+	if err := dockerClient.ContainerStop(ctx, containerName, container.StopOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to stop container %s", containerName)
+	}
+
+	return nil
+}
+
+// StartNode starts the node stopped with StopNode or KillNode
+func (i *Provider) StartNode(ctx context.Context, nodeIndex int, donName string) error {
+	if i.ReadOnly {
+		return errors.Wrap(ErrReadOnly, "nodes can't be started")
+	}
+	if i.IsCRIB() || i.IsKubernetes() {
+		return errors.New("starting nodes is not supported in Kubernetes, pods are recreated by their controller")
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	containerName := fmt.Sprintf("%s-node%d", donName, nodeIndex)
+	if err := dockerClient.ContainerStart(ctx, containerName, container.StartOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to start container %s", containerName)
+	}
+
+	return nil
+}
+
+func killDockerContainer(ctx context.Context, containerName string) error {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	if err := dockerClient.ContainerKill(ctx, containerName, "SIGKILL"); err != nil {
+		return errors.Wrapf(err, "failed to kill container %s", containerName)
+	}
+
+	return nil
+}
+
+func killKubernetesPods(ctx context.Context, namespace, labelSelector string) error {
+	restConfig, restConfigErr := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).ClientConfig()
+	if restConfigErr != nil {
+		return errors.Wrap(restConfigErr, "failed to load Kubernetes client config")
+	}
+
+	clientset, clientsetErr := kubernetes.NewForConfig(restConfig)
+	if clientsetErr != nil {
+		return errors.Wrap(clientsetErr, "failed to create Kubernetes client")
+	}
+
+	deleteErr := clientset.CoreV1().Pods(namespace).DeleteCollection(ctx, metav1.DeleteOptions{GracePeriodSeconds: ptr.To(int64(0))}, metav1.ListOptions{LabelSelector: labelSelector})
+	if deleteErr != nil {
+		return errors.Wrapf(deleteErr, "failed to delete pods matching %s in namespace %s", labelSelector, namespace)
+	}
+
+	return nil
+}
+
+// RestartNode gracefully restarts the container of the node with given index in given DON. Only Docker is supported,
+// because in CRIB and Kubernetes restarted pods are recreated from their spec and lose files copied to them.
+func (i *Provider) RestartNode(ctx context.Context, nodeIndex int, donName string) error {
+	if i.ReadOnly {
+		return errors.Wrap(ErrReadOnly, "nodes can't be restarted")
+	}
+	if err := i.CheckSafeTarget(ctx, nodeIndex, donName); err != nil {
+		return err
+	}
+	if i.IsCRIB() || i.IsKubernetes() {
+		return errors.New("restarting nodes is not supported in Kubernetes, use KillNode instead")
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	containerName := fmt.Sprintf("%s-node%d", donName, nodeIndex)
+	if err := dockerClient.ContainerRestart(ctx, containerName, container.StopOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to restart container %s", containerName)
+	}
+
+	return nil
+}
+
+// NodeRestartCount returns how many times the node with given index in given DON was restarted by its infra after it
+// exited: by the restart policy of its Docker container or by the kubelet in its pod. Restarts requested with
+// RestartNode and pods recreated after KillNode aren't counted.
+func (i *Provider) NodeRestartCount(ctx context.Context, nodeIndex int, donName string) (int, error) {
+	if i.IsCRIB() || i.IsKubernetes() {
+		return kubernetesRestartCount(ctx, i.KubernetesNamespace(), NodeLabelSelector(nodeIndex, donName))
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return 0, errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	containerName := fmt.Sprintf("%s-node%d", donName, nodeIndex)
+	inspected, inspectErr := dockerClient.ContainerInspect(ctx, containerName)
+	if inspectErr != nil {
+		return 0, errors.Wrapf(inspectErr, "failed to inspect container %s", containerName)
+	}
+
+	return inspected.RestartCount, nil
+}
+
+func kubernetesRestartCount(ctx context.Context, namespace, labelSelector string) (int, error) {
+	restConfig, restConfigErr := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).ClientConfig()
+	if restConfigErr != nil {
+		return 0, errors.Wrap(restConfigErr, "failed to load Kubernetes client config")
+	}
+
+	clientset, clientsetErr := kubernetes.NewForConfig(restConfig)
+	if clientsetErr != nil {
+		return 0, errors.Wrap(clientsetErr, "failed to create Kubernetes client")
+	}
+
+	pods, listErr := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if listErr != nil {
+		return 0, errors.Wrapf(listErr, "failed to list pods matching %s in namespace %s", labelSelector, namespace)
+	}
+	restarts := 0
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += int(status.RestartCount)
+		}
+	}
+
+	return restarts, nil
+}
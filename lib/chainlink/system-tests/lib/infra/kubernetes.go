@@ -0,0 +1,80 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// KubernetesCapabilitiesDir is where capability binaries are copied to in pods of nodes deployed to a Kubernetes cluster.
+// It's in the home directory of the chainlink user, because root filesystems of pods are often read-only for it.
+const KubernetesCapabilitiesDir = "/home/chainlink/capabilities"
+
+// KubernetesInput describes a real cluster, to which DONs are deployed by other means than CRIB (e.g. their own Helm charts).
+// Pods of nodes must be labelled with app.kubernetes.io/instance=<name> and reachable through services with the same
+// names as in CRIB, i.e. <DON name>-<node index> (or <DON name>-bt-<node index> for bootstrap nodes), see CRIBNodeName.
+// The cluster is accessed the same way as with kubectl (KUBECONFIG or ~/.kube/config with current context).
+//
+// Experimental: labels and service names expected by this infra type may change.
+type KubernetesInput struct {
+	Namespace string `toml:"namespace" validate:"required"`
+	// Container of the node in its pod, if empty the default container of the pod is used
+	Container string `toml:"container"`
+}
+
+func (i *Provider) IsKubernetes() bool {
+	return strings.EqualFold(i.Type, Kubernetes)
+}
+
+// KubernetesNamespace returns the namespace nodes run in, for both CRIB and Kubernetes
+func (i *Provider) KubernetesNamespace() string {
+	if i.IsKubernetes() {
+		return i.Kubernetes.Namespace
+	}
+
+	return i.CRIB.Namespace
+}
+
+// CopyFileToKubernetesPod copies a file from the host to containerDir in the pod selected by the executor, so that it's
+// owned by the user the node runs as and executable. Like 'kubectl cp' it streams the file through exec, so the image
+// only needs sh and cat, and binaries of any size can be copied, unlike with ConfigMaps, which are limited to 1 MiB.
+func CopyFileToKubernetesPod(ctx context.Context, executor *KubernetesExecutor, hostPath, containerDir string) error {
+	file, openErr := os.Open(hostPath)
+	if openErr != nil {
+		return errors.Wrapf(openErr, "failed to open file %s", hostPath)
+	}
+	defer file.Close()
+
+	targetPath := path.Join(containerDir, filepath.Base(hostPath))
+	// the file is written to a temporary path first, so that a running binary with the same name isn't corrupted
+	script := `mkdir -p "$0" && cat > "$1.tmp" && chmod 755 "$1.tmp" && mv -f "$1.tmp" "$1"`
+	result, execErr := executor.Exec(ctx, []string{"sh", "-c", script, containerDir, targetPath}, ExecOptions{Stdin: file})
+	if execErr != nil {
+		return errors.Wrapf(execErr, "failed to copy %s to pod matching %s", hostPath, executor.LabelSelector)
+	}
+	if !result.Succeeded() {
+		return fmt.Errorf("failed to copy %s to %s in pod matching %s (exit code %d): %s", hostPath, targetPath, executor.LabelSelector, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	return nil
+}
+
+// LinkFileInKubernetesPod points linkPath in the pod selected by the executor at targetPath, replacing what was there.
+// Use it to install binaries staged on a persistent volume of the pod, see CopyFileToKubernetesPod.
+func LinkFileInKubernetesPod(ctx context.Context, executor *KubernetesExecutor, targetPath, linkPath string) error {
+	script := `mkdir -p "$(dirname "$1")" && ln -sfn "$0" "$1"`
+	result, execErr := executor.Exec(ctx, []string{"sh", "-c", script, targetPath, linkPath}, ExecOptions{})
+	if execErr != nil {
+		return errors.Wrapf(execErr, "failed to link %s in pod matching %s", linkPath, executor.LabelSelector)
+	}
+	if !result.Succeeded() {
+		return fmt.Errorf("failed to link %s to %s in pod matching %s (exit code %d): %s", linkPath, targetPath, executor.LabelSelector, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+
+	return nil
+}
@@ -0,0 +1,372 @@
+package infra
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	dc "github.com/docker/docker/client"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+	tc "github.com/testcontainers/testcontainers-go"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+)
+
+// Labels attached to every resource created for a test environment
+const (
+	LabelRunID    = "cre.run_id"
+	LabelTestName = "cre.test"
+	LabelDON      = "cre.don"
+	LabelRole     = "cre.role"
+	LabelNode     = "cre.node"
+	// LabelSession is set by testcontainers on every container and volume it creates, including all CTF components,
+	// to the session of the creating process. Unlike labels above, it can't be set by this framework.
+	LabelSession = "org.testcontainers.sessionId"
+)
+
+type ResourceKind = string
+
+const (
+	ResourceContainer ResourceKind = "container"
+	ResourceVolume    ResourceKind = "volume"
+	ResourceNetwork   ResourceKind = "network"
+	ResourcePod       ResourceKind = "pod"
+	ResourceNamespace ResourceKind = "namespace"
+)
+
+type Labels map[string]string
+
+// With returns a copy of labels extended with (and overridden by) other labels
+func (l Labels) With(other Labels) Labels {
+	out := make(Labels, len(l)+len(other))
+	for k, v := range l {
+		out[k] = v
+	}
+	for k, v := range other {
+		out[k] = v
+	}
+
+	return out
+}
+
+// Matches returns true if labels contain all key-value pairs from selector. Empty value in selector matches any value of the key.
+func (l Labels) Matches(selector Labels) bool {
+	for k, v := range selector {
+		actual, ok := l[k]
+		if !ok || (v != "" && actual != v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type Resource struct {
+	Kind   ResourceKind `toml:"kind" json:"kind"`
+	Name   string       `toml:"name" json:"name"`
+	Labels Labels       `toml:"labels" json:"labels"`
+}
+
+// ResourceIndex keeps track of all resources created for a test environment together with their labels.
+// CTF doesn't allow setting custom Docker labels and CRIB components don't expose them either, so labels of the index are
+// set only on containers this framework starts itself (see DockerLabels), other resources are identified by the session
+// label testcontainers set on them once they were created, see RecordDockerSessions. The index is stored next to the
+// environment state. GC, cost accounting and chaos experiments should use Filter() to find resources belonging to a given
+// run, DON or role.
+type ResourceIndex struct {
+	Provider  Type        `toml:"provider" json:"provider"`
+	Labels    Labels      `toml:"labels" json:"labels"` // common labels applied to every resource
+	Resources []*Resource `toml:"resources" json:"resources"`
+
+	mu sync.Mutex
+}
+
+func NewResourceIndex(provider Type, runID, testName string) *ResourceIndex {
+	labels := Labels{LabelRunID: runID}
+	if testName != "" {
+		labels[LabelTestName] = testName
+	}
+
+	return &ResourceIndex{
+		Provider: provider,
+		Labels:   labels,
+	}
+}
+
+// Add registers a resource with common labels and given labels. If a resource of the same kind and name is already registered,
+// its labels are merged.
+func (r *ResourceIndex) Add(kind ResourceKind, name string, labels Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, res := range r.Resources {
+		if res.Kind == kind && res.Name == name {
+			res.Labels = res.Labels.With(labels)
+			return
+		}
+	}
+
+	r.Resources = append(r.Resources, &Resource{
+		Kind:   kind,
+		Name:   name,
+		Labels: r.Labels.With(labels),
+	})
+}
+
+// Filter returns resources of given kind matching selector. Empty kind matches resources of all kinds.
+func (r *ResourceIndex) Filter(kind ResourceKind, selector Labels) []*Resource {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []*Resource
+	for _, res := range r.Resources {
+		if kind != "" && res.Kind != kind {
+			continue
+		}
+		if res.Labels.Matches(selector) {
+			out = append(out, res)
+		}
+	}
+
+	return out
+}
+
+// Names returns names of resources of given kind matching selector
+func (r *ResourceIndex) Names(kind ResourceKind, selector Labels) []string {
+	resources := r.Filter(kind, selector)
+	names := make([]string, 0, len(resources))
+	for _, res := range resources {
+		names = append(names, res.Name)
+	}
+
+	return names
+}
+
+// DockerLabels returns labels of containers started by this framework: labels CTF sets on its containers with given
+// labels, e.g. common labels of the index, which cleanup then matches
+func DockerLabels(labels Labels) map[string]string {
+	return Labels(framework.DefaultTCLabels()).With(labels)
+}
+
+// RecordDockerSessions records the session label, which containers and volumes of the index got from testcontainers
+// when they were created, so that cleanup removes them only, if they are still the same resources, and not ones with the
+// same name created by another environment since. Resources, which don't exist, are skipped.
+func RecordDockerSessions(ctx context.Context, index *ResourceIndex) error {
+	existing, listErr := dockerResourceLabels(ctx, "")
+	if listErr != nil {
+		return listErr
+	}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	for _, res := range index.Resources {
+		if session, ok := existing[res.Kind][res.Name][LabelSession]; ok {
+			res.Labels = res.Labels.With(Labels{LabelSession: session})
+		}
+	}
+
+	return nil
+}
+
+// DockerResourceNames returns names of existing Docker resources by kind, see IndexNewDockerResources
+func DockerResourceNames(ctx context.Context) (map[ResourceKind][]string, error) {
+	existing, listErr := dockerResourceLabels(ctx, "")
+	if listErr != nil {
+		return nil, listErr
+	}
+
+	names := make(map[ResourceKind][]string, len(existing))
+	for kind, resources := range existing {
+		names[kind] = slices.Sorted(maps.Keys(resources))
+	}
+
+	return names, nil
+}
+
+// IndexNewDockerResources returns an index of Docker resources, which didn't exist before (their names weren't returned by
+// DockerResourceNames) and were created by this process, i.e. carry its testcontainers session or given run ID. Only
+// containers created by the framework are indexed, see DockerLabels. It's useful for removing resources of a setup,
+// which failed before it indexed them, without touching ones of other environments on the host.
+func IndexNewDockerResources(ctx context.Context, before map[ResourceKind][]string, runID string) (*ResourceIndex, error) {
+	existing, listErr := dockerResourceLabels(ctx, "")
+	if listErr != nil {
+		return nil, listErr
+	}
+
+	index := NewResourceIndex(Docker, runID, "")
+	session := tc.SessionID()
+	for _, kind := range []ResourceKind{ResourceContainer, ResourceVolume, ResourceNetwork} {
+		for _, name := range slices.Sorted(maps.Keys(existing[kind])) {
+			if slices.Contains(before[kind], name) {
+				continue
+			}
+			labels := Labels(existing[kind][name])
+			// containers of testcontainers itself, e.g. its reaper, share the session, but weren't created by the framework
+			if kind == ResourceContainer && !labels.Matches(framework.DefaultTCLabels()) {
+				continue
+			}
+			if (runID != "" && labels[LabelRunID] == runID) || labels[LabelSession] == session {
+				index.Add(kind, name, Labels{LabelSession: labels[LabelSession]})
+			}
+		}
+	}
+
+	return index, nil
+}
+
+func (r *ResourceIndex) Store(absPath string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if dErr := os.MkdirAll(filepath.Dir(absPath), 0o755); dErr != nil {
+		return errors.Wrap(dErr, "failed to create directory for the resource index")
+	}
+
+	d, mErr := toml.Marshal(r)
+	if mErr != nil {
+		return errors.Wrap(mErr, "failed to marshal resource index to TOML")
+	}
+
+	return os.WriteFile(absPath, d, 0o600)
+}
+
+func LoadResourceIndex(absPath string) (*ResourceIndex, error) {
+	d, rErr := os.ReadFile(absPath)
+	if rErr != nil {
+		return nil, errors.Wrapf(rErr, "failed to read resource index from %s", absPath)
+	}
+
+	index := &ResourceIndex{}
+	if uErr := toml.Unmarshal(d, index); uErr != nil {
+		return nil, errors.Wrapf(uErr, "failed to unmarshal resource index from %s", absPath)
+	}
+
+	return index, nil
+}
+
+// ListDockerResources returns indexed Docker resources of given kind matching selector, which still exist and carry the
+// labels they were created with (the run ID of the index or their recorded session, see RecordDockerSessions). It's useful
+// for garbage collection, since some of the resources might have already been removed by CTF or by hand or replaced by
+// resources of another environment with the same name.
+func ListDockerResources(ctx context.Context, index *ResourceIndex, kind ResourceKind, selector Labels) ([]*Resource, error) {
+	existing, listErr := dockerResourceLabels(ctx, kind)
+	if listErr != nil {
+		return nil, listErr
+	}
+
+	var out []*Resource
+	for _, res := range index.Filter(kind, selector) {
+		if labels, ok := existing[res.Kind][res.Name]; ok && createdFor(labels, res) {
+			out = append(out, res)
+		}
+	}
+
+	return out, nil
+}
+
+// createdFor returns true, if Docker labels of a resource identify it as the indexed one
+func createdFor(dockerLabels map[string]string, res *Resource) bool {
+	if runID := res.Labels[LabelRunID]; runID != "" && dockerLabels[LabelRunID] == runID {
+		return true
+	}
+	session := res.Labels[LabelSession]
+
+	return session != "" && dockerLabels[LabelSession] == session
+}
+
+// dockerResourceLabels returns labels of existing Docker resources of given kind (all kinds if it's empty) by name
+func dockerResourceLabels(ctx context.Context, kind ResourceKind) (map[ResourceKind]map[string]map[string]string, error) {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return nil, errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	existing := map[ResourceKind]map[string]map[string]string{
+		ResourceContainer: {},
+		ResourceVolume:    {},
+		ResourceNetwork:   {},
+	}
+	if kind == "" || kind == ResourceContainer {
+		containers, err := dockerClient.ContainerList(ctx, container.ListOptions{All: true})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list Docker containers")
+		}
+		for _, c := range containers {
+			for _, name := range c.Names {
+				existing[ResourceContainer][strings.TrimPrefix(name, "/")] = c.Labels
+			}
+		}
+	}
+	if kind == "" || kind == ResourceVolume {
+		volumes, err := dockerClient.VolumeList(ctx, volume.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list Docker volumes")
+		}
+		for _, v := range volumes.Volumes {
+			existing[ResourceVolume][v.Name] = v.Labels
+		}
+	}
+	if kind == "" || kind == ResourceNetwork {
+		networks, err := dockerClient.NetworkList(ctx, network.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list Docker networks")
+		}
+		for _, n := range networks {
+			existing[ResourceNetwork][n.Name] = n.Labels
+		}
+	}
+
+	return existing, nil
+}
+
+// RemoveDockerResources removes indexed Docker resources matching selector, which still exist and carry the labels they
+// were created with, see ListDockerResources. Containers are removed first, because volumes and networks can't be removed
+// while they are in use. The CTF network is never removed, because it's shared by all environments on the host.
+// Containers not created by the framework are refused with ErrUnsafeTarget, e.g. if an index of another host was loaded.
+func RemoveDockerResources(ctx context.Context, index *ResourceIndex, selector Labels) error {
+	resources, listErr := ListDockerResources(ctx, index, "", selector)
+	if listErr != nil {
+		return listErr
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	for _, kind := range []ResourceKind{ResourceContainer, ResourceVolume, ResourceNetwork} {
+		for _, res := range resources {
+			if res.Kind != kind || (kind == ResourceNetwork && res.Name == framework.DefaultNetworkName) {
+				continue
+			}
+
+			var err error
+			switch kind {
+			case ResourceContainer:
+				if safeErr := CheckSafeDockerContainer(ctx, res.Name); safeErr != nil {
+					return safeErr
+				}
+				err = dockerClient.ContainerRemove(ctx, res.Name, container.RemoveOptions{Force: true, RemoveVolumes: true})
+			case ResourceVolume:
+				err = dockerClient.VolumeRemove(ctx, res.Name, true)
+			case ResourceNetwork:
+				err = dockerClient.NetworkRemove(ctx, res.Name)
+			}
+			if err != nil {
+				return errors.Wrapf(err, "failed to remove Docker %s %s", kind, res.Name)
+			}
+		}
+	}
+
+	return nil
+}
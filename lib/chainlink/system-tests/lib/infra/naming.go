@@ -0,0 +1,29 @@
+package infra
+
+import "fmt"
+
+const (
+	NodeAliasRoleBootstrap = "bootstrap"
+	NodeAliasRoleGateway   = "gateway"
+	NodeAliasRoleWorker    = "worker"
+)
+
+// NodeAliasRole returns the role used in aliases of nodes, bootstrap nodes take precedence over gateway nodes
+func NodeAliasRole(isBootstrap, isGateway bool) string {
+	switch {
+	case isBootstrap:
+		return NodeAliasRoleBootstrap
+	case isGateway:
+		return NodeAliasRoleGateway
+	default:
+		return NodeAliasRoleWorker
+	}
+}
+
+// NodeAlias returns a predictable, infra-independent name of a node derived from DON name, node role and node index
+// (e.g. workflow-worker-3 for the fourth node of DON named "workflow"), see NodeAliasRole. It is exposed in DON metadata
+// and registered as a network alias of the node container, so that logs, metrics labels and chaos experiments can all
+// refer to a node by the same name.
+func NodeAlias(donName, role string, nodeIndex int) string {
+	return fmt.Sprintf("%s-%s-%d", donName, role, nodeIndex)
+}
@@ -0,0 +1,13 @@
+package infra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeAlias(t *testing.T) {
+	assert.Equal(t, "workflow-bootstrap-0", NodeAlias("workflow", NodeAliasRole(true, true), 0), "bootstrap role must take precedence")
+	assert.Equal(t, "workflow-gateway-1", NodeAlias("workflow", NodeAliasRole(false, true), 1))
+	assert.Equal(t, "workflow-worker-3", NodeAlias("workflow", NodeAliasRole(false, false), 3))
+}
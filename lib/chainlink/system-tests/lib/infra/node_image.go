@@ -0,0 +1,99 @@
+package infra
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	dc "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// NodeImageContents is what an image of the node ships: its labels and names of executables in directories
+type NodeImageContents struct {
+	Labels      map[string]string
+	Executables map[string][]string // by directory
+}
+
+// InspectNodeImage pulls the image, unless it's present locally, and lists executables in dirs of its filesystem. The
+// image isn't run, files are read from a container, which is created from it and removed without being started.
+// Executables in subdirectories aren't listed. A missing dir means the image ships no executables there.
+func InspectNodeImage(ctx context.Context, imageName string, dirs ...string) (*NodeImageContents, error) {
+	if err := PullDockerImage(ctx, imageName); err != nil {
+		return nil, err
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return nil, errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	inspected, inspectErr := dockerClient.ImageInspect(ctx, imageName)
+	if inspectErr != nil {
+		return nil, errors.Wrapf(inspectErr, "failed to inspect image %s", imageName)
+	}
+	contents := &NodeImageContents{Labels: make(map[string]string), Executables: make(map[string][]string)}
+	if inspected.Config != nil && inspected.Config.Labels != nil {
+		contents.Labels = inspected.Config.Labels
+	}
+
+	created, createErr := dockerClient.ContainerCreate(ctx, &container.Config{Image: imageName}, nil, nil, nil, "")
+	if createErr != nil {
+		return nil, errors.Wrapf(createErr, "failed to create container from image %s", imageName)
+	}
+	defer func() {
+		// use a fresh context, so that the container is removed even if ctx was cancelled
+		_ = dockerClient.ContainerRemove(context.Background(), created.ID, container.RemoveOptions{Force: true, RemoveVolumes: true})
+	}()
+
+	for _, dir := range dirs {
+		executables, listErr := listExecutables(ctx, dockerClient, created.ID, dir)
+		if listErr != nil {
+			return nil, errors.Wrapf(listErr, "failed to read %s of image %s", dir, imageName)
+		}
+		contents.Executables[dir] = executables
+	}
+
+	return contents, nil
+}
+
+func listExecutables(ctx context.Context, dockerClient *dc.Client, containerID, dir string) ([]string, error) {
+	reader, _, copyErr := dockerClient.CopyFromContainer(ctx, containerID, dir)
+	if copyErr != nil {
+		if dc.IsErrNotFound(copyErr) {
+			return nil, nil
+		}
+		return nil, copyErr
+	}
+	defer reader.Close()
+
+	// entries of the archive are prefixed with the base name of dir
+	base := path.Base(path.Clean(dir))
+	var executables []string
+	tr := tar.NewReader(reader)
+	for {
+		header, nextErr := tr.Next()
+		if errors.Is(nextErr, io.EOF) {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		name, ok := strings.CutPrefix(path.Clean(header.Name), base+"/")
+		if !ok || strings.Contains(name, "/") {
+			continue
+		}
+		// symlinks, e.g. to binaries installed elsewhere, are listed as they are usually executable
+		if header.Typeflag == tar.TypeSymlink || (header.Typeflag == tar.TypeReg && header.FileInfo().Mode().Perm()&0o111 != 0) {
+			executables = append(executables, name)
+		}
+	}
+	slices.Sort(executables)
+
+	return executables, nil
+}
@@ -0,0 +1,210 @@
+package infra
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+	dc "github.com/docker/docker/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	text "github.com/smartcontractkit/chainlink/system-tests/lib/format"
+)
+
+const (
+	arm64Platform = "linux/arm64"
+	// DefaultClusterPlatform is the platform of containers in shared Kubernetes clusters, e.g. of CRIB in AWS
+	DefaultClusterPlatform = "linux/amd64"
+)
+
+// ImageRef is an image used by a component of the environment
+type ImageRef struct {
+	Image     string
+	Component string // e.g. "node 0 of nodeset workflow" or "database of nodeset workflow", used in warnings
+}
+
+type ImageArchitecture struct {
+	ImageRef
+	Architecture string // of the local image after EnsureNativeImages
+	Emulated     bool   // true, if the image will run under QEMU emulation
+	Repulled     bool   // true, if a local amd64 image was replaced with the arm64 variant
+}
+
+// EnsureNativeImages makes sure that on ARM hosts arm64 variants of images are used, when they are available. Docker
+// keeps using an amd64 image, if it's already present locally (e.g. pulled with --platform or built on a different host),
+// and runs it under QEMU emulation, which is several times slower and often causes timeouts, which look like flakiness.
+// Images without an arm64 variant are reported with a prominent warning. On non-ARM hosts it does nothing.
+func EnsureNativeImages(ctx context.Context, logger zerolog.Logger, images []ImageRef) ([]ImageArchitecture, error) {
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return nil, errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	info, infoErr := dockerClient.Info(ctx)
+	if infoErr != nil {
+		return nil, errors.Wrap(infoErr, "failed to get Docker daemon info")
+	}
+	if !isARM(info.Architecture) {
+		return nil, nil
+	}
+
+	checked := make(map[string]*ImageArchitecture)
+	results := make([]ImageArchitecture, 0, len(images))
+	for _, ref := range images {
+		if ref.Image == "" {
+			continue
+		}
+		if result, ok := checked[ref.Image]; ok {
+			results = append(results, ImageArchitecture{ImageRef: ref, Architecture: result.Architecture, Emulated: result.Emulated, Repulled: result.Repulled})
+			continue
+		}
+
+		result, err := ensureNativeImage(ctx, dockerClient, ref)
+		if err != nil {
+			return nil, err
+		}
+		if result.Repulled {
+			logger.Info().Msgf("Replaced local %s image %s with its arm64 variant", result.Architecture, ref.Image)
+			result.Architecture = "arm64"
+		}
+		checked[ref.Image] = result
+		results = append(results, *result)
+	}
+
+	printEmulationWarning(logger, results)
+
+	return results, nil
+}
+
+func ensureNativeImage(ctx context.Context, dockerClient *dc.Client, ref ImageRef) (*ImageArchitecture, error) {
+	result := &ImageArchitecture{ImageRef: ref}
+
+	local, inspectErr := dockerClient.ImageInspect(ctx, ref.Image)
+	if inspectErr == nil {
+		result.Architecture = local.Architecture
+		if isARM(local.Architecture) {
+			return result, nil
+		}
+	}
+
+	// fails for images that exist only locally, then we can only rely on the local architecture
+	distribution, distErr := dockerClient.DistributionInspect(ctx, ref.Image, "")
+	hasARMVariant := distErr == nil && slices.ContainsFunc(distribution.Platforms, func(p ocispec.Platform) bool {
+		return p.OS == "linux" && isARM(p.Architecture)
+	})
+
+	if !hasARMVariant {
+		// missing local image will be pulled (or built) later, Docker picks the arm64 variant on its own, if there is one
+		result.Emulated = inspectErr == nil || distErr == nil
+		if result.Architecture == "" {
+			result.Architecture = "amd64"
+		}
+		return result, nil
+	}
+
+	if inspectErr != nil {
+		// not present locally, Docker will pull the native variant
+		result.Architecture = "arm64"
+		return result, nil
+	}
+
+	reader, pullErr := dockerClient.ImagePull(ctx, ref.Image, image.PullOptions{Platform: arm64Platform})
+	if pullErr != nil {
+		return nil, errors.Wrapf(pullErr, "failed to pull arm64 variant of image %s", ref.Image)
+	}
+	defer reader.Close()
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return nil, errors.Wrapf(err, "failed to pull arm64 variant of image %s", ref.Image)
+	}
+	result.Repulled = true
+
+	return result, nil
+}
+
+// ContainerPlatforms returns platforms (e.g. linux/arm64), on which containers with given images run, keyed by image.
+// Local Docker images run on their own platform, images that aren't present locally (or are built later) on the platform
+// of the Docker daemon, which also runs CRIB in kind. Other clusters are expected to run DefaultClusterPlatform.
+func ContainerPlatforms(ctx context.Context, provider Provider, images []string) (map[string]string, error) {
+	platforms := make(map[string]string, len(images))
+	usesDocker := provider.IsDocker() || (provider.IsCRIB() && provider.CRIB != nil && provider.CRIB.Provider == Kind)
+	if !usesDocker {
+		for _, img := range images {
+			platforms[img] = DefaultClusterPlatform
+		}
+		return platforms, nil
+	}
+
+	dockerClient, dockerClientErr := dc.NewClientWithOpts(dc.FromEnv, dc.WithAPIVersionNegotiation())
+	if dockerClientErr != nil {
+		return nil, errors.Wrap(dockerClientErr, "failed to create Docker client")
+	}
+	defer dockerClient.Close()
+
+	info, infoErr := dockerClient.Info(ctx)
+	if infoErr != nil {
+		return nil, errors.Wrap(infoErr, "failed to get Docker daemon info")
+	}
+	daemonPlatform := "linux/" + goArchitecture(info.Architecture)
+
+	for _, img := range images {
+		if _, ok := platforms[img]; ok {
+			continue
+		}
+		platforms[img] = daemonPlatform
+		if img == "" || provider.IsCRIB() {
+			continue
+		}
+		if local, inspectErr := dockerClient.ImageInspect(ctx, img); inspectErr == nil && local.Os != "" {
+			platforms[img] = local.Os + "/" + goArchitecture(local.Architecture)
+		}
+	}
+
+	return platforms, nil
+}
+
+// goArchitecture converts architectures reported by Docker (e.g. x86_64 or aarch64) to GOARCH values
+func goArchitecture(architecture string) string {
+	switch architecture {
+	case "x86_64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	default:
+		return architecture
+	}
+}
+
+func isARM(architecture string) bool {
+	return architecture == "arm64" || architecture == "aarch64"
+}
+
+func printEmulationWarning(logger zerolog.Logger, results []ImageArchitecture) {
+	var emulated []string
+	hasDatabase := false
+	for _, result := range results {
+		if !result.Emulated {
+			continue
+		}
+		emulated = append(emulated, fmt.Sprintf("  - %s: %s (%s)", result.Component, result.Image, result.Architecture))
+		if strings.Contains(strings.ToLower(result.Image), "postgres") {
+			hasDatabase = true
+		}
+	}
+	if len(emulated) == 0 {
+		return
+	}
+
+	logger.Warn().Msgf("%d image(s) will run under QEMU emulation", len(emulated))
+	msg := fmt.Sprintf("\nWARNING: these images have no arm64 variant and will run under QEMU emulation:\n%s\n"+
+		"Expect them to be 2-10x slower. Startup and workflow timeouts in this run may be caused by emulation rather than by bugs.\n", strings.Join(emulated, "\n"))
+	if hasDatabase {
+		msg += "Emulated Postgres is especially slow, use an arm64 Postgres image (e.g. the official 'postgres' one).\n"
+	}
+	fmt.Print(text.RedText("%s\n", msg))
+}
@@ -0,0 +1,32 @@
+package infra
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrReadOnly is returned by operations, which would change an environment attached read-only (see Provider.ReadOnly)
+var ErrReadOnly = errors.New("environment is attached read-only")
+
+// readOnlyCommands are commands, which can't change the workload they run in. Commands taking arbitrary arguments,
+// which could write files or run other commands (e.g. find -delete, sh -c, env <cmd>) or change the clock (date -s), are
+// not allowed.
+var readOnlyCommands = []string{"cat", "ls", "head", "tail", "stat", "ps", "df", "du", "printenv", "uname", "id", "whoami", "sha256sum"}
+
+// ReadOnlyExecutor runs only commands from an allow-list of reading commands in the wrapped executor
+//
+// Experimental: the allow-list may change.
+type ReadOnlyExecutor struct {
+	Executor Executor
+}
+
+func (e *ReadOnlyExecutor) Exec(ctx context.Context, cmd []string, opts ExecOptions) (*ExecResult, error) {
+	if len(cmd) == 0 || !slices.Contains(readOnlyCommands, cmd[0]) || opts.Stdin != nil {
+		return nil, errors.Wrapf(ErrReadOnly, "'%s' is not a read-only command, allowed ones are: %s", strings.Join(cmd, " "), strings.Join(readOnlyCommands, ", "))
+	}
+
+	return e.Executor.Exec(ctx, cmd, opts)
+}
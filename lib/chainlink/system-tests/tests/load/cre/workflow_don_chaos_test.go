@@ -59,6 +59,7 @@ func TestChaos(t *testing.T) {
 func runChaosSuite(t *testing.T, testConfig *TestConfigLoadTest) {
 	cr, gc, err := prepareChaos(t)
 	require.NoError(t, err)
+	require.NoError(t, testConfig.Infra.CheckSafeNamespace(t.Context()), "chaos experiments target only test infra")
 	cribCfg := testConfig.Infra.CRIB
 	chaosCfg := testConfig.Chaos
 
@@ -570,7 +570,8 @@ func deleteWorkflows(t *testing.T, uniqueWorkflowName string,
 	require.NoError(t, localEnvErr, "failed to remove workflow artifacts from local environment")
 
 	require.IsType(t, &evm.Blockchain{}, blockchains[0], "expected EVM blockchain type")
-	deleteErr := creworkflow.DeleteWithContract(t.Context(), blockchains[0].(*evm.Blockchain).SethClient, workflowRegistryAddress, tv, uniqueWorkflowName)
+	registryChain := blockchains[0].(*evm.Blockchain)
+	deleteErr := creworkflow.DeleteWithContract(evm.ContextWithTxManager(t.Context(), registryChain.TxManager()), registryChain.SethClient, workflowRegistryAddress, tv, uniqueWorkflowName)
 	require.NoError(t, deleteErr, "failed to delete workflow '%s'. Please delete/unregister it manually.", uniqueWorkflowName)
 	testLogger.Info().Msgf("Workflow '%s' deleted successfully from the registry.", uniqueWorkflowName)
 }